@@ -7,12 +7,19 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/spf13/cobra"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/team"
 	"github.com/xescugc/pikoci/pikoci/transport/http/client"
+	"github.com/xescugc/pikoci/pikoci/user"
 )
 
 var (
@@ -43,11 +50,18 @@ var clientCmd = &cobra.Command{
 func init() {
 	clientCmd.PersistentFlags().StringP("url", "u", "localhost:4000", "URL to the PikoCI server")
 	clientCmd.MarkPersistentFlagRequired("url")
-	clientCmd.PersistentFlags().String("jwt", "", "Provide the JWT to authenticate on the API, if not provided will read it from the FS")
+	clientCmd.PersistentFlags().String("jwt", "", "Provide the JWT or the API token to authenticate on the API, if not provided will read it from the FS")
 
 	clientCmd.AddCommand(loginCmd)
 	clientCmd.AddCommand(pipelinesCmd)
 	clientCmd.AddCommand(jobsCmd)
+	clientCmd.AddCommand(buildsCmd)
+	clientCmd.AddCommand(workersCmd)
+	clientCmd.AddCommand(queueCmd)
+	clientCmd.AddCommand(locksCmd)
+	clientCmd.AddCommand(secretsCmd)
+	clientCmd.AddCommand(membersCmd)
+	clientCmd.AddCommand(tokensCmd)
 }
 
 // login
@@ -108,6 +122,8 @@ func init() {
 	pipelinesCmd.AddCommand(pipelinesGetCmd)
 	pipelinesCmd.AddCommand(pipelinesGraphCmd)
 	pipelinesCmd.AddCommand(pipelinesDeleteCmd)
+	pipelinesCmd.AddCommand(pipelinesPauseCmd)
+	pipelinesCmd.AddCommand(pipelinesUnpauseCmd)
 }
 
 var pipelinesCreateCmd = &cobra.Command{
@@ -318,6 +334,62 @@ func init() {
 	pipelinesDeleteCmd.MarkFlagRequired("name")
 }
 
+var pipelinesPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pauses a PikoCI Pipeline",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.PausePipeline(cmd.Context(), tc, name)
+		if err != nil {
+			return fmt.Errorf("failed to pause Pipeline %q: %w", name, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	pipelinesPauseCmd.Flags().StringP("name", "n", "", "Name of the Pipeline")
+	pipelinesPauseCmd.MarkFlagRequired("name")
+}
+
+var pipelinesUnpauseCmd = &cobra.Command{
+	Use:   "unpause",
+	Short: "Unpauses a PikoCI Pipeline",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.UnpausePipeline(cmd.Context(), tc, name)
+		if err != nil {
+			return fmt.Errorf("failed to unpause Pipeline %q: %w", name, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	pipelinesUnpauseCmd.Flags().StringP("name", "n", "", "Name of the Pipeline")
+	pipelinesUnpauseCmd.MarkFlagRequired("name")
+}
+
 // jobs
 var jobsCmd = &cobra.Command{
 	Use:   "jobs",
@@ -332,6 +404,8 @@ func init() {
 
 	jobsCmd.AddCommand(jobsGetCmd)
 	jobsCmd.AddCommand(jobsTriggerCmd)
+	jobsCmd.AddCommand(jobsPauseCmd)
+	jobsCmd.AddCommand(jobsUnpauseCmd)
 }
 
 var jobsGetCmd = &cobra.Command{
@@ -373,13 +447,30 @@ var jobsTriggerCmd = &cobra.Command{
 		tc, _ := cmd.Flags().GetString("team-canonical")
 		pn, _ := cmd.Flags().GetString("pipeline-name")
 		jn, _ := cmd.Flags().GetString("job-name")
+		vs, _ := cmd.Flags().GetStringArray("version")
+
+		var versions map[string]uint32
+		for _, v := range vs {
+			sv := strings.SplitN(v, "=", 2)
+			if len(sv) != 2 {
+				return fmt.Errorf("invalid version format %q, expected STEP=VERSION_ID", v)
+			}
+			vID, err := strconv.ParseUint(sv[1], 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid version ID %q for step %q: %w", sv[1], sv[0], err)
+			}
+			if versions == nil {
+				versions = make(map[string]uint32)
+			}
+			versions[sv[0]] = uint32(vID)
+		}
 
 		c, err := newClientWithConfig(url, jwt)
 		if err != nil {
 			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
 		}
 
-		err = c.TriggerPipelineJob(cmd.Context(), tc, pn, jn)
+		err = c.TriggerPipelineJob(cmd.Context(), tc, pn, jn, versions)
 		if err != nil {
 			return fmt.Errorf("failed to trigger Job %q from Pipeline %q: %w", jn, pn, err)
 		}
@@ -391,6 +482,790 @@ var jobsTriggerCmd = &cobra.Command{
 func init() {
 	jobsTriggerCmd.Flags().StringP("job-name", "n", "", "Name of the Job")
 	jobsTriggerCmd.MarkFlagRequired("job-name")
+	jobsTriggerCmd.Flags().StringArray("version", nil, "Version ID to use on a get step as 'STEP=VERSION_ID', can be repeated")
+}
+
+var jobsPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pauses a PikoCI Pipeline Job",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		pn, _ := cmd.Flags().GetString("pipeline-name")
+		jn, _ := cmd.Flags().GetString("job-name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.PauseJob(cmd.Context(), tc, pn, jn)
+		if err != nil {
+			return fmt.Errorf("failed to pause Job %q from Pipeline %q: %w", jn, pn, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	jobsPauseCmd.Flags().StringP("job-name", "n", "", "Name of the Job")
+	jobsPauseCmd.MarkFlagRequired("job-name")
+}
+
+var jobsUnpauseCmd = &cobra.Command{
+	Use:   "unpause",
+	Short: "Unpauses a PikoCI Pipeline Job",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		pn, _ := cmd.Flags().GetString("pipeline-name")
+		jn, _ := cmd.Flags().GetString("job-name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.UnpauseJob(cmd.Context(), tc, pn, jn)
+		if err != nil {
+			return fmt.Errorf("failed to unpause Job %q from Pipeline %q: %w", jn, pn, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	jobsUnpauseCmd.Flags().StringP("job-name", "n", "", "Name of the Job")
+	jobsUnpauseCmd.MarkFlagRequired("job-name")
+}
+
+// builds
+var buildsCmd = &cobra.Command{
+	Use:   "builds",
+	Short: "Interacts with the PikoCI Job Builds",
+}
+
+func init() {
+	buildsCmd.PersistentFlags().String("team-canonical", "", "Team Canonical to scope the action")
+	buildsCmd.PersistentFlags().String("pipeline-name", "", "Name of the Pipeline")
+	buildsCmd.PersistentFlags().StringP("job-name", "n", "", "Name of the Job")
+	buildsCmd.MarkPersistentFlagRequired("team-canonical")
+	buildsCmd.MarkPersistentFlagRequired("pipeline-name")
+	buildsCmd.MarkPersistentFlagRequired("job-name")
+
+	buildsCmd.AddCommand(buildsWatchCmd)
+	buildsCmd.AddCommand(buildsTestsCmd)
+}
+
+var buildsWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Streams the logs of a PikoCI Job Build until it finishes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		pn, _ := cmd.Flags().GetString("pipeline-name")
+		jn, _ := cmd.Flags().GetString("job-name")
+		bn, _ := cmd.Flags().GetString("build-number")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		return watchBuild(cmd.Context(), c, cmd.OutOrStdout(), tc, pn, jn, bn)
+	},
+}
+
+func init() {
+	buildsWatchCmd.Flags().StringP("build-number", "b", "", "Number of the Build")
+	buildsWatchCmd.MarkFlagRequired("build-number")
+}
+
+var buildsTestsCmd = &cobra.Command{
+	Use:   "tests",
+	Short: "Lists the test results reported by a PikoCI Job Build, or the flaky tests of the Job",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		pn, _ := cmd.Flags().GetString("pipeline-name")
+		jn, _ := cmd.Flags().GetString("job-name")
+		bn, _ := cmd.Flags().GetString("build-number")
+		flaky, _ := cmd.Flags().GetBool("flaky")
+
+		if !flaky && bn == "" {
+			return fmt.Errorf("the build-number is required if not listing the flaky tests")
+		}
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		if flaky {
+			ft, err := c.ListJobFlakyTests(cmd.Context(), tc, pn, jn)
+			if err != nil {
+				return fmt.Errorf("failed to list flaky Tests of Job %q: %w", jn, err)
+			}
+
+			spew.Dump(ft)
+			return nil
+		}
+
+		ts, err := c.ListJobBuildTests(cmd.Context(), tc, pn, jn, bn)
+		if err != nil {
+			return fmt.Errorf("failed to list Tests of Build %q of Job %q: %w", bn, jn, err)
+		}
+
+		spew.Dump(ts)
+		return nil
+	},
+}
+
+func init() {
+	buildsTestsCmd.Flags().StringP("build-number", "b", "", "Number of the Build")
+	buildsTestsCmd.Flags().Bool("flaky", false, "Lists the tests that flipped between passing and failing on the last Builds of the Job")
+}
+
+// watchBuild writes to out the logs of the build as they are streamed.
+// The logs of a step are first received as chunks while it runs and then
+// whole once it finishes, so it keeps track of how much of the logs of
+// each step has been written to not write them twice.
+func watchBuild(ctx context.Context, svc pikoci.Service, out io.Writer, tc, pn, jn, bn string) error {
+	events, err := svc.WatchJobBuild(ctx, tc, pn, jn, bn)
+	if err != nil {
+		return fmt.Errorf("failed to watch Build %q of Job %q: %w", bn, jn, err)
+	}
+
+	var (
+		b       *build.Build
+		written = make(map[string]int)
+		current string
+		newline = true
+	)
+	write := func(key string, s build.Step, offset int, data string) {
+		end := offset + len(data)
+		if n := written[key]; n >= end {
+			return
+		} else if n > offset {
+			data = data[n-offset:]
+		}
+		if key != current {
+			if !newline {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "==> %s %s\n", s.Type, s.Name)
+			current = key
+		}
+		fmt.Fprint(out, data)
+		newline = strings.HasSuffix(data, "\n")
+		written[key] = end
+	}
+
+	for e := range events {
+		switch e.Type {
+		case build.EventLog:
+			s := build.Step{Name: e.Log.StepID}
+			if b != nil {
+				for _, bs := range b.Steps {
+					if bs.ID == e.Log.StepID {
+						s = bs
+					}
+				}
+			}
+			write(e.Log.StepID, s, e.Log.Offset, e.Log.Data)
+		case build.EventStatus:
+			b = e.Build
+			for i, s := range b.Steps {
+				if s.Status == build.Started {
+					continue
+				}
+				// The steps that do not stream
+				// their logs have no ID
+				key := s.ID
+				if key == "" {
+					key = fmt.Sprintf("%d:%s:%s", i, s.Type, s.Name)
+				}
+				write(key, s, 0, s.Logs)
+			}
+		}
+	}
+
+	if b == nil || !b.Status.Finished() {
+		return fmt.Errorf("the stream of Build %q of Job %q ended before it finished", bn, jn)
+	}
+	if !newline {
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "==> build %s %s\n", bn, b.Status)
+	if b.Status != build.Succeeded {
+		return fmt.Errorf("build %q of Job %q finished with status %s", bn, jn, b.Status)
+	}
+
+	return nil
+}
+
+// workers
+var workersCmd = &cobra.Command{
+	Use:   "workers",
+	Short: "Interacts with the PikoCI Workers",
+}
+
+func init() {
+	workersCmd.AddCommand(workersListCmd)
+}
+
+var workersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the PikoCI Workers registered on the server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		ws, err := c.ListWorkers(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list Workers: %w", err)
+		}
+
+		spew.Dump(ws)
+		return nil
+	},
+}
+
+// queue
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Lists the PikoCI Builds waiting on the queue for a worker",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		pn, _ := cmd.Flags().GetString("pipeline-name")
+		jn, _ := cmd.Flags().GetString("job-name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		bs, err := c.ListQueuedBuilds(cmd.Context(), tc, pn, jn)
+		if err != nil {
+			return fmt.Errorf("failed to list queued Builds: %w", err)
+		}
+
+		spew.Dump(bs)
+		return nil
+	},
+}
+
+func init() {
+	queueCmd.Flags().String("team-canonical", "", "Team Canonical to scope the action")
+	queueCmd.Flags().String("pipeline-name", "", "Name of the Pipeline, to only list its queued Builds")
+	queueCmd.Flags().StringP("job-name", "n", "", "Name of the Job, to only list its queued Builds")
+	queueCmd.MarkFlagRequired("team-canonical")
+}
+
+// locks
+var locksCmd = &cobra.Command{
+	Use:   "locks",
+	Short: "Interacts with the PikoCI named Locks of a Team",
+}
+
+func init() {
+	locksCmd.PersistentFlags().String("team-canonical", "main", "Team Canonical to scope the action")
+	locksCmd.MarkPersistentFlagRequired("team-canonical")
+
+	locksCmd.AddCommand(locksListCmd)
+	locksCmd.AddCommand(locksAcquireCmd)
+	locksCmd.AddCommand(locksReleaseCmd)
+	locksCmd.AddCommand(locksForceReleaseCmd)
+}
+
+var locksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the PikoCI Locks currently held on the Team",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		ls, err := c.ListLocks(cmd.Context(), tc)
+		if err != nil {
+			return fmt.Errorf("failed to list Locks: %w", err)
+		}
+
+		spew.Dump(ls)
+		return nil
+	},
+}
+
+var locksAcquireCmd = &cobra.Command{
+	Use:   "acquire",
+	Short: "Acquires a PikoCI Lock, failing if it's already held",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+		reason, _ := cmd.Flags().GetString("reason")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		l, err := c.AcquireLock(cmd.Context(), tc, name, "", reason)
+		if err != nil {
+			return fmt.Errorf("failed to acquire Lock %q: %w", name, err)
+		}
+
+		spew.Dump(l)
+		return nil
+	},
+}
+
+func init() {
+	locksAcquireCmd.Flags().StringP("name", "n", "", "Name of the Lock")
+	locksAcquireCmd.Flags().String("reason", "", "Why the Lock is acquired, it's held by the logged in user")
+	locksAcquireCmd.MarkFlagRequired("name")
+}
+
+var locksReleaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Releases a PikoCI Lock held by the logged in user",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.ReleaseLock(cmd.Context(), tc, name, "")
+		if err != nil {
+			return fmt.Errorf("failed to release Lock %q: %w", name, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	locksReleaseCmd.Flags().StringP("name", "n", "", "Name of the Lock")
+	locksReleaseCmd.MarkFlagRequired("name")
+}
+
+var locksForceReleaseCmd = &cobra.Command{
+	Use:   "force-release",
+	Short: "Releases a PikoCI Lock whoever holds it, Builds included",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.ForceReleaseLock(cmd.Context(), tc, name)
+		if err != nil {
+			return fmt.Errorf("failed to force release Lock %q: %w", name, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	locksForceReleaseCmd.Flags().StringP("name", "n", "", "Name of the Lock")
+	locksForceReleaseCmd.MarkFlagRequired("name")
+}
+
+// secrets
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Interacts with the PikoCI Secrets of a Team, used with the 'pikoci' secret type",
+}
+
+func init() {
+	secretsCmd.PersistentFlags().String("team-canonical", "main", "Team Canonical to scope the action")
+	secretsCmd.MarkPersistentFlagRequired("team-canonical")
+
+	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsDeleteCmd)
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the PikoCI Secrets of the Team, without the values",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		ss, err := c.ListSecrets(cmd.Context(), tc)
+		if err != nil {
+			return fmt.Errorf("failed to list Secrets: %w", err)
+		}
+
+		spew.Dump(ss)
+		return nil
+	},
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Sets the value of a PikoCI Secret, creating it if it does not exist",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+		value, _ := cmd.Flags().GetString("value")
+
+		// Without the flag the value is read from the stdin
+		// so it does not end up in the shell history
+		if value == "" {
+			b, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return fmt.Errorf("failed to read the value from stdin: %w", err)
+			}
+			value = strings.TrimSuffix(string(b), "\n")
+		}
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		sec, err := c.SetSecret(cmd.Context(), tc, name, value)
+		if err != nil {
+			return fmt.Errorf("failed to set Secret %q: %w", name, err)
+		}
+
+		spew.Dump(sec)
+		return nil
+	},
+}
+
+func init() {
+	secretsSetCmd.Flags().StringP("name", "n", "", "Name of the Secret")
+	secretsSetCmd.Flags().String("value", "", "Value of the Secret, if not set it's read from the stdin")
+	secretsSetCmd.MarkFlagRequired("name")
+}
+
+var secretsDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Deletes a PikoCI Secret",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.DeleteSecret(cmd.Context(), tc, name)
+		if err != nil {
+			return fmt.Errorf("failed to delete Secret %q: %w", name, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	secretsDeleteCmd.Flags().StringP("name", "n", "", "Name of the Secret")
+	secretsDeleteCmd.MarkFlagRequired("name")
+}
+
+// members
+var membersCmd = &cobra.Command{
+	Use:   "members",
+	Short: "Interacts with the Members of a Team and their Roles",
+}
+
+func init() {
+	membersCmd.PersistentFlags().String("team-canonical", "main", "Team Canonical to scope the action")
+	membersCmd.MarkPersistentFlagRequired("team-canonical")
+
+	membersCmd.AddCommand(membersListCmd)
+	membersCmd.AddCommand(membersAddCmd)
+	membersCmd.AddCommand(membersUpdateCmd)
+	membersCmd.AddCommand(membersRemoveCmd)
+}
+
+var membersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the Members of the Team with their Roles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		t, err := c.GetTeam(cmd.Context(), tc)
+		if err != nil {
+			return fmt.Errorf("failed to get Team: %w", err)
+		}
+
+		spew.Dump(t.Members)
+		return nil
+	},
+}
+
+var membersAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Adds a User to the Team with a Role",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		un, _ := cmd.Flags().GetString("username")
+		role, _ := cmd.Flags().GetString("role")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		m, err := c.CreateTeamMember(cmd.Context(), tc, team.Member{
+			Role: user.Role(role),
+			User: user.User{Username: un},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add Member %q: %w", un, err)
+		}
+
+		spew.Dump(m)
+		return nil
+	},
+}
+
+func init() {
+	membersAddCmd.Flags().StringP("username", "n", "", "Username of the User")
+	membersAddCmd.Flags().String("role", string(user.RoleOperator), fmt.Sprintf("Role of the Member, one of %v, each one includes the previous ones", user.Roles))
+	membersAddCmd.MarkFlagRequired("username")
+}
+
+var membersUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Updates the Role of a Member of the Team",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		un, _ := cmd.Flags().GetString("username")
+		role, _ := cmd.Flags().GetString("role")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		m, err := c.UpdateTeamMember(cmd.Context(), tc, un, team.Member{
+			Role: user.Role(role),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update Member %q: %w", un, err)
+		}
+
+		spew.Dump(m)
+		return nil
+	},
+}
+
+func init() {
+	membersUpdateCmd.Flags().StringP("username", "n", "", "Username of the Member")
+	membersUpdateCmd.Flags().String("role", "", fmt.Sprintf("Role of the Member, one of %v, each one includes the previous ones", user.Roles))
+	membersUpdateCmd.MarkFlagRequired("username")
+	membersUpdateCmd.MarkFlagRequired("role")
+}
+
+var membersRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Removes a Member from the Team",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		un, _ := cmd.Flags().GetString("username")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		err = c.DeleteTeamMember(cmd.Context(), tc, un)
+		if err != nil {
+			return fmt.Errorf("failed to remove Member %q: %w", un, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	membersRemoveCmd.Flags().StringP("username", "n", "", "Username of the Member")
+	membersRemoveCmd.MarkFlagRequired("username")
+}
+
+// tokens
+var tokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Interacts with the API tokens of the User, or of the service account of a Team",
+}
+
+func init() {
+	tokensCmd.PersistentFlags().String("team-canonical", "", "Team Canonical of the service account, if not set the tokens of the User are used")
+
+	tokensCmd.AddCommand(tokensListCmd)
+	tokensCmd.AddCommand(tokensCreateCmd)
+	tokensCmd.AddCommand(tokensRevokeCmd)
+}
+
+var tokensListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the API tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		var tks []*apitoken.Token
+		if tc != "" {
+			tks, err = c.ListTeamTokens(cmd.Context(), tc)
+		} else {
+			tks, err = c.ListUserTokens(cmd.Context(), "")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list Tokens: %w", err)
+		}
+
+		spew.Dump(tks)
+		return nil
+	},
+}
+
+var tokensCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Creates an API token and prints it, it can't be retrieved again",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scopes")
+		expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+
+		t := apitoken.Token{Name: name}
+		for _, s := range scopes {
+			t.Scopes = append(t.Scopes, apitoken.Scope(s))
+		}
+		if expiresIn != 0 {
+			ea := time.Now().Add(expiresIn).UTC()
+			t.ExpiresAt = &ea
+		}
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		var (
+			tk *apitoken.Token
+			v  string
+		)
+		if tc != "" {
+			tk, v, err = c.CreateTeamToken(cmd.Context(), tc, t)
+		} else {
+			tk, v, err = c.CreateUserToken(cmd.Context(), "", t)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create Token %q: %w", name, err)
+		}
+
+		spew.Dump(tk)
+		fmt.Println(v)
+		return nil
+	},
+}
+
+func init() {
+	tokensCreateCmd.Flags().StringP("name", "n", "", "Name of the Token")
+	tokensCreateCmd.Flags().StringSlice("scopes", []string{string(apitoken.Read)}, fmt.Sprintf("Scopes of the Token, one of %v, each one includes the previous ones", apitoken.Scopes))
+	tokensCreateCmd.Flags().Duration("expires-in", 0, "Duration after which the Token expires, if not set it never expires")
+	tokensCreateCmd.MarkFlagRequired("name")
+}
+
+var tokensRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revokes an API token",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		url, _ := cmd.Flags().GetString("url")
+		jwt, _ := cmd.Flags().GetString("jwt")
+		tc, _ := cmd.Flags().GetString("team-canonical")
+		id, _ := cmd.Flags().GetUint32("id")
+
+		c, err := newClientWithConfig(url, jwt)
+		if err != nil {
+			return fmt.Errorf("failed to initialize client with url %q: %w", url, err)
+		}
+
+		if tc != "" {
+			err = c.DeleteTeamToken(cmd.Context(), tc, id)
+		} else {
+			err = c.DeleteUserToken(cmd.Context(), "", id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to revoke Token %d: %w", id, err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	tokensRevokeCmd.Flags().Uint32("id", 0, "ID of the Token")
+	tokensRevokeCmd.MarkFlagRequired("id")
 }
 
 func newClientWithConfig(url, jwt string) (*client.Client, error) {
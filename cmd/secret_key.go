@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/xescugc/pikoci/pikoci/secret"
+)
+
+var secretKeyCmd = &cobra.Command{
+	Use:   "secret-key",
+	Short: "This is a helper to generate a key for the server 'secret-keys'. It generates the right value to set",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, _ := cmd.Flags().GetString("id")
+
+		k, err := secret.GenerateKey(id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(k)
+		return nil
+	},
+}
+
+func init() {
+	secretKeyCmd.Flags().String("id", "", "ID of the key, it's stored with the Secrets to know which key decrypts them")
+	secretKeyCmd.MarkFlagRequired("id")
+}
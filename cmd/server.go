@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -22,9 +23,13 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/artifact"
 	"github.com/xescugc/pikoci/pikoci/config"
 	"github.com/xescugc/pikoci/pikoci/mysql"
 	"github.com/xescugc/pikoci/pikoci/mysql/migrate"
+	"github.com/xescugc/pikoci/pikoci/oidc"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	tshttp "github.com/xescugc/pikoci/pikoci/transport/http"
 	"github.com/xescugc/pikoci/pikoci/unitwork"
 	"github.com/xescugc/pikoci/pikoci/user"
@@ -70,6 +75,15 @@ var serverCmd = &cobra.Command{
 		}
 		jwtSecret := []byte(cfg.JWTSecret)
 
+		var sk *secret.Keyring
+		if len(cfg.SecretKeys) != 0 {
+			var err error
+			sk, err = secret.NewKeyring(cfg.SecretKeys)
+			if err != nil {
+				return fmt.Errorf("invalid secret-keys: %w", err)
+			}
+		}
+
 		logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: parseSlogLevel(cfg.LogLevel)}))
 		logger = logger.With("service", "pikoci")
 
@@ -77,11 +91,6 @@ var serverCmd = &cobra.Command{
 			return fmt.Errorf("invalid DBSystem %q, should be one of: %s, %s, %s or %s", cfg.DBSystem, mysql.Mem, mysql.MySQL, mysql.SQLite, mysql.PostgreSQL)
 		}
 
-		topic, err := pubsub.OpenTopic(ctx, getTopicURL(cfg.PubSubSystem))
-		if err != nil {
-			return fmt.Errorf("failed to open: %v", err)
-		}
-		defer topic.Shutdown(ctx)
 		dbFile, err := xdg.DataFile(filepath.Join(AppName, AppName+".db"))
 		if err != nil {
 			return fmt.Errorf("failed to create dbFile: %v", err)
@@ -108,6 +117,15 @@ var serverCmd = &cobra.Command{
 			logger.Info("Migrations ran")
 		}
 
+		if cfg.PubSubSystem == mysql.QueueScheme {
+			registerSQLPubSub(db, cfg.DBSystem)
+		}
+		topic := newTopicRouter(cfg.PubSubSystem)
+		if _, err := topic.Topic(ctx, ""); err != nil {
+			return fmt.Errorf("failed to open: %v", err)
+		}
+		defer topic.Shutdown(ctx)
+
 		var querier sqlr.Querier = db
 		if mysql.IsPostgreSQL(cfg.DBSystem) {
 			querier = mysql.NewPGQuerier(db)
@@ -122,12 +140,77 @@ var serverCmd = &cobra.Command{
 		br := mysql.NewBuildRepository(querier, cfg.DBSystem)
 		rur := mysql.NewRunnerRepository(querier)
 		str := mysql.NewSecretTypeRepository(querier)
+		wr := mysql.NewWorkerRepository(querier)
+		lr := mysql.NewLockRepository(querier)
+		sr := mysql.NewSecretRepository(querier)
+		tkr := mysql.NewTokenRepository(querier)
+
+		artifactsDir := cfg.ArtifactsDir
+		if artifactsDir == "" {
+			artifactsDir = filepath.Join(xdg.DataHome, AppName, "artifacts")
+		}
+		as := artifact.NewLocalStore(artifactsDir)
 
 		suow := unitwork.NewStartUnitOfWork(db, cfg.DBSystem)
 
+		workerHeartbeatTTL, err := time.ParseDuration(cfg.WorkerHeartbeatTTL)
+		if err != nil {
+			return fmt.Errorf("invalid worker-heartbeat-ttl %q: %w", cfg.WorkerHeartbeatTTL, err)
+		}
+
 		logger.Info("initializing service")
-		var svc = pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, suow, jwtSecret, logger)
+		var svc = pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, wr, lr, sr, tkr, as, suow, jwtSecret, sk, logger)
+		if sk != nil {
+			n, err := svc.RotateSecrets(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to rotate the secrets: %w", err)
+			}
+			if n != 0 {
+				logger.Info("secrets re-encrypted with the new key", "count", n, "key-id", sk.KeyID())
+			}
+		}
 		svc.StartScheduler(ctx)
+		svc.StartReaper(ctx, workerHeartbeatTTL, cfg.RequeueOrphanedBuilds)
+		if cfg.PullWorkers {
+			workLeaseTTL, err := time.ParseDuration(cfg.WorkLeaseTTL)
+			if err != nil {
+				return fmt.Errorf("invalid work-lease-ttl %q: %w", cfg.WorkLeaseTTL, err)
+			} else if workLeaseTTL <= 0 {
+				return fmt.Errorf("invalid work-lease-ttl %q, it has to be positive", cfg.WorkLeaseTTL)
+			}
+			d := queue.NewDispatcher(topic, func(ctx context.Context, key string) (queue.Subscription, error) {
+				return openSubscription(ctx, cfg.PubSubSystem, topic, key)
+			}, mysql.NewLeaseRepository(querier), workLeaseTTL)
+			if err := d.Open(ctx, nil); err != nil {
+				return fmt.Errorf("failed to open the work dispatcher: %w", err)
+			}
+			defer d.Shutdown(ctx)
+			svc.StartWorkDispatcher(ctx, d)
+		}
+		if cfg.OIDCIssuer != "" {
+			ms := make([]oidc.Mapping, 0, len(cfg.OIDCTeamMappings))
+			for _, m := range cfg.OIDCTeamMappings {
+				mp, err := oidc.ParseMapping(m)
+				if err != nil {
+					return err
+				}
+				ms = append(ms, mp)
+			}
+			p, err := oidc.NewProvider(ctx, oidc.Config{
+				Issuer:        cfg.OIDCIssuer,
+				ClientID:      cfg.OIDCClientID,
+				ClientSecret:  cfg.OIDCClientSecret,
+				RedirectURL:   cfg.OIDCRedirectURL,
+				Scopes:        cfg.OIDCScopes,
+				UsernameClaim: cfg.OIDCUsernameClaim,
+				GroupsClaim:   cfg.OIDCGroupsClaim,
+				Mappings:      ms,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to initialize OIDC: %w", err)
+			}
+			svc.EnableOIDC(p)
+		}
 		logger.Info("initialized service")
 
 		logger.Info("initializing http handlers")
@@ -179,7 +262,14 @@ var serverCmd = &cobra.Command{
 			logger.Info("Starting Worker ...")
 			var werr error
 			var workerCleanup func()
-			workers, wg, workerCleanup, werr = runWorker(ctx, cfg.PubSubSystem, topic, svc, cfg.Concurrency, cfg.LogLevel)
+			subscribe := pubsubSubscriber(cfg.PubSubSystem, topic)
+			if cfg.PullWorkers {
+				// The embedded worker also claims its work from the server so it
+				// does not receive the messages the dispatcher already received
+				// on the PubSub systems that send them to all the subscriptions, like mem
+				subscribe = pullSubscriber(svc)
+			}
+			workers, wg, workerCleanup, werr = runWorker(ctx, topic, subscribe, svc, newCacheStore(cfg.CacheDir, cfg.CacheMaxSize), "", cfg.Tags, defaultHeartbeatInterval, cfg.Concurrency, cfg.LogLevel)
 			if werr != nil {
 				return fmt.Errorf("worker failed to start: %w", werr)
 			}
@@ -265,7 +355,16 @@ func init() {
 
 	serverCmd.Flags().IntP("port", "p", 8080, "Port in which to start the server")
 	serverCmd.Flags().String("jwt-secret", "", "Declares the Secret used to sign the JWT when user login")
+	serverCmd.Flags().StringSlice("secret-keys", nil, "List of keys, with 'ID:KEY', the Team Secrets are encrypted with, the first one encrypts and the rest are only used to re-encrypt the old Secrets. You can use the 'secret-key' command to help you")
 	serverCmd.Flags().StringSlice("users", nil, "List of Users which will have 'USERNAME:HASH-PASSWORD', you can use the 'user-password' command to help you")
+	serverCmd.Flags().String("oidc-issuer", "", "URL of the OIDC Provider the users can log in with, the OIDC login is disabled if empty")
+	serverCmd.Flags().String("oidc-client-id", "", "Client ID of PikoCI on the OIDC Provider")
+	serverCmd.Flags().String("oidc-client-secret", "", "Client Secret of PikoCI on the OIDC Provider")
+	serverCmd.Flags().String("oidc-redirect-url", "", "URL the OIDC Provider redirects back to, it has to be the '/oidc/callback' of the server, ex: 'https://ci.example.com/oidc/callback'")
+	serverCmd.Flags().StringSlice("oidc-scopes", []string{"profile", "email"}, "Scopes requested to the OIDC Provider on top of 'openid', some Providers need the 'groups' one for the groups claim")
+	serverCmd.Flags().String("oidc-username-claim", oidc.DefaultUsernameClaim, "Claim of the ID Token used as Username, the 'email' is used if it's missing")
+	serverCmd.Flags().String("oidc-groups-claim", oidc.DefaultGroupsClaim, "Claim of the ID Token with the groups of the user used for the 'oidc-team-mappings'")
+	serverCmd.Flags().StringSlice("oidc-team-mappings", nil, "List of 'GROUP=TEAM:ROLE' giving the ROLE on the TEAM to the users of the GROUP, the Members of those Teams are synced with the groups on each OIDC login")
 	serverCmd.Flags().String("db-system", mysql.Mem, "Which DB system to use (mem, sqlite, mysql, postgresql)")
 	serverCmd.Flags().String("db-host", "", "Database Host")
 	serverCmd.Flags().Int("db-port", 0, "Database Port")
@@ -275,8 +374,16 @@ func init() {
 	serverCmd.Flags().Bool("run-migrations", true, "Flag to know if migrations should be ran")
 	serverCmd.Flags().Bool("run-worker", true, "Runs a worker with PikoCI server")
 	serverCmd.Flags().Int("concurrency", 1, "Number of workers to start in one instance")
+	serverCmd.Flags().StringSlice("tags", nil, "Tags of the embedded worker, it only runs the jobs and checks the resources with tags that it has all of")
 	serverCmd.Flags().String("drain-timeout", "10m", "Maximum time to wait for in-flight jobs to finish during graceful shutdown (SIGQUIT)")
-	serverCmd.Flags().String("pubsub-system", mempubsub.Scheme, "Which PubSub system to use (mem, nats, rabbit, kafka). Env vars: NATS_SERVER_URL, RABBIT_SERVER_URL, KAFKA_BROKERS")
+	serverCmd.Flags().String("cache-dir", "", "Directory where the embedded worker stores the job and task caches, defaults to the XDG cache directory")
+	serverCmd.Flags().Int64("cache-max-size", 5120, "Maximum size in MB of all the embedded worker caches, the least recently used are evicted first (0 means no limit)")
+	serverCmd.Flags().String("worker-heartbeat-ttl", "2m", "Time after the last heartbeat of a worker for it to be considered dead and its running builds failed")
+	serverCmd.Flags().Bool("requeue-orphaned-builds", false, "Retry the running builds of the dead workers after failing them")
+	serverCmd.Flags().Bool("pull-workers", false, "Lets the workers claim the work from the server with 'worker --pull', so they do not need access to the PubSub system")
+	serverCmd.Flags().String("work-lease-ttl", "1m", "Time the work claimed by a pull worker is leased without being renewed before it's sent again to the queue")
+	serverCmd.Flags().String("pubsub-system", mempubsub.Scheme, "Which PubSub system to use (mem, sql, nats, rabbit, kafka). Env vars: NATS_SERVER_URL, RABBIT_SERVER_URL, KAFKA_BROKERS")
+	serverCmd.Flags().String("artifacts-dir", "", "Directory where the task outputs (artifacts) are stored, defaults to the XDG data directory")
 	serverCmd.Flags().String("log-level", "info", "Sets the log level ('debug', 'info', 'warn', 'error')")
 	serverCmd.Flags().String("team-canonical", mainTeamCanonical, "Team Canonical to scope the action")
 	serverCmd.Flags().String("pipeline-config", "", "Path to the Pipeline config file")
@@ -291,21 +398,47 @@ func init() {
 	serverViper.AutomaticEnv()
 }
 
-func getSubscriptionURL(s string) string {
-	u := fmt.Sprintf("%s://pikoci", s)
+// newTopicRouter returns the Topic that sends the messages to
+// the topic of their tags on the PubSub system sy
+func newTopicRouter(sy string) *queue.Router {
+	return queue.NewRouter(func(ctx context.Context, key string) (queue.Topic, error) {
+		return pubsub.OpenTopic(ctx, getTopicURL(sy, key))
+	})
+}
+
+// registerSQLPubSub registers the queue stored on the db
+// as the PubSub system with the mysql.QueueScheme
+func registerSQLPubSub(db *sql.DB, system string) {
+	o := &mysql.QueueURLOpener{DB: db, System: system}
+	pubsub.DefaultURLMux().RegisterTopic(mysql.QueueScheme, o)
+	pubsub.DefaultURLMux().RegisterSubscription(mysql.QueueScheme, o)
+}
+
+// topicName returns the name of the topic of the tags key,
+// the messages with no tags use "pikoci"
+func topicName(key string) string {
+	if key == "" {
+		return "pikoci"
+	}
+	return "pikoci." + key
+}
+
+func getSubscriptionURL(s, key string) string {
+	n := topicName(key)
+	u := fmt.Sprintf("%s://%s", s, n)
 	switch s {
 	case natspubsub.Scheme:
-		u += "?queue=pikoci&natsv2"
+		u += fmt.Sprintf("?queue=%s&natsv2", n)
 	case "rabbit":
 		// rabbit://pikoci — uses RABBIT_SERVER_URL env var
 	case "kafka":
-		u = "kafka://pikoci-group?topic=pikoci"
+		u = fmt.Sprintf("kafka://%s-group?topic=%s", n, n)
 	}
 	return u
 }
 
-func getTopicURL(s string) string {
-	u := fmt.Sprintf("%s://pikoci", s)
+func getTopicURL(s, key string) string {
+	u := fmt.Sprintf("%s://%s", s, topicName(key))
 	switch s {
 	case natspubsub.Scheme:
 		u += "?natsv2"
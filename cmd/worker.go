@@ -6,15 +6,21 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/adrg/xdg"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/mysql"
 	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
 	"github.com/xescugc/pikoci/pikoci/transport/http/client"
 	"github.com/xescugc/pikoci/worker"
 	"github.com/xescugc/pikoci/worker/config"
@@ -60,9 +66,45 @@ var workerCmd = &cobra.Command{
 			return fmt.Errorf("failed to initialize client with url %q: %w", cfg.PikoCIURL, err)
 		}
 
-		topic, err := pubsub.OpenTopic(ctx, getTopicURL(cfg.PubSubSystem))
-		if err != nil {
-			return fmt.Errorf("failed to open: %v", err)
+		var (
+			topic     queue.Topic
+			subscribe subscribeFunc
+		)
+		if cfg.Pull {
+			// The work is claimed from and published
+			// through the server, so no PubSub is needed
+			topic = worker.NewPullTopic(c)
+			subscribe = pullSubscriber(c)
+		} else {
+			if cfg.PubSubSystem == mysql.QueueScheme {
+				// The in memory DB only lives in the server process
+				if cfg.DBSystem != mysql.SQLite && cfg.DBSystem != mysql.MySQL && cfg.DBSystem != mysql.PostgreSQL {
+					return fmt.Errorf("invalid DBSystem %q for the %q pubsub-system, should be one of: %s, %s or %s", cfg.DBSystem, mysql.QueueScheme, mysql.SQLite, mysql.MySQL, mysql.PostgreSQL)
+				}
+				dbFile, err := xdg.DataFile(filepath.Join(AppName, AppName+".db"))
+				if err != nil {
+					return fmt.Errorf("failed to create dbFile: %v", err)
+				}
+				db, err := mysql.New(cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, mysql.Options{
+					DBName:          cfg.DBName,
+					MultiStatements: true,
+					ClientFoundRows: true,
+					System:          cfg.DBSystem,
+					DBFile:          dbFile,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to connect to database: %w", err)
+				}
+				defer db.Close()
+				registerSQLPubSub(db, cfg.DBSystem)
+			}
+
+			router := newTopicRouter(cfg.PubSubSystem)
+			if _, err := router.Topic(ctx, ""); err != nil {
+				return fmt.Errorf("failed to open: %v", err)
+			}
+			topic = router
+			subscribe = pubsubSubscriber(cfg.PubSubSystem, router)
 		}
 		defer topic.Shutdown(ctx)
 
@@ -71,7 +113,14 @@ var workerCmd = &cobra.Command{
 			return fmt.Errorf("invalid drain-timeout %q: %w", cfg.DrainTimeout, err)
 		}
 
-		workers, wg, cleanup, err := runWorker(ctx, cfg.PubSubSystem, topic, c, cfg.Concurrency, cfg.LogLevel)
+		heartbeatInterval, err := time.ParseDuration(cfg.HeartbeatInterval)
+		if err != nil {
+			return fmt.Errorf("invalid heartbeat-interval %q: %w", cfg.HeartbeatInterval, err)
+		}
+
+		cs := newCacheStore(cfg.CacheDir, cfg.CacheMaxSize)
+
+		workers, wg, cleanup, err := runWorker(ctx, topic, subscribe, c, cs, cfg.Name, cfg.Tags, heartbeatInterval, cfg.Concurrency, cfg.LogLevel)
 		if err != nil {
 			return fmt.Errorf("failed to start worker: %w", err)
 		}
@@ -124,9 +173,21 @@ var workerCmd = &cobra.Command{
 func init() {
 	workerCmd.Flags().StringP("config", "c", "", "Path to the config file")
 	workerCmd.Flags().StringP("pikoci-url", "u", "localhost:8080", "URL to the PikoCI server")
-	workerCmd.Flags().String("pubsub-system", mempubsub.Scheme, "Which PubSub system to use (mem, nats, rabbit, kafka). Env vars: NATS_SERVER_URL, RABBIT_SERVER_URL, KAFKA_BROKERS")
+	workerCmd.Flags().String("pubsub-system", mempubsub.Scheme, "Which PubSub system to use (mem, sql, nats, rabbit, kafka). Env vars: NATS_SERVER_URL, RABBIT_SERVER_URL, KAFKA_BROKERS")
+	workerCmd.Flags().Bool("pull", false, "Claims the work from the PikoCI server instead of the PubSub system, the server has to run with 'pull-workers'")
+	workerCmd.Flags().String("db-system", mysql.SQLite, "Which DB system the 'sql' PubSub system uses, the same as the server (sqlite, mysql, postgresql)")
+	workerCmd.Flags().String("db-host", "", "Database Host of the 'sql' PubSub system")
+	workerCmd.Flags().Int("db-port", 0, "Database Port of the 'sql' PubSub system")
+	workerCmd.Flags().String("db-user", "", "Database User of the 'sql' PubSub system")
+	workerCmd.Flags().String("db-password", "", "Database Password of the 'sql' PubSub system")
+	workerCmd.Flags().String("db-name", "", "Database Name of the 'sql' PubSub system")
 	workerCmd.Flags().Int("concurrency", 1, "Number of workers to start in one instance")
+	workerCmd.Flags().String("name", "", "Name the worker registers with on the server, defaults to the hostname")
+	workerCmd.Flags().StringSlice("tags", nil, fmt.Sprintf("Tags of the worker (max %d), it only runs the jobs and checks the resources with tags that it has all of", queue.MaxWorkerTags))
+	workerCmd.Flags().String("heartbeat-interval", defaultHeartbeatInterval.String(), "Interval between the heartbeats sent to the server, it has to be lower than the server 'worker-heartbeat-ttl'")
 	workerCmd.Flags().String("drain-timeout", "10m", "Maximum time to wait for in-flight jobs to finish during graceful shutdown (SIGQUIT)")
+	workerCmd.Flags().String("cache-dir", "", "Directory where the job and task caches are stored, defaults to the XDG cache directory")
+	workerCmd.Flags().Int64("cache-max-size", 5120, "Maximum size in MB of all the caches, the least recently used are evicted first (0 means no limit)")
 	workerCmd.Flags().String("log-level", "info", "Sets the log level ('debug', 'info', 'warn', 'error')")
 	workerCmd.Flags().String("worker-token", "", "Worker authentication token (from 'pikoci worker-token' or server startup logs)")
 
@@ -136,13 +197,85 @@ func init() {
 	workerViper.AutomaticEnv()
 }
 
-func runWorker(ctx context.Context, sy string, t queue.Topic, s pikoci.Service, c int, llvl string) ([]*worker.Worker, *sync.WaitGroup, func(), error) {
+// newCacheStore returns the worker CacheStore on dir, or on the XDG
+// cache directory if empty, limited to maxSizeMB megabytes
+func newCacheStore(dir string, maxSizeMB int64) *worker.CacheStore {
+	if dir == "" {
+		dir = filepath.Join(xdg.CacheHome, AppName, "caches")
+	}
+	return worker.NewCacheStore(dir, maxSizeMB*1024*1024)
+}
+
+// defaultHeartbeatInterval is the interval between the heartbeats of the workers
+const defaultHeartbeatInterval = 30 * time.Second
+
+// subscribeFunc opens the Subscription of the worker registered with reg
+type subscribeFunc func(ctx context.Context, reg *worker.Registration, tags []string, l *slog.Logger) (queue.Subscription, error)
+
+// pubsubSubscriber returns the subscribeFunc that subscribes to the topic of
+// each combination of the tags on the PubSub system sy, so the worker
+// receives all the messages it can process
+func pubsubSubscriber(sy string, t *queue.Router) subscribeFunc {
+	return func(ctx context.Context, _ *worker.Registration, tags []string, _ *slog.Logger) (queue.Subscription, error) {
+		var subs []queue.Subscription
+		for _, k := range queue.TagsKeys(tags) {
+			sub, err := openSubscription(ctx, sy, t, k)
+			if err != nil {
+				return nil, err
+			}
+			subs = append(subs, sub)
+		}
+		return queue.NewMultiSubscription(subs...), nil
+	}
+}
+
+// pullSubscriber returns the subscribeFunc that claims
+// the work the worker can process from s
+func pullSubscriber(s pikoci.Service) subscribeFunc {
+	return func(_ context.Context, reg *worker.Registration, tags []string, l *slog.Logger) (queue.Subscription, error) {
+		return worker.NewPullSubscription(s, reg, tags, l), nil
+	}
+}
+
+// openSubscription opens the Subscription to the topic
+// of the tags key on the PubSub system sy
+func openSubscription(ctx context.Context, sy string, t *queue.Router, key string) (queue.Subscription, error) {
+	// Some PubSub systems, like mem, need the topic to exist
+	if _, err := t.Topic(ctx, key); err != nil {
+		return nil, err
+	}
+	sub, err := pubsub.OpenSubscription(ctx, getSubscriptionURL(sy, key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to OpenSubscription: %w", err)
+	}
+	return sub, nil
+}
+
+func runWorker(ctx context.Context, t queue.Topic, subscribe subscribeFunc, s pikoci.Service, cs *worker.CacheStore, name string, tags []string, hbi time.Duration, c int, llvl string) ([]*worker.Worker, *sync.WaitGroup, func(), error) {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: parseSlogLevel(llvl)}))
 	logger = logger.With("service", "worker")
-	// Create a subscription connected to that topic.
-	subscription, err := pubsub.OpenSubscription(ctx, getSubscriptionURL(sy))
+
+	if name == "" {
+		hn, err := os.Hostname()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get hostname: %w", err)
+		}
+		name = hn
+	}
+	reg := worker.NewRegistration(s, registry.Worker{
+		Name:        name,
+		Version:     buildVersion(),
+		Platform:    runtime.GOOS + "/" + runtime.GOARCH,
+		Concurrency: c,
+		Tags:        tags,
+	}, hbi, logger)
+	if err := reg.Start(ctx); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to register worker: %w", err)
+	}
+
+	subscription, err := subscribe(ctx, reg, tags, logger)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to OpenSubscription: %w", err)
+		return nil, nil, nil, err
 	}
 
 	cleanup := func() { subscription.Shutdown(context.Background()) }
@@ -153,7 +286,7 @@ func runWorker(ctx context.Context, sy string, t queue.Topic, s pikoci.Service,
 		wg.Add(1)
 		nlogger := logger.With("num", i+1)
 		nlogger.Info(fmt.Sprintf("Starting Worker %d", i+1))
-		w := worker.New(s, t, subscription, nlogger)
+		w := worker.New(s, t, subscription, cs, reg, nlogger)
 		workers = append(workers, w)
 
 		go func() {
@@ -166,3 +299,12 @@ func runWorker(ctx context.Context, sy string, t queue.Topic, s pikoci.Service,
 	}
 	return workers, &wg, cleanup, nil
 }
+
+// buildVersion returns the module version PikoCI was built with
+func buildVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return bi.Main.Version
+}
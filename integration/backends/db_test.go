@@ -60,10 +60,24 @@ func TestDBBackends(t *testing.T) {
 				require.NoError(t, err)
 				assert.Equal(t, "testuser", um.Username)
 
+				// Create a user from OIDC, the users without
+				// subject are NULL so they do not collide
+				_, err = ur.Create(ctx, user.User{
+					FullName:    "OIDC User",
+					Username:    "oidcuser",
+					Password:    "hashed_password",
+					OIDCSubject: "1234",
+				})
+				require.NoError(t, err)
+
+				u, err = ur.Find(ctx, "oidcuser")
+				require.NoError(t, err)
+				assert.Equal(t, "1234", u.OIDCSubject)
+
 				// Filter all users
 				users, err = ur.Filter(ctx)
 				require.NoError(t, err)
-				assert.GreaterOrEqual(t, len(users), 2) // default admin + testuser
+				assert.GreaterOrEqual(t, len(users), 3) // default admin + testuser + oidcuser
 			})
 
 			t.Run("TeamRepository", func(t *testing.T) {
@@ -74,6 +88,9 @@ func TestDBBackends(t *testing.T) {
 				require.NoError(t, err)
 				assert.Equal(t, "main", twm.Canonical)
 
+				_, err = tr.Find(ctx, "unknown")
+				assert.Error(t, err)
+
 				// Create a new team
 				teamID, err := tr.Create(ctx, team.Team{
 					Name:      "Backend Test",
@@ -84,8 +101,8 @@ func TestDBBackends(t *testing.T) {
 
 				// Add member to team
 				err = tr.CreateMember(ctx, "backend-test", team.Member{
-					Admin: true,
-					User:  user.User{Username: "admin"},
+					Role: user.RoleOwner,
+					User: user.User{Username: "admin"},
 				})
 				require.NoError(t, err)
 
@@ -98,7 +115,7 @@ func TestDBBackends(t *testing.T) {
 				// FindMember
 				member, err := tr.FindMember(ctx, "backend-test", "admin")
 				require.NoError(t, err)
-				assert.True(t, member.Admin)
+				assert.Equal(t, user.RoleOwner, member.Role)
 			})
 
 			t.Run("PipelineRepository", func(t *testing.T) {
@@ -15,9 +15,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/artifact"
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/mysql"
 	"github.com/xescugc/pikoci/pikoci/mysql/migrate"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	"github.com/xescugc/pikoci/pikoci/unitwork"
 	"github.com/xescugc/pikoci/pikoci/user"
 	"github.com/xescugc/pikoci/worker"
@@ -54,10 +56,19 @@ func TestSecretsE2E(t *testing.T) {
 	br := mysql.NewBuildRepository(db, mysql.Mem)
 	rur := mysql.NewRunnerRepository(db)
 	str := mysql.NewSecretTypeRepository(db)
+	wr := mysql.NewWorkerRepository(db)
+	lr := mysql.NewLockRepository(db)
+	sr := mysql.NewSecretRepository(db)
+	tkr := mysql.NewTokenRepository(db)
+	as := artifact.NewLocalStore(t.TempDir())
 	suow := unitwork.NewStartUnitOfWork(db, mysql.Mem)
 
 	jwtSecret := []byte("test-secret")
-	svc := pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, suow, jwtSecret, logger)
+	key, err := secret.GenerateKey("test")
+	require.NoError(t, err)
+	sk, err := secret.NewKeyring([]string{key})
+	require.NoError(t, err)
+	svc := pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, wr, lr, sr, tkr, as, suow, jwtSecret, sk, logger)
 	svc.StartScheduler(ctx)
 
 	// Migration already creates admin user and "main" team.
@@ -77,7 +88,7 @@ func TestSecretsE2E(t *testing.T) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		w := worker.New(svc, topic, subscription, logger.With("component", "worker"))
+		w := worker.New(svc, topic, subscription, nil, nil, logger.With("component", "worker"))
 		w.Run(ctx)
 	}()
 
@@ -163,8 +174,9 @@ job "deploy" {
 		}
 
 		require.NotNil(t, taskStep, "task step 'use-secrets' should exist in build steps")
-		assert.True(t, strings.Contains(taskStep.Logs, "db_username=admin"), "logs should contain db_username=admin, got: %s", taskStep.Logs)
-		assert.True(t, strings.Contains(taskStep.Logs, "db_password=s3cret"), "logs should contain db_password=s3cret, got: %s", taskStep.Logs)
+		// The resolved values are redacted from the logs
+		assert.True(t, strings.Contains(taskStep.Logs, "db_username=*** db_password=***"), "logs should contain the redacted secrets, got: %s", taskStep.Logs)
+		assert.False(t, strings.Contains(taskStep.Logs, "s3cret"), "logs should not contain the secret, got: %s", taskStep.Logs)
 	})
 
 	t.Run("SecretBackedVariableWithFileSource", func(t *testing.T) {
@@ -360,6 +372,71 @@ job "deploy" {
 		assert.True(t, strings.Contains(taskStep.Logs, "db_conn=host=db;port=5432"), "logs should contain db_conn=host=db;port=5432 (value with = sign), got: %s", taskStep.Logs)
 	})
 
+	t.Run("SecretBackedVariableWithPikoCISecretType", func(t *testing.T) {
+		// Uses the built-in "pikoci" secret_type with the Secrets of the Team
+		_, err := svc.SetSecret(ctx, "main", "deploy_token", "t0k3n-value")
+		require.NoError(t, err)
+
+		hclConfig := []byte(`
+variable "deploy_token" {
+  type = string
+  secret "pikoci" {
+    key = "deploy_token"
+  }
+}
+
+resource "cron" "timer" {
+  check_interval = "@every 1h"
+}
+
+job "deploy" {
+  get "cron" "timer" {
+    trigger = true
+  }
+  task "use-team-secrets" {
+    run "exec" {
+      path = "/bin/sh"
+      args = ["-ec", "test '${var.deploy_token}' = t0k3n-value && echo deploy_token=${var.deploy_token}"]
+    }
+  }
+}
+`)
+
+		_, err = svc.CreatePipeline(ctx, "main", "secrets-pikoci-e2e", hclConfig, nil)
+		require.NoError(t, err)
+
+		err = svc.TriggerPipelineResource(ctx, "main", "secrets-pikoci-e2e", "cron.timer")
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			vers, err := svc.ListResourceVersions(ctx, "main", "secrets-pikoci-e2e", "cron.timer")
+			return err == nil && len(vers) > 0
+		}, 10*time.Second, 200*time.Millisecond)
+
+		var builds []*build.Build
+		require.Eventually(t, func() bool {
+			builds, err = svc.ListJobBuilds(ctx, "main", "secrets-pikoci-e2e", "deploy")
+			if err != nil || len(builds) == 0 {
+				return false
+			}
+			return builds[0].Status.Finished()
+		}, 15*time.Second, 200*time.Millisecond)
+
+		require.NotEmpty(t, builds)
+		b := builds[0]
+		assert.Equal(t, build.Succeeded, b.Status, "build should succeed, error: %s", b.Error)
+
+		var taskStep *build.Step
+		for i, s := range b.Steps {
+			if s.Type == "task" && s.Name == "use-team-secrets" {
+				taskStep = &b.Steps[i]
+				break
+			}
+		}
+		require.NotNil(t, taskStep, "task step 'use-team-secrets' should exist in build steps")
+		assert.True(t, strings.Contains(taskStep.Logs, "deploy_token=***"), "logs should contain the redacted secret, got: %s", taskStep.Logs)
+	})
+
 	cancel()
 	wg.Wait()
 }
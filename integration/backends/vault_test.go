@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/artifact"
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/mysql"
 	"github.com/xescugc/pikoci/pikoci/mysql/migrate"
@@ -140,9 +141,14 @@ func TestSecretsVaultE2E(t *testing.T) {
 	br := mysql.NewBuildRepository(db, mysql.Mem)
 	rur := mysql.NewRunnerRepository(db)
 	str := mysql.NewSecretTypeRepository(db)
+	wr := mysql.NewWorkerRepository(db)
+	lr := mysql.NewLockRepository(db)
+	sr := mysql.NewSecretRepository(db)
+	tkr := mysql.NewTokenRepository(db)
+	as := artifact.NewLocalStore(t.TempDir())
 	suow := unitwork.NewStartUnitOfWork(db, mysql.Mem)
 
-	svc := pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, suow, []byte("jwt"), logger)
+	svc := pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, wr, lr, sr, tkr, as, suow, []byte("jwt"), nil, logger)
 	svc.StartScheduler(ctx)
 
 	_, _ = svc.CreateUser(ctx, user.User{
@@ -159,7 +165,7 @@ func TestSecretsVaultE2E(t *testing.T) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		w := worker.New(svc, topic, subscription, logger.With("component", "worker"))
+		w := worker.New(svc, topic, subscription, nil, nil, logger.With("component", "worker"))
 		w.Run(ctx)
 	}()
 
@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/artifact"
 	"github.com/xescugc/pikoci/pikoci/mysql"
 	"github.com/xescugc/pikoci/pikoci/mysql/migrate"
 	"github.com/xescugc/pikoci/pikoci/queue"
@@ -62,8 +63,13 @@ func runTests(m *testing.M) int {
 	br := mysql.NewBuildRepository(db, mysql.Mem)
 	rur := mysql.NewRunnerRepository(db)
 	str := mysql.NewSecretTypeRepository(db)
+	wr := mysql.NewWorkerRepository(db)
+	lr := mysql.NewLockRepository(db)
+	sr := mysql.NewSecretRepository(db)
+	tkr := mysql.NewTokenRepository(db)
+	as := artifact.NewLocalStore(os.TempDir())
 	suow := unitwork.NewStartUnitOfWork(db, mysql.Mem)
-	var svc = pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, suow, jwtSecret, logger)
+	var svc = pikoci.New(ctx, topic, ur, tr, ppr, jr, rr, rt, br, rur, str, wr, lr, sr, tkr, as, suow, jwtSecret, nil, logger)
 	svc.StartScheduler(ctx)
 	var handler = tshttp.Handler(svc, jwtSecret, logger.With("component", "HTTP"))
 	server := httptest.NewServer(handler)
@@ -123,7 +129,7 @@ func runWorker(ctx context.Context, sy string, t queue.Topic, s pikoci.Service,
 		wg.Add(1)
 		nlogger := logger.With("num", i+1)
 		nlogger.Info(fmt.Sprintf("Starting Worker %d", i+1))
-		w := worker.New(s, t, subscription, nlogger)
+		w := worker.New(s, t, subscription, nil, nil, nlogger)
 
 		go func() {
 			err = w.Run(ctx)
@@ -17,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/tebeka/selenium"
 	thttp "github.com/xescugc/pikoci/pikoci/transport/http"
+	"github.com/xescugc/pikoci/pikoci/user"
 )
 
 func TestPikoCI(t *testing.T) {
@@ -135,7 +136,7 @@ func TestPikoCI(t *testing.T) {
 
 			// As we fetch the users to fill in we have to wait
 			waitFor(t, wd, func(t *testing.T, wd selenium.WebDriver) bool {
-				opts, err := wd.FindElements(selenium.ByCSSSelector, "option")
+				opts, err := wd.FindElements(selenium.ByCSSSelector, "#username>option")
 				require.NoError(t, err)
 
 				return 2 == len(opts)
@@ -163,35 +164,39 @@ func TestPikoCI(t *testing.T) {
 			require.Equal(t, 2, len(members))
 		})
 		t.Run("Update Member", func(t *testing.T) {
-			aBtns, err := wd.FindElements(selenium.ByCSSSelector, "#admin")
+			rSels, err := wd.FindElements(selenium.ByCSSSelector, "#role")
 			require.NoError(t, err)
-			require.Equal(t, 2, len(aBtns))
+			require.Equal(t, 2, len(rSels))
 
-			as1, err := aBtns[0].IsSelected()
+			rv1, err := rSels[0].GetAttribute("value")
 			require.NoError(t, err)
-			ae1, err := aBtns[0].IsEnabled()
+			re1, err := rSels[0].IsEnabled()
 			require.NoError(t, err)
-			as2, err := aBtns[1].IsSelected()
+			rv2, err := rSels[1].GetAttribute("value")
 			require.NoError(t, err)
-			ae2, err := aBtns[1].IsEnabled()
+			re2, err := rSels[1].IsEnabled()
 			require.NoError(t, err)
-			require.True(t, as1)
-			require.True(t, ae1)
-			require.False(t, as2)
-			require.True(t, ae2)
+			require.Equal(t, "owner", rv1)
+			require.True(t, re1)
+			require.Equal(t, "operator", rv2)
+			require.True(t, re2)
 
-			err = aBtns[1].Click()
+			owOpt, err := rSels[1].FindElement(selenium.ByCSSSelector, "option[value=owner]")
 			require.NoError(t, err)
-
-			aBtns, err = wd.FindElements(selenium.ByCSSSelector, "#admin")
+			err = owOpt.Click()
 			require.NoError(t, err)
 
-			as1, err = aBtns[0].IsSelected()
-			require.NoError(t, err)
-			as2, err = aBtns[1].IsSelected()
-			require.NoError(t, err)
-			require.True(t, as1)
-			require.True(t, as2)
+			waitFor(t, wd, func(t *testing.T, wd selenium.WebDriver) bool {
+				rSels, err := wd.FindElements(selenium.ByCSSSelector, "#role")
+				require.NoError(t, err)
+				require.Equal(t, 2, len(rSels))
+
+				rv1, err := rSels[0].GetAttribute("value")
+				require.NoError(t, err)
+				rv2, err := rSels[1].GetAttribute("value")
+				require.NoError(t, err)
+				return rv1 == "owner" && rv2 == "owner"
+			}, 5*time.Second)
 		})
 		t.Run("Delete Member", func(t *testing.T) {
 			members, err := wd.FindElements(selenium.ByCSSSelector, "tbody>tr")
@@ -534,7 +539,7 @@ job "gen" {
 
 				// As we fetch the users to fill in we have to wait
 				waitFor(t, wd, func(t *testing.T, wd selenium.WebDriver) bool {
-					opts, err := wd.FindElements(selenium.ByCSSSelector, "option")
+					opts, err := wd.FindElements(selenium.ByCSSSelector, "#username>option")
 					require.NoError(t, err)
 
 					return 2 == len(opts)
@@ -645,22 +650,22 @@ job "gen" {
 			require.NoError(t, err)
 			require.False(t, nameEnabled)
 
-			aBtns, err := wd.FindElements(selenium.ByCSSSelector, "#admin")
+			rSels, err := wd.FindElements(selenium.ByCSSSelector, "#role")
 			require.NoError(t, err)
-			require.Equal(t, 2, len(aBtns))
+			require.Equal(t, 2, len(rSels))
 
-			as1, err := aBtns[0].IsSelected()
+			rv1, err := rSels[0].GetAttribute("value")
 			require.NoError(t, err)
-			ae1, err := aBtns[0].IsEnabled()
+			re1, err := rSels[0].IsEnabled()
 			require.NoError(t, err)
-			as2, err := aBtns[1].IsSelected()
+			rv2, err := rSels[1].GetAttribute("value")
 			require.NoError(t, err)
-			ae2, err := aBtns[1].IsEnabled()
+			re2, err := rSels[1].IsEnabled()
 			require.NoError(t, err)
-			require.True(t, as1)
-			require.False(t, ae1)
-			require.False(t, as2)
-			require.False(t, ae2)
+			require.Equal(t, "owner", rv1)
+			require.False(t, re1)
+			require.Equal(t, "operator", rv2)
+			require.False(t, re2)
 		})
 		t.Run("Pipelines", func(t *testing.T) {
 			tmsBtn, err := wd.FindElement(selenium.ByLinkText, "Teams")
@@ -791,7 +796,7 @@ job "gen" {
 	})
 	t.Run("RefreshToken", func(t *testing.T) {
 		// Pepito is still logged in as a non-admin member of "main".
-		// We promote pepito to team admin via a direct HTTP call (as admin),
+		// We promote pepito to team owner via a direct HTTP call (as admin),
 		// then navigate in the browser. The next Backbone.sync fetch should
 		// detect the stale JWT via the X-Refresh-Token header, auto-refresh
 		// the session, and pepito should now see admin controls.
@@ -812,8 +817,8 @@ job "gen" {
 		require.Empty(t, lr.Err)
 		adminJWT := lr.Data.JWT
 
-		// Step 2: Promote pepito to admin on "main" team via HTTP
-		updateBody, _ := json.Marshal(thttp.UpdateTeamMemberRequest{Admin: true})
+		// Step 2: Promote pepito to owner on "main" team via HTTP
+		updateBody, _ := json.Marshal(thttp.UpdateTeamMemberRequest{Role: user.RoleOwner})
 		updateReq, err := http.NewRequest(http.MethodPut, pikoURL+"/teams/main/members/pepito.json", bytes.NewReader(updateBody))
 		require.NoError(t, err)
 		updateReq.Header.Set("Authorization", "Bearer "+adminJWT)
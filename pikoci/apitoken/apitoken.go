@@ -0,0 +1,151 @@
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned by a Repository when no Token exists
+	ErrNotFound = errors.New("token not found")
+
+	// ErrInvalid is returned when authenticating with a Token that does not exist
+	ErrInvalid = errors.New("invalid token")
+
+	// ErrExpired is returned when authenticating with a Token past its ExpiresAt
+	ErrExpired = errors.New("token expired")
+)
+
+// Prefix is the start of all the Tokens, so they
+// can be told apart from the JWTs
+const Prefix = "pikoci_"
+
+// hintLen is the length of the Hint, Prefix included
+const hintLen = len(Prefix) + 4
+
+// Scope limits the routes a Token can be used on
+type Scope string
+
+// The Scopes are ordered, each one allows
+// everything the previous ones allow
+const (
+	// Read allows reading the Teams, Pipelines, Builds, ...
+	Read Scope = "read"
+	// Trigger allows triggering, pausing, cancelling and retrying
+	// Jobs and Resources
+	Trigger Scope = "trigger"
+	// PipelineWrite allows creating, updating and deleting Pipelines
+	PipelineWrite Scope = "pipeline-write"
+	// Admin allows everything the owner of the Token can do
+	Admin Scope = "admin"
+)
+
+// Scopes are all the Scopes, ordered
+var Scopes = []Scope{Read, Trigger, PipelineWrite, Admin}
+
+func (s Scope) level() int {
+	for i, sc := range Scopes {
+		if sc == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Valid checks if s is one of the Scopes
+func (s Scope) Valid() bool { return s.level() != -1 }
+
+// Token is a long lived API token of a User, or of the service
+// account of a Team. Only its Hash is stored, the token itself
+// is only known when it's created.
+type Token struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+
+	// Hint is the start of the token, to tell them apart
+	Hint string `json:"hint"`
+	Hash string `json:"-"`
+
+	Scopes []Scope `json:"scopes"`
+
+	// Username is the User owning the Token, it's empty
+	// for the Tokens of the Team service accounts
+	Username string `json:"username,omitempty"`
+
+	// TeamCanonical is the Team of the service account
+	// owning the Token
+	TeamCanonical string `json:"team_canonical,omitempty"`
+
+	// ExpiresAt is when the Token stops being valid, nil never expires
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Generate returns a new random token and its Hash
+func Generate() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	t := Prefix + base64.RawURLEncoding.EncodeToString(b)
+	return t, Hash(t), nil
+}
+
+// Hash returns the hash the token t is stored with. As the tokens
+// are random a plain SHA-256 is enough, and it can be looked up.
+func Hash(t string) string {
+	h := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(h[:])
+}
+
+// Hint returns the Hint of the token t
+func Hint(t string) string {
+	if len(t) < hintLen {
+		return t
+	}
+	return t[:hintLen]
+}
+
+// IsToken checks if t has the format of a Token
+func IsToken(t string) bool {
+	return strings.HasPrefix(t, Prefix)
+}
+
+// HasScope checks if the Token can be used on the routes of the Scope s
+func (t Token) HasScope(s Scope) bool {
+	l := s.level()
+	if l == -1 {
+		return false
+	}
+	for _, ts := range t.Scopes {
+		if ts.level() >= l {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired checks if the Token is expired at now
+func (t Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// IsServiceAccount checks if the Token is owned by
+// the service account of a Team instead of a User
+func (t Token) IsServiceAccount() bool {
+	return t.TeamCanonical != ""
+}
+
+// ServiceAccount is the name the service account of the Token is
+// identified with, 'TEAM/NAME'. It's not a valid Username so it
+// can't be confused with a User.
+func (t Token) ServiceAccount() string {
+	return t.TeamCanonical + "/" + t.Name
+}
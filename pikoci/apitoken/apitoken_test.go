@@ -0,0 +1,86 @@
+package apitoken_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+)
+
+func TestGenerate(t *testing.T) {
+	raw, hash, err := apitoken.Generate()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(raw, apitoken.Prefix))
+	assert.True(t, apitoken.IsToken(raw))
+	assert.Equal(t, apitoken.Hash(raw), hash)
+	assert.NotContains(t, hash, raw)
+	assert.Equal(t, raw[:len(apitoken.Prefix)+4], apitoken.Hint(raw))
+
+	raw2, hash2, err := apitoken.Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, raw, raw2)
+	assert.NotEqual(t, hash, hash2)
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Scopes []apitoken.Scope
+		Has    []apitoken.Scope
+		HasNot []apitoken.Scope
+	}{
+		{
+			Name:   "Read",
+			Scopes: []apitoken.Scope{apitoken.Read},
+			Has:    []apitoken.Scope{apitoken.Read},
+			HasNot: []apitoken.Scope{apitoken.Trigger, apitoken.PipelineWrite, apitoken.Admin},
+		},
+		{
+			Name:   "Trigger",
+			Scopes: []apitoken.Scope{apitoken.Trigger},
+			Has:    []apitoken.Scope{apitoken.Read, apitoken.Trigger},
+			HasNot: []apitoken.Scope{apitoken.PipelineWrite, apitoken.Admin},
+		},
+		{
+			Name:   "Multiple",
+			Scopes: []apitoken.Scope{apitoken.Read, apitoken.PipelineWrite},
+			Has:    []apitoken.Scope{apitoken.Read, apitoken.Trigger, apitoken.PipelineWrite},
+			HasNot: []apitoken.Scope{apitoken.Admin},
+		},
+		{
+			Name:   "Admin",
+			Scopes: []apitoken.Scope{apitoken.Admin},
+			Has:    apitoken.Scopes,
+		},
+		{
+			Name:   "Unknown",
+			Scopes: []apitoken.Scope{"potato"},
+			HasNot: append(apitoken.Scopes, "potato"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tk := apitoken.Token{Scopes: tt.Scopes}
+			for _, s := range tt.Has {
+				assert.True(t, tk.HasScope(s), s)
+			}
+			for _, s := range tt.HasNot {
+				assert.False(t, tk.HasScope(s), s)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, apitoken.Token{}.Expired(now))
+
+	exp := now.Add(time.Minute)
+	tk := apitoken.Token{ExpiresAt: &exp}
+	assert.False(t, tk.Expired(now))
+	assert.True(t, tk.Expired(exp))
+	assert.True(t, tk.Expired(exp.Add(time.Second)))
+}
@@ -0,0 +1,24 @@
+package apitoken
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go tool mockgen -destination=../mock/apitoken_repository.go -mock_names=Repository=APITokenRepository -package mock github.com/xescugc/pikoci/pikoci/apitoken Repository
+
+type Repository interface {
+	// Create uses the Username or the TeamCanonical
+	// of the Token as its owner
+	Create(ctx context.Context, t Token) (uint32, error)
+	// FindByHash returns ErrNotFound if the Token does not exist
+	FindByHash(ctx context.Context, hash string) (*Token, error)
+	FilterByUser(ctx context.Context, un string) ([]*Token, error)
+	FilterByTeam(ctx context.Context, tc string) ([]*Token, error)
+	// DeleteByUser returns ErrNotFound if the User has no Token with id
+	DeleteByUser(ctx context.Context, un string, id uint32) error
+	// DeleteByTeam returns ErrNotFound if the Team has no Token with id
+	DeleteByTeam(ctx context.Context, tc string, id uint32) error
+
+	UpdateLastUsedAt(ctx context.Context, id uint32, at time.Time) error
+}
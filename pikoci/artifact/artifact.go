@@ -0,0 +1,29 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+//go:generate go tool mockgen -destination=../mock/artifact_store.go -mock_names=Store=ArtifactStore -package mock github.com/xescugc/pikoci/pikoci/artifact Store
+
+// ErrNotFound is returned by a Store when no artifact exists for a Key.
+var ErrNotFound = errors.New("artifact not found")
+
+// Key identifies the artifact produced by a task of a specific build.
+// All the outputs of a task are stored together as a single archive.
+type Key struct {
+	TeamCanonical string
+	PipelineName  string
+	JobName       string
+	BuildNumber   string
+	TaskName      string
+}
+
+// Store is the backend where task outputs are persisted so they
+// can be restored on the workdir of downstream jobs.
+type Store interface {
+	Put(ctx context.Context, k Key, r io.Reader) error
+	Get(ctx context.Context, k Key) (io.ReadCloser, error)
+}
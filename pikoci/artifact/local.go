@@ -0,0 +1,68 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore stores the artifacts on the local filesystem of the server
+// under Dir, one file per Key.
+type LocalStore struct {
+	Dir string
+}
+
+// NewLocalStore returns a Store that keeps the artifacts inside dir
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{
+		Dir: dir,
+	}
+}
+
+func (s *LocalStore) path(k Key) string {
+	return filepath.Join(s.Dir, k.TeamCanonical, k.PipelineName, k.JobName, k.BuildNumber, k.TaskName+".tar.gz")
+}
+
+func (s *LocalStore) Put(ctx context.Context, k Key, r io.Reader) error {
+	p := s.path(k)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	// Write to a temporary file first so a concurrent Get never
+	// reads a partially written artifact.
+	f, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create artifact file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close artifact file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("failed to store artifact: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, k Key) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(k))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,36 @@
+package artifact_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/artifact"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.TODO()
+	s := artifact.NewLocalStore(t.TempDir())
+	k := artifact.Key{TeamCanonical: "main", PipelineName: "my-pipeline", JobName: "build", BuildNumber: "1", TaskName: "compile"}
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := s.Get(ctx, k)
+		assert.ErrorIs(t, err, artifact.ErrNotFound)
+	})
+
+	t.Run("PutGet", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, k, strings.NewReader("first")))
+		require.NoError(t, s.Put(ctx, k, strings.NewReader("second")))
+
+		rc, err := s.Get(ctx, k)
+		require.NoError(t, err)
+		defer rc.Close()
+
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(b))
+	})
+}
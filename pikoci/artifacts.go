@@ -0,0 +1,65 @@
+package pikoci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/xescugc/pikoci/pikoci/artifact"
+	"github.com/xescugc/pikoci/pikoci/utils"
+)
+
+var buildNumberRe = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+func (q *PikoCI) UploadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, r io.Reader) error {
+	if err := validateArtifactKey(tc, pn, jn, buildNumber, tn); err != nil {
+		return err
+	}
+
+	if _, err := q.Builds.Find(ctx, tc, pn, jn, buildNumber); err != nil {
+		return fmt.Errorf("failed to Find Build: %w", err)
+	}
+
+	k := artifact.Key{TeamCanonical: tc, PipelineName: pn, JobName: jn, BuildNumber: buildNumber, TaskName: tn}
+	if err := q.Artifacts.Put(ctx, k, r); err != nil {
+		return fmt.Errorf("failed to Put Artifact: %w", err)
+	}
+
+	return nil
+}
+
+func (q *PikoCI) DownloadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, w io.Writer) error {
+	if err := validateArtifactKey(tc, pn, jn, buildNumber, tn); err != nil {
+		return err
+	}
+
+	k := artifact.Key{TeamCanonical: tc, PipelineName: pn, JobName: jn, BuildNumber: buildNumber, TaskName: tn}
+	rc, err := q.Artifacts.Get(ctx, k)
+	if err != nil {
+		return fmt.Errorf("failed to Get Artifact: %w", err)
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(w, rc); err != nil {
+		return fmt.Errorf("failed to read Artifact: %w", err)
+	}
+
+	return nil
+}
+
+func validateArtifactKey(tc, pn, jn, buildNumber, tn string) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return fmt.Errorf("invalid Job Name format %q", jn)
+	} else if !buildNumberRe.MatchString(buildNumber) {
+		return fmt.Errorf("invalid Build Number format %q", buildNumber)
+	} else if tn == "" || tn == "." || tn == ".." || strings.ContainsAny(tn, `/\`) {
+		return fmt.Errorf("invalid Task Name format %q", tn)
+	}
+	return nil
+}
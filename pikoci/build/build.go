@@ -11,8 +11,20 @@ const (
 	Failed
 	Started
 	Cancelled
+	// Pending is a Build waiting on the queue
+	// for a worker to start it
+	Pending
+	// Skipped is a Step not run because
+	// its when condition was false
+	Skipped
 )
 
+// Finished returns true if s is a final Status, so
+// the Build is no longer pending nor running
+func (s Status) Finished() bool {
+	return s != Pending && s != Started
+}
+
 // Build represents a run of a Job
 type Build struct {
 	ID          uint32 `json:"id"`
@@ -23,15 +35,82 @@ type Build struct {
 	// Job are the general logs printed at the end
 	Job []Step `json:"job"`
 
+	// StartedAt is when the Build was queued while it's
+	// Pending, and when a worker started it after that
 	StartedAt time.Time     `json:"started_at"`
 	Duration  time.Duration `json:"duration"`
+
+	// WorkerID is the registered worker running the Build
+	WorkerID uint32 `json:"worker_id,omitempty"`
+
+	// Cells is the status of each cell of the matrix of the Job,
+	// the Build only succeeds if all of them succeed
+	Cells []Cell `json:"cells,omitempty"`
+}
+
+// Cell is the status of one of the cells of the matrix of a Job
+type Cell struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WithJob is a Build with the Job it belongs to
+type WithJob struct {
+	Build
+	TeamCanonical string `json:"team_canonical"`
+	PipelineName  string `json:"pipeline_name"`
+	JobName       string `json:"job_name"`
+}
+
+// PendingReason is why a Pending Build is not running yet
+type PendingReason string
+
+const (
+	// ReasonQueued is a Build waiting for
+	// a worker to pick it from the queue
+	ReasonQueued PendingReason = "queued"
+	// ReasonConcurrency is a Build waiting for a slot
+	// of the concurrency limit of its Job
+	ReasonConcurrency PendingReason = "concurrency"
+	// ReasonNoWorker is a Build of a Job with tags that
+	// none of the registered workers has
+	ReasonNoWorker PendingReason = "no_worker"
+	// ReasonLock is a Build waiting for a lock of
+	// the serial groups of its Job to be released
+	ReasonLock PendingReason = "lock"
+)
+
+// Queued is a Pending Build with the reason it's not running yet
+type Queued struct {
+	WithJob
+	Reason PendingReason `json:"reason"`
 }
 
 type Step struct {
+	// ID identifies the Step on the LogChunks
+	// appended while it's running
+	ID        string        `json:"id,omitempty"`
 	Type      string        `json:"type"`
 	Name      string        `json:"name"`
 	VersionID uint32        `json:"version_id"`
 	Logs      string        `json:"logs"`
 	Duration  time.Duration `json:"duration"`
 	Status    Status        `json:"status"`
+
+	// Group and Lane are set on the steps that run inside an in_parallel
+	// group, Lane is the position of the step on the group
+	Group string `json:"group,omitempty"`
+	Lane  int    `json:"lane,omitempty"`
+
+	// Cell is the name of the matrix cell the step runs on
+	Cell string `json:"cell,omitempty"`
+
+	// Version are the fields of the version fetched by a get step,
+	// which the when conditions of the next steps can use
+	Version map[string]string `json:"version,omitempty"`
+
+	// Outputs are the KEY=VALUE outputs a task wrote on
+	// $PIKOCI_OUTPUT, which the next steps can use
+	Outputs map[string]string `json:"outputs,omitempty"`
 }
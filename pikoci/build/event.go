@@ -0,0 +1,47 @@
+package build
+
+// LogChunk is a piece of the logs of a running Step, the worker
+// appends them while the step runs so the logs can be streamed
+// without sending the whole Build each time
+type LogChunk struct {
+	ID     uint32 `json:"id"`
+	StepID string `json:"step_id"`
+	// Offset is the position, in bytes, of the Data
+	// on the logs of the Step
+	Offset int    `json:"offset"`
+	Data   string `json:"data"`
+}
+
+type EventType string
+
+const (
+	// EventLog is sent when a LogChunk is appended to a Step
+	EventLog EventType = "log"
+	// EventStatus is sent when the Status of the Build
+	// or of any of its Steps changes
+	EventStatus EventType = "status"
+)
+
+// Event is a change of a running Build
+type Event struct {
+	Type EventType `json:"type"`
+
+	// Log is set on the EventLog
+	Log *LogChunk `json:"log,omitempty"`
+	// Build is set on the EventStatus
+	Build *Build `json:"build,omitempty"`
+}
+
+// StatusChanged returns true if the Status of b, or the one
+// of any of its Steps, is different from the one of ob
+func (b Build) StatusChanged(ob Build) bool {
+	if b.Status != ob.Status || b.Error != ob.Error || len(b.Steps) != len(ob.Steps) {
+		return true
+	}
+	for i, s := range b.Steps {
+		if s.Status != ob.Steps[i].Status {
+			return true
+		}
+	}
+	return false
+}
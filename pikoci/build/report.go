@@ -0,0 +1,140 @@
+package build
+
+import (
+	"sort"
+	"time"
+)
+
+// TestStatus is the result of a TestCase
+type TestStatus string
+
+const (
+	TestPassed  TestStatus = "passed"
+	TestFailed  TestStatus = "failed"
+	TestError   TestStatus = "error"
+	TestSkipped TestStatus = "skipped"
+)
+
+// TestCase is the result of one test of the reports
+// uploaded by a step of the Build
+type TestCase struct {
+	ID uint32 `json:"id"`
+	// BuildNumber is the one of the Build the
+	// TestCase belongs to, only set when listing
+	// the TestCases of a Job
+	BuildNumber string `json:"build_number,omitempty"`
+
+	// Step is the name of the step with the report
+	Step      string        `json:"step"`
+	Suite     string        `json:"suite"`
+	Classname string        `json:"classname,omitempty"`
+	Name      string        `json:"name"`
+	Status    TestStatus    `json:"status"`
+	Duration  time.Duration `json:"duration"`
+	// Message is the failure, error or skip message
+	Message string `json:"message,omitempty"`
+}
+
+// TestSuite groups the TestCases of a step with the same Suite
+type TestSuite struct {
+	Step     string        `json:"step"`
+	Name     string        `json:"name"`
+	Tests    int           `json:"tests"`
+	Failures int           `json:"failures"`
+	Errors   int           `json:"errors"`
+	Skipped  int           `json:"skipped"`
+	Duration time.Duration `json:"duration"`
+
+	Cases []*TestCase `json:"cases"`
+}
+
+// FlakyTest is a test that flipped between passing
+// and failing on the builds of a Job
+type FlakyTest struct {
+	Step      string `json:"step"`
+	Suite     string `json:"suite"`
+	Classname string `json:"classname,omitempty"`
+	Name      string `json:"name"`
+
+	// Flips is the number of times the test changed
+	// from passing to failing, or the other way around
+	Flips  int `json:"flips"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+
+	LastStatus      TestStatus `json:"last_status"`
+	LastBuildNumber string     `json:"last_build_number"`
+}
+
+// GroupTestSuites groups the cs into TestSuites, in
+// the order they first appear on cs
+func GroupTestSuites(cs []*TestCase) []*TestSuite {
+	var (
+		suites []*TestSuite
+		idx    = make(map[[2]string]*TestSuite)
+	)
+	for _, c := range cs {
+		k := [2]string{c.Step, c.Suite}
+		ts, ok := idx[k]
+		if !ok {
+			ts = &TestSuite{Step: c.Step, Name: c.Suite}
+			idx[k] = ts
+			suites = append(suites, ts)
+		}
+		ts.Tests++
+		ts.Duration += c.Duration
+		switch c.Status {
+		case TestFailed:
+			ts.Failures++
+		case TestError:
+			ts.Errors++
+		case TestSkipped:
+			ts.Skipped++
+		}
+		ts.Cases = append(ts.Cases, c)
+	}
+	return suites
+}
+
+// FlakyTests returns the tests of cs that flipped between passing and
+// failing, cs have to be ordered from the oldest build to the newest.
+// Skipped results are ignored. The tests with more flips go first.
+func FlakyTests(cs []*TestCase) []*FlakyTest {
+	var (
+		flaky []*FlakyTest
+		idx   = make(map[[4]string]*FlakyTest)
+	)
+	for _, c := range cs {
+		if c.Status == TestSkipped {
+			continue
+		}
+		k := [4]string{c.Step, c.Suite, c.Classname, c.Name}
+		ft, ok := idx[k]
+		if !ok {
+			ft = &FlakyTest{Step: c.Step, Suite: c.Suite, Classname: c.Classname, Name: c.Name}
+			idx[k] = ft
+			flaky = append(flaky, ft)
+		}
+		if ft.LastStatus != "" && (ft.LastStatus == TestPassed) != (c.Status == TestPassed) {
+			ft.Flips++
+		}
+		if c.Status == TestPassed {
+			ft.Passed++
+		} else {
+			ft.Failed++
+		}
+		ft.LastStatus = c.Status
+		ft.LastBuildNumber = c.BuildNumber
+	}
+
+	res := flaky[:0]
+	for _, ft := range flaky {
+		if ft.Flips != 0 {
+			res = append(res, ft)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Flips > res[j].Flips
+	})
+	return res
+}
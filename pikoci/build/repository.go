@@ -19,4 +19,12 @@ type Repository interface {
 	FindReadyDownstreamVersion(ctx context.Context, tc, pn string, upstreamJobs []string, downstreamJob string, stepName string, upstreamCount int) (uint32, bool, error)
 	LastBuildAtByPipeline(ctx context.Context, tc string) (map[uint32]time.Time, error)
 	CountRunning(ctx context.Context, tc, pn, jn string) (int, error)
+	FilterStartedByWorker(ctx context.Context, workerID uint32) ([]*WithJob, error)
+	FilterPending(ctx context.Context, tc, pn, jn string) ([]*WithJob, error)
+	AppendLogChunk(ctx context.Context, tc, pn, jn string, buildNumber string, c LogChunk) error
+	FilterLogChunks(ctx context.Context, tc, pn, jn string, buildNumber string, afterID uint32) ([]*LogChunk, error)
+	DeleteLogChunks(ctx context.Context, tc, pn, jn string, buildNumber string, stepIDs []string) error
+	CreateTestCases(ctx context.Context, tc, pn, jn string, buildNumber string, cs []TestCase) error
+	FilterTestCases(ctx context.Context, tc, pn, jn string, buildNumber string) ([]*TestCase, error)
+	FilterJobTestCases(ctx context.Context, tc, pn, jn string, builds int) ([]*TestCase, error)
 }
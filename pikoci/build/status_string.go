@@ -8,11 +8,11 @@ import (
 	"strings"
 )
 
-const _StatusName = "succeededfailedstartedcancelled"
+const _StatusName = "succeededfailedstartedcancelledpendingskipped"
 
-var _StatusIndex = [...]uint8{0, 9, 15, 22, 31}
+var _StatusIndex = [...]uint8{0, 9, 15, 22, 31, 38, 45}
 
-const _StatusLowerName = "succeededfailedstartedcancelled"
+const _StatusLowerName = "succeededfailedstartedcancelledpendingskipped"
 
 func (i Status) String() string {
 	if i < 0 || i >= Status(len(_StatusIndex)-1) {
@@ -29,9 +29,11 @@ func _StatusNoOp() {
 	_ = x[Failed-(1)]
 	_ = x[Started-(2)]
 	_ = x[Cancelled-(3)]
+	_ = x[Pending-(4)]
+	_ = x[Skipped-(5)]
 }
 
-var _StatusValues = []Status{Succeeded, Failed, Started, Cancelled}
+var _StatusValues = []Status{Succeeded, Failed, Started, Cancelled, Pending, Skipped}
 
 var _StatusNameToValueMap = map[string]Status{
 	_StatusName[0:9]:        Succeeded,
@@ -42,6 +44,10 @@ var _StatusNameToValueMap = map[string]Status{
 	_StatusLowerName[15:22]: Started,
 	_StatusName[22:31]:      Cancelled,
 	_StatusLowerName[22:31]: Cancelled,
+	_StatusName[31:38]:      Pending,
+	_StatusLowerName[31:38]: Pending,
+	_StatusName[38:45]:      Skipped,
+	_StatusLowerName[38:45]: Skipped,
 }
 
 var _StatusNames = []string{
@@ -49,6 +55,8 @@ var _StatusNames = []string{
 	_StatusName[9:15],
 	_StatusName[15:22],
 	_StatusName[22:31],
+	_StatusName[31:38],
+	_StatusName[38:45],
 }
 
 // StatusString retrieves an enum value from the enum constants string name.
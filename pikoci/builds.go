@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/queue"
 	"github.com/xescugc/pikoci/pikoci/unitwork"
 	"github.com/xescugc/pikoci/pikoci/utils"
@@ -18,6 +20,10 @@ import (
 
 var ErrConcurrencyLimit = errors.New("concurrency limit reached")
 
+// ErrBuildNotPending is returned when starting a Build that
+// was already started or cancelled while it was queued
+var ErrBuildNotPending = errors.New("build is not pending")
+
 func (q *PikoCI) CreateJobBuild(ctx context.Context, tc, pn, jn string, b build.Build) (*build.Build, error) {
 	if !utils.ValidateCanonical(tc) {
 		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
@@ -28,8 +34,17 @@ func (q *PikoCI) CreateJobBuild(ctx context.Context, tc, pn, jn string, b build.
 	}
 
 	err := q.StartUoW(ctx, func(uow unitwork.UnitOfWork) error {
-		if err := checkConcurrencyLimit(ctx, uow, tc, pn, jn); err != nil {
-			return err
+		// The Pending Builds are only limited once they are started
+		var j *job.Job
+		if b.Status != build.Pending {
+			var err error
+			j, err = uow.Jobs().Find(ctx, tc, pn, jn)
+			if err != nil {
+				return fmt.Errorf("failed to find job: %w", err)
+			}
+			if err := checkConcurrencyLimit(ctx, uow, tc, pn, jn, j, 0); err != nil {
+				return err
+			}
 		}
 
 		id, buildNumber, err := uow.Builds().Create(ctx, tc, pn, jn, b)
@@ -39,6 +54,9 @@ func (q *PikoCI) CreateJobBuild(ctx context.Context, tc, pn, jn string, b build.
 
 		b.ID = id
 		b.BuildNumber = buildNumber
+		if j != nil {
+			return acquireSerialGroups(ctx, uow, tc, pn, jn, j, b)
+		}
 		return nil
 	})
 	if err != nil {
@@ -96,12 +114,20 @@ func (q *PikoCI) CancelJobBuild(ctx context.Context, tc, pn, jn string, buildNum
 	if err != nil {
 		return fmt.Errorf("failed to Find Build: %w", err)
 	}
-	if b.Status != build.Started {
+	if b.Status.Finished() {
 		return fmt.Errorf("build %s is not running (status: %s)", buildNumber, b.Status)
 	}
+	// The Pending Builds never started so they have no Duration,
+	// the worker drops their message once it receives it
+	if b.Status == build.Started {
+		b.Duration = time.Since(b.StartedAt)
+	}
 	b.Status = build.Cancelled
-	b.Duration = time.Since(b.StartedAt)
-	return q.Builds.Update(ctx, tc, pn, jn, buildNumber, *b)
+	if err := q.Builds.Update(ctx, tc, pn, jn, buildNumber, *b); err != nil {
+		return err
+	}
+
+	return q.releaseBuildLocks(ctx, b.ID)
 }
 
 func (q *PikoCI) UpdateJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string, b build.Build) error {
@@ -119,7 +145,7 @@ func (q *PikoCI) UpdateJobBuild(ctx context.Context, tc, pn, jn string, buildNum
 		b.Status = build.Cancelled
 	}
 
-	if b.Status != build.Started && b.Duration == 0 {
+	if b.Status.Finished() && b.Duration == 0 {
 		b.Duration = time.Since(b.StartedAt)
 	}
 
@@ -127,9 +153,252 @@ func (q *PikoCI) UpdateJobBuild(ctx context.Context, tc, pn, jn string, buildNum
 		return fmt.Errorf("failed to Update Build: %w", err)
 	}
 
+	// The log chunks are only needed while the Steps
+	// run, once they finish the Build has their Logs
+	if b.Status.Finished() {
+		err = q.Builds.DeleteLogChunks(ctx, tc, pn, jn, buildNumber, nil)
+	} else if ids := finishedStepIDs(b); len(ids) != 0 {
+		err = q.Builds.DeleteLogChunks(ctx, tc, pn, jn, buildNumber, ids)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to Delete Log Chunks: %w", err)
+	}
+
+	if b.Status.Finished() {
+		id := b.ID
+		if existing != nil {
+			id = existing.ID
+		}
+		return q.releaseBuildLocks(ctx, id)
+	}
+
 	return nil
 }
 
+// finishedStepIDs returns the IDs of the Steps of b that are not running
+func finishedStepIDs(b build.Build) []string {
+	var ids []string
+	for _, s := range b.Steps {
+		if s.ID != "" && s.Status != build.Started {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+func (q *PikoCI) AppendJobBuildLogs(ctx context.Context, tc, pn, jn string, buildNumber string, c build.LogChunk) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return fmt.Errorf("invalid Job Name format %q", jn)
+	} else if c.StepID == "" {
+		return fmt.Errorf("the log chunk has no Step ID")
+	} else if c.Offset < 0 {
+		return fmt.Errorf("invalid log chunk Offset %d", c.Offset)
+	}
+
+	if err := q.Builds.AppendLogChunk(ctx, tc, pn, jn, buildNumber, c); err != nil {
+		return fmt.Errorf("failed to Append Log Chunk: %w", err)
+	}
+
+	return nil
+}
+
+// CreateJobBuildTests stores the test cases of the
+// reports uploaded by the steps of the build
+func (q *PikoCI) CreateJobBuildTests(ctx context.Context, tc, pn, jn string, buildNumber string, cs []build.TestCase) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return fmt.Errorf("invalid Job Name format %q", jn)
+	}
+
+	for _, c := range cs {
+		if c.Step == "" || c.Name == "" {
+			return fmt.Errorf("the test case has no Step or Name")
+		}
+		switch c.Status {
+		case build.TestPassed, build.TestFailed, build.TestError, build.TestSkipped:
+		default:
+			return fmt.Errorf("invalid test case Status %q", c.Status)
+		}
+	}
+
+	if err := q.Builds.CreateTestCases(ctx, tc, pn, jn, buildNumber, cs); err != nil {
+		return fmt.Errorf("failed to Create Test Cases: %w", err)
+	}
+
+	return nil
+}
+
+// ListJobBuildTests returns the test suites
+// reported by the steps of the build
+func (q *PikoCI) ListJobBuildTests(ctx context.Context, tc, pn, jn string, buildNumber string) ([]*build.TestSuite, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return nil, fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return nil, fmt.Errorf("invalid Job Name format %q", jn)
+	}
+
+	cs, err := q.Builds.FilterTestCases(ctx, tc, pn, jn, buildNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Test Cases: %w", err)
+	}
+
+	return build.GroupTestSuites(cs), nil
+}
+
+// flakyTestsBuilds is the number of builds, with
+// test reports, ListJobFlakyTests checks
+const flakyTestsBuilds = 20
+
+// ListJobFlakyTests returns the tests that flipped between
+// passing and failing on the last builds of the job
+func (q *PikoCI) ListJobFlakyTests(ctx context.Context, tc, pn, jn string) ([]*build.FlakyTest, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return nil, fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return nil, fmt.Errorf("invalid Job Name format %q", jn)
+	}
+
+	cs, err := q.Builds.FilterJobTestCases(ctx, tc, pn, jn, flakyTestsBuilds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Test Cases: %w", err)
+	}
+
+	return build.FlakyTests(cs), nil
+}
+
+// watchJobBuildInterval is how often WatchJobBuild
+// checks for changes on the build
+var watchJobBuildInterval = time.Second
+
+// WatchJobBuild returns the Events of the build, first an EventStatus
+// with the current state and then the logs appended and the status
+// changes until the build finishes or ctx is done, when the channel
+// is closed. The EventStatus have no Logs on the Steps that stream
+// them, which are sent as EventLog, so for a finished build only
+// the EventStatus and the logs of its Steps are sent.
+func (q *PikoCI) WatchJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string) (<-chan build.Event, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return nil, fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return nil, fmt.Errorf("invalid Job Name format %q", jn)
+	}
+
+	b, err := q.Builds.Find(ctx, tc, pn, jn, buildNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Find Build: %w", err)
+	}
+
+	events := make(chan build.Event)
+	go q.watchJobBuild(ctx, tc, pn, jn, buildNumber, b, events)
+
+	return events, nil
+}
+
+func (q *PikoCI) watchJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string, b *build.Build, events chan<- build.Event) {
+	defer close(events)
+
+	send := func(e build.Event) bool {
+		select {
+		case events <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// sent is the offset of the logs already sent of each Step
+	sent := make(map[string]int)
+
+	// sendLogs sends the logs of the finished Steps of b not sent yet,
+	// as their log chunks are deleted once they finish
+	sendLogs := func(b *build.Build) bool {
+		for _, s := range b.Steps {
+			if s.ID == "" || s.Status == build.Started || len(s.Logs) <= sent[s.ID] {
+				continue
+			}
+			if !send(build.Event{Type: build.EventLog, Log: &build.LogChunk{StepID: s.ID, Offset: sent[s.ID], Data: s.Logs[sent[s.ID]:]}}) {
+				return false
+			}
+			sent[s.ID] = len(s.Logs)
+		}
+		return true
+	}
+
+	if !send(build.Event{Type: build.EventStatus, Build: withoutStepLogs(b)}) || !sendLogs(b) {
+		return
+	}
+
+	ticker := time.NewTicker(watchJobBuildInterval)
+	defer ticker.Stop()
+
+	var lastID uint32
+	for !b.Status.Finished() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		// The build is fetched before the chunks so all the logs
+		// appended before it finished are sent before returning
+		nb, err := q.Builds.Find(ctx, tc, pn, jn, buildNumber)
+		if err != nil {
+			return
+		}
+
+		cs, err := q.Builds.FilterLogChunks(ctx, tc, pn, jn, buildNumber, lastID)
+		if err != nil {
+			return
+		}
+		for _, c := range cs {
+			if !send(build.Event{Type: build.EventLog, Log: c}) {
+				return
+			}
+			lastID = c.ID
+			if end := c.Offset + len(c.Data); end > sent[c.StepID] {
+				sent[c.StepID] = end
+			}
+		}
+
+		if nb.StatusChanged(*b) {
+			if !send(build.Event{Type: build.EventStatus, Build: withoutStepLogs(nb)}) {
+				return
+			}
+		}
+		if !sendLogs(nb) {
+			return
+		}
+		b = nb
+	}
+}
+
+// withoutStepLogs returns a copy of b without the Logs of
+// the Steps that stream them, which have an ID
+func withoutStepLogs(b *build.Build) *build.Build {
+	nb := *b
+	nb.Steps = make([]build.Step, len(b.Steps))
+	for i, s := range b.Steps {
+		if s.ID != "" {
+			s.Logs = ""
+		}
+		nb.Steps[i] = s
+	}
+	return &nb
+}
+
 func (q *PikoCI) InsertBuildGetVersion(ctx context.Context, tc, pn, jn string, buildID uint32, stepName string, versionID uint32) error {
 	return q.Builds.InsertGetVersion(ctx, tc, pn, jn, buildID, stepName, versionID)
 }
@@ -164,7 +433,7 @@ func (q *PikoCI) RetryJobBuild(ctx context.Context, tc, pn, jn, buildNumber stri
 	if err != nil {
 		return fmt.Errorf("failed to Find Build: %w", err)
 	}
-	if b.Status == build.Started {
+	if !b.Status.Finished() {
 		return fmt.Errorf("build %s is still running", buildNumber)
 	}
 
@@ -186,12 +455,45 @@ func (q *PikoCI) RetryJobBuild(ctx context.Context, tc, pn, jn, buildNumber stri
 		retryBuildID = parentBuild.ID
 	}
 
+	j, err := q.Jobs.Find(ctx, tc, pn, jn)
+	if err != nil {
+		return fmt.Errorf("failed to Find Job %q on Pipeline %q: %w", jn, pn, err)
+	}
+
 	m := queue.Body{
 		TeamCanonical:    tc,
 		PipelineName:     pn,
 		JobName:          jn,
 		RetryBuildNumber: parentBN,
 		RetryBuildID:     retryBuildID,
+		Tags:             j.Tags,
+	}
+
+	err = q.enqueueJobBuild(ctx, m)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue retry for Build %q: %w", buildNumber, err)
+	}
+
+	return nil
+}
+
+// enqueueJobBuild creates the Pending Build of the message m and
+// sends m to the queue with it, so a worker starts the Build
+func (q *PikoCI) enqueueJobBuild(ctx context.Context, m queue.Body) error {
+	b := build.Build{
+		Status:    build.Pending,
+		Steps:     []build.Step{},
+		StartedAt: time.Now().Round(0),
+	}
+
+	var err error
+	if m.RetryBuildNumber != "" {
+		_, m.BuildNumber, err = q.Builds.CreateRetry(ctx, m.TeamCanonical, m.PipelineName, m.JobName, m.RetryBuildNumber, b)
+	} else {
+		_, m.BuildNumber, err = q.Builds.Create(ctx, m.TeamCanonical, m.PipelineName, m.JobName, b)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to Create Build: %w", err)
 	}
 
 	mb, err := json.Marshal(m)
@@ -203,13 +505,22 @@ func (q *PikoCI) RetryJobBuild(ctx context.Context, tc, pn, jn, buildNumber stri
 		Body: mb,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to enqueue retry for Build %q: %w", buildNumber, err)
+		// Without its message nothing would ever start the Build
+		if derr := q.Builds.Delete(ctx, m.TeamCanonical, m.PipelineName, m.JobName, m.BuildNumber); derr != nil {
+			q.logger.Error("failed to Delete Pending Build", "build_number", m.BuildNumber, "error", derr)
+		}
+		return err
 	}
 
 	return nil
 }
 
-func (q *PikoCI) CreateRetryJobBuild(ctx context.Context, tc, pn, jn, parentBuildNumber string, b build.Build) (*build.Build, error) {
+// StartJobBuild starts the Pending Build buildNumber with b. It returns
+// ErrBuildNotPending if it's no longer Pending, ErrConcurrencyLimit
+// if the Job has no free slot for it and lock.ErrHeld if one of the
+// serial groups of the Job is held. The Pending Builds of a Job with
+// a concurrency limit are started in the order they were queued.
+func (q *PikoCI) StartJobBuild(ctx context.Context, tc, pn, jn, buildNumber string, b build.Build) (*build.Build, error) {
 	if !utils.ValidateCanonical(tc) {
 		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
 	} else if !utils.ValidateCanonical(pn) {
@@ -219,9 +530,137 @@ func (q *PikoCI) CreateRetryJobBuild(ctx context.Context, tc, pn, jn, parentBuil
 	}
 
 	err := q.StartUoW(ctx, func(uow unitwork.UnitOfWork) error {
-		if err := checkConcurrencyLimit(ctx, uow, tc, pn, jn); err != nil {
+		pb, err := uow.Builds().Find(ctx, tc, pn, jn, buildNumber)
+		if err != nil {
+			return fmt.Errorf("failed to Find Build: %w", err)
+		}
+		if pb.Status != build.Pending {
+			return ErrBuildNotPending
+		}
+
+		j, err := uow.Jobs().Find(ctx, tc, pn, jn)
+		if err != nil {
+			return fmt.Errorf("failed to find job: %w", err)
+		}
+		if err := checkConcurrencyLimit(ctx, uow, tc, pn, jn, j, pb.ID); err != nil {
+			return err
+		}
+
+		b.ID = pb.ID
+		b.BuildNumber = pb.BuildNumber
+		b.Status = build.Started
+		if err := acquireSerialGroups(ctx, uow, tc, pn, jn, j, b); err != nil {
 			return err
 		}
+		if err := uow.Builds().Update(ctx, tc, pn, jn, buildNumber, b); err != nil {
+			return fmt.Errorf("failed to Update Build: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// ListQueuedBuilds returns the Pending Builds of the Team, or of the Pipeline pn
+// or the Job jn if set, in the order they were queued with the reason they
+// are not running yet
+func (q *PikoCI) ListQueuedBuilds(ctx context.Context, tc, pn, jn string) ([]*build.Queued, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if pn != "" && !utils.ValidateCanonical(pn) {
+		return nil, fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if jn != "" && (pn == "" || !utils.ValidateCanonical(jn)) {
+		return nil, fmt.Errorf("invalid Job Name format %q", jn)
+	}
+
+	bs, err := q.Builds.FilterPending(ctx, tc, pn, jn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Filter Pending Builds: %w", err)
+	}
+
+	// jobQueue is the Job of the Builds with the number of
+	// slots taken by the running and the older Pending Builds
+	type jobQueue struct {
+		job   *job.Job
+		taken int
+	}
+	jobs := make(map[string]*jobQueue)
+
+	// The Team locks are only needed if a Job has serial groups
+	var held map[string]bool
+
+	qbs := make([]*build.Queued, 0, len(bs))
+	for _, b := range bs {
+		key := b.PipelineName + "/" + b.JobName
+		jq, ok := jobs[key]
+		if !ok {
+			j, err := q.Jobs.Find(ctx, tc, b.PipelineName, b.JobName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to Find Job %q on Pipeline %q: %w", b.JobName, b.PipelineName, err)
+			}
+			if err := q.setJobWaiting(ctx, j); err != nil {
+				return nil, err
+			}
+			jq = &jobQueue{job: j}
+			if j.Concurrency > 0 {
+				jq.taken, err = q.Builds.CountRunning(ctx, tc, b.PipelineName, b.JobName)
+				if err != nil {
+					return nil, fmt.Errorf("failed to Count Running Builds of Job %q: %w", b.JobName, err)
+				}
+			}
+			if len(j.SerialGroups) != 0 && held == nil {
+				ls, err := q.Locks.Filter(ctx, tc)
+				if err != nil {
+					return nil, fmt.Errorf("failed to Filter Locks: %w", err)
+				}
+				held = make(map[string]bool, len(ls))
+				for _, l := range ls {
+					held[l.Name] = true
+				}
+			}
+			jobs[key] = jq
+		}
+
+		reason := build.ReasonQueued
+		if jq.job.Waiting {
+			reason = build.ReasonNoWorker
+		} else if jq.job.Concurrency > 0 && jq.taken >= jq.job.Concurrency {
+			reason = build.ReasonConcurrency
+		} else if slices.ContainsFunc(jq.job.SerialGroups, func(sg string) bool { return held[sg] }) {
+			reason = build.ReasonLock
+		}
+		jq.taken++
+
+		qbs = append(qbs, &build.Queued{WithJob: *b, Reason: reason})
+	}
+
+	return qbs, nil
+}
+
+func (q *PikoCI) CreateRetryJobBuild(ctx context.Context, tc, pn, jn, parentBuildNumber string, b build.Build) (*build.Build, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return nil, fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return nil, fmt.Errorf("invalid Job Name format %q", jn)
+	}
+
+	err := q.StartUoW(ctx, func(uow unitwork.UnitOfWork) error {
+		var j *job.Job
+		if b.Status != build.Pending {
+			var err error
+			j, err = uow.Jobs().Find(ctx, tc, pn, jn)
+			if err != nil {
+				return fmt.Errorf("failed to find job: %w", err)
+			}
+			if err := checkConcurrencyLimit(ctx, uow, tc, pn, jn, j, 0); err != nil {
+				return err
+			}
+		}
 
 		id, buildNumber, err := uow.Builds().CreateRetry(ctx, tc, pn, jn, parentBuildNumber, b)
 		if err != nil {
@@ -230,6 +669,9 @@ func (q *PikoCI) CreateRetryJobBuild(ctx context.Context, tc, pn, jn, parentBuil
 
 		b.ID = id
 		b.BuildNumber = buildNumber
+		if j != nil {
+			return acquireSerialGroups(ctx, uow, tc, pn, jn, j, b)
+		}
 		return nil
 	})
 	if err != nil {
@@ -251,19 +693,68 @@ func (q *PikoCI) FindBuildGetVersions(ctx context.Context, tc, pn, jn string, bu
 	return q.Builds.FindGetVersions(ctx, buildID)
 }
 
-func checkConcurrencyLimit(ctx context.Context, uow unitwork.UnitOfWork, tc, pn, jn string) error {
-	j, err := uow.Jobs().Find(ctx, tc, pn, jn)
-	if err != nil {
-		return fmt.Errorf("failed to find job: %w", err)
-	}
+// checkConcurrencyLimit returns ErrConcurrencyLimit if the Job j has no free
+// slot. If pendingID is set the slots are first kept for the Pending Builds
+// queued before it, so they are started in the order they were queued.
+func checkConcurrencyLimit(ctx context.Context, uow unitwork.UnitOfWork, tc, pn, jn string, j *job.Job, pendingID uint32) error {
 	if j.Concurrency > 0 {
 		running, err := uow.Builds().CountRunning(ctx, tc, pn, jn)
 		if err != nil {
 			return fmt.Errorf("failed to count running builds: %w", err)
 		}
+		if pendingID != 0 {
+			pbs, err := uow.Builds().FilterPending(ctx, tc, pn, jn)
+			if err != nil {
+				return fmt.Errorf("failed to filter pending builds: %w", err)
+			}
+			for _, pb := range pbs {
+				if pb.ID < pendingID {
+					running++
+				}
+			}
+		}
 		if running >= j.Concurrency {
 			return ErrConcurrencyLimit
 		}
 	}
 	return nil
 }
+
+// acquireSerialGroups acquires the Team locks of the serial groups of the
+// Job j for the Build b, it returns lock.ErrHeld if any of them is held by
+// another Build or user. The locks are released once the Build finishes.
+func acquireSerialGroups(ctx context.Context, uow unitwork.UnitOfWork, tc, pn, jn string, j *job.Job, b build.Build) error {
+	for _, sg := range j.SerialGroups {
+		l, err := uow.Locks().Find(ctx, tc, sg)
+		if err == nil {
+			if l.BuildID == b.ID {
+				continue
+			}
+			return lock.ErrHeld
+		} else if !errors.Is(err, lock.ErrNotFound) {
+			return fmt.Errorf("failed to find lock %q: %w", sg, err)
+		}
+
+		_, err = uow.Locks().Create(ctx, tc, lock.Lock{
+			Name:       sg,
+			Holder:     fmt.Sprintf("%s/%s #%s", pn, jn, b.BuildNumber),
+			BuildID:    b.ID,
+			AcquiredAt: time.Now().UTC(),
+		})
+		if err != nil {
+			if errors.Is(err, lock.ErrHeld) {
+				return err
+			}
+			return fmt.Errorf("failed to create lock %q: %w", sg, err)
+		}
+	}
+	return nil
+}
+
+// releaseBuildLocks releases the locks of the serial groups held by the Build id
+func (q *PikoCI) releaseBuildLocks(ctx context.Context, id uint32) error {
+	if err := q.Locks.DeleteByBuild(ctx, id); err != nil {
+		return fmt.Errorf("failed to release the Locks of the Build: %w", err)
+	}
+	return nil
+}
@@ -1,14 +1,22 @@
 package pikoci_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/artifact"
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
+	"github.com/xescugc/pikoci/pikoci/registry"
 	"go.uber.org/mock/gomock"
 	"gocloud.dev/pubsub"
 )
@@ -66,13 +74,208 @@ func TestUpdateJobBuild(t *testing.T) {
 	s := newService(ctrl)
 	ctx := context.TODO()
 
-	s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(&build.Build{Status: build.Started}, nil)
+	s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(&build.Build{ID: 3, Status: build.Started}, nil)
 	s.Builds.EXPECT().Update(ctx, "main", "my-pipeline", "my-job", "1", gomock.Any()).Return(nil)
+	// The finished Builds delete all their log chunks and
+	// release the locks of their serial groups
+	s.Builds.EXPECT().DeleteLogChunks(ctx, "main", "my-pipeline", "my-job", "1", nil).Return(nil)
+	s.Locks.EXPECT().DeleteByBuild(ctx, uint32(3)).Return(nil)
 
 	err := s.S.UpdateJobBuild(ctx, "main", "my-pipeline", "my-job", "1", build.Build{Status: build.Succeeded})
 	require.NoError(t, err)
 }
 
+func TestUpdateJobBuild_FinishedSteps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	b := build.Build{ID: 3, Status: build.Started, Steps: []build.Step{
+		{Type: "cache-restore", Status: build.Succeeded},
+		{ID: "s1", Status: build.Succeeded, Logs: "hello"},
+		{ID: "s2", Status: build.Started},
+	}}
+	s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(&build.Build{ID: 3, Status: build.Started}, nil)
+	s.Builds.EXPECT().Update(ctx, "main", "my-pipeline", "my-job", "1", b).Return(nil)
+	// Only the log chunks of the finished Steps are deleted
+	s.Builds.EXPECT().DeleteLogChunks(ctx, "main", "my-pipeline", "my-job", "1", []string{"s1"}).Return(nil)
+
+	err := s.S.UpdateJobBuild(ctx, "main", "my-pipeline", "my-job", "1", b)
+	require.NoError(t, err)
+}
+
+func TestAppendJobBuildLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	c := build.LogChunk{StepID: "step", Offset: 5, Data: "world"}
+	s.Builds.EXPECT().AppendLogChunk(ctx, "main", "my-pipeline", "my-job", "1", c).Return(nil)
+
+	err := s.S.AppendJobBuildLogs(ctx, "main", "my-pipeline", "my-job", "1", c)
+	require.NoError(t, err)
+
+	err = s.S.AppendJobBuildLogs(ctx, "main", "my-pipeline", "my-job", "1", build.LogChunk{Data: "world"})
+	require.Error(t, err)
+
+	err = s.S.AppendJobBuildLogs(ctx, "main", "my-pipeline", "my-job", "1", build.LogChunk{StepID: "step", Offset: -1})
+	require.Error(t, err)
+}
+
+func TestCreateJobBuildTests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	cs := []build.TestCase{{Step: "test", Suite: "pkg", Name: "TestA", Status: build.TestPassed}}
+	s.Builds.EXPECT().CreateTestCases(ctx, "main", "my-pipeline", "my-job", "1", cs).Return(nil)
+
+	err := s.S.CreateJobBuildTests(ctx, "main", "my-pipeline", "my-job", "1", cs)
+	require.NoError(t, err)
+
+	err = s.S.CreateJobBuildTests(ctx, "main", "my-pipeline", "my-job", "1", []build.TestCase{{Step: "test", Status: build.TestPassed}})
+	require.Error(t, err)
+
+	err = s.S.CreateJobBuildTests(ctx, "main", "my-pipeline", "my-job", "1", []build.TestCase{{Step: "test", Name: "TestA", Status: "unknown"}})
+	require.Error(t, err)
+}
+
+func TestListJobBuildTests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	a := &build.TestCase{Step: "test", Suite: "pkg/a", Name: "TestA", Status: build.TestPassed, Duration: time.Second}
+	b := &build.TestCase{Step: "test", Suite: "pkg/b", Name: "TestB", Status: build.TestFailed, Duration: time.Second}
+	c := &build.TestCase{Step: "test", Suite: "pkg/a", Name: "TestC", Status: build.TestSkipped}
+	d := &build.TestCase{Step: "lint", Suite: "pkg/a", Name: "TestD", Status: build.TestError}
+	s.Builds.EXPECT().FilterTestCases(ctx, "main", "my-pipeline", "my-job", "1").Return([]*build.TestCase{a, b, c, d}, nil)
+
+	suites, err := s.S.ListJobBuildTests(ctx, "main", "my-pipeline", "my-job", "1")
+	require.NoError(t, err)
+	assert.Equal(t, []*build.TestSuite{
+		{Step: "test", Name: "pkg/a", Tests: 2, Skipped: 1, Duration: time.Second, Cases: []*build.TestCase{a, c}},
+		{Step: "test", Name: "pkg/b", Tests: 1, Failures: 1, Duration: time.Second, Cases: []*build.TestCase{b}},
+		{Step: "lint", Name: "pkg/a", Tests: 1, Errors: 1, Cases: []*build.TestCase{d}},
+	}, suites)
+}
+
+func TestListJobFlakyTests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	tc := func(bn, name string, st build.TestStatus) *build.TestCase {
+		return &build.TestCase{BuildNumber: bn, Step: "test", Suite: "pkg", Name: name, Status: st}
+	}
+	s.Builds.EXPECT().FilterJobTestCases(ctx, "main", "my-pipeline", "my-job", 20).Return([]*build.TestCase{
+		tc("1", "TestStable", build.TestPassed),
+		tc("1", "TestFlip", build.TestPassed),
+		tc("1", "TestOften", build.TestFailed),
+		tc("2", "TestStable", build.TestPassed),
+		tc("2", "TestFlip", build.TestError),
+		tc("2", "TestOften", build.TestPassed),
+		tc("3", "TestStable", build.TestSkipped),
+		tc("3", "TestFlip", build.TestFailed),
+		tc("3", "TestOften", build.TestFailed),
+	}, nil)
+
+	flaky, err := s.S.ListJobFlakyTests(ctx, "main", "my-pipeline", "my-job")
+	require.NoError(t, err)
+	assert.Equal(t, []*build.FlakyTest{
+		{Step: "test", Suite: "pkg", Name: "TestOften", Flips: 2, Passed: 1, Failed: 2, LastStatus: build.TestFailed, LastBuildNumber: "3"},
+		{Step: "test", Suite: "pkg", Name: "TestFlip", Flips: 1, Passed: 1, Failed: 2, LastStatus: build.TestFailed, LastBuildNumber: "3"},
+	}, flaky)
+}
+
+func TestWatchJobBuild(t *testing.T) {
+	collect := func(events <-chan build.Event) []build.Event {
+		var es []build.Event
+		for e := range events {
+			es = append(es, e)
+		}
+		return es
+	}
+
+	t.Run("Running", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		running := &build.Build{BuildNumber: "1", Status: build.Started, Steps: []build.Step{{ID: "s1", Status: build.Started}}}
+		stillRunning := &build.Build{BuildNumber: "1", Status: build.Started, Steps: []build.Step{{ID: "s1", Status: build.Started}}}
+		finished := &build.Build{BuildNumber: "1", Status: build.Succeeded, Steps: []build.Step{{ID: "s1", Status: build.Succeeded, Logs: "hello world!"}}}
+		c1 := &build.LogChunk{ID: 1, StepID: "s1", Offset: 0, Data: "hello "}
+		c2 := &build.LogChunk{ID: 2, StepID: "s1", Offset: 6, Data: "world"}
+
+		gomock.InOrder(
+			s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(running, nil),
+			s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(stillRunning, nil),
+			s.Builds.EXPECT().FilterLogChunks(ctx, "main", "my-pipeline", "my-job", "1", uint32(0)).Return([]*build.LogChunk{c1}, nil),
+			s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(finished, nil),
+			s.Builds.EXPECT().FilterLogChunks(ctx, "main", "my-pipeline", "my-job", "1", uint32(1)).Return([]*build.LogChunk{c2}, nil),
+		)
+
+		events, err := s.S.WatchJobBuild(ctx, "main", "my-pipeline", "my-job", "1")
+		require.NoError(t, err)
+
+		// The status is only sent again when it changes, without the logs of the
+		// Steps, and the ones of the finished Steps not on the chunks are sent after
+		assert.Equal(t, []build.Event{
+			{Type: build.EventStatus, Build: running},
+			{Type: build.EventLog, Log: c1},
+			{Type: build.EventLog, Log: c2},
+			{Type: build.EventStatus, Build: &build.Build{BuildNumber: "1", Status: build.Succeeded, Steps: []build.Step{{ID: "s1", Status: build.Succeeded}}}},
+			{Type: build.EventLog, Log: &build.LogChunk{StepID: "s1", Offset: 11, Data: "!"}},
+		}, collect(events))
+	})
+	t.Run("Finished", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		finished := &build.Build{BuildNumber: "1", Status: build.Failed, Steps: []build.Step{
+			{Type: "cache-restore", Status: build.Succeeded, Logs: "restored"},
+			{ID: "s1", Status: build.Failed, Logs: "failed"},
+		}}
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(finished, nil)
+
+		events, err := s.S.WatchJobBuild(ctx, "main", "my-pipeline", "my-job", "1")
+		require.NoError(t, err)
+		assert.Equal(t, []build.Event{
+			{Type: build.EventStatus, Build: &build.Build{BuildNumber: "1", Status: build.Failed, Steps: []build.Step{
+				{Type: "cache-restore", Status: build.Succeeded, Logs: "restored"},
+				{ID: "s1", Status: build.Failed},
+			}}},
+			{Type: build.EventLog, Log: &build.LogChunk{StepID: "s1", Data: "failed"}},
+		}, collect(events))
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx, cancel := context.WithCancel(context.TODO())
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(&build.Build{Status: build.Started}, nil)
+
+		events, err := s.S.WatchJobBuild(ctx, "main", "my-pipeline", "my-job", "1")
+		require.NoError(t, err)
+		<-events
+		cancel()
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(nil, errors.New("not found"))
+
+		_, err := s.S.WatchJobBuild(ctx, "main", "my-pipeline", "my-job", "1")
+		require.Error(t, err)
+	})
+}
+
 func TestDeleteJobBuild(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
@@ -91,9 +294,18 @@ func TestRetryJobBuild_BaseBuild(t *testing.T) {
 
 	s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "3").
 		Return(&build.Build{ID: 5, BuildNumber: "3", Status: build.Succeeded}, nil)
+	s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "my-job").
+		Return(&job.Job{Name: "my-job", Tags: []string{"docker"}}, nil)
+	s.Builds.EXPECT().CreateRetry(ctx, "main", "my-pipeline", "my-job", "3", gomock.Any()).
+		DoAndReturn(func(_ context.Context, tc, pn, jn, pbn string, b build.Build) (uint32, string, error) {
+			assert.Equal(t, build.Pending, b.Status)
+			return 8, "3.1", nil
+		})
 	s.Topic.EXPECT().Send(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, msg *pubsub.Message) error {
 		assert.Contains(t, string(msg.Body), `"retry_build_number":"3"`)
 		assert.Contains(t, string(msg.Body), `"retry_build_id":5`)
+		assert.Contains(t, string(msg.Body), `"build_number":"3.1"`)
+		assert.Contains(t, string(msg.Body), `"tags":["docker"]`)
 		return nil
 	})
 
@@ -111,6 +323,8 @@ func TestRetryJobBuild_RetryOfRetry(t *testing.T) {
 		Return(&build.Build{ID: 7, BuildNumber: "3.1", Status: build.Failed}, nil)
 	s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "3").
 		Return(&build.Build{ID: 5, BuildNumber: "3", Status: build.Succeeded}, nil)
+	s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "my-job").Return(&job.Job{Name: "my-job"}, nil)
+	s.Builds.EXPECT().CreateRetry(ctx, "main", "my-pipeline", "my-job", "3", gomock.Any()).Return(uint32(9), "3.2", nil)
 	s.Topic.EXPECT().Send(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, msg *pubsub.Message) error {
 		assert.Contains(t, string(msg.Body), `"retry_build_number":"3"`)
 		// Should use parent build ID (5), not the retry build ID (7)
@@ -188,6 +402,191 @@ func TestCreateRetryJobBuild_ConcurrencyLimit(t *testing.T) {
 	require.ErrorIs(t, err, pikoci.ErrConcurrencyLimit)
 }
 
+func TestCreateJobBuild_Pending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	// The concurrency limit is only checked once the Pending Build is started
+	s.Builds.EXPECT().Create(ctx, "main", "my-pipeline", "my-job", gomock.Any()).Return(uint32(1), "1", nil)
+
+	b, err := s.S.CreateJobBuild(ctx, "main", "my-pipeline", "my-job", build.Build{Status: build.Pending})
+	require.NoError(t, err)
+	assert.Equal(t, "1", b.BuildNumber)
+}
+
+func TestStartJobBuild(t *testing.T) {
+	pending := func(id uint32, bn string) *build.WithJob {
+		return &build.WithJob{Build: build.Build{ID: id, BuildNumber: bn, Status: build.Pending}}
+	}
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "2").Return(&build.Build{ID: 5, BuildNumber: "2", Status: build.Pending}, nil)
+		s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "my-job").Return(&job.Job{Name: "my-job"}, nil)
+		s.Builds.EXPECT().Update(ctx, "main", "my-pipeline", "my-job", "2", gomock.Any()).DoAndReturn(func(_ context.Context, tc, pn, jn, bn string, b build.Build) error {
+			assert.Equal(t, build.Started, b.Status)
+			assert.Equal(t, uint32(3), b.WorkerID)
+			return nil
+		})
+
+		b, err := s.S.StartJobBuild(ctx, "main", "my-pipeline", "my-job", "2", build.Build{WorkerID: 3})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(5), b.ID)
+		assert.Equal(t, "2", b.BuildNumber)
+		assert.Equal(t, build.Started, b.Status)
+	})
+	t.Run("NotPending", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "2").Return(&build.Build{ID: 5, BuildNumber: "2", Status: build.Cancelled}, nil)
+
+		_, err := s.S.StartJobBuild(ctx, "main", "my-pipeline", "my-job", "2", build.Build{})
+		require.ErrorIs(t, err, pikoci.ErrBuildNotPending)
+	})
+	t.Run("OlderPendingFirst", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		// The free slot is kept for the Build queued before
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "3").Return(&build.Build{ID: 6, BuildNumber: "3", Status: build.Pending}, nil)
+		s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "my-job").Return(&job.Job{Name: "my-job", Concurrency: 2}, nil)
+		s.Builds.EXPECT().CountRunning(ctx, "main", "my-pipeline", "my-job").Return(1, nil)
+		s.Builds.EXPECT().FilterPending(ctx, "main", "my-pipeline", "my-job").Return([]*build.WithJob{pending(5, "2"), pending(6, "3")}, nil)
+
+		_, err := s.S.StartJobBuild(ctx, "main", "my-pipeline", "my-job", "3", build.Build{})
+		require.ErrorIs(t, err, pikoci.ErrConcurrencyLimit)
+	})
+	t.Run("OldestPending", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "2").Return(&build.Build{ID: 5, BuildNumber: "2", Status: build.Pending}, nil)
+		s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "my-job").Return(&job.Job{Name: "my-job", Concurrency: 2}, nil)
+		s.Builds.EXPECT().CountRunning(ctx, "main", "my-pipeline", "my-job").Return(1, nil)
+		s.Builds.EXPECT().FilterPending(ctx, "main", "my-pipeline", "my-job").Return([]*build.WithJob{pending(5, "2"), pending(6, "3")}, nil)
+		s.Builds.EXPECT().Update(ctx, "main", "my-pipeline", "my-job", "2", gomock.Any()).Return(nil)
+
+		_, err := s.S.StartJobBuild(ctx, "main", "my-pipeline", "my-job", "2", build.Build{})
+		require.NoError(t, err)
+	})
+	t.Run("SerialGroups", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "deploy", "2").Return(&build.Build{ID: 5, BuildNumber: "2", Status: build.Pending}, nil)
+		s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "deploy").Return(&job.Job{Name: "deploy", SerialGroups: []string{"staging", "db"}}, nil)
+		// A lock already held by the same Build is kept
+		s.Locks.EXPECT().Find(ctx, "main", "staging").Return(&lock.Lock{Name: "staging", BuildID: 5}, nil)
+		s.Locks.EXPECT().Find(ctx, "main", "db").Return(nil, lock.ErrNotFound)
+		s.Locks.EXPECT().Create(ctx, "main", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, l lock.Lock) (uint32, error) {
+			assert.Equal(t, "db", l.Name)
+			assert.Equal(t, "my-pipeline/deploy #2", l.Holder)
+			assert.Equal(t, uint32(5), l.BuildID)
+			return 1, nil
+		})
+		s.Builds.EXPECT().Update(ctx, "main", "my-pipeline", "deploy", "2", gomock.Any()).Return(nil)
+
+		_, err := s.S.StartJobBuild(ctx, "main", "my-pipeline", "deploy", "2", build.Build{})
+		require.NoError(t, err)
+	})
+	t.Run("LockHeld", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "deploy", "2").Return(&build.Build{ID: 5, BuildNumber: "2", Status: build.Pending}, nil)
+		s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "deploy").Return(&job.Job{Name: "deploy", SerialGroups: []string{"staging"}}, nil)
+		s.Locks.EXPECT().Find(ctx, "main", "staging").Return(&lock.Lock{Name: "staging", Holder: "other/deploy #7", BuildID: 9}, nil)
+
+		_, err := s.S.StartJobBuild(ctx, "main", "my-pipeline", "deploy", "2", build.Build{})
+		require.ErrorIs(t, err, lock.ErrHeld)
+	})
+}
+
+func TestCancelJobBuild(t *testing.T) {
+	t.Run("Pending", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "2").
+			Return(&build.Build{ID: 5, BuildNumber: "2", Status: build.Pending, StartedAt: time.Now().Add(-time.Hour)}, nil)
+		s.Builds.EXPECT().Update(ctx, "main", "my-pipeline", "my-job", "2", gomock.Any()).DoAndReturn(func(_ context.Context, tc, pn, jn, bn string, b build.Build) error {
+			assert.Equal(t, build.Cancelled, b.Status)
+			assert.Zero(t, b.Duration)
+			return nil
+		})
+		s.Locks.EXPECT().DeleteByBuild(ctx, uint32(5)).Return(nil)
+
+		err := s.S.CancelJobBuild(ctx, "main", "my-pipeline", "my-job", "2")
+		require.NoError(t, err)
+	})
+	t.Run("Finished", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "2").
+			Return(&build.Build{ID: 5, BuildNumber: "2", Status: build.Succeeded}, nil)
+
+		err := s.S.CancelJobBuild(ctx, "main", "my-pipeline", "my-job", "2")
+		require.Error(t, err)
+	})
+}
+
+func TestListQueuedBuilds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	pending := func(id uint32, jn, bn string) *build.WithJob {
+		return &build.WithJob{
+			Build:         build.Build{ID: id, BuildNumber: bn, Status: build.Pending},
+			TeamCanonical: "main",
+			PipelineName:  "my-pipeline",
+			JobName:       jn,
+		}
+	}
+	s.Builds.EXPECT().FilterPending(ctx, "main", "", "").Return([]*build.WithJob{
+		pending(1, "deploy", "4"),
+		pending(2, "build", "7"),
+		pending(3, "deploy", "5"),
+		pending(4, "deploy", "6"),
+		pending(5, "migrate", "1"),
+	}, nil)
+	s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "deploy").Return(&job.Job{Name: "deploy", Concurrency: 2}, nil)
+	s.Builds.EXPECT().CountRunning(ctx, "main", "my-pipeline", "deploy").Return(1, nil)
+	s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "build").Return(&job.Job{Name: "build", Tags: []string{"arm64"}}, nil)
+	s.Workers.EXPECT().Filter(ctx).Return([]*registry.Worker{{ID: 1, Tags: []string{"docker"}}}, nil)
+	s.Jobs.EXPECT().Find(ctx, "main", "my-pipeline", "migrate").Return(&job.Job{Name: "migrate", SerialGroups: []string{"db"}}, nil)
+	s.Locks.EXPECT().Filter(ctx, "main").Return([]*lock.Lock{{Name: "db", Holder: "admin"}}, nil)
+
+	qbs, err := s.S.ListQueuedBuilds(ctx, "main", "", "")
+	require.NoError(t, err)
+	require.Len(t, qbs, 5)
+	assert.Equal(t, "4", qbs[0].BuildNumber)
+	assert.Equal(t, build.ReasonQueued, qbs[0].Reason)
+	assert.Equal(t, "7", qbs[1].BuildNumber)
+	assert.Equal(t, build.ReasonNoWorker, qbs[1].Reason)
+	assert.Equal(t, "5", qbs[2].BuildNumber)
+	assert.Equal(t, build.ReasonConcurrency, qbs[2].Reason)
+	assert.Equal(t, "6", qbs[3].BuildNumber)
+	assert.Equal(t, build.ReasonConcurrency, qbs[3].Reason)
+	assert.Equal(t, "1", qbs[4].BuildNumber)
+	assert.Equal(t, build.ReasonLock, qbs[4].Reason)
+
+	_, err = s.S.ListQueuedBuilds(ctx, "main", "", "deploy")
+	require.Error(t, err)
+}
+
 func TestFindBuildGetVersions(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
@@ -200,3 +599,58 @@ func TestFindBuildGetVersions(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
+
+func TestUploadBuildArtifact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	k := artifact.Key{TeamCanonical: "main", PipelineName: "my-pipeline", JobName: "my-job", BuildNumber: "1", TaskName: "compile"}
+	s.Builds.EXPECT().Find(ctx, "main", "my-pipeline", "my-job", "1").Return(&build.Build{ID: 1, BuildNumber: "1"}, nil)
+	s.Artifacts.EXPECT().Put(ctx, k, gomock.Any()).DoAndReturn(func(ctx context.Context, k artifact.Key, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data"), b)
+		return nil
+	})
+
+	err := s.S.UploadBuildArtifact(ctx, "main", "my-pipeline", "my-job", "1", "compile", strings.NewReader("data"))
+	require.NoError(t, err)
+}
+
+func TestUploadBuildArtifact_InvalidTaskName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	err := s.S.UploadBuildArtifact(ctx, "main", "my-pipeline", "my-job", "1", "../compile", strings.NewReader("data"))
+	require.Error(t, err)
+
+	err = s.S.UploadBuildArtifact(ctx, "main", "my-pipeline", "my-job", "../1", "compile", strings.NewReader("data"))
+	require.Error(t, err)
+}
+
+func TestDownloadBuildArtifact(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	k := artifact.Key{TeamCanonical: "main", PipelineName: "my-pipeline", JobName: "my-job", BuildNumber: "1", TaskName: "compile"}
+	s.Artifacts.EXPECT().Get(ctx, k).Return(io.NopCloser(strings.NewReader("data")), nil)
+
+	var buf bytes.Buffer
+	err := s.S.DownloadBuildArtifact(ctx, "main", "my-pipeline", "my-job", "1", "compile", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, "data", buf.String())
+}
+
+func TestDownloadBuildArtifact_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Artifacts.EXPECT().Get(ctx, gomock.Any()).Return(nil, artifact.ErrNotFound)
+
+	err := s.S.DownloadBuildArtifact(ctx, "main", "my-pipeline", "my-job", "1", "compile", io.Discard)
+	require.ErrorIs(t, err, artifact.ErrNotFound)
+}
@@ -11,6 +11,11 @@ import (
 	"github.com/xescugc/pikoci/pikoci/sectype"
 )
 
+// PikoCISecretType is the secret type of the Secrets stored on the
+// server for the Team, it has no commands as the workers get them
+// from the server. The key of the variables is the name of the Secret.
+const PikoCISecretType = "pikoci"
+
 //go:embed resource_types/cron.hcl resource_types/git.hcl
 var resourceTypeFS embed.FS
 
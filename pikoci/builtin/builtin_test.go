@@ -23,9 +23,9 @@ func TestResourceTypes(t *testing.T) {
 		rt, ok := rts["git"]
 		require.True(t, ok)
 		assert.Equal(t, "git", rt.Name)
-		assert.Equal(t, "exec", rt.Check.Runner)
-		assert.Equal(t, "exec", rt.Pull.Runner)
-		assert.Equal(t, "exec", rt.Push.Runner)
+		assert.Equal(t, "native", rt.Check.Runner)
+		assert.Equal(t, "native", rt.Pull.Runner)
+		assert.Equal(t, "native", rt.Push.Runner)
 		assert.Contains(t, rt.Params, "url")
 		assert.Contains(t, rt.Params, "name")
 		assert.Contains(t, rt.Params, "token")
@@ -46,6 +46,13 @@ func TestRunners(t *testing.T) {
 		assert.Equal(t, []string{"$args"}, ru.Run.Args)
 	})
 
+	t.Run("native", func(t *testing.T) {
+		ru, ok := rus[builtin.NativeRunner]
+		require.True(t, ok)
+		assert.Equal(t, "native", ru.Name)
+		assert.Empty(t, ru.Run.Path)
+	})
+
 	t.Run("docker", func(t *testing.T) {
 		ru, ok := rus["docker"]
 		require.True(t, ok)
@@ -0,0 +1,535 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// maxGitVersions is the maximum number of tags or pull
+// requests returned by the check of the git resource type
+const maxGitVersions = 100
+
+// gitLogFormat is the format of the commits parsed by git.commit,
+// the fields are separated by NUL so any of them can have spaces
+const gitLogFormat = "%H%x00%an <%ae>%x00%cI%x00%s"
+
+// gitSource is the configuration of a git resource from its params
+type gitSource struct {
+	URL    string
+	Branch string
+	Name   string
+	Token  string
+	PR     bool
+	Tag    bool
+	// Paths filters the commits of the branch to
+	// the ones touching any of them
+	Paths []string
+	// IgnorePaths filters out the commits of the branch
+	// that only touch any of them
+	IgnorePaths []string
+}
+
+func newGitSource(env map[string]string) (gitSource, error) {
+	s := gitSource{
+		URL:    env["param_url"],
+		Branch: env["param_branch"],
+		Name:   env["param_name"],
+		Token:  env["param_token"],
+		PR:     env["param_pr"] == "true",
+		Tag:    env["param_tag"] == "true",
+	}
+	if s.URL == "" {
+		return s, fmt.Errorf("the url param is required")
+	}
+	if s.PR && s.Tag {
+		return s, fmt.Errorf("the pr and tag params can't be both enabled")
+	}
+	s.Paths = splitGitPaths(env["param_paths"])
+	s.IgnorePaths = splitGitPaths(env["param_ignore_paths"])
+	return s, nil
+}
+
+// splitGitPaths splits the comma separated list of paths ps
+func splitGitPaths(ps string) []string {
+	var paths []string
+	for _, p := range strings.Split(ps, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// pathspecs returns the git pathspecs matching the Paths
+// but not the IgnorePaths, nil if there are none
+func (s gitSource) pathspecs() []string {
+	if len(s.Paths) == 0 && len(s.IgnorePaths) == 0 {
+		return nil
+	}
+	ps := make([]string, 0, len(s.Paths)+len(s.IgnorePaths)+1)
+	for _, p := range s.Paths {
+		ps = append(ps, ":(top)"+p)
+	}
+	// The excludes need something to exclude from
+	if len(ps) == 0 {
+		ps = append(ps, ":(top)")
+	}
+	for _, p := range s.IgnorePaths {
+		ps = append(ps, ":(top,exclude)"+p)
+	}
+	return ps
+}
+
+// remoteURL returns the URL with the token as credentials, if any,
+// only HTTP URLs use it as the other ones authenticate on their own
+func (s gitSource) remoteURL() string {
+	if s.Token == "" {
+		return s.URL
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return s.URL
+	}
+	u.User = url.UserPassword("oauth2", s.Token)
+	return u.String()
+}
+
+// gitVersion is a version of the git resource
+type gitVersion struct {
+	Ref       string `json:"ref"`
+	Tag       string `json:"tag,omitempty"`
+	PR        string `json:"pr,omitempty"`
+	Author    string `json:"author"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+}
+
+// git runs the git CLI inside dir. The stderr of the commands is
+// written to log, if set, and the token is redacted from it.
+type git struct {
+	dir   string
+	token string
+	log   io.Writer
+}
+
+func (g git) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if g.log != nil && stderr.Len() != 0 {
+		io.WriteString(g.log, g.redact(stderr.String()))
+	}
+	if err != nil {
+		msg := strings.TrimSpace(g.redact(stderr.String()))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+func (g git) redact(s string) string {
+	if g.token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, g.token, "*****")
+}
+
+// commit returns the version of the last commit of rev touching the
+// pathspecs, or just the last one if there are no pathspecs. It returns
+// false if none of the commits touches the pathspecs.
+func (g git) commit(ctx context.Context, rev string, pathspecs []string) (gitVersion, bool, error) {
+	vers, err := g.commits(ctx, rev, 1, pathspecs)
+	if err != nil {
+		return gitVersion{}, false, err
+	} else if len(vers) == 0 {
+		return gitVersion{}, false, nil
+	}
+	return vers[0], true, nil
+}
+
+// commits returns the versions of the last n commits of the revision
+// range rev touching the pathspecs, with the newest one last
+func (g git) commits(ctx context.Context, rev string, n int, pathspecs []string) ([]gitVersion, error) {
+	args := append([]string{"log", "--reverse", "-z", fmt.Sprintf("-%d", n), "--format=" + gitLogFormat, rev, "--"}, pathspecs...)
+	out, err := g.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// With -z the commits are also separated by NUL,
+	// so each 4 fields are a commit
+	fs := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	if len(fs) == 1 && fs[0] == "" {
+		return []gitVersion{}, nil
+	}
+	if len(fs)%4 != 0 {
+		return nil, fmt.Errorf("invalid git log output %q", out)
+	}
+
+	vers := make([]gitVersion, 0, len(fs)/4)
+	for i := 0; i < len(fs); i += 4 {
+		vers = append(vers, gitVersion{
+			Ref:       fs[i],
+			Author:    fs[i+1],
+			Timestamp: fs[i+2],
+			Message:   strings.TrimSuffix(fs[i+3], "\n"),
+		})
+	}
+	return vers, nil
+}
+
+// gitCheck fetches the repository into a temporary bare repository
+// and writes the versions of the branch, tags or pull requests
+func gitCheck(ctx context.Context, cwd string, env map[string]string, out io.Writer) error {
+	s, err := newGitSource(env)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(cwd, "git-check-")
+	if err != nil {
+		return fmt.Errorf("failed to create check directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	g := git{dir: dir, token: s.Token}
+	if _, err := g.run(ctx, "init", "--quiet", "--bare"); err != nil {
+		return err
+	}
+
+	var vers []gitVersion
+	switch {
+	case s.Tag:
+		vers, err = s.checkTags(ctx, g)
+	case s.PR:
+		vers, err = s.checkPRs(ctx, g)
+	default:
+		vers, err = s.checkBranch(ctx, g, env["version_ref"])
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(out).Encode(vers)
+}
+
+// checkBranch returns the commits of the branch, or of the default
+// branch if none is set, touching the pathspecs of the Paths and
+// IgnorePaths. If since, the last version ref, is on the history
+// all the commits after it are returned, if not only the last one.
+func (s gitSource) checkBranch(ctx context.Context, g git, since string) ([]gitVersion, error) {
+	branch := s.Branch
+	if branch == "" {
+		out, err := g.run(ctx, "ls-remote", "--symref", s.remoteURL(), "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range strings.Split(out, "\n") {
+			if r, ok := strings.CutPrefix(l, "ref: refs/heads/"); ok {
+				branch, _, _ = strings.Cut(r, "\t")
+				break
+			}
+		}
+		if branch == "" {
+			return nil, fmt.Errorf("failed to find the default branch of %q", s.URL)
+		}
+	}
+
+	pathspecs := s.pathspecs()
+
+	ref := "refs/heads/" + branch
+	args := []string{"fetch", "--quiet", "--no-tags"}
+	// The history is only needed to find the commits after since
+	// or the ones touching the paths, and for those the blobs
+	// are not needed
+	if !isGitSHA(since) && len(pathspecs) == 0 {
+		args = append(args, "--depth", "1")
+	} else {
+		args = append(args, "--filter=blob:none")
+	}
+	args = append(args, s.remoteURL(), "+"+ref+":"+ref)
+	if _, err := g.run(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	// If since is not an ancestor of the branch (it was force pushed,
+	// or the branch changed) there is no history to return
+	if isGitSHA(since) {
+		if _, err := g.run(ctx, "merge-base", "--is-ancestor", since, ref); err == nil {
+			return g.commits(ctx, since+".."+ref, maxGitVersions, pathspecs)
+		}
+	}
+
+	v, ok, err := g.commit(ctx, ref, pathspecs)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return []gitVersion{}, nil
+	}
+	return []gitVersion{v}, nil
+}
+
+// isGitSHA returns true if ref is a full SHA-1 or SHA-256 commit hash
+func isGitSHA(ref string) bool {
+	if len(ref) != 40 && len(ref) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(ref)
+	return err == nil
+}
+
+// checkTags returns the last maxGitVersions tags, the newest one last
+func (s gitSource) checkTags(ctx context.Context, g git) ([]gitVersion, error) {
+	if _, err := g.run(ctx, "fetch", "--quiet", "--depth", "1", s.remoteURL(), "+refs/tags/*:refs/tags/*"); err != nil {
+		return nil, err
+	}
+
+	out, err := g.run(ctx, "for-each-ref", "--sort=creatordate", "--format=%(refname:strip=2)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	tags := strings.Fields(out)
+	if len(tags) > maxGitVersions {
+		tags = tags[len(tags)-maxGitVersions:]
+	}
+
+	vers := make([]gitVersion, 0, len(tags))
+	for _, t := range tags {
+		v, ok, err := g.commit(ctx, "refs/tags/"+t+"^{commit}", nil)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		v.Tag = t
+		vers = append(vers, v)
+	}
+	return vers, nil
+}
+
+// checkPRs returns the heads of the last maxGitVersions updated pull
+// requests (GitHub) or merge requests (GitLab), the newest one last.
+// For github.com and gitlab.com only the open ones are returned.
+func (s gitSource) checkPRs(ctx context.Context, g git) ([]gitVersion, error) {
+	refspecs := []string{
+		"+refs/pull/*/head:refs/pull/*/head",
+		"+refs/merge-requests/*/head:refs/merge-requests/*/head",
+	}
+
+	prefix, prs, ok, err := s.openPRs(ctx)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		if len(prs) == 0 {
+			return []gitVersion{}, nil
+		}
+		refspecs = make([]string, 0, len(prs))
+		for _, n := range prs {
+			ref := fmt.Sprintf("%s/%d/head", prefix, n)
+			refspecs = append(refspecs, "+"+ref+":"+ref)
+		}
+	}
+
+	args := append([]string{"fetch", "--quiet", "--no-tags", "--depth", "1", s.remoteURL()}, refspecs...)
+	if _, err := g.run(ctx, args...); err != nil {
+		return nil, err
+	}
+
+	out, err := g.run(ctx, "for-each-ref", "--sort=committerdate", "--format=%(refname)", "refs/pull", "refs/merge-requests")
+	if err != nil {
+		return nil, err
+	}
+	refs := strings.Fields(out)
+	if len(refs) > maxGitVersions {
+		refs = refs[len(refs)-maxGitVersions:]
+	}
+
+	vers := make([]gitVersion, 0, len(refs))
+	for _, ref := range refs {
+		// The refs are refs/pull/<number>/head or refs/merge-requests/<number>/head
+		parts := strings.Split(ref, "/")
+		if len(parts) != 4 {
+			continue
+		}
+		v, ok, err := g.commit(ctx, ref, nil)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		v.PR = parts[2]
+		vers = append(vers, v)
+	}
+	return vers, nil
+}
+
+// openPRs returns the ref prefix and the numbers of the open pull requests
+// from the API of github.com or gitlab.com. It returns false if the URL
+// is not from any of them, as then it's not known which ones are open.
+func (s gitSource) openPRs(ctx context.Context) (string, []int, bool, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return "", nil, false, nil
+	}
+	repo := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+
+	var (
+		prefix string
+		aurl   string
+		header string
+		token  string
+	)
+	switch u.Host {
+	case "github.com":
+		prefix = "refs/pull"
+		aurl = fmt.Sprintf("https://api.github.com/repos/%s/pulls?state=open&sort=updated&direction=desc&per_page=%d", repo, maxGitVersions)
+		header = "Authorization"
+		if s.Token != "" {
+			token = "token " + s.Token
+		}
+	case "gitlab.com":
+		prefix = "refs/merge-requests"
+		aurl = fmt.Sprintf("https://gitlab.com/api/v4/projects/%s/merge_requests?state=opened&order_by=updated_at&sort=desc&per_page=%d", url.PathEscape(repo), maxGitVersions)
+		header = "PRIVATE-TOKEN"
+		token = s.Token
+	default:
+		return "", nil, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, aurl, nil)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set(header, token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", nil, false, fmt.Errorf("failed to list the open pull requests: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, false, fmt.Errorf("failed to list the open pull requests: %s", resp.Status)
+	}
+
+	var prs []struct {
+		Number int `json:"number"`
+		IID    int `json:"iid"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
+		return "", nil, false, fmt.Errorf("failed to decode the open pull requests: %w", err)
+	}
+
+	ns := make([]int, 0, len(prs))
+	for _, pr := range prs {
+		if u.Host == "gitlab.com" {
+			ns = append(ns, pr.IID)
+		} else {
+			ns = append(ns, pr.Number)
+		}
+	}
+	return prefix, ns, true, nil
+}
+
+// gitPull clones the repository into the name directory and checks out
+// the version. On branch mode the branch is kept checked out so it
+// can be pushed, tags and pull requests are checked out detached.
+func gitPull(ctx context.Context, cwd string, env map[string]string, out io.Writer) error {
+	s, err := newGitSource(env)
+	if err != nil {
+		return err
+	}
+	if s.Name == "" {
+		return fmt.Errorf("the name param is required")
+	}
+
+	g := git{dir: cwd, token: s.Token, log: out}
+	args := []string{"clone", "--quiet"}
+	if s.Branch != "" && !s.Tag && !s.PR {
+		args = append(args, "--branch", s.Branch)
+	}
+	args = append(args, s.remoteURL(), s.Name)
+	if _, err := g.run(ctx, args...); err != nil {
+		return err
+	}
+	g.dir = filepath.Join(cwd, s.Name)
+
+	ref := env["version_ref"]
+	switch {
+	case s.Tag:
+		if ref == "" && env["version_tag"] != "" {
+			ref = "refs/tags/" + env["version_tag"]
+		}
+	case s.PR:
+		if pr := env["version_pr"]; pr != "" {
+			_, err := g.run(ctx, "fetch", "--quiet", "origin", "refs/pull/"+pr+"/head")
+			if err != nil {
+				_, err = g.run(ctx, "fetch", "--quiet", "origin", "refs/merge-requests/"+pr+"/head")
+			}
+			if err != nil {
+				return fmt.Errorf("failed to fetch pull request %s: %w", pr, err)
+			}
+			if ref == "" {
+				ref = "FETCH_HEAD"
+			}
+		}
+	}
+	if ref == "" {
+		fmt.Fprintf(out, "cloned %s into %s\n", s.URL, s.Name)
+		return nil
+	}
+
+	if s.Tag || s.PR {
+		_, err = g.run(ctx, "checkout", "--quiet", "--detach", ref)
+	} else {
+		_, err = g.run(ctx, "reset", "--quiet", "--hard", ref)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "cloned %s into %s at %s\n", s.URL, s.Name, ref)
+	return nil
+}
+
+// gitPush pushes the current branch of the name directory
+func gitPush(ctx context.Context, cwd string, env map[string]string, out io.Writer) error {
+	s, err := newGitSource(env)
+	if err != nil {
+		return err
+	}
+	if s.Name == "" {
+		return fmt.Errorf("the name param is required")
+	}
+
+	g := git{dir: filepath.Join(cwd, s.Name), token: s.Token, log: out}
+	if s.Token != "" {
+		if _, err := g.run(ctx, "remote", "set-url", "origin", s.remoteURL()); err != nil {
+			return err
+		}
+	}
+	if _, err := g.run(ctx, "push"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "pushed %s to %s\n", s.Name, s.URL)
+	return nil
+}
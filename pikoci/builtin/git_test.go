@@ -0,0 +1,245 @@
+package builtin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/builtin"
+)
+
+// runGit runs git on dir and returns the trimmed stdout
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+		"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes the file p on the work repository dir and commits it
+func commitFile(t *testing.T, dir, p, msg string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, p), []byte(msg), 0o644))
+	runGit(t, dir, "add", p)
+	runGit(t, dir, "commit", "--quiet", "-m", msg)
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
+
+// setupGitRemote creates an empty bare repository and returns its
+// path and the one of a work repository with it as origin
+func setupGitRemote(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, t.TempDir(), "init", "--quiet", "--bare", "--initial-branch=main", remote)
+
+	work := t.TempDir()
+	runGit(t, work, "init", "--quiet", "--initial-branch=main")
+	runGit(t, work, "remote", "add", "origin", remote)
+	return remote, work
+}
+
+func checkVersions(t *testing.T, env map[string]string) []map[string]string {
+	t.Helper()
+	var out bytes.Buffer
+	err := builtin.RunNative(context.Background(), t.TempDir(), []string{"git", "check"}, env, &out)
+	require.NoError(t, err, out.String())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var vers []map[string]string
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &vers))
+	return vers
+}
+
+func TestGitCheck(t *testing.T) {
+	remote, work := setupGitRemote(t)
+
+	first := commitFile(t, work, "src/main.go", "add main")
+	runGit(t, work, "tag", "v0.1.0")
+	second := commitFile(t, work, "docs/README.md", "add docs")
+	runGit(t, work, "push", "--quiet", "origin", "main", "--tags")
+
+	runGit(t, work, "checkout", "--quiet", "-b", "feature")
+	feature := commitFile(t, work, "src/feature.go", "add feature")
+	runGit(t, work, "push", "--quiet", "origin", "feature:refs/pull/7/head")
+
+	t.Run("DefaultBranch", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote})
+		require.Len(t, vers, 1)
+		assert.Equal(t, second, vers[0]["ref"])
+		assert.Equal(t, "Alice <alice@example.com>", vers[0]["author"])
+		assert.Equal(t, "add docs", vers[0]["message"])
+		assert.NotEmpty(t, vers[0]["timestamp"])
+	})
+	t.Run("Branch", func(t *testing.T) {
+		runGit(t, work, "push", "--quiet", "origin", "feature")
+		vers := checkVersions(t, map[string]string{"param_url": remote, "param_branch": "feature"})
+		require.Len(t, vers, 1)
+		assert.Equal(t, feature, vers[0]["ref"])
+	})
+	t.Run("Paths", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "param_branch": "main", "param_paths": "src/, other/"})
+		require.Len(t, vers, 1)
+		assert.Equal(t, first, vers[0]["ref"])
+
+		vers = checkVersions(t, map[string]string{"param_url": remote, "param_paths": "other/"})
+		assert.Empty(t, vers)
+	})
+	t.Run("Tag", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "param_tag": "true"})
+		require.Len(t, vers, 1)
+		assert.Equal(t, first, vers[0]["ref"])
+		assert.Equal(t, "v0.1.0", vers[0]["tag"])
+	})
+	t.Run("PR", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "param_pr": "true"})
+		require.Len(t, vers, 1)
+		assert.Equal(t, feature, vers[0]["ref"])
+		assert.Equal(t, "7", vers[0]["pr"])
+		assert.Equal(t, "add feature", vers[0]["message"])
+	})
+	t.Run("MissingBranch", func(t *testing.T) {
+		var out bytes.Buffer
+		err := builtin.RunNative(context.Background(), t.TempDir(), []string{"git", "check"}, map[string]string{"param_url": remote, "param_branch": "nope"}, &out)
+		require.Error(t, err)
+		assert.NotContains(t, out.String(), `"ref":""`)
+	})
+	t.Run("MissingURL", func(t *testing.T) {
+		var out bytes.Buffer
+		err := builtin.RunNative(context.Background(), t.TempDir(), []string{"git", "check"}, map[string]string{}, &out)
+		require.Error(t, err)
+	})
+}
+
+func TestGitCheck_History(t *testing.T) {
+	remote, work := setupGitRemote(t)
+
+	c1 := commitFile(t, work, "src/main.go", "add main")
+	c2 := commitFile(t, work, "docs/README.md", "add docs")
+	c3 := commitFile(t, work, "src/util.go", "add util")
+	c4 := commitFile(t, work, "src/vendor/lib.go", "vendor lib")
+	runGit(t, work, "push", "--quiet", "origin", "main")
+
+	refs := func(vers []map[string]string) []string {
+		rs := make([]string, 0, len(vers))
+		for _, v := range vers {
+			rs = append(rs, v["ref"])
+		}
+		return rs
+	}
+
+	t.Run("SinceVersionRef", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "version_ref": c1})
+		assert.Equal(t, []string{c2, c3, c4}, refs(vers))
+		assert.Equal(t, "add docs", vers[0]["message"])
+	})
+	t.Run("Paths", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "version_ref": c1, "param_paths": "src/"})
+		assert.Equal(t, []string{c3, c4}, refs(vers))
+	})
+	t.Run("PathsAndIgnorePaths", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "version_ref": c1, "param_paths": "src/", "param_ignore_paths": "src/vendor/"})
+		assert.Equal(t, []string{c3}, refs(vers))
+	})
+	t.Run("IgnorePaths", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "version_ref": c1, "param_ignore_paths": "docs/, src/vendor/"})
+		assert.Equal(t, []string{c3}, refs(vers))
+	})
+	t.Run("WithoutVersionRef", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "param_paths": "docs/"})
+		assert.Equal(t, []string{c2}, refs(vers))
+	})
+	t.Run("UpToDate", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "version_ref": c4})
+		assert.Empty(t, vers)
+	})
+	t.Run("UnknownVersionRef", func(t *testing.T) {
+		vers := checkVersions(t, map[string]string{"param_url": remote, "version_ref": strings.Repeat("a", 40)})
+		assert.Equal(t, []string{c4}, refs(vers))
+	})
+}
+
+func TestGitPull(t *testing.T) {
+	remote, work := setupGitRemote(t)
+
+	first := commitFile(t, work, "a.txt", "first")
+	runGit(t, work, "tag", "v1")
+	second := commitFile(t, work, "b.txt", "second")
+	runGit(t, work, "push", "--quiet", "origin", "main", "--tags")
+	runGit(t, work, "checkout", "--quiet", "-b", "feature")
+	feature := commitFile(t, work, "c.txt", "feature")
+	runGit(t, work, "push", "--quiet", "origin", "feature:refs/pull/3/head")
+
+	pull := func(t *testing.T, env map[string]string) string {
+		t.Helper()
+		cwd := t.TempDir()
+		var out bytes.Buffer
+		err := builtin.RunNative(context.Background(), cwd, []string{"git", "pull"}, env, &out)
+		require.NoError(t, err, out.String())
+		return filepath.Join(cwd, "repo")
+	}
+
+	t.Run("Branch", func(t *testing.T) {
+		dir := pull(t, map[string]string{"param_url": remote, "param_name": "repo", "param_branch": "main", "version_ref": first})
+		assert.Equal(t, first, runGit(t, dir, "rev-parse", "HEAD"))
+		assert.Equal(t, "main", runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+		assert.NoFileExists(t, filepath.Join(dir, "b.txt"))
+	})
+	t.Run("Latest", func(t *testing.T) {
+		dir := pull(t, map[string]string{"param_url": remote, "param_name": "repo"})
+		assert.Equal(t, second, runGit(t, dir, "rev-parse", "HEAD"))
+	})
+	t.Run("Tag", func(t *testing.T) {
+		dir := pull(t, map[string]string{"param_url": remote, "param_name": "repo", "param_tag": "true", "version_tag": "v1"})
+		assert.Equal(t, first, runGit(t, dir, "rev-parse", "HEAD"))
+	})
+	t.Run("PR", func(t *testing.T) {
+		dir := pull(t, map[string]string{"param_url": remote, "param_name": "repo", "param_pr": "true", "version_pr": "3", "version_ref": feature})
+		assert.Equal(t, feature, runGit(t, dir, "rev-parse", "HEAD"))
+	})
+	t.Run("MissingName", func(t *testing.T) {
+		var out bytes.Buffer
+		err := builtin.RunNative(context.Background(), t.TempDir(), []string{"git", "pull"}, map[string]string{"param_url": remote}, &out)
+		require.Error(t, err)
+	})
+}
+
+func TestGitPush(t *testing.T) {
+	remote, work := setupGitRemote(t)
+	commitFile(t, work, "a.txt", "first")
+	runGit(t, work, "push", "--quiet", "origin", "main")
+
+	cwd := t.TempDir()
+	env := map[string]string{"param_url": remote, "param_name": "repo", "param_branch": "main"}
+	var out bytes.Buffer
+	require.NoError(t, builtin.RunNative(context.Background(), cwd, []string{"git", "pull"}, env, &out), out.String())
+
+	pushed := commitFile(t, filepath.Join(cwd, "repo"), "b.txt", "second")
+	require.NoError(t, builtin.RunNative(context.Background(), cwd, []string{"git", "push"}, env, &out), out.String())
+
+	assert.Equal(t, pushed, runGit(t, remote, "rev-parse", "refs/heads/main"))
+}
+
+func TestRunNative(t *testing.T) {
+	var out bytes.Buffer
+	assert.Error(t, builtin.RunNative(context.Background(), t.TempDir(), []string{"git"}, nil, &out))
+	assert.Error(t, builtin.RunNative(context.Background(), t.TempDir(), []string{"svn", "check"}, nil, &out))
+	assert.Error(t, builtin.RunNative(context.Background(), t.TempDir(), []string{"git", "clean"}, nil, &out))
+}
@@ -0,0 +1,67 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// NativeRunner is the runner of the resource type commands implemented
+// in Go. The args of the command are the resource type and the command
+// to run, for example ["git", "check"].
+const NativeRunner = "native"
+
+// NativeCommand is a resource type command implemented in Go. It receives
+// the same env variables ($param_*, $version_*, ...) the exec runner sets
+// on the process and writes its logs to out. Like the exec commands,
+// the check has to write the versions as JSON on the last line.
+type NativeCommand func(ctx context.Context, cwd string, env map[string]string, out io.Writer) error
+
+// NativeResourceType is a resource type compiled into PikoCI, the
+// HCL of the resource type runs its commands with the NativeRunner
+type NativeResourceType struct {
+	Check NativeCommand
+	Pull  NativeCommand
+	Push  NativeCommand
+}
+
+var nativeResourceTypes = map[string]NativeResourceType{
+	"git": {
+		Check: gitCheck,
+		Pull:  gitPull,
+		Push:  gitPush,
+	},
+}
+
+// NativeResourceTypes returns the resource types implemented in Go
+func NativeResourceTypes() map[string]NativeResourceType {
+	return nativeResourceTypes
+}
+
+// RunNative runs the native command of args, which has to be
+// the name of the resource type followed by check, pull or push
+func RunNative(ctx context.Context, cwd string, args []string, env map[string]string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("invalid native args %q, expected the resource type and the command", args)
+	}
+
+	nrt, ok := nativeResourceTypes[args[0]]
+	if !ok {
+		return fmt.Errorf("native resource type %q not found", args[0])
+	}
+
+	var cmd NativeCommand
+	switch args[1] {
+	case "check":
+		cmd = nrt.Check
+	case "pull":
+		cmd = nrt.Pull
+	case "push":
+		cmd = nrt.Push
+	}
+	if cmd == nil {
+		return fmt.Errorf("native resource type %q has no %q command", args[0], args[1])
+	}
+
+	return cmd(ctx, cwd, env, out)
+}
@@ -5,6 +5,7 @@ runner_type "docker" {
       "run", "--rm",
       "-v", "$WORKDIR:/workdir",
       "-w", "/workdir",
+      "-e", "PIKOCI_OUTPUT=/workdir/$PIKOCI_OUTPUT_NAME",
       "$args",
       "$image",
       "/bin/sh", "-ec", "$cmd",
@@ -0,0 +1,5 @@
+# The commands of the native runner are implemented in Go and run by
+# the worker itself, the args are the resource type and the command
+runner_type "native" {
+  run {}
+}
@@ -7,8 +7,19 @@ type Config struct {
 
 	JWTSecret string `mapstructure:"jwt-secret"`
 
+	SecretKeys []string `mapstructure:"secret-keys"`
+
 	Users []string `mapstructure:"users"`
 
+	OIDCIssuer        string   `mapstructure:"oidc-issuer"`
+	OIDCClientID      string   `mapstructure:"oidc-client-id"`
+	OIDCClientSecret  string   `mapstructure:"oidc-client-secret"`
+	OIDCRedirectURL   string   `mapstructure:"oidc-redirect-url"`
+	OIDCScopes        []string `mapstructure:"oidc-scopes"`
+	OIDCUsernameClaim string   `mapstructure:"oidc-username-claim"`
+	OIDCGroupsClaim   string   `mapstructure:"oidc-groups-claim"`
+	OIDCTeamMappings  []string `mapstructure:"oidc-team-mappings"`
+
 	// MySQL
 	DBHost     string `mapstructure:"db-host"`
 	DBPort     int    `mapstructure:"db-port"`
@@ -16,12 +27,23 @@ type Config struct {
 	DBPassword string `mapstructure:"db-password"`
 	DBName     string `mapstructure:"db-name"`
 
-	RunWorker    bool   `mapstructure:"run-worker"`
-	Concurrency  int    `mapstructure:"concurrency"`
-	DrainTimeout string `mapstructure:"drain-timeout"`
+	RunWorker    bool     `mapstructure:"run-worker"`
+	Concurrency  int      `mapstructure:"concurrency"`
+	Tags         []string `mapstructure:"tags"`
+	DrainTimeout string   `mapstructure:"drain-timeout"`
+	CacheDir     string   `mapstructure:"cache-dir"`
+	CacheMaxSize int64    `mapstructure:"cache-max-size"`
+
+	WorkerHeartbeatTTL    string `mapstructure:"worker-heartbeat-ttl"`
+	RequeueOrphanedBuilds bool   `mapstructure:"requeue-orphaned-builds"`
+
+	PullWorkers  bool   `mapstructure:"pull-workers"`
+	WorkLeaseTTL string `mapstructure:"work-lease-ttl"`
 
 	PubSubSystem string `mapstructure:"pubsub-system"`
 
+	ArtifactsDir string `mapstructure:"artifacts-dir"`
+
 	LogLevel string `mapstructure:"log-level"`
 
 	TeamCanonical  string `mapstructure:"team-canonical"`
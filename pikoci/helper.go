@@ -3,12 +3,17 @@ package pikoci
 import (
 	"context"
 	"fmt"
+	"maps"
 	"math/big"
+	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclsimple"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/xescugc/pikoci/pikoci/builtin"
 	"github.com/xescugc/pikoci/pikoci/job"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/resource"
@@ -41,12 +46,15 @@ type hclGetStep struct {
 
 // hclTaskStep is the HCL-decoded task step with per-step hooks.
 type hclTaskStep struct {
-	Name     string              `json:"name" hcl:"name,label"`
-	Timeout  string              `json:"timeout" hcl:"timeout,optional"`
-	Attempts int                 `json:"attempts" hcl:"attempts,optional"`
-	Inputs   []string            `json:"inputs" hcl:"inputs,optional"`
-	Outputs  []string            `json:"outputs" hcl:"outputs,optional"`
-	Run      utils.RunnerCommand `json:"run" hcl:"run,block"`
+	Name       string              `json:"name" hcl:"name,label"`
+	Timeout    string              `json:"timeout" hcl:"timeout,optional"`
+	Attempts   int                 `json:"attempts" hcl:"attempts,optional"`
+	Inputs     []string            `json:"inputs" hcl:"inputs,optional"`
+	Outputs    []string            `json:"outputs" hcl:"outputs,optional"`
+	InputsFrom []string            `json:"inputs_from" hcl:"inputs_from,optional"`
+	Cache      *hclCache           `json:"cache" hcl:"cache,block"`
+	Reports    *hclReports         `json:"reports" hcl:"reports,block"`
+	Run        utils.RunnerCommand `json:"run" hcl:"run,block"`
 
 	Remain hcl.Body `hcl:",remain"` // absorbs hook blocks; parsed by parseHooks from AST
 }
@@ -64,14 +72,255 @@ type hclPutStep struct {
 	Remain hcl.Body `hcl:",remain"`
 }
 
+// hclReports is the HCL-decoded reports block of a task.
+type hclReports struct {
+	JUnit []string `hcl:"junit,optional"`
+}
+
+// toReports validates the reports block and converts it to a
+// job.Reports, returns nil if no block was defined.
+func (hr *hclReports) toReports() (*job.Reports, error) {
+	if hr == nil {
+		return nil, nil
+	}
+	if len(hr.JUnit) == 0 {
+		return nil, fmt.Errorf("reports junit can not be empty")
+	}
+	for _, p := range hr.JUnit {
+		if !filepath.IsLocal(filepath.Clean(p)) {
+			return nil, fmt.Errorf("invalid reports path %q: must be relative to the workdir", p)
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid reports path %q: %w", p, err)
+		}
+	}
+	return &job.Reports{
+		JUnit: hr.JUnit,
+	}, nil
+}
+
+// hclCache is the HCL-decoded cache block of a job or task.
+type hclCache struct {
+	Paths    []string `hcl:"paths"`
+	Key      string   `hcl:"key,optional"`
+	KeyFiles []string `hcl:"key_files,optional"`
+}
+
+// toCache validates the cache block and converts it to a job.Cache,
+// returns nil if no block was defined.
+func (hc *hclCache) toCache() (*job.Cache, error) {
+	if hc == nil {
+		return nil, nil
+	}
+	if len(hc.Paths) == 0 {
+		return nil, fmt.Errorf("cache paths can not be empty")
+	}
+	for _, p := range hc.Paths {
+		if !filepath.IsLocal(filepath.Clean(p)) {
+			return nil, fmt.Errorf("invalid cache path %q: must be relative to the workdir", p)
+		}
+	}
+	for _, p := range hc.KeyFiles {
+		if _, err := filepath.Match(p, ""); err != nil || !filepath.IsLocal(filepath.Clean(p)) {
+			return nil, fmt.Errorf("invalid cache key file %q: must be a pattern relative to the workdir", p)
+		}
+	}
+	return &job.Cache{
+		Paths:    hc.Paths,
+		Key:      hc.Key,
+		KeyFiles: hc.KeyFiles,
+	}, nil
+}
+
 // hclJob is the intermediate HCL-decoded job with separate get/task/put arrays.
 type hclJob struct {
-	Name        string          `hcl:"name,label"`
-	Concurrency int             `hcl:"concurrency,optional"`
-	Get         []hclGetStep    `hcl:"get,block"`
-	Task        []hclTaskStep   `hcl:"task,block"`
-	Put         []hclPutStep    `hcl:"put,block"`
-	Service     []hclServiceRef `hcl:"service,block"`
+	Name         string          `hcl:"name,label"`
+	Concurrency  int             `hcl:"concurrency,optional"`
+	Tags         []string        `hcl:"tags,optional"`
+	SerialGroups []string        `hcl:"serial_groups,optional"`
+	Matrix       *hclMatrix      `hcl:"matrix,block"`
+	Cache        *hclCache       `hcl:"cache,block"`
+	Get          []hclGetStep    `hcl:"get,block"`
+	Task         []hclTaskStep   `hcl:"task,block"`
+	Put          []hclPutStep    `hcl:"put,block"`
+	Service      []hclServiceRef `hcl:"service,block"`
+	InParallel   []hclParallel   `hcl:"in_parallel,block"`
+
+	Remain hcl.Body `hcl:",remain"` // absorbs hook blocks; parsed by parseHooks from AST
+}
+
+// hclMatrix is the HCL-decoded matrix block of a job, each
+// attribute is a list with the values of one of its axes
+type hclMatrix struct {
+	Axes hcl.Attributes `hcl:",remain"`
+}
+
+// maxMatrixCells is the maximum number of combinations of a matrix
+const maxMatrixCells = 64
+
+// matrixCells returns all the combinations of the values of the
+// matrix axes, combined in the order they are defined. The Plan
+// of the cells is left for parseJobPlans.
+func matrixCells(hm *hclMatrix, ectx *hcl.EvalContext) ([]job.MatrixCell, error) {
+	if len(hm.Axes) == 0 {
+		return nil, fmt.Errorf("matrix must have at least one value")
+	}
+	axes := make([]*hcl.Attribute, 0, len(hm.Axes))
+	for _, a := range hm.Axes {
+		axes = append(axes, a)
+	}
+	sort.Slice(axes, func(i, j int) bool {
+		return axes[i].Range.Start.Byte < axes[j].Range.Start.Byte
+	})
+
+	cells := []job.MatrixCell{{Values: make(map[string]string)}}
+	for _, a := range axes {
+		var vs []string
+		diags := gohcl.DecodeExpression(a.Expr, ectx, &vs)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid matrix %q: %s", a.Name, diags.Error())
+		}
+		if len(vs) == 0 {
+			return nil, fmt.Errorf("matrix %q can not be empty", a.Name)
+		}
+		seen := make(map[string]bool)
+		for _, v := range vs {
+			if seen[v] {
+				return nil, fmt.Errorf("matrix %q has the value %q more than once", a.Name, v)
+			}
+			seen[v] = true
+		}
+		if len(cells)*len(vs) > maxMatrixCells {
+			return nil, fmt.Errorf("matrix has more than %d combinations", maxMatrixCells)
+		}
+
+		next := make([]job.MatrixCell, 0, len(cells)*len(vs))
+		for _, c := range cells {
+			for _, v := range vs {
+				values := maps.Clone(c.Values)
+				values[a.Name] = v
+				name := a.Name + "=" + v
+				if c.Name != "" {
+					name = c.Name + "," + name
+				}
+				next = append(next, job.MatrixCell{Name: name, Values: values})
+			}
+		}
+		cells = next
+	}
+	return cells, nil
+}
+
+// matrixPlaceholder returns the matrix variable used to decode the
+// pipeline, with an empty value for each axis of all the matrix
+// blocks, as the real values are only known for each cell
+func matrixPlaceholder(rpp []byte) cty.Value {
+	file, diags := hclsyntax.ParseConfig(rpp, "pipeline.hcl", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return cty.EmptyObjectVal
+	}
+	axes := make(map[string]cty.Value)
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "job" {
+			continue
+		}
+		for _, inner := range block.Body.Blocks {
+			if inner.Type != "matrix" {
+				continue
+			}
+			for name := range inner.Body.Attributes {
+				axes[name] = cty.StringVal("")
+			}
+		}
+	}
+	if len(axes) == 0 {
+		return cty.EmptyObjectVal
+	}
+	return cty.ObjectVal(axes)
+}
+
+// stepsPlaceholder returns the steps variable used to evaluate the
+// pipeline, with a placeholder for each steps.<name>.outputs.<key> it
+// uses, as the outputs are only known by the worker once the step ran
+func stepsPlaceholder(rpp []byte) cty.Value {
+	file, diags := hclsyntax.ParseConfig(rpp, "pipeline.hcl", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return cty.EmptyObjectVal
+	}
+	outputs := make(map[string]map[string]cty.Value)
+	var walk func(body *hclsyntax.Body)
+	walk = func(body *hclsyntax.Body) {
+		for _, attr := range body.Attributes {
+			for _, tr := range attr.Expr.Variables() {
+				if tr.RootName() != "steps" || len(tr) < 4 {
+					continue
+				}
+				name, ok := traverserName(tr[1])
+				if !ok {
+					continue
+				}
+				if o, ok := traverserName(tr[2]); !ok || o != "outputs" {
+					continue
+				}
+				key, ok := traverserName(tr[3])
+				if !ok {
+					continue
+				}
+				if outputs[name] == nil {
+					outputs[name] = make(map[string]cty.Value)
+				}
+				outputs[name][key] = cty.StringVal(fmt.Sprintf("__pikoci_output:%s:%s__", name, key))
+			}
+		}
+		for _, b := range body.Blocks {
+			walk(b.Body)
+		}
+	}
+	walk(file.Body.(*hclsyntax.Body))
+	if len(outputs) == 0 {
+		return cty.EmptyObjectVal
+	}
+
+	steps := make(map[string]cty.Value, len(outputs))
+	for name, o := range outputs {
+		steps[name] = cty.ObjectVal(map[string]cty.Value{
+			"outputs": cty.ObjectVal(o),
+		})
+	}
+	return cty.ObjectVal(steps)
+}
+
+// traverserName returns the name of the attribute, or the
+// string key, the traverser t accesses
+func traverserName(t hcl.Traverser) (string, bool) {
+	switch s := t.(type) {
+	case hcl.TraverseAttr:
+		return s.Name, true
+	case hcl.TraverseIndex:
+		if s.Key.Type() == cty.String {
+			return s.Key.AsString(), true
+		}
+	}
+	return "", false
+}
+
+// matrixValue returns the matrix variable of a cell
+func matrixValue(values map[string]string) cty.Value {
+	vals := make(map[string]cty.Value, len(values))
+	for k, v := range values {
+		vals[k] = cty.StringVal(v)
+	}
+	return cty.ObjectVal(vals)
+}
+
+// hclParallel is the HCL-decoded in_parallel block, its steps
+// are ordered from the AST in parseParallelStep.
+type hclParallel struct {
+	Limit    int           `hcl:"limit,optional"`
+	FailFast bool          `hcl:"fail_fast,optional"`
+	Get      []hclGetStep  `hcl:"get,block"`
+	Task     []hclTaskStep `hcl:"task,block"`
+	Put      []hclPutStep  `hcl:"put,block"`
 
 	Remain hcl.Body `hcl:",remain"` // absorbs hook blocks; parsed by parseHooks from AST
 }
@@ -214,7 +463,9 @@ type hclPipeline struct {
 	Remain        hcl.Body            `hcl:",remain"`
 }
 
-func hclFunctions() map[string]function.Function {
+// HCLFunctions returns the functions that can be used on the pipelines
+// and on the when conditions of the steps evaluated by the workers
+func HCLFunctions() map[string]function.Function {
 	return map[string]function.Function{
 		// String
 		"chomp":      stdlib.ChompFunc,
@@ -262,7 +513,7 @@ func hclFunctions() map[string]function.Function {
 }
 
 func (q *PikoCI) readPipeline(ctx context.Context, rpp []byte, vars map[string]interface{}) (*pipeline.Pipeline, error) {
-	funcs := hclFunctions()
+	funcs := HCLFunctions()
 	ectx := pipeline.TypeEvalContext()
 	ectx.Functions = funcs
 	var pvars pipeline.Variables
@@ -344,13 +595,22 @@ func (q *PikoCI) readPipeline(ctx context.Context, rpp []byte, vars map[string]i
 	}
 	ectx = &hcl.EvalContext{
 		Variables: map[string]cty.Value{
-			"var": cty.ObjectVal(ecvars),
+			"var":   cty.ObjectVal(ecvars),
+			"steps": stepsPlaceholder(rpp),
 		},
 		Functions: funcs,
 	}
 
+	// The values of the matrix are only known for each of its
+	// cells, so the jobs are decoded again for each one of them
+	// on parseJobPlans
+	dctx := ectx.NewChild()
+	dctx.Variables = map[string]cty.Value{
+		"matrix": matrixPlaceholder(rpp),
+	}
+
 	var hp hclPipeline
-	err = hclsimple.Decode("pipeline.hcl", rpp, ectx, &hp)
+	err = hclsimple.Decode("pipeline.hcl", rpp, dctx, &hp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to Decode Pipeline config: %w", err)
 	}
@@ -377,6 +637,11 @@ func (q *PikoCI) readPipeline(ctx context.Context, rpp []byte, vars map[string]i
 
 	var runners []runner.Runner
 	for _, hrd := range hp.Runners {
+		// The worker runs the commands of the native runner itself,
+		// so it can't be overridden like the other built-in runners
+		if hrd.Name == builtin.NativeRunner {
+			return nil, fmt.Errorf("runner_type %q is reserved for the built-in resource types", hrd.Name)
+		}
 		if hrd.Source != "" {
 			hasInline := len(hrd.Run) > 0
 			if hasInline {
@@ -482,7 +747,7 @@ func (q *PikoCI) readPipeline(ctx context.Context, rpp []byte, vars map[string]i
 	}
 
 	// Parse the raw HCL to determine block ordering within each job.
-	jobPlans, jobHooksMap, expandedServices, err := parseJobPlans(rpp, ectx, hp.Jobs, services)
+	jobPlans, jobHooksMap, jobMatrices, expandedServices, err := parseJobPlans(rpp, ectx, hp.Jobs, services)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse job plans: %w", err)
 	}
@@ -500,24 +765,100 @@ func (q *PikoCI) readPipeline(ctx context.Context, rpp []byte, vars map[string]i
 		if hj.Concurrency < 0 {
 			return nil, fmt.Errorf("job %q: concurrency must be >= 0", hj.Name)
 		}
+		if err := validateTags(hj.Tags); err != nil {
+			return nil, fmt.Errorf("job %q: %w", hj.Name, err)
+		}
+		for _, sg := range hj.SerialGroups {
+			if !utils.ValidateCanonical(sg) {
+				return nil, fmt.Errorf("job %q: invalid serial group format %q", hj.Name, sg)
+			}
+		}
+		jc, err := hj.Cache.toCache()
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %w", hj.Name, err)
+		}
+		jm := jobMatrices[hj.Name]
+		if len(jm) != 0 {
+			jc = jm[0].Cache
+		}
 		jh := jobHooksMap[hj.Name]
 		j := job.Job{
 			Name:        hj.Name,
 			Concurrency: hj.Concurrency,
+			Tags:        hj.Tags,
+			Cache:       jc,
 			Plan:        jobPlans[hj.Name],
 			OnSuccess:   jh.OnSuccess,
 			OnFailure:   jh.OnFailure,
 			Ensure:      jh.Ensure,
+
+			SerialGroups: hj.SerialGroups,
+			Matrix:       jm,
 		}
 		pp.Jobs = append(pp.Jobs, j)
 	}
 
+	if err := validateInputsFrom(pp.Jobs); err != nil {
+		return nil, err
+	}
+
 	for i, r := range pp.Resources {
+		if err := validateTags(r.Tags); err != nil {
+			return nil, fmt.Errorf("resource %q: %w", r.Name, err)
+		}
 		pp.Resources[i].Canonical = utils.ResourceCanonical(r.Type, r.Name)
 	}
 	return &pp, nil
 }
 
+// validateTags checks that the tags are valid canonicals
+func validateTags(tags []string) error {
+	for _, t := range tags {
+		if !utils.ValidateCanonical(t) {
+			return fmt.Errorf("invalid tag format %q", t)
+		}
+	}
+	return nil
+}
+
+// validateInputsFrom checks that every task inputs_from references
+// a task of another job that declares outputs.
+func validateInputsFrom(jobs []job.Job) error {
+	jobsByName := make(map[string]*job.Job)
+	for i := range jobs {
+		jobsByName[jobs[i].Name] = &jobs[i]
+	}
+	for _, j := range jobs {
+		for _, t := range j.TaskSteps() {
+			for _, ref := range t.InputsFrom {
+				jn, tn, ok := job.ParseInputsFrom(ref)
+				if !ok {
+					return fmt.Errorf("job %q: task %q has invalid inputs_from %q, expected format \"job.task\"", j.Name, t.Name, ref)
+				}
+				if jn == j.Name {
+					return fmt.Errorf("job %q: task %q inputs_from %q cannot reference its own job", j.Name, t.Name, ref)
+				}
+				uj, ok := jobsByName[jn]
+				if !ok {
+					return fmt.Errorf("job %q: task %q inputs_from %q references job %q which does not exist", j.Name, t.Name, ref, jn)
+				}
+				ut, ok := uj.Task(tn)
+				if !ok {
+					return fmt.Errorf("job %q: task %q inputs_from %q references task %q which does not exist", j.Name, t.Name, ref, tn)
+				}
+				if len(ut.Outputs) == 0 {
+					return fmt.Errorf("job %q: task %q inputs_from %q references task %q which has no outputs", j.Name, t.Name, ref, tn)
+				}
+				// Each cell of the matrix uploads the outputs of the
+				// task, so there is no single artifact to restore
+				if len(uj.Matrix) != 0 {
+					return fmt.Errorf("job %q: task %q inputs_from %q references job %q which has a matrix", j.Name, t.Name, ref, jn)
+				}
+			}
+		}
+	}
+	return nil
+}
 
 // jobHooks holds parsed hook steps for a job.
 type jobHooks struct {
@@ -530,7 +871,7 @@ type jobHooks struct {
 // hook type (on_success, on_failure, ensure) within the given AST block.
 // Labeled blocks (e.g. on_success "exec" { ... }) are runner commands.
 // Unlabeled blocks (e.g. on_success { put "type" "name" { ... } }) contain put steps.
-func parseHooks(block *hclsyntax.Block, ectx *hcl.EvalContext, hookType string) []job.HookStep {
+func parseHooks(block *hclsyntax.Block, src []byte, ectx *hcl.EvalContext, hookType string) ([]job.HookStep, error) {
 	var steps []job.HookStep
 	for _, b := range block.Body.Blocks {
 		if b.Type != hookType {
@@ -544,6 +885,9 @@ func parseHooks(block *hclsyntax.Block, ectx *hcl.EvalContext, hookType string)
 				Params: make(map[string]string),
 			}
 			for name, attr := range b.Body.Attributes {
+				if name == "when" {
+					continue
+				}
 				val, vdiags := attr.Expr.Value(ectx)
 				if vdiags.HasErrors() {
 					continue
@@ -562,9 +906,14 @@ func parseHooks(block *hclsyntax.Block, ectx *hcl.EvalContext, hookType string)
 					rc.Params[name] = val.AsString()
 				}
 			}
+			when, err := parseWhen(b.Body, src, ectx)
+			if err != nil {
+				return nil, fmt.Errorf("%s %q: %w", hookType, b.Labels[0], err)
+			}
 			steps = append(steps, job.HookStep{
 				Type:   job.StepTypeRunner,
 				Runner: &rc,
+				When:   when,
 			})
 		} else if len(b.Labels) == 0 {
 			// Unlabeled hook: contains put blocks
@@ -576,12 +925,19 @@ func parseHooks(block *hclsyntax.Block, ectx *hcl.EvalContext, hookType string)
 				putName := inner.Labels[1]
 				params := make(map[string]string)
 				for name, attr := range inner.Body.Attributes {
+					if name == "when" {
+						continue
+					}
 					val, vdiags := attr.Expr.Value(ectx)
 					if vdiags.HasErrors() {
 						continue
 					}
 					params[name] = val.AsString()
 				}
+				when, err := parseWhen(inner.Body, src, ectx)
+				if err != nil {
+					return nil, fmt.Errorf("%s put %q: %w", hookType, putName, err)
+				}
 				steps = append(steps, job.HookStep{
 					Type: job.StepTypePut,
 					Put: &job.PutStep{
@@ -589,25 +945,134 @@ func parseHooks(block *hclsyntax.Block, ectx *hcl.EvalContext, hookType string)
 						Name:   putName,
 						Params: params,
 					},
+					When: when,
 				})
 			}
 		}
 	}
-	return steps
+	return steps, nil
 }
 
+// parseStepWhen returns the when condition and all the hooks of the given AST block
+func parseStepWhen(block *hclsyntax.Block, src []byte, ectx *hcl.EvalContext) (*job.When, jobHooks, error) {
+	when, err := parseWhen(block.Body, src, ectx)
+	if err != nil {
+		return nil, jobHooks{}, err
+	}
+	jh, err := parseStepHooks(block, src, ectx)
+	if err != nil {
+		return nil, jobHooks{}, err
+	}
+	return when, jh, nil
+}
+
+// parseStepHooks returns all the hooks of the given AST block
+func parseStepHooks(block *hclsyntax.Block, src []byte, ectx *hcl.EvalContext) (jobHooks, error) {
+	var (
+		jh  jobHooks
+		err error
+	)
+	jh.OnSuccess, err = parseHooks(block, src, ectx, "on_success")
+	if err != nil {
+		return jobHooks{}, err
+	}
+	jh.OnFailure, err = parseHooks(block, src, ectx, "on_failure")
+	if err != nil {
+		return jobHooks{}, err
+	}
+	jh.Ensure, err = parseHooks(block, src, ectx, "ensure")
+	if err != nil {
+		return jobHooks{}, err
+	}
+	return jh, nil
+}
+
+// whenRoots are the variables of the when conditions
+// that are only known by the worker running the build
+var whenRoots = map[string]bool{
+	"version":             true,
+	"versions":            true,
+	"steps":               true,
+	"BUILD_NUMBER":        true,
+	"BUILD_JOB_NAME":      true,
+	"BUILD_PIPELINE_NAME": true,
+	"BUILD_TEAM_NAME":     true,
+	"BUILD_STATUS":        true,
+}
+
+// parseWhen returns the condition of the when attribute of body, or nil if
+// it has none. The values of the var and matrix variables it uses are
+// stored with it as they are not known by the worker running the build.
+func parseWhen(body *hclsyntax.Body, src []byte, ectx *hcl.EvalContext) (*job.When, error) {
+	attr, ok := body.Attributes["when"]
+	if !ok {
+		return nil, nil
+	}
+
+	vars := make(map[string]interface{})
+	for _, tr := range attr.Expr.Variables() {
+		root := tr.RootName()
+		if whenRoots[root] {
+			continue
+		}
+		if _, diags := tr[:1].TraverseAbs(ectx); diags.HasErrors() {
+			return nil, fmt.Errorf("invalid when: %s", diags.Error())
+		}
+		if len(tr) < 2 {
+			return nil, fmt.Errorf("invalid when: %q can only be used with one of its attributes", root)
+		}
+		name, ok := traverserName(tr[1])
+		if !ok {
+			return nil, fmt.Errorf("invalid when: %q can only be used with one of its attributes", root)
+		}
+
+		val, diags := hcl.Traversal{tr[0], tr[1]}.TraverseAbs(ectx)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid when: %s", diags.Error())
+		}
+		switch val.Type() {
+		case cty.String:
+			vars[root+"."+name] = val.AsString()
+		case cty.Number:
+			f, _ := val.AsBigFloat().Float64()
+			vars[root+"."+name] = f
+		case cty.Bool:
+			vars[root+"."+name] = val.True()
+		default:
+			return nil, fmt.Errorf("invalid when: %s.%s has an unsupported type", root, name)
+		}
+	}
+	if len(vars) == 0 {
+		vars = nil
+	}
+
+	rng := attr.Expr.Range()
+	return &job.When{
+		Expr: string(src[rng.Start.Byte:rng.End.Byte]),
+		Vars: vars,
+	}, nil
+}
 
 // parseJobPlans walks the raw HCL AST to extract get/task/put blocks in source
 // order for each job, then builds ordered PlanStep slices using the decoded data.
-func parseJobPlans(rpp []byte, ectx *hcl.EvalContext, hclJobs []hclJob, services []service.Service) (map[string][]job.PlanStep, map[string]jobHooks, []service.Service, error) {
+// The jobs with a matrix are decoded again for each of its cells, with their
+// values on the matrix variable, and have the plan of the first one.
+func parseJobPlans(rpp []byte, ectx *hcl.EvalContext, hclJobs []hclJob, services []service.Service) (map[string][]job.PlanStep, map[string]jobHooks, map[string][]job.MatrixCell, []service.Service, error) {
 	file, diags := hclsyntax.ParseConfig(rpp, "pipeline.hcl", hcl.Pos{Line: 1, Column: 1})
 	if diags.HasErrors() {
-		return nil, nil, nil, diags
+		return nil, nil, nil, nil, diags
 	}
 
 	body := file.Body.(*hclsyntax.Body)
 	result := make(map[string][]job.PlanStep)
 	jobHooksMap := make(map[string]jobHooks)
+	matrices := make(map[string][]job.MatrixCell)
+
+	// Build a lookup for services by name
+	serviceByName := make(map[string]service.Service)
+	for _, s := range services {
+		serviceByName[s.Name] = s
+	}
 
 	jobIndex := 0
 	for _, block := range body.Blocks {
@@ -620,172 +1085,338 @@ func parseJobPlans(rpp []byte, ectx *hcl.EvalContext, hclJobs []hclJob, services
 		hj := hclJobs[jobIndex]
 		jobIndex++
 
-		// Build a lookup for services by name
-		serviceByName := make(map[string]service.Service)
-		for _, s := range services {
-			serviceByName[s.Name] = s
+		if hj.Matrix == nil {
+			plan, jh, err := parseJobPlan(block, rpp, ectx, hj, serviceByName)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			result[hj.Name] = plan
+			jobHooksMap[hj.Name] = jh
+			continue
 		}
 
-		var plan []job.PlanStep
-		getIdx, taskIdx, putIdx, serviceIdx := 0, 0, 0, 0
+		cells, err := matrixCells(hj.Matrix, ectx)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("job %q: %w", hj.Name, err)
+		}
+		for i, c := range cells {
+			cctx := ectx.NewChild()
+			cctx.Variables = map[string]cty.Value{
+				"matrix": matrixValue(c.Values),
+			}
+			var chj hclJob
+			diags := gohcl.DecodeBody(block.Body, cctx, &chj)
+			if diags.HasErrors() {
+				return nil, nil, nil, nil, fmt.Errorf("job %q: failed to decode matrix cell %q: %s", hj.Name, c.Name, diags.Error())
+			}
+			chj.Name = hj.Name
 
-		for _, innerBlock := range block.Body.Blocks {
-			switch innerBlock.Type {
-			case "service":
-				if serviceIdx >= len(hj.Service) {
-					continue
-				}
-				sr := hj.Service[serviceIdx]
-				serviceIdx++
+			plan, jh, err := parseJobPlan(block, rpp, cctx, chj, serviceByName)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			cc, err := chj.Cache.toCache()
+			if err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("job %q: %w", hj.Name, err)
+			}
+			cells[i].Plan = plan
+			cells[i].Cache = cc
 
-				if _, ok := serviceByName[sr.Name]; !ok {
-					return nil, nil, nil, fmt.Errorf("service_type %q referenced in job %q does not exist", sr.Name, hj.Name)
-				}
+			if i == 0 {
+				result[hj.Name] = plan
+				jobHooksMap[hj.Name] = jh
+			}
+		}
+		matrices[hj.Name] = cells
+	}
 
-				// Parse param overrides from the remain body
-				params := make(map[string]string)
-				if sr.Remain != nil {
-					if body, ok := sr.Remain.(*hclsyntax.Body); ok {
-						for name, attr := range body.Attributes {
-							val, vdiags := attr.Expr.Value(nil)
-							if vdiags.HasErrors() {
-								return nil, nil, nil, fmt.Errorf("failed to evaluate service param %q: %s", name, vdiags.Error())
-							}
-							params[name] = val.AsString()
-						}
-					}
-				}
+	return result, jobHooksMap, matrices, services, nil
+}
 
-				var paramMap map[string]string
-				if len(params) > 0 {
-					paramMap = params
-				}
+// parseJobPlan builds the ordered plan and the hooks of the job
+// block, using the decoded hj and evaluating the hooks with ectx
+func parseJobPlan(block *hclsyntax.Block, src []byte, ectx *hcl.EvalContext, hj hclJob, serviceByName map[string]service.Service) ([]job.PlanStep, jobHooks, error) {
+	var plan []job.PlanStep
+	serviceIdx, parallelIdx := 0, 0
+	hs := &hclPlanSteps{Get: hj.Get, Task: hj.Task, Put: hj.Put}
 
-				plan = append(plan, job.PlanStep{
-					Type: job.StepTypeService,
-					Service: &job.ServiceStep{
-						Name:   sr.Name,
-						Params: paramMap,
-					},
-				})
-			case "get":
-				if getIdx >= len(hj.Get) {
-					continue
-				}
-				g := hj.Get[getIdx]
-				getIdx++
-				var timeout time.Duration
-				if g.Timeout != "" {
-					var err error
-					timeout, err = time.ParseDuration(g.Timeout)
-					if err != nil {
-						return nil, nil, nil, fmt.Errorf("invalid timeout %q on get step %q: %w", g.Timeout, g.Name, err)
-					}
-				}
-				if g.Attempts < 0 {
-					return nil, nil, nil, fmt.Errorf("invalid attempts %d on get step %q: must be >= 0", g.Attempts, g.Name)
-				}
-				plan = append(plan, job.PlanStep{
-					Type:     job.StepTypeGet,
-					Timeout:  timeout,
-					Attempts: g.Attempts,
-					Get: &job.GetStep{
-						Type:    g.Type,
-						Name:    g.Name,
-						Passed:  g.Passed,
-						Trigger: g.Trigger,
-					},
-					OnSuccess: parseHooks(innerBlock, ectx, "on_success"),
-					OnFailure: parseHooks(innerBlock, ectx, "on_failure"),
-					Ensure:    parseHooks(innerBlock, ectx, "ensure"),
-				})
-			case "task":
-				if taskIdx >= len(hj.Task) {
-					continue
-				}
-				t := hj.Task[taskIdx]
-				taskIdx++
-				var timeout time.Duration
-				if t.Timeout != "" {
-					var err error
-					timeout, err = time.ParseDuration(t.Timeout)
-					if err != nil {
-						return nil, nil, nil, fmt.Errorf("invalid timeout %q on task step %q: %w", t.Timeout, t.Name, err)
-					}
-				}
-				if t.Attempts < 0 {
-					return nil, nil, nil, fmt.Errorf("invalid attempts %d on task step %q: must be >= 0", t.Attempts, t.Name)
-				}
-				plan = append(plan, job.PlanStep{
-					Type:     job.StepTypeTask,
-					Timeout:  timeout,
-					Attempts: t.Attempts,
-					Task: &job.TaskStep{
-						Name:    t.Name,
-						Run:     t.Run,
-						Inputs:  t.Inputs,
-						Outputs: t.Outputs,
-					},
-					OnSuccess: parseHooks(innerBlock, ectx, "on_success"),
-					OnFailure: parseHooks(innerBlock, ectx, "on_failure"),
-					Ensure:    parseHooks(innerBlock, ectx, "ensure"),
-				})
-			case "put":
-				if putIdx >= len(hj.Put) {
-					continue
-				}
-				p := hj.Put[putIdx]
-				putIdx++
-				var timeout time.Duration
-				if p.Timeout != "" {
+	for _, innerBlock := range block.Body.Blocks {
+		switch innerBlock.Type {
+		case "service":
+			if serviceIdx >= len(hj.Service) {
+				continue
+			}
+			sr := hj.Service[serviceIdx]
+			serviceIdx++
+
+			if _, ok := serviceByName[sr.Name]; !ok {
+				return nil, jobHooks{}, fmt.Errorf("service_type %q referenced in job %q does not exist", sr.Name, hj.Name)
+			}
+
+			// Parse param overrides from the remain body
+			params := make(map[string]string)
+			var when *job.When
+			if sr.Remain != nil {
+				if body, ok := sr.Remain.(*hclsyntax.Body); ok {
 					var err error
-					timeout, err = time.ParseDuration(p.Timeout)
+					when, err = parseWhen(body, src, ectx)
 					if err != nil {
-						return nil, nil, nil, fmt.Errorf("invalid timeout %q on put step %q: %w", p.Timeout, p.Name, err)
-					}
-				}
-				if p.Attempts < 0 {
-					return nil, nil, nil, fmt.Errorf("invalid attempts %d on put step %q: must be >= 0", p.Attempts, p.Name)
-				}
-				// Extract put params from AST attributes (exclude known fields)
-				putParams := make(map[string]string)
-				for name, attr := range innerBlock.Body.Attributes {
-					if name == "timeout" || name == "attempts" {
-						continue
+						return nil, jobHooks{}, fmt.Errorf("service %q in job %q: %w", sr.Name, hj.Name, err)
 					}
-					val, vdiags := attr.Expr.Value(ectx)
-					if vdiags.HasErrors() {
-						continue
+					for name, attr := range body.Attributes {
+						if name == "when" {
+							continue
+						}
+						val, vdiags := attr.Expr.Value(nil)
+						if vdiags.HasErrors() {
+							return nil, jobHooks{}, fmt.Errorf("failed to evaluate service param %q: %s", name, vdiags.Error())
+						}
+						params[name] = val.AsString()
 					}
-					putParams[name] = val.AsString()
 				}
+			}
 
-				plan = append(plan, job.PlanStep{
-					Type:     job.StepTypePut,
-					Timeout:  timeout,
-					Attempts: p.Attempts,
-					Put: &job.PutStep{
-						Type:   p.Type,
-						Name:   p.Name,
-						Params: putParams,
-					},
-					OnSuccess: parseHooks(innerBlock, ectx, "on_success"),
-					OnFailure: parseHooks(innerBlock, ectx, "on_failure"),
-					Ensure:    parseHooks(innerBlock, ectx, "ensure"),
-				})
+			var paramMap map[string]string
+			if len(params) > 0 {
+				paramMap = params
+			}
+
+			plan = append(plan, job.PlanStep{
+				Type: job.StepTypeService,
+				Service: &job.ServiceStep{
+					Name:   sr.Name,
+					Params: paramMap,
+				},
+				When: when,
+			})
+		case "in_parallel":
+			if parallelIdx >= len(hj.InParallel) {
+				continue
+			}
+			hp := hj.InParallel[parallelIdx]
+			parallelIdx++
+
+			ps, err := parseParallelStep(innerBlock, src, ectx, hp)
+			if err != nil {
+				return nil, jobHooks{}, fmt.Errorf("job %q: %w", hj.Name, err)
+			}
+			plan = append(plan, *ps)
+		default:
+			ps, err := parsePlanStep(innerBlock, src, ectx, hs)
+			if err != nil {
+				return nil, jobHooks{}, err
+			}
+			if ps != nil {
+				plan = append(plan, *ps)
 			}
 		}
+	}
 
-		result[hj.Name] = plan
+	// Parse job-level hooks
+	jh, err := parseStepHooks(block, src, ectx)
+	if err != nil {
+		return nil, jobHooks{}, fmt.Errorf("job %q: %w", hj.Name, err)
+	}
 
-		// Parse job-level hooks
-		jh := jobHooks{
-			OnSuccess: parseHooks(block, ectx, "on_success"),
-			OnFailure: parseHooks(block, ectx, "on_failure"),
-			Ensure:    parseHooks(block, ectx, "ensure"),
+	return plan, jh, nil
+}
+
+// parseParallelStep builds the StepTypeParallel PlanStep of an in_parallel
+// block, with its get/task/put steps in source order.
+func parseParallelStep(block *hclsyntax.Block, src []byte, ectx *hcl.EvalContext, hp hclParallel) (*job.PlanStep, error) {
+	if hp.Limit < 0 {
+		return nil, fmt.Errorf("invalid limit %d on in_parallel: must be >= 0", hp.Limit)
+	}
+
+	hs := &hclPlanSteps{Get: hp.Get, Task: hp.Task, Put: hp.Put}
+	var steps []job.PlanStep
+	for _, innerBlock := range block.Body.Blocks {
+		switch innerBlock.Type {
+		case "get", "task", "put":
+			ps, err := parsePlanStep(innerBlock, src, ectx, hs)
+			if err != nil {
+				return nil, err
+			}
+			if ps != nil {
+				steps = append(steps, *ps)
+			}
+		case "on_success", "on_failure", "ensure":
+		default:
+			return nil, fmt.Errorf("in_parallel only supports get, task and put steps, found %q", innerBlock.Type)
 		}
-		jobHooksMap[hj.Name] = jh
 	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("in_parallel must have at least one step")
+	}
+	when, hooks, err := parseStepWhen(block, src, ectx)
+	if err != nil {
+		return nil, fmt.Errorf("in_parallel: %w", err)
+	}
+
+	return &job.PlanStep{
+		Type: job.StepTypeParallel,
+		Parallel: &job.ParallelStep{
+			Limit:    hp.Limit,
+			FailFast: hp.FailFast,
+			Steps:    steps,
+		},
+		OnSuccess: hooks.OnSuccess,
+		OnFailure: hooks.OnFailure,
+		Ensure:    hooks.Ensure,
+		When:      when,
+	}, nil
+}
 
-	return result, jobHooksMap, services, nil
+// hclPlanSteps holds the HCL-decoded get/task/put steps of a job or an
+// in_parallel block, which are consumed in order by parsePlanStep.
+type hclPlanSteps struct {
+	Get  []hclGetStep
+	Task []hclTaskStep
+	Put  []hclPutStep
+
+	getIdx, taskIdx, putIdx int
+}
+
+// parsePlanStep builds the PlanStep for a get/task/put innerBlock using the
+// next decoded step of the same type. It returns nil for any other block.
+func parsePlanStep(innerBlock *hclsyntax.Block, src []byte, ectx *hcl.EvalContext, hs *hclPlanSteps) (*job.PlanStep, error) {
+	switch innerBlock.Type {
+	case "get":
+		if hs.getIdx >= len(hs.Get) {
+			return nil, nil
+		}
+		g := hs.Get[hs.getIdx]
+		hs.getIdx++
+		var timeout time.Duration
+		if g.Timeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(g.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout %q on get step %q: %w", g.Timeout, g.Name, err)
+			}
+		}
+		if g.Attempts < 0 {
+			return nil, fmt.Errorf("invalid attempts %d on get step %q: must be >= 0", g.Attempts, g.Name)
+		}
+		when, hooks, err := parseStepWhen(innerBlock, src, ectx)
+		if err != nil {
+			return nil, fmt.Errorf("get step %q: %w", g.Name, err)
+		}
+		return &job.PlanStep{
+			Type:     job.StepTypeGet,
+			Timeout:  timeout,
+			Attempts: g.Attempts,
+			Get: &job.GetStep{
+				Type:    g.Type,
+				Name:    g.Name,
+				Passed:  g.Passed,
+				Trigger: g.Trigger,
+			},
+			OnSuccess: hooks.OnSuccess,
+			OnFailure: hooks.OnFailure,
+			Ensure:    hooks.Ensure,
+			When:      when,
+		}, nil
+	case "task":
+		if hs.taskIdx >= len(hs.Task) {
+			return nil, nil
+		}
+		t := hs.Task[hs.taskIdx]
+		hs.taskIdx++
+		var timeout time.Duration
+		if t.Timeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(t.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout %q on task step %q: %w", t.Timeout, t.Name, err)
+			}
+		}
+		if t.Attempts < 0 {
+			return nil, fmt.Errorf("invalid attempts %d on task step %q: must be >= 0", t.Attempts, t.Name)
+		}
+		for _, o := range t.Outputs {
+			if !filepath.IsLocal(filepath.Clean(o)) {
+				return nil, fmt.Errorf("task step %q: invalid output %q: must be relative to the workdir", t.Name, o)
+			}
+		}
+		tc, err := t.Cache.toCache()
+		if err != nil {
+			return nil, fmt.Errorf("task step %q: %w", t.Name, err)
+		}
+		tr, err := t.Reports.toReports()
+		if err != nil {
+			return nil, fmt.Errorf("task step %q: %w", t.Name, err)
+		}
+		when, hooks, err := parseStepWhen(innerBlock, src, ectx)
+		if err != nil {
+			return nil, fmt.Errorf("task step %q: %w", t.Name, err)
+		}
+		return &job.PlanStep{
+			Type:     job.StepTypeTask,
+			Timeout:  timeout,
+			Attempts: t.Attempts,
+			Task: &job.TaskStep{
+				Name:       t.Name,
+				Run:        t.Run,
+				Inputs:     t.Inputs,
+				Outputs:    t.Outputs,
+				InputsFrom: t.InputsFrom,
+				Cache:      tc,
+				Reports:    tr,
+			},
+			OnSuccess: hooks.OnSuccess,
+			OnFailure: hooks.OnFailure,
+			Ensure:    hooks.Ensure,
+			When:      when,
+		}, nil
+	case "put":
+		if hs.putIdx >= len(hs.Put) {
+			return nil, nil
+		}
+		p := hs.Put[hs.putIdx]
+		hs.putIdx++
+		var timeout time.Duration
+		if p.Timeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(p.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout %q on put step %q: %w", p.Timeout, p.Name, err)
+			}
+		}
+		if p.Attempts < 0 {
+			return nil, fmt.Errorf("invalid attempts %d on put step %q: must be >= 0", p.Attempts, p.Name)
+		}
+		// Extract put params from AST attributes (exclude known fields)
+		putParams := make(map[string]string)
+		for name, attr := range innerBlock.Body.Attributes {
+			if name == "timeout" || name == "attempts" || name == "when" {
+				continue
+			}
+			val, vdiags := attr.Expr.Value(ectx)
+			if vdiags.HasErrors() {
+				continue
+			}
+			putParams[name] = val.AsString()
+		}
+		when, hooks, err := parseStepWhen(innerBlock, src, ectx)
+		if err != nil {
+			return nil, fmt.Errorf("put step %q: %w", p.Name, err)
+		}
+
+		return &job.PlanStep{
+			Type:     job.StepTypePut,
+			Timeout:  timeout,
+			Attempts: p.Attempts,
+			Put: &job.PutStep{
+				Type:   p.Type,
+				Name:   p.Name,
+				Params: putParams,
+			},
+			OnSuccess: hooks.OnSuccess,
+			OnFailure: hooks.OnFailure,
+			Ensure:    hooks.Ensure,
+			When:      when,
+		}, nil
+	}
+	return nil, nil
 }
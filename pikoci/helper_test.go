@@ -5,10 +5,15 @@ import (
 
 	"github.com/xescugc/pikoci/pikoci"
 	"github.com/xescugc/pikoci/pikoci/mock"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	"github.com/xescugc/pikoci/pikoci/unitwork"
 	"go.uber.org/mock/gomock"
 )
 
+// testSecretKey is the key the Secrets of the
+// tests are encrypted with
+const testSecretKey = "test:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
 type MockService struct {
 	Topic         *mock.Topic
 	Users         *mock.UserRepository
@@ -20,6 +25,11 @@ type MockService struct {
 	Builds        *mock.BuildRepository
 	Runners       *mock.RunnerRepository
 	SecretTypes *mock.SecretTypeRepository
+	Workers       *mock.WorkerRepository
+	Locks         *mock.LockRepository
+	Secrets       *mock.SecretRepository
+	Tokens        *mock.APITokenRepository
+	Artifacts     *mock.ArtifactStore
 
 	S pikoci.Service
 	P *pikoci.PikoCI
@@ -35,6 +45,11 @@ func newService(ctrl *gomock.Controller) MockService {
 	br := mock.NewBuildRepository(ctrl)
 	rur := mock.NewRunnerRepository(ctrl)
 	str := mock.NewSecretTypeRepository(ctrl)
+	wr := mock.NewWorkerRepository(ctrl)
+	lr := mock.NewLockRepository(ctrl)
+	sr := mock.NewSecretRepository(ctrl)
+	tkr := mock.NewAPITokenRepository(ctrl)
+	as := mock.NewArtifactStore(ctrl)
 	t := mock.NewTopic(ctrl)
 
 	suow := unitwork.NewNoopStartUnitOfWork(unitwork.Repositories{
@@ -47,9 +62,15 @@ func newService(ctrl *gomock.Controller) MockService {
 		BuildsRepo:        br,
 		RunnersRepo:       rur,
 		SecretTypesRepo: str,
+		LocksRepo:       lr,
 	})
 
-	p := pikoci.New(context.TODO(), t, ur, tr, pr, jr, rr, rtr, br, rur, str, suow, []byte("test-secret"), nil)
+	sk, err := secret.NewKeyring([]string{testSecretKey})
+	if err != nil {
+		panic(err)
+	}
+
+	p := pikoci.New(context.TODO(), t, ur, tr, pr, jr, rr, rtr, br, rur, str, wr, lr, sr, tkr, as, suow, []byte("test-secret"), sk, nil)
 	return MockService{
 		Topic:         t,
 		Users:         ur,
@@ -61,6 +82,11 @@ func newService(ctrl *gomock.Controller) MockService {
 		Builds:        br,
 		Runners:       rur,
 		SecretTypes: str,
+		Workers:       wr,
+		Locks:         lr,
+		Secrets:       sr,
+		Tokens:        tkr,
+		Artifacts:     as,
 
 		S: p,
 		P: p,
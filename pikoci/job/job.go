@@ -1,6 +1,7 @@
 package job
 
 import (
+	"strings"
 	"time"
 
 	"github.com/xescugc/pikoci/pikoci/utils"
@@ -14,6 +15,8 @@ const (
 	StepTypePut     StepType = "put"
 	StepTypeService StepType = "service"
 	StepTypeRunner  StepType = "runner"
+	// StepTypeParallel groups get/task/put steps that run concurrently
+	StepTypeParallel StepType = "parallel"
 )
 
 // HookStep represents a single step inside a hook (on_success, on_failure, ensure).
@@ -22,23 +25,74 @@ type HookStep struct {
 	Type   StepType             `json:"type"`
 	Runner *utils.RunnerCommand `json:"runner,omitempty"`
 	Put    *PutStep             `json:"put,omitempty"`
+	When   *When                `json:"when,omitempty"`
+}
+
+// When is the condition of a step, an HCL expression evaluated by the
+// worker right before running the step, which is skipped if it's false.
+// Vars has the values of the variables known when the pipeline is set
+// (var.* and matrix.*) that Expr uses, keyed by their full name.
+type When struct {
+	Expr string                 `json:"expr"`
+	Vars map[string]interface{} `json:"vars,omitempty"`
 }
 
 type Job struct {
 	ID          uint32     `json:"id"`
 	Name        string     `json:"name" hcl:"name,label"`
 	Concurrency int        `json:"concurrency,omitempty"`
+	Cache       *Cache     `json:"cache,omitempty"`
+	Paused      bool       `json:"paused,omitempty"`
 	Plan        []PlanStep `json:"plan"`
 
+	// Tags are the tags a worker needs to have to run the Job
+	Tags []string `json:"tags,omitempty"`
+	// Waiting is set when no registered worker has all the Tags,
+	// so the builds of the Job wait on the queue until one does
+	Waiting bool `json:"waiting,omitempty"`
+
+	// SerialGroups are the names of the Team locks the
+	// builds of the Job hold while they run, so the Jobs
+	// sharing one never run at the same time
+	SerialGroups []string `json:"serial_groups,omitempty"`
+
+	// Matrix are the combinations of the values of the matrix
+	// block, each one with the Plan evaluated with them. The
+	// Plan and Cache of the Job are the ones of the first cell
+	Matrix []MatrixCell `json:"matrix,omitempty"`
+
 	OnSuccess []HookStep `json:"on_success,omitempty"`
 	OnFailure []HookStep `json:"on_failure,omitempty"`
 	Ensure    []HookStep `json:"ensure,omitempty"`
 }
 
+// MatrixCell is one of the combinations of the matrix of a Job,
+// the builds of the Job run the Plan of each one of them
+type MatrixCell struct {
+	// Name identifies the cell by its values, like "go=1.25,db=mysql"
+	Name   string            `json:"name"`
+	Values map[string]string `json:"values"`
+	Plan   []PlanStep        `json:"plan"`
+	Cache  *Cache            `json:"cache,omitempty"`
+}
+
+// Steps returns all the plan steps in order, the steps of an
+// in_parallel group are returned right after the group step itself.
+func (j *Job) Steps() []PlanStep {
+	var steps []PlanStep
+	for _, p := range j.Plan {
+		steps = append(steps, p)
+		if p.Type == StepTypeParallel && p.Parallel != nil {
+			steps = append(steps, p.Parallel.Steps...)
+		}
+	}
+	return steps
+}
+
 // GetSteps returns all get steps from the plan in order.
 func (j *Job) GetSteps() []GetStep {
 	var steps []GetStep
-	for _, p := range j.Plan {
+	for _, p := range j.Steps() {
 		if p.Type == StepTypeGet && p.Get != nil {
 			steps = append(steps, *p.Get)
 		}
@@ -68,7 +122,7 @@ func (j *Job) AllPutSteps() []PutStep {
 			}
 		}
 	}
-	for _, p := range j.Plan {
+	for _, p := range j.Steps() {
 		if p.Type == StepTypePut {
 			add(p.Put)
 		}
@@ -85,7 +139,7 @@ func (j *Job) AllPutSteps() []PutStep {
 // PlanGetSteps returns all PlanSteps that are get steps.
 func (j *Job) PlanGetSteps() []PlanStep {
 	var steps []PlanStep
-	for _, p := range j.Plan {
+	for _, p := range j.Steps() {
 		if p.Type == StepTypeGet {
 			steps = append(steps, p)
 		}
@@ -101,9 +155,20 @@ type PlanStep struct {
 	Task      *TaskStep             `json:"task,omitempty"`
 	Put       *PutStep              `json:"put,omitempty"`
 	Service   *ServiceStep          `json:"service,omitempty"`
+	Parallel  *ParallelStep         `json:"parallel,omitempty"`
 	OnSuccess []HookStep `json:"on_success,omitempty"`
 	OnFailure []HookStep `json:"on_failure,omitempty"`
 	Ensure    []HookStep `json:"ensure,omitempty"`
+	When      *When      `json:"when,omitempty"`
+}
+
+// ParallelStep runs its Steps concurrently, at most Limit at the
+// same time (0 means all of them). With FailFast the first failure
+// cancels the rest of the steps.
+type ParallelStep struct {
+	Limit    int        `json:"limit,omitempty"`
+	FailFast bool       `json:"fail_fast,omitempty"`
+	Steps    []PlanStep `json:"steps"`
 }
 
 type GetStep struct {
@@ -122,6 +187,66 @@ type TaskStep struct {
 	Run     utils.RunnerCommand `json:"run" hcl:"run,block"`
 	Inputs  []string            `json:"inputs,omitempty"`
 	Outputs []string            `json:"outputs,omitempty"`
+
+	// InputsFrom references the outputs of tasks from other jobs
+	// with the format "job.task", they are restored on the workdir
+	// before the task runs
+	InputsFrom []string `json:"inputs_from,omitempty"`
+
+	Cache *Cache `json:"cache,omitempty"`
+
+	// Reports are the files with the test results the
+	// task produces, uploaded after the task runs
+	Reports *Reports `json:"reports,omitempty"`
+}
+
+// Reports has the glob patterns, relative to the workdir,
+// of the test report files of a task by format
+type Reports struct {
+	JUnit []string `json:"junit,omitempty"`
+}
+
+// Cache defines paths of the workdir that are persisted on the worker
+// host between builds. Caches are scoped to the pipeline and job (and
+// task when defined on a task) and optionally to a Key, so changing
+// the Key starts from an empty cache. The KeyFiles are glob patterns
+// of files of the workdir whose content is hashed into the Key by
+// the worker when the cache is restored, like a lockfile.
+type Cache struct {
+	Paths    []string `json:"paths"`
+	Key      string   `json:"key,omitempty"`
+	KeyFiles []string `json:"key_files,omitempty"`
+}
+
+// TaskSteps returns all task steps from the plan in order.
+func (j *Job) TaskSteps() []TaskStep {
+	var steps []TaskStep
+	for _, p := range j.Steps() {
+		if p.Type == StepTypeTask && p.Task != nil {
+			steps = append(steps, *p.Task)
+		}
+	}
+	return steps
+}
+
+// Task returns the task step with the name tn from the plan
+func (j *Job) Task(tn string) (*TaskStep, bool) {
+	for _, p := range j.Steps() {
+		if p.Type == StepTypeTask && p.Task != nil && p.Task.Name == tn {
+			return p.Task, true
+		}
+	}
+	return nil, false
+}
+
+// ParseInputsFrom splits an InputsFrom reference "job.task"
+// into the job name and the task name
+func ParseInputsFrom(ref string) (string, string, bool) {
+	jn, tn, ok := strings.Cut(ref, ".")
+	if !ok || jn == "" || tn == "" {
+		return "", "", false
+	}
+	return jn, tn, true
 }
 
 type PutStep struct {
@@ -49,6 +49,46 @@ func TestJob_GetSteps_Empty(t *testing.T) {
 	assert.Nil(t, gets)
 }
 
+func TestJob_Steps_InParallel(t *testing.T) {
+	j := job.Job{
+		Name: "test",
+		Plan: []job.PlanStep{
+			{Type: job.StepTypeGet, Get: &job.GetStep{Type: "git", Name: "repo"}},
+			{
+				Type: job.StepTypeParallel,
+				Parallel: &job.ParallelStep{
+					Steps: []job.PlanStep{
+						{Type: job.StepTypeGet, Get: &job.GetStep{Type: "cron", Name: "timer"}},
+						{Type: job.StepTypeTask, Task: &job.TaskStep{Name: "lint"}},
+						{Type: job.StepTypePut, Put: &job.PutStep{Type: "git", Name: "repo"}},
+					},
+				},
+			},
+			{Type: job.StepTypeTask, Task: &job.TaskStep{Name: "build"}},
+		},
+	}
+
+	steps := j.Steps()
+	require.Len(t, steps, 6)
+	assert.Equal(t, job.StepTypeParallel, steps[1].Type)
+	assert.Equal(t, "timer", steps[2].Get.Name)
+
+	gets := j.GetSteps()
+	require.Len(t, gets, 2)
+	assert.Equal(t, "repo", gets[0].Name)
+	assert.Equal(t, "timer", gets[1].Name)
+
+	tasks := j.TaskSteps()
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "lint", tasks[0].Name)
+	assert.Equal(t, "build", tasks[1].Name)
+
+	_, ok := j.Task("lint")
+	assert.True(t, ok)
+
+	assert.Len(t, j.AllPutSteps(), 1)
+}
+
 func TestJob_PlanGetSteps(t *testing.T) {
 	j := job.Job{
 		Name: "test",
@@ -9,5 +9,6 @@ type Repository interface {
 	Update(ctx context.Context, tc, pn, jn string, j Job) error
 	Find(ctx context.Context, tc, pn, jn string) (*Job, error)
 	Filter(ctx context.Context, tc, pn string) ([]*Job, error)
+	SetPaused(ctx context.Context, tc, pn, jn string, paused bool) error
 	Delete(ctx context.Context, tc, pn, jn string) error
 }
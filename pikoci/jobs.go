@@ -2,16 +2,27 @@ package pikoci
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/xescugc/pikoci/pikoci/job"
 	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/resource"
 	"github.com/xescugc/pikoci/pikoci/utils"
-	"gocloud.dev/pubsub"
 )
 
-func (q *PikoCI) TriggerPipelineJob(ctx context.Context, tc, pn, jn string) error {
+// ErrPipelinePaused is returned when triggering a Job of a paused Pipeline
+var ErrPipelinePaused = errors.New("pipeline is paused")
+
+// ErrJobPaused is returned when triggering a paused Job
+var ErrJobPaused = errors.New("job is paused")
+
+// TriggerPipelineJob queues a new build of the Job jn. The versions map,
+// which can be nil, forces the Version ID to use on each get step (by name)
+// instead of the one the worker would resolve. It returns ErrPipelinePaused
+// or ErrJobPaused if the build would be dropped for being paused.
+func (q *PikoCI) TriggerPipelineJob(ctx context.Context, tc, pn, jn string, versions map[string]uint32) error {
 	if !utils.ValidateCanonical(tc) {
 		return fmt.Errorf("invalid Team Canonical format %q", tc)
 	} else if !utils.ValidateCanonical(pn) {
@@ -25,10 +36,28 @@ func (q *PikoCI) TriggerPipelineJob(ctx context.Context, tc, pn, jn string) erro
 		return fmt.Errorf("failed to Find Job %q on Pipeline %q: %w", jn, pn, err)
 	}
 
+	pp, err := q.Pipelines.Find(ctx, tc, pn)
+	if err != nil {
+		return fmt.Errorf("failed to Find Pipeline %q: %w", pn, err)
+	}
+
+	if pp.Paused {
+		return ErrPipelinePaused
+	} else if j.Paused {
+		return ErrJobPaused
+	}
+
+	err = q.validateTriggerVersions(ctx, tc, pn, j, versions)
+	if err != nil {
+		return err
+	}
+
 	m := queue.Body{
 		TeamCanonical: tc,
 		PipelineName:  pn,
 		JobName:       jn,
+		Versions:      versions,
+		Tags:          j.Tags,
 	}
 
 	// Pin the latest version of the first get-step resource so the version
@@ -38,22 +67,55 @@ func (q *PikoCI) TriggerPipelineJob(ctx context.Context, tc, pn, jn string) erro
 		g := getSteps[0]
 		rCan := g.ResourceCanonical()
 		vers, err := q.Resources.FilterVersions(ctx, tc, pn, rCan)
+		vers = resource.UsableVersions(vers)
 		if err == nil && len(vers) > 0 {
 			m.ResourceCanonical = rCan
 			m.VersionID = vers[len(vers)-1].ID
 		}
 	}
 
-	mb, err := json.Marshal(m)
+	err = q.enqueueJobBuild(ctx, m)
 	if err != nil {
-		return fmt.Errorf("failed to marshal Message Body: %w", err)
+		return fmt.Errorf("failed to Trigger Job %q on Pipeline %q: %w", jn, pn, err)
 	}
 
-	err = q.Topic.Send(ctx, &pubsub.Message{
-		Body: mb,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to Trigger Job %q on Pipeline %q: %w", jn, pn, err)
+	return nil
+}
+
+// validateTriggerVersions checks that each one of the versions is from
+// a get step of the Job j and that the Version can be used
+func (q *PikoCI) validateTriggerVersions(ctx context.Context, tc, pn string, j *job.Job, versions map[string]uint32) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	found := make(map[string]bool)
+	for _, g := range j.GetSteps() {
+		vID, ok := versions[g.Name]
+		if !ok || found[g.Name] {
+			continue
+		}
+		found[g.Name] = true
+
+		rCan := g.ResourceCanonical()
+		vers, err := q.Resources.FilterVersions(ctx, tc, pn, rCan)
+		if err != nil {
+			return fmt.Errorf("failed to list Versions of Resource %q: %w", rCan, err)
+		}
+		idx := slices.IndexFunc(vers, func(v *resource.Version) bool { return v.ID == vID })
+		if idx == -1 {
+			return fmt.Errorf("version %d not found on Resource %q", vID, rCan)
+		} else if vers[idx].Disabled {
+			return fmt.Errorf("version %d of Resource %q is disabled", vID, rCan)
+		} else if !slices.Contains(resource.UsableVersions(vers), vers[idx]) {
+			return fmt.Errorf("resource %q is pinned to another version", rCan)
+		}
+	}
+
+	for sn := range versions {
+		if !found[sn] {
+			return fmt.Errorf("get step %q not found on Job %q", sn, j.Name)
+		}
 	}
 
 	return nil
@@ -73,5 +135,37 @@ func (q *PikoCI) GetPipelineJob(ctx context.Context, tc, pn, jn string) (*job.Jo
 		return nil, fmt.Errorf("failed to Find Job %q on Pipeline %q: %w", jn, pn, err)
 	}
 
+	err = q.setJobWaiting(ctx, j)
+	if err != nil {
+		return nil, err
+	}
+
 	return j, nil
 }
+
+// PauseJob stops the Job jn from being triggered until it's unpaused
+func (q *PikoCI) PauseJob(ctx context.Context, tc, pn, jn string) error {
+	return q.setJobPaused(ctx, tc, pn, jn, true)
+}
+
+// UnpauseJob resumes the Job jn
+func (q *PikoCI) UnpauseJob(ctx context.Context, tc, pn, jn string) error {
+	return q.setJobPaused(ctx, tc, pn, jn, false)
+}
+
+func (q *PikoCI) setJobPaused(ctx context.Context, tc, pn, jn string, paused bool) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateCanonical(jn) {
+		return fmt.Errorf("invalid Job Name format %q", jn)
+	}
+
+	err := q.Jobs.SetPaused(ctx, tc, pn, jn, paused)
+	if err != nil {
+		return fmt.Errorf("failed to set paused on Job %q on Pipeline %q: %w", jn, pn, err)
+	}
+
+	return nil
+}
@@ -7,8 +7,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
 	"github.com/xescugc/pikoci/pikoci/resource"
 	"go.uber.org/mock/gomock"
 	"gocloud.dev/pubsub"
@@ -34,13 +37,13 @@ func TestTriggerPipelineJob_InvalidCanonical(t *testing.T) {
 	s := newService(ctrl)
 	ctx := context.TODO()
 
-	err := s.S.TriggerPipelineJob(ctx, "INVALID", "pp", "jn")
+	err := s.S.TriggerPipelineJob(ctx, "INVALID", "pp", "jn", nil)
 	require.Error(t, err)
 
-	err = s.S.TriggerPipelineJob(ctx, "main", "INVALID", "jn")
+	err = s.S.TriggerPipelineJob(ctx, "main", "INVALID", "jn", nil)
 	require.Error(t, err)
 
-	err = s.S.TriggerPipelineJob(ctx, "main", "pp", "INVALID")
+	err = s.S.TriggerPipelineJob(ctx, "main", "pp", "INVALID", nil)
 	require.Error(t, err)
 }
 
@@ -55,6 +58,43 @@ func TestGetPipelineJob_NotFound(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestGetPipelineJob_Waiting(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers []*registry.Worker
+		waiting bool
+	}{
+		{name: "NoWorkers", waiting: true},
+		{name: "MissingTag", workers: []*registry.Worker{{Name: "w1", Tags: []string{"docker"}}}, waiting: true},
+		{name: "AllTags", workers: []*registry.Worker{{Name: "w1"}, {Name: "w2", Tags: []string{"arm64", "docker", "gpu"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := newService(ctrl)
+			ctx := context.TODO()
+
+			s.Jobs.EXPECT().Find(ctx, "main", "pp", "jn").Return(&job.Job{Name: "jn", Tags: []string{"docker", "arm64"}}, nil)
+			s.Workers.EXPECT().Filter(ctx).Return(tt.workers, nil)
+
+			j, err := s.S.GetPipelineJob(ctx, "main", "pp", "jn")
+			require.NoError(t, err)
+			assert.Equal(t, tt.waiting, j.Waiting)
+		})
+	}
+	t.Run("NoTags", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Jobs.EXPECT().Find(ctx, "main", "pp", "jn").Return(&job.Job{Name: "jn"}, nil)
+
+		j, err := s.S.GetPipelineJob(ctx, "main", "pp", "jn")
+		require.NoError(t, err)
+		assert.False(t, j.Waiting)
+	})
+}
+
 func TestTriggerPipelineJob_NotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
@@ -62,19 +102,48 @@ func TestTriggerPipelineJob_NotFound(t *testing.T) {
 
 	s.Jobs.EXPECT().Find(ctx, "main", "pp", "jn").Return(nil, assert.AnError)
 
-	err := s.S.TriggerPipelineJob(ctx, "main", "pp", "jn")
+	err := s.S.TriggerPipelineJob(ctx, "main", "pp", "jn", nil)
 	require.Error(t, err)
 }
 
+func TestTriggerPipelineJob_Paused(t *testing.T) {
+	t.Run("Pipeline", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Jobs.EXPECT().Find(ctx, "main", "pp", "jn").Return(&job.Job{ID: 1}, nil)
+		s.Pipelines.EXPECT().Find(ctx, "main", "pp").Return(&pipeline.Pipeline{Name: "pp", Paused: true}, nil)
+
+		err := s.S.TriggerPipelineJob(ctx, "main", "pp", "jn", nil)
+		assert.ErrorIs(t, err, pikoci.ErrPipelinePaused)
+	})
+	t.Run("Job", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Jobs.EXPECT().Find(ctx, "main", "pp", "jn").Return(&job.Job{ID: 1, Paused: true}, nil)
+		s.Pipelines.EXPECT().Find(ctx, "main", "pp").Return(&pipeline.Pipeline{Name: "pp"}, nil)
+
+		err := s.S.TriggerPipelineJob(ctx, "main", "pp", "jn", nil)
+		assert.ErrorIs(t, err, pikoci.ErrJobPaused)
+	})
+}
+
 func TestTriggerPipelineJob_SendError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
 
 	s.Jobs.EXPECT().Find(ctx, "main", "pp", "jn").Return(&job.Job{ID: 1}, nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "pp").Return(&pipeline.Pipeline{Name: "pp"}, nil)
+	s.Builds.EXPECT().Create(ctx, "main", "pp", "jn", gomock.Any()).Return(uint32(1), "1", nil)
 	s.Topic.EXPECT().Send(ctx, gomock.Any()).Return(assert.AnError)
+	// Nothing would start the Pending Build without its message
+	s.Builds.EXPECT().Delete(ctx, "main", "pp", "jn", "1").Return(nil)
 
-	err := s.S.TriggerPipelineJob(ctx, "main", "pp", "jn")
+	err := s.S.TriggerPipelineJob(ctx, "main", "pp", "jn", nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to Trigger Job")
 }
@@ -107,6 +176,7 @@ func TestTriggerPipelineJob_PinsLatestVersion(t *testing.T) {
 	rCan := j.GetSteps()[0].ResourceCanonical()
 
 	s.Jobs.EXPECT().Find(ctx, tc, ppn, jn).Return(j, nil)
+	s.Pipelines.EXPECT().Find(ctx, tc, ppn).Return(&pipeline.Pipeline{Name: ppn}, nil)
 	s.Resources.EXPECT().FilterVersions(ctx, tc, ppn, rCan).Return(versions, nil)
 
 	m := queue.Body{
@@ -115,12 +185,154 @@ func TestTriggerPipelineJob_PinsLatestVersion(t *testing.T) {
 		JobName:           jn,
 		ResourceCanonical: rCan,
 		VersionID:         30,
+		BuildNumber:       "1",
 	}
 	mb, err := json.Marshal(m)
 	require.NoError(t, err)
 
+	s.Builds.EXPECT().Create(ctx, tc, ppn, jn, gomock.Any()).Return(uint32(1), "1", nil)
 	s.Topic.EXPECT().Send(ctx, &pubsub.Message{Body: mb}).Return(nil)
 
-	err = s.S.TriggerPipelineJob(ctx, tc, ppn, jn)
+	err = s.S.TriggerPipelineJob(ctx, tc, ppn, jn, nil)
 	require.NoError(t, err)
 }
+
+func TestTriggerPipelineJob_WithVersions(t *testing.T) {
+	tc := "main"
+	ppn := "pp"
+	jn := "jn"
+
+	j := &job.Job{
+		ID:   1,
+		Name: jn,
+		Plan: []job.PlanStep{
+			{
+				Type: job.StepTypeGet,
+				Get:  &job.GetStep{Type: "git", Name: "my-repo", Trigger: true},
+			},
+		},
+	}
+	rCan := "git.my-repo"
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		versions := []*resource.Version{{ID: 10}, {ID: 20}, {ID: 30}}
+
+		s.Jobs.EXPECT().Find(ctx, tc, ppn, jn).Return(j, nil)
+		s.Pipelines.EXPECT().Find(ctx, tc, ppn).Return(&pipeline.Pipeline{Name: ppn}, nil)
+		s.Resources.EXPECT().FilterVersions(ctx, tc, ppn, rCan).Return(versions, nil).Times(2)
+
+		m := queue.Body{
+			TeamCanonical:     tc,
+			PipelineName:      ppn,
+			JobName:           jn,
+			ResourceCanonical: rCan,
+			VersionID:         30,
+			BuildNumber:       "1",
+			Versions:          map[string]uint32{"my-repo": 20},
+		}
+		mb, err := json.Marshal(m)
+		require.NoError(t, err)
+
+		s.Builds.EXPECT().Create(ctx, tc, ppn, jn, gomock.Any()).Return(uint32(1), "1", nil)
+		s.Topic.EXPECT().Send(ctx, &pubsub.Message{Body: mb}).Return(nil)
+
+		err = s.S.TriggerPipelineJob(ctx, tc, ppn, jn, map[string]uint32{"my-repo": 20})
+		require.NoError(t, err)
+	})
+	t.Run("UnknownStep", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Jobs.EXPECT().Find(ctx, tc, ppn, jn).Return(j, nil)
+		s.Pipelines.EXPECT().Find(ctx, tc, ppn).Return(&pipeline.Pipeline{Name: ppn}, nil)
+
+		err := s.S.TriggerPipelineJob(ctx, tc, ppn, jn, map[string]uint32{"other": 20})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `get step "other" not found`)
+	})
+	t.Run("VersionNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Jobs.EXPECT().Find(ctx, tc, ppn, jn).Return(j, nil)
+		s.Pipelines.EXPECT().Find(ctx, tc, ppn).Return(&pipeline.Pipeline{Name: ppn}, nil)
+		s.Resources.EXPECT().FilterVersions(ctx, tc, ppn, rCan).Return([]*resource.Version{{ID: 10}}, nil)
+
+		err := s.S.TriggerPipelineJob(ctx, tc, ppn, jn, map[string]uint32{"my-repo": 20})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "version 20 not found")
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Jobs.EXPECT().Find(ctx, tc, ppn, jn).Return(j, nil)
+		s.Pipelines.EXPECT().Find(ctx, tc, ppn).Return(&pipeline.Pipeline{Name: ppn}, nil)
+		s.Resources.EXPECT().FilterVersions(ctx, tc, ppn, rCan).Return([]*resource.Version{{ID: 10}, {ID: 20, Disabled: true}}, nil)
+
+		err := s.S.TriggerPipelineJob(ctx, tc, ppn, jn, map[string]uint32{"my-repo": 20})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is disabled")
+	})
+	t.Run("Pinned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Jobs.EXPECT().Find(ctx, tc, ppn, jn).Return(j, nil)
+		s.Pipelines.EXPECT().Find(ctx, tc, ppn).Return(&pipeline.Pipeline{Name: ppn}, nil)
+		s.Resources.EXPECT().FilterVersions(ctx, tc, ppn, rCan).Return([]*resource.Version{{ID: 10, Pinned: true}, {ID: 20}}, nil)
+
+		err := s.S.TriggerPipelineJob(ctx, tc, ppn, jn, map[string]uint32{"my-repo": 20})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is pinned")
+	})
+}
+
+func TestPauseJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Jobs.EXPECT().SetPaused(ctx, "main", "pp", "jn", true).Return(nil)
+	s.Jobs.EXPECT().SetPaused(ctx, "main", "pp", "jn", false).Return(nil)
+
+	err := s.S.PauseJob(ctx, "main", "pp", "jn")
+	require.NoError(t, err)
+
+	err = s.S.UnpauseJob(ctx, "main", "pp", "jn")
+	require.NoError(t, err)
+}
+
+func TestPauseJob_InvalidCanonical(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	err := s.S.PauseJob(ctx, "INVALID", "pp", "jn")
+	require.Error(t, err)
+
+	err = s.S.PauseJob(ctx, "main", "INVALID", "jn")
+	require.Error(t, err)
+
+	err = s.S.UnpauseJob(ctx, "main", "pp", "INVALID")
+	require.Error(t, err)
+}
+
+func TestPauseJob_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Jobs.EXPECT().SetPaused(ctx, "main", "pp", "jn", true).Return(assert.AnError)
+
+	err := s.S.PauseJob(ctx, "main", "pp", "jn")
+	require.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package lock
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned by a Repository when no Lock exists with the Name
+	ErrNotFound = errors.New("lock not found")
+
+	// ErrHeld is returned when acquiring a Lock that is already held
+	ErrHeld = errors.New("lock is held")
+)
+
+// Lock is a named lock of a Team, it's held by a Build of the Jobs
+// with it on their serial groups while the Build runs, or by a user
+// that acquired it until it's released
+type Lock struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+
+	// Holder is who holds the Lock, the username or
+	// the 'pipeline/job #build_number' of the Build
+	Holder string `json:"holder"`
+
+	// Reason is a free-form note of why the
+	// Lock was acquired by the user
+	Reason string `json:"reason,omitempty"`
+
+	// BuildID is the Build holding the Lock, it's
+	// released once the Build finishes
+	BuildID uint32 `json:"build_id,omitempty"`
+
+	AcquiredAt time.Time `json:"acquired_at"`
+}
@@ -0,0 +1,20 @@
+package lock
+
+import (
+	"context"
+)
+
+//go:generate go tool mockgen -destination=../mock/lock_repository.go -mock_names=Repository=LockRepository -package mock github.com/xescugc/pikoci/pikoci/lock Repository
+
+type Repository interface {
+	// Create acquires the Lock, it returns ErrHeld
+	// if a Lock with the same Name already exists
+	Create(ctx context.Context, tc string, l Lock) (uint32, error)
+	// Find returns ErrNotFound if the Lock is not held
+	Find(ctx context.Context, tc, name string) (*Lock, error)
+	Filter(ctx context.Context, tc string) ([]*Lock, error)
+	// Delete releases the Lock, it returns ErrNotFound if the Lock is not held
+	Delete(ctx context.Context, tc, name string) error
+	// DeleteByBuild releases all the Locks held by the Build
+	DeleteByBuild(ctx context.Context, buildID uint32) error
+}
@@ -0,0 +1,93 @@
+package pikoci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/lock"
+	"github.com/xescugc/pikoci/pikoci/unitwork"
+	"github.com/xescugc/pikoci/pikoci/utils"
+)
+
+// ErrLockNotHolder is returned when releasing a lock held by someone else,
+// the locks held by the builds are released once the builds finish
+var ErrLockNotHolder = errors.New("lock is not held by the user")
+
+func (q *PikoCI) ListLocks(ctx context.Context, tc string) ([]*lock.Lock, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	}
+
+	ls, err := q.Locks.Filter(ctx, tc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Filter Locks: %w", err)
+	}
+
+	return ls, nil
+}
+
+// AcquireLock acquires the lock for the user un, which is its Holder,
+// the reason is an optional note of why it was acquired
+func (q *PikoCI) AcquireLock(ctx context.Context, tc, name, un, reason string) (*lock.Lock, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(name) {
+		return nil, fmt.Errorf("invalid Lock Name format %q", name)
+	} else if un == "" {
+		return nil, fmt.Errorf("locks can only be acquired by users")
+	}
+
+	l := lock.Lock{
+		Name:       name,
+		Holder:     un,
+		Reason:     reason,
+		AcquiredAt: time.Now().UTC(),
+	}
+	id, err := q.Locks.Create(ctx, tc, l)
+	if err != nil {
+		if errors.Is(err, lock.ErrHeld) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to Create Lock: %w", err)
+	}
+	l.ID = id
+
+	return &l, nil
+}
+
+// ReleaseLock releases the lock only if it was acquired by the user un
+func (q *PikoCI) ReleaseLock(ctx context.Context, tc, name, un string) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(name) {
+		return fmt.Errorf("invalid Lock Name format %q", name)
+	} else if un == "" {
+		return ErrLockNotHolder
+	}
+
+	return q.StartUoW(ctx, func(uow unitwork.UnitOfWork) error {
+		l, err := uow.Locks().Find(ctx, tc, name)
+		if err != nil {
+			return err
+		}
+		if l.BuildID != 0 || l.Holder != un {
+			return ErrLockNotHolder
+		}
+
+		return uow.Locks().Delete(ctx, tc, name)
+	})
+}
+
+// ForceReleaseLock releases the lock whoever holds it, if it's held by
+// a Build the Build keeps running and other Builds can acquire it
+func (q *PikoCI) ForceReleaseLock(ctx context.Context, tc, name string) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(name) {
+		return fmt.Errorf("invalid Lock Name format %q", name)
+	}
+
+	return q.Locks.Delete(ctx, tc, name)
+}
@@ -0,0 +1,129 @@
+package pikoci_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/lock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListLocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	ls := []*lock.Lock{{ID: 1, Name: "staging", Holder: "admin"}}
+	s.Locks.EXPECT().Filter(ctx, "main").Return(ls, nil)
+
+	rls, err := s.S.ListLocks(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, ls, rls)
+}
+
+func TestAcquireLock(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Locks.EXPECT().Create(ctx, "main", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, l lock.Lock) (uint32, error) {
+			assert.Equal(t, "staging", l.Name)
+			assert.Equal(t, "admin", l.Holder)
+			assert.Equal(t, "maintenance", l.Reason)
+			assert.Zero(t, l.BuildID)
+			assert.WithinDuration(t, time.Now(), l.AcquiredAt, time.Second)
+			return 2, nil
+		})
+
+		l, err := s.S.AcquireLock(ctx, "main", "staging", "admin", "maintenance")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(2), l.ID)
+	})
+	t.Run("Held", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Locks.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(0), lock.ErrHeld)
+
+		_, err := s.S.AcquireLock(ctx, "main", "staging", "admin", "")
+		assert.ErrorIs(t, err, lock.ErrHeld)
+	})
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		_, err := s.S.AcquireLock(ctx, "main", "staging", "", "maintenance")
+		assert.Error(t, err)
+	})
+}
+
+func TestReleaseLock(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Locks.EXPECT().Find(ctx, "main", "staging").Return(&lock.Lock{Name: "staging", Holder: "admin"}, nil)
+		s.Locks.EXPECT().Delete(ctx, "main", "staging").Return(nil)
+
+		err := s.S.ReleaseLock(ctx, "main", "staging", "admin")
+		require.NoError(t, err)
+	})
+	t.Run("OtherHolder", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Locks.EXPECT().Find(ctx, "main", "staging").Return(&lock.Lock{Name: "staging", Holder: "other"}, nil)
+
+		err := s.S.ReleaseLock(ctx, "main", "staging", "admin")
+		assert.ErrorIs(t, err, pikoci.ErrLockNotHolder)
+	})
+	t.Run("NoUser", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		err := s.S.ReleaseLock(ctx, "main", "staging", "")
+		assert.ErrorIs(t, err, pikoci.ErrLockNotHolder)
+	})
+	t.Run("HeldByBuild", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		// The locks of the Builds are released once they finish
+		s.Locks.EXPECT().Find(ctx, "main", "staging").Return(&lock.Lock{Name: "staging", Holder: "pp/deploy #1", BuildID: 3}, nil)
+
+		err := s.S.ReleaseLock(ctx, "main", "staging", "pp/deploy #1")
+		assert.ErrorIs(t, err, pikoci.ErrLockNotHolder)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Locks.EXPECT().Find(ctx, "main", "staging").Return(nil, lock.ErrNotFound)
+
+		err := s.S.ReleaseLock(ctx, "main", "staging", "admin")
+		assert.ErrorIs(t, err, lock.ErrNotFound)
+	})
+}
+
+func TestForceReleaseLock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Locks.EXPECT().Delete(ctx, "main", "staging").Return(nil)
+
+	err := s.S.ForceReleaseLock(ctx, "main", "staging")
+	require.NoError(t, err)
+}
@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/pikoci/pikoci/apitoken (interfaces: Repository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/apitoken_repository.go -mock_names=Repository=APITokenRepository -package mock github.com/xescugc/pikoci/pikoci/apitoken Repository
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	apitoken "github.com/xescugc/pikoci/pikoci/apitoken"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// APITokenRepository is a mock of Repository interface.
+type APITokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *APITokenRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// APITokenRepositoryMockRecorder is the mock recorder for APITokenRepository.
+type APITokenRepositoryMockRecorder struct {
+	mock *APITokenRepository
+}
+
+// NewAPITokenRepository creates a new mock instance.
+func NewAPITokenRepository(ctrl *gomock.Controller) *APITokenRepository {
+	mock := &APITokenRepository{ctrl: ctrl}
+	mock.recorder = &APITokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *APITokenRepository) EXPECT() *APITokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *APITokenRepository) Create(ctx context.Context, t apitoken.Token) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, t)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *APITokenRepositoryMockRecorder) Create(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*APITokenRepository)(nil).Create), ctx, t)
+}
+
+// DeleteByTeam mocks base method.
+func (m *APITokenRepository) DeleteByTeam(ctx context.Context, tc string, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByTeam", ctx, tc, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByTeam indicates an expected call of DeleteByTeam.
+func (mr *APITokenRepositoryMockRecorder) DeleteByTeam(ctx, tc, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByTeam", reflect.TypeOf((*APITokenRepository)(nil).DeleteByTeam), ctx, tc, id)
+}
+
+// DeleteByUser mocks base method.
+func (m *APITokenRepository) DeleteByUser(ctx context.Context, un string, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, un, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *APITokenRepositoryMockRecorder) DeleteByUser(ctx, un, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*APITokenRepository)(nil).DeleteByUser), ctx, un, id)
+}
+
+// FilterByTeam mocks base method.
+func (m *APITokenRepository) FilterByTeam(ctx context.Context, tc string) ([]*apitoken.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterByTeam", ctx, tc)
+	ret0, _ := ret[0].([]*apitoken.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterByTeam indicates an expected call of FilterByTeam.
+func (mr *APITokenRepositoryMockRecorder) FilterByTeam(ctx, tc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterByTeam", reflect.TypeOf((*APITokenRepository)(nil).FilterByTeam), ctx, tc)
+}
+
+// FilterByUser mocks base method.
+func (m *APITokenRepository) FilterByUser(ctx context.Context, un string) ([]*apitoken.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterByUser", ctx, un)
+	ret0, _ := ret[0].([]*apitoken.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterByUser indicates an expected call of FilterByUser.
+func (mr *APITokenRepositoryMockRecorder) FilterByUser(ctx, un any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterByUser", reflect.TypeOf((*APITokenRepository)(nil).FilterByUser), ctx, un)
+}
+
+// FindByHash mocks base method.
+func (m *APITokenRepository) FindByHash(ctx context.Context, hash string) (*apitoken.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByHash", ctx, hash)
+	ret0, _ := ret[0].(*apitoken.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByHash indicates an expected call of FindByHash.
+func (mr *APITokenRepositoryMockRecorder) FindByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByHash", reflect.TypeOf((*APITokenRepository)(nil).FindByHash), ctx, hash)
+}
+
+// UpdateLastUsedAt mocks base method.
+func (m *APITokenRepository) UpdateLastUsedAt(ctx context.Context, id uint32, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastUsedAt", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastUsedAt indicates an expected call of UpdateLastUsedAt.
+func (mr *APITokenRepositoryMockRecorder) UpdateLastUsedAt(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastUsedAt", reflect.TypeOf((*APITokenRepository)(nil).UpdateLastUsedAt), ctx, id, at)
+}
@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/pikoci/pikoci/artifact (interfaces: Store)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/artifact_store.go -mock_names=Store=ArtifactStore -package mock github.com/xescugc/pikoci/pikoci/artifact Store
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	artifact "github.com/xescugc/pikoci/pikoci/artifact"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// ArtifactStore is a mock of Store interface.
+type ArtifactStore struct {
+	ctrl     *gomock.Controller
+	recorder *ArtifactStoreMockRecorder
+	isgomock struct{}
+}
+
+// ArtifactStoreMockRecorder is the mock recorder for ArtifactStore.
+type ArtifactStoreMockRecorder struct {
+	mock *ArtifactStore
+}
+
+// NewArtifactStore creates a new mock instance.
+func NewArtifactStore(ctrl *gomock.Controller) *ArtifactStore {
+	mock := &ArtifactStore{ctrl: ctrl}
+	mock.recorder = &ArtifactStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *ArtifactStore) EXPECT() *ArtifactStoreMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *ArtifactStore) Get(ctx context.Context, k artifact.Key) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, k)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *ArtifactStoreMockRecorder) Get(ctx, k any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*ArtifactStore)(nil).Get), ctx, k)
+}
+
+// Put mocks base method.
+func (m *ArtifactStore) Put(ctx context.Context, k artifact.Key, r io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, k, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *ArtifactStoreMockRecorder) Put(ctx, k, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*ArtifactStore)(nil).Put), ctx, k, r)
+}
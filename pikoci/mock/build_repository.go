@@ -42,6 +42,20 @@ func (m *BuildRepository) EXPECT() *BuildRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AppendLogChunk mocks base method.
+func (m *BuildRepository) AppendLogChunk(ctx context.Context, tc, pn, jn, buildNumber string, c build.LogChunk) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendLogChunk", ctx, tc, pn, jn, buildNumber, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendLogChunk indicates an expected call of AppendLogChunk.
+func (mr *BuildRepositoryMockRecorder) AppendLogChunk(ctx, tc, pn, jn, buildNumber, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendLogChunk", reflect.TypeOf((*BuildRepository)(nil).AppendLogChunk), ctx, tc, pn, jn, buildNumber, c)
+}
+
 // CountRunning mocks base method.
 func (m *BuildRepository) CountRunning(ctx context.Context, tc, pn, jn string) (int, error) {
 	m.ctrl.T.Helper()
@@ -89,6 +103,20 @@ func (mr *BuildRepositoryMockRecorder) CreateRetry(ctx, tc, pn, jn, parentBuildN
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRetry", reflect.TypeOf((*BuildRepository)(nil).CreateRetry), ctx, tc, pn, jn, parentBuildNumber, b)
 }
 
+// CreateTestCases mocks base method.
+func (m *BuildRepository) CreateTestCases(ctx context.Context, tc, pn, jn, buildNumber string, cs []build.TestCase) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTestCases", ctx, tc, pn, jn, buildNumber, cs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTestCases indicates an expected call of CreateTestCases.
+func (mr *BuildRepositoryMockRecorder) CreateTestCases(ctx, tc, pn, jn, buildNumber, cs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTestCases", reflect.TypeOf((*BuildRepository)(nil).CreateTestCases), ctx, tc, pn, jn, buildNumber, cs)
+}
+
 // Delete mocks base method.
 func (m *BuildRepository) Delete(ctx context.Context, tc, pn, jn, buildNumber string) error {
 	m.ctrl.T.Helper()
@@ -103,6 +131,20 @@ func (mr *BuildRepositoryMockRecorder) Delete(ctx, tc, pn, jn, buildNumber any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*BuildRepository)(nil).Delete), ctx, tc, pn, jn, buildNumber)
 }
 
+// DeleteLogChunks mocks base method.
+func (m *BuildRepository) DeleteLogChunks(ctx context.Context, tc, pn, jn, buildNumber string, stepIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLogChunks", ctx, tc, pn, jn, buildNumber, stepIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLogChunks indicates an expected call of DeleteLogChunks.
+func (mr *BuildRepositoryMockRecorder) DeleteLogChunks(ctx, tc, pn, jn, buildNumber, stepIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLogChunks", reflect.TypeOf((*BuildRepository)(nil).DeleteLogChunks), ctx, tc, pn, jn, buildNumber, stepIDs)
+}
+
 // Filter mocks base method.
 func (m *BuildRepository) Filter(ctx context.Context, tc, pn, jn string) ([]*build.Build, error) {
 	m.ctrl.T.Helper()
@@ -118,6 +160,81 @@ func (mr *BuildRepositoryMockRecorder) Filter(ctx, tc, pn, jn any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Filter", reflect.TypeOf((*BuildRepository)(nil).Filter), ctx, tc, pn, jn)
 }
 
+// FilterJobTestCases mocks base method.
+func (m *BuildRepository) FilterJobTestCases(ctx context.Context, tc, pn, jn string, builds int) ([]*build.TestCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterJobTestCases", ctx, tc, pn, jn, builds)
+	ret0, _ := ret[0].([]*build.TestCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterJobTestCases indicates an expected call of FilterJobTestCases.
+func (mr *BuildRepositoryMockRecorder) FilterJobTestCases(ctx, tc, pn, jn, builds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterJobTestCases", reflect.TypeOf((*BuildRepository)(nil).FilterJobTestCases), ctx, tc, pn, jn, builds)
+}
+
+// FilterLogChunks mocks base method.
+func (m *BuildRepository) FilterLogChunks(ctx context.Context, tc, pn, jn, buildNumber string, afterID uint32) ([]*build.LogChunk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterLogChunks", ctx, tc, pn, jn, buildNumber, afterID)
+	ret0, _ := ret[0].([]*build.LogChunk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterLogChunks indicates an expected call of FilterLogChunks.
+func (mr *BuildRepositoryMockRecorder) FilterLogChunks(ctx, tc, pn, jn, buildNumber, afterID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterLogChunks", reflect.TypeOf((*BuildRepository)(nil).FilterLogChunks), ctx, tc, pn, jn, buildNumber, afterID)
+}
+
+// FilterPending mocks base method.
+func (m *BuildRepository) FilterPending(ctx context.Context, tc, pn, jn string) ([]*build.WithJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterPending", ctx, tc, pn, jn)
+	ret0, _ := ret[0].([]*build.WithJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterPending indicates an expected call of FilterPending.
+func (mr *BuildRepositoryMockRecorder) FilterPending(ctx, tc, pn, jn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterPending", reflect.TypeOf((*BuildRepository)(nil).FilterPending), ctx, tc, pn, jn)
+}
+
+// FilterStartedByWorker mocks base method.
+func (m *BuildRepository) FilterStartedByWorker(ctx context.Context, workerID uint32) ([]*build.WithJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterStartedByWorker", ctx, workerID)
+	ret0, _ := ret[0].([]*build.WithJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterStartedByWorker indicates an expected call of FilterStartedByWorker.
+func (mr *BuildRepositoryMockRecorder) FilterStartedByWorker(ctx, workerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterStartedByWorker", reflect.TypeOf((*BuildRepository)(nil).FilterStartedByWorker), ctx, workerID)
+}
+
+// FilterTestCases mocks base method.
+func (m *BuildRepository) FilterTestCases(ctx context.Context, tc, pn, jn, buildNumber string) ([]*build.TestCase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterTestCases", ctx, tc, pn, jn, buildNumber)
+	ret0, _ := ret[0].([]*build.TestCase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterTestCases indicates an expected call of FilterTestCases.
+func (mr *BuildRepositoryMockRecorder) FilterTestCases(ctx, tc, pn, jn, buildNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterTestCases", reflect.TypeOf((*BuildRepository)(nil).FilterTestCases), ctx, tc, pn, jn, buildNumber)
+}
+
 // Find mocks base method.
 func (m *BuildRepository) Find(ctx context.Context, tc, pn, jn, buildNumber string) (*build.Build, error) {
 	m.ctrl.T.Helper()
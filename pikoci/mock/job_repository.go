@@ -100,6 +100,20 @@ func (mr *JobRepositoryMockRecorder) Find(ctx, tc, pn, jn any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*JobRepository)(nil).Find), ctx, tc, pn, jn)
 }
 
+// SetPaused mocks base method.
+func (m *JobRepository) SetPaused(ctx context.Context, tc, pn, jn string, paused bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPaused", ctx, tc, pn, jn, paused)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPaused indicates an expected call of SetPaused.
+func (mr *JobRepositoryMockRecorder) SetPaused(ctx, tc, pn, jn, paused any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPaused", reflect.TypeOf((*JobRepository)(nil).SetPaused), ctx, tc, pn, jn, paused)
+}
+
 // Update mocks base method.
 func (m *JobRepository) Update(ctx context.Context, tc, pn, jn string, j job.Job) error {
 	m.ctrl.T.Helper()
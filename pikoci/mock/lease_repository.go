@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/pikoci/pikoci/queue (interfaces: LeaseRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/lease_repository.go -mock_names=LeaseRepository=LeaseRepository -package mock github.com/xescugc/pikoci/pikoci/queue LeaseRepository
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	queue "github.com/xescugc/pikoci/pikoci/queue"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// LeaseRepository is a mock of LeaseRepository interface.
+type LeaseRepository struct {
+	ctrl     *gomock.Controller
+	recorder *LeaseRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// LeaseRepositoryMockRecorder is the mock recorder for LeaseRepository.
+type LeaseRepositoryMockRecorder struct {
+	mock *LeaseRepository
+}
+
+// NewLeaseRepository creates a new mock instance.
+func NewLeaseRepository(ctrl *gomock.Controller) *LeaseRepository {
+	mock := &LeaseRepository{ctrl: ctrl}
+	mock.recorder = &LeaseRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *LeaseRepository) EXPECT() *LeaseRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *LeaseRepository) Create(ctx context.Context, l queue.Lease) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, l)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *LeaseRepositoryMockRecorder) Create(ctx, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*LeaseRepository)(nil).Create), ctx, l)
+}
+
+// Delete mocks base method.
+func (m *LeaseRepository) Delete(ctx context.Context, workerID uint32, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, workerID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *LeaseRepositoryMockRecorder) Delete(ctx, workerID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*LeaseRepository)(nil).Delete), ctx, workerID, id)
+}
+
+// DeleteExpired mocks base method.
+func (m *LeaseRepository) DeleteExpired(ctx context.Context, id string, before time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, id, before)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *LeaseRepositoryMockRecorder) DeleteExpired(ctx, id, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*LeaseRepository)(nil).DeleteExpired), ctx, id, before)
+}
+
+// FilterExpired mocks base method.
+func (m *LeaseRepository) FilterExpired(ctx context.Context, before time.Time) ([]*queue.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterExpired", ctx, before)
+	ret0, _ := ret[0].([]*queue.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterExpired indicates an expected call of FilterExpired.
+func (mr *LeaseRepositoryMockRecorder) FilterExpired(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterExpired", reflect.TypeOf((*LeaseRepository)(nil).FilterExpired), ctx, before)
+}
+
+// Find mocks base method.
+func (m *LeaseRepository) Find(ctx context.Context, workerID uint32, id string) (*queue.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, workerID, id)
+	ret0, _ := ret[0].(*queue.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *LeaseRepositoryMockRecorder) Find(ctx, workerID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*LeaseRepository)(nil).Find), ctx, workerID, id)
+}
+
+// UpdateExpiresAt mocks base method.
+func (m *LeaseRepository) UpdateExpiresAt(ctx context.Context, workerID uint32, id string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExpiresAt", ctx, workerID, id, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateExpiresAt indicates an expected call of UpdateExpiresAt.
+func (mr *LeaseRepositoryMockRecorder) UpdateExpiresAt(ctx, workerID, id, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpiresAt", reflect.TypeOf((*LeaseRepository)(nil).UpdateExpiresAt), ctx, workerID, id, expiresAt)
+}
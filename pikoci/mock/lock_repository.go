@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/pikoci/pikoci/lock (interfaces: Repository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/lock_repository.go -mock_names=Repository=LockRepository -package mock github.com/xescugc/pikoci/pikoci/lock Repository
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+
+	lock "github.com/xescugc/pikoci/pikoci/lock"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// LockRepository is a mock of Repository interface.
+type LockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *LockRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// LockRepositoryMockRecorder is the mock recorder for LockRepository.
+type LockRepositoryMockRecorder struct {
+	mock *LockRepository
+}
+
+// NewLockRepository creates a new mock instance.
+func NewLockRepository(ctrl *gomock.Controller) *LockRepository {
+	mock := &LockRepository{ctrl: ctrl}
+	mock.recorder = &LockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *LockRepository) EXPECT() *LockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *LockRepository) Create(ctx context.Context, tc string, l lock.Lock) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, tc, l)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *LockRepositoryMockRecorder) Create(ctx, tc, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*LockRepository)(nil).Create), ctx, tc, l)
+}
+
+// Delete mocks base method.
+func (m *LockRepository) Delete(ctx context.Context, tc, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, tc, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *LockRepositoryMockRecorder) Delete(ctx, tc, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*LockRepository)(nil).Delete), ctx, tc, name)
+}
+
+// DeleteByBuild mocks base method.
+func (m *LockRepository) DeleteByBuild(ctx context.Context, buildID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByBuild", ctx, buildID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByBuild indicates an expected call of DeleteByBuild.
+func (mr *LockRepositoryMockRecorder) DeleteByBuild(ctx, buildID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByBuild", reflect.TypeOf((*LockRepository)(nil).DeleteByBuild), ctx, buildID)
+}
+
+// Filter mocks base method.
+func (m *LockRepository) Filter(ctx context.Context, tc string) ([]*lock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Filter", ctx, tc)
+	ret0, _ := ret[0].([]*lock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Filter indicates an expected call of Filter.
+func (mr *LockRepositoryMockRecorder) Filter(ctx, tc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Filter", reflect.TypeOf((*LockRepository)(nil).Filter), ctx, tc)
+}
+
+// Find mocks base method.
+func (m *LockRepository) Find(ctx context.Context, tc, name string) (*lock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, tc, name)
+	ret0, _ := ret[0].(*lock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *LockRepositoryMockRecorder) Find(ctx, tc, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*LockRepository)(nil).Find), ctx, tc, name)
+}
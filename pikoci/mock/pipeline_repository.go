@@ -130,6 +130,20 @@ func (mr *PipelineRepositoryMockRecorder) FindPublic(ctx, tc, pn any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPublic", reflect.TypeOf((*PipelineRepository)(nil).FindPublic), ctx, tc, pn)
 }
 
+// SetPaused mocks base method.
+func (m *PipelineRepository) SetPaused(ctx context.Context, tc, pn string, paused bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPaused", ctx, tc, pn, paused)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPaused indicates an expected call of SetPaused.
+func (mr *PipelineRepositoryMockRecorder) SetPaused(ctx, tc, pn, paused any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPaused", reflect.TypeOf((*PipelineRepository)(nil).SetPaused), ctx, tc, pn, paused)
+}
+
 // SetPublic mocks base method.
 func (m *PipelineRepository) SetPublic(ctx context.Context, tc, pn string, public bool) error {
 	m.ctrl.T.Helper()
@@ -115,6 +115,21 @@ func (mr *ResourceRepositoryMockRecorder) FilterDueResources(ctx any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterDueResources", reflect.TypeOf((*ResourceRepository)(nil).FilterDueResources), ctx)
 }
 
+// FilterOutdatedWebhooks mocks base method.
+func (m *ResourceRepository) FilterOutdatedWebhooks(ctx context.Context, keyID string) ([]*resource.ResourceWithPipeline, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterOutdatedWebhooks", ctx, keyID)
+	ret0, _ := ret[0].([]*resource.ResourceWithPipeline)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterOutdatedWebhooks indicates an expected call of FilterOutdatedWebhooks.
+func (mr *ResourceRepositoryMockRecorder) FilterOutdatedWebhooks(ctx, keyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterOutdatedWebhooks", reflect.TypeOf((*ResourceRepository)(nil).FilterOutdatedWebhooks), ctx, keyID)
+}
+
 // FilterVersions mocks base method.
 func (m *ResourceRepository) FilterVersions(ctx context.Context, tc, pn, rCan string) ([]*resource.Version, error) {
 	m.ctrl.T.Helper()
@@ -162,6 +177,62 @@ func (mr *ResourceRepositoryMockRecorder) FindByWebhookToken(ctx, token any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWebhookToken", reflect.TypeOf((*ResourceRepository)(nil).FindByWebhookToken), ctx, token)
 }
 
+// PinVersion mocks base method.
+func (m *ResourceRepository) PinVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinVersion", ctx, tc, pn, rCan, vID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PinVersion indicates an expected call of PinVersion.
+func (mr *ResourceRepositoryMockRecorder) PinVersion(ctx, tc, pn, rCan, vID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinVersion", reflect.TypeOf((*ResourceRepository)(nil).PinVersion), ctx, tc, pn, rCan, vID)
+}
+
+// SetVersionDisabled mocks base method.
+func (m *ResourceRepository) SetVersionDisabled(ctx context.Context, tc, pn, rCan string, vID uint32, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVersionDisabled", ctx, tc, pn, rCan, vID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVersionDisabled indicates an expected call of SetVersionDisabled.
+func (mr *ResourceRepositoryMockRecorder) SetVersionDisabled(ctx, tc, pn, rCan, vID, disabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersionDisabled", reflect.TypeOf((*ResourceRepository)(nil).SetVersionDisabled), ctx, tc, pn, rCan, vID, disabled)
+}
+
+// SetWebhook mocks base method.
+func (m *ResourceRepository) SetWebhook(ctx context.Context, tc, pn, rCan string, wh *resource.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWebhook", ctx, tc, pn, rCan, wh)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWebhook indicates an expected call of SetWebhook.
+func (mr *ResourceRepositoryMockRecorder) SetWebhook(ctx, tc, pn, rCan, wh any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWebhook", reflect.TypeOf((*ResourceRepository)(nil).SetWebhook), ctx, tc, pn, rCan, wh)
+}
+
+// UnpinVersion mocks base method.
+func (m *ResourceRepository) UnpinVersion(ctx context.Context, tc, pn, rCan string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpinVersion", ctx, tc, pn, rCan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnpinVersion indicates an expected call of UnpinVersion.
+func (mr *ResourceRepositoryMockRecorder) UnpinVersion(ctx, tc, pn, rCan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpinVersion", reflect.TypeOf((*ResourceRepository)(nil).UnpinVersion), ctx, tc, pn, rCan)
+}
+
 // Update mocks base method.
 func (m *ResourceRepository) Update(ctx context.Context, tc, pn, rCan string, r resource.Resource) error {
 	m.ctrl.T.Helper()
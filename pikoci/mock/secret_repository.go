@@ -42,74 +42,103 @@ func (m *SecretRepository) EXPECT() *SecretRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *SecretRepository) Create(ctx context.Context, tc, pn string, s secret.Secret) (uint32, error) {
+func (m *SecretRepository) Create(ctx context.Context, tc string, s secret.Secret) (uint32, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, tc, pn, s)
+	ret := m.ctrl.Call(m, "Create", ctx, tc, s)
 	ret0, _ := ret[0].(uint32)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *SecretRepositoryMockRecorder) Create(ctx, tc, pn, s any) *gomock.Call {
+func (mr *SecretRepositoryMockRecorder) Create(ctx, tc, s any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*SecretRepository)(nil).Create), ctx, tc, pn, s)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*SecretRepository)(nil).Create), ctx, tc, s)
 }
 
 // Delete mocks base method.
-func (m *SecretRepository) Delete(ctx context.Context, tc, pn, sCan string) error {
+func (m *SecretRepository) Delete(ctx context.Context, tc, name string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, tc, pn, sCan)
+	ret := m.ctrl.Call(m, "Delete", ctx, tc, name)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *SecretRepositoryMockRecorder) Delete(ctx, tc, pn, sCan any) *gomock.Call {
+func (mr *SecretRepositoryMockRecorder) Delete(ctx, tc, name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*SecretRepository)(nil).Delete), ctx, tc, pn, sCan)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*SecretRepository)(nil).Delete), ctx, tc, name)
 }
 
 // Filter mocks base method.
-func (m *SecretRepository) Filter(ctx context.Context, tc, pn string) ([]*secret.Secret, error) {
+func (m *SecretRepository) Filter(ctx context.Context, tc string) ([]*secret.Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Filter", ctx, tc, pn)
+	ret := m.ctrl.Call(m, "Filter", ctx, tc)
 	ret0, _ := ret[0].([]*secret.Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Filter indicates an expected call of Filter.
-func (mr *SecretRepositoryMockRecorder) Filter(ctx, tc, pn any) *gomock.Call {
+func (mr *SecretRepositoryMockRecorder) Filter(ctx, tc any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Filter", reflect.TypeOf((*SecretRepository)(nil).Filter), ctx, tc, pn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Filter", reflect.TypeOf((*SecretRepository)(nil).Filter), ctx, tc)
+}
+
+// FilterOutdated mocks base method.
+func (m *SecretRepository) FilterOutdated(ctx context.Context, keyID string) ([]*secret.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterOutdated", ctx, keyID)
+	ret0, _ := ret[0].([]*secret.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterOutdated indicates an expected call of FilterOutdated.
+func (mr *SecretRepositoryMockRecorder) FilterOutdated(ctx, keyID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterOutdated", reflect.TypeOf((*SecretRepository)(nil).FilterOutdated), ctx, keyID)
 }
 
 // Find mocks base method.
-func (m *SecretRepository) Find(ctx context.Context, tc, pn, sCan string) (*secret.Secret, error) {
+func (m *SecretRepository) Find(ctx context.Context, tc, name string) (*secret.Secret, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Find", ctx, tc, pn, sCan)
+	ret := m.ctrl.Call(m, "Find", ctx, tc, name)
 	ret0, _ := ret[0].(*secret.Secret)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Find indicates an expected call of Find.
-func (mr *SecretRepositoryMockRecorder) Find(ctx, tc, pn, sCan any) *gomock.Call {
+func (mr *SecretRepositoryMockRecorder) Find(ctx, tc, name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*SecretRepository)(nil).Find), ctx, tc, pn, sCan)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*SecretRepository)(nil).Find), ctx, tc, name)
 }
 
 // Update mocks base method.
-func (m *SecretRepository) Update(ctx context.Context, tc, pn, sCan string, s secret.Secret) error {
+func (m *SecretRepository) Update(ctx context.Context, tc, name string, s secret.Secret) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, tc, pn, sCan, s)
+	ret := m.ctrl.Call(m, "Update", ctx, tc, name, s)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *SecretRepositoryMockRecorder) Update(ctx, tc, pn, sCan, s any) *gomock.Call {
+func (mr *SecretRepositoryMockRecorder) Update(ctx, tc, name, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*SecretRepository)(nil).Update), ctx, tc, name, s)
+}
+
+// UpdateData mocks base method.
+func (m *SecretRepository) UpdateData(ctx context.Context, id uint32, keyID string, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateData", ctx, id, keyID, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateData indicates an expected call of UpdateData.
+func (mr *SecretRepositoryMockRecorder) UpdateData(ctx, id, keyID, data any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*SecretRepository)(nil).Update), ctx, tc, pn, sCan, s)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*SecretRepository)(nil).UpdateData), ctx, id, keyID, data)
 }
@@ -11,12 +11,20 @@ package mock
 
 import (
 	context "context"
+	io "io"
+	http "net/http"
 	reflect "reflect"
+	time "time"
 
+	apitoken "github.com/xescugc/pikoci/pikoci/apitoken"
 	build "github.com/xescugc/pikoci/pikoci/build"
 	job "github.com/xescugc/pikoci/pikoci/job"
+	lock "github.com/xescugc/pikoci/pikoci/lock"
 	pipeline "github.com/xescugc/pikoci/pikoci/pipeline"
+	queue "github.com/xescugc/pikoci/pikoci/queue"
+	registry "github.com/xescugc/pikoci/pikoci/registry"
 	resource "github.com/xescugc/pikoci/pikoci/resource"
+	secret "github.com/xescugc/pikoci/pikoci/secret"
 	team "github.com/xescugc/pikoci/pikoci/team"
 	user "github.com/xescugc/pikoci/pikoci/user"
 	gomock "go.uber.org/mock/gomock"
@@ -46,6 +54,51 @@ func (m *Service) EXPECT() *ServiceMockRecorder {
 	return m.recorder
 }
 
+// AcquireLock mocks base method.
+func (m *Service) AcquireLock(ctx context.Context, tc, name, un, reason string) (*lock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLock", ctx, tc, name, un, reason)
+	ret0, _ := ret[0].(*lock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireLock indicates an expected call of AcquireLock.
+func (mr *ServiceMockRecorder) AcquireLock(ctx, tc, name, un, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLock", reflect.TypeOf((*Service)(nil).AcquireLock), ctx, tc, name, un, reason)
+}
+
+// AppendJobBuildLogs mocks base method.
+func (m *Service) AppendJobBuildLogs(ctx context.Context, tc, pn, jn, buildNumber string, c build.LogChunk) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendJobBuildLogs", ctx, tc, pn, jn, buildNumber, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendJobBuildLogs indicates an expected call of AppendJobBuildLogs.
+func (mr *ServiceMockRecorder) AppendJobBuildLogs(ctx, tc, pn, jn, buildNumber, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendJobBuildLogs", reflect.TypeOf((*Service)(nil).AppendJobBuildLogs), ctx, tc, pn, jn, buildNumber, c)
+}
+
+// AuthenticateToken mocks base method.
+func (m *Service) AuthenticateToken(ctx context.Context, raw string) (*apitoken.Token, *user.WithMemberships, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateToken", ctx, raw)
+	ret0, _ := ret[0].(*apitoken.Token)
+	ret1, _ := ret[1].(*user.WithMemberships)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AuthenticateToken indicates an expected call of AuthenticateToken.
+func (mr *ServiceMockRecorder) AuthenticateToken(ctx, raw any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateToken", reflect.TypeOf((*Service)(nil).AuthenticateToken), ctx, raw)
+}
+
 // CancelJobBuild mocks base method.
 func (m *Service) CancelJobBuild(ctx context.Context, tc, pn, jn, buildNumber string) error {
 	m.ctrl.T.Helper()
@@ -60,6 +113,35 @@ func (mr *ServiceMockRecorder) CancelJobBuild(ctx, tc, pn, jn, buildNumber any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJobBuild", reflect.TypeOf((*Service)(nil).CancelJobBuild), ctx, tc, pn, jn, buildNumber)
 }
 
+// ClaimWork mocks base method.
+func (m *Service) ClaimWork(ctx context.Context, workerID uint32, tags []string, wait time.Duration) (*queue.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWork", ctx, workerID, tags, wait)
+	ret0, _ := ret[0].(*queue.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWork indicates an expected call of ClaimWork.
+func (mr *ServiceMockRecorder) ClaimWork(ctx, workerID, tags, wait any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWork", reflect.TypeOf((*Service)(nil).ClaimWork), ctx, workerID, tags, wait)
+}
+
+// CompleteWork mocks base method.
+func (m *Service) CompleteWork(ctx context.Context, workerID uint32, leaseID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteWork", ctx, workerID, leaseID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteWork indicates an expected call of CompleteWork.
+func (mr *ServiceMockRecorder) CompleteWork(ctx, workerID, leaseID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteWork", reflect.TypeOf((*Service)(nil).CompleteWork), ctx, workerID, leaseID)
+}
+
 // CreateJobBuild mocks base method.
 func (m *Service) CreateJobBuild(ctx context.Context, tc, pn, jn string, b build.Build) (*build.Build, error) {
 	m.ctrl.T.Helper()
@@ -75,6 +157,20 @@ func (mr *ServiceMockRecorder) CreateJobBuild(ctx, tc, pn, jn, b any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJobBuild", reflect.TypeOf((*Service)(nil).CreateJobBuild), ctx, tc, pn, jn, b)
 }
 
+// CreateJobBuildTests mocks base method.
+func (m *Service) CreateJobBuildTests(ctx context.Context, tc, pn, jn, buildNumber string, cs []build.TestCase) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJobBuildTests", ctx, tc, pn, jn, buildNumber, cs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJobBuildTests indicates an expected call of CreateJobBuildTests.
+func (mr *ServiceMockRecorder) CreateJobBuildTests(ctx, tc, pn, jn, buildNumber, cs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJobBuildTests", reflect.TypeOf((*Service)(nil).CreateJobBuildTests), ctx, tc, pn, jn, buildNumber, cs)
+}
+
 // CreatePipeline mocks base method.
 func (m *Service) CreatePipeline(ctx context.Context, tc, pn string, pp []byte, vars map[string]any) (*pipeline.Pipeline, error) {
 	m.ctrl.T.Helper()
@@ -165,6 +261,22 @@ func (mr *ServiceMockRecorder) CreateTeamMember(ctx, tc, tm any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTeamMember", reflect.TypeOf((*Service)(nil).CreateTeamMember), ctx, tc, tm)
 }
 
+// CreateTeamToken mocks base method.
+func (m *Service) CreateTeamToken(ctx context.Context, tc string, t apitoken.Token) (*apitoken.Token, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTeamToken", ctx, tc, t)
+	ret0, _ := ret[0].(*apitoken.Token)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateTeamToken indicates an expected call of CreateTeamToken.
+func (mr *ServiceMockRecorder) CreateTeamToken(ctx, tc, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTeamToken", reflect.TypeOf((*Service)(nil).CreateTeamToken), ctx, tc, t)
+}
+
 // CreateUser mocks base method.
 func (m *Service) CreateUser(ctx context.Context, u user.User, isHash bool) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -180,6 +292,22 @@ func (mr *ServiceMockRecorder) CreateUser(ctx, u, isHash any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*Service)(nil).CreateUser), ctx, u, isHash)
 }
 
+// CreateUserToken mocks base method.
+func (m *Service) CreateUserToken(ctx context.Context, un string, t apitoken.Token) (*apitoken.Token, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserToken", ctx, un, t)
+	ret0, _ := ret[0].(*apitoken.Token)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateUserToken indicates an expected call of CreateUserToken.
+func (mr *ServiceMockRecorder) CreateUserToken(ctx, un, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserToken", reflect.TypeOf((*Service)(nil).CreateUserToken), ctx, un, t)
+}
+
 // DeleteJobBuild mocks base method.
 func (m *Service) DeleteJobBuild(ctx context.Context, tc, pn, jn, buildNumber string) error {
 	m.ctrl.T.Helper()
@@ -208,6 +336,20 @@ func (mr *ServiceMockRecorder) DeletePipeline(ctx, tc, pn any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePipeline", reflect.TypeOf((*Service)(nil).DeletePipeline), ctx, tc, pn)
 }
 
+// DeleteSecret mocks base method.
+func (m *Service) DeleteSecret(ctx context.Context, tc, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSecret", ctx, tc, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecret indicates an expected call of DeleteSecret.
+func (mr *ServiceMockRecorder) DeleteSecret(ctx, tc, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*Service)(nil).DeleteSecret), ctx, tc, name)
+}
+
 // DeleteTeam mocks base method.
 func (m *Service) DeleteTeam(ctx context.Context, tc string) error {
 	m.ctrl.T.Helper()
@@ -236,6 +378,76 @@ func (mr *ServiceMockRecorder) DeleteTeamMember(ctx, tc, mc any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTeamMember", reflect.TypeOf((*Service)(nil).DeleteTeamMember), ctx, tc, mc)
 }
 
+// DeleteTeamToken mocks base method.
+func (m *Service) DeleteTeamToken(ctx context.Context, tc string, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTeamToken", ctx, tc, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTeamToken indicates an expected call of DeleteTeamToken.
+func (mr *ServiceMockRecorder) DeleteTeamToken(ctx, tc, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTeamToken", reflect.TypeOf((*Service)(nil).DeleteTeamToken), ctx, tc, id)
+}
+
+// DeleteUserToken mocks base method.
+func (m *Service) DeleteUserToken(ctx context.Context, un string, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserToken", ctx, un, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserToken indicates an expected call of DeleteUserToken.
+func (mr *ServiceMockRecorder) DeleteUserToken(ctx, un, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserToken", reflect.TypeOf((*Service)(nil).DeleteUserToken), ctx, un, id)
+}
+
+// DisableResourceVersion mocks base method.
+func (m *Service) DisableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableResourceVersion", ctx, tc, pn, rCan, vID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableResourceVersion indicates an expected call of DisableResourceVersion.
+func (mr *ServiceMockRecorder) DisableResourceVersion(ctx, tc, pn, rCan, vID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableResourceVersion", reflect.TypeOf((*Service)(nil).DisableResourceVersion), ctx, tc, pn, rCan, vID)
+}
+
+// DownloadBuildArtifact mocks base method.
+func (m *Service) DownloadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadBuildArtifact", ctx, tc, pn, jn, buildNumber, tn, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadBuildArtifact indicates an expected call of DownloadBuildArtifact.
+func (mr *ServiceMockRecorder) DownloadBuildArtifact(ctx, tc, pn, jn, buildNumber, tn, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadBuildArtifact", reflect.TypeOf((*Service)(nil).DownloadBuildArtifact), ctx, tc, pn, jn, buildNumber, tn, w)
+}
+
+// EnableResourceVersion mocks base method.
+func (m *Service) EnableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableResourceVersion", ctx, tc, pn, rCan, vID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableResourceVersion indicates an expected call of EnableResourceVersion.
+func (mr *ServiceMockRecorder) EnableResourceVersion(ctx, tc, pn, rCan, vID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableResourceVersion", reflect.TypeOf((*Service)(nil).EnableResourceVersion), ctx, tc, pn, rCan, vID)
+}
+
 // FindBuildGetVersions mocks base method.
 func (m *Service) FindBuildGetVersions(ctx context.Context, tc, pn, jn string, buildID uint32) (map[string]uint32, error) {
 	m.ctrl.T.Helper()
@@ -251,6 +463,20 @@ func (mr *ServiceMockRecorder) FindBuildGetVersions(ctx, tc, pn, jn, buildID any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBuildGetVersions", reflect.TypeOf((*Service)(nil).FindBuildGetVersions), ctx, tc, pn, jn, buildID)
 }
 
+// ForceReleaseLock mocks base method.
+func (m *Service) ForceReleaseLock(ctx context.Context, tc, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceReleaseLock", ctx, tc, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceReleaseLock indicates an expected call of ForceReleaseLock.
+func (mr *ServiceMockRecorder) ForceReleaseLock(ctx, tc, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceReleaseLock", reflect.TypeOf((*Service)(nil).ForceReleaseLock), ctx, tc, name)
+}
+
 // GetJobBuild mocks base method.
 func (m *Service) GetJobBuild(ctx context.Context, tc, pn, jn, buildNumber string) (*build.Build, error) {
 	m.ctrl.T.Helper()
@@ -416,6 +642,20 @@ func (mr *ServiceMockRecorder) GetUser(ctx, un any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*Service)(nil).GetUser), ctx, un)
 }
 
+// HeartbeatWorker mocks base method.
+func (m *Service) HeartbeatWorker(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HeartbeatWorker", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HeartbeatWorker indicates an expected call of HeartbeatWorker.
+func (mr *ServiceMockRecorder) HeartbeatWorker(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HeartbeatWorker", reflect.TypeOf((*Service)(nil).HeartbeatWorker), ctx, id)
+}
+
 // InsertBuildGetVersion mocks base method.
 func (m *Service) InsertBuildGetVersion(ctx context.Context, tc, pn, jn string, buildID uint32, stepName string, versionID uint32) error {
 	m.ctrl.T.Helper()
@@ -430,6 +670,21 @@ func (mr *ServiceMockRecorder) InsertBuildGetVersion(ctx, tc, pn, jn, buildID, s
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBuildGetVersion", reflect.TypeOf((*Service)(nil).InsertBuildGetVersion), ctx, tc, pn, jn, buildID, stepName, versionID)
 }
 
+// ListJobBuildTests mocks base method.
+func (m *Service) ListJobBuildTests(ctx context.Context, tc, pn, jn, buildNumber string) ([]*build.TestSuite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJobBuildTests", ctx, tc, pn, jn, buildNumber)
+	ret0, _ := ret[0].([]*build.TestSuite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJobBuildTests indicates an expected call of ListJobBuildTests.
+func (mr *ServiceMockRecorder) ListJobBuildTests(ctx, tc, pn, jn, buildNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobBuildTests", reflect.TypeOf((*Service)(nil).ListJobBuildTests), ctx, tc, pn, jn, buildNumber)
+}
+
 // ListJobBuilds mocks base method.
 func (m *Service) ListJobBuilds(ctx context.Context, tc, pn, jn string) ([]*build.Build, error) {
 	m.ctrl.T.Helper()
@@ -445,6 +700,36 @@ func (mr *ServiceMockRecorder) ListJobBuilds(ctx, tc, pn, jn any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobBuilds", reflect.TypeOf((*Service)(nil).ListJobBuilds), ctx, tc, pn, jn)
 }
 
+// ListJobFlakyTests mocks base method.
+func (m *Service) ListJobFlakyTests(ctx context.Context, tc, pn, jn string) ([]*build.FlakyTest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListJobFlakyTests", ctx, tc, pn, jn)
+	ret0, _ := ret[0].([]*build.FlakyTest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListJobFlakyTests indicates an expected call of ListJobFlakyTests.
+func (mr *ServiceMockRecorder) ListJobFlakyTests(ctx, tc, pn, jn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListJobFlakyTests", reflect.TypeOf((*Service)(nil).ListJobFlakyTests), ctx, tc, pn, jn)
+}
+
+// ListLocks mocks base method.
+func (m *Service) ListLocks(ctx context.Context, tc string) ([]*lock.Lock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLocks", ctx, tc)
+	ret0, _ := ret[0].([]*lock.Lock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLocks indicates an expected call of ListLocks.
+func (mr *ServiceMockRecorder) ListLocks(ctx, tc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLocks", reflect.TypeOf((*Service)(nil).ListLocks), ctx, tc)
+}
+
 // ListPipelines mocks base method.
 func (m *Service) ListPipelines(ctx context.Context, tc string) ([]*pipeline.Pipeline, error) {
 	m.ctrl.T.Helper()
@@ -490,6 +775,21 @@ func (mr *ServiceMockRecorder) ListPublicResourceVersions(ctx, tc, pn, rCan any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublicResourceVersions", reflect.TypeOf((*Service)(nil).ListPublicResourceVersions), ctx, tc, pn, rCan)
 }
 
+// ListQueuedBuilds mocks base method.
+func (m *Service) ListQueuedBuilds(ctx context.Context, tc, pn, jn string) ([]*build.Queued, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQueuedBuilds", ctx, tc, pn, jn)
+	ret0, _ := ret[0].([]*build.Queued)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQueuedBuilds indicates an expected call of ListQueuedBuilds.
+func (mr *ServiceMockRecorder) ListQueuedBuilds(ctx, tc, pn, jn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQueuedBuilds", reflect.TypeOf((*Service)(nil).ListQueuedBuilds), ctx, tc, pn, jn)
+}
+
 // ListResourceVersions mocks base method.
 func (m *Service) ListResourceVersions(ctx context.Context, tc, pn, rCan string) ([]*resource.Version, error) {
 	m.ctrl.T.Helper()
@@ -505,6 +805,36 @@ func (mr *ServiceMockRecorder) ListResourceVersions(ctx, tc, pn, rCan any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListResourceVersions", reflect.TypeOf((*Service)(nil).ListResourceVersions), ctx, tc, pn, rCan)
 }
 
+// ListSecrets mocks base method.
+func (m *Service) ListSecrets(ctx context.Context, tc string) ([]*secret.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSecrets", ctx, tc)
+	ret0, _ := ret[0].([]*secret.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSecrets indicates an expected call of ListSecrets.
+func (mr *ServiceMockRecorder) ListSecrets(ctx, tc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSecrets", reflect.TypeOf((*Service)(nil).ListSecrets), ctx, tc)
+}
+
+// ListTeamTokens mocks base method.
+func (m *Service) ListTeamTokens(ctx context.Context, tc string) ([]*apitoken.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTeamTokens", ctx, tc)
+	ret0, _ := ret[0].([]*apitoken.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTeamTokens indicates an expected call of ListTeamTokens.
+func (mr *ServiceMockRecorder) ListTeamTokens(ctx, tc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTeamTokens", reflect.TypeOf((*Service)(nil).ListTeamTokens), ctx, tc)
+}
+
 // ListTeams mocks base method.
 func (m *Service) ListTeams(ctx context.Context, un string) ([]*team.WithMembers, error) {
 	m.ctrl.T.Helper()
@@ -520,6 +850,21 @@ func (mr *ServiceMockRecorder) ListTeams(ctx, un any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTeams", reflect.TypeOf((*Service)(nil).ListTeams), ctx, un)
 }
 
+// ListUserTokens mocks base method.
+func (m *Service) ListUserTokens(ctx context.Context, un string) ([]*apitoken.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserTokens", ctx, un)
+	ret0, _ := ret[0].([]*apitoken.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserTokens indicates an expected call of ListUserTokens.
+func (mr *ServiceMockRecorder) ListUserTokens(ctx, un any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserTokens", reflect.TypeOf((*Service)(nil).ListUserTokens), ctx, un)
+}
+
 // ListUsers mocks base method.
 func (m *Service) ListUsers(ctx context.Context) ([]*user.User, error) {
 	m.ctrl.T.Helper()
@@ -535,6 +880,122 @@ func (mr *ServiceMockRecorder) ListUsers(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*Service)(nil).ListUsers), ctx)
 }
 
+// ListWorkers mocks base method.
+func (m *Service) ListWorkers(ctx context.Context) ([]*registry.Worker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWorkers", ctx)
+	ret0, _ := ret[0].([]*registry.Worker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWorkers indicates an expected call of ListWorkers.
+func (mr *ServiceMockRecorder) ListWorkers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWorkers", reflect.TypeOf((*Service)(nil).ListWorkers), ctx)
+}
+
+// OIDCAuthCodeURL mocks base method.
+func (m *Service) OIDCAuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OIDCAuthCodeURL", ctx, state, nonce, verifier)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OIDCAuthCodeURL indicates an expected call of OIDCAuthCodeURL.
+func (mr *ServiceMockRecorder) OIDCAuthCodeURL(ctx, state, nonce, verifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDCAuthCodeURL", reflect.TypeOf((*Service)(nil).OIDCAuthCodeURL), ctx, state, nonce, verifier)
+}
+
+// OIDCEnabled mocks base method.
+func (m *Service) OIDCEnabled(ctx context.Context) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OIDCEnabled", ctx)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// OIDCEnabled indicates an expected call of OIDCEnabled.
+func (mr *ServiceMockRecorder) OIDCEnabled(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDCEnabled", reflect.TypeOf((*Service)(nil).OIDCEnabled), ctx)
+}
+
+// OIDCLogin mocks base method.
+func (m *Service) OIDCLogin(ctx context.Context, code, verifier, nonce string) (*user.WithMemberships, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OIDCLogin", ctx, code, verifier, nonce)
+	ret0, _ := ret[0].(*user.WithMemberships)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OIDCLogin indicates an expected call of OIDCLogin.
+func (mr *ServiceMockRecorder) OIDCLogin(ctx, code, verifier, nonce any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDCLogin", reflect.TypeOf((*Service)(nil).OIDCLogin), ctx, code, verifier, nonce)
+}
+
+// PauseJob mocks base method.
+func (m *Service) PauseJob(ctx context.Context, tc, pn, jn string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseJob", ctx, tc, pn, jn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseJob indicates an expected call of PauseJob.
+func (mr *ServiceMockRecorder) PauseJob(ctx, tc, pn, jn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseJob", reflect.TypeOf((*Service)(nil).PauseJob), ctx, tc, pn, jn)
+}
+
+// PausePipeline mocks base method.
+func (m *Service) PausePipeline(ctx context.Context, tc, pn string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PausePipeline", ctx, tc, pn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PausePipeline indicates an expected call of PausePipeline.
+func (mr *ServiceMockRecorder) PausePipeline(ctx, tc, pn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PausePipeline", reflect.TypeOf((*Service)(nil).PausePipeline), ctx, tc, pn)
+}
+
+// PinResourceVersion mocks base method.
+func (m *Service) PinResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinResourceVersion", ctx, tc, pn, rCan, vID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PinResourceVersion indicates an expected call of PinResourceVersion.
+func (mr *ServiceMockRecorder) PinResourceVersion(ctx, tc, pn, rCan, vID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinResourceVersion", reflect.TypeOf((*Service)(nil).PinResourceVersion), ctx, tc, pn, rCan, vID)
+}
+
+// PublishWork mocks base method.
+func (m *Service) PublishWork(ctx context.Context, body []byte, metadata map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishWork", ctx, body, metadata)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishWork indicates an expected call of PublishWork.
+func (mr *ServiceMockRecorder) PublishWork(ctx, body, metadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishWork", reflect.TypeOf((*Service)(nil).PublishWork), ctx, body, metadata)
+}
+
 // RefreshToken mocks base method.
 func (m *Service) RefreshToken(ctx context.Context, un string) (*user.WithMemberships, string, error) {
 	m.ctrl.T.Helper()
@@ -566,6 +1027,65 @@ func (mr *ServiceMockRecorder) RegenerateWebhookToken(ctx, tc, pn, rCan any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateWebhookToken", reflect.TypeOf((*Service)(nil).RegenerateWebhookToken), ctx, tc, pn, rCan)
 }
 
+// RegisterWorker mocks base method.
+func (m *Service) RegisterWorker(ctx context.Context, w registry.Worker) (*registry.Worker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterWorker", ctx, w)
+	ret0, _ := ret[0].(*registry.Worker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterWorker indicates an expected call of RegisterWorker.
+func (mr *ServiceMockRecorder) RegisterWorker(ctx, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterWorker", reflect.TypeOf((*Service)(nil).RegisterWorker), ctx, w)
+}
+
+// ReleaseLock mocks base method.
+func (m *Service) ReleaseLock(ctx context.Context, tc, name, un string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLock", ctx, tc, name, un)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLock indicates an expected call of ReleaseLock.
+func (mr *ServiceMockRecorder) ReleaseLock(ctx, tc, name, un any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLock", reflect.TypeOf((*Service)(nil).ReleaseLock), ctx, tc, name, un)
+}
+
+// RenewWorkLease mocks base method.
+func (m *Service) RenewWorkLease(ctx context.Context, workerID uint32, leaseID string) (*queue.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewWorkLease", ctx, workerID, leaseID)
+	ret0, _ := ret[0].(*queue.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewWorkLease indicates an expected call of RenewWorkLease.
+func (mr *ServiceMockRecorder) RenewWorkLease(ctx, workerID, leaseID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewWorkLease", reflect.TypeOf((*Service)(nil).RenewWorkLease), ctx, workerID, leaseID)
+}
+
+// ResolveSecrets mocks base method.
+func (m *Service) ResolveSecrets(ctx context.Context, tc string, names []string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveSecrets", ctx, tc, names)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveSecrets indicates an expected call of ResolveSecrets.
+func (mr *ServiceMockRecorder) ResolveSecrets(ctx, tc, names any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveSecrets", reflect.TypeOf((*Service)(nil).ResolveSecrets), ctx, tc, names)
+}
+
 // RetryJobBuild mocks base method.
 func (m *Service) RetryJobBuild(ctx context.Context, tc, pn, jn, buildNumber string) error {
 	m.ctrl.T.Helper()
@@ -594,18 +1114,48 @@ func (mr *ServiceMockRecorder) SetPipelinePublic(ctx, tc, pn, public any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPipelinePublic", reflect.TypeOf((*Service)(nil).SetPipelinePublic), ctx, tc, pn, public)
 }
 
+// SetSecret mocks base method.
+func (m *Service) SetSecret(ctx context.Context, tc, name, value string) (*secret.Secret, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecret", ctx, tc, name, value)
+	ret0, _ := ret[0].(*secret.Secret)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSecret indicates an expected call of SetSecret.
+func (mr *ServiceMockRecorder) SetSecret(ctx, tc, name, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecret", reflect.TypeOf((*Service)(nil).SetSecret), ctx, tc, name, value)
+}
+
+// StartJobBuild mocks base method.
+func (m *Service) StartJobBuild(ctx context.Context, tc, pn, jn, buildNumber string, b build.Build) (*build.Build, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartJobBuild", ctx, tc, pn, jn, buildNumber, b)
+	ret0, _ := ret[0].(*build.Build)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartJobBuild indicates an expected call of StartJobBuild.
+func (mr *ServiceMockRecorder) StartJobBuild(ctx, tc, pn, jn, buildNumber, b any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartJobBuild", reflect.TypeOf((*Service)(nil).StartJobBuild), ctx, tc, pn, jn, buildNumber, b)
+}
+
 // TriggerPipelineJob mocks base method.
-func (m *Service) TriggerPipelineJob(ctx context.Context, tc, pn, jn string) error {
+func (m *Service) TriggerPipelineJob(ctx context.Context, tc, pn, jn string, versions map[string]uint32) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TriggerPipelineJob", ctx, tc, pn, jn)
+	ret := m.ctrl.Call(m, "TriggerPipelineJob", ctx, tc, pn, jn, versions)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // TriggerPipelineJob indicates an expected call of TriggerPipelineJob.
-func (mr *ServiceMockRecorder) TriggerPipelineJob(ctx, tc, pn, jn any) *gomock.Call {
+func (mr *ServiceMockRecorder) TriggerPipelineJob(ctx, tc, pn, jn, versions any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerPipelineJob", reflect.TypeOf((*Service)(nil).TriggerPipelineJob), ctx, tc, pn, jn)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerPipelineJob", reflect.TypeOf((*Service)(nil).TriggerPipelineJob), ctx, tc, pn, jn, versions)
 }
 
 // TriggerPipelineResource mocks base method.
@@ -622,6 +1172,48 @@ func (mr *ServiceMockRecorder) TriggerPipelineResource(ctx, tc, pn, rCan any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerPipelineResource", reflect.TypeOf((*Service)(nil).TriggerPipelineResource), ctx, tc, pn, rCan)
 }
 
+// UnpauseJob mocks base method.
+func (m *Service) UnpauseJob(ctx context.Context, tc, pn, jn string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpauseJob", ctx, tc, pn, jn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnpauseJob indicates an expected call of UnpauseJob.
+func (mr *ServiceMockRecorder) UnpauseJob(ctx, tc, pn, jn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpauseJob", reflect.TypeOf((*Service)(nil).UnpauseJob), ctx, tc, pn, jn)
+}
+
+// UnpausePipeline mocks base method.
+func (m *Service) UnpausePipeline(ctx context.Context, tc, pn string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpausePipeline", ctx, tc, pn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnpausePipeline indicates an expected call of UnpausePipeline.
+func (mr *ServiceMockRecorder) UnpausePipeline(ctx, tc, pn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpausePipeline", reflect.TypeOf((*Service)(nil).UnpausePipeline), ctx, tc, pn)
+}
+
+// UnpinResource mocks base method.
+func (m *Service) UnpinResource(ctx context.Context, tc, pn, rCan string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpinResource", ctx, tc, pn, rCan)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnpinResource indicates an expected call of UnpinResource.
+func (mr *ServiceMockRecorder) UnpinResource(ctx, tc, pn, rCan any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpinResource", reflect.TypeOf((*Service)(nil).UnpinResource), ctx, tc, pn, rCan)
+}
+
 // UpdateJobBuild mocks base method.
 func (m *Service) UpdateJobBuild(ctx context.Context, tc, pn, jn, buildNumber string, b build.Build) error {
 	m.ctrl.T.Helper()
@@ -695,6 +1287,20 @@ func (mr *ServiceMockRecorder) UpdateTeamMember(ctx, tc, mc, tm any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTeamMember", reflect.TypeOf((*Service)(nil).UpdateTeamMember), ctx, tc, mc, tm)
 }
 
+// UploadBuildArtifact mocks base method.
+func (m *Service) UploadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, r io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadBuildArtifact", ctx, tc, pn, jn, buildNumber, tn, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadBuildArtifact indicates an expected call of UploadBuildArtifact.
+func (mr *ServiceMockRecorder) UploadBuildArtifact(ctx, tc, pn, jn, buildNumber, tn, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadBuildArtifact", reflect.TypeOf((*Service)(nil).UploadBuildArtifact), ctx, tc, pn, jn, buildNumber, tn, r)
+}
+
 // UserLogin mocks base method.
 func (m *Service) UserLogin(ctx context.Context, un, pass string) (*user.WithMemberships, string, error) {
 	m.ctrl.T.Helper()
@@ -711,16 +1317,31 @@ func (mr *ServiceMockRecorder) UserLogin(ctx, un, pass any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserLogin", reflect.TypeOf((*Service)(nil).UserLogin), ctx, un, pass)
 }
 
+// WatchJobBuild mocks base method.
+func (m *Service) WatchJobBuild(ctx context.Context, tc, pn, jn, buildNumber string) (<-chan build.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchJobBuild", ctx, tc, pn, jn, buildNumber)
+	ret0, _ := ret[0].(<-chan build.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchJobBuild indicates an expected call of WatchJobBuild.
+func (mr *ServiceMockRecorder) WatchJobBuild(ctx, tc, pn, jn, buildNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchJobBuild", reflect.TypeOf((*Service)(nil).WatchJobBuild), ctx, tc, pn, jn, buildNumber)
+}
+
 // WebhookTrigger mocks base method.
-func (m *Service) WebhookTrigger(ctx context.Context, token string) error {
+func (m *Service) WebhookTrigger(ctx context.Context, token string, h http.Header, body []byte) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WebhookTrigger", ctx, token)
+	ret := m.ctrl.Call(m, "WebhookTrigger", ctx, token, h, body)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // WebhookTrigger indicates an expected call of WebhookTrigger.
-func (mr *ServiceMockRecorder) WebhookTrigger(ctx, token any) *gomock.Call {
+func (mr *ServiceMockRecorder) WebhookTrigger(ctx, token, h, body any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookTrigger", reflect.TypeOf((*Service)(nil).WebhookTrigger), ctx, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookTrigger", reflect.TypeOf((*Service)(nil).WebhookTrigger), ctx, token, h, body)
 }
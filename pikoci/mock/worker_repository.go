@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/xescugc/pikoci/pikoci/registry (interfaces: Repository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=../mock/worker_repository.go -mock_names=Repository=WorkerRepository -package mock github.com/xescugc/pikoci/pikoci/registry Repository
+//
+
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	registry "github.com/xescugc/pikoci/pikoci/registry"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// WorkerRepository is a mock of Repository interface.
+type WorkerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *WorkerRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// WorkerRepositoryMockRecorder is the mock recorder for WorkerRepository.
+type WorkerRepositoryMockRecorder struct {
+	mock *WorkerRepository
+}
+
+// NewWorkerRepository creates a new mock instance.
+func NewWorkerRepository(ctrl *gomock.Controller) *WorkerRepository {
+	mock := &WorkerRepository{ctrl: ctrl}
+	mock.recorder = &WorkerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *WorkerRepository) EXPECT() *WorkerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *WorkerRepository) Create(ctx context.Context, w registry.Worker) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, w)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *WorkerRepositoryMockRecorder) Create(ctx, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*WorkerRepository)(nil).Create), ctx, w)
+}
+
+// Delete mocks base method.
+func (m *WorkerRepository) Delete(ctx context.Context, id uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *WorkerRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*WorkerRepository)(nil).Delete), ctx, id)
+}
+
+// Filter mocks base method.
+func (m *WorkerRepository) Filter(ctx context.Context) ([]*registry.Worker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Filter", ctx)
+	ret0, _ := ret[0].([]*registry.Worker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Filter indicates an expected call of Filter.
+func (mr *WorkerRepositoryMockRecorder) Filter(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Filter", reflect.TypeOf((*WorkerRepository)(nil).Filter), ctx)
+}
+
+// FilterExpired mocks base method.
+func (m *WorkerRepository) FilterExpired(ctx context.Context, t time.Time) ([]*registry.Worker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FilterExpired", ctx, t)
+	ret0, _ := ret[0].([]*registry.Worker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FilterExpired indicates an expected call of FilterExpired.
+func (mr *WorkerRepositoryMockRecorder) FilterExpired(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FilterExpired", reflect.TypeOf((*WorkerRepository)(nil).FilterExpired), ctx, t)
+}
+
+// Heartbeat mocks base method.
+func (m *WorkerRepository) Heartbeat(ctx context.Context, id uint32, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Heartbeat", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Heartbeat indicates an expected call of Heartbeat.
+func (mr *WorkerRepositoryMockRecorder) Heartbeat(ctx, id, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Heartbeat", reflect.TypeOf((*WorkerRepository)(nil).Heartbeat), ctx, id, at)
+}
@@ -42,11 +42,17 @@ type dbBuild struct {
 	Error       sql.NullString
 	StartedAt   sql.NullTime
 	Duration    sql.NullInt64
+	WorkerID    sql.NullInt64
+	Cells       sql.NullString
 }
 
 func newDBBuild(b build.Build) dbBuild {
 	s, _ := json.Marshal(b.Steps)
 	j, _ := json.Marshal(b.Job)
+	var c []byte
+	if len(b.Cells) != 0 {
+		c, _ = json.Marshal(b.Cells)
+	}
 	return dbBuild{
 		Steps:     toNullString(string(s)),
 		Job:       toNullString(string(j)),
@@ -54,6 +60,8 @@ func newDBBuild(b build.Build) dbBuild {
 		Error:     toNullString(b.Error),
 		StartedAt: toNullTime(b.StartedAt),
 		Duration:  toNullInt64(int(b.Duration)),
+		WorkerID:  toNullInt64(int(b.WorkerID)),
+		Cells:     toNullString(string(c)),
 	}
 }
 
@@ -66,10 +74,14 @@ func (dbb *dbBuild) toDomainEntity() *build.Build {
 		Error:       dbb.Error.String,
 		StartedAt:   dbb.StartedAt.Time,
 		Duration:    time.Duration(dbb.Duration.Int64),
+		WorkerID:    uint32(dbb.WorkerID.Int64),
 	}
 
 	_ = json.Unmarshal([]byte(dbb.Steps.String), &b.Steps)
 	_ = json.Unmarshal([]byte(dbb.Job.String), &b.Job)
+	if dbb.Cells.Valid {
+		_ = json.Unmarshal([]byte(dbb.Cells.String), &b.Cells)
+	}
 
 	return b
 }
@@ -102,8 +114,8 @@ func (r *BuildRepository) Create(ctx context.Context, tc, pn, jn string, b build
 		buildNumber := fmt.Sprintf("%d", nextNum)
 
 		res, err := r.querier.ExecContext(ctx, `
-			INSERT INTO builds(steps, job, status, error, started_at, duration, build_number, job_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?,
+			INSERT INTO builds(steps, job, status, error, started_at, duration, worker_id, cells, build_number, job_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?,
 				-- job_id
 				(
 					SELECT j.id
@@ -113,7 +125,7 @@ func (r *BuildRepository) Create(ctx context.Context, tc, pn, jn string, b build
 					JOIN teams AS t
 						ON p.team_id = t.id
 					WHERE t.canonical = ? AND p.name = ? AND j.name = ?
-				))`, dbb.Steps, dbb.Job, dbb.Status, dbb.Error, dbb.StartedAt, dbb.Duration, buildNumber, tc, pn, jn)
+				))`, dbb.Steps, dbb.Job, dbb.Status, dbb.Error, dbb.StartedAt, dbb.Duration, dbb.WorkerID, dbb.Cells, buildNumber, tc, pn, jn)
 		if err != nil {
 			if isUniqueViolation(err) {
 				continue
@@ -161,8 +173,8 @@ func (r *BuildRepository) CreateRetry(ctx context.Context, tc, pn, jn, parentBui
 		buildNumber := fmt.Sprintf("%s.%d", parentBuildNumber, nextNum)
 
 		res, err := r.querier.ExecContext(ctx, `
-			INSERT INTO builds(steps, job, status, error, started_at, duration, build_number, job_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?,
+			INSERT INTO builds(steps, job, status, error, started_at, duration, worker_id, cells, build_number, job_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?,
 				(
 					SELECT j.id
 					FROM jobs AS j
@@ -171,7 +183,7 @@ func (r *BuildRepository) CreateRetry(ctx context.Context, tc, pn, jn, parentBui
 					JOIN teams AS t
 						ON p.team_id = t.id
 					WHERE t.canonical = ? AND p.name = ? AND j.name = ?
-				))`, dbb.Steps, dbb.Job, dbb.Status, dbb.Error, dbb.StartedAt, dbb.Duration, buildNumber, tc, pn, jn)
+				))`, dbb.Steps, dbb.Job, dbb.Status, dbb.Error, dbb.StartedAt, dbb.Duration, dbb.WorkerID, dbb.Cells, buildNumber, tc, pn, jn)
 		if err != nil {
 			if isUniqueViolation(err) {
 				continue
@@ -216,7 +228,7 @@ func (r *BuildRepository) FindGetVersions(ctx context.Context, buildID uint32) (
 
 func (r *BuildRepository) Find(ctx context.Context, tc, pn, jn string, buildNumber string) (*build.Build, error) {
 	row := r.querier.QueryRowContext(ctx, `
-		SELECT b.id, b.build_number, b.steps, b.job, b.status, b.error, b.started_at, b.duration
+		SELECT b.id, b.build_number, b.steps, b.job, b.status, b.error, b.started_at, b.duration, b.worker_id, b.cells
 		FROM builds AS b
 		JOIN jobs AS j
 			ON b.job_id = j.id
@@ -237,7 +249,7 @@ func (r *BuildRepository) Find(ctx context.Context, tc, pn, jn string, buildNumb
 
 func (r *BuildRepository) Filter(ctx context.Context, tc, pn, jn string) ([]*build.Build, error) {
 	rows, err := r.querier.QueryContext(ctx, `
-		SELECT b.id, b.build_number, b.steps, b.job, b.status, b.error, b.started_at, b.duration
+		SELECT b.id, b.build_number, b.steps, b.job, b.status, b.error, b.started_at, b.duration, b.worker_id, b.cells
 		FROM builds AS b
 		JOIN jobs AS j
 			ON b.job_id = j.id
@@ -263,7 +275,7 @@ func (r *BuildRepository) Update(ctx context.Context, tc, pn, jn string, buildNu
 	dbb := newDBBuild(b)
 	res, err := r.querier.ExecContext(ctx, `
 		UPDATE builds AS b
-		SET steps = ?, job = ?, status = ?, error = ?, started_at = ?, duration = ?
+		SET steps = ?, job = ?, status = ?, error = ?, started_at = ?, duration = ?, worker_id = ?, cells = ?
 		FROM (
 			SELECT b.id
 			FROM builds AS b
@@ -276,7 +288,7 @@ func (r *BuildRepository) Update(ctx context.Context, tc, pn, jn string, buildNu
 			WHERE t.canonical = ? AND p.name = ? AND j.name = ? AND b.build_number = ?
 		) AS bb
 		WHERE bb.id = b.id
-	`, dbb.Steps, dbb.Job, dbb.Status, dbb.Error, dbb.StartedAt, dbb.Duration, tc, pn, jn, buildNumber)
+	`, dbb.Steps, dbb.Job, dbb.Status, dbb.Error, dbb.StartedAt, dbb.Duration, dbb.WorkerID, dbb.Cells, tc, pn, jn, buildNumber)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -348,6 +360,8 @@ func (r *BuildRepository) FindReadyDownstreamVersion(ctx context.Context, tc, pn
 	// HAVING count
 	args = append(args, upstreamCount)
 
+	// Disabled versions are never ready and, if the resource
+	// is pinned, only the pinned version can be
 	query := `
 		SELECT bgv.version_id
 		FROM build_get_versions bgv
@@ -355,9 +369,13 @@ func (r *BuildRepository) FindReadyDownstreamVersion(ctx context.Context, tc, pn
 		JOIN jobs j ON b.job_id = j.id
 		JOIN pipelines p ON j.pipeline_id = p.id
 		JOIN teams t ON p.team_id = t.id
+		LEFT JOIN resource_versions rv ON bgv.version_id = rv.id
+		LEFT JOIN resources r ON rv.resource_id = r.id
 		WHERE t.canonical = ? AND p.name = ? AND b.status = 'succeeded'
 		  AND j.name IN (` + strings.Join(placeholders, ", ") + `)
 		  AND bgv.step_name = ?
+		  AND COALESCE(rv.disabled, FALSE) = FALSE
+		  AND (r.pinned_version_id IS NULL OR r.pinned_version_id = bgv.version_id)
 		  AND bgv.version_id NOT IN (
 			  SELECT bgv2.version_id FROM build_get_versions bgv2
 			  JOIN builds b2 ON bgv2.build_id = b2.id
@@ -453,6 +471,279 @@ func (r *BuildRepository) CountRunning(ctx context.Context, tc, pn, jn string) (
 	return count, nil
 }
 
+// FilterStartedByWorker returns the builds still started
+// by the worker, with the Job they belong to
+func (r *BuildRepository) FilterStartedByWorker(ctx context.Context, workerID uint32) ([]*build.WithJob, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT b.id, b.build_number, b.steps, b.job, b.status, b.error, b.started_at, b.duration, b.worker_id, b.cells,
+			t.canonical, p.name, j.name
+		FROM builds AS b
+		JOIN jobs AS j
+			ON b.job_id = j.id
+		JOIN pipelines AS p
+			ON j.pipeline_id = p.id
+		JOIN teams AS t
+			ON p.team_id = t.id
+		WHERE b.worker_id = ? AND b.status = 'started'
+	`, workerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter started builds: %w", err)
+	}
+
+	bs, err := scanBuildsWithJob(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter started builds: %w", err)
+	}
+
+	return bs, nil
+}
+
+// FilterPending returns the pending builds of the Team, or only the ones
+// of the Pipeline pn or the Job jn if set, in the order they were queued
+func (r *BuildRepository) FilterPending(ctx context.Context, tc, pn, jn string) ([]*build.WithJob, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT b.id, b.build_number, b.steps, b.job, b.status, b.error, b.started_at, b.duration, b.worker_id, b.cells,
+			t.canonical, p.name, j.name
+		FROM builds AS b
+		JOIN jobs AS j
+			ON b.job_id = j.id
+		JOIN pipelines AS p
+			ON j.pipeline_id = p.id
+		JOIN teams AS t
+			ON p.team_id = t.id
+		WHERE t.canonical = ? AND (? = '' OR p.name = ?) AND (? = '' OR j.name = ?)
+			AND b.status = 'pending'
+		ORDER BY b.id
+	`, tc, pn, pn, jn, jn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter pending builds: %w", err)
+	}
+
+	bs, err := scanBuildsWithJob(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter pending builds: %w", err)
+	}
+
+	return bs, nil
+}
+
+func (r *BuildRepository) AppendLogChunk(ctx context.Context, tc, pn, jn string, buildNumber string, c build.LogChunk) error {
+	res, err := r.querier.ExecContext(ctx, `
+		INSERT INTO build_log_chunks (build_id, step_id, log_offset, data)
+		SELECT b.id, ?, ?, ?
+		FROM builds AS b
+		JOIN jobs AS j
+			ON b.job_id = j.id
+		JOIN pipelines AS p
+			ON j.pipeline_id = p.id
+		JOIN teams AS t
+			ON p.team_id = t.id
+		WHERE t.canonical = ? AND p.name = ? AND j.name = ? AND b.build_number = ?
+	`, c.StepID, c.Offset, c.Data, tc, pn, jn, buildNumber)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to append log chunk: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteLogChunks deletes the log chunks of the steps with stepIDs
+// of the build, or all the ones of the build if stepIDs is empty
+func (r *BuildRepository) DeleteLogChunks(ctx context.Context, tc, pn, jn string, buildNumber string, stepIDs []string) error {
+	args := []interface{}{tc, pn, jn, buildNumber}
+	query := `
+		DELETE
+		FROM build_log_chunks
+		WHERE build_id IN (
+			SELECT b.id
+			FROM builds AS b
+			JOIN jobs AS j
+				ON b.job_id = j.id
+			JOIN pipelines AS p
+				ON j.pipeline_id = p.id
+			JOIN teams AS t
+				ON p.team_id = t.id
+			WHERE t.canonical = ? AND p.name = ? AND j.name = ? AND b.build_number = ?
+		)`
+	if len(stepIDs) != 0 {
+		placeholders := make([]string, len(stepIDs))
+		for i, id := range stepIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += ` AND step_id IN (` + strings.Join(placeholders, ", ") + `)`
+	}
+
+	_, err := r.querier.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+// FilterLogChunks returns the log chunks of the build
+// appended after the one with afterID, in order
+func (r *BuildRepository) FilterLogChunks(ctx context.Context, tc, pn, jn string, buildNumber string, afterID uint32) ([]*build.LogChunk, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT c.id, c.step_id, c.log_offset, c.data
+		FROM build_log_chunks AS c
+		JOIN builds AS b
+			ON c.build_id = b.id
+		JOIN jobs AS j
+			ON b.job_id = j.id
+		JOIN pipelines AS p
+			ON j.pipeline_id = p.id
+		JOIN teams AS t
+			ON p.team_id = t.id
+		WHERE t.canonical = ? AND p.name = ? AND j.name = ? AND b.build_number = ? AND c.id > ?
+		ORDER BY c.id
+	`, tc, pn, jn, buildNumber, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var cs []*build.LogChunk
+	for rows.Next() {
+		var (
+			c    build.LogChunk
+			data sql.NullString
+		)
+		if err := rows.Scan(&c.ID, &c.StepID, &c.Offset, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan log chunk: %w", err)
+		}
+		c.Data = data.String
+		cs = append(cs, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan log chunk: %w", err)
+	}
+
+	return cs, nil
+}
+
+// CreateTestCases stores the cs reported by
+// the steps of the build with buildNumber
+func (r *BuildRepository) CreateTestCases(ctx context.Context, tc, pn, jn string, buildNumber string, cs []build.TestCase) error {
+	var bID uint32
+	err := r.querier.QueryRowContext(ctx, `
+		SELECT b.id
+		FROM builds AS b
+		JOIN jobs AS j
+			ON b.job_id = j.id
+		JOIN pipelines AS p
+			ON j.pipeline_id = p.id
+		JOIN teams AS t
+			ON p.team_id = t.id
+		WHERE t.canonical = ? AND p.name = ? AND j.name = ? AND b.build_number = ?
+	`, tc, pn, jn, buildNumber).Scan(&bID)
+	if err != nil {
+		return fmt.Errorf("failed to find build: %w", err)
+	}
+
+	for _, c := range cs {
+		_, err := r.querier.ExecContext(ctx, `
+			INSERT INTO test_cases (build_id, step_name, suite, classname, name, status, duration, message)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, bID, c.Step, c.Suite, toNullString(c.Classname), c.Name, string(c.Status), int64(c.Duration), toNullString(c.Message))
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FilterTestCases returns the test cases of the
+// build in the order they were reported
+func (r *BuildRepository) FilterTestCases(ctx context.Context, tc, pn, jn string, buildNumber string) ([]*build.TestCase, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT c.id, b.build_number, c.step_name, c.suite, c.classname, c.name, c.status, c.duration, c.message
+		FROM test_cases AS c
+		JOIN builds AS b
+			ON c.build_id = b.id
+		JOIN jobs AS j
+			ON b.job_id = j.id
+		JOIN pipelines AS p
+			ON j.pipeline_id = p.id
+		JOIN teams AS t
+			ON p.team_id = t.id
+		WHERE t.canonical = ? AND p.name = ? AND j.name = ? AND b.build_number = ?
+		ORDER BY c.id
+	`, tc, pn, jn, buildNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTestCases(rows)
+}
+
+// FilterJobTestCases returns the test cases of the last builds
+// of the job, from the oldest build to the newest
+func (r *BuildRepository) FilterJobTestCases(ctx context.Context, tc, pn, jn string, builds int) ([]*build.TestCase, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT c.id, b.build_number, c.step_name, c.suite, c.classname, c.name, c.status, c.duration, c.message
+		FROM test_cases AS c
+		JOIN builds AS b
+			ON c.build_id = b.id
+		JOIN (
+			SELECT b.id
+			FROM builds AS b
+			JOIN jobs AS j
+				ON b.job_id = j.id
+			JOIN pipelines AS p
+				ON j.pipeline_id = p.id
+			JOIN teams AS t
+				ON p.team_id = t.id
+			WHERE t.canonical = ? AND p.name = ? AND j.name = ? AND EXISTS (
+				SELECT 1 FROM test_cases AS tcs WHERE tcs.build_id = b.id
+			)
+			ORDER BY b.id DESC
+			LIMIT ?
+		) AS lb
+			ON lb.id = b.id
+		ORDER BY b.id, c.id
+	`, tc, pn, jn, builds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTestCases(rows)
+}
+
+func scanTestCases(rows *sql.Rows) ([]*build.TestCase, error) {
+	var cs []*build.TestCase
+	for rows.Next() {
+		var (
+			c                  build.TestCase
+			classname, message sql.NullString
+			status             string
+			duration           int64
+		)
+		if err := rows.Scan(&c.ID, &c.BuildNumber, &c.Step, &c.Suite, &classname, &c.Name, &status, &duration, &message); err != nil {
+			return nil, fmt.Errorf("failed to scan test case: %w", err)
+		}
+		c.Classname = classname.String
+		c.Message = message.String
+		c.Status = build.TestStatus(status)
+		c.Duration = time.Duration(duration)
+		cs = append(cs, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan test case: %w", err)
+	}
+
+	return cs, nil
+}
+
 func scanBuild(s sqlr.Scanner) (*build.Build, error) {
 	var b dbBuild
 
@@ -465,6 +756,8 @@ func scanBuild(s sqlr.Scanner) (*build.Build, error) {
 		&b.Error,
 		&b.StartedAt,
 		&b.Duration,
+		&b.WorkerID,
+		&b.Cells,
 	)
 
 	if err != nil {
@@ -492,3 +785,44 @@ func scanBuilds(rows *sql.Rows) ([]*build.Build, error) {
 	}
 	return bs, nil
 }
+
+func scanBuildsWithJob(rows *sql.Rows) ([]*build.WithJob, error) {
+	defer rows.Close()
+
+	var bs []*build.WithJob
+	for rows.Next() {
+		var (
+			dbb        dbBuild
+			tc, pn, jn sql.NullString
+		)
+		err := rows.Scan(
+			&dbb.ID,
+			&dbb.BuildNumber,
+			&dbb.Steps,
+			&dbb.Job,
+			&dbb.Status,
+			&dbb.Error,
+			&dbb.StartedAt,
+			&dbb.Duration,
+			&dbb.WorkerID,
+			&dbb.Cells,
+			&tc,
+			&pn,
+			&jn,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan build: %w", err)
+		}
+		bs = append(bs, &build.WithJob{
+			Build:         *dbb.toDomainEntity(),
+			TeamCanonical: tc.String,
+			PipelineName:  pn.String,
+			JobName:       jn.String,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan build: %w", err)
+	}
+
+	return bs, nil
+}
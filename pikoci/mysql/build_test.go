@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/mysql"
 )
 
@@ -34,6 +35,19 @@ func TestFind(t *testing.T) {
 	assert.Equal(t, uint32(buildID), b.ID)
 	assert.Equal(t, "1", b.BuildNumber)
 	assert.Equal(t, "started", b.Status.String())
+	assert.Nil(t, b.Cells)
+
+	b.Status = build.Failed
+	b.Cells = []build.Cell{
+		{Name: "go=1.24", Status: build.Failed, Error: "failed to create the workdir"},
+		{Name: "go=1.25", Status: build.Succeeded},
+	}
+	err = br.Update(ctx, "main", "find-pipe", "build", "1", *b)
+	require.NoError(t, err)
+
+	ub, err := br.Find(ctx, "main", "find-pipe", "build", "1")
+	require.NoError(t, err)
+	assert.Equal(t, b.Cells, ub.Cells)
 }
 
 func TestInsertGetVersion(t *testing.T) {
@@ -71,6 +85,159 @@ func TestInsertGetVersion(t *testing.T) {
 	assert.Equal(t, 42, versionID)
 }
 
+func TestLogChunks(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name) VALUES (1, 'chunks')`)
+	require.NoError(t, err)
+	ppID, _ := res.LastInsertId()
+
+	res, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'build')`, ppID)
+	require.NoError(t, err)
+	jobID, _ := res.LastInsertId()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO builds (job_id, status, build_number) VALUES (?, 'started', '1')`, jobID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO builds (job_id, status, build_number) VALUES (?, 'started', '2')`, jobID)
+	require.NoError(t, err)
+
+	br := mysql.NewBuildRepository(db, mysql.Mem)
+
+	require.NoError(t, br.AppendLogChunk(ctx, "main", "chunks", "build", "1", build.LogChunk{StepID: "s1", Offset: 0, Data: "hello "}))
+	require.NoError(t, br.AppendLogChunk(ctx, "main", "chunks", "build", "2", build.LogChunk{StepID: "s1", Offset: 0, Data: "other"}))
+	require.NoError(t, br.AppendLogChunk(ctx, "main", "chunks", "build", "1", build.LogChunk{StepID: "s1", Offset: 6, Data: "world"}))
+
+	// The same offset of a step can't be appended twice
+	assert.Error(t, br.AppendLogChunk(ctx, "main", "chunks", "build", "1", build.LogChunk{StepID: "s1", Offset: 6, Data: "again"}))
+	assert.Error(t, br.AppendLogChunk(ctx, "main", "chunks", "build", "3", build.LogChunk{StepID: "s1", Offset: 0, Data: "missing"}))
+
+	cs, err := br.FilterLogChunks(ctx, "main", "chunks", "build", "1", 0)
+	require.NoError(t, err)
+	require.Len(t, cs, 2)
+	assert.Equal(t, build.LogChunk{ID: cs[0].ID, StepID: "s1", Offset: 0, Data: "hello "}, *cs[0])
+	assert.Equal(t, build.LogChunk{ID: cs[1].ID, StepID: "s1", Offset: 6, Data: "world"}, *cs[1])
+
+	cs, err = br.FilterLogChunks(ctx, "main", "chunks", "build", "1", cs[0].ID)
+	require.NoError(t, err)
+	require.Len(t, cs, 1)
+	assert.Equal(t, "world", cs[0].Data)
+
+	// Only the chunks of the finished steps are deleted
+	require.NoError(t, br.AppendLogChunk(ctx, "main", "chunks", "build", "1", build.LogChunk{StepID: "s2", Offset: 0, Data: "running"}))
+	require.NoError(t, br.DeleteLogChunks(ctx, "main", "chunks", "build", "1", []string{"s1"}))
+	cs, err = br.FilterLogChunks(ctx, "main", "chunks", "build", "1", 0)
+	require.NoError(t, err)
+	require.Len(t, cs, 1)
+	assert.Equal(t, "s2", cs[0].StepID)
+
+	// Without steps all the chunks of the build are deleted
+	require.NoError(t, br.DeleteLogChunks(ctx, "main", "chunks", "build", "1", nil))
+	cs, err = br.FilterLogChunks(ctx, "main", "chunks", "build", "1", 0)
+	require.NoError(t, err)
+	assert.Empty(t, cs)
+
+	// Deleting the build removes its chunks
+	require.NoError(t, br.Delete(ctx, "main", "chunks", "build", "1"))
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM build_log_chunks`).Scan(&n))
+	assert.Equal(t, 1, n)
+}
+
+func TestTestCases(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name) VALUES (1, 'tests')`)
+	require.NoError(t, err)
+	ppID, _ := res.LastInsertId()
+
+	res, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'unit')`, ppID)
+	require.NoError(t, err)
+	jobID, _ := res.LastInsertId()
+
+	for _, bn := range []string{"1", "2", "3"} {
+		_, err = db.ExecContext(ctx, `INSERT INTO builds (job_id, status, build_number) VALUES (?, 'succeeded', ?)`, jobID, bn)
+		require.NoError(t, err)
+	}
+
+	br := mysql.NewBuildRepository(db, mysql.Mem)
+
+	pass := build.TestCase{Step: "test", Suite: "pkg", Classname: "pkg.A", Name: "TestA", Status: build.TestPassed, Duration: time.Second}
+	fail := build.TestCase{Step: "test", Suite: "pkg", Name: "TestB", Status: build.TestFailed, Message: "expected 1"}
+
+	require.NoError(t, br.CreateTestCases(ctx, "main", "tests", "unit", "1", []build.TestCase{pass, fail}))
+	require.NoError(t, br.CreateTestCases(ctx, "main", "tests", "unit", "2", []build.TestCase{fail}))
+	require.NoError(t, br.CreateTestCases(ctx, "main", "tests", "unit", "3", []build.TestCase{pass}))
+	assert.Error(t, br.CreateTestCases(ctx, "main", "tests", "unit", "4", []build.TestCase{pass}))
+
+	cs, err := br.FilterTestCases(ctx, "main", "tests", "unit", "1")
+	require.NoError(t, err)
+	require.Len(t, cs, 2)
+	pass.ID, pass.BuildNumber = cs[0].ID, "1"
+	fail.ID, fail.BuildNumber = cs[1].ID, "1"
+	assert.Equal(t, pass, *cs[0])
+	assert.Equal(t, fail, *cs[1])
+
+	cs, err = br.FilterJobTestCases(ctx, "main", "tests", "unit", 2)
+	require.NoError(t, err)
+	require.Len(t, cs, 2)
+	assert.Equal(t, "2", cs[0].BuildNumber)
+	assert.Equal(t, "TestB", cs[0].Name)
+	assert.Equal(t, "3", cs[1].BuildNumber)
+	assert.Equal(t, "TestA", cs[1].Name)
+
+	// Deleting the build removes its test cases
+	require.NoError(t, br.Delete(ctx, "main", "tests", "unit", "1"))
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM test_cases`).Scan(&n))
+	assert.Equal(t, 2, n)
+}
+
+func TestFilterPending(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name) VALUES (1, 'pending')`)
+	require.NoError(t, err)
+	ppID, _ := res.LastInsertId()
+
+	res, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'build')`, ppID)
+	require.NoError(t, err)
+	buildJobID, _ := res.LastInsertId()
+	res, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'test')`, ppID)
+	require.NoError(t, err)
+	testJobID, _ := res.LastInsertId()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO builds (job_id, status, build_number) VALUES (?, 'pending', '1')`, testJobID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO builds (job_id, status, build_number) VALUES (?, 'started', '1')`, buildJobID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO builds (job_id, status, build_number) VALUES (?, 'pending', '2')`, buildJobID)
+	require.NoError(t, err)
+
+	br := mysql.NewBuildRepository(db, mysql.Mem)
+
+	bs, err := br.FilterPending(ctx, "main", "", "")
+	require.NoError(t, err)
+	require.Len(t, bs, 2)
+	assert.Equal(t, "test", bs[0].JobName)
+	assert.Equal(t, "1", bs[0].BuildNumber)
+	assert.Equal(t, "build", bs[1].JobName)
+	assert.Equal(t, "2", bs[1].BuildNumber)
+	assert.Equal(t, build.Pending, bs[1].Status)
+	assert.Equal(t, "pending", bs[1].PipelineName)
+
+	bs, err = br.FilterPending(ctx, "main", "pending", "build")
+	require.NoError(t, err)
+	require.Len(t, bs, 1)
+	assert.Equal(t, "2", bs[0].BuildNumber)
+
+	bs, err = br.FilterPending(ctx, "other", "", "")
+	require.NoError(t, err)
+	assert.Empty(t, bs)
+}
+
 func TestLastBuildAtByPipeline(t *testing.T) {
 	db := setupTestDB(t)
 	ctx := context.Background()
@@ -389,3 +556,63 @@ func TestFindReadyDownstreamVersion_PicksHighestVersion(t *testing.T) {
 	assert.True(t, ready)
 	assert.Equal(t, uint32(10), vID)
 }
+
+func TestFindReadyDownstreamVersion_DisabledAndPinnedVersions(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name) VALUES (1, 'bgv-pinned')`)
+	require.NoError(t, err)
+	ppID, _ := res.LastInsertId()
+
+	res, err = db.ExecContext(ctx, `INSERT INTO resources (pipeline_id, name, type, canonical) VALUES (?, 'repo', 'git', 'git.repo')`, ppID)
+	require.NoError(t, err)
+	rID, _ := res.LastInsertId()
+
+	var vIDs []int64
+	for _, h := range []string{"a", "b", "c"} {
+		res, err = db.ExecContext(ctx, `INSERT INTO resource_versions (resource_id, version) VALUES (?, ?)`, rID, h)
+		require.NoError(t, err)
+		vID, _ := res.LastInsertId()
+		vIDs = append(vIDs, vID)
+	}
+
+	res, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'lint')`, ppID)
+	require.NoError(t, err)
+	lintJobID, _ := res.LastInsertId()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'deploy')`, ppID)
+	require.NoError(t, err)
+
+	// Lint succeeded with all the versions
+	for i, vID := range vIDs {
+		res, err = db.ExecContext(ctx, `INSERT INTO builds (job_id, status, build_number) VALUES (?, 'succeeded', ?)`, lintJobID, i+1)
+		require.NoError(t, err)
+		bID, _ := res.LastInsertId()
+		_, err = db.ExecContext(ctx, `INSERT INTO build_get_versions (build_id, step_name, version_id) VALUES (?, 'repo', ?)`, bID, vID)
+		require.NoError(t, err)
+	}
+
+	br := mysql.NewBuildRepository(db, mysql.Mem)
+	rr := mysql.NewResourceRepository(db, mysql.Mem)
+
+	// The newest version is disabled so the previous one is used
+	err = rr.SetVersionDisabled(ctx, "main", "bgv-pinned", "git.repo", uint32(vIDs[2]), true)
+	require.NoError(t, err)
+
+	vID, ready, err := br.FindReadyDownstreamVersion(ctx, "main", "bgv-pinned",
+		[]string{"lint"}, "deploy", "repo", 1)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.Equal(t, uint32(vIDs[1]), vID)
+
+	// Only the pinned version can be used
+	err = rr.PinVersion(ctx, "main", "bgv-pinned", "git.repo", uint32(vIDs[0]))
+	require.NoError(t, err)
+
+	vID, ready, err = br.FindReadyDownstreamVersion(ctx, "main", "bgv-pinned",
+		[]string{"lint"}, "deploy", "repo", 1)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.Equal(t, uint32(vIDs[0]), vID)
+}
@@ -28,6 +28,12 @@ type dbJob struct {
 	OnFailure   sql.NullString
 	Ensure      sql.NullString
 	Concurrency sql.NullInt64
+	Cache       sql.NullString
+	Paused      sql.NullBool
+	Tags        sql.NullString
+
+	SerialGroups sql.NullString
+	Matrix       sql.NullString
 }
 
 func newDBJob(p job.Job) dbJob {
@@ -35,6 +41,26 @@ func newDBJob(p job.Job) dbJob {
 	s, _ := json.Marshal(p.OnSuccess)
 	f, _ := json.Marshal(p.OnFailure)
 	e, _ := json.Marshal(p.Ensure)
+	var c sql.NullString
+	if p.Cache != nil {
+		cb, _ := json.Marshal(p.Cache)
+		c = toNullString(string(cb))
+	}
+	var t sql.NullString
+	if len(p.Tags) != 0 {
+		tb, _ := json.Marshal(p.Tags)
+		t = toNullString(string(tb))
+	}
+	var sg sql.NullString
+	if len(p.SerialGroups) != 0 {
+		sgb, _ := json.Marshal(p.SerialGroups)
+		sg = toNullString(string(sgb))
+	}
+	var m sql.NullString
+	if len(p.Matrix) != 0 {
+		mb, _ := json.Marshal(p.Matrix)
+		m = toNullString(string(mb))
+	}
 	return dbJob{
 		Name:        toNullString(p.Name),
 		Plan:        toNullString(string(pl)),
@@ -42,6 +68,11 @@ func newDBJob(p job.Job) dbJob {
 		OnFailure:   toNullString(string(f)),
 		Ensure:      toNullString(string(e)),
 		Concurrency: sql.NullInt64{Int64: int64(p.Concurrency), Valid: true},
+		Cache:       c,
+		Tags:        t,
+
+		SerialGroups: sg,
+		Matrix:       m,
 	}
 }
 
@@ -50,12 +81,25 @@ func (dbp *dbJob) toDomainEntity() *job.Job {
 		ID:          uint32(dbp.ID.Int64),
 		Name:        dbp.Name.String,
 		Concurrency: int(dbp.Concurrency.Int64),
+		Paused:      dbp.Paused.Bool,
 	}
 
 	_ = json.Unmarshal([]byte(dbp.Plan.String), &j.Plan)
 	_ = json.Unmarshal([]byte(dbp.OnSuccess.String), &j.OnSuccess)
 	_ = json.Unmarshal([]byte(dbp.OnFailure.String), &j.OnFailure)
 	_ = json.Unmarshal([]byte(dbp.Ensure.String), &j.Ensure)
+	if dbp.Cache.Valid && dbp.Cache.String != "" {
+		_ = json.Unmarshal([]byte(dbp.Cache.String), &j.Cache)
+	}
+	if dbp.Tags.Valid && dbp.Tags.String != "" {
+		_ = json.Unmarshal([]byte(dbp.Tags.String), &j.Tags)
+	}
+	if dbp.SerialGroups.Valid && dbp.SerialGroups.String != "" {
+		_ = json.Unmarshal([]byte(dbp.SerialGroups.String), &j.SerialGroups)
+	}
+	if dbp.Matrix.Valid && dbp.Matrix.String != "" {
+		_ = json.Unmarshal([]byte(dbp.Matrix.String), &j.Matrix)
+	}
 
 	return j
 }
@@ -63,8 +107,8 @@ func (dbp *dbJob) toDomainEntity() *job.Job {
 func (r *JobRepository) Create(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
 	dbj := newDBJob(j)
 	res, err := r.querier.ExecContext(ctx, `
-		INSERT INTO jobs(name, plan, on_success, on_failure, ensure, concurrency, pipeline_id)
-		VALUES (?, ?, ?, ?, ?, ?,
+		INSERT INTO jobs(name, plan, on_success, on_failure, ensure, concurrency, cache, tags, serial_groups, matrix, pipeline_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
 			-- pipeline_id
 			(
 				SELECT p.id
@@ -72,7 +116,7 @@ func (r *JobRepository) Create(ctx context.Context, tc, pn string, j job.Job) (u
 				JOIN teams AS t
 					ON p.team_id = t.id
 				WHERE t.canonical = ? AND p.name = ?
-			))`, dbj.Name, dbj.Plan, dbj.OnSuccess, dbj.OnFailure, dbj.Ensure, dbj.Concurrency, tc, pn)
+			))`, dbj.Name, dbj.Plan, dbj.OnSuccess, dbj.OnFailure, dbj.Ensure, dbj.Concurrency, dbj.Cache, dbj.Tags, dbj.SerialGroups, dbj.Matrix, tc, pn)
 	if err != nil {
 		return 0, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -89,7 +133,7 @@ func (r *JobRepository) Update(ctx context.Context, tc, pn, jn string, j job.Job
 	dbj := newDBJob(j)
 	res, err := r.querier.ExecContext(ctx, `
 		UPDATE jobs AS j
-		SET name = ?, plan = ?, on_success = ?, on_failure = ?, ensure = ?, concurrency = ?
+		SET name = ?, plan = ?, on_success = ?, on_failure = ?, ensure = ?, concurrency = ?, cache = ?, tags = ?, serial_groups = ?, matrix = ?
 		FROM (
 			SELECT j.id
 			FROM jobs AS j
@@ -100,7 +144,7 @@ func (r *JobRepository) Update(ctx context.Context, tc, pn, jn string, j job.Job
 			WHERE t.canonical = ? AND p.name = ? AND j.name = ?
 		) AS jj
 		WHERE jj.id = j.id
-	`, dbj.Name, dbj.Plan, dbj.OnSuccess, dbj.OnFailure, dbj.Ensure, dbj.Concurrency, tc, pn, jn)
+	`, dbj.Name, dbj.Plan, dbj.OnSuccess, dbj.OnFailure, dbj.Ensure, dbj.Concurrency, dbj.Cache, dbj.Tags, dbj.SerialGroups, dbj.Matrix, tc, pn, jn)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -113,9 +157,36 @@ func (r *JobRepository) Update(ctx context.Context, tc, pn, jn string, j job.Job
 	return nil
 }
 
+func (r *JobRepository) SetPaused(ctx context.Context, tc, pn, jn string, paused bool) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE jobs AS j
+		SET paused = ?
+		FROM (
+			SELECT j.id
+			FROM jobs AS j
+			JOIN pipelines AS p
+				ON j.pipeline_id = p.id
+			JOIN teams AS t
+				ON p.team_id = t.id
+			WHERE t.canonical = ? AND p.name = ? AND j.name = ?
+		) AS jj
+		WHERE jj.id = j.id
+	`, paused, tc, pn, jn)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to set paused on Job: %w", err)
+	}
+
+	return nil
+}
+
 func (r *JobRepository) Find(ctx context.Context, tc, pn, jn string) (*job.Job, error) {
 	row := r.querier.QueryRowContext(ctx, `
-		SELECT j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure, j.concurrency
+		SELECT j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure, j.concurrency, j.cache, j.paused, j.tags, j.serial_groups, j.matrix
 		FROM jobs AS j
 		JOIN pipelines AS p
 			ON j.pipeline_id = p.id
@@ -134,7 +205,7 @@ func (r *JobRepository) Find(ctx context.Context, tc, pn, jn string) (*job.Job,
 
 func (r *JobRepository) Filter(ctx context.Context, tc, pn string) ([]*job.Job, error) {
 	rows, err := r.querier.QueryContext(ctx, `
-		SELECT j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure, j.concurrency
+		SELECT j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure, j.concurrency, j.cache, j.paused, j.tags, j.serial_groups, j.matrix
 		FROM jobs AS j
 		JOIN pipelines AS p
 			ON j.pipeline_id = p.id
@@ -191,6 +262,11 @@ func scanJob(s sqlr.Scanner) (*job.Job, error) {
 		&j.OnFailure,
 		&j.Ensure,
 		&j.Concurrency,
+		&j.Cache,
+		&j.Paused,
+		&j.Tags,
+		&j.SerialGroups,
+		&j.Matrix,
 	)
 
 	if err != nil {
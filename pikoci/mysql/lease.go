@@ -0,0 +1,175 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cycloidio/sqlr"
+	"github.com/xescugc/pikoci/pikoci/queue"
+)
+
+type LeaseRepository struct {
+	querier sqlr.Querier
+}
+
+func NewLeaseRepository(db sqlr.Querier) *LeaseRepository {
+	return &LeaseRepository{
+		querier: db,
+	}
+}
+
+type dbLease struct {
+	ID        sql.NullString
+	WorkerID  sql.NullInt64
+	Body      sql.NullString
+	ExpiresAt sql.NullTime
+}
+
+func newDBLease(l queue.Lease) dbLease {
+	return dbLease{
+		ID:        toNullString(l.ID),
+		WorkerID:  toNullInt64(int(l.WorkerID)),
+		Body:      toNullString(string(l.Body)),
+		ExpiresAt: toNullTime(l.ExpiresAt),
+	}
+}
+
+func (dbl *dbLease) toDomainEntity() *queue.Lease {
+	return &queue.Lease{
+		ID:        dbl.ID.String,
+		WorkerID:  uint32(dbl.WorkerID.Int64),
+		Body:      []byte(dbl.Body.String),
+		ExpiresAt: dbl.ExpiresAt.Time,
+	}
+}
+
+func (r *LeaseRepository) Create(ctx context.Context, l queue.Lease) error {
+	dbl := newDBLease(l)
+	_, err := r.querier.ExecContext(ctx, `
+		INSERT INTO work_leases(id, worker_id, body, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, dbl.ID, dbl.WorkerID, dbl.Body, dbl.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LeaseRepository) Find(ctx context.Context, workerID uint32, id string) (*queue.Lease, error) {
+	row := r.querier.QueryRowContext(ctx, `
+		SELECT l.id, l.worker_id, l.body, l.expires_at
+		FROM work_leases AS l
+		WHERE l.worker_id = ? AND l.id = ?
+	`, workerID, id)
+
+	l, err := scanLease(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, queue.ErrLeaseNotFound
+		}
+		return nil, fmt.Errorf("failed to scan Lease: %w", err)
+	}
+
+	return l, nil
+}
+
+func (r *LeaseRepository) UpdateExpiresAt(ctx context.Context, workerID uint32, id string, expiresAt time.Time) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE work_leases
+		SET expires_at = ?
+		WHERE worker_id = ? AND id = ?
+	`, expiresAt, workerID, id)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return queue.ErrLeaseNotFound
+	}
+
+	return nil
+}
+
+func (r *LeaseRepository) Delete(ctx context.Context, workerID uint32, id string) error {
+	res, err := r.querier.ExecContext(ctx, `
+		DELETE
+		FROM work_leases
+		WHERE worker_id = ? AND id = ?
+	`, workerID, id)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return queue.ErrLeaseNotFound
+	}
+
+	return nil
+}
+
+func (r *LeaseRepository) FilterExpired(ctx context.Context, before time.Time) ([]*queue.Lease, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT l.id, l.worker_id, l.body, l.expires_at
+		FROM work_leases AS l
+		WHERE l.expires_at < ?
+		ORDER BY l.expires_at
+	`, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter expired leases: %w", err)
+	}
+	defer rows.Close()
+
+	var ls []*queue.Lease
+	for rows.Next() {
+		l, err := scanLease(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Lease: %w", err)
+		}
+		ls = append(ls, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan Lease: %w", err)
+	}
+
+	return ls, nil
+}
+
+func (r *LeaseRepository) DeleteExpired(ctx context.Context, id string, before time.Time) error {
+	res, err := r.querier.ExecContext(ctx, `
+		DELETE
+		FROM work_leases
+		WHERE id = ? AND expires_at < ?
+	`, id, before)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return queue.ErrLeaseNotFound
+	}
+
+	return nil
+}
+
+// scanLease returns the sql.ErrNoRows as is so
+// Find can return the queue.ErrLeaseNotFound
+func scanLease(s sqlr.Scanner) (*queue.Lease, error) {
+	var dbl dbLease
+	err := s.Scan(
+		&dbl.ID,
+		&dbl.WorkerID,
+		&dbl.Body,
+		&dbl.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbl.toDomainEntity(), nil
+}
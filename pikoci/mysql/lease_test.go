@@ -0,0 +1,56 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+	"github.com/xescugc/pikoci/pikoci/queue"
+)
+
+func TestLeaseRepository(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	lr := mysql.NewLeaseRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	l := queue.Lease{ID: "l1", WorkerID: 3, Body: []byte(`{"job_name":"jn"}`), ExpiresAt: now.Add(time.Minute)}
+	require.NoError(t, lr.Create(ctx, l))
+	require.NoError(t, lr.Create(ctx, queue.Lease{ID: "l2", WorkerID: 4, Body: []byte(`{}`), ExpiresAt: now.Add(time.Hour)}))
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(ctx, `DELETE FROM work_leases`)
+	})
+
+	// Only the worker with the lease can find, renew or delete it
+	_, err := lr.Find(ctx, 4, "l1")
+	assert.ErrorIs(t, err, queue.ErrLeaseNotFound)
+	assert.ErrorIs(t, lr.UpdateExpiresAt(ctx, 4, "l1", now), queue.ErrLeaseNotFound)
+	assert.ErrorIs(t, lr.Delete(ctx, 4, "l1"), queue.ErrLeaseNotFound)
+
+	fl, err := lr.Find(ctx, 3, "l1")
+	require.NoError(t, err)
+	assert.Equal(t, l.Body, fl.Body)
+	assert.True(t, l.ExpiresAt.Equal(fl.ExpiresAt))
+
+	ls, err := lr.FilterExpired(ctx, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, ls, 1)
+	assert.Equal(t, "l1", ls[0].ID)
+
+	// Renewed leases are not expired
+	require.NoError(t, lr.UpdateExpiresAt(ctx, 3, "l1", now.Add(3*time.Minute)))
+	assert.ErrorIs(t, lr.DeleteExpired(ctx, "l1", now.Add(2*time.Minute)), queue.ErrLeaseNotFound)
+	ls, err = lr.FilterExpired(ctx, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, ls)
+
+	require.NoError(t, lr.DeleteExpired(ctx, "l1", now.Add(4*time.Minute)))
+	_, err = lr.Find(ctx, 3, "l1")
+	assert.ErrorIs(t, err, queue.ErrLeaseNotFound)
+
+	require.NoError(t, lr.Delete(ctx, 4, "l2"))
+	assert.ErrorIs(t, lr.Delete(ctx, 4, "l2"), queue.ErrLeaseNotFound)
+}
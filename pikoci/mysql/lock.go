@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cycloidio/sqlr"
+	"github.com/xescugc/pikoci/pikoci/lock"
+)
+
+type LockRepository struct {
+	querier sqlr.Querier
+}
+
+func NewLockRepository(db sqlr.Querier) *LockRepository {
+	return &LockRepository{
+		querier: db,
+	}
+}
+
+type dbLock struct {
+	ID         sql.NullInt64
+	Name       sql.NullString
+	Holder     sql.NullString
+	Reason     sql.NullString
+	BuildID    sql.NullInt64
+	AcquiredAt sql.NullTime
+}
+
+func newDBLock(l lock.Lock) dbLock {
+	return dbLock{
+		Name:       toNullString(l.Name),
+		Holder:     toNullString(l.Holder),
+		Reason:     toNullString(l.Reason),
+		BuildID:    toNullInt64(int(l.BuildID)),
+		AcquiredAt: toNullTime(l.AcquiredAt),
+	}
+}
+
+func (dbl *dbLock) toDomainEntity() *lock.Lock {
+	return &lock.Lock{
+		ID:         uint32(dbl.ID.Int64),
+		Name:       dbl.Name.String,
+		Holder:     dbl.Holder.String,
+		Reason:     dbl.Reason.String,
+		BuildID:    uint32(dbl.BuildID.Int64),
+		AcquiredAt: dbl.AcquiredAt.Time,
+	}
+}
+
+func (r *LockRepository) Create(ctx context.Context, tc string, l lock.Lock) (uint32, error) {
+	dbl := newDBLock(l)
+	res, err := r.querier.ExecContext(ctx, `
+		INSERT INTO locks(name, holder, reason, build_id, acquired_at, team_id)
+		VALUES (?, ?, ?, ?, ?,
+			-- team_id
+			(
+				SELECT t.id
+				FROM teams AS t
+				WHERE t.canonical = ?
+			))
+	`, dbl.Name, dbl.Holder, dbl.Reason, dbl.BuildID, dbl.AcquiredAt, tc)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, lock.ErrHeld
+		}
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	id, err := lastInsertedID(res)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last inserted id: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *LockRepository) Find(ctx context.Context, tc, name string) (*lock.Lock, error) {
+	row := r.querier.QueryRowContext(ctx, `
+		SELECT l.id, l.name, l.holder, l.reason, l.build_id, l.acquired_at
+		FROM locks AS l
+		JOIN teams AS t
+			ON l.team_id = t.id
+		WHERE t.canonical = ? AND l.name = ?
+	`, tc, name)
+
+	l, err := scanLock(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, lock.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan Lock: %w", err)
+	}
+
+	return l, nil
+}
+
+func (r *LockRepository) Filter(ctx context.Context, tc string) ([]*lock.Lock, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT l.id, l.name, l.holder, l.reason, l.build_id, l.acquired_at
+		FROM locks AS l
+		JOIN teams AS t
+			ON l.team_id = t.id
+		WHERE t.canonical = ?
+		ORDER BY l.name
+	`, tc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter locks: %w", err)
+	}
+	defer rows.Close()
+
+	var ls []*lock.Lock
+	for rows.Next() {
+		l, err := scanLock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lock: %w", err)
+		}
+		ls = append(ls, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan lock: %w", err)
+	}
+
+	return ls, nil
+}
+
+func (r *LockRepository) Delete(ctx context.Context, tc, name string) error {
+	res, err := r.querier.ExecContext(ctx, `
+		DELETE
+		FROM locks
+		WHERE id IN (
+			SELECT l.id
+			FROM locks AS l
+			JOIN teams AS t
+				ON l.team_id = t.id
+			WHERE t.canonical = ? AND l.name = ?
+		)
+	`, tc, name)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return lock.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *LockRepository) DeleteByBuild(ctx context.Context, buildID uint32) error {
+	_, err := r.querier.ExecContext(ctx, `
+		DELETE
+		FROM locks
+		WHERE build_id = ?
+	`, buildID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+// scanLock returns the sql.ErrNoRows as is so
+// Find can return the lock.ErrNotFound
+func scanLock(s sqlr.Scanner) (*lock.Lock, error) {
+	var l dbLock
+
+	err := s.Scan(
+		&l.ID,
+		&l.Name,
+		&l.Holder,
+		&l.Reason,
+		&l.BuildID,
+		&l.AcquiredAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan: %w", err)
+	}
+
+	return l.toDomainEntity(), nil
+}
@@ -0,0 +1,77 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/lock"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+)
+
+func TestLocks(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name) VALUES (1, 'locks')`)
+	require.NoError(t, err)
+	ppID, _ := res.LastInsertId()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'deploy')`, ppID)
+	require.NoError(t, err)
+
+	br := mysql.NewBuildRepository(db, mysql.Mem)
+	bID, _, err := br.Create(ctx, "main", "locks", "deploy", build.Build{Status: build.Started})
+	require.NoError(t, err)
+
+	lr := mysql.NewLockRepository(db)
+
+	now := time.Now().Round(time.Second).UTC()
+	staging := lock.Lock{Name: "staging", Holder: "locks/deploy #1", BuildID: bID, AcquiredAt: now}
+	id, err := lr.Create(ctx, "main", staging)
+	require.NoError(t, err)
+	staging.ID = id
+
+	_, err = lr.Create(ctx, "main", lock.Lock{Name: "staging", Holder: "admin", AcquiredAt: now})
+	assert.ErrorIs(t, err, lock.ErrHeld)
+
+	_, err = lr.Create(ctx, "main", lock.Lock{Name: "production", Holder: "admin", Reason: "maintenance", AcquiredAt: now})
+	require.NoError(t, err)
+
+	l, err := lr.Find(ctx, "main", "staging")
+	require.NoError(t, err)
+	assert.Equal(t, staging.ID, l.ID)
+	assert.Equal(t, staging.Holder, l.Holder)
+	assert.Equal(t, bID, l.BuildID)
+	assert.True(t, now.Equal(l.AcquiredAt))
+
+	_, err = lr.Find(ctx, "main", "qa")
+	assert.ErrorIs(t, err, lock.ErrNotFound)
+
+	ls, err := lr.Filter(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, ls, 2)
+	assert.Equal(t, "production", ls[0].Name)
+	assert.Equal(t, "maintenance", ls[0].Reason)
+	assert.Equal(t, uint32(0), ls[0].BuildID)
+	assert.Equal(t, "staging", ls[1].Name)
+
+	err = lr.DeleteByBuild(ctx, bID)
+	require.NoError(t, err)
+
+	_, err = lr.Find(ctx, "main", "staging")
+	assert.ErrorIs(t, err, lock.ErrNotFound)
+
+	err = lr.Delete(ctx, "main", "production")
+	require.NoError(t, err)
+
+	err = lr.Delete(ctx, "main", "production")
+	assert.ErrorIs(t, err, lock.ErrNotFound)
+
+	ls, err = lr.Filter(ctx, "main")
+	require.NoError(t, err)
+	assert.Len(t, ls, 0)
+}
@@ -51,6 +51,7 @@ func adaptSQL(sql, system string) string {
 		sql = strings.ReplaceAll(sql, "id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,", "id SERIAL PRIMARY KEY,")
 		sql = strings.ReplaceAll(sql, "INT UNSIGNED NOT NULL", "INTEGER NOT NULL")
 		sql = strings.ReplaceAll(sql, "INT UNSIGNED", "INTEGER")
+		sql = strings.ReplaceAll(sql, "MEDIUMTEXT", "TEXT")
 		// Replace backtick-quoted identifiers with double-quote-quoted ones
 		sql = strings.ReplaceAll(sql, "`type`", `"type"`)
 		sql = strings.ReplaceAll(sql, "`check`", `"check"`)
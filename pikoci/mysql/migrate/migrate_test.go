@@ -15,6 +15,7 @@ func TestAdaptSQL(t *testing.T) {
 			name VARCHAR(255),
 			` + "`type`" + ` VARCHAR(255),
 			` + "`check`" + ` TEXT,
+			data MEDIUMTEXT,
 			team_id INT UNSIGNED NOT NULL
 		);
 	`
@@ -39,6 +40,7 @@ func TestAdaptSQL(t *testing.T) {
 		assert.NotContains(t, result, "AUTO_INCREMENT")
 		assert.Contains(t, result, "INTEGER NOT NULL")
 		assert.NotContains(t, result, "INT UNSIGNED")
+		assert.NotContains(t, result, "MEDIUMTEXT")
 		assert.Contains(t, result, `"type"`)
 		assert.Contains(t, result, `"check"`)
 		assert.NotContains(t, result, "`type`")
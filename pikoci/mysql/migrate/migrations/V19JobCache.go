@@ -0,0 +1,7 @@
+package migrations
+
+// V19JobCache adds a cache column to the jobs table.
+var V19JobCache = Migration{
+	Name: "JobCache",
+	SQL:  `ALTER TABLE jobs ADD COLUMN cache TEXT;`,
+}
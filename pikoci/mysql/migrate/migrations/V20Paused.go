@@ -0,0 +1,10 @@
+package migrations
+
+// V20Paused adds a paused column to the pipelines and jobs tables.
+var V20Paused = Migration{
+	Name: "Paused",
+	SQL: `
+		ALTER TABLE pipelines ADD COLUMN paused BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE jobs ADD COLUMN paused BOOLEAN NOT NULL DEFAULT FALSE;
+	`,
+}
@@ -0,0 +1,11 @@
+package migrations
+
+// V21ResourceVersionPinning adds the pinned version to the resources
+// and the disabled flag to the resource versions.
+var V21ResourceVersionPinning = Migration{
+	Name: "ResourceVersionPinning",
+	SQL: `
+		ALTER TABLE resources ADD COLUMN pinned_version_id INT UNSIGNED;
+		ALTER TABLE resource_versions ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE;
+	`,
+}
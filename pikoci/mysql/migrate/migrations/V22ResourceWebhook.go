@@ -0,0 +1,11 @@
+package migrations
+
+// V22ResourceWebhook adds the webhook verification
+// configuration to the resources.
+var V22ResourceWebhook = Migration{
+	Name: "ResourceWebhook",
+	SQL: `
+		ALTER TABLE resources ADD COLUMN webhook_type VARCHAR(16) DEFAULT '';
+		ALTER TABLE resources ADD COLUMN webhook_secret VARCHAR(255) DEFAULT '';
+	`,
+}
@@ -0,0 +1,19 @@
+package migrations
+
+// V23BuildLogChunks adds the build_log_chunks table with the
+// logs the workers append while the steps are running, so they
+// can be streamed without updating the whole build.
+var V23BuildLogChunks = Migration{
+	Name: "BuildLogChunks",
+	SQL: `
+		CREATE TABLE build_log_chunks (
+			id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			build_id INT UNSIGNED NOT NULL,
+			step_id VARCHAR(64) NOT NULL,
+			log_offset INT UNSIGNED NOT NULL,
+			data TEXT,
+			UNIQUE (build_id, step_id, log_offset),
+			FOREIGN KEY (build_id) REFERENCES builds(id) ON DELETE CASCADE
+		);
+	`,
+}
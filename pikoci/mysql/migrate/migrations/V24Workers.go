@@ -0,0 +1,22 @@
+package migrations
+
+// V24Workers adds the workers table with the registered
+// workers and their heartbeats, and the worker_id of the
+// builds so the ones of the dead workers can be recovered.
+var V24Workers = Migration{
+	Name: "Workers",
+	SQL: `
+		CREATE TABLE workers (
+			id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			version VARCHAR(255),
+			platform VARCHAR(255),
+			concurrency INT NOT NULL DEFAULT 1,
+			tags TEXT,
+			started_at TIMESTAMP NULL,
+			heartbeat_at TIMESTAMP NULL
+		);
+
+		ALTER TABLE builds ADD COLUMN worker_id INT UNSIGNED NULL;
+	`,
+}
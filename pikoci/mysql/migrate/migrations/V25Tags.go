@@ -0,0 +1,11 @@
+package migrations
+
+// V25Tags adds the tags a worker needs to have
+// to run the jobs and check the resources.
+var V25Tags = Migration{
+	Name: "Tags",
+	SQL: `
+		ALTER TABLE jobs ADD COLUMN tags TEXT;
+		ALTER TABLE resources ADD COLUMN tags TEXT;
+	`,
+}
@@ -0,0 +1,22 @@
+package migrations
+
+// V26QueueMessages adds the queue_messages table used as
+// queue by the 'sql' PubSub system, so the server and the
+// standalone workers can share it with only the DB.
+var V26QueueMessages = Migration{
+	Name: "QueueMessages",
+	SQL: `
+		CREATE TABLE queue_messages (
+			id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			topic VARCHAR(255) NOT NULL,
+			body MEDIUMTEXT,
+			metadata TEXT,
+			attempts INT NOT NULL DEFAULT 0,
+			visible_at TIMESTAMP NULL,
+			created_at TIMESTAMP NULL,
+			dead_at TIMESTAMP NULL
+		);
+
+		CREATE INDEX idx_queue_messages_visible ON queue_messages(topic, dead_at, visible_at);
+	`,
+}
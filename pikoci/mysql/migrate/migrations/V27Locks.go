@@ -0,0 +1,30 @@
+package migrations
+
+// V27Locks adds the locks table with the named locks of
+// the teams, and the serial_groups of the jobs that have
+// to hold them while their builds run.
+var V27Locks = Migration{
+	Name: "Locks",
+	SQL: `
+		CREATE TABLE locks (
+			id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			holder VARCHAR(255),
+			acquired_at TIMESTAMP NULL,
+			team_id INT UNSIGNED NOT NULL,
+			build_id INT UNSIGNED NULL,
+
+			CONSTRAINT uq__locks__team__name UNIQUE ( team_id, name ),
+
+			CONSTRAINT fk__locks__teams
+				FOREIGN KEY (team_id) REFERENCES teams (id)
+				ON DELETE CASCADE,
+
+			CONSTRAINT fk__locks__builds
+				FOREIGN KEY (build_id) REFERENCES builds (id)
+				ON DELETE CASCADE
+		);
+
+		ALTER TABLE jobs ADD COLUMN serial_groups TEXT;
+	`,
+}
@@ -0,0 +1,12 @@
+package migrations
+
+// V28Matrix adds the matrix of the jobs, with the plan
+// of each of its cells, and the status of the cells
+// of their builds.
+var V28Matrix = Migration{
+	Name: "Matrix",
+	SQL: `
+		ALTER TABLE jobs ADD COLUMN matrix TEXT;
+		ALTER TABLE builds ADD COLUMN cells TEXT;
+	`,
+}
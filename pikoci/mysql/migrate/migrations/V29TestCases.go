@@ -0,0 +1,21 @@
+package migrations
+
+// V29TestCases adds the test_cases table with the results
+// of the test reports the steps of the builds upload
+var V29TestCases = Migration{
+	Name: "TestCases",
+	SQL: `
+		CREATE TABLE test_cases (
+			id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			build_id INT UNSIGNED NOT NULL,
+			step_name VARCHAR(255) NOT NULL,
+			suite VARCHAR(255) NOT NULL,
+			classname VARCHAR(255),
+			name VARCHAR(255) NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			duration BIGINT NOT NULL DEFAULT 0,
+			message TEXT,
+			FOREIGN KEY (build_id) REFERENCES builds(id) ON DELETE CASCADE
+		);
+	`,
+}
@@ -0,0 +1,25 @@
+package migrations
+
+// V30TeamSecrets adds the secrets table with the secrets of
+// the teams, encrypted with the key of the key_id, as the
+// secrets table of V14Secrets was dropped on V15SecretTypeConfig
+var V30TeamSecrets = Migration{
+	Name: "TeamSecrets",
+	SQL: `
+		CREATE TABLE secrets (
+			id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			key_id VARCHAR(255) NOT NULL,
+			data TEXT NOT NULL,
+			created_at TIMESTAMP NULL,
+			updated_at TIMESTAMP NULL,
+			team_id INT UNSIGNED NOT NULL,
+
+			CONSTRAINT uq__secrets__team__name UNIQUE ( team_id, name ),
+
+			CONSTRAINT fk__secrets__teams
+				FOREIGN KEY (team_id) REFERENCES teams (id)
+				ON DELETE CASCADE
+		);
+	`,
+}
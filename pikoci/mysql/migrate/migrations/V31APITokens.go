@@ -0,0 +1,33 @@
+package migrations
+
+// V31APITokens adds the tokens table with the hashed API tokens,
+// owned by a user or by the service account of a team
+var V31APITokens = Migration{
+	Name: "APITokens",
+	SQL: `
+		CREATE TABLE tokens (
+			id INT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			hint VARCHAR(255),
+			hash VARCHAR(255) NOT NULL,
+			scopes TEXT,
+			expires_at TIMESTAMP NULL,
+			last_used_at TIMESTAMP NULL,
+			created_at TIMESTAMP NULL,
+			user_id INT UNSIGNED NULL,
+			team_id INT UNSIGNED NULL,
+
+			CONSTRAINT uq__tokens__hash UNIQUE ( hash ),
+			CONSTRAINT uq__tokens__user__name UNIQUE ( user_id, name ),
+			CONSTRAINT uq__tokens__team__name UNIQUE ( team_id, name ),
+
+			CONSTRAINT fk__tokens__users
+				FOREIGN KEY (user_id) REFERENCES users (id)
+				ON DELETE CASCADE,
+
+			CONSTRAINT fk__tokens__teams
+				FOREIGN KEY (team_id) REFERENCES teams (id)
+				ON DELETE CASCADE
+		);
+	`,
+}
@@ -0,0 +1,12 @@
+package migrations
+
+// V32TeamRoles replaces the admin flag of the team members with a role,
+// the admins are owners and the rest operators, which is what they could do
+var V32TeamRoles = Migration{
+	Name: "TeamRoles",
+	SQL: `
+		ALTER TABLE teams_users ADD COLUMN role VARCHAR(255) NOT NULL DEFAULT 'viewer';
+		UPDATE teams_users SET role = CASE WHEN admin THEN 'owner' ELSE 'operator' END;
+		ALTER TABLE teams_users DROP COLUMN admin;
+	`,
+}
@@ -0,0 +1,11 @@
+package migrations
+
+// V33UsersOIDCSubject adds the subject of the OIDC Provider the
+// Users are provisioned from, so they are only linked to it
+var V33UsersOIDCSubject = Migration{
+	Name: "UsersOIDCSubject",
+	SQL: `
+		ALTER TABLE users ADD COLUMN oidc_subject VARCHAR(255) NULL;
+		CREATE UNIQUE INDEX idx_users_oidc_subject ON users(oidc_subject);
+	`,
+}
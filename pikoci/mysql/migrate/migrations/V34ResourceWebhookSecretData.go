@@ -0,0 +1,12 @@
+package migrations
+
+// V34ResourceWebhookSecretData adds the webhook secret of the resources
+// encrypted with the key of the webhook_secret_key_id, the plain
+// webhook_secret is only kept until it's encrypted on the rotation
+var V34ResourceWebhookSecretData = Migration{
+	Name: "ResourceWebhookSecretData",
+	SQL: `
+		ALTER TABLE resources ADD COLUMN webhook_secret_key_id VARCHAR(255) NULL;
+		ALTER TABLE resources ADD COLUMN webhook_secret_data TEXT NULL;
+	`,
+}
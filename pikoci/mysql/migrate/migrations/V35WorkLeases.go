@@ -0,0 +1,18 @@
+package migrations
+
+// V35WorkLeases adds the work_leases table with the work claimed by
+// the pull workers, so it's sent again to the queue when they expire
+// even if the server was restarted since it was claimed
+var V35WorkLeases = Migration{
+	Name: "WorkLeases",
+	SQL: `
+		CREATE TABLE work_leases (
+			id VARCHAR(36) NOT NULL PRIMARY KEY,
+			worker_id INT UNSIGNED NOT NULL,
+			body MEDIUMTEXT,
+			expires_at TIMESTAMP NULL
+		);
+
+		CREATE INDEX idx_work_leases_expires_at ON work_leases(expires_at);
+	`,
+}
@@ -0,0 +1,10 @@
+package migrations
+
+// V36LocksReason adds the reason of the locks acquired by the
+// users, the holder is now always the username that acquired them
+var V36LocksReason = Migration{
+	Name: "LocksReason",
+	SQL: `
+		ALTER TABLE locks ADD COLUMN reason VARCHAR(255) NULL;
+	`,
+}
@@ -12,7 +12,7 @@ type Migration struct {
 // in compilation time if some order is wrong
 // if it where to have more than one person working
 // on it
-var Migrations = [19]Migration{
+var Migrations = [37]Migration{
 	V0Initial,
 	V1ResourceCheckInterval,
 	V2JobsAndBuilds,
@@ -32,4 +32,22 @@ var Migrations = [19]Migration{
 	V16BuildGetVersions,
 	V17BuildNumber,
 	V18Concurrency,
+	V19JobCache,
+	V20Paused,
+	V21ResourceVersionPinning,
+	V22ResourceWebhook,
+	V23BuildLogChunks,
+	V24Workers,
+	V25Tags,
+	V26QueueMessages,
+	V27Locks,
+	V28Matrix,
+	V29TestCases,
+	V30TeamSecrets,
+	V31APITokens,
+	V32TeamRoles,
+	V33UsersOIDCSubject,
+	V34ResourceWebhookSecretData,
+	V35WorkLeases,
+	V36LocksReason,
 }
@@ -24,6 +24,7 @@ type dbPipeline struct {
 	Name   sql.NullString
 	Raw    sql.NullString
 	Public sql.NullBool
+	Paused sql.NullBool
 }
 
 func newDBPipeline(p pipeline.Pipeline) dbPipeline {
@@ -40,6 +41,7 @@ func (dbp *dbPipeline) toDomainEntity() *pipeline.Pipeline {
 		Name:   dbp.Name.String,
 		Raw:    []byte(dbp.Raw.String),
 		Public: dbp.Public.Bool,
+		Paused: dbp.Paused.Bool,
 	}
 }
 
@@ -157,6 +159,31 @@ func (r *PipelineRepository) SetPublic(ctx context.Context, tc, pn string, publi
 	return nil
 }
 
+func (r *PipelineRepository) SetPaused(ctx context.Context, tc, pn string, paused bool) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE pipelines AS p
+		SET paused = ?
+		FROM (
+			SELECT p.id
+			FROM pipelines AS p
+			JOIN teams AS t
+				ON p.team_id = t.id
+			WHERE t.canonical = ? AND p.name = ?
+		) AS pp
+		WHERE p.id = pp.id
+	`, paused, tc, pn)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to set paused on Pipeline: %w", err)
+	}
+
+	return nil
+}
+
 func (r *PipelineRepository) Filter(ctx context.Context, tc string) ([]*pipeline.Pipeline, error) {
 	rows, err := r.querier.QueryContext(ctx, pipelineQuery+`
 		WHERE t.canonical = ?
@@ -177,9 +204,9 @@ func (r *PipelineRepository) FilterAll(ctx context.Context) ([]*pipeline.WithTea
 	rows, err := r.querier.QueryContext(ctx, `
 		SELECT
 			t.id, t.name, t.canonical,
-			p.id, p.name, p.raw, p.public,
-			j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure,
-			r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check,
+			p.id, p.name, p.raw, p.public, p.paused,
+			j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure, j.paused, j.tags,
+			r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.pinned_version_id, r.tags,
 			rt.id, rt.name, rt.`+"`check`"+`, rt.pull, rt.push, rt.params,
 			ru.id, ru.name, ru.run,
 			st.id, st.name, st.source, st.get, st.params, st.config
@@ -227,9 +254,9 @@ func scanPipelinesWithTeam(rows *sql.Rows) ([]*pipeline.WithTeam, error) {
 
 		err := rows.Scan(
 			&tt.ID, &tt.Name, &tt.Canonical,
-			&pp.ID, &pp.Name, &pp.Raw, &pp.Public,
-			&j.ID, &j.Name, &j.Plan, &j.OnSuccess, &j.OnFailure, &j.Ensure,
-			&r.ID, &r.Name, &r.Type, &r.Canonical, &r.Params, &r.CheckInterval, &r.Logs, &r.LastCheck, &r.NextCheck,
+			&pp.ID, &pp.Name, &pp.Raw, &pp.Public, &pp.Paused,
+			&j.ID, &j.Name, &j.Plan, &j.OnSuccess, &j.OnFailure, &j.Ensure, &j.Paused, &j.Tags,
+			&r.ID, &r.Name, &r.Type, &r.Canonical, &r.Params, &r.CheckInterval, &r.Logs, &r.LastCheck, &r.NextCheck, &r.PinnedVersionID, &r.Tags,
 			&rt.ID, &rt.Name, &rt.Check, &rt.Pull, &rt.Push, &rt.Params,
 			&ru.ID, &ru.Name, &ru.Run,
 			&st.ID, &st.Name, &st.Source, &st.Get, &st.Params, &st.Config,
@@ -325,9 +352,9 @@ func (r *PipelineRepository) Delete(ctx context.Context, tc, pn string) error {
 
 const pipelineQuery = `
 	SELECT
-		p.id, p.name, p.raw, p.public,
-		j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure,
-		r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check,
+		p.id, p.name, p.raw, p.public, p.paused,
+		j.id, j.name, j.plan, j.on_success, j.on_failure, j.ensure, j.paused, j.tags,
+		r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.pinned_version_id, r.tags,
 		rt.id, rt.name, rt.` + "`check`" + `, rt.pull, rt.push, rt.params,
 		ru.id, ru.name, ru.run,
 		st.id, st.name, st.source, st.get, st.params, st.config
@@ -363,9 +390,9 @@ func scanPipelines(rows *sql.Rows) ([]*pipeline.Pipeline, error) {
 		)
 
 		err := rows.Scan(
-			&pp.ID, &pp.Name, &pp.Raw, &pp.Public,
-			&j.ID, &j.Name, &j.Plan, &j.OnSuccess, &j.OnFailure, &j.Ensure,
-			&r.ID, &r.Name, &r.Type, &r.Canonical, &r.Params, &r.CheckInterval, &r.Logs, &r.LastCheck, &r.NextCheck,
+			&pp.ID, &pp.Name, &pp.Raw, &pp.Public, &pp.Paused,
+			&j.ID, &j.Name, &j.Plan, &j.OnSuccess, &j.OnFailure, &j.Ensure, &j.Paused, &j.Tags,
+			&r.ID, &r.Name, &r.Type, &r.Canonical, &r.Params, &r.CheckInterval, &r.Logs, &r.LastCheck, &r.NextCheck, &r.PinnedVersionID, &r.Tags,
 			&rt.ID, &rt.Name, &rt.Check, &rt.Pull, &rt.Push, &rt.Params,
 			&ru.ID, &ru.Name, &ru.Run,
 			&st.ID, &st.Name, &st.Source, &st.Get, &st.Params, &st.Config,
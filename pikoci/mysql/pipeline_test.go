@@ -7,8 +7,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/job"
 	"github.com/xescugc/pikoci/pikoci/mysql"
 	"github.com/xescugc/pikoci/pikoci/mysql/migrate"
+	"github.com/xescugc/pikoci/pikoci/resource"
 )
 
 func setupTestDB(t *testing.T) *sql.DB {
@@ -170,3 +172,102 @@ func TestDeletePipeline_CascadesRunners(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, count, "runners should be cascade-deleted when pipeline is deleted")
 }
+
+func TestSetPaused(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name, raw) VALUES (1, 'paused-pipe', '')`)
+	require.NoError(t, err)
+	ppID, _ := res.LastInsertId()
+
+	_, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'lint')`, ppID)
+	require.NoError(t, err)
+
+	pr := mysql.NewPipelineRepository(db)
+	jr := mysql.NewJobRepository(db)
+
+	err = pr.SetPaused(ctx, "main", "paused-pipe", true)
+	require.NoError(t, err)
+	err = jr.SetPaused(ctx, "main", "paused-pipe", "lint", true)
+	require.NoError(t, err)
+
+	pp, err := pr.Find(ctx, "main", "paused-pipe")
+	require.NoError(t, err)
+	assert.True(t, pp.Paused)
+	require.Len(t, pp.Jobs, 1)
+	assert.True(t, pp.Jobs[0].Paused)
+
+	// Updating the Job from the config keeps it paused
+	j, err := jr.Find(ctx, "main", "paused-pipe", "lint")
+	require.NoError(t, err)
+	assert.True(t, j.Paused)
+	j.Paused = false
+	err = jr.Update(ctx, "main", "paused-pipe", "lint", *j)
+	require.NoError(t, err)
+	j, err = jr.Find(ctx, "main", "paused-pipe", "lint")
+	require.NoError(t, err)
+	assert.True(t, j.Paused)
+
+	err = pr.SetPaused(ctx, "main", "paused-pipe", false)
+	require.NoError(t, err)
+	err = jr.SetPaused(ctx, "main", "paused-pipe", "lint", false)
+	require.NoError(t, err)
+
+	pps, err := pr.FilterAll(ctx)
+	require.NoError(t, err)
+	for _, p := range pps {
+		if p.Name == "paused-pipe" {
+			assert.False(t, p.Paused)
+			require.Len(t, p.Jobs, 1)
+			assert.False(t, p.Jobs[0].Paused)
+		}
+	}
+
+	err = jr.SetPaused(ctx, "main", "paused-pipe", "missing", true)
+	require.Error(t, err)
+}
+
+func TestTags(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name, raw) VALUES (1, 'tags-pipe', '')`)
+	require.NoError(t, err)
+
+	jr := mysql.NewJobRepository(db)
+	_, err = jr.Create(ctx, "main", "tags-pipe", job.Job{Name: "build", Tags: []string{"docker"}})
+	require.NoError(t, err)
+	_, err = jr.Create(ctx, "main", "tags-pipe", job.Job{Name: "lint"})
+	require.NoError(t, err)
+
+	rr := mysql.NewResourceRepository(db, mysql.Mem)
+	_, err = rr.Create(ctx, "main", "tags-pipe", resource.Resource{Name: "repo", Type: "git", Canonical: "git.repo", Tags: []string{"internal"}})
+	require.NoError(t, err)
+
+	j, err := jr.Find(ctx, "main", "tags-pipe", "build")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docker"}, j.Tags)
+
+	j.Tags = []string{"docker", "arm64"}
+	err = jr.Update(ctx, "main", "tags-pipe", "build", *j)
+	require.NoError(t, err)
+
+	r, err := rr.Find(ctx, "main", "tags-pipe", "git.repo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"internal"}, r.Tags)
+
+	pr := mysql.NewPipelineRepository(db)
+	pp, err := pr.Find(ctx, "main", "tags-pipe")
+	require.NoError(t, err)
+	require.Len(t, pp.Jobs, 2)
+	for _, j := range pp.Jobs {
+		if j.Name == "build" {
+			assert.Equal(t, []string{"docker", "arm64"}, j.Tags)
+		} else {
+			assert.Nil(t, j.Tags)
+		}
+	}
+	require.Len(t, pp.Resources, 1)
+	assert.Equal(t, []string{"internal"}, pp.Resources[0].Tags)
+}
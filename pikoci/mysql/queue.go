@@ -0,0 +1,413 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"gocloud.dev/gcerrors"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/batcher"
+	"gocloud.dev/pubsub/driver"
+)
+
+// QueueScheme is the PubSub system, and URL scheme, of the
+// queue stored on the DB on the queue_messages table
+const QueueScheme = "sql"
+
+const (
+	// DefaultQueueVisibilityTimeout is the time a received message is hidden
+	// from the other subscriptions before it's delivered again if not acked
+	DefaultQueueVisibilityTimeout = time.Minute
+
+	// DefaultQueueMaxAttempts is the number of deliveries of a
+	// message without being acked before it's dead-lettered
+	DefaultQueueMaxAttempts = 5
+
+	// queuePollInterval is the time ReceiveBatch waits
+	// before returning when there are no messages
+	queuePollInterval = 250 * time.Millisecond
+)
+
+// QueueURLOpener opens the Topics and Subscriptions of the queue stored on
+// the DB, the name of the topic is the host and path of the URL, like
+// "sql://pikoci". The Subscription URLs accept the query parameters
+// 'visibility_timeout' and 'max_attempts' to overwrite the ones of the opener.
+type QueueURLOpener struct {
+	DB     *sql.DB
+	System string
+
+	// VisibilityTimeout defaults to DefaultQueueVisibilityTimeout
+	VisibilityTimeout time.Duration
+	// MaxAttempts defaults to DefaultQueueMaxAttempts
+	MaxAttempts int
+}
+
+// OpenTopicURL opens the Topic of the URL u
+func (o *QueueURLOpener) OpenTopicURL(ctx context.Context, u *url.URL) (*pubsub.Topic, error) {
+	for param := range u.Query() {
+		return nil, fmt.Errorf("open topic %v: invalid query parameter %q", u, param)
+	}
+	return NewQueueTopic(o.DB, o.System, queueTopicName(u)), nil
+}
+
+// OpenSubscriptionURL opens a Subscription to the Topic of the URL u
+func (o *QueueURLOpener) OpenSubscriptionURL(ctx context.Context, u *url.URL) (*pubsub.Subscription, error) {
+	vt, ma := o.VisibilityTimeout, o.MaxAttempts
+	for param, values := range u.Query() {
+		v := values[0]
+		switch param {
+		case "visibility_timeout":
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("open subscription %v: invalid visibility_timeout %q: %w", u, v, err)
+			}
+			vt = d
+		case "max_attempts":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("open subscription %v: invalid max_attempts %q: %w", u, v, err)
+			}
+			ma = n
+		default:
+			return nil, fmt.Errorf("open subscription %v: invalid query parameter %q", u, param)
+		}
+	}
+	return NewQueueSubscription(o.DB, o.System, queueTopicName(u), vt, ma), nil
+}
+
+func queueTopicName(u *url.URL) string {
+	return path.Join(u.Host, u.Path)
+}
+
+// queueDB has the queries shared by the topic and the subscription
+type queueDB struct {
+	db     *sql.DB
+	system string
+	topic  string
+}
+
+// rebind rewrites the placeholders of the query s for the system
+func (q *queueDB) rebind(s string) string {
+	if IsPostgreSQL(q.system) {
+		return rewritePlaceholders(s)
+	}
+	return s
+}
+
+type queueTopic struct {
+	queueDB
+}
+
+// NewQueueTopic returns the Topic with the name stored on the db
+func NewQueueTopic(db *sql.DB, system, name string) *pubsub.Topic {
+	return pubsub.NewTopic(&queueTopic{
+		queueDB: queueDB{db: db, system: system, topic: name},
+	}, nil)
+}
+
+// SendBatch inserts the messages on the queue, the ones with the
+// queue.DelayMetadataKey are not visible until the delay passes
+func (t *queueTopic) SendBatch(ctx context.Context, ms []*driver.Message) error {
+	for _, m := range ms {
+		if m.BeforeSend != nil {
+			if err := m.BeforeSend(func(any) bool { return false }); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		visibleAt := now
+		if d, ok := m.Metadata[queue.DelayMetadataKey]; ok {
+			delay, err := time.ParseDuration(d)
+			if err != nil {
+				return fmt.Errorf("invalid %s metadata %q: %w", queue.DelayMetadataKey, d, err)
+			}
+			visibleAt = now.Add(delay)
+		}
+
+		var md sql.NullString
+		if len(m.Metadata) != 0 {
+			b, err := json.Marshal(m.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to marshal Metadata: %w", err)
+			}
+			md = toNullString(string(b))
+		}
+
+		_, err := t.db.ExecContext(ctx, t.rebind(`
+			INSERT INTO queue_messages(topic, body, metadata, attempts, visible_at, created_at)
+			VALUES (?, ?, ?, 0, ?, ?)
+		`), t.topic, string(m.Body), md, visibleAt, now)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+
+		if m.AfterSend != nil {
+			if err := m.AfterSend(func(any) bool { return false }); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (*queueTopic) IsRetryable(error) bool             { return false }
+func (*queueTopic) As(i any) bool                      { return false }
+func (*queueTopic) ErrorAs(error, any) bool            { return false }
+func (*queueTopic) ErrorCode(error) gcerrors.ErrorCode { return gcerrors.Unknown }
+func (*queueTopic) Close() error                       { return nil }
+
+type queueSubscription struct {
+	queueDB
+
+	visibilityTimeout time.Duration
+	maxAttempts       int
+}
+
+// queueAckID identifies one delivery of a message, so the acks and nacks
+// of a delivery that already timed out do not change the next one
+type queueAckID struct {
+	id       int64
+	attempts int64
+}
+
+// NewQueueSubscription returns a Subscription to the Topic with the name stored on the db.
+// The messages received are not visible to the other subscriptions for the visibilityTimeout,
+// and after maxAttempts deliveries without being acked they are dead-lettered.
+func NewQueueSubscription(db *sql.DB, system, name string, visibilityTimeout time.Duration, maxAttempts int) *pubsub.Subscription {
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = DefaultQueueVisibilityTimeout
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultQueueMaxAttempts
+	}
+	s := &queueSubscription{
+		queueDB:           queueDB{db: db, system: system, topic: name},
+		visibilityTimeout: visibilityTimeout,
+		maxAttempts:       maxAttempts,
+	}
+	// Messages are claimed one at a time so the subscription does not
+	// hold messages the other subscriptions could be processing
+	return pubsub.NewSubscription(s, &batcher.Options{MaxBatchSize: 1, MaxHandlers: 1}, nil)
+}
+
+type dbQueueMessage struct {
+	ID       sql.NullInt64
+	Body     sql.NullString
+	Metadata sql.NullString
+	Attempts sql.NullInt64
+}
+
+func (dbm *dbQueueMessage) toDriverMessage() *driver.Message {
+	m := &driver.Message{
+		LoggableID: strconv.FormatInt(dbm.ID.Int64, 10),
+		Body:       []byte(dbm.Body.String),
+		AckID:      queueAckID{id: dbm.ID.Int64, attempts: dbm.Attempts.Int64},
+		AsFunc:     func(any) bool { return false },
+	}
+	if dbm.Metadata.Valid {
+		_ = json.Unmarshal([]byte(dbm.Metadata.String), &m.Metadata)
+	}
+	return m
+}
+
+// ReceiveBatch claims up to maxMessages visible messages, waiting
+// the poll interval before returning if there are none
+func (s *queueSubscription) ReceiveBatch(ctx context.Context, maxMessages int) ([]*driver.Message, error) {
+	var (
+		dbms []*dbQueueMessage
+		err  error
+	)
+	if s.system == PostgreSQL || s.system == MySQL {
+		dbms, err = s.claimLocked(ctx, maxMessages)
+	} else {
+		dbms, err = s.claim(ctx, maxMessages)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dbms) == 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(queuePollInterval):
+		}
+		return nil, nil
+	}
+
+	ms := make([]*driver.Message, 0, len(dbms))
+	for _, dbm := range dbms {
+		ms = append(ms, dbm.toDriverMessage())
+	}
+	return ms, nil
+}
+
+const selectVisibleQueueMessages = `
+	SELECT id, body, metadata, attempts
+	FROM queue_messages
+	WHERE topic = ? AND dead_at IS NULL AND visible_at <= ?
+	ORDER BY id
+	LIMIT ?
+`
+
+// claimLocked claims the messages on a transaction skipping the
+// ones locked by the other subscriptions claiming at the same time
+func (s *queueSubscription) claimLocked(ctx context.Context, maxMessages int) ([]*dbQueueMessage, error) {
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, s.rebind(selectVisibleQueueMessages+" FOR UPDATE SKIP LOCKED"), s.topic, now, maxMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	candidates, err := scanQueueMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	dbms, err := s.claimMessages(ctx, tx, now, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return dbms, nil
+}
+
+// claim claims the messages one by one only if they were not
+// claimed by another subscription since they were selected.
+// When there are no messages it only reads from the DB.
+func (s *queueSubscription) claim(ctx context.Context, maxMessages int) ([]*dbQueueMessage, error) {
+	now := time.Now().UTC()
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(selectVisibleQueueMessages), s.topic, now, maxMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	candidates, err := scanQueueMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.claimMessages(ctx, s.db, now, candidates)
+}
+
+type queueExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// claimMessages hides the candidates for the visibility timeout and returns
+// the ones claimed. The candidates that already had all the attempts are
+// dead-lettered instead.
+func (s *queueSubscription) claimMessages(ctx context.Context, ex queueExecer, now time.Time, candidates []*dbQueueMessage) ([]*dbQueueMessage, error) {
+	var dbms []*dbQueueMessage
+	for _, dbm := range candidates {
+		if dbm.Attempts.Int64 >= int64(s.maxAttempts) {
+			_, err := ex.ExecContext(ctx, s.rebind(`
+				UPDATE queue_messages
+				SET dead_at = ?
+				WHERE id = ? AND attempts = ? AND dead_at IS NULL
+			`), now, dbm.ID.Int64, dbm.Attempts.Int64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to dead-letter message: %w", err)
+			}
+			continue
+		}
+
+		res, err := ex.ExecContext(ctx, s.rebind(`
+			UPDATE queue_messages
+			SET attempts = attempts + 1, visible_at = ?
+			WHERE id = ? AND attempts = ? AND dead_at IS NULL
+		`), now.Add(s.visibilityTimeout), dbm.ID.Int64, dbm.Attempts.Int64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute query: %w", err)
+		}
+		if err := isEntityFound(res); err != nil {
+			// Claimed by another subscription
+			continue
+		}
+		dbm.Attempts.Int64++
+		dbms = append(dbms, dbm)
+	}
+
+	return dbms, nil
+}
+
+func scanQueueMessages(rows *sql.Rows) ([]*dbQueueMessage, error) {
+	defer rows.Close()
+
+	var dbms []*dbQueueMessage
+	for rows.Next() {
+		var dbm dbQueueMessage
+		if err := rows.Scan(&dbm.ID, &dbm.Body, &dbm.Metadata, &dbm.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan: %w", err)
+		}
+		dbms = append(dbms, &dbm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return dbms, nil
+}
+
+// SendAcks removes the acked messages from the queue
+func (s *queueSubscription) SendAcks(ctx context.Context, ackIDs []driver.AckID) error {
+	for _, aid := range ackIDs {
+		id := aid.(queueAckID)
+		_, err := s.db.ExecContext(ctx, s.rebind(`
+			DELETE FROM queue_messages
+			WHERE id = ? AND attempts = ?
+		`), id.id, id.attempts)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+	}
+	return nil
+}
+
+func (*queueSubscription) CanNack() bool { return true }
+
+// SendNacks makes the nacked messages visible again
+func (s *queueSubscription) SendNacks(ctx context.Context, ackIDs []driver.AckID) error {
+	now := time.Now().UTC()
+	for _, aid := range ackIDs {
+		id := aid.(queueAckID)
+		_, err := s.db.ExecContext(ctx, s.rebind(`
+			UPDATE queue_messages
+			SET visible_at = ?
+			WHERE id = ? AND attempts = ?
+		`), now, id.id, id.attempts)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %w", err)
+		}
+	}
+	return nil
+}
+
+func (*queueSubscription) IsRetryable(error) bool  { return false }
+func (*queueSubscription) As(i any) bool           { return false }
+func (*queueSubscription) ErrorAs(error, any) bool { return false }
+func (*queueSubscription) ErrorCode(err error) gcerrors.ErrorCode {
+	if errors.Is(err, context.Canceled) {
+		return gcerrors.Canceled
+	}
+	return gcerrors.Unknown
+}
+func (*queueSubscription) Close() error { return nil }
@@ -0,0 +1,163 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"gocloud.dev/pubsub"
+)
+
+func TestQueue(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	// The in memory DB is shared by all the tests
+	_, err := db.ExecContext(ctx, `DELETE FROM queue_messages`)
+	require.NoError(t, err)
+
+	receive := func(t *testing.T, s *pubsub.Subscription, timeout time.Duration) (*pubsub.Message, error) {
+		rctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return s.Receive(rctx)
+	}
+	count := func(t *testing.T, topic, where string) int {
+		var n int
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM queue_messages WHERE topic = ? AND `+where, topic).Scan(&n)
+		require.NoError(t, err)
+		return n
+	}
+
+	t.Run("SendReceiveAck", func(t *testing.T) {
+		tp := mysql.NewQueueTopic(db, mysql.Mem, "ack")
+		defer tp.Shutdown(ctx)
+		s := mysql.NewQueueSubscription(db, mysql.Mem, "ack", time.Minute, 3)
+		defer s.Shutdown(ctx)
+
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte("1"), Metadata: map[string]string{"k": "v"}}))
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte("2")}))
+
+		m, err := receive(t, s, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "1", string(m.Body))
+		assert.Equal(t, map[string]string{"k": "v"}, m.Metadata)
+		m.Ack()
+
+		m, err = receive(t, s, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "2", string(m.Body))
+		m.Ack()
+
+		// The acks are sent on Shutdown
+		require.NoError(t, s.Shutdown(ctx))
+		assert.Equal(t, 0, count(t, "ack", "1 = 1"))
+	})
+	t.Run("OtherTopic", func(t *testing.T) {
+		tp := mysql.NewQueueTopic(db, mysql.Mem, "topic-a")
+		defer tp.Shutdown(ctx)
+		s := mysql.NewQueueSubscription(db, mysql.Mem, "topic-b", time.Minute, 3)
+		defer s.Shutdown(ctx)
+
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte("a")}))
+
+		_, err := receive(t, s, 500*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	t.Run("Delay", func(t *testing.T) {
+		tp := mysql.NewQueueTopic(db, mysql.Mem, "delay")
+		defer tp.Shutdown(ctx)
+		s := mysql.NewQueueSubscription(db, mysql.Mem, "delay", time.Minute, 3)
+		defer s.Shutdown(ctx)
+
+		sentAt := time.Now()
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte("d"), Metadata: map[string]string{queue.DelayMetadataKey: "1s"}}))
+
+		m, err := receive(t, s, 3*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "d", string(m.Body))
+		assert.GreaterOrEqual(t, time.Since(sentAt), time.Second)
+		m.Ack()
+
+		err = tp.Send(ctx, &pubsub.Message{Body: []byte("d"), Metadata: map[string]string{queue.DelayMetadataKey: "soon"}})
+		assert.Error(t, err)
+	})
+	t.Run("Nack", func(t *testing.T) {
+		tp := mysql.NewQueueTopic(db, mysql.Mem, "nack")
+		defer tp.Shutdown(ctx)
+		s := mysql.NewQueueSubscription(db, mysql.Mem, "nack", time.Minute, 3)
+		defer s.Shutdown(ctx)
+
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte("n")}))
+
+		m, err := receive(t, s, time.Second)
+		require.NoError(t, err)
+		m.Nack()
+
+		m, err = receive(t, s, 2*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "n", string(m.Body))
+		m.Ack()
+	})
+	t.Run("VisibilityTimeoutAndDeadLetter", func(t *testing.T) {
+		tp := mysql.NewQueueTopic(db, mysql.Mem, "dead")
+		defer tp.Shutdown(ctx)
+		s1 := mysql.NewQueueSubscription(db, mysql.Mem, "dead", time.Second, 2)
+		defer s1.Shutdown(ctx)
+		s2 := mysql.NewQueueSubscription(db, mysql.Mem, "dead", time.Second, 2)
+		defer s2.Shutdown(ctx)
+
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte("x")}))
+
+		// The message is not acked so it's hidden from the other
+		// subscription until the visibility timeout passes
+		m1, err := receive(t, s1, time.Second)
+		require.NoError(t, err)
+
+		_, err = receive(t, s2, 500*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		m2, err := receive(t, s2, 2*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "x", string(m2.Body))
+
+		// The ack of the delivery that timed out does nothing
+		m1.Ack()
+		require.NoError(t, s1.Shutdown(ctx))
+		assert.Equal(t, 1, count(t, "dead", "1 = 1"))
+
+		// After the second delivery is not acked it's dead-lettered
+		s3 := mysql.NewQueueSubscription(db, mysql.Mem, "dead", time.Second, 2)
+		defer s3.Shutdown(ctx)
+		_, err = receive(t, s3, 2*time.Second)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, count(t, "dead", "dead_at IS NOT NULL"))
+	})
+	t.Run("URLOpener", func(t *testing.T) {
+		mux := new(pubsub.URLMux)
+		o := &mysql.QueueURLOpener{DB: db, System: mysql.Mem}
+		mux.RegisterTopic(mysql.QueueScheme, o)
+		mux.RegisterSubscription(mysql.QueueScheme, o)
+
+		tp, err := mux.OpenTopic(ctx, "sql://pikoci.url")
+		require.NoError(t, err)
+		defer tp.Shutdown(ctx)
+		s, err := mux.OpenSubscription(ctx, "sql://pikoci.url?visibility_timeout=30s&max_attempts=2")
+		require.NoError(t, err)
+		defer s.Shutdown(ctx)
+
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte("u")}))
+		m, err := receive(t, s, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "u", string(m.Body))
+		m.Ack()
+
+		_, err = mux.OpenSubscription(ctx, "sql://pikoci.url?visibility_timeout=soon")
+		assert.Error(t, err)
+		_, err = mux.OpenTopic(ctx, "sql://pikoci.url?unknown=1")
+		assert.Error(t, err)
+	})
+}
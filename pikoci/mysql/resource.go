@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -34,16 +35,27 @@ type dbResource struct {
 	LastCheck     sql.NullTime
 	NextCheck     sql.NullTime
 	WebhookToken  sql.NullString
+	WebhookType   sql.NullString
+	WebhookSecret sql.NullString
+	Tags          sql.NullString
+
+	// WebhookSecretData is stored encoded in base64
+	WebhookSecretKeyID sql.NullString
+	WebhookSecretData  sql.NullString
+
+	PinnedVersionID sql.NullInt64
 }
 
 type dbResourceVersion struct {
-	ID      sql.NullInt64
-	Version sql.NullString
+	ID       sql.NullInt64
+	Version  sql.NullString
+	Pinned   sql.NullBool
+	Disabled sql.NullBool
 }
 
 func newDBResource(r resource.Resource) dbResource {
 	i, _ := json.Marshal(r.Params)
-	return dbResource{
+	dbr := dbResource{
 		Name:          toNullString(r.Name),
 		Type:          toNullString(r.Type),
 		Canonical:     toNullString(r.Canonical),
@@ -54,6 +66,19 @@ func newDBResource(r resource.Resource) dbResource {
 		NextCheck:     toNullTime(r.NextCheck),
 		WebhookToken:  toNullString(r.WebhookToken),
 	}
+	if r.Webhook != nil {
+		dbr.WebhookType = toNullString(r.Webhook.Type)
+		dbr.WebhookSecret = toNullString(r.Webhook.Secret)
+		if len(r.Webhook.Data) != 0 {
+			dbr.WebhookSecretKeyID = toNullString(r.Webhook.KeyID)
+			dbr.WebhookSecretData = toNullString(base64.StdEncoding.EncodeToString(r.Webhook.Data))
+		}
+	}
+	if len(r.Tags) != 0 {
+		t, _ := json.Marshal(r.Tags)
+		dbr.Tags = toNullString(string(t))
+	}
+	return dbr
 }
 
 func (dbr *dbResource) toDomainEntity() *resource.Resource {
@@ -67,9 +92,26 @@ func (dbr *dbResource) toDomainEntity() *resource.Resource {
 		LastCheck:     dbr.LastCheck.Time,
 		NextCheck:     dbr.NextCheck.Time,
 		WebhookToken:  dbr.WebhookToken.String,
+
+		PinnedVersionID: uint32(dbr.PinnedVersionID.Int64),
 	}
 
 	_ = json.Unmarshal([]byte(dbr.Params.String), &r.Params)
+	if dbr.Tags.Valid && dbr.Tags.String != "" {
+		_ = json.Unmarshal([]byte(dbr.Tags.String), &r.Tags)
+	}
+
+	if dbr.WebhookType.String != "" {
+		r.Webhook = &resource.Webhook{
+			Type:   dbr.WebhookType.String,
+			Secret: dbr.WebhookSecret.String,
+			KeyID:  dbr.WebhookSecretKeyID.String,
+		}
+		r.Webhook.Data, _ = base64.StdEncoding.DecodeString(dbr.WebhookSecretData.String)
+		if len(r.Webhook.Data) == 0 {
+			r.Webhook.Data = nil
+		}
+	}
 
 	return r
 }
@@ -83,7 +125,9 @@ func newDBResourceVersion(v resource.Version) dbResourceVersion {
 
 func (dbrv *dbResourceVersion) toDomainEntity() *resource.Version {
 	v := &resource.Version{
-		ID: uint32(dbrv.ID.Int64),
+		ID:       uint32(dbrv.ID.Int64),
+		Pinned:   dbrv.Pinned.Bool,
+		Disabled: dbrv.Disabled.Bool,
 	}
 	_ = json.Unmarshal([]byte(dbrv.Version.String), &v.Version)
 
@@ -93,8 +137,8 @@ func (dbrv *dbResourceVersion) toDomainEntity() *resource.Version {
 func (r *ResourceRepository) Create(ctx context.Context, tc, pn string, rs resource.Resource) (uint32, error) {
 	dbrs := newDBResource(rs)
 	res, err := r.querier.ExecContext(ctx, `
-		INSERT INTO resources(name, `+"`type`"+`, canonical, params, check_interval, last_check, next_check, webhook_token, pipeline_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?,
+		INSERT INTO resources(name, `+"`type`"+`, canonical, params, check_interval, last_check, next_check, webhook_token, webhook_type, webhook_secret, webhook_secret_key_id, webhook_secret_data, tags, pipeline_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
 			-- pipeline_id
 			(
 				SELECT p.id
@@ -102,7 +146,7 @@ func (r *ResourceRepository) Create(ctx context.Context, tc, pn string, rs resou
 				JOIN teams AS t
 					ON p.team_id = t.id
 				WHERE t.canonical = ? AND p.name = ?
-			))`, dbrs.Name, dbrs.Type, dbrs.Canonical, dbrs.Params, dbrs.CheckInterval, dbrs.LastCheck, dbrs.NextCheck, dbrs.WebhookToken, tc, pn)
+			))`, dbrs.Name, dbrs.Type, dbrs.Canonical, dbrs.Params, dbrs.CheckInterval, dbrs.LastCheck, dbrs.NextCheck, dbrs.WebhookToken, dbrs.WebhookType, dbrs.WebhookSecret, dbrs.WebhookSecretKeyID, dbrs.WebhookSecretData, dbrs.Tags, tc, pn)
 	if err != nil {
 		return 0, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -119,7 +163,41 @@ func (r *ResourceRepository) Update(ctx context.Context, tc, pn, rCan string, rs
 	dbrs := newDBResource(rs)
 	res, err := r.querier.ExecContext(ctx, `
 		UPDATE resources AS r
-		SET name = ?, type = ?, canonical = ?, params = ?, check_interval = ?, logs = ?, last_check = ?, next_check = ?, webhook_token = ?
+		SET name = ?, type = ?, canonical = ?, params = ?, check_interval = ?, logs = ?, last_check = ?, next_check = ?, webhook_token = ?, tags = ?
+		FROM (
+			SELECT r.id
+			FROM resources AS r
+			JOIN pipelines AS p
+				ON r.pipeline_id = p.id
+			JOIN teams AS t
+				ON p.team_id = t.id
+			WHERE t.canonical = ? AND p.name = ? AND r.canonical = ?
+		) AS rr
+		WHERE rr.id = r.id
+	`, dbrs.Name, dbrs.Type, dbrs.Canonical, dbrs.Params, dbrs.CheckInterval, dbrs.Logs, dbrs.LastCheck, dbrs.NextCheck, dbrs.WebhookToken, dbrs.Tags, tc, pn, rCan)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to update resource: %w", err)
+	}
+
+	return nil
+}
+
+// SetWebhook sets the webhook verification of the Resource, it's not
+// changed by Update so the Secret is never lost on a round trip over the API.
+// A nil wh removes the verification.
+func (r *ResourceRepository) SetWebhook(ctx context.Context, tc, pn, rCan string, wh *resource.Webhook) error {
+	var dbr dbResource
+	if wh != nil {
+		dbr = newDBResource(resource.Resource{Webhook: wh})
+	}
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE resources AS r
+		SET webhook_type = ?, webhook_secret = ?, webhook_secret_key_id = ?, webhook_secret_data = ?
 		FROM (
 			SELECT r.id
 			FROM resources AS r
@@ -130,7 +208,7 @@ func (r *ResourceRepository) Update(ctx context.Context, tc, pn, rCan string, rs
 			WHERE t.canonical = ? AND p.name = ? AND r.canonical = ?
 		) AS rr
 		WHERE rr.id = r.id
-	`, dbrs.Name, dbrs.Type, dbrs.Canonical, dbrs.Params, dbrs.CheckInterval, dbrs.Logs, dbrs.LastCheck, dbrs.NextCheck, dbrs.WebhookToken, tc, pn, rCan)
+	`, dbr.WebhookType, dbr.WebhookSecret, dbr.WebhookSecretKeyID, dbr.WebhookSecretData, tc, pn, rCan)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -145,7 +223,7 @@ func (r *ResourceRepository) Update(ctx context.Context, tc, pn, rCan string, rs
 
 func (r *ResourceRepository) Find(ctx context.Context, tc, pn, rCan string) (*resource.Resource, error) {
 	row := r.querier.QueryRowContext(ctx, `
-		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token
+		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token, r.pinned_version_id, r.tags
 		FROM resources AS r
 		JOIN pipelines AS p
 			ON r.pipeline_id = p.id
@@ -165,8 +243,8 @@ func (r *ResourceRepository) Find(ctx context.Context, tc, pn, rCan string) (*re
 func (r *ResourceRepository) FindByWebhookToken(ctx context.Context, token string) (*resource.Resource, string, string, error) {
 	var tc, pn sql.NullString
 	row := r.querier.QueryRowContext(ctx, `
-		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token,
-			t.canonical, p.name
+		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token, r.pinned_version_id, r.tags,
+			r.webhook_type, r.webhook_secret, r.webhook_secret_key_id, r.webhook_secret_data, t.canonical, p.name
 		FROM resources AS r
 		JOIN pipelines AS p
 			ON r.pipeline_id = p.id
@@ -187,6 +265,12 @@ func (r *ResourceRepository) FindByWebhookToken(ctx context.Context, token strin
 		&dbr.LastCheck,
 		&dbr.NextCheck,
 		&dbr.WebhookToken,
+		&dbr.PinnedVersionID,
+		&dbr.Tags,
+		&dbr.WebhookType,
+		&dbr.WebhookSecret,
+		&dbr.WebhookSecretKeyID,
+		&dbr.WebhookSecretData,
 		&tc,
 		&pn,
 	)
@@ -202,7 +286,7 @@ func (r *ResourceRepository) FindByWebhookToken(ctx context.Context, token strin
 
 func (r *ResourceRepository) Filter(ctx context.Context, tc, pn string) ([]*resource.Resource, error) {
 	rows, err := r.querier.QueryContext(ctx, `
-		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token
+		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token, r.pinned_version_id, r.tags
 		FROM resources AS r
 		JOIN pipelines AS p
 			ON r.pipeline_id = p.id
@@ -224,14 +308,14 @@ func (r *ResourceRepository) Filter(ctx context.Context, tc, pn string) ([]*reso
 
 func (r *ResourceRepository) FilterDueResources(ctx context.Context) ([]*resource.ResourceWithPipeline, error) {
 	q := `
-		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token,
+		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token, r.pinned_version_id, r.tags,
 			t.canonical, p.name
 		FROM resources AS r
 		JOIN pipelines AS p
 			ON r.pipeline_id = p.id
 		JOIN teams AS t
 			ON p.team_id = t.id
-		WHERE r.next_check IS NOT NULL AND r.next_check <= ?
+		WHERE r.next_check IS NOT NULL AND r.next_check <= ? AND p.paused = FALSE
 	`
 	if r.system == PostgreSQL || r.system == MySQL {
 		q += " FOR UPDATE SKIP LOCKED"
@@ -261,6 +345,8 @@ func (r *ResourceRepository) FilterDueResources(ctx context.Context) ([]*resourc
 			&dbr.LastCheck,
 			&dbr.NextCheck,
 			&dbr.WebhookToken,
+			&dbr.PinnedVersionID,
+			&dbr.Tags,
 			&tc,
 			&pn,
 		)
@@ -279,6 +365,66 @@ func (r *ResourceRepository) FilterDueResources(ctx context.Context) ([]*resourc
 	return results, nil
 }
 
+// FilterOutdatedWebhooks returns the Resources with a Webhook which
+// secret is not encrypted with the keyID, including the ones not encrypted
+func (r *ResourceRepository) FilterOutdatedWebhooks(ctx context.Context, keyID string) ([]*resource.ResourceWithPipeline, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT r.id, r.name, r.type, r.canonical, r.params, r.check_interval, r.logs, r.last_check, r.next_check, r.webhook_token, r.pinned_version_id, r.tags,
+			r.webhook_type, r.webhook_secret, r.webhook_secret_key_id, r.webhook_secret_data, t.canonical, p.name
+		FROM resources AS r
+		JOIN pipelines AS p
+			ON r.pipeline_id = p.id
+		JOIN teams AS t
+			ON p.team_id = t.id
+		WHERE r.webhook_type <> '' AND (r.webhook_secret_key_id IS NULL OR r.webhook_secret_key_id <> ?)
+		ORDER BY r.id
+	`, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter outdated webhooks: %w", err)
+	}
+
+	var results []*resource.ResourceWithPipeline
+	for rows.Next() {
+		var (
+			dbr dbResource
+			tc  sql.NullString
+			pn  sql.NullString
+		)
+		err := rows.Scan(
+			&dbr.ID,
+			&dbr.Name,
+			&dbr.Type,
+			&dbr.Canonical,
+			&dbr.Params,
+			&dbr.CheckInterval,
+			&dbr.Logs,
+			&dbr.LastCheck,
+			&dbr.NextCheck,
+			&dbr.WebhookToken,
+			&dbr.PinnedVersionID,
+			&dbr.Tags,
+			&dbr.WebhookType,
+			&dbr.WebhookSecret,
+			&dbr.WebhookSecretKeyID,
+			&dbr.WebhookSecretData,
+			&tc,
+			&pn,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outdated webhook: %w", err)
+		}
+		results = append(results, &resource.ResourceWithPipeline{
+			Resource:      *dbr.toDomainEntity(),
+			TeamCanonical: tc.String,
+			PipelineName:  pn.String,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outdated webhooks: %w", err)
+	}
+	return results, nil
+}
+
 func (r *ResourceRepository) CreateVersion(ctx context.Context, tc, pn, rCan string, rv resource.Version) (uint32, error) {
 	dbrv := newDBResourceVersion(rv)
 	res, err := r.querier.ExecContext(ctx, `
@@ -308,7 +454,7 @@ func (r *ResourceRepository) CreateVersion(ctx context.Context, tc, pn, rCan str
 
 func (r *ResourceRepository) FilterVersions(ctx context.Context, tc, pn, rCan string) ([]*resource.Version, error) {
 	rows, err := r.querier.QueryContext(ctx, `
-		SELECT rv.id, rv.version
+		SELECT rv.id, rv.version, r.pinned_version_id = rv.id, rv.disabled
 		FROM resource_versions AS rv
 		JOIN resources AS r
 			ON rv.resource_id = r.id
@@ -331,6 +477,91 @@ func (r *ResourceRepository) FilterVersions(ctx context.Context, tc, pn, rCan st
 	return rvs, nil
 }
 
+func (r *ResourceRepository) PinVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE resources AS r
+		SET pinned_version_id = ?
+		FROM (
+			SELECT r.id
+			FROM resources AS r
+			JOIN pipelines AS p
+				ON r.pipeline_id = p.id
+			JOIN teams AS t
+				ON p.team_id = t.id
+			JOIN resource_versions AS rv
+				ON rv.resource_id = r.id
+			WHERE t.canonical = ? AND p.name = ? AND r.canonical = ? AND rv.id = ? AND rv.disabled = FALSE
+		) AS rr
+		WHERE rr.id = r.id
+	`, vID, tc, pn, rCan, vID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to pin resource version: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ResourceRepository) UnpinVersion(ctx context.Context, tc, pn, rCan string) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE resources AS r
+		SET pinned_version_id = NULL
+		FROM (
+			SELECT r.id
+			FROM resources AS r
+			JOIN pipelines AS p
+				ON r.pipeline_id = p.id
+			JOIN teams AS t
+				ON p.team_id = t.id
+			WHERE t.canonical = ? AND p.name = ? AND r.canonical = ?
+		) AS rr
+		WHERE rr.id = r.id
+	`, tc, pn, rCan)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to unpin resource version: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ResourceRepository) SetVersionDisabled(ctx context.Context, tc, pn, rCan string, vID uint32, disabled bool) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE resource_versions AS rv
+		SET disabled = ?
+		FROM (
+			SELECT rv.id
+			FROM resource_versions AS rv
+			JOIN resources AS r
+				ON rv.resource_id = r.id
+			JOIN pipelines AS p
+				ON r.pipeline_id = p.id
+			JOIN teams AS t
+				ON p.team_id = t.id
+			WHERE t.canonical = ? AND p.name = ? AND r.canonical = ? AND rv.id = ?
+		) AS vv
+		WHERE vv.id = rv.id
+	`, disabled, tc, pn, rCan, vID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to set disabled on resource version: %w", err)
+	}
+
+	return nil
+}
+
 func (r *ResourceRepository) Delete(ctx context.Context, tc, pn, rCan string) error {
 	res, err := r.querier.ExecContext(ctx, `
 		DELETE
@@ -371,6 +602,8 @@ func scanResource(s sqlr.Scanner) (*resource.Resource, error) {
 		&r.LastCheck,
 		&r.NextCheck,
 		&r.WebhookToken,
+		&r.PinnedVersionID,
+		&r.Tags,
 	)
 
 	if err != nil {
@@ -405,6 +638,8 @@ func scanResourceVersion(s sqlr.Scanner) (*resource.Version, error) {
 	err := s.Scan(
 		&rv.ID,
 		&rv.Version,
+		&rv.Pinned,
+		&rv.Disabled,
 	)
 
 	if err != nil {
@@ -0,0 +1,167 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+	"github.com/xescugc/pikoci/pikoci/resource"
+)
+
+func TestResourceVersionPinning(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name, raw) VALUES (1, 'pin-pipe', '')`)
+	require.NoError(t, err)
+
+	rr := mysql.NewResourceRepository(db, mysql.Mem)
+	_, err = rr.Create(ctx, "main", "pin-pipe", resource.Resource{Name: "repo", Type: "git", Canonical: "git.repo"})
+	require.NoError(t, err)
+
+	v1, err := rr.CreateVersion(ctx, "main", "pin-pipe", "git.repo", resource.Version{Version: map[string]interface{}{"ref": "a"}})
+	require.NoError(t, err)
+	v2, err := rr.CreateVersion(ctx, "main", "pin-pipe", "git.repo", resource.Version{Version: map[string]interface{}{"ref": "b"}})
+	require.NoError(t, err)
+
+	err = rr.PinVersion(ctx, "main", "pin-pipe", "git.repo", v1)
+	require.NoError(t, err)
+	err = rr.SetVersionDisabled(ctx, "main", "pin-pipe", "git.repo", v2, true)
+	require.NoError(t, err)
+
+	r, err := rr.Find(ctx, "main", "pin-pipe", "git.repo")
+	require.NoError(t, err)
+	assert.Equal(t, v1, r.PinnedVersionID)
+
+	vers, err := rr.FilterVersions(ctx, "main", "pin-pipe", "git.repo")
+	require.NoError(t, err)
+	require.Len(t, vers, 2)
+	assert.True(t, vers[0].Pinned)
+	assert.False(t, vers[0].Disabled)
+	assert.False(t, vers[1].Pinned)
+	assert.True(t, vers[1].Disabled)
+
+	pr := mysql.NewPipelineRepository(db)
+	pp, err := pr.Find(ctx, "main", "pin-pipe")
+	require.NoError(t, err)
+	require.Len(t, pp.Resources, 1)
+	assert.Equal(t, v1, pp.Resources[0].PinnedVersionID)
+
+	// Updating the Resource from the config keeps it pinned
+	r.PinnedVersionID = 0
+	err = rr.Update(ctx, "main", "pin-pipe", "git.repo", *r)
+	require.NoError(t, err)
+	r, err = rr.Find(ctx, "main", "pin-pipe", "git.repo")
+	require.NoError(t, err)
+	assert.Equal(t, v1, r.PinnedVersionID)
+
+	// Disabled versions can't be pinned
+	err = rr.PinVersion(ctx, "main", "pin-pipe", "git.repo", v2)
+	require.Error(t, err)
+
+	err = rr.UnpinVersion(ctx, "main", "pin-pipe", "git.repo")
+	require.NoError(t, err)
+	r, err = rr.Find(ctx, "main", "pin-pipe", "git.repo")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), r.PinnedVersionID)
+
+	vers, err = rr.FilterVersions(ctx, "main", "pin-pipe", "git.repo")
+	require.NoError(t, err)
+	assert.False(t, vers[0].Pinned)
+}
+
+func TestResourceWebhook(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name, raw) VALUES (1, 'wh-pipe', '')`)
+	require.NoError(t, err)
+
+	rr := mysql.NewResourceRepository(db, mysql.Mem)
+	wh := &resource.Webhook{Type: resource.WebhookTypeGitHub, Secret: "my-secret"}
+	_, err = rr.Create(ctx, "main", "wh-pipe", resource.Resource{Name: "repo", Type: "git", Canonical: "git.repo", WebhookToken: "token", Webhook: wh})
+	require.NoError(t, err)
+
+	r, tc, pn, err := rr.FindByWebhookToken(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "main", tc)
+	assert.Equal(t, "wh-pipe", pn)
+	assert.Equal(t, wh, r.Webhook)
+
+	// Updating the Resource without the Webhook keeps it
+	r.Webhook = nil
+	err = rr.Update(ctx, "main", "wh-pipe", "git.repo", *r)
+	require.NoError(t, err)
+	r, _, _, err = rr.FindByWebhookToken(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, wh, r.Webhook)
+
+	// Not encrypted secrets are outdated for any key
+	rwps, err := rr.FilterOutdatedWebhooks(ctx, "k1")
+	require.NoError(t, err)
+	require.Len(t, rwps, 1)
+	assert.Equal(t, "main", rwps[0].TeamCanonical)
+	assert.Equal(t, "wh-pipe", rwps[0].PipelineName)
+	assert.Equal(t, wh, rwps[0].Webhook)
+
+	ewh := &resource.Webhook{Type: resource.WebhookTypeGitHub, KeyID: "k1", Data: []byte("encrypted")}
+	err = rr.SetWebhook(ctx, "main", "wh-pipe", "git.repo", ewh)
+	require.NoError(t, err)
+	r, _, _, err = rr.FindByWebhookToken(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, ewh, r.Webhook)
+
+	rwps, err = rr.FilterOutdatedWebhooks(ctx, "k1")
+	require.NoError(t, err)
+	assert.Empty(t, rwps)
+	rwps, err = rr.FilterOutdatedWebhooks(ctx, "k2")
+	require.NoError(t, err)
+	require.Len(t, rwps, 1)
+	assert.Equal(t, ewh, rwps[0].Webhook)
+
+	err = rr.SetWebhook(ctx, "main", "wh-pipe", "git.repo", nil)
+	require.NoError(t, err)
+	r, _, _, err = rr.FindByWebhookToken(ctx, "token")
+	require.NoError(t, err)
+	assert.Nil(t, r.Webhook)
+
+	rwps, err = rr.FilterOutdatedWebhooks(ctx, "k2")
+	require.NoError(t, err)
+	assert.Empty(t, rwps)
+}
+
+func TestFilterDueResources(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name, raw) VALUES (1, 'due-pipe', '')`)
+	require.NoError(t, err)
+
+	rr := mysql.NewResourceRepository(db, mysql.Mem)
+	_, err = rr.Create(ctx, "main", "due-pipe", resource.Resource{Name: "due", Type: "git", Canonical: "git.due", NextCheck: time.Now().Add(-time.Minute)})
+	require.NoError(t, err)
+	_, err = rr.Create(ctx, "main", "due-pipe", resource.Resource{Name: "later", Type: "git", Canonical: "git.later", NextCheck: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	v, err := rr.CreateVersion(ctx, "main", "due-pipe", "git.due", resource.Version{Version: map[string]interface{}{"ref": "a"}})
+	require.NoError(t, err)
+	err = rr.PinVersion(ctx, "main", "due-pipe", "git.due", v)
+	require.NoError(t, err)
+
+	rwps, err := rr.FilterDueResources(ctx)
+	require.NoError(t, err)
+
+	var due []*resource.ResourceWithPipeline
+	for _, rwp := range rwps {
+		if rwp.PipelineName == "due-pipe" {
+			due = append(due, rwp)
+		}
+	}
+	require.Len(t, due, 1)
+	assert.Equal(t, "git.due", due[0].Canonical)
+	assert.Equal(t, "main", due[0].TeamCanonical)
+	assert.Equal(t, v, due[0].PinnedVersionID)
+}
@@ -3,7 +3,7 @@ package mysql
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/cycloidio/sqlr"
@@ -22,48 +22,53 @@ func NewSecretRepository(db sqlr.Querier) *SecretRepository {
 
 type dbSecret struct {
 	ID        sql.NullInt64
+	TeamID    sql.NullInt64
 	Name      sql.NullString
-	Type      sql.NullString
-	Canonical sql.NullString
-	Params    sql.NullString
+	KeyID     sql.NullString
+	Data      sql.NullString
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
 }
 
+// newDBSecret stores the Data encoded in base64
+// so it can be on a TEXT column on all the DBs
 func newDBSecret(s secret.Secret) dbSecret {
-	p, _ := json.Marshal(s.Params)
 	return dbSecret{
 		Name:      toNullString(s.Name),
-		Type:      toNullString(s.Type),
-		Canonical: toNullString(s.Canonical),
-		Params:    toNullString(string(p)),
+		KeyID:     toNullString(s.KeyID),
+		Data:      toNullString(base64.StdEncoding.EncodeToString(s.Data)),
+		CreatedAt: toNullTime(s.CreatedAt),
+		UpdatedAt: toNullTime(s.UpdatedAt),
 	}
 }
 
 func (dbs *dbSecret) toDomainEntity() *secret.Secret {
 	s := &secret.Secret{
 		ID:        uint32(dbs.ID.Int64),
+		TeamID:    uint32(dbs.TeamID.Int64),
 		Name:      dbs.Name.String,
-		Type:      dbs.Type.String,
-		Canonical: dbs.Canonical.String,
+		KeyID:     dbs.KeyID.String,
+		CreatedAt: dbs.CreatedAt.Time,
+		UpdatedAt: dbs.UpdatedAt.Time,
 	}
 
-	_ = json.Unmarshal([]byte(dbs.Params.String), &s.Params)
+	s.Data, _ = base64.StdEncoding.DecodeString(dbs.Data.String)
 
 	return s
 }
 
-func (r *SecretRepository) Create(ctx context.Context, tc, pn string, s secret.Secret) (uint32, error) {
+func (r *SecretRepository) Create(ctx context.Context, tc string, s secret.Secret) (uint32, error) {
 	dbs := newDBSecret(s)
 	res, err := r.querier.ExecContext(ctx, `
-		INSERT INTO secrets(name, `+"`type`"+`, canonical, params, pipeline_id)
-		VALUES (?, ?, ?, ?,
-			-- pipeline_id
+		INSERT INTO secrets(name, key_id, data, created_at, updated_at, team_id)
+		VALUES (?, ?, ?, ?, ?,
+			-- team_id
 			(
-				SELECT p.id
-				FROM pipelines AS p
-				JOIN teams AS t
-					ON p.team_id = t.id
-				WHERE t.canonical = ? AND p.name = ?
-			))`, dbs.Name, dbs.Type, dbs.Canonical, dbs.Params, tc, pn)
+				SELECT t.id
+				FROM teams AS t
+				WHERE t.canonical = ?
+			))
+	`, dbs.Name, dbs.KeyID, dbs.Data, dbs.CreatedAt, dbs.UpdatedAt, tc)
 	if err != nil {
 		return 0, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -76,62 +81,60 @@ func (r *SecretRepository) Create(ctx context.Context, tc, pn string, s secret.S
 	return id, nil
 }
 
-func (r *SecretRepository) Update(ctx context.Context, tc, pn, sCan string, s secret.Secret) error {
+func (r *SecretRepository) Update(ctx context.Context, tc, name string, s secret.Secret) error {
 	dbs := newDBSecret(s)
 	res, err := r.querier.ExecContext(ctx, `
 		UPDATE secrets
-		SET name = ?, `+"`type`"+` = ?, canonical = ?, params = ?
+		SET key_id = ?, data = ?, updated_at = ?
 		WHERE id = (
 			SELECT s.id
 			FROM (SELECT * FROM secrets) AS s
-			JOIN pipelines AS p
-				ON s.pipeline_id = p.id
 			JOIN teams AS t
-				ON p.team_id = t.id
-			WHERE t.canonical = ? AND p.name = ? AND s.canonical = ?
+				ON s.team_id = t.id
+			WHERE t.canonical = ? AND s.name = ?
 		)
-	`, dbs.Name, dbs.Type, dbs.Canonical, dbs.Params, tc, pn, sCan)
+	`, dbs.KeyID, dbs.Data, dbs.UpdatedAt, tc, name)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
 
 	err = isEntityFound(res)
 	if err != nil {
-		return fmt.Errorf("failed to update secret: %w", err)
+		return secret.ErrNotFound
 	}
 
 	return nil
 }
 
-func (r *SecretRepository) Find(ctx context.Context, tc, pn, sCan string) (*secret.Secret, error) {
+func (r *SecretRepository) Find(ctx context.Context, tc, name string) (*secret.Secret, error) {
 	row := r.querier.QueryRowContext(ctx, `
-		SELECT s.id, s.name, s.type, s.canonical, s.params
+		SELECT s.id, s.team_id, s.name, s.key_id, s.data, s.created_at, s.updated_at
 		FROM secrets AS s
-		JOIN pipelines AS p
-			ON s.pipeline_id = p.id
 		JOIN teams AS t
-			ON p.team_id = t.id
-		WHERE t.canonical = ? AND p.name = ? AND s.canonical = ?
-	`, tc, pn, sCan)
+			ON s.team_id = t.id
+		WHERE t.canonical = ? AND s.name = ?
+	`, tc, name)
 
 	s, err := scanSecret(row)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, secret.ErrNotFound
+		}
 		return nil, fmt.Errorf("failed to scan Secret: %w", err)
 	}
 
 	return s, nil
 }
 
-func (r *SecretRepository) Filter(ctx context.Context, tc, pn string) ([]*secret.Secret, error) {
+func (r *SecretRepository) Filter(ctx context.Context, tc string) ([]*secret.Secret, error) {
 	rows, err := r.querier.QueryContext(ctx, `
-		SELECT s.id, s.name, s.type, s.canonical, s.params
+		SELECT s.id, s.team_id, s.name, s.key_id, s.data, s.created_at, s.updated_at
 		FROM secrets AS s
-		JOIN pipelines AS p
-			ON s.pipeline_id = p.id
 		JOIN teams AS t
-			ON p.team_id = t.id
-		WHERE t.canonical = ? AND p.name = ?
-	`, tc, pn)
+			ON s.team_id = t.id
+		WHERE t.canonical = ?
+		ORDER BY s.name
+	`, tc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to filter Secrets: %w", err)
 	}
@@ -144,46 +147,84 @@ func (r *SecretRepository) Filter(ctx context.Context, tc, pn string) ([]*secret
 	return secrets, nil
 }
 
-func (r *SecretRepository) Delete(ctx context.Context, tc, pn, sCan string) error {
+func (r *SecretRepository) Delete(ctx context.Context, tc, name string) error {
 	res, err := r.querier.ExecContext(ctx, `
 		DELETE
 		FROM secrets
 		WHERE id IN (
 			SELECT s.id
 			FROM secrets AS s
-			JOIN pipelines AS p
-				ON s.pipeline_id = p.id
 			JOIN teams AS t
-				ON p.team_id = t.id
-			WHERE t.canonical = ? AND p.name = ? AND s.canonical = ?
+				ON s.team_id = t.id
+			WHERE t.canonical = ? AND s.name = ?
 		)
-	`, tc, pn, sCan)
+	`, tc, name)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return secret.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *SecretRepository) FilterOutdated(ctx context.Context, keyID string) ([]*secret.Secret, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT s.id, s.team_id, s.name, s.key_id, s.data, s.created_at, s.updated_at
+		FROM secrets AS s
+		WHERE s.key_id <> ?
+		ORDER BY s.id
+	`, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter Secrets: %w", err)
+	}
+
+	secrets, err := scanSecrets(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter secrets: %w", err)
+	}
+
+	return secrets, nil
+}
+
+func (r *SecretRepository) UpdateData(ctx context.Context, id uint32, keyID string, data []byte) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE secrets
+		SET key_id = ?, data = ?
+		WHERE id = ?
+	`, keyID, base64.StdEncoding.EncodeToString(data), id)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
 
 	err = isEntityFound(res)
 	if err != nil {
-		return fmt.Errorf("failed to delete the Secret: %w", err)
+		return secret.ErrNotFound
 	}
 
 	return nil
 }
 
+// scanSecret returns the sql.ErrNoRows as is so
+// Find can return the secret.ErrNotFound
 func scanSecret(s sqlr.Scanner) (*secret.Secret, error) {
 	var sec dbSecret
 
 	err := s.Scan(
 		&sec.ID,
+		&sec.TeamID,
 		&sec.Name,
-		&sec.Type,
-		&sec.Canonical,
-		&sec.Params,
+		&sec.KeyID,
+		&sec.Data,
+		&sec.CreatedAt,
+		&sec.UpdatedAt,
 	)
-
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("not found")
+			return nil, err
 		}
 		return nil, fmt.Errorf("failed to scan: %w", err)
 	}
@@ -192,8 +233,9 @@ func scanSecret(s sqlr.Scanner) (*secret.Secret, error) {
 }
 
 func scanSecrets(rows *sql.Rows) ([]*secret.Secret, error) {
-	var ss []*secret.Secret
+	defer rows.Close()
 
+	var ss []*secret.Secret
 	for rows.Next() {
 		s, err := scanSecret(rows)
 		if err != nil {
@@ -0,0 +1,85 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+	"github.com/xescugc/pikoci/pikoci/secret"
+)
+
+func TestSecrets(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	sr := mysql.NewSecretRepository(db)
+
+	now := time.Now().Round(time.Second).UTC()
+	token := secret.Secret{Name: "github_token", KeyID: "k1", Data: []byte{0, 1, 2, 255}, CreatedAt: now, UpdatedAt: now}
+	id, err := sr.Create(ctx, "main", token)
+	require.NoError(t, err)
+
+	_, err = sr.Create(ctx, "main", secret.Secret{Name: "github_token", KeyID: "k1", Data: []byte("x")})
+	assert.Error(t, err)
+
+	_, err = sr.Create(ctx, "main", secret.Secret{Name: "db_password", KeyID: "k2", Data: []byte("db"), CreatedAt: now, UpdatedAt: now})
+	require.NoError(t, err)
+
+	s, err := sr.Find(ctx, "main", "github_token")
+	require.NoError(t, err)
+	assert.Equal(t, id, s.ID)
+	assert.Equal(t, uint32(1), s.TeamID)
+	assert.Equal(t, "k1", s.KeyID)
+	assert.Equal(t, token.Data, s.Data)
+	assert.Empty(t, s.Value)
+	assert.True(t, now.Equal(s.CreatedAt))
+
+	_, err = sr.Find(ctx, "main", "other")
+	assert.ErrorIs(t, err, secret.ErrNotFound)
+
+	later := now.Add(time.Hour)
+	err = sr.Update(ctx, "main", "github_token", secret.Secret{KeyID: "k2", Data: []byte("new"), UpdatedAt: later})
+	require.NoError(t, err)
+
+	err = sr.Update(ctx, "main", "other", secret.Secret{KeyID: "k2", Data: []byte("new"), UpdatedAt: later})
+	assert.ErrorIs(t, err, secret.ErrNotFound)
+
+	ss, err := sr.Filter(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, ss, 2)
+	assert.Equal(t, "db_password", ss[0].Name)
+	assert.Equal(t, "github_token", ss[1].Name)
+	assert.Equal(t, []byte("new"), ss[1].Data)
+	assert.True(t, now.Equal(ss[1].CreatedAt))
+	assert.True(t, later.Equal(ss[1].UpdatedAt))
+
+	ss, err = sr.FilterOutdated(ctx, "k2")
+	require.NoError(t, err)
+	assert.Len(t, ss, 0)
+
+	ss, err = sr.FilterOutdated(ctx, "k3")
+	require.NoError(t, err)
+	require.Len(t, ss, 2)
+	assert.Equal(t, uint32(1), ss[0].TeamID)
+
+	err = sr.UpdateData(ctx, ss[0].ID, "k3", []byte("rotated"))
+	require.NoError(t, err)
+
+	s, err = sr.Find(ctx, "main", ss[0].Name)
+	require.NoError(t, err)
+	assert.Equal(t, "k3", s.KeyID)
+	assert.Equal(t, []byte("rotated"), s.Data)
+
+	err = sr.Delete(ctx, "main", "db_password")
+	require.NoError(t, err)
+
+	err = sr.Delete(ctx, "main", "db_password")
+	assert.ErrorIs(t, err, secret.ErrNotFound)
+
+	ss, err = sr.Filter(ctx, "main")
+	require.NoError(t, err)
+	assert.Len(t, ss, 1)
+}
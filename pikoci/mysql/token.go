@@ -0,0 +1,276 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cycloidio/sqlr"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+)
+
+type TokenRepository struct {
+	querier sqlr.Querier
+}
+
+func NewTokenRepository(db sqlr.Querier) *TokenRepository {
+	return &TokenRepository{
+		querier: db,
+	}
+}
+
+type dbToken struct {
+	ID            sql.NullInt64
+	Name          sql.NullString
+	Hint          sql.NullString
+	Hash          sql.NullString
+	Scopes        sql.NullString
+	Username      sql.NullString
+	TeamCanonical sql.NullString
+	ExpiresAt     sql.NullTime
+	LastUsedAt    sql.NullTime
+	CreatedAt     sql.NullTime
+}
+
+func newDBToken(t apitoken.Token) dbToken {
+	s, _ := json.Marshal(t.Scopes)
+	dbt := dbToken{
+		Name:          toNullString(t.Name),
+		Hint:          toNullString(t.Hint),
+		Hash:          toNullString(t.Hash),
+		Scopes:        toNullString(string(s)),
+		Username:      toNullString(t.Username),
+		TeamCanonical: toNullString(t.TeamCanonical),
+		CreatedAt:     toNullTime(t.CreatedAt),
+	}
+	if t.ExpiresAt != nil {
+		dbt.ExpiresAt = toNullTime(*t.ExpiresAt)
+	}
+	if t.LastUsedAt != nil {
+		dbt.LastUsedAt = toNullTime(*t.LastUsedAt)
+	}
+	return dbt
+}
+
+func (dbt *dbToken) toDomainEntity() *apitoken.Token {
+	t := &apitoken.Token{
+		ID:            uint32(dbt.ID.Int64),
+		Name:          dbt.Name.String,
+		Hint:          dbt.Hint.String,
+		Hash:          dbt.Hash.String,
+		Username:      dbt.Username.String,
+		TeamCanonical: dbt.TeamCanonical.String,
+		CreatedAt:     dbt.CreatedAt.Time,
+	}
+	_ = json.Unmarshal([]byte(dbt.Scopes.String), &t.Scopes)
+	if dbt.ExpiresAt.Valid {
+		t.ExpiresAt = &dbt.ExpiresAt.Time
+	}
+	if dbt.LastUsedAt.Valid {
+		t.LastUsedAt = &dbt.LastUsedAt.Time
+	}
+	return t
+}
+
+// selectTokens joins the owner of the Tokens, the
+// users for Username and the teams for TeamCanonical
+const selectTokens = `
+	SELECT tk.id, tk.name, tk.hint, tk.hash, tk.scopes, u.username, t.canonical, tk.expires_at, tk.last_used_at, tk.created_at
+	FROM tokens AS tk
+	LEFT JOIN users AS u
+		ON tk.user_id = u.id
+	LEFT JOIN teams AS t
+		ON tk.team_id = t.id
+`
+
+func (r *TokenRepository) Create(ctx context.Context, t apitoken.Token) (uint32, error) {
+	dbt := newDBToken(t)
+	res, err := r.querier.ExecContext(ctx, `
+		INSERT INTO tokens(name, hint, hash, scopes, expires_at, created_at, user_id, team_id)
+		VALUES (?, ?, ?, ?, ?, ?,
+			-- user_id
+			(
+				SELECT u.id
+				FROM users AS u
+				WHERE u.username = ?
+			),
+			-- team_id
+			(
+				SELECT t.id
+				FROM teams AS t
+				WHERE t.canonical = ?
+			))
+	`, dbt.Name, dbt.Hint, dbt.Hash, dbt.Scopes, dbt.ExpiresAt, dbt.CreatedAt, dbt.Username, dbt.TeamCanonical)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	id, err := lastInsertedID(res)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last inserted id: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *TokenRepository) FindByHash(ctx context.Context, hash string) (*apitoken.Token, error) {
+	row := r.querier.QueryRowContext(ctx, selectTokens+`
+		WHERE tk.hash = ?
+	`, hash)
+
+	t, err := scanToken(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apitoken.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan Token: %w", err)
+	}
+
+	return t, nil
+}
+
+func (r *TokenRepository) FilterByUser(ctx context.Context, un string) ([]*apitoken.Token, error) {
+	rows, err := r.querier.QueryContext(ctx, selectTokens+`
+		WHERE u.username = ?
+		ORDER BY tk.name
+	`, un)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter Tokens: %w", err)
+	}
+
+	tokens, err := scanTokens(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *TokenRepository) FilterByTeam(ctx context.Context, tc string) ([]*apitoken.Token, error) {
+	rows, err := r.querier.QueryContext(ctx, selectTokens+`
+		WHERE t.canonical = ?
+		ORDER BY tk.name
+	`, tc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter Tokens: %w", err)
+	}
+
+	tokens, err := scanTokens(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *TokenRepository) DeleteByUser(ctx context.Context, un string, id uint32) error {
+	res, err := r.querier.ExecContext(ctx, `
+		DELETE
+		FROM tokens
+		WHERE id IN (
+			SELECT tk.id
+			FROM tokens AS tk
+			JOIN users AS u
+				ON tk.user_id = u.id
+			WHERE u.username = ? AND tk.id = ?
+		)
+	`, un, id)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return apitoken.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) DeleteByTeam(ctx context.Context, tc string, id uint32) error {
+	res, err := r.querier.ExecContext(ctx, `
+		DELETE
+		FROM tokens
+		WHERE id IN (
+			SELECT tk.id
+			FROM tokens AS tk
+			JOIN teams AS t
+				ON tk.team_id = t.id
+			WHERE t.canonical = ? AND tk.id = ?
+		)
+	`, tc, id)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return apitoken.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) UpdateLastUsedAt(ctx context.Context, id uint32, at time.Time) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE tokens
+		SET last_used_at = ?
+		WHERE id = ?
+	`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return apitoken.ErrNotFound
+	}
+
+	return nil
+}
+
+// scanToken returns the sql.ErrNoRows as is so
+// FindByHash can return the apitoken.ErrNotFound
+func scanToken(s sqlr.Scanner) (*apitoken.Token, error) {
+	var t dbToken
+
+	err := s.Scan(
+		&t.ID,
+		&t.Name,
+		&t.Hint,
+		&t.Hash,
+		&t.Scopes,
+		&t.Username,
+		&t.TeamCanonical,
+		&t.ExpiresAt,
+		&t.LastUsedAt,
+		&t.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan: %w", err)
+	}
+
+	return t.toDomainEntity(), nil
+}
+
+func scanTokens(rows *sql.Rows) ([]*apitoken.Token, error) {
+	defer rows.Close()
+
+	var ts []*apitoken.Token
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		ts = append(ts, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan token: %w", err)
+	}
+	return ts, nil
+}
@@ -0,0 +1,91 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+)
+
+func TestTokens(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	tr := mysql.NewTokenRepository(db)
+
+	now := time.Now().Round(time.Second).UTC()
+	exp := now.Add(time.Hour)
+	ut := apitoken.Token{
+		Name: "ci", Hint: "pikoci_abcd", Hash: "h1",
+		Scopes:   []apitoken.Scope{apitoken.Read, apitoken.Trigger},
+		Username: "admin", ExpiresAt: &exp, CreatedAt: now,
+	}
+	uid, err := tr.Create(ctx, ut)
+	require.NoError(t, err)
+
+	_, err = tr.Create(ctx, apitoken.Token{Name: "ci", Hash: "h2", Username: "admin"})
+	assert.Error(t, err, "duplicated name of the user")
+
+	tid, err := tr.Create(ctx, apitoken.Token{
+		Name: "ci", Hint: "pikoci_efgh", Hash: "h3",
+		Scopes:        []apitoken.Scope{apitoken.Admin},
+		TeamCanonical: "main", CreatedAt: now,
+	})
+	require.NoError(t, err)
+
+	_, err = tr.Create(ctx, apitoken.Token{Name: "other", Hash: "h3", TeamCanonical: "main"})
+	assert.Error(t, err, "duplicated hash")
+
+	tk, err := tr.FindByHash(ctx, "h1")
+	require.NoError(t, err)
+	assert.Equal(t, uid, tk.ID)
+	assert.Equal(t, "admin", tk.Username)
+	assert.Empty(t, tk.TeamCanonical)
+	assert.Equal(t, ut.Scopes, tk.Scopes)
+	require.NotNil(t, tk.ExpiresAt)
+	assert.True(t, exp.Equal(*tk.ExpiresAt))
+	assert.Nil(t, tk.LastUsedAt)
+
+	tk, err = tr.FindByHash(ctx, "h3")
+	require.NoError(t, err)
+	assert.Equal(t, tid, tk.ID)
+	assert.Equal(t, "main", tk.TeamCanonical)
+	assert.Empty(t, tk.Username)
+	assert.Nil(t, tk.ExpiresAt)
+
+	_, err = tr.FindByHash(ctx, "other")
+	assert.ErrorIs(t, err, apitoken.ErrNotFound)
+
+	require.NoError(t, tr.UpdateLastUsedAt(ctx, uid, exp))
+	assert.ErrorIs(t, tr.UpdateLastUsedAt(ctx, 999, exp), apitoken.ErrNotFound)
+
+	tks, err := tr.FilterByUser(ctx, "admin")
+	require.NoError(t, err)
+	require.Len(t, tks, 1)
+	assert.Equal(t, uid, tks[0].ID)
+	require.NotNil(t, tks[0].LastUsedAt)
+	assert.True(t, exp.Equal(*tks[0].LastUsedAt))
+
+	tks, err = tr.FilterByTeam(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, tks, 1)
+	assert.Equal(t, tid, tks[0].ID)
+
+	// The Tokens can only be deleted by their owner
+	assert.ErrorIs(t, tr.DeleteByUser(ctx, "admin", tid), apitoken.ErrNotFound)
+	assert.ErrorIs(t, tr.DeleteByTeam(ctx, "main", uid), apitoken.ErrNotFound)
+
+	require.NoError(t, tr.DeleteByUser(ctx, "admin", uid))
+	require.NoError(t, tr.DeleteByTeam(ctx, "main", tid))
+
+	tks, err = tr.FilterByUser(ctx, "admin")
+	require.NoError(t, err)
+	assert.Len(t, tks, 0)
+	tks, err = tr.FilterByTeam(ctx, "main")
+	require.NoError(t, err)
+	assert.Len(t, tks, 0)
+}
@@ -25,6 +25,8 @@ type dbUser struct {
 	Username sql.NullString
 	Password sql.NullString
 	Admin    sql.NullBool
+
+	OIDCSubject sql.NullString
 }
 
 func newDBUser(u user.User) dbUser {
@@ -33,6 +35,8 @@ func newDBUser(u user.User) dbUser {
 		Username: toNullString(u.Username),
 		Password: toNullString(u.Password),
 		Admin:    toNullBool(u.Admin),
+
+		OIDCSubject: toNullString(u.OIDCSubject),
 	}
 }
 
@@ -43,15 +47,17 @@ func (dbu *dbUser) toDomainEntity() *user.User {
 		Username: dbu.Username.String,
 		Password: dbu.Password.String,
 		Admin:    dbu.Admin.Bool,
+
+		OIDCSubject: dbu.OIDCSubject.String,
 	}
 }
 
 func (r *UserRepository) Create(ctx context.Context, u user.User) (uint32, error) {
 	dbu := newDBUser(u)
 	res, err := r.querier.ExecContext(ctx, `
-		INSERT INTO users(full_name, username, password, admin)
-		VALUES (?, ?, ?, ?)
-	`, dbu.FullName, dbu.Username, dbu.Password, dbu.Admin)
+		INSERT INTO users(full_name, username, password, admin, oidc_subject)
+		VALUES (?, ?, ?, ?, ?)
+	`, dbu.FullName, dbu.Username, dbu.Password, dbu.Admin, dbu.OIDCSubject)
 	if err != nil {
 		return 0, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -68,9 +74,9 @@ func (r *UserRepository) Update(ctx context.Context, un string, u user.User) err
 	dbu := newDBUser(u)
 	res, err := r.querier.ExecContext(ctx, `
 		UPDATE users AS u
-		SET full_name = ?, username = ?, password = ?, admin = ?
+		SET full_name = ?, username = ?, password = ?, admin = ?, oidc_subject = ?
 		WHERE u.username = ?
-	`, dbu.FullName, dbu.Username, dbu.Password, dbu.Admin, un)
+	`, dbu.FullName, dbu.Username, dbu.Password, dbu.Admin, dbu.OIDCSubject, un)
 	if err != nil {
 		return fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -85,7 +91,7 @@ func (r *UserRepository) Update(ctx context.Context, un string, u user.User) err
 
 func (r *UserRepository) Find(ctx context.Context, un string) (*user.User, error) {
 	row := r.querier.QueryRowContext(ctx, `
-		SELECT u.id, u.full_name, u.username, u.password, u.admin
+		SELECT u.id, u.full_name, u.username, u.password, u.admin, u.oidc_subject
 		FROM users AS u
 		WHERE u.username = ?
 	`, un)
@@ -100,8 +106,8 @@ func (r *UserRepository) Find(ctx context.Context, un string) (*user.User, error
 
 func (r *UserRepository) FindWithMemberships(ctx context.Context, un string) (*user.WithMemberships, error) {
 	rows, err := r.querier.QueryContext(ctx, `
-		SELECT u.id, u.full_name, u.username, u.password, u.admin,
-			tu.admin, t.id, t.name, t.canonical
+		SELECT u.id, u.full_name, u.username, u.password, u.admin, u.oidc_subject,
+			tu.role, t.id, t.name, t.canonical
 		FROM users AS u
 		LEFT JOIN teams_users AS tu
 			ON tu.user_id = u.id
@@ -123,7 +129,7 @@ func (r *UserRepository) FindWithMemberships(ctx context.Context, un string) (*u
 
 func (r *UserRepository) Filter(ctx context.Context) ([]*user.User, error) {
 	rows, err := r.querier.QueryContext(ctx, `
-		SELECT u.id, u.full_name, u.username, u.password, u.admin
+		SELECT u.id, u.full_name, u.username, u.password, u.admin, u.oidc_subject
 		FROM users AS u
 	`)
 	if err != nil {
@@ -165,6 +171,7 @@ func scanUser(s sqlr.Scanner) (*user.User, error) {
 		&u.Username,
 		&u.Password,
 		&u.Admin,
+		&u.OIDCSubject,
 	)
 
 	if err != nil {
@@ -198,9 +205,9 @@ func scanUserWithMemberships(rows *sql.Rows) (*user.WithMemberships, error) {
 
 	for rows.Next() {
 		var (
-			du    dbUser
-			dt    dbTeam
-			admin sql.NullBool
+			du   dbUser
+			dt   dbTeam
+			role sql.NullString
 		)
 		err := rows.Scan(
 			&du.ID,
@@ -208,7 +215,8 @@ func scanUserWithMemberships(rows *sql.Rows) (*user.WithMemberships, error) {
 			&du.Username,
 			&du.Password,
 			&du.Admin,
-			&admin,
+			&du.OIDCSubject,
+			&role,
 			&dt.ID,
 			&dt.Name,
 			&dt.Canonical,
@@ -229,7 +237,7 @@ func scanUserWithMemberships(rows *sql.Rows) (*user.WithMemberships, error) {
 			um.Memberships = make([]user.Member, 0)
 		}
 		um.Memberships = append(um.Memberships, user.Member{
-			Admin:         admin.Bool,
+			Role:          user.Role(role.String),
 			TeamCanonical: t.Canonical,
 		})
 	}
@@ -0,0 +1,192 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cycloidio/sqlr"
+	"github.com/xescugc/pikoci/pikoci/registry"
+)
+
+type WorkerRepository struct {
+	querier sqlr.Querier
+}
+
+func NewWorkerRepository(db sqlr.Querier) *WorkerRepository {
+	return &WorkerRepository{
+		querier: db,
+	}
+}
+
+type dbWorker struct {
+	ID          sql.NullInt64
+	Name        sql.NullString
+	Version     sql.NullString
+	Platform    sql.NullString
+	Concurrency sql.NullInt64
+	Tags        sql.NullString
+	StartedAt   sql.NullTime
+	HeartbeatAt sql.NullTime
+}
+
+func newDBWorker(w registry.Worker) dbWorker {
+	t, _ := json.Marshal(w.Tags)
+	return dbWorker{
+		Name:        toNullString(w.Name),
+		Version:     toNullString(w.Version),
+		Platform:    toNullString(w.Platform),
+		Concurrency: toNullInt64(w.Concurrency),
+		Tags:        toNullString(string(t)),
+		StartedAt:   toNullTime(w.StartedAt),
+		HeartbeatAt: toNullTime(w.HeartbeatAt),
+	}
+}
+
+func (dbw *dbWorker) toDomainEntity() *registry.Worker {
+	w := &registry.Worker{
+		ID:          uint32(dbw.ID.Int64),
+		Name:        dbw.Name.String,
+		Version:     dbw.Version.String,
+		Platform:    dbw.Platform.String,
+		Concurrency: int(dbw.Concurrency.Int64),
+		StartedAt:   dbw.StartedAt.Time,
+		HeartbeatAt: dbw.HeartbeatAt.Time,
+	}
+
+	_ = json.Unmarshal([]byte(dbw.Tags.String), &w.Tags)
+
+	return w
+}
+
+func (r *WorkerRepository) Create(ctx context.Context, w registry.Worker) (uint32, error) {
+	dbw := newDBWorker(w)
+	res, err := r.querier.ExecContext(ctx, `
+		INSERT INTO workers(name, version, platform, concurrency, tags, started_at, heartbeat_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, dbw.Name, dbw.Version, dbw.Platform, dbw.Concurrency, dbw.Tags, dbw.StartedAt, dbw.HeartbeatAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	id, err := lastInsertedID(res)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last inserted id: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *WorkerRepository) Filter(ctx context.Context) ([]*registry.Worker, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT w.id, w.name, w.version, w.platform, w.concurrency, w.tags, w.started_at, w.heartbeat_at
+		FROM workers AS w
+		ORDER BY w.name, w.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter workers: %w", err)
+	}
+
+	workers, err := scanWorkers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter workers: %w", err)
+	}
+
+	return workers, nil
+}
+
+func (r *WorkerRepository) Heartbeat(ctx context.Context, id uint32, at time.Time) error {
+	res, err := r.querier.ExecContext(ctx, `
+		UPDATE workers
+		SET heartbeat_at = ?
+		WHERE id = ?
+	`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return registry.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *WorkerRepository) FilterExpired(ctx context.Context, t time.Time) ([]*registry.Worker, error) {
+	rows, err := r.querier.QueryContext(ctx, `
+		SELECT w.id, w.name, w.version, w.platform, w.concurrency, w.tags, w.started_at, w.heartbeat_at
+		FROM workers AS w
+		WHERE w.heartbeat_at < ?
+	`, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter expired workers: %w", err)
+	}
+
+	workers, err := scanWorkers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter expired workers: %w", err)
+	}
+
+	return workers, nil
+}
+
+func (r *WorkerRepository) Delete(ctx context.Context, id uint32) error {
+	res, err := r.querier.ExecContext(ctx, `
+		DELETE
+		FROM workers
+		WHERE id = ?
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	err = isEntityFound(res)
+	if err != nil {
+		return fmt.Errorf("failed to delete the Worker: %w", err)
+	}
+
+	return nil
+}
+
+func scanWorker(s sqlr.Scanner) (*registry.Worker, error) {
+	var w dbWorker
+
+	err := s.Scan(
+		&w.ID,
+		&w.Name,
+		&w.Version,
+		&w.Platform,
+		&w.Concurrency,
+		&w.Tags,
+		&w.StartedAt,
+		&w.HeartbeatAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("not found")
+		}
+		return nil, fmt.Errorf("failed to scan: %w", err)
+	}
+
+	return w.toDomainEntity(), nil
+}
+
+func scanWorkers(rows *sql.Rows) ([]*registry.Worker, error) {
+	var ws []*registry.Worker
+
+	for rows.Next() {
+		w, err := scanWorker(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan worker: %w", err)
+		}
+		ws = append(ws, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan worker: %w", err)
+	}
+	return ws, nil
+}
@@ -0,0 +1,111 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+	"github.com/xescugc/pikoci/pikoci/registry"
+)
+
+func TestWorkers(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	wr := mysql.NewWorkerRepository(db)
+
+	now := time.Now().Round(time.Second).UTC()
+	alive := registry.Worker{
+		Name:        "alive",
+		Version:     "v1.0.0",
+		Platform:    "linux/amd64",
+		Concurrency: 2,
+		Tags:        []string{"docker"},
+		StartedAt:   now,
+		HeartbeatAt: now,
+	}
+	aliveID, err := wr.Create(ctx, alive)
+	require.NoError(t, err)
+	alive.ID = aliveID
+
+	dead := registry.Worker{
+		Name:        "dead",
+		Concurrency: 1,
+		StartedAt:   now.Add(-time.Hour),
+		HeartbeatAt: now.Add(-time.Hour),
+	}
+	deadID, err := wr.Create(ctx, dead)
+	require.NoError(t, err)
+	dead.ID = deadID
+
+	ws, err := wr.Filter(ctx)
+	require.NoError(t, err)
+	require.Len(t, ws, 2)
+	assert.Equal(t, alive.Name, ws[0].Name)
+	assert.Equal(t, alive.Version, ws[0].Version)
+	assert.Equal(t, alive.Platform, ws[0].Platform)
+	assert.Equal(t, alive.Concurrency, ws[0].Concurrency)
+	assert.Equal(t, alive.Tags, ws[0].Tags)
+	assert.True(t, alive.HeartbeatAt.Equal(ws[0].HeartbeatAt))
+
+	ws, err = wr.FilterExpired(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, ws, 1)
+	assert.Equal(t, deadID, ws[0].ID)
+
+	err = wr.Heartbeat(ctx, deadID, now)
+	require.NoError(t, err)
+
+	ws, err = wr.FilterExpired(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, ws, 0)
+
+	err = wr.Delete(ctx, deadID)
+	require.NoError(t, err)
+
+	err = wr.Heartbeat(ctx, deadID, now)
+	assert.ErrorIs(t, err, registry.ErrNotFound)
+
+	ws, err = wr.Filter(ctx)
+	require.NoError(t, err)
+	require.Len(t, ws, 1)
+	assert.Equal(t, aliveID, ws[0].ID)
+}
+
+func TestFilterStartedByWorker(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	res, err := db.ExecContext(ctx, `INSERT INTO pipelines (team_id, name) VALUES (1, 'by-worker')`)
+	require.NoError(t, err)
+	ppID, _ := res.LastInsertId()
+
+	res, err = db.ExecContext(ctx, `INSERT INTO jobs (pipeline_id, name) VALUES (?, 'build')`, ppID)
+	require.NoError(t, err)
+
+	br := mysql.NewBuildRepository(db, mysql.Mem)
+
+	_, bn, err := br.Create(ctx, "main", "by-worker", "build", build.Build{Status: build.Started, WorkerID: 7})
+	require.NoError(t, err)
+	_, _, err = br.Create(ctx, "main", "by-worker", "build", build.Build{Status: build.Succeeded, WorkerID: 7})
+	require.NoError(t, err)
+	_, _, err = br.Create(ctx, "main", "by-worker", "build", build.Build{Status: build.Started, WorkerID: 8})
+	require.NoError(t, err)
+
+	bs, err := br.FilterStartedByWorker(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, bs, 1)
+	assert.Equal(t, bn, bs[0].BuildNumber)
+	assert.Equal(t, uint32(7), bs[0].WorkerID)
+	assert.Equal(t, "main", bs[0].TeamCanonical)
+	assert.Equal(t, "by-worker", bs[0].PipelineName)
+	assert.Equal(t, "build", bs[0].JobName)
+
+	b, err := br.Find(ctx, "main", "by-worker", "build", bn)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), b.WorkerID)
+}
@@ -0,0 +1,170 @@
+package pikoci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/xescugc/pikoci/pikoci/oidc"
+	"github.com/xescugc/pikoci/pikoci/team"
+	"github.com/xescugc/pikoci/pikoci/unitwork"
+	"github.com/xescugc/pikoci/pikoci/user"
+	"github.com/xescugc/pikoci/pikoci/utils"
+)
+
+// ErrOIDCDisabled is returned when logging in with OIDC
+// on a server that has no OIDC Provider configured
+var ErrOIDCDisabled = errors.New("the OIDC login is not enabled on the server")
+
+// EnableOIDC makes the users able to log in with the OIDC Provider p
+func (q *PikoCI) EnableOIDC(p *oidc.Provider) {
+	q.oidc = p
+}
+
+func (q *PikoCI) OIDCEnabled(ctx context.Context) bool {
+	return q.oidc != nil
+}
+
+func (q *PikoCI) OIDCAuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if q.oidc == nil {
+		return "", ErrOIDCDisabled
+	} else if state == "" || nonce == "" || verifier == "" {
+		return "", fmt.Errorf("the OIDC state, nonce and verifier are required")
+	}
+
+	return q.oidc.AuthCodeURL(state, nonce, verifier), nil
+}
+
+func (q *PikoCI) OIDCLogin(ctx context.Context, code, verifier, nonce string) (*user.WithMemberships, string, error) {
+	if q.oidc == nil {
+		return nil, "", ErrOIDCDisabled
+	}
+
+	id, err := q.oidc.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to log in with OIDC: %w", err)
+	}
+
+	un := utils.Canonicalize(id.Username)
+	if !utils.ValidateCanonical(un) {
+		return nil, "", fmt.Errorf("invalid Username format %q", id.Username)
+	}
+
+	fn := id.Name
+	if fn == "" {
+		fn = un
+	}
+
+	err = q.StartUoW(ctx, func(uow unitwork.UnitOfWork) error {
+		u, err := uow.Users().Find(ctx, un)
+		if err != nil {
+			// The User has no password as it can only log in with
+			// OIDC, so it gets a random one nobody knows
+			pass, err := oidc.RandomString()
+			if err != nil {
+				return err
+			}
+			hash, err := utils.HashPassword(pass)
+			if err != nil {
+				return fmt.Errorf("failed to hash Passowrd: %w", err)
+			}
+
+			_, err = uow.Users().Create(ctx, user.User{
+				FullName:    fn,
+				Username:    un,
+				Password:    hash,
+				OIDCSubject: id.Subject,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to Create User: %w", err)
+			}
+		} else if u.OIDCSubject != id.Subject {
+			// Otherwise anyone able to choose its username on the
+			// Provider could log in as any of the existing Users
+			return fmt.Errorf("the User %q already exists and was not created with this OIDC login", un)
+		} else if u.FullName != fn {
+			u.FullName = fn
+			err = uow.Users().Update(ctx, un, *u)
+			if err != nil {
+				return fmt.Errorf("failed to Update User: %w", err)
+			}
+		}
+
+		return q.syncOIDCMemberships(ctx, uow, un, id.Groups)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	um, err := q.Users.FindWithMemberships(ctx, un)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to Find User: %w", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user": um,
+	})
+	tokenString, err := token.SignedString(q.JWTSecret)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return um, tokenString, nil
+}
+
+// syncOIDCMemberships makes the Memberships of the User un on the Teams
+// with OIDC Mappings the ones of its groups, the Memberships on the rest
+// of the Teams are managed as always
+func (q *PikoCI) syncOIDCMemberships(ctx context.Context, uow unitwork.UnitOfWork, un string, groups []string) error {
+	cfg := q.oidc.Config()
+
+	roles := make(map[string]user.Role)
+	for _, m := range cfg.Memberships(groups) {
+		roles[m.TeamCanonical] = m.Role
+	}
+
+	for _, tc := range cfg.Teams() {
+		t, err := uow.Teams().Find(ctx, tc)
+		if err != nil {
+			q.logger.Warn("failed to find the Team of the OIDC mapping", "team_canonical", tc, "error", err)
+			continue
+		}
+
+		var cur *team.Member
+		for _, tm := range t.Members {
+			if tm.User.Username == un {
+				cur = &tm
+				break
+			}
+		}
+
+		r, ok := roles[tc]
+		tm := team.Member{
+			Role: r,
+			User: user.User{
+				Username: un,
+			},
+		}
+		switch {
+		case ok && cur == nil:
+			err = uow.Teams().CreateMember(ctx, tc, tm)
+			if err != nil {
+				return fmt.Errorf("failed to create Team Member: %w", err)
+			}
+		case ok && cur.Role != r:
+			err = uow.Teams().UpdateMember(ctx, tc, un, tm)
+			if err != nil {
+				return fmt.Errorf("failed to update Team Member: %w", err)
+			}
+		case !ok && cur != nil:
+			err = uow.Teams().DeleteMember(ctx, tc, un)
+			if err != nil {
+				return fmt.Errorf("failed to delete Team Member: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
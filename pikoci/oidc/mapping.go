@@ -0,0 +1,87 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xescugc/pikoci/pikoci/user"
+	"github.com/xescugc/pikoci/pikoci/utils"
+)
+
+// Mapping gives the Role on the Team to the users of the Group
+type Mapping struct {
+	Group         string
+	TeamCanonical string
+	Role          user.Role
+}
+
+// ParseMapping parses the 'GROUP=TEAM:ROLE' m, the ROLE is optional and
+// defaults to user.RoleViewer. The GROUP can have '=' or ':' as the
+// TEAM and ROLE are taken from the end.
+func ParseMapping(m string) (Mapping, error) {
+	i := strings.LastIndex(m, "=")
+	if i <= 0 {
+		return Mapping{}, fmt.Errorf("invalid OIDC mapping %q, expected GROUP=TEAM:ROLE", m)
+	}
+
+	tc, r, _ := strings.Cut(m[i+1:], ":")
+	mp := Mapping{
+		Group:         m[:i],
+		TeamCanonical: tc,
+		Role:          user.Role(r),
+	}
+	if mp.Role == "" {
+		mp.Role = user.RoleViewer
+	}
+
+	if !utils.ValidateCanonical(mp.TeamCanonical) {
+		return Mapping{}, fmt.Errorf("invalid OIDC mapping %q, invalid Team Canonical format %q", m, mp.TeamCanonical)
+	} else if !mp.Role.Valid() {
+		return Mapping{}, fmt.Errorf("invalid OIDC mapping %q, invalid Role %q", m, mp.Role)
+	}
+
+	return mp, nil
+}
+
+// Teams returns the canonicals of the Teams with Mappings, the
+// Memberships of the users on them are managed by the Provider
+func (c Config) Teams() []string {
+	var tcs []string
+	seen := make(map[string]bool)
+	for _, m := range c.Mappings {
+		if !seen[m.TeamCanonical] {
+			seen[m.TeamCanonical] = true
+			tcs = append(tcs, m.TeamCanonical)
+		}
+	}
+	return tcs
+}
+
+// Memberships returns the Memberships the groups have with the Mappings,
+// with the highest Role if more than one Mapping matches the same Team
+func (c Config) Memberships(groups []string) []user.Member {
+	gs := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		gs[g] = true
+	}
+
+	var ms []user.Member
+	idx := make(map[string]int)
+	for _, m := range c.Mappings {
+		if !gs[m.Group] {
+			continue
+		}
+		if i, ok := idx[m.TeamCanonical]; ok {
+			if m.Role.Includes(ms[i].Role) {
+				ms[i].Role = m.Role
+			}
+			continue
+		}
+		idx[m.TeamCanonical] = len(ms)
+		ms = append(ms, user.Member{
+			Role:          m.Role,
+			TeamCanonical: m.TeamCanonical,
+		})
+	}
+	return ms
+}
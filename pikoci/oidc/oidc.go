@@ -0,0 +1,343 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultUsernameClaim is the claim used as Username if
+	// none is configured, with a fallback to the 'email'
+	DefaultUsernameClaim = "preferred_username"
+	// DefaultGroupsClaim is the claim with the groups of the
+	// user if none is configured
+	DefaultGroupsClaim = "groups"
+)
+
+// Config is the configuration of the OIDC Provider the users log in with
+type Config struct {
+	// Issuer is the URL of the Provider, the rest of
+	// the endpoints are discovered from it
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the /oidc/callback of the server
+	RedirectURL string
+
+	// Scopes are requested on top of 'openid'
+	Scopes        []string
+	UsernameClaim string
+	GroupsClaim   string
+
+	Mappings []Mapping
+}
+
+// Identity is the user that logged in on the Provider
+type Identity struct {
+	Subject  string
+	Username string
+	Name     string
+	Email    string
+	Groups   []string
+}
+
+// Provider does the authorization code flow with PKCE
+// against an OIDC Provider and verifies its ID Tokens
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	authURL  string
+	tokenURL string
+	jwksURL  string
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewProvider validates the cfg and discovers the endpoints of the Provider
+func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("the OIDC Issuer is required")
+	} else if cfg.ClientID == "" {
+		return nil, fmt.Errorf("the OIDC Client ID is required")
+	} else if cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("the OIDC Redirect URL is required")
+	}
+	for _, m := range cfg.Mappings {
+		if !m.Role.Valid() {
+			return nil, fmt.Errorf("invalid OIDC mapping Role %q", m.Role)
+		}
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = DefaultUsernameClaim
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = DefaultGroupsClaim
+	}
+
+	p := &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		keys:   make(map[string]*rsa.PublicKey),
+	}
+
+	var d discovery
+	err := p.getJSON(ctx, strings.TrimSuffix(cfg.Issuer, "/")+"/.well-known/openid-configuration", &d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the OIDC Provider: %w", err)
+	}
+
+	// The Issuer of the ID Tokens has to be the same as the
+	// one configured, so it has to match exactly
+	if d.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("the OIDC Issuer %q does not match the discovered %q", cfg.Issuer, d.Issuer)
+	} else if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("the OIDC Provider is missing the authorization, token or JWKS endpoints")
+	}
+
+	p.authURL = d.AuthorizationEndpoint
+	p.tokenURL = d.TokenEndpoint
+	p.jwksURL = d.JWKSURI
+
+	return p, nil
+}
+
+// Config returns the Config of the Provider
+func (p *Provider) Config() Config { return p.cfg }
+
+// AuthCodeURL returns the URL the user has to be redirected to in order
+// to log in. The verifier is sent hashed as the PKCE challenge.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.cfg.ClientID)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("scope", strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", challenge(verifier))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.authURL, "?") {
+		sep = "&"
+	}
+	return p.authURL + sep + v.Encode()
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange exchanges the code of the callback for the ID Token and returns
+// the Identity on it, the nonce has to be the one of the AuthCodeURL
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	if code == "" {
+		return nil, fmt.Errorf("the OIDC code is required")
+	}
+
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", p.cfg.RedirectURL)
+	v.Set("code_verifier", verifier)
+	v.Set("client_id", p.cfg.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request the token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tr tokenResponse
+	err = json.NewDecoder(resp.Body).Decode(&tr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the token response (%s): %w", resp.Status, err)
+	} else if tr.Error != "" {
+		return nil, fmt.Errorf("failed to exchange the code: %s %s", tr.Error, tr.ErrorDescription)
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to exchange the code: %s", resp.Status)
+	} else if tr.IDToken == "" {
+		return nil, fmt.Errorf("the token response has no ID Token")
+	}
+
+	return p.verify(ctx, tr.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, raw, nonce string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID Token: %w", err)
+	}
+
+	if n, _ := claims["nonce"].(string); n == "" || n != nonce {
+		return nil, fmt.Errorf("invalid ID Token: the nonce does not match")
+	}
+
+	id := &Identity{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Name, _ = claims["name"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Username, _ = claims[p.cfg.UsernameClaim].(string)
+	if id.Username == "" {
+		id.Username = id.Email
+	}
+	if id.Subject == "" {
+		return nil, fmt.Errorf("invalid ID Token: missing the subject")
+	} else if id.Username == "" {
+		return nil, fmt.Errorf("invalid ID Token: missing the %q claim", p.cfg.UsernameClaim)
+	}
+
+	switch gs := claims[p.cfg.GroupsClaim].(type) {
+	case string:
+		id.Groups = []string{gs}
+	case []any:
+		for _, g := range gs {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	}
+
+	return id, nil
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// key returns the key kid, the keys are fetched again if it's
+// not known as the Provider may have rotated them
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+
+	var ks jwks
+	err := p.getJSON(ctx, p.jwksURL, &ks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range ks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	p.keys = keys
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	} else if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request %q: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to request %q: %s", u, resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("failed to decode %q: %w", u, err)
+	}
+
+	return nil
+}
+
+// RandomString returns a random URL safe string, to
+// use as state, nonce or PKCE verifier
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// challenge returns the PKCE S256 challenge of the verifier
+func challenge(verifier string) string {
+	h := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
@@ -0,0 +1,231 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/oidc"
+	"github.com/xescugc/pikoci/pikoci/oidc/oidctest"
+	"github.com/xescugc/pikoci/pikoci/user"
+)
+
+func newProvider(t *testing.T, fp *oidctest.Provider) *oidc.Provider {
+	p, err := oidc.NewProvider(context.TODO(), oidc.Config{
+		Issuer:       fp.Issuer(),
+		ClientID:     fp.ClientID,
+		ClientSecret: fp.ClientSecret,
+		RedirectURL:  "http://pikoci.test/oidc/callback",
+		Scopes:       []string{"profile", "email", "groups"},
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestProvider(t *testing.T) {
+	fp := oidctest.NewProvider("pikoci", "s3cr3t")
+	defer fp.Close()
+
+	t.Run("Success", func(t *testing.T) {
+		p := newProvider(t, fp)
+		ctx := context.TODO()
+
+		fp.SetClaims(map[string]any{
+			"sub":                "1234",
+			"preferred_username": "pepito",
+			"name":               "Pepito Grillo",
+			"email":              "pepito@example.com",
+			"groups":             []string{"devs", "ops"},
+		})
+
+		verifier, err := oidc.RandomString()
+		require.NoError(t, err)
+
+		au := p.AuthCodeURL("state", "nonce", verifier)
+		u, err := url.Parse(au)
+		require.NoError(t, err)
+		assert.Equal(t, "openid profile email groups", u.Query().Get("scope"))
+		assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+		assert.NotEqual(t, verifier, u.Query().Get("code_challenge"))
+
+		code, state, err := fp.Login(au)
+		require.NoError(t, err)
+		assert.Equal(t, "state", state)
+
+		id, err := p.Exchange(ctx, code, verifier, "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, &oidc.Identity{
+			Subject:  "1234",
+			Username: "pepito",
+			Name:     "Pepito Grillo",
+			Email:    "pepito@example.com",
+			Groups:   []string{"devs", "ops"},
+		}, id)
+	})
+	t.Run("UsernameFromEmail", func(t *testing.T) {
+		p := newProvider(t, fp)
+		ctx := context.TODO()
+
+		fp.SetClaims(map[string]any{
+			"email":  "pepito@example.com",
+			"groups": "devs",
+		})
+
+		au := p.AuthCodeURL("state", "nonce", "verifier")
+		code, _, err := fp.Login(au)
+		require.NoError(t, err)
+
+		id, err := p.Exchange(ctx, code, "verifier", "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, "pepito@example.com", id.Username)
+		assert.Equal(t, []string{"devs"}, id.Groups)
+	})
+	t.Run("InvalidNonce", func(t *testing.T) {
+		p := newProvider(t, fp)
+		ctx := context.TODO()
+
+		fp.SetClaims(map[string]any{"preferred_username": "pepito"})
+
+		au := p.AuthCodeURL("state", "nonce", "verifier")
+		code, _, err := fp.Login(au)
+		require.NoError(t, err)
+
+		_, err = p.Exchange(ctx, code, "verifier", "other")
+		assert.EqualError(t, err, "invalid ID Token: the nonce does not match")
+	})
+	t.Run("InvalidVerifier", func(t *testing.T) {
+		p := newProvider(t, fp)
+		ctx := context.TODO()
+
+		au := p.AuthCodeURL("state", "nonce", "verifier")
+		code, _, err := fp.Login(au)
+		require.NoError(t, err)
+
+		_, err = p.Exchange(ctx, code, "other", "nonce")
+		assert.EqualError(t, err, "failed to exchange the code: invalid_grant the code_verifier does not match")
+	})
+	t.Run("CodeUsedTwice", func(t *testing.T) {
+		p := newProvider(t, fp)
+		ctx := context.TODO()
+
+		fp.SetClaims(map[string]any{"preferred_username": "pepito"})
+
+		au := p.AuthCodeURL("state", "nonce", "verifier")
+		code, _, err := fp.Login(au)
+		require.NoError(t, err)
+
+		_, err = p.Exchange(ctx, code, "verifier", "nonce")
+		require.NoError(t, err)
+
+		_, err = p.Exchange(ctx, code, "verifier", "nonce")
+		assert.EqualError(t, err, "failed to exchange the code: invalid_grant unknown code")
+	})
+	t.Run("InvalidClientSecret", func(t *testing.T) {
+		p, err := oidc.NewProvider(context.TODO(), oidc.Config{
+			Issuer:       fp.Issuer(),
+			ClientID:     fp.ClientID,
+			ClientSecret: "wrong",
+			RedirectURL:  "http://pikoci.test/oidc/callback",
+		})
+		require.NoError(t, err)
+
+		au := p.AuthCodeURL("state", "nonce", "verifier")
+		code, _, err := fp.Login(au)
+		require.NoError(t, err)
+
+		_, err = p.Exchange(context.TODO(), code, "verifier", "nonce")
+		assert.EqualError(t, err, "failed to exchange the code: invalid_client invalid client credentials")
+	})
+	t.Run("IssuerMismatch", func(t *testing.T) {
+		_, err := oidc.NewProvider(context.TODO(), oidc.Config{
+			Issuer:      fp.Issuer() + "/",
+			ClientID:    fp.ClientID,
+			RedirectURL: "http://pikoci.test/oidc/callback",
+		})
+		assert.EqualError(t, err, `the OIDC Issuer "`+fp.Issuer()+`/" does not match the discovered "`+fp.Issuer()+`"`)
+	})
+	t.Run("MissingClientID", func(t *testing.T) {
+		_, err := oidc.NewProvider(context.TODO(), oidc.Config{
+			Issuer:      fp.Issuer(),
+			RedirectURL: "http://pikoci.test/oidc/callback",
+		})
+		assert.EqualError(t, err, "the OIDC Client ID is required")
+	})
+}
+
+func TestParseMapping(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Mapping string
+		Result  oidc.Mapping
+		Err     string
+	}{
+		{
+			Name:    "Success",
+			Mapping: "devs=main:member",
+			Result:  oidc.Mapping{Group: "devs", TeamCanonical: "main", Role: user.RoleMember},
+		},
+		{
+			Name:    "DefaultRole",
+			Mapping: "devs=main",
+			Result:  oidc.Mapping{Group: "devs", TeamCanonical: "main", Role: user.RoleViewer},
+		},
+		{
+			Name:    "GroupWithSeparators",
+			Mapping: "org:team=a=main:owner",
+			Result:  oidc.Mapping{Group: "org:team=a", TeamCanonical: "main", Role: user.RoleOwner},
+		},
+		{
+			Name:    "NoGroup",
+			Mapping: "=main:owner",
+			Err:     `invalid OIDC mapping "=main:owner", expected GROUP=TEAM:ROLE`,
+		},
+		{
+			Name:    "InvalidTeam",
+			Mapping: "devs=Main Team:owner",
+			Err:     `invalid OIDC mapping "devs=Main Team:owner", invalid Team Canonical format "Main Team"`,
+		},
+		{
+			Name:    "InvalidRole",
+			Mapping: "devs=main:admin",
+			Err:     `invalid OIDC mapping "devs=main:admin", invalid Role "admin"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			m, err := oidc.ParseMapping(tt.Mapping)
+			if tt.Err != "" {
+				assert.EqualError(t, err, tt.Err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.Result, m)
+			}
+		})
+	}
+}
+
+func TestConfig_Memberships(t *testing.T) {
+	cfg := oidc.Config{
+		Mappings: []oidc.Mapping{
+			{Group: "devs", TeamCanonical: "main", Role: user.RoleMember},
+			{Group: "ops", TeamCanonical: "main", Role: user.RoleOwner},
+			{Group: "devs", TeamCanonical: "infra", Role: user.RoleViewer},
+			{Group: "all", TeamCanonical: "main", Role: user.RoleViewer},
+		},
+	}
+
+	assert.Equal(t, []string{"main", "infra"}, cfg.Teams())
+
+	assert.Equal(t, []user.Member{
+		{TeamCanonical: "main", Role: user.RoleOwner},
+		{TeamCanonical: "infra", Role: user.RoleViewer},
+	}, cfg.Memberships([]string{"all", "devs", "ops"}))
+
+	assert.Equal(t, []user.Member{
+		{TeamCanonical: "main", Role: user.RoleViewer},
+	}, cfg.Memberships([]string{"all", "other"}))
+
+	assert.Empty(t, cfg.Memberships(nil))
+}
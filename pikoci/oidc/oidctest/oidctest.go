@@ -0,0 +1,256 @@
+// Package oidctest has an in process OIDC Provider to test the login with
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "oidctest"
+
+// Provider is a fake OIDC Provider, the users are logged in directly
+// without asking for anything and the ID Tokens have the Claims
+type Provider struct {
+	ClientID     string
+	ClientSecret string
+
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims map[string]any
+	codes  map[string]authRequest
+}
+
+type authRequest struct {
+	redirectURI string
+	nonce       string
+	challenge   string
+	claims      map[string]any
+}
+
+// NewProvider starts a new Provider, it has to be closed after
+func NewProvider(clientID, clientSecret string) *Provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: failed to generate key: %v", err))
+	}
+
+	p := &Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]authRequest),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	p.server = httptest.NewServer(mux)
+
+	return p
+}
+
+// Issuer is the URL of the Provider
+func (p *Provider) Issuer() string { return p.server.URL }
+
+// Close stops the Provider
+func (p *Provider) Close() { p.server.Close() }
+
+// SetClaims sets the claims of the ID Tokens of the next logins,
+// the 'iss', 'aud', 'exp', 'iat' and 'nonce' are always set
+func (p *Provider) SetClaims(c map[string]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.claims = c
+}
+
+// Login follows the authURL as the user would and returns the code and
+// state the Provider redirects back with, without following the redirect
+func (p *Provider) Login(authURL string) (string, string, error) {
+	c := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := c.Get(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("oidctest: unexpected status %s", resp.Status)
+	}
+
+	u, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	return u.Query().Get("code"), u.Query().Get("state"), nil
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.Issuer() + "/authorize",
+		"token_endpoint":         p.Issuer() + "/token",
+		"jwks_uri":               p.Issuer() + "/jwks",
+	})
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != p.ClientID {
+		http.Error(w, "invalid client_id", http.StatusBadRequest)
+		return
+	} else if q.Get("response_type") != "code" {
+		http.Error(w, "invalid response_type", http.StatusBadRequest)
+		return
+	} else if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "the PKCE S256 challenge is required", http.StatusBadRequest)
+		return
+	}
+
+	ru, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+
+	p.mu.Lock()
+	p.codes[code] = authRequest{
+		redirectURI: q.Get("redirect_uri"),
+		nonce:       q.Get("nonce"),
+		challenge:   q.Get("code_challenge"),
+		claims:      p.claims,
+	}
+	p.mu.Unlock()
+
+	rq := ru.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	ru.RawQuery = rq.Encode()
+
+	http.Redirect(w, r, ru.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != p.ClientID || secret != p.ClientSecret {
+		tokenError(w, "invalid_client", "invalid client credentials")
+		return
+	} else if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type", "")
+		return
+	}
+
+	p.mu.Lock()
+	ar, ok := p.codes[r.PostForm.Get("code")]
+	// The codes can only be used once
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if !ok {
+		tokenError(w, "invalid_grant", "unknown code")
+		return
+	} else if ar.redirectURI != r.PostForm.Get("redirect_uri") {
+		tokenError(w, "invalid_grant", "the redirect_uri does not match")
+		return
+	}
+
+	h := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(h[:]) != ar.challenge {
+		tokenError(w, "invalid_grant", "the code_verifier does not match")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": "oidctest",
+	}
+	for k, v := range ar.claims {
+		claims[k] = v
+	}
+	claims["iss"] = p.Issuer()
+	claims["aud"] = p.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	claims["nonce"] = ar.nonce
+
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = keyID
+	idt, err := t.SignedString(p.key)
+	if err != nil {
+		tokenError(w, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idt,
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]any{
+			{
+				"kty": "RSA",
+				"kid": keyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func tokenError(w http.ResponseWriter, code, desc string) {
+	writeJSON(w, http.StatusBadRequest, map[string]any{
+		"error":             code,
+		"error_description": desc,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
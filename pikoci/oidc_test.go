@@ -0,0 +1,161 @@
+package pikoci_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/oidc"
+	"github.com/xescugc/pikoci/pikoci/oidc/oidctest"
+	"github.com/xescugc/pikoci/pikoci/team"
+	"github.com/xescugc/pikoci/pikoci/user"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	fp := oidctest.NewProvider("pikoci", "s3cr3t")
+	defer fp.Close()
+
+	// login enables OIDC on s and returns the code
+	// of the user with the claims logging in
+	login := func(t *testing.T, s MockService, claims map[string]any) string {
+		p, err := oidc.NewProvider(context.TODO(), oidc.Config{
+			Issuer:       fp.Issuer(),
+			ClientID:     fp.ClientID,
+			ClientSecret: fp.ClientSecret,
+			RedirectURL:  "http://pikoci.test/oidc/callback",
+			Mappings: []oidc.Mapping{
+				{Group: "devs", TeamCanonical: "main", Role: user.RoleMember},
+				{Group: "ops", TeamCanonical: "main", Role: user.RoleOwner},
+				{Group: "devs", TeamCanonical: "infra", Role: user.RoleViewer},
+				{Group: "ops", TeamCanonical: "prod", Role: user.RoleOperator},
+			},
+		})
+		require.NoError(t, err)
+		s.P.EnableOIDC(p)
+
+		fp.SetClaims(claims)
+
+		au, err := s.S.OIDCAuthCodeURL(context.TODO(), "state", "nonce", "verifier")
+		require.NoError(t, err)
+
+		code, _, err := fp.Login(au)
+		require.NoError(t, err)
+		return code
+	}
+
+	t.Run("Provision", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		code := login(t, s, map[string]any{
+			"sub":                "1234",
+			"preferred_username": "pepito",
+			"name":               "Pepito Grillo",
+			"groups":             []string{"devs"},
+		})
+
+		um := &user.WithMemberships{
+			User: user.User{Username: "pepito", FullName: "Pepito Grillo"},
+			Memberships: []user.Member{
+				{TeamCanonical: "main", Role: user.RoleMember},
+				{TeamCanonical: "infra", Role: user.RoleViewer},
+			},
+		}
+
+		s.Users.EXPECT().Find(ctx, "pepito").Return(nil, fmt.Errorf("not found"))
+		s.Users.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, u user.User) (uint32, error) {
+			assert.Equal(t, "pepito", u.Username)
+			assert.Equal(t, "Pepito Grillo", u.FullName)
+			assert.Equal(t, "1234", u.OIDCSubject)
+			assert.NotEmpty(t, u.Password)
+			return 1, nil
+		})
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{Canonical: "main"}}, nil)
+		s.Teams.EXPECT().CreateMember(ctx, "main", team.Member{Role: user.RoleMember, User: user.User{Username: "pepito"}}).Return(nil)
+		s.Teams.EXPECT().Find(ctx, "infra").Return(&team.WithMembers{Team: team.Team{Canonical: "infra"}}, nil)
+		s.Teams.EXPECT().CreateMember(ctx, "infra", team.Member{Role: user.RoleViewer, User: user.User{Username: "pepito"}}).Return(nil)
+		s.Teams.EXPECT().Find(ctx, "prod").Return(&team.WithMembers{Team: team.Team{Canonical: "prod"}}, nil)
+		s.Users.EXPECT().FindWithMemberships(ctx, "pepito").Return(um, nil)
+
+		rum, tk, err := s.S.OIDCLogin(ctx, code, "verifier", "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, um, rum)
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(tk, claims, func(*jwt.Token) (any, error) { return []byte("test-secret"), nil })
+		require.NoError(t, err)
+		assert.Equal(t, "pepito", claims["user"].(map[string]any)["username"])
+	})
+	t.Run("SyncMemberships", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		code := login(t, s, map[string]any{
+			"sub":                "1234",
+			"preferred_username": "pepito",
+			"name":               "Pepito",
+			"groups":             []string{"ops"},
+		})
+
+		pepito := func(r user.Role) team.Member {
+			return team.Member{Role: r, User: user.User{Username: "pepito"}}
+		}
+
+		s.Users.EXPECT().Find(ctx, "pepito").Return(&user.User{Username: "pepito", FullName: "Pepito Grillo", OIDCSubject: "1234"}, nil)
+		s.Users.EXPECT().Update(ctx, "pepito", user.User{Username: "pepito", FullName: "Pepito", OIDCSubject: "1234"}).Return(nil)
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Members: []team.Member{pepito(user.RoleMember)}}, nil)
+		s.Teams.EXPECT().UpdateMember(ctx, "main", "pepito", pepito(user.RoleOwner)).Return(nil)
+		s.Teams.EXPECT().Find(ctx, "infra").Return(&team.WithMembers{Members: []team.Member{pepito(user.RoleViewer)}}, nil)
+		s.Teams.EXPECT().DeleteMember(ctx, "infra", "pepito").Return(nil)
+		s.Teams.EXPECT().Find(ctx, "prod").Return(&team.WithMembers{Members: []team.Member{pepito(user.RoleOperator)}}, nil)
+		s.Users.EXPECT().FindWithMemberships(ctx, "pepito").Return(&user.WithMemberships{User: user.User{Username: "pepito"}}, nil)
+
+		_, _, err := s.S.OIDCLogin(ctx, code, "verifier", "nonce")
+		require.NoError(t, err)
+	})
+	t.Run("UserNotFromOIDC", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		code := login(t, s, map[string]any{
+			"sub":                "1234",
+			"preferred_username": "admin",
+		})
+
+		s.Users.EXPECT().Find(ctx, "admin").Return(&user.User{Username: "admin", Admin: true}, nil)
+
+		_, _, err := s.S.OIDCLogin(ctx, code, "verifier", "nonce")
+		assert.EqualError(t, err, `the User "admin" already exists and was not created with this OIDC login`)
+	})
+	t.Run("InvalidNonce", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		code := login(t, s, map[string]any{"preferred_username": "pepito"})
+
+		_, _, err := s.S.OIDCLogin(ctx, code, "verifier", "other")
+		assert.EqualError(t, err, "failed to log in with OIDC: invalid ID Token: the nonce does not match")
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		assert.False(t, s.S.OIDCEnabled(ctx))
+
+		_, err := s.S.OIDCAuthCodeURL(ctx, "state", "nonce", "verifier")
+		assert.ErrorIs(t, err, pikoci.ErrOIDCDisabled)
+
+		_, _, err = s.S.OIDCLogin(ctx, "code", "verifier", "nonce")
+		assert.ErrorIs(t, err, pikoci.ErrOIDCDisabled)
+	})
+}
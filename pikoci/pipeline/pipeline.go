@@ -41,6 +41,7 @@ type Pipeline struct {
 	ID            uint32                    `json:"id"`
 	Name          string                    `json:"name"`
 	Public        bool                      `json:"public"`
+	Paused        bool                      `json:"paused"`
 	Jobs          []job.Job                 `json:"jobs" hcl:"job,block"`
 	Resources     []resource.Resource       `json:"resources" hcl:"resource,block"`
 	ResourceTypes []restype.ResourceType    `json:"resource_types" hcl:"resource_type,block"`
@@ -37,8 +37,8 @@ func TestPipeline_ResourceType(t *testing.T) {
 		rt, ok := pp.ResourceType("git")
 		assert.True(t, ok)
 		assert.Equal(t, "git", rt.Name)
-		assert.Equal(t, "exec", rt.Check.Runner)
-		assert.Equal(t, "exec", rt.Pull.Runner)
+		assert.Equal(t, "native", rt.Check.Runner)
+		assert.Equal(t, "native", rt.Pull.Runner)
 		assert.Contains(t, rt.Params, "url")
 		assert.Contains(t, rt.Params, "token")
 	})
@@ -14,5 +14,6 @@ type Repository interface {
 	Filter(ctx context.Context, tc string) ([]*Pipeline, error)
 	FilterAll(ctx context.Context) ([]*WithTeam, error)
 	SetPublic(ctx context.Context, tc, pn string, public bool) error
+	SetPaused(ctx context.Context, tc, pn string, paused bool) error
 	Delete(ctx context.Context, tc, pn string) error
 }
@@ -62,6 +62,8 @@ func (q *PikoCI) CreatePipeline(ctx context.Context, tc, pn string, rpp []byte,
 			}
 		}
 
+		// The ID of the Team the webhook secrets are bound to
+		var tID uint32
 		for _, r := range pp.Resources {
 			if !utils.ValidateCanonical(r.Name) {
 				return fmt.Errorf("invalid Resource Name format %q", r.Name)
@@ -73,6 +75,22 @@ func (q *PikoCI) CreatePipeline(ctx context.Context, tc, pn string, rpp []byte,
 			if err := scheduler.ValidateCheckInterval(spec); err != nil {
 				return fmt.Errorf("invalid check_interval for resource %q: %w", r.Name, err)
 			}
+			if r.Webhook != nil {
+				if err := r.Webhook.Validate(); err != nil {
+					return fmt.Errorf("invalid webhook for resource %q: %w", r.Name, err)
+				}
+				if tID == 0 {
+					t, err := uow.Teams().Find(ctx, tc)
+					if err != nil {
+						return fmt.Errorf("failed to Find Team: %w", err)
+					}
+					tID = t.ID
+				}
+				r.Webhook, err = q.encryptWebhook(tID, pn, r.Canonical, r.Webhook)
+				if err != nil {
+					return fmt.Errorf("failed to encrypt webhook for resource %q: %w", r.Name, err)
+				}
+			}
 			nextCheck, err := scheduler.ComputeNextCheck(spec, time.Now())
 			if err != nil {
 				return fmt.Errorf("failed to compute next check for resource %q: %w", r.Name, err)
@@ -204,6 +222,8 @@ func (q *PikoCI) UpdatePipeline(ctx context.Context, tc, pn string, rpp []byte,
 		for _, r := range dbpp.Resources {
 			dbrs[r.Canonical] = r
 		}
+		// The ID of the Team the webhook secrets are bound to
+		var tID uint32
 		for _, r := range pp.Resources {
 			if !utils.ValidateCanonical(r.Name) {
 				return fmt.Errorf("invalid Resource Name format %q", r.Name)
@@ -215,6 +235,22 @@ func (q *PikoCI) UpdatePipeline(ctx context.Context, tc, pn string, rpp []byte,
 			if err := scheduler.ValidateCheckInterval(spec); err != nil {
 				return fmt.Errorf("invalid check_interval for resource %q: %w", r.Name, err)
 			}
+			if r.Webhook != nil {
+				if err := r.Webhook.Validate(); err != nil {
+					return fmt.Errorf("invalid webhook for resource %q: %w", r.Name, err)
+				}
+				if tID == 0 {
+					t, err := uow.Teams().Find(ctx, tc)
+					if err != nil {
+						return fmt.Errorf("failed to Find Team: %w", err)
+					}
+					tID = t.ID
+				}
+				r.Webhook, err = q.encryptWebhook(tID, pn, r.Canonical, r.Webhook)
+				if err != nil {
+					return fmt.Errorf("failed to encrypt webhook for resource %q: %w", r.Name, err)
+				}
+			}
 			if dbr, ok := dbrs[r.Canonical]; ok {
 				delete(dbrs, r.Canonical)
 				if dbr.CheckInterval != r.CheckInterval {
@@ -231,6 +267,10 @@ func (q *PikoCI) UpdatePipeline(ctx context.Context, tc, pn string, rpp []byte,
 				if err != nil {
 					return fmt.Errorf("failed to update Resource %q: %w", r.Canonical, err)
 				}
+				err = uow.Resources().SetWebhook(ctx, tc, pn, r.Canonical, r.Webhook)
+				if err != nil {
+					return fmt.Errorf("failed to set webhook of Resource %q: %w", r.Canonical, err)
+				}
 			} else {
 				nextCheck, err := scheduler.ComputeNextCheck(spec, time.Now())
 				if err != nil {
@@ -379,6 +419,8 @@ var (
 	colorDefault        = `"#83769C"`
 	colorDefaultBorder  = `"#5F574F"`
 	colorError          = `"#FF004D"`
+	colorPaused         = `"#29ADFF"`
+	colorPinned         = `"#FFA300"`
 )
 
 func (q *PikoCI) GetPipelineImage(ctx context.Context, tc, pn, format string) ([]byte, error) {
@@ -433,18 +475,27 @@ func (q *PikoCI) generateImage(ctx context.Context, tc string, pp *pipeline.Pipe
 	}
 
 	resourceBorders := make(map[string]string)
+	resourceLabels := make(map[string]string)
 	// Print all the resources
 	for _, r := range pp.Resources {
 		borderColor := colorResourceBorder
+		label := fmt.Sprintf(`"%s"`, r.Canonical)
+		// Pinned resources are marked, the check errors still take precedence
+		if r.PinnedVersionID != 0 {
+			borderColor = colorPinned
+			label = fmt.Sprintf(`"%s (pinned)"`, r.Canonical)
+		}
 		if r.Logs != "" {
 			borderColor = colorError
 		}
 		resourceBorders[r.Canonical] = borderColor
+		resourceLabels[r.Canonical] = label
 		if !referencedResources[r.Canonical] {
 			continue
 		}
 		vurl := fmt.Sprintf(`"/teams/%s/pipelines/%s/resources/%s/versions"`, tc, pp.Name, r.Canonical)
 		err = graph.AddNode(pn, fmt.Sprintf(`"%s"`, r.Canonical), map[string]string{
+			string(gographviz.Label):     label,
 			string(gographviz.Margin):    "0.2",
 			string(gographviz.Shape):     "cds",
 			string(gographviz.FillColor): colorResource,
@@ -474,7 +525,8 @@ func (q *PikoCI) generateImage(ctx context.Context, tc string, pp *pipeline.Pipe
 		)
 		if len(builds) != 0 {
 			cb = builds[0]
-			if len(builds) > 1 && cb.Status == build.Started {
+			// The jobs with a build running or queued keep the color of the previous one
+			if len(builds) > 1 && !cb.Status.Finished() {
 				pb = builds[1]
 				if c, ok := jobColors[pb.Status]; ok {
 					color = c
@@ -485,7 +537,7 @@ func (q *PikoCI) generateImage(ctx context.Context, tc string, pp *pipeline.Pipe
 			}
 		}
 
-		if pb == nil && cb != nil && cb.Status != build.Started {
+		if pb == nil && cb != nil && cb.Status.Finished() {
 			if c, ok := jobColors[cb.Status]; ok {
 				color = c
 			}
@@ -507,7 +559,7 @@ func (q *PikoCI) generateImage(ctx context.Context, tc string, pp *pipeline.Pipe
 
 		burl := fmt.Sprintf(`"/teams/%s/pipelines/%s/jobs/%s/builds"`, tc, pp.Name, j.Name)
 		quotedJobName := fmt.Sprintf(`"%s"`, j.Name)
-		err = graph.AddNode(jg, quotedJobName, map[string]string{
+		attrs := map[string]string{
 			string(gographviz.Margin):    "0.5",
 			string(gographviz.Shape):     "rectangle",
 			string(gographviz.FillColor): color,
@@ -515,7 +567,14 @@ func (q *PikoCI) generateImage(ctx context.Context, tc string, pp *pipeline.Pipe
 			string(gographviz.FontColor): "white",
 			string(gographviz.Color):     borderColor,
 			string(gographviz.URL):       burl,
-		})
+		}
+		// The jobs of a paused pipeline are shown as paused too
+		if pp.Paused || j.Paused {
+			attrs[string(gographviz.Label)] = fmt.Sprintf(`"%s (paused)"`, j.Name)
+			attrs[string(gographviz.Color)] = colorPaused
+			attrs[string(gographviz.Style)] = `"filled,bold"`
+		}
+		err = graph.AddNode(jg, quotedJobName, attrs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to add node to Graph: %w", err)
 		}
@@ -544,7 +603,7 @@ func (q *PikoCI) generateImage(ctx context.Context, tc string, pp *pipeline.Pipe
 			vurl := fmt.Sprintf(`"/teams/%s/pipelines/%s/resources/%s/versions"`, tc, pp.Name, rCan)
 			border := resourceBorders[rCan]
 			err = graph.AddNode(pn, nn, map[string]string{
-				string(gographviz.Label):     fmt.Sprintf(`"%s"`, rCan),
+				string(gographviz.Label):     resourceLabels[rCan],
 				string(gographviz.Margin):    "0.2",
 				string(gographviz.Shape):     "cds",
 				string(gographviz.FillColor): colorResource,
@@ -576,7 +635,7 @@ func (q *PikoCI) generateImage(ctx context.Context, tc string, pp *pipeline.Pipe
 					vurl := fmt.Sprintf(`"/teams/%s/pipelines/%s/resources/%s/versions"`, tc, pp.Name, rCan)
 					border := resourceBorders[rCan]
 					err = graph.AddNode(pn, nn, map[string]string{
-						string(gographviz.Label):     fmt.Sprintf(`"%s"`, rCan),
+						string(gographviz.Label):     resourceLabels[rCan],
 						string(gographviz.Margin):    "0.2",
 						string(gographviz.Shape):     "cds",
 						string(gographviz.FillColor): colorResource,
@@ -672,6 +731,32 @@ func (q *PikoCI) SetPipelinePublic(ctx context.Context, tc, pn string, public bo
 	return q.Pipelines.SetPublic(ctx, tc, pn, public)
 }
 
+// PausePipeline stops the Pipeline pn from checking resources
+// and triggering jobs until it's unpaused
+func (q *PikoCI) PausePipeline(ctx context.Context, tc, pn string) error {
+	return q.setPipelinePaused(ctx, tc, pn, true)
+}
+
+// UnpausePipeline resumes the Pipeline pn
+func (q *PikoCI) UnpausePipeline(ctx context.Context, tc, pn string) error {
+	return q.setPipelinePaused(ctx, tc, pn, false)
+}
+
+func (q *PikoCI) setPipelinePaused(ctx context.Context, tc, pn string, paused bool) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	}
+
+	err := q.Pipelines.SetPaused(ctx, tc, pn, paused)
+	if err != nil {
+		return fmt.Errorf("failed to set paused on Pipeline %q: %w", pn, err)
+	}
+
+	return nil
+}
+
 func (q *PikoCI) GetPublicPipeline(ctx context.Context, tc, pn string) (*pipeline.Pipeline, error) {
 	pp, err := q.Pipelines.FindPublic(ctx, tc, pn)
 	if err != nil {
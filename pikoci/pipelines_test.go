@@ -13,7 +13,9 @@ import (
 	"github.com/xescugc/pikoci/pikoci/job"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/resource"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	"github.com/xescugc/pikoci/pikoci/sectype"
+	"github.com/xescugc/pikoci/pikoci/team"
 	"go.uber.org/mock/gomock"
 )
 
@@ -140,6 +142,33 @@ func TestDeletePipeline_InvalidCanonical(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestPausePipeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Pipelines.EXPECT().SetPaused(ctx, "main", "my-pipeline", true).Return(nil)
+	s.Pipelines.EXPECT().SetPaused(ctx, "main", "my-pipeline", false).Return(nil)
+
+	err := s.S.PausePipeline(ctx, "main", "my-pipeline")
+	require.NoError(t, err)
+
+	err = s.S.UnpausePipeline(ctx, "main", "my-pipeline")
+	require.NoError(t, err)
+}
+
+func TestPausePipeline_InvalidCanonical(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	err := s.S.PausePipeline(ctx, "INVALID", "my-pipeline")
+	require.Error(t, err)
+
+	err = s.S.UnpausePipeline(ctx, "main", "INVALID")
+	require.Error(t, err)
+}
+
 func TestCreatePipeline_OrderedPlanWithPut(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
@@ -317,171 +346,1004 @@ resource "cron" "timer" {
 }
 
 job "test" {
-  get "cron" "timer" { trigger = true }
-  task "echo" {
+  get "cron" "timer" { trigger = true }
+  task "echo" {
+    run "exec" {
+      path = "echo"
+      args = ["hello"]
+    }
+  }
+}
+`)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "conflict-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "both source and inline commands")
+}
+
+func TestCreatePipeline_NativeRunnerReserved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+runner_type "native" {
+  run {
+    path = "sh"
+    args = ["-c", "$args"]
+  }
+}
+
+job "test" {
+  task "echo" {
+    run "exec" {
+      path = "echo"
+      args = ["hello"]
+    }
+  }
+}
+`)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "native-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `runner_type "native" is reserved`)
+}
+
+func TestCreatePipeline_WithTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+resource "cron" "timer" {
+  check_interval = "@every 1h"
+}
+
+job "test" {
+  get "cron" "timer" {
+    trigger = true
+    timeout = "2m"
+  }
+  task "build" {
+    timeout = "10m"
+    run "exec" {
+      path = "echo"
+      args = ["building"]
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "timeout-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.Len(t, j.Plan, 2)
+			assert.Equal(t, 2*time.Minute, j.Plan[0].Timeout)
+			assert.Equal(t, 10*time.Minute, j.Plan[1].Timeout)
+			return uint32(1), nil
+		})
+	s.Resources.EXPECT().Create(ctx, "main", "timeout-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "timeout-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "timeout-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "timeout-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+}
+
+func TestCreatePipeline_InvalidTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+resource "cron" "timer" {
+  check_interval = "@every 1h"
+}
+
+job "test" {
+  get "cron" "timer" {
+    trigger = true
+  }
+  task "build" {
+    timeout = "invalid"
+    run "exec" {
+      path = "echo"
+      args = ["building"]
+    }
+  }
+}
+`)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "invalid-timeout-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid timeout")
+}
+
+func TestCreatePipeline_WithAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+resource "cron" "timer" {
+  check_interval = "@every 1h"
+}
+
+job "test" {
+  get "cron" "timer" {
+    trigger  = true
+    attempts = 3
+  }
+  task "build" {
+    attempts = 2
+    run "exec" {
+      path = "echo"
+      args = ["building"]
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "attempts-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.Len(t, j.Plan, 2)
+			assert.Equal(t, 3, j.Plan[0].Attempts)
+			assert.Equal(t, 2, j.Plan[1].Attempts)
+			return uint32(1), nil
+		})
+	s.Resources.EXPECT().Create(ctx, "main", "attempts-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "attempts-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "attempts-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "attempts-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+}
+
+func TestCreatePipeline_WithInputsOutputs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+resource "cron" "timer" {
+  check_interval = "@every 1h"
+}
+
+job "test" {
+  get "cron" "timer" {
+    trigger = true
+  }
+  task "build" {
+    inputs  = ["src/"]
+    outputs = ["bin/app"]
+    run "exec" {
+      path = "make"
+      args = ["build"]
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "inputs-outputs-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.Len(t, j.Plan, 2)
+			assert.Equal(t, []string{"src/"}, j.Plan[1].Task.Inputs)
+			assert.Equal(t, []string{"bin/app"}, j.Plan[1].Task.Outputs)
+			return uint32(1), nil
+		})
+	s.Resources.EXPECT().Create(ctx, "main", "inputs-outputs-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "inputs-outputs-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "inputs-outputs-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "inputs-outputs-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+}
+
+func TestCreatePipeline_WithoutInputsOutputs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+resource "cron" "timer" {
+  check_interval = "@every 1h"
+}
+
+job "test" {
+  get "cron" "timer" {
+    trigger = true
+  }
+  task "build" {
+    run "exec" {
+      path = "echo"
+      args = ["building"]
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "no-io-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.Len(t, j.Plan, 2)
+			assert.Nil(t, j.Plan[1].Task.Inputs)
+			assert.Nil(t, j.Plan[1].Task.Outputs)
+			return uint32(1), nil
+		})
+	s.Resources.EXPECT().Create(ctx, "main", "no-io-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "no-io-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "no-io-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "no-io-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+}
+
+func TestCreatePipeline_WithInputsFrom(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+job "build" {
+  task "compile" {
+    outputs = ["bin/app"]
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+
+job "deploy" {
+  task "ship" {
+    inputs_from = ["build.compile"]
+    run "exec" {
+      path = "bin/app"
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "inputs-from-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			if j.Name == "deploy" {
+				require.Len(t, j.Plan, 1)
+				assert.Equal(t, []string{"build.compile"}, j.Plan[0].Task.InputsFrom)
+			}
+			return uint32(1), nil
+		}).Times(2)
+	s.Pipelines.EXPECT().Find(ctx, "main", "inputs-from-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "inputs-from-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "inputs-from-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+}
+
+func TestCreatePipeline_InvalidInputsFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		inputsFrom string
+		err        string
+	}{
+		{name: "Format", inputsFrom: "build", err: `expected format "job.task"`},
+		{name: "OwnJob", inputsFrom: "deploy.ship", err: "cannot reference its own job"},
+		{name: "UnknownJob", inputsFrom: "test.compile", err: `references job "test" which does not exist`},
+		{name: "UnknownTask", inputsFrom: "build.lint", err: `references task "lint" which does not exist`},
+		{name: "NoOutputs", inputsFrom: "build.vet", err: `references task "vet" which has no outputs`},
+		{name: "Matrix", inputsFrom: "cross.compile", err: `references job "cross" which has a matrix`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := newService(ctrl)
+			ctx := context.TODO()
+
+			hclConfig := []byte(`
+job "build" {
+  task "compile" {
+    outputs = ["bin/app"]
+    run "exec" {
+      path = "make"
+    }
+  }
+  task "vet" {
+    run "exec" {
+      path = "go"
+    }
+  }
+}
+
+job "cross" {
+  matrix {
+    goos = ["linux", "darwin"]
+  }
+  task "compile" {
+    outputs = ["bin/app"]
+    run "exec" {
+      path = "make"
+      args = ["GOOS=${matrix.goos}"]
+    }
+  }
+}
+
+job "deploy" {
+  task "ship" {
+    inputs_from = ["` + tt.inputsFrom + `"]
+    run "exec" {
+      path = "bin/app"
+    }
+  }
+}
+`)
+
+			_, err := s.S.CreatePipeline(ctx, "main", "invalid-inputs-from-pipeline", hclConfig, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestCreatePipeline_WithCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+variable "go_version" {
+  type    = string
+  default = "1.25"
+}
+
+job "test" {
+  cache {
+    paths = [".gocache", "go/pkg/mod"]
+    key   = "go-${var.go_version}"
+  }
+  task "build" {
+    cache {
+      paths     = ["node_modules"]
+      key_files = ["repo/package-lock.json"]
+    }
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "cache-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.NotNil(t, j.Cache)
+			assert.Equal(t, &job.Cache{Paths: []string{".gocache", "go/pkg/mod"}, Key: "go-1.25"}, j.Cache)
+			require.Len(t, j.Plan, 1)
+			assert.Equal(t, &job.Cache{Paths: []string{"node_modules"}, KeyFiles: []string{"repo/package-lock.json"}}, j.Plan[0].Task.Cache)
+			return uint32(1), nil
+		})
+	s.Pipelines.EXPECT().Find(ctx, "main", "cache-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "cache-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "cache-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+}
+
+func TestCreatePipeline_InvalidCachePath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+job "test" {
+  cache {
+    paths = ["../outside"]
+  }
+  task "build" {
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "cache-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid cache path")
+}
+
+func TestCreatePipeline_InvalidCacheKeyFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+job "test" {
+  cache {
+    paths     = ["node_modules"]
+    key_files = ["/etc/passwd"]
+  }
+  task "build" {
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "cache-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid cache key file")
+}
+
+func TestCreatePipeline_InvalidTaskOutputs(t *testing.T) {
+	for _, o := range []string{"../../../../root/.ssh", "/etc", "bin/../../outside"} {
+		t.Run(o, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := newService(ctrl)
+			ctx := context.TODO()
+
+			hclConfig := []byte(fmt.Sprintf(`
+job "test" {
+  task "build" {
+    outputs = [%q]
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`, o))
+
+			_, err := s.S.CreatePipeline(ctx, "main", "outputs-pipeline", hclConfig, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), fmt.Sprintf(`task step "build": invalid output %q`, o))
+		})
+	}
+}
+
+func TestCreatePipeline_WithReports(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+job "test" {
+  task "unit" {
+    reports {
+      junit = ["reports/*.xml", "junit.xml"]
+    }
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "reports-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.Len(t, j.Plan, 1)
+			assert.Equal(t, &job.Reports{JUnit: []string{"reports/*.xml", "junit.xml"}}, j.Plan[0].Task.Reports)
+			return uint32(1), nil
+		})
+	s.Pipelines.EXPECT().Find(ctx, "main", "reports-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "reports-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "reports-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+
+	for _, junit := range []string{`[]`, `["../reports/*.xml"]`, `["reports/[.xml"]`} {
+		hclConfig := []byte(`
+job "test" {
+  task "unit" {
+    reports {
+      junit = ` + junit + `
+    }
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`)
+		_, err := s.S.CreatePipeline(ctx, "main", "reports-pipeline", hclConfig, nil)
+		require.Error(t, err, junit)
+		assert.Contains(t, err.Error(), "reports", junit)
+	}
+}
+
+func TestCreatePipeline_WithTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+resource "git" "repo" {
+  tags = ["internal"]
+  params {
+    url = "http://example.com"
+  }
+}
+
+job "test" {
+  tags = ["docker", "arm64"]
+  task "build" {
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "tags-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			assert.Equal(t, []string{"docker", "arm64"}, j.Tags)
+			return uint32(1), nil
+		})
+	s.Resources.EXPECT().Create(ctx, "main", "tags-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, r resource.Resource) (uint32, error) {
+			assert.Equal(t, []string{"internal"}, r.Tags)
+			return uint32(1), nil
+		})
+	s.Pipelines.EXPECT().Find(ctx, "main", "tags-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "tags-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "tags-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+}
+
+func TestCreatePipeline_InvalidTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+job "test" {
+  tags = ["Docker Host"]
+  task "build" {
+    run "exec" {
+      path = "make"
+    }
+  }
+}
+`)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "tags-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid tag format")
+}
+
+func TestCreatePipeline_WithSerialGroups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+job "deploy" {
+  serial_groups = ["staging"]
+  task "deploy" {
+    run "exec" {
+      path = "./deploy.sh"
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "locks-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			assert.Equal(t, []string{"staging"}, j.SerialGroups)
+			return uint32(1), nil
+		})
+	s.Pipelines.EXPECT().Find(ctx, "main", "locks-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "locks-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "locks-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+
+	_, err = s.S.CreatePipeline(ctx, "main", "locks-pipeline", []byte(`
+job "deploy" {
+  serial_groups = ["Staging Env"]
+  task "deploy" {
+    run "exec" {
+      path = "./deploy.sh"
+    }
+  }
+}
+`), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid serial group format")
+}
+
+func TestCreatePipeline_WithMatrix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+variable "dbs" {
+  type    = "string"
+  default = "mysql"
+}
+
+job "test" {
+  matrix {
+    go = ["1.24", "1.25"]
+    db = [var.dbs, "postgresql"]
+  }
+  cache {
+    paths = [".gocache"]
+    key   = "go-${matrix.go}"
+  }
+  task "test" {
+    run "exec" {
+      path = "go"
+      args = ["test", "-tags", matrix.db]
+    }
+    ensure "exec" {
+      args = ["echo", "${matrix.go}"]
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "matrix-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.Len(t, j.Matrix, 4)
+			var names []string
+			for _, c := range j.Matrix {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, []string{"go=1.24,db=mysql", "go=1.24,db=postgresql", "go=1.25,db=mysql", "go=1.25,db=postgresql"}, names)
+
+			c := j.Matrix[3]
+			assert.Equal(t, map[string]string{"go": "1.25", "db": "postgresql"}, c.Values)
+			assert.Equal(t, &job.Cache{Paths: []string{".gocache"}, Key: "go-1.25"}, c.Cache)
+			require.Len(t, c.Plan, 1)
+			assert.Equal(t, []string{"test", "-tags", "postgresql"}, c.Plan[0].Task.Run.Args)
+			require.Len(t, c.Plan[0].Ensure, 1)
+			assert.Equal(t, []string{"echo", "1.25"}, c.Plan[0].Ensure[0].Runner.Args)
+
+			// The Job has the plan of the first cell
+			assert.Equal(t, j.Matrix[0].Plan, j.Plan)
+			assert.Equal(t, j.Matrix[0].Cache, j.Cache)
+			return uint32(1), nil
+		})
+	s.Pipelines.EXPECT().Find(ctx, "main", "matrix-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "matrix-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "matrix-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "Empty",
+			config: `matrix {}`,
+			err:    "matrix must have at least one value",
+		},
+		{
+			name:   "EmptyAxis",
+			config: `matrix { go = [] }`,
+			err:    `matrix "go" can not be empty`,
+		},
+		{
+			name:   "Duplicated",
+			config: `matrix { go = ["1.25", "1.25"] }`,
+			err:    `matrix "go" has the value "1.25" more than once`,
+		},
+		{
+			name: "TooManyCombinations",
+			config: `matrix {
+    a = ["1", "2", "3", "4", "5", "6", "7", "8"]
+    b = ["1", "2", "3", "4", "5", "6", "7", "8", "9"]
+  }`,
+			err: "matrix has more than 64 combinations",
+		},
+		{
+			name:   "UnknownAxis",
+			config: `matrix { go = ["1.25"] }`,
+			err:    `failed to decode matrix cell "go=1.25"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.S.CreatePipeline(ctx, "main", "matrix-pipeline", []byte(`
+job "test" {
+  `+tt.config+`
+  task "test" {
+    run "exec" {
+      path = "go"
+      args = ["test", matrix.db]
+    }
+  }
+}
+job "other" {
+  matrix { db = ["mysql"] }
+  task "test" {
+    run "exec" {
+      path = "go"
+    }
+  }
+}
+`), nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestCreatePipeline_WithWhen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	hclConfig := []byte(`
+variable "deploy" {
+  type    = "bool"
+  default = true
+}
+
+variable "env" {
+  type    = "string"
+  default = "staging"
+}
+
+resource_type "notify" {
+  push "exec" {
+    path = "/bin/sh"
+    args = ["-ec", "echo notifying"]
+  }
+}
+
+resource "notify" "slack" {
+}
+
+resource "cron" "timer" {
+  check_interval = "@every 1h"
+}
+
+job "deploy" {
+  get "cron" "timer" {
+    trigger = true
+    when    = BUILD_NUMBER != "0"
+  }
+  task "deploy" {
+    when = var.deploy && version.ref == "main"
+    run "exec" {
+      path = "echo"
+      args = [var.env]
+    }
+    on_failure "exec" {
+      when = var.env == "production"
+      args = ["echo", "failed"]
+    }
+  }
+  in_parallel {
+    when = steps.deploy.status == "skipped"
+    task "lint" {
+      run "exec" {
+        path = "echo"
+      }
+    }
+  }
+  put "notify" "slack" {
+    message = "deployed"
+    when    = var.env != "staging"
+    ensure {
+      put "notify" "slack" {
+        message = "done"
+        when    = BUILD_STATUS == "failed"
+      }
+    }
+  }
+}
+`)
+
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Jobs.EXPECT().Create(ctx, "main", "when-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
+			require.Len(t, j.Plan, 4)
+
+			assert.Equal(t, &job.When{Expr: `BUILD_NUMBER != "0"`}, j.Plan[0].When)
+			assert.Equal(t, &job.When{
+				Expr: `var.deploy && version.ref == "main"`,
+				Vars: map[string]interface{}{"var.deploy": true},
+			}, j.Plan[1].When)
+			require.Len(t, j.Plan[1].OnFailure, 1)
+			assert.Equal(t, &job.When{
+				Expr: `var.env == "production"`,
+				Vars: map[string]interface{}{"var.env": "staging"},
+			}, j.Plan[1].OnFailure[0].When)
+			assert.Empty(t, j.Plan[1].OnFailure[0].Runner.Params)
+
+			assert.Equal(t, &job.When{Expr: `steps.deploy.status == "skipped"`}, j.Plan[2].When)
+
+			assert.Equal(t, map[string]string{"message": "deployed"}, j.Plan[3].Put.Params)
+			assert.Equal(t, "var.env != \"staging\"", j.Plan[3].When.Expr)
+			require.Len(t, j.Plan[3].Ensure, 1)
+			assert.Equal(t, map[string]string{"message": "done"}, j.Plan[3].Ensure[0].Put.Params)
+			assert.Equal(t, &job.When{Expr: `BUILD_STATUS == "failed"`}, j.Plan[3].Ensure[0].When)
+			return uint32(1), nil
+		})
+	s.Resources.EXPECT().Create(ctx, "main", "when-pipeline", gomock.Any()).Return(uint32(1), nil).Times(2)
+	s.ResourceTypes.EXPECT().Create(ctx, "main", "when-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "when-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "when-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "when-pipeline", hclConfig, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		when string
+		err  string
+	}{
+		{
+			name: "UnknownVariable",
+			when: `branch == "main"`,
+			err:  "Unknown variable",
+		},
+		{
+			name: "UnknownVar",
+			when: `var.missing`,
+			err:  "Unsupported attribute",
+		},
+		{
+			name: "WholeVar",
+			when: `length(var) > 0`,
+			err:  `"var" can only be used with one of its attributes`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.S.CreatePipeline(ctx, "main", "when-pipeline", []byte(`
+job "test" {
+  task "test" {
+    when = `+tt.when+`
     run "exec" {
       path = "echo"
-      args = ["hello"]
     }
   }
 }
-`)
-
-	_, err := s.S.CreatePipeline(ctx, "main", "conflict-pipeline", hclConfig, nil)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "both source and inline commands")
+`), nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), `task step "test"`)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
 }
 
-func TestCreatePipeline_WithTimeout(t *testing.T) {
+func TestCreatePipeline_WithStepOutputs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
 
 	hclConfig := []byte(`
-resource "cron" "timer" {
-  check_interval = "@every 1h"
+resource_type "notify" {
+  push "exec" {
+    path = "/bin/sh"
+    args = ["-ec", "echo notifying"]
+  }
 }
 
-job "test" {
-  get "cron" "timer" {
-    trigger = true
-    timeout = "2m"
+resource "notify" "slack" {
+}
+
+job "deploy" {
+  task "deploy" {
+    run "exec" {
+      path = "sh"
+      args = ["-c", "echo url=http://example.com >> $PIKOCI_OUTPUT"]
+    }
   }
-  task "build" {
-    timeout = "10m"
+  task "check" {
     run "exec" {
-      path = "echo"
-      args = ["building"]
+      path = "curl"
+      args = ["${steps.deploy.outputs.url}/health"]
     }
   }
+  put "notify" "slack" {
+    details_url = steps["deploy"].outputs.url
+  }
 }
 `)
 
 	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
-	s.Jobs.EXPECT().Create(ctx, "main", "timeout-pipeline", gomock.Any()).DoAndReturn(
+	s.Jobs.EXPECT().Create(ctx, "main", "outputs-pipeline", gomock.Any()).DoAndReturn(
 		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
-			require.Len(t, j.Plan, 2)
-			assert.Equal(t, 2*time.Minute, j.Plan[0].Timeout)
-			assert.Equal(t, 10*time.Minute, j.Plan[1].Timeout)
+			require.Len(t, j.Plan, 3)
+			assert.Equal(t, []string{"__pikoci_output:deploy:url__/health"}, j.Plan[1].Task.Run.Args)
+			assert.Equal(t, map[string]string{"details_url": "__pikoci_output:deploy:url__"}, j.Plan[2].Put.Params)
 			return uint32(1), nil
 		})
-	s.Resources.EXPECT().Create(ctx, "main", "timeout-pipeline", gomock.Any()).Return(uint32(1), nil)
-	s.Pipelines.EXPECT().Find(ctx, "main", "timeout-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "timeout-pipeline"}, nil)
+	s.Resources.EXPECT().Create(ctx, "main", "outputs-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.ResourceTypes.EXPECT().Create(ctx, "main", "outputs-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "outputs-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "outputs-pipeline"}, nil)
 
-	_, err := s.S.CreatePipeline(ctx, "main", "timeout-pipeline", hclConfig, nil)
+	_, err := s.S.CreatePipeline(ctx, "main", "outputs-pipeline", hclConfig, nil)
 	require.NoError(t, err)
 }
 
-func TestCreatePipeline_InvalidTimeout(t *testing.T) {
+func TestCreatePipeline_ResourceWebhook(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
 
 	hclConfig := []byte(`
-resource "cron" "timer" {
-  check_interval = "@every 1h"
-}
-
-job "test" {
-  get "cron" "timer" {
-    trigger = true
+resource "git" "repo" {
+  params {
+    url = "http://example.com"
   }
-  task "build" {
-    timeout = "invalid"
-    run "exec" {
-      path = "echo"
-      args = ["building"]
-    }
+  webhook {
+    type   = "github"
+    secret = "my-secret"
   }
 }
 `)
 
-	_, err := s.S.CreatePipeline(ctx, "main", "invalid-timeout-pipeline", hclConfig, nil)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid timeout")
+	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
+	s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+	s.Resources.EXPECT().Create(ctx, "main", "webhook-pipeline", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, tc, pn string, r resource.Resource) (uint32, error) {
+			assert.Equal(t, resource.WebhookTypeGitHub, r.Webhook.Type)
+			assert.Empty(t, r.Webhook.Secret)
+			assert.Equal(t, "test", r.Webhook.KeyID)
+
+			ws := secret.Secret{TeamID: 1, Name: "webhook:webhook-pipeline:git.repo", KeyID: r.Webhook.KeyID, Data: r.Webhook.Data}
+			require.NoError(t, s.P.SecretKeys.Decrypt(&ws))
+			assert.Equal(t, "my-secret", ws.Value)
+			return uint32(1), nil
+		})
+	s.Pipelines.EXPECT().Find(ctx, "main", "webhook-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "webhook-pipeline"}, nil)
+
+	_, err := s.S.CreatePipeline(ctx, "main", "webhook-pipeline", hclConfig, nil)
+	require.NoError(t, err)
 }
 
-func TestCreatePipeline_WithAttempts(t *testing.T) {
+func TestCreatePipeline_ResourceWebhookSecretPlaceholder(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
 
 	hclConfig := []byte(`
-resource "cron" "timer" {
-  check_interval = "@every 1h"
-}
-
-job "test" {
-  get "cron" "timer" {
-    trigger  = true
-    attempts = 3
+resource "git" "repo" {
+  params {
+    url = "http://example.com"
   }
-  task "build" {
-    attempts = 2
-    run "exec" {
-      path = "echo"
-      args = ["building"]
-    }
+  webhook {
+    type   = "github"
+    secret = "__pikoci_secret:pikoci:webhook::__"
   }
 }
 `)
 
 	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
-	s.Jobs.EXPECT().Create(ctx, "main", "attempts-pipeline", gomock.Any()).DoAndReturn(
-		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
-			require.Len(t, j.Plan, 2)
-			assert.Equal(t, 3, j.Plan[0].Attempts)
-			assert.Equal(t, 2, j.Plan[1].Attempts)
-			return uint32(1), nil
-		})
-	s.Resources.EXPECT().Create(ctx, "main", "attempts-pipeline", gomock.Any()).Return(uint32(1), nil)
-	s.Pipelines.EXPECT().Find(ctx, "main", "attempts-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "attempts-pipeline"}, nil)
 
-	_, err := s.S.CreatePipeline(ctx, "main", "attempts-pipeline", hclConfig, nil)
-	require.NoError(t, err)
+	_, err := s.S.CreatePipeline(ctx, "main", "webhook-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook secret can't be a secret-backed variable")
 }
 
-func TestCreatePipeline_WithInputsOutputs(t *testing.T) {
+func TestCreatePipeline_InvalidResourceWebhook(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
 
 	hclConfig := []byte(`
-resource "cron" "timer" {
-  check_interval = "@every 1h"
-}
-
-job "test" {
-  get "cron" "timer" {
-    trigger = true
+resource "git" "repo" {
+  params {
+    url = "http://example.com"
   }
-  task "build" {
-    inputs  = ["src/"]
-    outputs = ["bin/app"]
-    run "exec" {
-      path = "make"
-      args = ["build"]
-    }
+  webhook {
+    type   = "bitbucket"
+    secret = "my-secret"
   }
 }
 `)
 
 	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
-	s.Jobs.EXPECT().Create(ctx, "main", "inputs-outputs-pipeline", gomock.Any()).DoAndReturn(
-		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
-			require.Len(t, j.Plan, 2)
-			assert.Equal(t, []string{"src/"}, j.Plan[1].Task.Inputs)
-			assert.Equal(t, []string{"bin/app"}, j.Plan[1].Task.Outputs)
-			return uint32(1), nil
-		})
-	s.Resources.EXPECT().Create(ctx, "main", "inputs-outputs-pipeline", gomock.Any()).Return(uint32(1), nil)
-	s.Pipelines.EXPECT().Find(ctx, "main", "inputs-outputs-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "inputs-outputs-pipeline"}, nil)
 
-	_, err := s.S.CreatePipeline(ctx, "main", "inputs-outputs-pipeline", hclConfig, nil)
-	require.NoError(t, err)
+	_, err := s.S.CreatePipeline(ctx, "main", "webhook-pipeline", hclConfig, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid webhook")
 }
 
-func TestCreatePipeline_WithoutInputsOutputs(t *testing.T) {
+func TestCreatePipeline_InParallel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
@@ -495,30 +1357,132 @@ job "test" {
   get "cron" "timer" {
     trigger = true
   }
-  task "build" {
-    run "exec" {
+  in_parallel {
+    limit     = 2
+    fail_fast = true
+
+    task "lint" {
+      run "exec" {
+        path = "make"
+        args = ["lint"]
+      }
+    }
+    task "unit" {
+      attempts = 2
+      run "exec" {
+        path = "make"
+        args = ["test"]
+      }
+    }
+    put "cron" "timer" {
+    }
+
+    on_failure "exec" {
       path = "echo"
-      args = ["building"]
+      args = ["failed"]
+    }
+  }
+  task "package" {
+    run "exec" {
+      path = "make"
+      args = ["package"]
     }
   }
 }
 `)
 
 	s.Pipelines.EXPECT().Create(ctx, "main", gomock.Any()).Return(uint32(1), nil)
-	s.Jobs.EXPECT().Create(ctx, "main", "no-io-pipeline", gomock.Any()).DoAndReturn(
+	s.Jobs.EXPECT().Create(ctx, "main", "parallel-pipeline", gomock.Any()).DoAndReturn(
 		func(ctx context.Context, tc, pn string, j job.Job) (uint32, error) {
-			require.Len(t, j.Plan, 2)
-			assert.Nil(t, j.Plan[1].Task.Inputs)
-			assert.Nil(t, j.Plan[1].Task.Outputs)
+			require.Len(t, j.Plan, 3)
+			assert.Equal(t, job.StepTypeGet, j.Plan[0].Type)
+			assert.Equal(t, job.StepTypeParallel, j.Plan[1].Type)
+			assert.Equal(t, job.StepTypeTask, j.Plan[2].Type)
+			assert.Equal(t, "package", j.Plan[2].Task.Name)
+
+			p := j.Plan[1].Parallel
+			require.NotNil(t, p)
+			assert.Equal(t, 2, p.Limit)
+			assert.True(t, p.FailFast)
+			require.Len(t, p.Steps, 3)
+			assert.Equal(t, "lint", p.Steps[0].Task.Name)
+			assert.Equal(t, "unit", p.Steps[1].Task.Name)
+			assert.Equal(t, 2, p.Steps[1].Attempts)
+			assert.Equal(t, job.StepTypePut, p.Steps[2].Type)
+			assert.Equal(t, "timer", p.Steps[2].Put.Name)
+
+			require.Len(t, j.Plan[1].OnFailure, 1)
+			assert.Equal(t, "exec", j.Plan[1].OnFailure[0].Runner.Runner)
+
+			assert.Len(t, j.TaskSteps(), 3)
+			assert.Len(t, j.AllPutSteps(), 1)
 			return uint32(1), nil
 		})
-	s.Resources.EXPECT().Create(ctx, "main", "no-io-pipeline", gomock.Any()).Return(uint32(1), nil)
-	s.Pipelines.EXPECT().Find(ctx, "main", "no-io-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "no-io-pipeline"}, nil)
+	s.Resources.EXPECT().Create(ctx, "main", "parallel-pipeline", gomock.Any()).Return(uint32(1), nil)
+	s.Pipelines.EXPECT().Find(ctx, "main", "parallel-pipeline").Return(&pipeline.Pipeline{ID: 1, Name: "parallel-pipeline"}, nil)
 
-	_, err := s.S.CreatePipeline(ctx, "main", "no-io-pipeline", hclConfig, nil)
+	_, err := s.S.CreatePipeline(ctx, "main", "parallel-pipeline", hclConfig, nil)
 	require.NoError(t, err)
 }
 
+func TestCreatePipeline_InParallelInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		block string
+		err   string
+	}{
+		{
+			name: "Empty",
+			block: `in_parallel {
+  }`,
+			err: "in_parallel must have at least one step",
+		},
+		{
+			name: "InvalidLimit",
+			block: `in_parallel {
+    limit = -1
+    task "lint" {
+      run "exec" {
+        path = "make"
+      }
+    }
+  }`,
+			err: "invalid limit -1 on in_parallel",
+		},
+		{
+			name: "Nested",
+			block: `in_parallel {
+    in_parallel {
+      task "lint" {
+        run "exec" {
+          path = "make"
+        }
+      }
+    }
+  }`,
+			err: `in_parallel only supports get, task and put steps, found "in_parallel"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := newService(ctrl)
+			ctx := context.TODO()
+
+			hclConfig := []byte(`
+job "test" {
+  ` + tt.block + `
+}
+`)
+
+			_, err := s.S.CreatePipeline(ctx, "main", "parallel-pipeline", hclConfig, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
 func TestCreatePipeline_InvalidAttempts(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
@@ -1359,3 +2323,112 @@ func TestGetPipelineImage_ShowsLinkedResources(t *testing.T) {
 	assert.True(t, strings.Contains(dot, `"cron.timer"`), "first linked resource should appear")
 	assert.True(t, strings.Contains(dot, `"git.repo"`), "second linked resource should appear")
 }
+
+func TestGetPipelineImage_ShowsPausedJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	pp := &pipeline.Pipeline{
+		Name: "my-pipeline",
+		Resources: []resource.Resource{
+			{ID: 1, Canonical: "cron.timer"},
+		},
+		Jobs: []job.Job{
+			{
+				ID:     1,
+				Name:   "build",
+				Paused: true,
+				Plan: []job.PlanStep{
+					{
+						Type: job.StepTypeGet,
+						Get:  &job.GetStep{Type: "cron", Name: "timer", Trigger: true},
+					},
+				},
+			},
+			{
+				ID:   2,
+				Name: "test",
+				Plan: []job.PlanStep{
+					{
+						Type: job.StepTypeGet,
+						Get:  &job.GetStep{Type: "cron", Name: "timer", Trigger: true},
+					},
+				},
+			},
+		},
+	}
+
+	s.Pipelines.EXPECT().Find(ctx, "main", "my-pipeline").Return(pp, nil).Times(2)
+	s.Builds.EXPECT().Filter(ctx, "main", "my-pipeline", "build").Return([]*build.Build{}, nil).Times(2)
+	s.Builds.EXPECT().Filter(ctx, "main", "my-pipeline", "test").Return([]*build.Build{}, nil).Times(2)
+
+	img, err := s.S.GetPipelineImage(ctx, "main", "my-pipeline", "dot")
+	require.NoError(t, err)
+
+	dot := string(img)
+	assert.Contains(t, dot, `"build (paused)"`)
+	assert.NotContains(t, dot, `"test (paused)"`)
+
+	// All the jobs of a paused pipeline are shown as paused
+	pp.Paused = true
+	img, err = s.S.GetPipelineImage(ctx, "main", "my-pipeline", "dot")
+	require.NoError(t, err)
+
+	dot = string(img)
+	assert.Contains(t, dot, `"build (paused)"`)
+	assert.Contains(t, dot, `"test (paused)"`)
+}
+
+func TestGetPipelineImage_ShowsPinnedResources(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	pp := &pipeline.Pipeline{
+		Name: "my-pipeline",
+		Resources: []resource.Resource{
+			{ID: 1, Canonical: "cron.timer"},
+			{ID: 2, Canonical: "git.repo", PinnedVersionID: 7},
+		},
+		Jobs: []job.Job{
+			{
+				ID:   1,
+				Name: "build",
+				Plan: []job.PlanStep{
+					{
+						Type: job.StepTypeGet,
+						Get:  &job.GetStep{Type: "cron", Name: "timer", Trigger: true},
+					},
+					{
+						Type: job.StepTypeGet,
+						Get:  &job.GetStep{Type: "git", Name: "repo", Trigger: true},
+					},
+				},
+			},
+			{
+				ID:   2,
+				Name: "test",
+				Plan: []job.PlanStep{
+					{
+						Type: job.StepTypeGet,
+						Get:  &job.GetStep{Type: "git", Name: "repo", Passed: []string{"build"}},
+					},
+				},
+			},
+		},
+	}
+
+	s.Pipelines.EXPECT().Find(ctx, "main", "my-pipeline").Return(pp, nil)
+	s.Builds.EXPECT().Filter(ctx, "main", "my-pipeline", "build").Return([]*build.Build{}, nil)
+	s.Builds.EXPECT().Filter(ctx, "main", "my-pipeline", "test").Return([]*build.Build{}, nil)
+
+	img, err := s.S.GetPipelineImage(ctx, "main", "my-pipeline", "dot")
+	require.NoError(t, err)
+
+	dot := string(img)
+	assert.NotContains(t, dot, `"cron.timer (pinned)"`)
+	// The resource node and the passed node are both marked
+	assert.Equal(t, 2, strings.Count(dot, `"git.repo (pinned)"`))
+	assert.Contains(t, dot, `"#FFA300"`)
+}
@@ -0,0 +1,250 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gocloud.dev/pubsub"
+)
+
+// ErrLeaseNotFound is returned when the lease does not exist or
+// already expired, so its work was sent again to the queue
+var ErrLeaseNotFound = errors.New("work lease not found")
+
+// Lease is the work claimed by a worker from the Dispatcher, it has to be
+// renewed before it expires or the work is sent again to the queue
+type Lease struct {
+	ID        string        `json:"id"`
+	WorkerID  uint32        `json:"worker_id"`
+	Body      []byte        `json:"body"`
+	TTL       time.Duration `json:"ttl"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+// LeaseRepository stores the Leases of the Dispatcher, so the work
+// claimed by the workers is not lost when the server restarts
+type LeaseRepository interface {
+	Create(ctx context.Context, l Lease) error
+	// Find returns ErrLeaseNotFound if the worker has no lease id
+	Find(ctx context.Context, workerID uint32, id string) (*Lease, error)
+	// UpdateExpiresAt returns ErrLeaseNotFound if the worker has no lease id
+	UpdateExpiresAt(ctx context.Context, workerID uint32, id string, expiresAt time.Time) error
+	// Delete returns ErrLeaseNotFound if the worker has no lease id
+	Delete(ctx context.Context, workerID uint32, id string) error
+	FilterExpired(ctx context.Context, before time.Time) ([]*Lease, error)
+	// DeleteExpired returns ErrLeaseNotFound if the lease id does
+	// not exist or it does not expire before, as it was renewed
+	DeleteExpired(ctx context.Context, id string, before time.Time) error
+}
+
+// pollTimeout is the time a Claim, or a Receive of a multi subscription,
+// waits on the subscription of each one of the tags keys before trying
+// the next one
+const pollTimeout = 250 * time.Millisecond
+
+// Dispatcher hands out the messages of the queue to the workers that
+// claim them, so they do not need access to the PubSub system
+type Dispatcher struct {
+	topic     Topic
+	subscribe func(ctx context.Context, key string) (Subscription, error)
+	leases    LeaseRepository
+	ttl       time.Duration
+
+	mu   sync.Mutex
+	subs map[string]Subscription
+}
+
+// NewDispatcher returns a Dispatcher that receives the messages from the
+// subscriptions opened with subscribe for each tags key, and sends the
+// ones of the expired leases to t. The leases are stored on leases and
+// expire after ttl.
+func NewDispatcher(t Topic, subscribe func(ctx context.Context, key string) (Subscription, error), leases LeaseRepository, ttl time.Duration) *Dispatcher {
+	return &Dispatcher{
+		topic:     t,
+		subscribe: subscribe,
+		leases:    leases,
+		ttl:       ttl,
+		subs:      make(map[string]Subscription),
+	}
+}
+
+// TTL returns the time the leases last without being renewed
+func (d *Dispatcher) TTL() time.Duration {
+	return d.ttl
+}
+
+// subscriptions returns the subscriptions with all the messages a
+// worker with the tags can receive, opening them if needed. The
+// subscription of each key is shared by all the workers so each
+// message is only received once.
+func (d *Dispatcher) subscriptions(ctx context.Context, tags []string) ([]Subscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var subs []Subscription
+	for _, k := range TagsKeys(tags) {
+		s, ok := d.subs[k]
+		if !ok {
+			var err error
+			s, err = d.subscribe(ctx, k)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open Subscription %q: %w", k, err)
+			}
+			d.subs[k] = s
+		}
+		subs = append(subs, s)
+	}
+
+	return subs, nil
+}
+
+// Open opens the subscriptions of the workers with the tags before they
+// claim any work, as some PubSub systems, like mem, drop the messages
+// sent to topics with no subscriptions
+func (d *Dispatcher) Open(ctx context.Context, tags []string) error {
+	_, err := d.subscriptions(ctx, tags)
+	return err
+}
+
+// Claim waits up to wait for a message a worker with the tags can
+// receive and returns its lease, or nil if there was none
+func (d *Dispatcher) Claim(ctx context.Context, workerID uint32, tags []string, wait time.Duration) (*Lease, error) {
+	subs, err := d.subscriptions(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one subscription is received from at a time, so
+	// the claim never takes more than one message
+	timeout := wait
+	if len(subs) > 1 {
+		timeout = pollTimeout
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		for _, s := range subs {
+			m, err := receive(ctx, s, timeout)
+			if err != nil {
+				return nil, err
+			} else if m == nil {
+				continue
+			}
+			return d.lease(ctx, workerID, m)
+		}
+		if !time.Now().Before(deadline) {
+			return nil, nil
+		}
+	}
+}
+
+// receive returns the next message of s, or nil
+// if there is none after the timeout
+func receive(ctx context.Context, s Subscription, timeout time.Duration) (*pubsub.Message, error) {
+	rctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	m, err := s.Receive(rctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else if rctx.Err() != nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to Receive message: %w", err)
+	}
+	return m, nil
+}
+
+// lease stores the lease of the worker that now owns the message m
+// and acks it, if it can't be stored the message is nacked
+func (d *Dispatcher) lease(ctx context.Context, workerID uint32, m *pubsub.Message) (*Lease, error) {
+	l := Lease{
+		ID:        uuid.NewString(),
+		WorkerID:  workerID,
+		Body:      m.Body,
+		TTL:       d.ttl,
+		ExpiresAt: time.Now().UTC().Add(d.ttl),
+	}
+
+	if err := d.leases.Create(ctx, l); err != nil {
+		if m.Nackable() {
+			m.Nack()
+		}
+		return nil, fmt.Errorf("failed to Create Lease: %w", err)
+	}
+
+	// If the lease expires the message is sent again to the queue
+	m.Ack()
+
+	return &l, nil
+}
+
+// Renew extends the lease id of the worker for another TTL
+func (d *Dispatcher) Renew(ctx context.Context, workerID uint32, id string) (*Lease, error) {
+	err := d.leases.UpdateExpiresAt(ctx, workerID, id, time.Now().UTC().Add(d.ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := d.leases.Find(ctx, workerID, id)
+	if err != nil {
+		return nil, err
+	}
+	l.TTL = d.ttl
+
+	return l, nil
+}
+
+// Complete removes the lease id of the worker as its work is done
+func (d *Dispatcher) Complete(ctx context.Context, workerID uint32, id string) error {
+	return d.leases.Delete(ctx, workerID, id)
+}
+
+// Expire removes the leases expired before now and sends their work
+// again to the queue, returning the expired leases
+func (d *Dispatcher) Expire(ctx context.Context, now time.Time) ([]*Lease, error) {
+	ls, err := d.leases.FilterExpired(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Filter expired Leases: %w", err)
+	}
+
+	var (
+		expired []*Lease
+		errs    []error
+	)
+	for _, l := range ls {
+		// The lease could have been renewed or expired by
+		// another server since it was filtered
+		if err := d.leases.DeleteExpired(ctx, l.ID, now); errors.Is(err, ErrLeaseNotFound) {
+			continue
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("failed to Delete Lease %q: %w", l.ID, err))
+			continue
+		}
+		l.TTL = d.ttl
+		expired = append(expired, l)
+		if err := d.topic.Send(ctx, &pubsub.Message{Body: l.Body}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to Send the work of Lease %q: %w", l.ID, err))
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// Shutdown shuts down all the opened subscriptions
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []error
+	for k, s := range d.subs {
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to Shutdown Subscription %q: %w", k, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,157 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/mysql"
+	"github.com/xescugc/pikoci/pikoci/mysql/migrate"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/mempubsub"
+)
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	topics := make(map[string]*pubsub.Topic)
+	r := queue.NewRouter(func(_ context.Context, key string) (queue.Topic, error) {
+		tp := mempubsub.NewTopic()
+		topics[key] = tp
+		return tp, nil
+	})
+	defer r.Shutdown(ctx)
+
+	db, err := mysql.New("", 0, "", "", mysql.Options{
+		MultiStatements: true,
+		ClientFoundRows: true,
+		System:          mysql.Mem,
+	})
+	require.NoError(t, err)
+	require.NoError(t, migrate.Migrate(db, mysql.Mem))
+	leases := mysql.NewLeaseRepository(db)
+
+	subscribe := func(ctx context.Context, key string) (queue.Subscription, error) {
+		if _, err := r.Topic(ctx, key); err != nil {
+			return nil, err
+		}
+		return mempubsub.NewSubscription(topics[key], time.Minute), nil
+	}
+	d := queue.NewDispatcher(r, subscribe, leases, time.Minute)
+	defer d.Shutdown(ctx)
+
+	send := func(b queue.Body) {
+		mb, err := json.Marshal(b)
+		require.NoError(t, err)
+		require.NoError(t, r.Send(ctx, &pubsub.Message{Body: mb}))
+	}
+	claim := func(workerID uint32, tags []string) *queue.Lease {
+		l, err := d.Claim(ctx, workerID, tags, 500*time.Millisecond)
+		require.NoError(t, err)
+		return l
+	}
+	jobName := func(l *queue.Lease) string {
+		var b queue.Body
+		require.NoError(t, json.Unmarshal(l.Body, &b))
+		return b.JobName
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Nil(t, claim(1, nil))
+	})
+	t.Run("ClaimRenewComplete", func(t *testing.T) {
+		send(queue.Body{JobName: "jn"})
+
+		l := claim(1, nil)
+		require.NotNil(t, l)
+		assert.Equal(t, "jn", jobName(l))
+		assert.Equal(t, uint32(1), l.WorkerID)
+		assert.Equal(t, time.Minute, l.TTL)
+
+		// Only the worker with the lease can renew or complete it
+		_, err := d.Renew(ctx, 2, l.ID)
+		assert.ErrorIs(t, err, queue.ErrLeaseNotFound)
+
+		rl, err := d.Renew(ctx, 1, l.ID)
+		require.NoError(t, err)
+		assert.False(t, rl.ExpiresAt.Before(l.ExpiresAt))
+		assert.Equal(t, l.Body, rl.Body)
+		assert.Equal(t, time.Minute, rl.TTL)
+
+		require.NoError(t, d.Complete(ctx, 1, l.ID))
+		assert.ErrorIs(t, d.Complete(ctx, 1, l.ID), queue.ErrLeaseNotFound)
+
+		// Completed leases never expire
+		expired, err := d.Expire(ctx, time.Now().UTC().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, expired)
+	})
+	t.Run("Tags", func(t *testing.T) {
+		// The mem subscriptions are opened on the first claim and
+		// only receive the messages sent after that
+		assert.Nil(t, claim(2, []string{"arm64", "docker"}))
+
+		send(queue.Body{JobName: "docker", Tags: []string{"docker"}})
+
+		assert.Nil(t, claim(1, nil))
+
+		l := claim(2, []string{"arm64", "docker"})
+		require.NotNil(t, l)
+		assert.Equal(t, "docker", jobName(l))
+		require.NoError(t, d.Complete(ctx, 2, l.ID))
+	})
+	t.Run("Expire", func(t *testing.T) {
+		send(queue.Body{JobName: "expire"})
+
+		l := claim(1, nil)
+		require.NotNil(t, l)
+
+		expired, err := d.Expire(ctx, time.Now().UTC())
+		require.NoError(t, err)
+		assert.Empty(t, expired)
+
+		expired, err = d.Expire(ctx, l.ExpiresAt.Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+		assert.Equal(t, l.ID, expired[0].ID)
+
+		_, err = d.Renew(ctx, 1, l.ID)
+		assert.ErrorIs(t, err, queue.ErrLeaseNotFound)
+
+		// The work of the expired lease is sent again to the queue
+		nl := claim(2, nil)
+		require.NotNil(t, nl)
+		assert.Equal(t, "expire", jobName(nl))
+		assert.NotEqual(t, l.ID, nl.ID)
+		require.NoError(t, d.Complete(ctx, 2, nl.ID))
+	})
+	t.Run("Restart", func(t *testing.T) {
+		send(queue.Body{JobName: "restart"})
+
+		l := claim(1, nil)
+		require.NotNil(t, l)
+
+		// The leases are kept by the Dispatcher of the restarted server
+		nd := queue.NewDispatcher(r, subscribe, leases, time.Minute)
+		defer nd.Shutdown(ctx)
+		require.NoError(t, nd.Open(ctx, nil))
+
+		_, err := nd.Renew(ctx, 1, l.ID)
+		require.NoError(t, err)
+
+		expired, err := nd.Expire(ctx, time.Now().UTC().Add(2*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+		assert.Equal(t, l.ID, expired[0].ID)
+
+		nl, err := nd.Claim(ctx, 2, nil, 500*time.Millisecond)
+		require.NoError(t, err)
+		require.NotNil(t, nl)
+		assert.Equal(t, "restart", jobName(nl))
+		require.NoError(t, nd.Complete(ctx, 2, nl.ID))
+	})
+}
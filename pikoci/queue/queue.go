@@ -8,6 +8,7 @@ import (
 
 //go:generate go tool mockgen -destination=../mock/topic.go -mock_names=Topic=Topic -package mock github.com/xescugc/pikoci/pikoci/queue Topic
 //go:generate go tool mockgen -destination=../mock/subscription.go -mock_names=Subscription=Subscription -package mock github.com/xescugc/pikoci/pikoci/queue Subscription
+//go:generate go tool mockgen -destination=../mock/lease_repository.go -mock_names=LeaseRepository=LeaseRepository -package mock github.com/xescugc/pikoci/pikoci/queue LeaseRepository
 
 // COPIED from https://pkg.go.dev/gocloud.dev/pubsub@v0.43.0#Topic as it's an interface
 // and not a specific type
@@ -38,6 +39,11 @@ type Subscription interface {
 	Shutdown(ctx context.Context) (err error)
 }
 
+// DelayMetadataKey is the key of the Message Metadata with the duration,
+// like "2s", to wait before delivering it. Only the PubSub systems that
+// support delayed delivery, like "sql", use it.
+const DelayMetadataKey = "pikoci-delay"
+
 type Body struct {
 	TeamCanonical     string `json:"team_canonical,omitempty"`
 	PipelineName      string `json:"pipeline_name,omitempty"`
@@ -46,4 +52,17 @@ type Body struct {
 	VersionID         uint32 `json:"version_id,omitempty"`
 	RetryBuildNumber  string `json:"retry_build_number,omitempty"` // parent build number for retry numbering
 	RetryBuildID      uint32 `json:"retry_build_id,omitempty"`     // build ID to copy resource versions from
+
+	// BuildNumber is the Pending Build created when the
+	// job was queued, which the worker has to start
+	BuildNumber string `json:"build_number,omitempty"`
+
+	// Versions is the version ID to use on each get step (by name) chosen on a manual trigger
+	Versions map[string]uint32 `json:"versions,omitempty"`
+
+	// Payload is the body of the webhook request that triggered the resource check
+	Payload []byte `json:"payload,omitempty"`
+
+	// Tags are the tags a worker needs to have to receive the message
+	Tags []string `json:"tags,omitempty"`
 }
@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gocloud.dev/pubsub"
+)
+
+// MaxWorkerTags is the maximum number of tags of a worker, as it
+// receives from one topic for each combination of them
+const MaxWorkerTags = 6
+
+// TagsKey returns the key of the topic of the messages with the tags,
+// which is the sorted tags joined by '.' and "" for no tags
+func TagsKey(tags []string) string {
+	ts := slices.Clone(tags)
+	slices.Sort(ts)
+	return strings.Join(slices.Compact(ts), ".")
+}
+
+// TagsKeys returns the keys of all the topics a worker with the tags
+// has to receive from, one for each non empty combination of them.
+// A worker with no tags only receives from the "" key.
+func TagsKeys(tags []string) []string {
+	ts := slices.Clone(tags)
+	slices.Sort(ts)
+	ts = slices.Compact(ts)
+	if len(ts) == 0 {
+		return []string{""}
+	}
+
+	keys := make([]string, 0, 1<<len(ts)-1)
+	for mask := 1; mask < 1<<len(ts); mask++ {
+		var sub []string
+		for i, t := range ts {
+			if mask&(1<<i) != 0 {
+				sub = append(sub, t)
+			}
+		}
+		keys = append(keys, strings.Join(sub, "."))
+	}
+	return keys
+}
+
+// Router is a Topic that sends each message to the topic of the
+// Tags of its Body, so it's only received by the workers that
+// have all of them. The topics are opened when first used.
+type Router struct {
+	open func(ctx context.Context, key string) (Topic, error)
+
+	mu     sync.Mutex
+	topics map[string]Topic
+}
+
+// NewRouter returns a Router that opens the topic of each
+// tags key with open
+func NewRouter(open func(ctx context.Context, key string) (Topic, error)) *Router {
+	return &Router{
+		open:   open,
+		topics: make(map[string]Topic),
+	}
+}
+
+// Topic returns the topic of the tags key, opening it if needed
+func (r *Router) Topic(ctx context.Context, key string) (Topic, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.topics[key]; ok {
+		return t, nil
+	}
+	t, err := r.open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Topic %q: %w", key, err)
+	}
+	r.topics[key] = t
+	return t, nil
+}
+
+func (r *Router) Send(ctx context.Context, m *pubsub.Message) error {
+	var b Body
+	if err := json.Unmarshal(m.Body, &b); err != nil {
+		return fmt.Errorf("failed to unmarshal Message Body: %w", err)
+	}
+	t, err := r.Topic(ctx, TagsKey(b.Tags))
+	if err != nil {
+		return err
+	}
+	return t.Send(ctx, m)
+}
+
+// ErrorAs converts err with the topic of the messages with no tags
+func (r *Router) ErrorAs(err error, i any) bool {
+	t, ok := r.untagged()
+	return ok && t.ErrorAs(err, i)
+}
+
+// As converts i with the topic of the messages with no tags
+func (r *Router) As(i any) bool {
+	t, ok := r.untagged()
+	return ok && t.As(i)
+}
+
+func (r *Router) untagged() (Topic, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.topics[""]
+	return t, ok
+}
+
+// Shutdown shuts down all the opened topics
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for k, t := range r.topics {
+		if err := t.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to Shutdown Topic %q: %w", k, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewMultiSubscription returns a Subscription that receives
+// the messages of all the subs
+func NewMultiSubscription(subs ...Subscription) Subscription {
+	if len(subs) == 1 {
+		return subs[0]
+	}
+	return &multiSubscription{subs: subs}
+}
+
+type multiSubscription struct {
+	subs []Subscription
+}
+
+// Receive waits for a message on each one of the subs, one after the
+// other, so it never takes more than the one message it returns
+func (ms *multiSubscription) Receive(ctx context.Context) (*pubsub.Message, error) {
+	for {
+		for _, s := range ms.subs {
+			m, err := receive(ctx, s, pollTimeout)
+			if err != nil {
+				return nil, err
+			} else if m == nil {
+				continue
+			}
+			return m, nil
+		}
+	}
+}
+
+func (ms *multiSubscription) ErrorAs(err error, i any) bool {
+	for _, s := range ms.subs {
+		if s.ErrorAs(err, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms *multiSubscription) As(i any) bool {
+	for _, s := range ms.subs {
+		if s.As(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ms *multiSubscription) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, s := range ms.subs {
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
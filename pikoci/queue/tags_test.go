@@ -0,0 +1,96 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/mempubsub"
+)
+
+func TestTagsKey(t *testing.T) {
+	assert.Equal(t, "", queue.TagsKey(nil))
+	assert.Equal(t, "docker", queue.TagsKey([]string{"docker"}))
+	assert.Equal(t, "arm64.docker", queue.TagsKey([]string{"docker", "arm64", "docker"}))
+}
+
+func TestTagsKeys(t *testing.T) {
+	assert.Equal(t, []string{""}, queue.TagsKeys(nil))
+	assert.Equal(t, []string{"docker"}, queue.TagsKeys([]string{"docker"}))
+	assert.ElementsMatch(t, []string{"arm64", "docker", "gpu", "arm64.docker", "arm64.gpu", "docker.gpu", "arm64.docker.gpu"}, queue.TagsKeys([]string{"gpu", "docker", "arm64"}))
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+
+	topics := make(map[string]*pubsub.Topic)
+	r := queue.NewRouter(func(_ context.Context, key string) (queue.Topic, error) {
+		tp := mempubsub.NewTopic()
+		topics[key] = tp
+		return tp, nil
+	})
+	defer r.Shutdown(ctx)
+
+	// Subscriptions need the topic to exist
+	for _, k := range []string{"", "docker", "arm64.docker"} {
+		_, err := r.Topic(ctx, k)
+		require.NoError(t, err)
+	}
+	untagged := mempubsub.NewSubscription(topics[""], time.Minute)
+	docker := mempubsub.NewSubscription(topics["docker"], time.Minute)
+	both := mempubsub.NewSubscription(topics["arm64.docker"], time.Minute)
+
+	send := func(b queue.Body) {
+		mb, err := json.Marshal(b)
+		require.NoError(t, err)
+		require.NoError(t, r.Send(ctx, &pubsub.Message{Body: mb}))
+	}
+	receive := func(s queue.Subscription) queue.Body {
+		rctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		m, err := s.Receive(rctx)
+		require.NoError(t, err)
+		m.Ack()
+		var b queue.Body
+		require.NoError(t, json.Unmarshal(m.Body, &b))
+		return b
+	}
+
+	send(queue.Body{JobName: "untagged"})
+	send(queue.Body{JobName: "docker", Tags: []string{"docker"}})
+	send(queue.Body{JobName: "both", Tags: []string{"docker", "arm64"}})
+
+	assert.Equal(t, "untagged", receive(untagged).JobName)
+	assert.Equal(t, "docker", receive(docker).JobName)
+	assert.Equal(t, "both", receive(both).JobName)
+
+	t.Run("MultiSubscription", func(t *testing.T) {
+		ms := queue.NewMultiSubscription(docker, both)
+
+		send(queue.Body{JobName: "docker", Tags: []string{"docker"}})
+		send(queue.Body{JobName: "both", Tags: []string{"arm64", "docker"}})
+
+		names := []string{receive(ms).JobName, receive(ms).JobName}
+		assert.ElementsMatch(t, []string{"docker", "both"}, names)
+
+		// A Receive only takes the message it returns, the
+		// rest stay on the queue for the other workers
+		send(queue.Body{JobName: "docker", Tags: []string{"docker"}})
+		send(queue.Body{JobName: "both", Tags: []string{"arm64", "docker"}})
+
+		assert.Equal(t, "docker", receive(ms).JobName)
+		assert.Equal(t, "both", receive(both).JobName)
+
+		rctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err := ms.Receive(rctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, ms.Shutdown(ctx))
+	})
+}
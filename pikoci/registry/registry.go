@@ -0,0 +1,34 @@
+package registry
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrNotFound is returned by a Repository when no Worker exists with the ID
+var ErrNotFound = errors.New("worker not found")
+
+// Worker is a worker instance registered on the server, it's
+// considered alive as long as it keeps sending heartbeats
+type Worker struct {
+	ID          uint32   `json:"id"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Platform    string   `json:"platform"`
+	Concurrency int      `json:"concurrency"`
+	Tags        []string `json:"tags"`
+
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// HasTags returns true if the Worker has all the tags
+func (w Worker) HasTags(tags []string) bool {
+	for _, t := range tags {
+		if !slices.Contains(w.Tags, t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,19 @@
+package registry
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go tool mockgen -destination=../mock/worker_repository.go -mock_names=Repository=WorkerRepository -package mock github.com/xescugc/pikoci/pikoci/registry Repository
+
+type Repository interface {
+	Create(ctx context.Context, w Worker) (uint32, error)
+	Filter(ctx context.Context) ([]*Worker, error)
+	// Heartbeat sets the HeartbeatAt of the Worker, it returns
+	// ErrNotFound if the Worker is no longer registered
+	Heartbeat(ctx context.Context, id uint32, at time.Time) error
+	// FilterExpired returns the Workers with the last heartbeat before t
+	FilterExpired(ctx context.Context, t time.Time) ([]*Worker, error)
+	Delete(ctx context.Context, id uint32) error
+}
@@ -7,14 +7,19 @@ import "context"
 type Repository interface {
 	Create(ctx context.Context, tc, pn string, r Resource) (uint32, error)
 	Update(ctx context.Context, tc, pn, rCan string, r Resource) error
+	SetWebhook(ctx context.Context, tc, pn, rCan string, wh *Webhook) error
 	Find(ctx context.Context, tc, pn, rCan string) (*Resource, error)
 	FindByWebhookToken(ctx context.Context, token string) (*Resource, string, string, error)
 	Filter(ctx context.Context, tc, pn string) ([]*Resource, error)
 	FilterDueResources(ctx context.Context) ([]*ResourceWithPipeline, error)
+	FilterOutdatedWebhooks(ctx context.Context, keyID string) ([]*ResourceWithPipeline, error)
 	Delete(ctx context.Context, tc, pn, rCan string) error
 
 	CreateVersion(ctx context.Context, tc, pn, rCan string, v Version) (uint32, error)
 	FilterVersions(ctx context.Context, tc, pn, rCan string) ([]*Version, error)
+	PinVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error
+	UnpinVersion(ctx context.Context, tc, pn, rCan string) error
+	SetVersionDisabled(ctx context.Context, tc, pn, rCan string, vID uint32, disabled bool) error
 }
 
 type ResourceWithPipeline struct {
@@ -9,12 +9,19 @@ type Resource struct {
 
 	Params        *Params `json:"params,omitempty" hcl:"params,block"`
 	CheckInterval string `json:"check_interval" hcl:"check_interval,optional"`
+	Webhook       *Webhook `json:"webhook,omitempty" hcl:"webhook,block"`
+	// Tags are the tags a worker needs to have to check the Resource
+	Tags []string `json:"tags,omitempty" hcl:"tags,optional"`
 
 	Canonical string    `json:"canonical"`
 	Logs      string    `json:"logs"`
 	LastCheck     time.Time `json:"last_check"`
 	NextCheck     time.Time `json:"next_check"`
 	WebhookToken  string    `json:"webhook_token"`
+
+	// PinnedVersionID is the Version all the get steps of the
+	// resource use while it's pinned, 0 if it's not pinned
+	PinnedVersionID uint32 `json:"pinned_version_id,omitempty"`
 }
 
 func (r Resource) GetParams() map[string]string {
@@ -29,6 +36,27 @@ type Params struct {
 }
 
 type Version struct {
-	ID      uint32                 `json:"id"`
-	Version map[string]interface{} `json:"version"`
+	ID       uint32                 `json:"id"`
+	Version  map[string]interface{} `json:"version"`
+	Pinned   bool                   `json:"pinned,omitempty"`
+	Disabled bool                   `json:"disabled,omitempty"`
+}
+
+// UsableVersions returns the versions that can be used by the jobs,
+// which is only the pinned one if there is any or all the ones that
+// are not disabled otherwise. The order of vers is kept.
+func UsableVersions(vers []*Version) []*Version {
+	for _, v := range vers {
+		if v.Pinned {
+			return []*Version{v}
+		}
+	}
+
+	usable := make([]*Version, 0, len(vers))
+	for _, v := range vers {
+		if !v.Disabled {
+			usable = append(usable, v)
+		}
+	}
+	return usable
 }
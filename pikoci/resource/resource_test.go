@@ -0,0 +1,22 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/pikoci/pikoci/resource"
+)
+
+func TestUsableVersions(t *testing.T) {
+	t.Run("SkipsDisabled", func(t *testing.T) {
+		vers := []*resource.Version{{ID: 1}, {ID: 2, Disabled: true}, {ID: 3}}
+		assert.Equal(t, []*resource.Version{{ID: 1}, {ID: 3}}, resource.UsableVersions(vers))
+	})
+	t.Run("OnlyPinned", func(t *testing.T) {
+		vers := []*resource.Version{{ID: 1}, {ID: 2, Pinned: true}, {ID: 3}}
+		assert.Equal(t, []*resource.Version{{ID: 2, Pinned: true}}, resource.UsableVersions(vers))
+	})
+	t.Run("Empty", func(t *testing.T) {
+		assert.Empty(t, resource.UsableVersions(nil))
+	})
+}
@@ -0,0 +1,94 @@
+package resource
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// WebhookTypeGitHub verifies the HMAC SHA256 of the body
+	// sent on the X-Hub-Signature-256 header
+	WebhookTypeGitHub = "github"
+	// WebhookTypeGitLab verifies the secret sent on the X-Gitlab-Token header
+	WebhookTypeGitLab = "gitlab"
+	// WebhookTypeSecret verifies the secret sent on the X-Pikoci-Secret header
+	WebhookTypeSecret = "secret"
+)
+
+var (
+	ErrWebhookMissingSignature = errors.New("missing webhook signature")
+	ErrWebhookInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Webhook configures how the requests to the webhook of a
+// Resource are verified before they trigger a check
+type Webhook struct {
+	Type string `json:"type" hcl:"type"`
+	// Secret is never returned by the API
+	Secret string `json:"-" hcl:"secret"`
+
+	// KeyID and Data are the Secret encrypted with
+	// the secret keys, it's how it's stored
+	KeyID string `json:"-"`
+	Data  []byte `json:"-"`
+}
+
+// Validate checks that the Webhook has a known Type and a Secret.
+// Secret-backed variables are only resolved on the Workers so
+// their placeholder, which is predictable, is not a valid Secret
+func (wh Webhook) Validate() error {
+	switch wh.Type {
+	case WebhookTypeGitHub, WebhookTypeGitLab, WebhookTypeSecret:
+	default:
+		return fmt.Errorf("invalid webhook type %q, must be one of %q, %q or %q", wh.Type, WebhookTypeGitHub, WebhookTypeGitLab, WebhookTypeSecret)
+	}
+	if wh.Secret == "" {
+		return fmt.Errorf("webhook secret is required")
+	} else if strings.Contains(wh.Secret, "__pikoci_secret:") {
+		return fmt.Errorf("webhook secret can't be a secret-backed variable, use a plain variable instead")
+	}
+	return nil
+}
+
+// Verify checks that the request with headers h and body
+// was signed with the Secret of the Webhook
+func (wh Webhook) Verify(h http.Header, body []byte) error {
+	switch wh.Type {
+	case WebhookTypeGitHub:
+		sig := h.Get("X-Hub-Signature-256")
+		if sig == "" {
+			return ErrWebhookMissingSignature
+		}
+		dsig, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+		if err != nil {
+			return ErrWebhookInvalidSignature
+		}
+		mac := hmac.New(sha256.New, []byte(wh.Secret))
+		mac.Write(body)
+		if !hmac.Equal(dsig, mac.Sum(nil)) {
+			return ErrWebhookInvalidSignature
+		}
+		return nil
+	case WebhookTypeGitLab, WebhookTypeSecret:
+		hn := "X-Pikoci-Secret"
+		if wh.Type == WebhookTypeGitLab {
+			hn = "X-Gitlab-Token"
+		}
+		sec := h.Get(hn)
+		if sec == "" {
+			return ErrWebhookMissingSignature
+		}
+		if subtle.ConstantTimeCompare([]byte(sec), []byte(wh.Secret)) != 1 {
+			return ErrWebhookInvalidSignature
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid webhook type %q", wh.Type)
+	}
+}
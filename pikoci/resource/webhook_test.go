@@ -0,0 +1,51 @@
+package resource_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xescugc/pikoci/pikoci/resource"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	assert.NoError(t, resource.Webhook{Type: resource.WebhookTypeGitHub, Secret: "s"}.Validate())
+	assert.NoError(t, resource.Webhook{Type: resource.WebhookTypeGitLab, Secret: "s"}.Validate())
+	assert.NoError(t, resource.Webhook{Type: resource.WebhookTypeSecret, Secret: "s"}.Validate())
+	assert.Error(t, resource.Webhook{Type: "bitbucket", Secret: "s"}.Validate())
+	assert.Error(t, resource.Webhook{Type: resource.WebhookTypeGitHub}.Validate())
+	assert.EqualError(t, resource.Webhook{Type: resource.WebhookTypeGitHub, Secret: "__pikoci_secret:vault:path:key__"}.Validate(), "webhook secret can't be a secret-backed variable, use a plain variable instead")
+}
+
+func TestWebhookVerify(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	t.Run("GitHub", func(t *testing.T) {
+		wh := resource.Webhook{Type: resource.WebhookTypeGitHub, Secret: "my-secret"}
+		mac := hmac.New(sha256.New, []byte("my-secret"))
+		mac.Write(body)
+		sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		assert.NoError(t, wh.Verify(http.Header{"X-Hub-Signature-256": []string{sig}}, body))
+		assert.ErrorIs(t, wh.Verify(http.Header{"X-Hub-Signature-256": []string{sig}}, []byte("other")), resource.ErrWebhookInvalidSignature)
+		assert.ErrorIs(t, wh.Verify(http.Header{"X-Hub-Signature-256": []string{"sha256=zz"}}, body), resource.ErrWebhookInvalidSignature)
+		assert.ErrorIs(t, wh.Verify(http.Header{}, body), resource.ErrWebhookMissingSignature)
+	})
+	t.Run("GitLab", func(t *testing.T) {
+		wh := resource.Webhook{Type: resource.WebhookTypeGitLab, Secret: "my-secret"}
+
+		assert.NoError(t, wh.Verify(http.Header{"X-Gitlab-Token": []string{"my-secret"}}, body))
+		assert.ErrorIs(t, wh.Verify(http.Header{"X-Gitlab-Token": []string{"wrong"}}, body), resource.ErrWebhookInvalidSignature)
+		assert.ErrorIs(t, wh.Verify(http.Header{"X-Pikoci-Secret": []string{"my-secret"}}, body), resource.ErrWebhookMissingSignature)
+	})
+	t.Run("Secret", func(t *testing.T) {
+		wh := resource.Webhook{Type: resource.WebhookTypeSecret, Secret: "my-secret"}
+
+		assert.NoError(t, wh.Verify(http.Header{"X-Pikoci-Secret": []string{"my-secret"}}, body))
+		assert.ErrorIs(t, wh.Verify(http.Header{"X-Pikoci-Secret": []string{"wrong"}}, body), resource.ErrWebhookInvalidSignature)
+		assert.ErrorIs(t, wh.Verify(http.Header{}, body), resource.ErrWebhookMissingSignature)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"slices"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/xescugc/pikoci/pikoci/queue"
 	"github.com/xescugc/pikoci/pikoci/resource"
 	"github.com/xescugc/pikoci/pikoci/scheduler"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	"github.com/xescugc/pikoci/pikoci/utils"
 	"gocloud.dev/pubsub"
 )
@@ -53,6 +55,80 @@ func (q *PikoCI) ListResourceVersions(ctx context.Context, tc, pn, rCan string)
 	return rvers, nil
 }
 
+// PinResourceVersion pins the Resource rCan to the Version vID, so all
+// the get steps use it until it's unpinned. The Resource is still checked.
+func (q *PikoCI) PinResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateResourceCanonical(rCan) {
+		return fmt.Errorf("invalid Resource Canonical format %q", rCan)
+	}
+
+	err := q.Resources.PinVersion(ctx, tc, pn, rCan, vID)
+	if err != nil {
+		return fmt.Errorf("failed to pin Version %d of Resource %q: %w", vID, rCan, err)
+	}
+
+	return nil
+}
+
+func (q *PikoCI) UnpinResource(ctx context.Context, tc, pn, rCan string) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateResourceCanonical(rCan) {
+		return fmt.Errorf("invalid Resource Canonical format %q", rCan)
+	}
+
+	err := q.Resources.UnpinVersion(ctx, tc, pn, rCan)
+	if err != nil {
+		return fmt.Errorf("failed to unpin Resource %q: %w", rCan, err)
+	}
+
+	return nil
+}
+
+func (q *PikoCI) EnableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	return q.setResourceVersionDisabled(ctx, tc, pn, rCan, vID, false)
+}
+
+// DisableResourceVersion disables the Version vID of the Resource rCan
+// so it's never used to trigger jobs, resolve passed constraints or
+// on get steps. The pinned Version can't be disabled.
+func (q *PikoCI) DisableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	return q.setResourceVersionDisabled(ctx, tc, pn, rCan, vID, true)
+}
+
+func (q *PikoCI) setResourceVersionDisabled(ctx context.Context, tc, pn, rCan string, vID uint32, disabled bool) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !utils.ValidateCanonical(pn) {
+		return fmt.Errorf("invalid Pipeline Name format %q", pn)
+	} else if !utils.ValidateResourceCanonical(rCan) {
+		return fmt.Errorf("invalid Resource Canonical format %q", rCan)
+	}
+
+	if disabled {
+		r, err := q.Resources.Find(ctx, tc, pn, rCan)
+		if err != nil {
+			return fmt.Errorf("failed to find Resource: %w", err)
+		}
+		if r.PinnedVersionID == vID {
+			return fmt.Errorf("version %d of Resource %q is pinned, unpin it before disabling it", vID, rCan)
+		}
+	}
+
+	err := q.Resources.SetVersionDisabled(ctx, tc, pn, rCan, vID, disabled)
+	if err != nil {
+		return fmt.Errorf("failed to update Version %d of Resource %q: %w", vID, rCan, err)
+	}
+
+	return nil
+}
+
 func (q *PikoCI) GetPipelineResource(ctx context.Context, tc, pn, rCan string) (*resource.Resource, error) {
 	if !utils.ValidateCanonical(tc) {
 		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
@@ -88,6 +164,12 @@ func (q *PikoCI) UpdatePipelineResource(ctx context.Context, tc, pn, rCan string
 }
 
 func (q *PikoCI) TriggerPipelineResource(ctx context.Context, tc, pn, rCan string) error {
+	return q.triggerPipelineResource(ctx, tc, pn, rCan, nil)
+}
+
+// triggerPipelineResource queues a check of the Resource rCan, the payload
+// is the body of the webhook request that triggered it, if any
+func (q *PikoCI) triggerPipelineResource(ctx context.Context, tc, pn, rCan string, payload []byte) error {
 	if !utils.ValidateCanonical(tc) {
 		return fmt.Errorf("invalid Team Canonical format %q", tc)
 	} else if !utils.ValidateCanonical(pn) {
@@ -105,6 +187,8 @@ func (q *PikoCI) TriggerPipelineResource(ctx context.Context, tc, pn, rCan strin
 		TeamCanonical:     tc,
 		PipelineName:      pn,
 		ResourceCanonical: rCan,
+		Payload:           payload,
+		Tags:              r.Tags,
 	}
 	mb, err := json.Marshal(m)
 	if err != nil {
@@ -131,13 +215,32 @@ func (q *PikoCI) TriggerPipelineResource(ctx context.Context, tc, pn, rCan strin
 	return nil
 }
 
-func (q *PikoCI) WebhookTrigger(ctx context.Context, token string) error {
+// WebhookTrigger queues a check of the Resource with the webhook token.
+// If the Resource has a Webhook configured the request, with headers h
+// and body, has to be signed with its secret. The body is passed to
+// the check of the Resource.
+func (q *PikoCI) WebhookTrigger(ctx context.Context, token string, h http.Header, body []byte) error {
 	r, tc, pn, err := q.Resources.FindByWebhookToken(ctx, token)
 	if err != nil {
 		return fmt.Errorf("failed to find Resource by webhook token: %w", err)
 	}
 
-	return q.TriggerPipelineResource(ctx, tc, pn, r.Canonical)
+	if r.Webhook != nil {
+		t, err := q.Teams.Find(ctx, tc)
+		if err != nil {
+			return fmt.Errorf("failed to Find Team: %w", err)
+		}
+		wh, err := q.decryptWebhook(t.ID, pn, r.Canonical, r.Webhook)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt webhook of Resource %q: %w", r.Canonical, err)
+		}
+		err = wh.Verify(h, body)
+		if err != nil {
+			return fmt.Errorf("failed to verify webhook of Resource %q: %w", r.Canonical, err)
+		}
+	}
+
+	return q.triggerPipelineResource(ctx, tc, pn, r.Canonical, body)
 }
 
 func (q *PikoCI) RegenerateWebhookToken(ctx context.Context, tc, pn, rCan string) (string, error) {
@@ -162,3 +265,47 @@ func (q *PikoCI) RegenerateWebhookToken(ctx context.Context, tc, pn, rCan string
 
 	return r.WebhookToken, nil
 }
+
+// webhookSecret returns the secret.Secret the Webhook Secret of the
+// Resource rCan is encrypted as, the TeamID and Name bind the Data to it
+func webhookSecret(tID uint32, pn, rCan string, wh *resource.Webhook) *secret.Secret {
+	return &secret.Secret{
+		TeamID: tID,
+		Name:   fmt.Sprintf("webhook:%s:%s", pn, rCan),
+		Value:  wh.Secret,
+		KeyID:  wh.KeyID,
+		Data:   wh.Data,
+	}
+}
+
+// encryptWebhook returns a copy of wh with the Secret encrypted
+// with the SecretKeys, so it's never stored in plain text
+func (q *PikoCI) encryptWebhook(tID uint32, pn, rCan string, wh *resource.Webhook) (*resource.Webhook, error) {
+	if q.SecretKeys == nil {
+		return nil, secret.ErrNoKeyring
+	}
+
+	s := webhookSecret(tID, pn, rCan, wh)
+	if err := q.SecretKeys.Encrypt(s); err != nil {
+		return nil, err
+	}
+
+	return &resource.Webhook{Type: wh.Type, KeyID: s.KeyID, Data: s.Data}, nil
+}
+
+// decryptWebhook returns a copy of wh with the Secret decrypted, the
+// ones stored before they were encrypted have it in plain text
+func (q *PikoCI) decryptWebhook(tID uint32, pn, rCan string, wh *resource.Webhook) (*resource.Webhook, error) {
+	if wh.KeyID == "" {
+		return wh, nil
+	} else if q.SecretKeys == nil {
+		return nil, secret.ErrNoKeyring
+	}
+
+	s := webhookSecret(tID, pn, rCan, wh)
+	if err := q.SecretKeys.Decrypt(s); err != nil {
+		return nil, err
+	}
+
+	return &resource.Webhook{Type: wh.Type, Secret: s.Value}, nil
+}
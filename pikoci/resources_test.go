@@ -3,12 +3,15 @@ package pikoci_test
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xescugc/pikoci/pikoci/queue"
 	"github.com/xescugc/pikoci/pikoci/resource"
+	"github.com/xescugc/pikoci/pikoci/secret"
+	"github.com/xescugc/pikoci/pikoci/team"
 	"go.uber.org/mock/gomock"
 	"gocloud.dev/pubsub"
 )
@@ -107,3 +110,155 @@ func TestTriggerPipelineResource(t *testing.T) {
 	err := s.S.TriggerPipelineResource(ctx, "main", "my-pipeline", "git.repo")
 	require.NoError(t, err)
 }
+
+func TestWebhookTrigger(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	t.Run("WithoutWebhook", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		r := &resource.Resource{ID: 1, Canonical: "git.repo"}
+		s.Resources.EXPECT().FindByWebhookToken(ctx, "token").Return(r, "main", "my-pipeline", nil)
+		s.Resources.EXPECT().Find(ctx, "main", "my-pipeline", "git.repo").Return(r, nil)
+
+		mb, _ := json.Marshal(queue.Body{
+			TeamCanonical:     "main",
+			PipelineName:      "my-pipeline",
+			ResourceCanonical: "git.repo",
+			Payload:           body,
+		})
+		s.Topic.EXPECT().Send(ctx, &pubsub.Message{Body: mb}).Return(nil)
+		s.Resources.EXPECT().Update(ctx, "main", "my-pipeline", "git.repo", gomock.Any()).Return(nil)
+
+		err := s.S.WebhookTrigger(ctx, "token", http.Header{}, body)
+		require.NoError(t, err)
+	})
+	t.Run("ValidSignature", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		ws := secret.Secret{TeamID: 1, Name: "webhook:my-pipeline:git.repo", Value: "my-secret"}
+		require.NoError(t, s.P.SecretKeys.Encrypt(&ws))
+
+		r := &resource.Resource{ID: 1, Canonical: "git.repo", Webhook: &resource.Webhook{Type: resource.WebhookTypeGitLab, KeyID: ws.KeyID, Data: ws.Data}}
+		s.Resources.EXPECT().FindByWebhookToken(ctx, "token").Return(r, "main", "my-pipeline", nil)
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+		s.Resources.EXPECT().Find(ctx, "main", "my-pipeline", "git.repo").Return(r, nil)
+		s.Topic.EXPECT().Send(ctx, gomock.Any()).Return(nil)
+		s.Resources.EXPECT().Update(ctx, "main", "my-pipeline", "git.repo", gomock.Any()).Return(nil)
+
+		err := s.S.WebhookTrigger(ctx, "token", http.Header{"X-Gitlab-Token": []string{"my-secret"}}, body)
+		require.NoError(t, err)
+	})
+	t.Run("NotEncryptedSecret", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		r := &resource.Resource{ID: 1, Canonical: "git.repo", Webhook: &resource.Webhook{Type: resource.WebhookTypeGitLab, Secret: "my-secret"}}
+		s.Resources.EXPECT().FindByWebhookToken(ctx, "token").Return(r, "main", "my-pipeline", nil)
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+		s.Resources.EXPECT().Find(ctx, "main", "my-pipeline", "git.repo").Return(r, nil)
+		s.Topic.EXPECT().Send(ctx, gomock.Any()).Return(nil)
+		s.Resources.EXPECT().Update(ctx, "main", "my-pipeline", "git.repo", gomock.Any()).Return(nil)
+
+		err := s.S.WebhookTrigger(ctx, "token", http.Header{"X-Gitlab-Token": []string{"my-secret"}}, body)
+		require.NoError(t, err)
+	})
+	t.Run("InvalidSignature", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		r := &resource.Resource{ID: 1, Canonical: "git.repo", Webhook: &resource.Webhook{Type: resource.WebhookTypeGitLab, Secret: "my-secret"}}
+		s.Resources.EXPECT().FindByWebhookToken(ctx, "token").Return(r, "main", "my-pipeline", nil)
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+
+		err := s.S.WebhookTrigger(ctx, "token", http.Header{"X-Gitlab-Token": []string{"wrong"}}, body)
+		assert.ErrorIs(t, err, resource.ErrWebhookInvalidSignature)
+	})
+	t.Run("OtherTeamSecret", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		// The secret of the same pipeline and resource of other team
+		ws := secret.Secret{TeamID: 2, Name: "webhook:my-pipeline:git.repo", Value: "my-secret"}
+		require.NoError(t, s.P.SecretKeys.Encrypt(&ws))
+
+		r := &resource.Resource{ID: 1, Canonical: "git.repo", Webhook: &resource.Webhook{Type: resource.WebhookTypeGitLab, KeyID: ws.KeyID, Data: ws.Data}}
+		s.Resources.EXPECT().FindByWebhookToken(ctx, "token").Return(r, "main", "my-pipeline", nil)
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+
+		err := s.S.WebhookTrigger(ctx, "token", http.Header{"X-Gitlab-Token": []string{"my-secret"}}, body)
+		assert.ErrorContains(t, err, `failed to decrypt webhook of Resource "git.repo"`)
+	})
+}
+
+func TestPinResourceVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Resources.EXPECT().PinVersion(ctx, "main", "my-pipeline", "git.repo", uint32(3)).Return(nil)
+
+	err := s.S.PinResourceVersion(ctx, "main", "my-pipeline", "git.repo", 3)
+	require.NoError(t, err)
+
+	err = s.S.PinResourceVersion(ctx, "main", "my-pipeline", "INVALID", 3)
+	require.Error(t, err)
+}
+
+func TestUnpinResource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Resources.EXPECT().UnpinVersion(ctx, "main", "my-pipeline", "git.repo").Return(nil)
+
+	err := s.S.UnpinResource(ctx, "main", "my-pipeline", "git.repo")
+	require.NoError(t, err)
+}
+
+func TestDisableResourceVersion(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Resources.EXPECT().Find(ctx, "main", "my-pipeline", "git.repo").Return(&resource.Resource{
+			ID: 1, Canonical: "git.repo", PinnedVersionID: 2,
+		}, nil)
+		s.Resources.EXPECT().SetVersionDisabled(ctx, "main", "my-pipeline", "git.repo", uint32(3), true).Return(nil)
+
+		err := s.S.DisableResourceVersion(ctx, "main", "my-pipeline", "git.repo", 3)
+		require.NoError(t, err)
+	})
+	t.Run("Pinned", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Resources.EXPECT().Find(ctx, "main", "my-pipeline", "git.repo").Return(&resource.Resource{
+			ID: 1, Canonical: "git.repo", PinnedVersionID: 3,
+		}, nil)
+
+		err := s.S.DisableResourceVersion(ctx, "main", "my-pipeline", "git.repo", 3)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is pinned")
+	})
+}
+
+func TestEnableResourceVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Resources.EXPECT().SetVersionDisabled(ctx, "main", "my-pipeline", "git.repo", uint32(3), false).Return(nil)
+
+	err := s.S.EnableResourceVersion(ctx, "main", "my-pipeline", "git.repo", 3)
+	require.NoError(t, err)
+}
@@ -59,6 +59,8 @@ func (s *Scheduler) tick(ctx context.Context) {
 }
 
 func (s *Scheduler) tickResources(ctx context.Context) {
+	// The resources of paused pipelines are never due, so they are
+	// not checked until the pipeline is unpaused
 	due, err := s.resources.FilterDueResources(ctx)
 	if err != nil {
 		s.logger.Error("failed to filter due resources", "error", err)
@@ -72,6 +74,7 @@ func (s *Scheduler) tickResources(ctx context.Context) {
 			TeamCanonical:     rwp.TeamCanonical,
 			PipelineName:      rwp.PipelineName,
 			ResourceCanonical: rwp.Canonical,
+			Tags:              rwp.Tags,
 		}
 		mb, err := json.Marshal(m)
 		if err != nil {
@@ -115,7 +118,13 @@ func (s *Scheduler) tickJobs(ctx context.Context) {
 	}
 
 	for _, pwt := range pps {
+		if pwt.Paused {
+			continue
+		}
 		for _, j := range pwt.Jobs {
+			if j.Paused {
+				continue
+			}
 			s.evaluateJob(ctx, pwt, &j)
 		}
 	}
@@ -134,11 +143,7 @@ func (s *Scheduler) evaluateJob(ctx context.Context, pwt *pipeline.WithTeam, j *
 	}
 	var candidates []candidate
 
-	for _, ps := range j.Plan {
-		if ps.Type != job.StepTypeGet || ps.Get == nil {
-			continue
-		}
-		g := ps.Get
+	for _, g := range j.GetSteps() {
 		if len(g.Passed) == 0 || !g.Trigger {
 			continue
 		}
@@ -162,6 +167,17 @@ func (s *Scheduler) evaluateJob(ctx context.Context, pwt *pipeline.WithTeam, j *
 		return
 	}
 
+	// The versions are only recorded once the build runs its get steps,
+	// so while the job has a Pending Build it's not triggered again
+	pbs, err := s.builds.FilterPending(ctx, pwt.Team.Canonical, pwt.Name, j.Name)
+	if err != nil {
+		s.logger.Error("failed to filter pending builds",
+			"pipeline", pwt.Name, "job", j.Name, "error", err)
+		return
+	} else if len(pbs) != 0 {
+		return
+	}
+
 	// Use the version from the first candidate for the queue message.
 	versionID := candidates[0].versionID
 
@@ -169,11 +185,25 @@ func (s *Scheduler) evaluateJob(ctx context.Context, pwt *pipeline.WithTeam, j *
 		"pipeline", pwt.Name, "job", j.Name, "version_id", versionID,
 		"candidates", fmt.Sprintf("%+v", candidates))
 
+	b := build.Build{
+		Status:    build.Pending,
+		Steps:     []build.Step{},
+		StartedAt: time.Now().Round(0),
+	}
+	_, bn, err := s.builds.Create(ctx, pwt.Team.Canonical, pwt.Name, j.Name, b)
+	if err != nil {
+		s.logger.Error("failed to create downstream pending build",
+			"job", j.Name, "error", err)
+		return
+	}
+
 	m := queue.Body{
 		TeamCanonical: pwt.Team.Canonical,
 		PipelineName:  pwt.Name,
 		JobName:       j.Name,
 		VersionID:     versionID,
+		BuildNumber:   bn,
+		Tags:          j.Tags,
 	}
 	mb, err := json.Marshal(m)
 	if err != nil {
@@ -183,5 +213,10 @@ func (s *Scheduler) evaluateJob(ctx context.Context, pwt *pipeline.WithTeam, j *
 	if err := s.topic.Send(ctx, &pubsub.Message{Body: mb}); err != nil {
 		s.logger.Error("failed to send downstream trigger message",
 			"job", j.Name, "error", err)
+		// Without its message nothing would ever start the Build
+		if err := s.builds.Delete(ctx, pwt.Team.Canonical, pwt.Name, j.Name, bn); err != nil {
+			s.logger.Error("failed to delete downstream pending build",
+				"job", j.Name, "build_number", bn, "error", err)
+		}
 	}
 }
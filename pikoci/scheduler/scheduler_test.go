@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
 	"github.com/xescugc/pikoci/pikoci/mock"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
@@ -217,6 +218,12 @@ func TestTickJobs_TriggersWhenCommonVersionExists(t *testing.T) {
 		[]string{"lint", "test-mock"}, "test-backends", "repo", 2,
 	).Return(uint32(42), true, nil)
 
+	br.EXPECT().FilterPending(gomock.Any(), "main", "my-pipeline", "test-backends").Return(nil, nil)
+	br.EXPECT().Create(gomock.Any(), "main", "my-pipeline", "test-backends", gomock.Any()).DoAndReturn(func(ctx context.Context, tc, pn, jn string, b build.Build) (uint32, string, error) {
+		assert.Equal(t, build.Pending, b.Status)
+		return 1, "3", nil
+	})
+
 	topic.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg *pubsub.Message) error {
 		var body queue.Body
 		err := json.Unmarshal(msg.Body, &body)
@@ -225,12 +232,61 @@ func TestTickJobs_TriggersWhenCommonVersionExists(t *testing.T) {
 		assert.Equal(t, "my-pipeline", body.PipelineName)
 		assert.Equal(t, "main", body.TeamCanonical)
 		assert.Equal(t, uint32(42), body.VersionID)
+		assert.Equal(t, "3", body.BuildNumber)
 		return nil
 	})
 
 	s.tick(context.Background())
 }
 
+func TestTickJobs_SkipsWhenPendingBuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, rr, pr, br, _ := newTestScheduler(ctrl)
+
+	rr.EXPECT().FilterDueResources(gomock.Any()).Return(nil, nil)
+
+	pps := []*pipeline.WithTeam{
+		{
+			Pipeline: pipeline.Pipeline{
+				Name: "my-pipeline",
+				Jobs: []job.Job{
+					{Name: "lint"},
+					{
+						Name: "deploy",
+						Plan: []job.PlanStep{
+							{
+								Type: job.StepTypeGet,
+								Get: &job.GetStep{
+									Type:    "git",
+									Name:    "repo",
+									Passed:  []string{"lint"},
+									Trigger: true,
+								},
+							},
+						},
+					},
+				},
+			},
+			Team: team.Team{Canonical: "main"},
+		},
+	}
+	pr.EXPECT().FilterAll(gomock.Any()).Return(pps, nil)
+
+	br.EXPECT().FindReadyDownstreamVersion(
+		gomock.Any(), "main", "my-pipeline",
+		[]string{"lint"}, "deploy", "repo", 1,
+	).Return(uint32(42), true, nil)
+
+	// The queued build has not recorded the version yet, so it
+	// would be triggered again on each tick until it runs
+	br.EXPECT().FilterPending(gomock.Any(), "main", "my-pipeline", "deploy").Return([]*build.WithJob{
+		{Build: build.Build{BuildNumber: "3", Status: build.Pending}},
+	}, nil)
+	// No Create or Send should be called
+
+	s.tick(context.Background())
+}
+
 func TestTickJobs_SkipsWhenNoCommonVersion(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s, rr, pr, br, _ := newTestScheduler(ctrl)
@@ -453,6 +509,9 @@ func TestTickJobs_MultipleGetSteps_BothReady_TriggersOnce(t *testing.T) {
 		[]string{"build"}, "deploy", "image", 1,
 	).Return(uint32(99), true, nil)
 
+	br.EXPECT().FilterPending(gomock.Any(), "main", "my-pipeline", "deploy").Return(nil, nil)
+	br.EXPECT().Create(gomock.Any(), "main", "my-pipeline", "deploy", gomock.Any()).Return(uint32(1), "1", nil)
+
 	// Should trigger exactly once
 	topic.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, msg *pubsub.Message) error {
 		var body queue.Body
@@ -506,3 +565,50 @@ func TestTickJobs_FindReadyError_SkipsJob(t *testing.T) {
 	// topic.Send should NOT be called
 	s.tick(context.Background())
 }
+
+func TestTickJobs_SkipsPaused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, rr, pr, _, _ := newTestScheduler(ctrl)
+
+	rr.EXPECT().FilterDueResources(gomock.Any()).Return(nil, nil)
+
+	downstream := job.Job{
+		Name: "downstream",
+		Plan: []job.PlanStep{
+			{
+				Type: job.StepTypeGet,
+				Get: &job.GetStep{
+					Type:    "git",
+					Name:    "repo",
+					Passed:  []string{"upstream"},
+					Trigger: true,
+				},
+			},
+		},
+	}
+	pausedJob := downstream
+	pausedJob.Paused = true
+
+	pps := []*pipeline.WithTeam{
+		{
+			Pipeline: pipeline.Pipeline{
+				Name:   "paused-pipeline",
+				Paused: true,
+				Jobs:   []job.Job{downstream},
+			},
+			Team: team.Team{Canonical: "main"},
+		},
+		{
+			Pipeline: pipeline.Pipeline{
+				Name: "my-pipeline",
+				Jobs: []job.Job{pausedJob},
+			},
+			Team: team.Team{Canonical: "main"},
+		},
+	}
+	pr.EXPECT().FilterAll(gomock.Any()).Return(pps, nil)
+
+	// FindReadyDownstreamVersion should NOT be called
+	// topic.Send should NOT be called
+	s.tick(context.Background())
+}
@@ -0,0 +1,130 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// keySize is the size of the keys, for AES-256
+const keySize = 32
+
+// ErrNoKeyring is returned when the Secrets are used without
+// the master keys to encrypt them
+var ErrNoKeyring = errors.New("the secrets store has no keys configured")
+
+// Keyring has the master keys the Secrets are encrypted with using AES-GCM.
+// The first key encrypts and all of them decrypt, so a key is rotated by
+// adding the new one first, re-encrypting the Secrets and removing the
+// old one after.
+type Keyring struct {
+	keys []keyringKey
+}
+
+type keyringKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewKeyring returns a Keyring with the keys, each one has to be
+// 'ID:KEY' with the 32 bytes KEY encoded in base64
+func NewKeyring(keys []string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one key is required")
+	}
+
+	kr := &Keyring{}
+	ids := make(map[string]struct{})
+	for _, k := range keys {
+		id, b64, ok := strings.Cut(k, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid key format, expected ID:KEY")
+		} else if _, ok := ids[id]; ok {
+			return nil, fmt.Errorf("duplicated key ID %q", id)
+		}
+		ids[id] = struct{}{}
+
+		raw, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q, it has to be base64: %w", id, err)
+		} else if len(raw) != keySize {
+			return nil, fmt.Errorf("invalid key %q, it has to be %d bytes", id, keySize)
+		}
+
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", id, err)
+		}
+		kr.keys = append(kr.keys, keyringKey{id: id, aead: aead})
+	}
+
+	return kr, nil
+}
+
+// GenerateKey returns a new random key with id in
+// the format NewKeyring expects
+func GenerateKey(id string) (string, error) {
+	k := make([]byte, keySize)
+	if _, err := rand.Read(k); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(k), nil
+}
+
+// KeyID returns the ID of the key the Secrets are encrypted with
+func (kr *Keyring) KeyID() string {
+	return kr.keys[0].id
+}
+
+// Encrypt sets the Data of s to its Value encrypted with the first key,
+// the TeamID and the Name are authenticated with it so the Data can't be
+// used by other Secrets of the Team nor by the ones of other Teams
+func (kr *Keyring) Encrypt(s *Secret) error {
+	if s.TeamID == 0 {
+		return fmt.Errorf("failed to encrypt secret %q: the Team is required", s.Name)
+	}
+	k := kr.keys[0]
+
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(s.Value)+k.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	s.KeyID = k.id
+	s.Data = k.aead.Seal(nonce, nonce, []byte(s.Value), additionalData(s))
+	return nil
+}
+
+// Decrypt sets the Value of s from its Data, with the key it was encrypted with
+func (kr *Keyring) Decrypt(s *Secret) error {
+	for _, k := range kr.keys {
+		if k.id != s.KeyID {
+			continue
+		}
+		if len(s.Data) < k.aead.NonceSize() {
+			return fmt.Errorf("failed to decrypt secret %q: invalid data", s.Name)
+		}
+		nonce, data := s.Data[:k.aead.NonceSize()], s.Data[k.aead.NonceSize():]
+		v, err := k.aead.Open(nil, nonce, data, additionalData(s))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt secret %q: %w", s.Name, err)
+		}
+		s.Value = string(v)
+		return nil
+	}
+	return fmt.Errorf("failed to decrypt secret %q: key %q not found", s.Name, s.KeyID)
+}
+
+// additionalData returns the data of s authenticated with its Data,
+// the TeamID is a number so it can't be confused with the Name
+func additionalData(s *Secret) []byte {
+	return []byte(fmt.Sprintf("%d:%s", s.TeamID, s.Name))
+}
@@ -0,0 +1,83 @@
+package secret_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/secret"
+)
+
+func TestKeyring(t *testing.T) {
+	k1, err := secret.GenerateKey("k1")
+	require.NoError(t, err)
+	k2, err := secret.GenerateKey("k2")
+	require.NoError(t, err)
+
+	kr, err := secret.NewKeyring([]string{k1})
+	require.NoError(t, err)
+	assert.Equal(t, "k1", kr.KeyID())
+
+	s := secret.Secret{TeamID: 1, Name: "github_token", Value: "s3cr3t"}
+	require.NoError(t, kr.Encrypt(&s))
+	assert.Equal(t, "k1", s.KeyID)
+	assert.NotContains(t, string(s.Data), "s3cr3t")
+
+	// Each encryption has its own nonce
+	s2 := secret.Secret{TeamID: 1, Name: "github_token", Value: "s3cr3t"}
+	require.NoError(t, kr.Encrypt(&s2))
+	assert.NotEqual(t, s.Data, s2.Data)
+
+	t.Run("Decrypt", func(t *testing.T) {
+		ds := secret.Secret{TeamID: s.TeamID, Name: s.Name, KeyID: s.KeyID, Data: s.Data}
+		require.NoError(t, kr.Decrypt(&ds))
+		assert.Equal(t, "s3cr3t", ds.Value)
+	})
+	t.Run("Rotated", func(t *testing.T) {
+		rkr, err := secret.NewKeyring([]string{k2, k1})
+		require.NoError(t, err)
+		assert.Equal(t, "k2", rkr.KeyID())
+
+		ds := secret.Secret{TeamID: s.TeamID, Name: s.Name, KeyID: s.KeyID, Data: s.Data}
+		require.NoError(t, rkr.Decrypt(&ds))
+		assert.Equal(t, "s3cr3t", ds.Value)
+
+		require.NoError(t, rkr.Encrypt(&ds))
+		assert.Equal(t, "k2", ds.KeyID)
+
+		_, err = secret.NewKeyring([]string{k2})
+		require.NoError(t, err)
+	})
+	t.Run("KeyNotFound", func(t *testing.T) {
+		rkr, err := secret.NewKeyring([]string{k2})
+		require.NoError(t, err)
+
+		ds := secret.Secret{TeamID: s.TeamID, Name: s.Name, KeyID: s.KeyID, Data: s.Data}
+		assert.EqualError(t, rkr.Decrypt(&ds), `failed to decrypt secret "github_token": key "k1" not found`)
+	})
+	t.Run("OtherName", func(t *testing.T) {
+		ds := secret.Secret{TeamID: s.TeamID, Name: "other", KeyID: s.KeyID, Data: s.Data}
+		assert.Error(t, kr.Decrypt(&ds))
+	})
+	t.Run("OtherTeam", func(t *testing.T) {
+		ds := secret.Secret{TeamID: 2, Name: s.Name, KeyID: s.KeyID, Data: s.Data}
+		assert.Error(t, kr.Decrypt(&ds))
+	})
+	t.Run("NoTeam", func(t *testing.T) {
+		ns := secret.Secret{Name: "github_token", Value: "s3cr3t"}
+		assert.EqualError(t, kr.Encrypt(&ns), `failed to encrypt secret "github_token": the Team is required`)
+	})
+	t.Run("InvalidKeys", func(t *testing.T) {
+		for _, keys := range [][]string{
+			nil,
+			{"no-id"},
+			{":YWJj"},
+			{"k1:not base64"},
+			{"k1:YWJj"},
+			{k1, k1},
+		} {
+			_, err := secret.NewKeyring(keys)
+			assert.Error(t, err, keys)
+		}
+	})
+}
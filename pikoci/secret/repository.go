@@ -5,9 +5,18 @@ import "context"
 //go:generate go tool mockgen -destination=../mock/secret_repository.go -mock_names=Repository=SecretRepository -package mock github.com/xescugc/pikoci/pikoci/secret Repository
 
 type Repository interface {
-	Create(ctx context.Context, tc, pn string, s Secret) (uint32, error)
-	Update(ctx context.Context, tc, pn, sCan string, s Secret) error
-	Find(ctx context.Context, tc, pn, sCan string) (*Secret, error)
-	Filter(ctx context.Context, tc, pn string) ([]*Secret, error)
-	Delete(ctx context.Context, tc, pn, sCan string) error
+	Create(ctx context.Context, tc string, s Secret) (uint32, error)
+	// Update returns ErrNotFound if the Secret does not exist
+	Update(ctx context.Context, tc, name string, s Secret) error
+	// Find returns ErrNotFound if the Secret does not exist
+	Find(ctx context.Context, tc, name string) (*Secret, error)
+	Filter(ctx context.Context, tc string) ([]*Secret, error)
+	// Delete returns ErrNotFound if the Secret does not exist
+	Delete(ctx context.Context, tc, name string) error
+
+	// FilterOutdated returns the Secrets of all the Teams
+	// that are not encrypted with the key keyID
+	FilterOutdated(ctx context.Context, keyID string) ([]*Secret, error)
+	// UpdateData replaces the encrypted Data of the Secret with ID
+	UpdateData(ctx context.Context, id uint32, keyID string, data []byte) error
 }
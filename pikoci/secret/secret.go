@@ -1,9 +1,34 @@
 package secret
 
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by a Repository when no Secret exists with the Name
+var ErrNotFound = errors.New("secret not found")
+
+// Secret is a secret value of a Team, used by the pipelines of the
+// Team through the built-in 'pikoci' secret type. The Value is
+// stored encrypted and it's never returned once set.
 type Secret struct {
-	ID        uint32            `json:"id"`
-	Type      string            `json:"type" hcl:"type,label"`
-	Name      string            `json:"name" hcl:"name,label"`
-	Canonical string            `json:"canonical"`
-	Params    map[string]string `json:"params,omitempty"`
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+
+	// TeamID is the ID of the Team of the Secret,
+	// the Data is bound to it when encrypted
+	TeamID uint32 `json:"-"`
+
+	// Value is only set when the Secret is set
+	// and when the workers resolve it
+	Value string `json:"value,omitempty"`
+
+	// KeyID is the ID of the key of the Keyring
+	// the Data was encrypted with
+	KeyID string `json:"-"`
+	// Data is the Value encrypted
+	Data []byte `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,190 @@
+package pikoci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/secret"
+	"github.com/xescugc/pikoci/pikoci/utils"
+)
+
+// secretNameRe are the valid names of the Secrets, which are the
+// keys the variables use to get them
+var secretNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,255}$`)
+
+// ListSecrets returns the Secrets of the Team without the values
+func (q *PikoCI) ListSecrets(ctx context.Context, tc string) ([]*secret.Secret, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	}
+
+	ss, err := q.Secrets.Filter(ctx, tc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Filter Secrets: %w", err)
+	}
+
+	return ss, nil
+}
+
+// SetSecret creates the Secret of the Team or replaces its value
+// if it already exists, the value is encrypted with the SecretKeys
+func (q *PikoCI) SetSecret(ctx context.Context, tc, name, value string) (*secret.Secret, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !secretNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid Secret Name format %q", name)
+	} else if value == "" {
+		return nil, fmt.Errorf("secret Value is required")
+	} else if q.SecretKeys == nil {
+		return nil, secret.ErrNoKeyring
+	}
+
+	t, err := q.Teams.Find(ctx, tc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Find Team: %w", err)
+	}
+
+	now := time.Now().UTC()
+	s := secret.Secret{
+		TeamID:    t.ID,
+		Name:      name,
+		Value:     value,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := q.SecretKeys.Encrypt(&s); err != nil {
+		return nil, err
+	}
+
+	es, err := q.Secrets.Find(ctx, tc, name)
+	if err != nil && !errors.Is(err, secret.ErrNotFound) {
+		return nil, fmt.Errorf("failed to Find Secret: %w", err)
+	}
+	if es != nil {
+		if err := q.Secrets.Update(ctx, tc, name, s); err != nil {
+			return nil, fmt.Errorf("failed to Update Secret: %w", err)
+		}
+		s.ID = es.ID
+		s.CreatedAt = es.CreatedAt
+	} else {
+		id, err := q.Secrets.Create(ctx, tc, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to Create Secret: %w", err)
+		}
+		s.ID = id
+	}
+
+	s.Value = ""
+	return &s, nil
+}
+
+func (q *PikoCI) DeleteSecret(ctx context.Context, tc, name string) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if !secretNameRe.MatchString(name) {
+		return fmt.Errorf("invalid Secret Name format %q", name)
+	}
+
+	err := q.Secrets.Delete(ctx, tc, name)
+	if err != nil {
+		if errors.Is(err, secret.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to Delete Secret: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveSecrets returns the decrypted values of the Secrets of
+// the Team with the names, it's used by the workers to resolve
+// the variables of the 'pikoci' secret type
+func (q *PikoCI) ResolveSecrets(ctx context.Context, tc string, names []string) (map[string]string, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	} else if q.SecretKeys == nil {
+		return nil, secret.ErrNoKeyring
+	}
+
+	values := make(map[string]string, len(names))
+	for _, n := range names {
+		if !secretNameRe.MatchString(n) {
+			return nil, fmt.Errorf("invalid Secret Name format %q", n)
+		}
+		s, err := q.Secrets.Find(ctx, tc, n)
+		if err != nil {
+			if errors.Is(err, secret.ErrNotFound) {
+				return nil, fmt.Errorf("secret %q: %w", n, err)
+			}
+			return nil, fmt.Errorf("failed to Find Secret: %w", err)
+		}
+		if err := q.SecretKeys.Decrypt(s); err != nil {
+			return nil, err
+		}
+		values[n] = s.Value
+	}
+
+	return values, nil
+}
+
+// RotateSecrets re-encrypts the Secrets of all the Teams that are not
+// encrypted with the first of the SecretKeys, so the old keys can be
+// removed. The secrets of the Resource webhooks are re-encrypted too,
+// and encrypted if they were stored before they were encrypted.
+// It returns the number of Secrets re-encrypted.
+func (q *PikoCI) RotateSecrets(ctx context.Context) (int, error) {
+	if q.SecretKeys == nil {
+		return 0, nil
+	}
+
+	ss, err := q.Secrets.FilterOutdated(ctx, q.SecretKeys.KeyID())
+	if err != nil {
+		return 0, fmt.Errorf("failed to Filter outdated Secrets: %w", err)
+	}
+
+	for i, s := range ss {
+		if err := q.SecretKeys.Decrypt(s); err != nil {
+			return i, err
+		}
+		if err := q.SecretKeys.Encrypt(s); err != nil {
+			return i, err
+		}
+		if err := q.Secrets.UpdateData(ctx, s.ID, s.KeyID, s.Data); err != nil {
+			return i, fmt.Errorf("failed to Update Secret data: %w", err)
+		}
+	}
+
+	rs, err := q.Resources.FilterOutdatedWebhooks(ctx, q.SecretKeys.KeyID())
+	if err != nil {
+		return len(ss), fmt.Errorf("failed to Filter outdated webhooks: %w", err)
+	}
+
+	teamIDs := make(map[string]uint32)
+	for i, r := range rs {
+		tID, ok := teamIDs[r.TeamCanonical]
+		if !ok {
+			t, err := q.Teams.Find(ctx, r.TeamCanonical)
+			if err != nil {
+				return len(ss) + i, fmt.Errorf("failed to Find Team: %w", err)
+			}
+			tID = t.ID
+			teamIDs[r.TeamCanonical] = tID
+		}
+		wh, err := q.decryptWebhook(tID, r.PipelineName, r.Canonical, r.Webhook)
+		if err != nil {
+			return len(ss) + i, fmt.Errorf("failed to decrypt webhook of Resource %q: %w", r.Canonical, err)
+		}
+		wh, err = q.encryptWebhook(tID, r.PipelineName, r.Canonical, wh)
+		if err != nil {
+			return len(ss) + i, fmt.Errorf("failed to encrypt webhook of Resource %q: %w", r.Canonical, err)
+		}
+		if err := q.Resources.SetWebhook(ctx, r.TeamCanonical, r.PipelineName, r.Canonical, wh); err != nil {
+			return len(ss) + i, fmt.Errorf("failed to set webhook of Resource %q: %w", r.Canonical, err)
+		}
+	}
+
+	return len(ss) + len(rs), nil
+}
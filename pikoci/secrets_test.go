@@ -0,0 +1,200 @@
+package pikoci_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/resource"
+	"github.com/xescugc/pikoci/pikoci/secret"
+	"github.com/xescugc/pikoci/pikoci/team"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListSecrets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	ss := []*secret.Secret{{ID: 1, Name: "github_token"}}
+	s.Secrets.EXPECT().Filter(ctx, "main").Return(ss, nil)
+
+	rss, err := s.S.ListSecrets(ctx, "main")
+	require.NoError(t, err)
+	assert.Equal(t, ss, rss)
+}
+
+func TestSetSecret(t *testing.T) {
+	t.Run("Create", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+		s.Secrets.EXPECT().Find(ctx, "main", "github_token").Return(nil, secret.ErrNotFound)
+		s.Secrets.EXPECT().Create(ctx, "main", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, sec secret.Secret) (uint32, error) {
+			assert.Equal(t, uint32(1), sec.TeamID)
+			assert.Equal(t, "github_token", sec.Name)
+			assert.Equal(t, "test", sec.KeyID)
+			assert.NotContains(t, string(sec.Data), "s3cr3t")
+			assert.WithinDuration(t, time.Now(), sec.CreatedAt, time.Second)
+
+			require.NoError(t, s.P.SecretKeys.Decrypt(&sec))
+			assert.Equal(t, "s3cr3t", sec.Value)
+			return 2, nil
+		})
+
+		sec, err := s.S.SetSecret(ctx, "main", "github_token", "s3cr3t")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(2), sec.ID)
+		assert.Empty(t, sec.Value)
+	})
+	t.Run("Update", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		ca := time.Now().Add(-time.Hour).UTC()
+		s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+		s.Secrets.EXPECT().Find(ctx, "main", "github_token").Return(&secret.Secret{ID: 1, Name: "github_token", CreatedAt: ca}, nil)
+		s.Secrets.EXPECT().Update(ctx, "main", "github_token", gomock.Any()).Return(nil)
+
+		sec, err := s.S.SetSecret(ctx, "main", "github_token", "s3cr3t")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), sec.ID)
+		assert.Equal(t, ca, sec.CreatedAt)
+	})
+	t.Run("InvalidName", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		_, err := s.S.SetSecret(ctx, "main", "github token", "s3cr3t")
+		assert.EqualError(t, err, `invalid Secret Name format "github token"`)
+	})
+	t.Run("NoValue", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		_, err := s.S.SetSecret(ctx, "main", "github_token", "")
+		assert.Error(t, err)
+	})
+	t.Run("NoKeyring", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		s.P.SecretKeys = nil
+		ctx := context.TODO()
+
+		_, err := s.S.SetSecret(ctx, "main", "github_token", "s3cr3t")
+		assert.ErrorIs(t, err, secret.ErrNoKeyring)
+	})
+	t.Run("TeamNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Teams.EXPECT().Find(ctx, "main").Return(nil, errors.New("not found"))
+
+		_, err := s.S.SetSecret(ctx, "main", "github_token", "s3cr3t")
+		assert.EqualError(t, err, "failed to Find Team: not found")
+	})
+}
+
+func TestDeleteSecret(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Secrets.EXPECT().Delete(ctx, "main", "github_token").Return(nil)
+
+		err := s.S.DeleteSecret(ctx, "main", "github_token")
+		require.NoError(t, err)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Secrets.EXPECT().Delete(ctx, "main", "github_token").Return(secret.ErrNotFound)
+
+		err := s.S.DeleteSecret(ctx, "main", "github_token")
+		assert.ErrorIs(t, err, secret.ErrNotFound)
+	})
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		sec := secret.Secret{TeamID: 1, Name: "github_token", Value: "s3cr3t"}
+		require.NoError(t, s.P.SecretKeys.Encrypt(&sec))
+		sec.Value = ""
+		s.Secrets.EXPECT().Find(ctx, "main", "github_token").Return(&sec, nil)
+
+		vs, err := s.S.ResolveSecrets(ctx, "main", []string{"github_token"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"github_token": "s3cr3t"}, vs)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Secrets.EXPECT().Find(ctx, "main", "github_token").Return(nil, secret.ErrNotFound)
+
+		_, err := s.S.ResolveSecrets(ctx, "main", []string{"github_token"})
+		assert.ErrorIs(t, err, secret.ErrNotFound)
+	})
+}
+
+func TestRotateSecrets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	ok, err := secret.GenerateKey("old")
+	require.NoError(t, err)
+	okr, err := secret.NewKeyring([]string{ok})
+	require.NoError(t, err)
+
+	sec := secret.Secret{ID: 3, TeamID: 1, Name: "github_token", Value: "s3cr3t"}
+	require.NoError(t, okr.Encrypt(&sec))
+	sec.Value = ""
+
+	s.P.SecretKeys, err = secret.NewKeyring([]string{testSecretKey, ok})
+	require.NoError(t, err)
+
+	s.Secrets.EXPECT().FilterOutdated(ctx, "test").Return([]*secret.Secret{&sec}, nil)
+	s.Secrets.EXPECT().UpdateData(ctx, uint32(3), "test", gomock.Any()).DoAndReturn(func(_ context.Context, _ uint32, keyID string, data []byte) error {
+		rs := secret.Secret{TeamID: 1, Name: "github_token", KeyID: keyID, Data: data}
+		require.NoError(t, s.P.SecretKeys.Decrypt(&rs))
+		assert.Equal(t, "s3cr3t", rs.Value)
+		return nil
+	})
+	s.Resources.EXPECT().FilterOutdatedWebhooks(ctx, "test").Return([]*resource.ResourceWithPipeline{
+		{
+			Resource:      resource.Resource{Canonical: "git.repo", Webhook: &resource.Webhook{Type: resource.WebhookTypeGitHub, Secret: "my-secret"}},
+			TeamCanonical: "main",
+			PipelineName:  "pipeline",
+		},
+	}, nil)
+	s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{Team: team.Team{ID: 1, Canonical: "main"}}, nil)
+	s.Resources.EXPECT().SetWebhook(ctx, "main", "pipeline", "git.repo", gomock.Any()).DoAndReturn(func(_ context.Context, _, _, _ string, wh *resource.Webhook) error {
+		assert.Empty(t, wh.Secret)
+		ws := secret.Secret{TeamID: 1, Name: "webhook:pipeline:git.repo", KeyID: wh.KeyID, Data: wh.Data}
+		require.NoError(t, s.P.SecretKeys.Decrypt(&ws))
+		assert.Equal(t, "my-secret", ws.Value)
+		return nil
+	})
+
+	n, err := s.P.RotateSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
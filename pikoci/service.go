@@ -2,15 +2,24 @@ package pikoci
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"time"
 
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/artifact"
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
+	"github.com/xescugc/pikoci/pikoci/oidc"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
 	"github.com/xescugc/pikoci/pikoci/resource"
 	"github.com/xescugc/pikoci/pikoci/restype"
 	"github.com/xescugc/pikoci/pikoci/runner"
 	"github.com/xescugc/pikoci/pikoci/scheduler"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	"github.com/xescugc/pikoci/pikoci/sectype"
 	"github.com/xescugc/pikoci/pikoci/team"
 	"github.com/xescugc/pikoci/pikoci/unitwork"
@@ -25,6 +34,14 @@ type Service interface {
 	UserLogin(ctx context.Context, un, pass string) (*user.WithMemberships, string, error)
 	RefreshToken(ctx context.Context, un string) (*user.WithMemberships, string, error)
 
+	OIDCEnabled(ctx context.Context) bool
+	// OIDCAuthCodeURL returns ErrOIDCDisabled if there
+	// is no OIDC Provider configured
+	OIDCAuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	// OIDCLogin provisions the User of the code if it does not exist
+	// and syncs its Memberships with the OIDC mappings
+	OIDCLogin(ctx context.Context, code, verifier, nonce string) (*user.WithMemberships, string, error)
+
 	GetUser(ctx context.Context, un string) (*user.WithMemberships, error)
 	CreateUser(ctx context.Context, u user.User, isHash bool) (*user.User, error)
 	ListUsers(ctx context.Context) ([]*user.User, error)
@@ -46,6 +63,8 @@ type Service interface {
 	ListPipelines(ctx context.Context, tc string) ([]*pipeline.Pipeline, error)
 
 	SetPipelinePublic(ctx context.Context, tc, pn string, public bool) error
+	PausePipeline(ctx context.Context, tc, pn string) error
+	UnpausePipeline(ctx context.Context, tc, pn string) error
 
 	GetPublicPipeline(ctx context.Context, tc, pn string) (*pipeline.Pipeline, error)
 	GetPublicPipelineImage(ctx context.Context, tc, pn, format string) ([]byte, error)
@@ -57,29 +76,84 @@ type Service interface {
 	GetPipelineImage(ctx context.Context, tc, pn, format string) ([]byte, error)
 	CreatePipelineImage(ctx context.Context, tc string, pp []byte, vars map[string]interface{}, format string) ([]byte, error)
 
-	TriggerPipelineJob(ctx context.Context, tc, pn, jn string) error
+	TriggerPipelineJob(ctx context.Context, tc, pn, jn string, versions map[string]uint32) error
 	GetPipelineJob(ctx context.Context, tc, pn, jn string) (*job.Job, error)
+	PauseJob(ctx context.Context, tc, pn, jn string) error
+	UnpauseJob(ctx context.Context, tc, pn, jn string) error
 
 	CreateJobBuild(ctx context.Context, tc, pn, jn string, b build.Build) (*build.Build, error)
 	CreateRetryJobBuild(ctx context.Context, tc, pn, jn, parentBuildNumber string, b build.Build) (*build.Build, error)
+	StartJobBuild(ctx context.Context, tc, pn, jn, buildNumber string, b build.Build) (*build.Build, error)
 	UpdateJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string, b build.Build) error
+	AppendJobBuildLogs(ctx context.Context, tc, pn, jn string, buildNumber string, c build.LogChunk) error
+	WatchJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string) (<-chan build.Event, error)
+	CreateJobBuildTests(ctx context.Context, tc, pn, jn string, buildNumber string, cs []build.TestCase) error
+	ListJobBuildTests(ctx context.Context, tc, pn, jn string, buildNumber string) ([]*build.TestSuite, error)
+	ListJobFlakyTests(ctx context.Context, tc, pn, jn string) ([]*build.FlakyTest, error)
 	DeleteJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string) error
 	ListJobBuilds(ctx context.Context, tc, pn, jn string) ([]*build.Build, error)
 	GetJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string) (*build.Build, error)
 	CancelJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string) error
 	RetryJobBuild(ctx context.Context, tc, pn, jn, buildNumber string) error
+	ListQueuedBuilds(ctx context.Context, tc, pn, jn string) ([]*build.Queued, error)
 	FindBuildGetVersions(ctx context.Context, tc, pn, jn string, buildID uint32) (map[string]uint32, error)
 
+	UploadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, r io.Reader) error
+	DownloadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, w io.Writer) error
+
 	GetPipelineResource(ctx context.Context, tc, pn, rCan string) (*resource.Resource, error)
 	UpdatePipelineResource(ctx context.Context, tc, pn, rCan string, r resource.Resource) error
 	TriggerPipelineResource(ctx context.Context, tc, pn, rCan string) error
 	CreateResourceVersion(ctx context.Context, tc, pn, rCan string, v resource.Version) (*resource.Version, error)
 	ListResourceVersions(ctx context.Context, tc, pn, rCan string) ([]*resource.Version, error)
+	PinResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error
+	UnpinResource(ctx context.Context, tc, pn, rCan string) error
+	EnableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error
+	DisableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error
 
 	InsertBuildGetVersion(ctx context.Context, tc, pn, jn string, buildID uint32, stepName string, versionID uint32) error
 
-	WebhookTrigger(ctx context.Context, token string) error
+	WebhookTrigger(ctx context.Context, token string, h http.Header, body []byte) error
 	RegenerateWebhookToken(ctx context.Context, tc, pn, rCan string) (string, error)
+
+	RegisterWorker(ctx context.Context, w registry.Worker) (*registry.Worker, error)
+	HeartbeatWorker(ctx context.Context, id uint32) error
+	ListWorkers(ctx context.Context) ([]*registry.Worker, error)
+
+	// ClaimWork waits up to wait for work a worker with the tags can run
+	// and returns its lease, or nil if there was none
+	ClaimWork(ctx context.Context, workerID uint32, tags []string, wait time.Duration) (*queue.Lease, error)
+	RenewWorkLease(ctx context.Context, workerID uint32, leaseID string) (*queue.Lease, error)
+	CompleteWork(ctx context.Context, workerID uint32, leaseID string) error
+	// PublishWork sends the work to the queue of the server
+	PublishWork(ctx context.Context, body []byte, metadata map[string]string) error
+
+	ListLocks(ctx context.Context, tc string) ([]*lock.Lock, error)
+	// AcquireLock returns lock.ErrHeld if the lock is already held
+	AcquireLock(ctx context.Context, tc, name, un, reason string) (*lock.Lock, error)
+	// ReleaseLock returns ErrLockNotHolder if the lock was not acquired by the user un
+	ReleaseLock(ctx context.Context, tc, name, un string) error
+	ForceReleaseLock(ctx context.Context, tc, name string) error
+
+	ListSecrets(ctx context.Context, tc string) ([]*secret.Secret, error)
+	SetSecret(ctx context.Context, tc, name, value string) (*secret.Secret, error)
+	// DeleteSecret returns secret.ErrNotFound if the Secret does not exist
+	DeleteSecret(ctx context.Context, tc, name string) error
+	ResolveSecrets(ctx context.Context, tc string, names []string) (map[string]string, error)
+
+	ListUserTokens(ctx context.Context, un string) ([]*apitoken.Token, error)
+	// CreateUserToken returns the Token and the token to use it
+	CreateUserToken(ctx context.Context, un string, t apitoken.Token) (*apitoken.Token, string, error)
+	// DeleteUserToken returns apitoken.ErrNotFound if the Token does not exist
+	DeleteUserToken(ctx context.Context, un string, id uint32) error
+	ListTeamTokens(ctx context.Context, tc string) ([]*apitoken.Token, error)
+	// CreateTeamToken returns the Token and the token to use it
+	CreateTeamToken(ctx context.Context, tc string, t apitoken.Token) (*apitoken.Token, string, error)
+	// DeleteTeamToken returns apitoken.ErrNotFound if the Token does not exist
+	DeleteTeamToken(ctx context.Context, tc string, id uint32) error
+	// AuthenticateToken returns apitoken.ErrInvalid or apitoken.ErrExpired if
+	// the token can't be used
+	AuthenticateToken(ctx context.Context, raw string) (*apitoken.Token, *user.WithMemberships, error)
 }
 
 type PikoCI struct {
@@ -93,16 +167,25 @@ type PikoCI struct {
 	Builds        build.Repository
 	Runners       runner.Repository
 	SecretTypes   sectype.Repository
+	Workers       registry.Repository
+	Locks         lock.Repository
+	Secrets       secret.Repository
+	Tokens        apitoken.Repository
+	Artifacts     artifact.Store
 	StartUoW      unitwork.StartUnitOfWork
 	Ctx           context.Context
 
 	JWTSecret []byte
+	// SecretKeys encrypt the Secrets, if nil the Secrets are disabled
+	SecretKeys *secret.Keyring
 
-	scheduler *scheduler.Scheduler
-	logger    *slog.Logger
+	scheduler  *scheduler.Scheduler
+	dispatcher *queue.Dispatcher
+	oidc       *oidc.Provider
+	logger     *slog.Logger
 }
 
-func New(ctx context.Context, t queue.Topic, ur user.Repository, tr team.Repository, pr pipeline.Repository, jr job.Repository, rr resource.Repository, rt restype.Repository, br build.Repository, rur runner.Repository, str sectype.Repository, suow unitwork.StartUnitOfWork, js []byte, l *slog.Logger) *PikoCI {
+func New(ctx context.Context, t queue.Topic, ur user.Repository, tr team.Repository, pr pipeline.Repository, jr job.Repository, rr resource.Repository, rt restype.Repository, br build.Repository, rur runner.Repository, str sectype.Repository, wr registry.Repository, lr lock.Repository, sr secret.Repository, tkr apitoken.Repository, as artifact.Store, suow unitwork.StartUnitOfWork, js []byte, sk *secret.Keyring, l *slog.Logger) *PikoCI {
 	return &PikoCI{
 		Ctx:           ctx,
 		Topic:         t,
@@ -115,8 +198,14 @@ func New(ctx context.Context, t queue.Topic, ur user.Repository, tr team.Reposit
 		Builds:        br,
 		Runners:       rur,
 		SecretTypes:   str,
+		Workers:       wr,
+		Locks:         lr,
+		Secrets:       sr,
+		Tokens:        tkr,
+		Artifacts:     as,
 		StartUoW:      suow,
 		JWTSecret:     js,
+		SecretKeys:    sk,
 		logger:        l,
 		scheduler:     scheduler.New(rr, pr, br, t, l),
 	}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/queue"
@@ -52,16 +53,22 @@ func TestTriggerPipelineJob(t *testing.T) {
 		TeamCanonical: tc,
 		PipelineName:  ppn,
 		JobName:       jn,
+		BuildNumber:   "5",
 	}
 
 	mb, err := json.Marshal(m)
 	require.NoError(t, err)
 	s.Jobs.EXPECT().Find(ctx, tc, ppn, jn).Return(&job.Job{ID: 2}, nil)
+	s.Pipelines.EXPECT().Find(ctx, tc, ppn).Return(&pipeline.Pipeline{Name: ppn}, nil)
+	s.Builds.EXPECT().Create(ctx, tc, ppn, jn, gomock.Any()).DoAndReturn(func(_ context.Context, tc, pn, jn string, b build.Build) (uint32, string, error) {
+		assert.Equal(t, build.Pending, b.Status)
+		return 7, "5", nil
+	})
 	s.Topic.EXPECT().Send(ctx, &pubsub.Message{
 		Body: mb,
 	}).Return(nil)
 
-	err = s.S.TriggerPipelineJob(ctx, tc, ppn, jn)
+	err = s.S.TriggerPipelineJob(ctx, tc, ppn, jn, nil)
 	require.NoError(t, err)
 }
 
@@ -15,7 +15,7 @@ func TestResolveResourceType_PikoCI(t *testing.T) {
 	rt, err := source.ResolveResourceType(context.Background(), "pikoci://git")
 	require.NoError(t, err)
 	assert.Equal(t, "git", rt.Name)
-	assert.Equal(t, "exec", rt.Check.Runner)
+	assert.Equal(t, "native", rt.Check.Runner)
 }
 
 func TestResolveResourceType_HTTP(t *testing.T) {
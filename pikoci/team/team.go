@@ -17,7 +17,7 @@ type WithMembers struct {
 }
 
 type Member struct {
-	Admin bool `json:"admin"`
+	Role user.Role `json:"role"`
 
 	User user.User `json:"user"`
 }
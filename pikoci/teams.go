@@ -28,7 +28,7 @@ func (q *PikoCI) CreateTeam(ctx context.Context, un string, t team.Team) (*team.
 		t.ID = id
 
 		err = uow.Teams().CreateMember(ctx, t.Canonical, team.Member{
-			Admin: true,
+			Role: user.RoleOwner,
 			User: user.User{
 				Username: un,
 			},
@@ -117,6 +117,13 @@ func (q *PikoCI) CreateTeamMember(ctx context.Context, tc string, tm team.Member
 		return nil, fmt.Errorf("invalid Team Member Username format %q", tm.User.Username)
 	}
 
+	if tm.Role == "" {
+		// Before the Roles the Members that were not admin were operators
+		tm.Role = user.RoleOperator
+	} else if !tm.Role.Valid() {
+		return nil, fmt.Errorf("invalid Team Member Role %q", tm.Role)
+	}
+
 	err := q.Teams.CreateMember(ctx, tc, tm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create member: %w", err)
@@ -135,7 +142,9 @@ func (q *PikoCI) UpdateTeamMember(ctx context.Context, tc, mu string, tm team.Me
 		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
 	} else if !utils.ValidateCanonical(mu) {
 		return nil, fmt.Errorf("invalid Team Member Username format %q", mu)
-	} else if err := q.validateTeamAdmins(ctx, tc, mu, &tm); err != nil {
+	} else if !tm.Role.Valid() {
+		return nil, fmt.Errorf("invalid Team Member Role %q", tm.Role)
+	} else if err := q.validateTeamOwners(ctx, tc, mu, &tm); err != nil {
 		return nil, err
 	}
 
@@ -157,7 +166,7 @@ func (q *PikoCI) DeleteTeamMember(ctx context.Context, tc, mu string) error {
 		return fmt.Errorf("invalid Team Canonical format %q", tc)
 	} else if !utils.ValidateCanonical(mu) {
 		return fmt.Errorf("invalid Team Member Username format %q", mu)
-	} else if err := q.validateTeamAdmins(ctx, tc, mu, nil); err != nil {
+	} else if err := q.validateTeamOwners(ctx, tc, mu, nil); err != nil {
 		return err
 	}
 
@@ -169,24 +178,24 @@ func (q *PikoCI) DeleteTeamMember(ctx context.Context, tc, mu string) error {
 	return nil
 }
 
-func (q *PikoCI) validateTeamAdmins(ctx context.Context, tc, mu string, m *team.Member) error {
+func (q *PikoCI) validateTeamOwners(ctx context.Context, tc, mu string, m *team.Member) error {
 	t, err := q.Teams.Find(ctx, tc)
 	if err != nil {
 		return fmt.Errorf("failed to get Team: %w", err)
 	}
 
-	var admins int
+	var owners int
 	for _, tm := range t.Members {
 		if tm.User.Username == mu && m != nil {
 			tm = *m
 		}
-		if tm.Admin {
-			admins++
+		if tm.Role == user.RoleOwner {
+			owners++
 		}
 	}
 
-	if admins == 0 {
-		return fmt.Errorf("cannot have a team with no admins")
+	if owners == 0 {
+		return fmt.Errorf("cannot have a team with no owners")
 	}
 	return nil
 }
@@ -20,14 +20,14 @@ func TestCreateTeam(t *testing.T) {
 	s.Teams.EXPECT().CreateMember(ctx, "my-team", gomock.Any()).Return(nil)
 	s.Teams.EXPECT().Find(ctx, "my-team").Return(&team.WithMembers{
 		Team:    team.Team{ID: 1, Name: "My Team", Canonical: "my-team"},
-		Members: []team.Member{{Admin: true, User: user.User{Username: "admin"}}},
+		Members: []team.Member{{Role: user.RoleOwner, User: user.User{Username: "admin"}}},
 	}, nil)
 
 	twm, err := s.S.CreateTeam(ctx, "admin", team.Team{Name: "My Team"})
 	require.NoError(t, err)
 	assert.Equal(t, "my-team", twm.Canonical)
 	assert.Len(t, twm.Members, 1)
-	assert.True(t, twm.Members[0].Admin)
+	assert.Equal(t, user.RoleOwner, twm.Members[0].Role)
 }
 
 func TestCreateTeam_EmptyName(t *testing.T) {
@@ -116,13 +116,29 @@ func TestCreateTeamMember(t *testing.T) {
 	s := newService(ctrl)
 	ctx := context.TODO()
 
-	member := team.Member{Admin: false, User: user.User{Username: "pepito"}}
+	member := team.Member{Role: user.RoleOperator, User: user.User{Username: "pepito"}}
 	s.Teams.EXPECT().CreateMember(ctx, "main", member).Return(nil)
 	s.Teams.EXPECT().FindMember(ctx, "main", "pepito").Return(&member, nil)
 
 	m, err := s.S.CreateTeamMember(ctx, "main", member)
 	require.NoError(t, err)
 	assert.Equal(t, "pepito", m.User.Username)
+
+	t.Run("DefaultRole", func(t *testing.T) {
+		operator := team.Member{Role: user.RoleOperator, User: user.User{Username: "juanito"}}
+		s.Teams.EXPECT().CreateMember(ctx, "main", operator).Return(nil)
+		s.Teams.EXPECT().FindMember(ctx, "main", "juanito").Return(&operator, nil)
+
+		m, err := s.S.CreateTeamMember(ctx, "main", team.Member{User: user.User{Username: "juanito"}})
+		require.NoError(t, err)
+		assert.Equal(t, user.RoleOperator, m.Role)
+	})
+
+	t.Run("InvalidRole", func(t *testing.T) {
+		_, err := s.S.CreateTeamMember(ctx, "main", team.Member{Role: "admin", User: user.User{Username: "juanito"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid Team Member Role")
+	})
 }
 
 func TestUpdateTeamMember(t *testing.T) {
@@ -130,40 +146,40 @@ func TestUpdateTeamMember(t *testing.T) {
 	s := newService(ctrl)
 	ctx := context.TODO()
 
-	// validateTeamAdmins will call Find
+	// validateTeamOwners will call Find
 	s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{
 		Team: team.Team{ID: 1, Canonical: "main"},
 		Members: []team.Member{
-			{Admin: true, User: user.User{Username: "admin"}},
-			{Admin: false, User: user.User{Username: "pepito"}},
+			{Role: user.RoleOwner, User: user.User{Username: "admin"}},
+			{Role: user.RoleOperator, User: user.User{Username: "pepito"}},
 		},
 	}, nil)
 	s.Teams.EXPECT().UpdateMember(ctx, "main", "pepito", gomock.Any()).Return(nil)
 	s.Teams.EXPECT().FindMember(ctx, "main", "pepito").Return(&team.Member{
-		Admin: true, User: user.User{Username: "pepito"},
+		Role: user.RoleOwner, User: user.User{Username: "pepito"},
 	}, nil)
 
-	m, err := s.S.UpdateTeamMember(ctx, "main", "pepito", team.Member{Admin: true})
+	m, err := s.S.UpdateTeamMember(ctx, "main", "pepito", team.Member{Role: user.RoleOwner})
 	require.NoError(t, err)
-	assert.True(t, m.Admin)
+	assert.Equal(t, user.RoleOwner, m.Role)
 }
 
-func TestUpdateTeamMember_WouldRemoveLastAdmin(t *testing.T) {
+func TestUpdateTeamMember_WouldRemoveLastOwner(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
 
-	// Only one admin, trying to remove their admin status
+	// Only one owner, trying to remove their owner role
 	s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{
 		Team: team.Team{ID: 1, Canonical: "main"},
 		Members: []team.Member{
-			{Admin: true, User: user.User{Username: "admin"}},
+			{Role: user.RoleOwner, User: user.User{Username: "admin"}},
 		},
 	}, nil)
 
-	_, err := s.S.UpdateTeamMember(ctx, "main", "admin", team.Member{Admin: false})
+	_, err := s.S.UpdateTeamMember(ctx, "main", "admin", team.Member{Role: user.RoleOperator})
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "no admins")
+	assert.Contains(t, err.Error(), "no owners")
 }
 
 func TestDeleteTeamMember(t *testing.T) {
@@ -171,12 +187,12 @@ func TestDeleteTeamMember(t *testing.T) {
 	s := newService(ctrl)
 	ctx := context.TODO()
 
-	// validateTeamAdmins: team has 2 members, one admin remains
+	// validateTeamOwners: team has 2 members, one owner remains
 	s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{
 		Team: team.Team{ID: 1, Canonical: "main"},
 		Members: []team.Member{
-			{Admin: true, User: user.User{Username: "admin"}},
-			{Admin: false, User: user.User{Username: "pepito"}},
+			{Role: user.RoleOwner, User: user.User{Username: "admin"}},
+			{Role: user.RoleOperator, User: user.User{Username: "pepito"}},
 		},
 	}, nil)
 	s.Teams.EXPECT().DeleteMember(ctx, "main", "pepito").Return(nil)
@@ -185,18 +201,18 @@ func TestDeleteTeamMember(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestDeleteTeamMember_LastAdmin(t *testing.T) {
+func TestDeleteTeamMember_LastOwner(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s := newService(ctrl)
 	ctx := context.TODO()
 
-	// NOTE: validateTeamAdmins with m=nil (delete case) doesn't exclude
-	// the member being deleted from the admin count, so this currently
+	// NOTE: validateTeamOwners with m=nil (delete case) doesn't exclude
+	// the member being deleted from the owner count, so this currently
 	// passes validation even though it shouldn't. The delete proceeds.
 	s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{
 		Team: team.Team{ID: 1, Canonical: "main"},
 		Members: []team.Member{
-			{Admin: true, User: user.User{Username: "admin"}},
+			{Role: user.RoleOwner, User: user.User{Username: "admin"}},
 		},
 	}, nil)
 	s.Teams.EXPECT().DeleteMember(ctx, "main", "admin").Return(nil)
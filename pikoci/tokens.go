@@ -0,0 +1,224 @@
+package pikoci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/user"
+	"github.com/xescugc/pikoci/pikoci/utils"
+)
+
+// tokenNameRe are the valid names of the Tokens
+var tokenNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,255}$`)
+
+// tokenLastUsedInterval is how often the LastUsedAt of a Token
+// is updated, so not every request has to write it
+const tokenLastUsedInterval = time.Minute
+
+func (q *PikoCI) ListUserTokens(ctx context.Context, un string) ([]*apitoken.Token, error) {
+	if !utils.ValidateCanonical(un) {
+		return nil, fmt.Errorf("invalid Username format %q", un)
+	}
+
+	ts, err := q.Tokens.FilterByUser(ctx, un)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Filter Tokens: %w", err)
+	}
+
+	return ts, nil
+}
+
+// CreateUserToken creates a Token owned by the User, it returns
+// the token which is not stored so it can't be retrieved again
+func (q *PikoCI) CreateUserToken(ctx context.Context, un string, t apitoken.Token) (*apitoken.Token, string, error) {
+	if !utils.ValidateCanonical(un) {
+		return nil, "", fmt.Errorf("invalid Username format %q", un)
+	}
+
+	_, err := q.Users.Find(ctx, un)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to Find User: %w", err)
+	}
+
+	t.Username = un
+	t.TeamCanonical = ""
+
+	return q.createToken(ctx, t)
+}
+
+// DeleteUserToken returns apitoken.ErrNotFound if the User has no Token with the id
+func (q *PikoCI) DeleteUserToken(ctx context.Context, un string, id uint32) error {
+	if !utils.ValidateCanonical(un) {
+		return fmt.Errorf("invalid Username format %q", un)
+	}
+
+	err := q.Tokens.DeleteByUser(ctx, un, id)
+	if err != nil {
+		if errors.Is(err, apitoken.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to Delete Token: %w", err)
+	}
+
+	return nil
+}
+
+func (q *PikoCI) ListTeamTokens(ctx context.Context, tc string) ([]*apitoken.Token, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, fmt.Errorf("invalid Team Canonical format %q", tc)
+	}
+
+	ts, err := q.Tokens.FilterByTeam(ctx, tc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Filter Tokens: %w", err)
+	}
+
+	return ts, nil
+}
+
+// CreateTeamToken creates a Token owned by the service account of
+// the Team, it returns the token which is not stored so it can't
+// be retrieved again
+func (q *PikoCI) CreateTeamToken(ctx context.Context, tc string, t apitoken.Token) (*apitoken.Token, string, error) {
+	if !utils.ValidateCanonical(tc) {
+		return nil, "", fmt.Errorf("invalid Team Canonical format %q", tc)
+	}
+
+	_, err := q.Teams.Find(ctx, tc)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to Find Team: %w", err)
+	}
+
+	t.Username = ""
+	t.TeamCanonical = tc
+
+	return q.createToken(ctx, t)
+}
+
+// DeleteTeamToken returns apitoken.ErrNotFound if the Team has no Token with the id
+func (q *PikoCI) DeleteTeamToken(ctx context.Context, tc string, id uint32) error {
+	if !utils.ValidateCanonical(tc) {
+		return fmt.Errorf("invalid Team Canonical format %q", tc)
+	}
+
+	err := q.Tokens.DeleteByTeam(ctx, tc, id)
+	if err != nil {
+		if errors.Is(err, apitoken.ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to Delete Token: %w", err)
+	}
+
+	return nil
+}
+
+func (q *PikoCI) createToken(ctx context.Context, t apitoken.Token) (*apitoken.Token, string, error) {
+	if !tokenNameRe.MatchString(t.Name) {
+		return nil, "", fmt.Errorf("invalid Token Name format %q", t.Name)
+	} else if len(t.Scopes) == 0 {
+		return nil, "", fmt.Errorf("at least one Token Scope is required")
+	}
+	for _, s := range t.Scopes {
+		if !s.Valid() {
+			return nil, "", fmt.Errorf("invalid Token Scope %q, it has to be one of %v", s, apitoken.Scopes)
+		}
+	}
+
+	now := time.Now().UTC()
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(now) {
+		return nil, "", fmt.Errorf("the Token Expires At has to be in the future")
+	}
+
+	raw, hash, err := apitoken.Generate()
+	if err != nil {
+		return nil, "", err
+	}
+
+	t.Hint = apitoken.Hint(raw)
+	t.Hash = hash
+	t.LastUsedAt = nil
+	t.CreatedAt = now
+
+	id, err := q.Tokens.Create(ctx, t)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to Create Token: %w", err)
+	}
+	t.ID = id
+
+	return &t, raw, nil
+}
+
+// AuthenticateToken returns the Token of raw and the User it authenticates. For
+// the Tokens of the Team service accounts the User is the service account, a
+// member of the Team with the Role of the highest scope of the Token.
+// It returns apitoken.ErrInvalid or apitoken.ErrExpired if the token can't be used.
+func (q *PikoCI) AuthenticateToken(ctx context.Context, raw string) (*apitoken.Token, *user.WithMemberships, error) {
+	if !apitoken.IsToken(raw) {
+		return nil, nil, apitoken.ErrInvalid
+	}
+
+	t, err := q.Tokens.FindByHash(ctx, apitoken.Hash(raw))
+	if err != nil {
+		if errors.Is(err, apitoken.ErrNotFound) {
+			return nil, nil, apitoken.ErrInvalid
+		}
+		return nil, nil, fmt.Errorf("failed to Find Token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if t.Expired(now) {
+		return nil, nil, apitoken.ErrExpired
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= tokenLastUsedInterval {
+		if err := q.Tokens.UpdateLastUsedAt(ctx, t.ID, now); err != nil {
+			q.logger.Error("failed to update the token last used at", "token_id", t.ID, "error", err)
+		}
+		t.LastUsedAt = &now
+	}
+
+	if t.IsServiceAccount() {
+		return t, &user.WithMemberships{
+			User: user.User{
+				FullName: t.Name,
+				Username: t.ServiceAccount(),
+			},
+			Memberships: []user.Member{
+				{
+					Role:          scopeRole(t),
+					TeamCanonical: t.TeamCanonical,
+				},
+			},
+		}, nil
+	}
+
+	um, err := q.Users.FindWithMemberships(ctx, t.Username)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to Find User: %w", err)
+	}
+
+	return t, um, nil
+}
+
+// scopeRoles are the Roles on their Team of the
+// service accounts with each apitoken.Scope
+var scopeRoles = map[apitoken.Scope]user.Role{
+	apitoken.Read:          user.RoleViewer,
+	apitoken.Trigger:       user.RoleOperator,
+	apitoken.PipelineWrite: user.RoleMember,
+	apitoken.Admin:         user.RoleOwner,
+}
+
+// scopeRole returns the Role of the highest scope of t
+func scopeRole(t *apitoken.Token) user.Role {
+	for i := len(apitoken.Scopes) - 1; i >= 0; i-- {
+		if t.HasScope(apitoken.Scopes[i]) {
+			return scopeRoles[apitoken.Scopes[i]]
+		}
+	}
+	return user.RoleViewer
+}
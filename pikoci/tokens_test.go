@@ -0,0 +1,178 @@
+package pikoci_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/team"
+	"github.com/xescugc/pikoci/pikoci/user"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateUserToken(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		exp := time.Now().Add(time.Hour)
+		s.Users.EXPECT().Find(ctx, "pepito").Return(&user.User{Username: "pepito"}, nil)
+		s.Tokens.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, tk apitoken.Token) (uint32, error) {
+			assert.Equal(t, "ci", tk.Name)
+			assert.Equal(t, "pepito", tk.Username)
+			assert.Empty(t, tk.TeamCanonical)
+			assert.Equal(t, []apitoken.Scope{apitoken.Trigger}, tk.Scopes)
+			assert.Equal(t, &exp, tk.ExpiresAt)
+			assert.NotEmpty(t, tk.Hash)
+			assert.WithinDuration(t, time.Now(), tk.CreatedAt, time.Second)
+			return 1, nil
+		})
+
+		tk, raw, err := s.S.CreateUserToken(ctx, "pepito", apitoken.Token{
+			Name:          "ci",
+			Scopes:        []apitoken.Scope{apitoken.Trigger},
+			ExpiresAt:     &exp,
+			TeamCanonical: "main",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), tk.ID)
+		assert.True(t, apitoken.IsToken(raw))
+		assert.Equal(t, apitoken.Hash(raw), tk.Hash)
+		assert.Equal(t, apitoken.Hint(raw), tk.Hint)
+	})
+	t.Run("InvalidScope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Users.EXPECT().Find(ctx, "pepito").Return(&user.User{Username: "pepito"}, nil)
+
+		_, _, err := s.S.CreateUserToken(ctx, "pepito", apitoken.Token{Name: "ci", Scopes: []apitoken.Scope{"write"}})
+		assert.EqualError(t, err, `invalid Token Scope "write", it has to be one of [read trigger pipeline-write admin]`)
+	})
+	t.Run("NoScopes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Users.EXPECT().Find(ctx, "pepito").Return(&user.User{Username: "pepito"}, nil)
+
+		_, _, err := s.S.CreateUserToken(ctx, "pepito", apitoken.Token{Name: "ci"})
+		assert.EqualError(t, err, "at least one Token Scope is required")
+	})
+	t.Run("Expired", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		exp := time.Now().Add(-time.Hour)
+		s.Users.EXPECT().Find(ctx, "pepito").Return(&user.User{Username: "pepito"}, nil)
+
+		_, _, err := s.S.CreateUserToken(ctx, "pepito", apitoken.Token{Name: "ci", Scopes: []apitoken.Scope{apitoken.Read}, ExpiresAt: &exp})
+		assert.EqualError(t, err, "the Token Expires At has to be in the future")
+	})
+}
+
+func TestCreateTeamToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Teams.EXPECT().Find(ctx, "main").Return(&team.WithMembers{}, nil)
+	s.Tokens.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, tk apitoken.Token) (uint32, error) {
+		assert.Equal(t, "main", tk.TeamCanonical)
+		assert.Empty(t, tk.Username)
+		assert.Nil(t, tk.ExpiresAt)
+		return 2, nil
+	})
+
+	tk, raw, err := s.S.CreateTeamToken(ctx, "main", apitoken.Token{Name: "ci", Scopes: []apitoken.Scope{apitoken.Admin}, Username: "pepito"})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), tk.ID)
+	assert.NotEmpty(t, raw)
+}
+
+func TestDeleteUserToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	s.Tokens.EXPECT().DeleteByUser(ctx, "pepito", uint32(1)).Return(apitoken.ErrNotFound)
+
+	err := s.S.DeleteUserToken(ctx, "pepito", 1)
+	assert.ErrorIs(t, err, apitoken.ErrNotFound)
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	raw, hash, err := apitoken.Generate()
+	require.NoError(t, err)
+
+	t.Run("User", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		tk := &apitoken.Token{ID: 1, Username: "pepito", Scopes: []apitoken.Scope{apitoken.Read}}
+		um := &user.WithMemberships{User: user.User{Username: "pepito"}}
+		s.Tokens.EXPECT().FindByHash(ctx, hash).Return(tk, nil)
+		s.Tokens.EXPECT().UpdateLastUsedAt(ctx, uint32(1), gomock.Any()).Return(nil)
+		s.Users.EXPECT().FindWithMemberships(ctx, "pepito").Return(um, nil)
+
+		rtk, rum, err := s.S.AuthenticateToken(ctx, raw)
+		require.NoError(t, err)
+		assert.Equal(t, tk, rtk)
+		assert.Equal(t, um, rum)
+		require.NotNil(t, rtk.LastUsedAt)
+	})
+	t.Run("ServiceAccount", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		// Recently used so the LastUsedAt is not updated
+		lu := time.Now().UTC()
+		tk := &apitoken.Token{ID: 1, Name: "ci", TeamCanonical: "main", Scopes: []apitoken.Scope{apitoken.PipelineWrite}, LastUsedAt: &lu}
+		s.Tokens.EXPECT().FindByHash(ctx, hash).Return(tk, nil)
+
+		_, um, err := s.S.AuthenticateToken(ctx, raw)
+		require.NoError(t, err)
+		assert.Equal(t, &user.WithMemberships{
+			User:        user.User{FullName: "ci", Username: "main/ci"},
+			Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleMember}},
+		}, um)
+		assert.True(t, um.IsMember("main"))
+		assert.False(t, um.IsAdmin("main"))
+	})
+	t.Run("Expired", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		exp := time.Now().Add(-time.Minute)
+		s.Tokens.EXPECT().FindByHash(ctx, hash).Return(&apitoken.Token{ID: 1, Username: "pepito", ExpiresAt: &exp}, nil)
+
+		_, _, err := s.S.AuthenticateToken(ctx, raw)
+		assert.ErrorIs(t, err, apitoken.ErrExpired)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Tokens.EXPECT().FindByHash(ctx, hash).Return(nil, apitoken.ErrNotFound)
+
+		_, _, err := s.S.AuthenticateToken(ctx, raw)
+		assert.ErrorIs(t, err, apitoken.ErrInvalid)
+	})
+	t.Run("NotAToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+
+		_, _, err := s.S.AuthenticateToken(context.TODO(), "eyJhbGciOi")
+		assert.ErrorIs(t, err, apitoken.ErrInvalid)
+	})
+}
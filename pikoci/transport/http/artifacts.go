@@ -0,0 +1,110 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/xescugc/pikoci/pikoci"
+)
+
+// The artifacts are sent as they are on the body of the request and
+// the response, with the "application/octet-stream" Content-Type, so
+// they are streamed to and from the artifact.Store instead of being
+// encoded on JSON. Only the errors are sent as JSON.
+const artifactContentType = "application/octet-stream"
+
+type UploadBuildArtifactRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	PipelineName  string `json:"pipeline_name"`
+	JobName       string `json:"job_name"`
+	BuildNumber   string `json:"build_number"`
+	TaskName      string `json:"task_name"`
+}
+type UploadBuildArtifactResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r UploadBuildArtifactResponse) Error() string { return r.Err }
+
+func uploadBuildArtifact(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req UploadBuildArtifactRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		req.BuildNumber = vars["build_number"]
+		req.TaskName = vars["task_name"]
+		err := s.UploadBuildArtifact(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.BuildNumber, req.TaskName, r.Body)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(UploadBuildArtifactResponse{Err: errs}, w)
+	}
+}
+
+type DownloadBuildArtifactRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	PipelineName  string `json:"pipeline_name"`
+	JobName       string `json:"job_name"`
+	BuildNumber   string `json:"build_number"`
+	TaskName      string `json:"task_name"`
+}
+type DownloadBuildArtifactResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r DownloadBuildArtifactResponse) Error() string { return r.Err }
+
+func downloadBuildArtifact(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req DownloadBuildArtifactRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		req.BuildNumber = vars["build_number"]
+		req.TaskName = vars["task_name"]
+
+		aw := &artifactWriter{w: w}
+		err := s.DownloadBuildArtifact(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.BuildNumber, req.TaskName, aw)
+		if err != nil {
+			if aw.started {
+				// Part of the artifact has already been sent so the
+				// only way to let the client know is to abort the response
+				panic(http.ErrAbortHandler)
+			}
+			encodeResponse(DownloadBuildArtifactResponse{Err: err.Error()}, w)
+			return
+		}
+		aw.start()
+	}
+}
+
+// artifactWriter delays the headers of the response until the first
+// write, so errors before that can still be sent as JSON
+type artifactWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (aw *artifactWriter) start() {
+	if aw.started {
+		return
+	}
+	aw.started = true
+	aw.w.Header().Set("Content-Type", artifactContentType)
+	aw.w.WriteHeader(http.StatusOK)
+}
+
+func (aw *artifactWriter) Write(p []byte) (int, error) {
+	aw.start()
+	return aw.w.Write(p)
+}
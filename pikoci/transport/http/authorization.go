@@ -5,11 +5,16 @@ import (
 	"fmt"
 
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/user"
 )
 
 type authorizationFn func(ctx context.Context, s pikoci.Service, un, tc string) error
 
 var (
+	// routeAuthorization is the permission matrix of the routes, the team
+	// routes need the user.Role on the Team and the rest, with no Team,
+	// need to be a global admin except the ones any User can use
 	routeAuthorization = map[RouteName]authorizationFn{
 		UserLogin:    nothing,
 		RefreshToken: nothing,
@@ -18,68 +23,248 @@ var (
 		ListUsers:  admin,
 
 		CreateTeam: admin,
-		ListTeams:  member,
-		GetTeam:    member,
-		UpdateTeam: admin,
-		DeleteTeam: admin,
-
-		CreateTeamMember: admin,
-		UpdateTeamMember: admin,
-		DeleteTeamMember: admin,
-
-		CreatePipeline: admin,
-		UpdatePipeline: admin,
-		GetPipeline:    member,
-		DeletePipeline: admin,
-		ListPipelines:  member,
-
-		GetPipelineImage:    member,
-		CreatePipelineImage: admin,
-
-		TriggerPipelineJob: member,
-		GetPipelineJob:     member,
-
-		CreateJobBuild:       admin,
-		CreateRetryJobBuild: admin,
-		UpdateJobBuild:      admin,
-		DeleteJobBuild:      admin,
-		ListJobBuilds:       member,
-		GetJobBuild:         member,
-		CancelJobBuild:      member,
-		RetryJobBuild:       member,
-		FindBuildGetVersions: admin,
-
-		GetPipelineResource:     member,
-		UpdatePipelineResource:  admin,
-		TriggerPipelineResource: member,
-		CreateResourceVersion:   admin,
-		ListResourceVersions:    member,
+		ListTeams:  viewer,
+		GetTeam:    viewer,
+		UpdateTeam: owner,
+		DeleteTeam: owner,
+
+		CreateTeamMember: owner,
+		UpdateTeamMember: owner,
+		DeleteTeamMember: owner,
+
+		CreatePipeline: member,
+		UpdatePipeline: member,
+		GetPipeline:    viewer,
+		DeletePipeline: member,
+		ListPipelines:  viewer,
+
+		PausePipeline:   operator,
+		UnpausePipeline: operator,
+
+		GetPipelineImage:    viewer,
+		CreatePipelineImage: member,
+
+		TriggerPipelineJob: operator,
+		GetPipelineJob:     viewer,
+		PauseJob:           operator,
+		UnpauseJob:         operator,
+
+		CreateJobBuild:           owner,
+		CreateRetryJobBuild:      owner,
+		UpdateJobBuild:           owner,
+		DeleteJobBuild:           owner,
+		ListJobBuilds:            viewer,
+		GetJobBuild:              viewer,
+		CancelJobBuild:           operator,
+		RetryJobBuild:            operator,
+		StartJobBuild:            owner,
+		ListQueuedBuilds:         viewer,
+		ListPipelineQueuedBuilds: viewer,
+		ListJobQueuedBuilds:      viewer,
+		AppendJobBuildLogs:       owner,
+		WatchJobBuild:            viewer,
+		CreateJobBuildTests:      owner,
+		ListJobBuildTests:        viewer,
+		ListJobFlakyTests:        viewer,
+		FindBuildGetVersions:     owner,
+		InsertBuildGetVersion:    owner,
+
+		UploadBuildArtifact:   owner,
+		DownloadBuildArtifact: viewer,
+
+		GetPipelineResource:     viewer,
+		UpdatePipelineResource:  owner,
+		TriggerPipelineResource: operator,
+		CreateResourceVersion:   owner,
+		ListResourceVersions:    viewer,
+		PinResourceVersion:      operator,
+		UnpinResource:           operator,
+		EnableResourceVersion:   operator,
+		DisableResourceVersion:  operator,
 
 		WebhookTrigger:         nothing,
-		RegenerateWebhookToken: admin,
+		RegenerateWebhookToken: owner,
+
+		RegisterWorker:  admin,
+		HeartbeatWorker: admin,
+		ListWorkers:     viewer,
+		ClaimWork:       admin,
+		RenewWorkLease:  admin,
+		CompleteWork:    admin,
+		PublishWork:     admin,
+
+		ListLocks:        viewer,
+		AcquireLock:      operator,
+		ReleaseLock:      operator,
+		ForceReleaseLock: owner,
+
+		ListSecrets:    viewer,
+		SetSecret:      owner,
+		DeleteSecret:   owner,
+		ResolveSecrets: worker,
+
+		ListUserTokens:  nothing,
+		CreateUserToken: nothing,
+		DeleteUserToken: nothing,
+		ListTeamTokens:  owner,
+		CreateTeamToken: owner,
+		DeleteTeamToken: owner,
+
+		OIDCLogin:    nothing,
+		OIDCCallback: nothing,
+	}
+
+	// routeScopes is the apitoken.Scope an API token needs to
+	// use the route, on top of the routeAuthorization of its owner
+	routeScopes = map[RouteName]apitoken.Scope{
+		// UserLogin and WebhookTrigger are not authenticated
+		UserLogin: apitoken.Read,
+		// RefreshToken returns a JWT, which has no Scopes
+		RefreshToken: apitoken.Admin,
+
+		CreateUser: apitoken.Admin,
+		ListUsers:  apitoken.Read,
+
+		CreateTeam: apitoken.Admin,
+		ListTeams:  apitoken.Read,
+		GetTeam:    apitoken.Read,
+		UpdateTeam: apitoken.Admin,
+		DeleteTeam: apitoken.Admin,
+
+		CreateTeamMember: apitoken.Admin,
+		UpdateTeamMember: apitoken.Admin,
+		DeleteTeamMember: apitoken.Admin,
+
+		CreatePipeline: apitoken.PipelineWrite,
+		UpdatePipeline: apitoken.PipelineWrite,
+		GetPipeline:    apitoken.Read,
+		DeletePipeline: apitoken.PipelineWrite,
+		ListPipelines:  apitoken.Read,
+
+		PausePipeline:   apitoken.Trigger,
+		UnpausePipeline: apitoken.Trigger,
+
+		GetPipelineImage:    apitoken.Read,
+		CreatePipelineImage: apitoken.PipelineWrite,
+
+		TriggerPipelineJob: apitoken.Trigger,
+		GetPipelineJob:     apitoken.Read,
+		PauseJob:           apitoken.Trigger,
+		UnpauseJob:         apitoken.Trigger,
+
+		CreateJobBuild:           apitoken.Admin,
+		CreateRetryJobBuild:      apitoken.Admin,
+		UpdateJobBuild:           apitoken.Admin,
+		DeleteJobBuild:           apitoken.Admin,
+		ListJobBuilds:            apitoken.Read,
+		GetJobBuild:              apitoken.Read,
+		CancelJobBuild:           apitoken.Trigger,
+		RetryJobBuild:            apitoken.Trigger,
+		StartJobBuild:            apitoken.Admin,
+		ListQueuedBuilds:         apitoken.Read,
+		ListPipelineQueuedBuilds: apitoken.Read,
+		ListJobQueuedBuilds:      apitoken.Read,
+		AppendJobBuildLogs:       apitoken.Admin,
+		WatchJobBuild:            apitoken.Read,
+		CreateJobBuildTests:      apitoken.Admin,
+		ListJobBuildTests:        apitoken.Read,
+		ListJobFlakyTests:        apitoken.Read,
+		FindBuildGetVersions:     apitoken.Admin,
+		InsertBuildGetVersion:    apitoken.Admin,
+
+		UploadBuildArtifact:   apitoken.Admin,
+		DownloadBuildArtifact: apitoken.Read,
+
+		GetPipelineResource:     apitoken.Read,
+		UpdatePipelineResource:  apitoken.PipelineWrite,
+		TriggerPipelineResource: apitoken.Trigger,
+		CreateResourceVersion:   apitoken.Admin,
+		ListResourceVersions:    apitoken.Read,
+		PinResourceVersion:      apitoken.Trigger,
+		UnpinResource:           apitoken.Trigger,
+		EnableResourceVersion:   apitoken.Trigger,
+		DisableResourceVersion:  apitoken.Trigger,
+
+		WebhookTrigger:         apitoken.Read,
+		RegenerateWebhookToken: apitoken.PipelineWrite,
+
+		RegisterWorker:  apitoken.Admin,
+		HeartbeatWorker: apitoken.Admin,
+		ListWorkers:     apitoken.Read,
+		ClaimWork:       apitoken.Admin,
+		RenewWorkLease:  apitoken.Admin,
+		CompleteWork:    apitoken.Admin,
+		PublishWork:     apitoken.Admin,
+
+		ListLocks:        apitoken.Read,
+		AcquireLock:      apitoken.Trigger,
+		ReleaseLock:      apitoken.Trigger,
+		ForceReleaseLock: apitoken.Admin,
+
+		ListSecrets:    apitoken.Read,
+		SetSecret:      apitoken.Admin,
+		DeleteSecret:   apitoken.Admin,
+		ResolveSecrets: apitoken.Admin,
+
+		ListUserTokens:  apitoken.Read,
+		CreateUserToken: apitoken.Admin,
+		DeleteUserToken: apitoken.Admin,
+		ListTeamTokens:  apitoken.Read,
+		CreateTeamToken: apitoken.Admin,
+		DeleteTeamToken: apitoken.Admin,
+
+		// OIDCLogin and OIDCCallback are not authenticated
+		OIDCLogin:    apitoken.Read,
+		OIDCCallback: apitoken.Read,
 	}
 )
 
 func nothing(ctx context.Context, s pikoci.Service, un, tc string) error { return nil }
 
+// worker is for the routes only the workers can use, as the
+// requests of the workers are not authorized it always fails
+func worker(ctx context.Context, s pikoci.Service, un, tc string) error {
+	return fmt.Errorf("needs to be a worker")
+}
+
+// getUser returns the User authenticated with an API token, which
+// is on the ctx, or the User un
+func getUser(ctx context.Context, s pikoci.Service, un string) (*user.WithMemberships, error) {
+	if um, ok := ctx.Value(UserContextKey).(*user.WithMemberships); ok {
+		return um, nil
+	}
+	return s.GetUser(ctx, un)
+}
+
+// admin is for the routes only the global admins can use
 func admin(ctx context.Context, s pikoci.Service, un, tc string) error {
-	um, err := s.GetUser(ctx, un)
+	um, err := getUser(ctx, s, un)
 	if err != nil {
 		return fmt.Errorf("failed to GetUser: %w", err)
 	}
-	if !um.IsAdmin(tc) {
+	if !um.Admin {
 		return fmt.Errorf("needs to be admin")
 	}
 	return nil
 }
 
-func member(ctx context.Context, s pikoci.Service, un, tc string) error {
-	um, err := s.GetUser(ctx, un)
-	if err != nil {
-		return fmt.Errorf("failed to GetUser: %w", err)
-	}
-	if !um.IsMember(tc) {
-		return fmt.Errorf("needs to be member")
+var (
+	viewer   = role(user.RoleViewer)
+	operator = role(user.RoleOperator)
+	member   = role(user.RoleMember)
+	owner    = role(user.RoleOwner)
+)
+
+// role is for the routes that need at least the Role r on the Team tc
+func role(r user.Role) authorizationFn {
+	return func(ctx context.Context, s pikoci.Service, un, tc string) error {
+		um, err := getUser(ctx, s, un)
+		if err != nil {
+			return fmt.Errorf("failed to GetUser: %w", err)
+		}
+		if !um.HasRole(r, tc) {
+			return fmt.Errorf("needs to be %s", r)
+		}
+		return nil
 	}
-	return nil
 }
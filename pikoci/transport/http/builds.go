@@ -2,8 +2,10 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/xescugc/pikoci/pikoci"
@@ -23,6 +25,30 @@ type CreateJobBuildResponse struct {
 
 func (r CreateJobBuildResponse) Error() string { return r.Err }
 
+func createJobBuild(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req CreateJobBuildRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(CreateJobBuildResponse{Err: err.Error()}, w)
+			return
+		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		b, err := s.CreateJobBuild(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.Build)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(CreateJobBuildResponse{Build: b, Err: errs}, w)
+	}
+}
 
 type UpdateJobBuildRequest struct {
 	TeamCanonical string      `json:"team_canonical"`
@@ -43,16 +69,17 @@ func updateJobBuild(s pikoci.Service) http.HandlerFunc {
 			req UpdateJobBuildRequest
 			ctx = r.Context()
 		)
-		vars := mux.Vars(r)
-		req.TeamCanonical = vars["team_canonical"]
-		req.PipelineName = vars["pipeline_name"]
-		req.JobName = vars["job_name"]
-		req.BuildNumber = vars["build_number"]
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			encodeResponse(UpdateJobBuildResponse{Err: err.Error()}, w)
 			return
 		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		req.BuildNumber = vars["build_number"]
 		err = s.UpdateJobBuild(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.BuildNumber, req.Build)
 		var errs string
 		if err != nil {
@@ -206,6 +233,79 @@ func retryJobBuild(s pikoci.Service) http.HandlerFunc {
 	}
 }
 
+type StartJobBuildRequest struct {
+	TeamCanonical string      `json:"team_canonical"`
+	PipelineName  string      `json:"pipeline_name"`
+	JobName       string      `json:"job_name"`
+	BuildNumber   string      `json:"build_number"`
+	Build         build.Build `json:"build"`
+}
+type StartJobBuildResponse struct {
+	Build *build.Build `json:"build,omitempty"`
+	Err   string       `json:"error,omitempty"`
+}
+
+func (r StartJobBuildResponse) Error() string { return r.Err }
+
+func startJobBuild(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req StartJobBuildRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(StartJobBuildResponse{Err: err.Error()}, w)
+			return
+		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		req.BuildNumber = vars["build_number"]
+		b, err := s.StartJobBuild(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.BuildNumber, req.Build)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(StartJobBuildResponse{Build: b, Err: errs}, w)
+	}
+}
+
+type ListQueuedBuildsRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	PipelineName  string `json:"pipeline_name"`
+	JobName       string `json:"job_name"`
+}
+type ListQueuedBuildsResponse struct {
+	Builds []*build.Queued `json:"data,omitempty"`
+	Err    string          `json:"error,omitempty"`
+}
+
+func (r ListQueuedBuildsResponse) Error() string { return r.Err }
+
+// listQueuedBuilds lists the queue of the Team, or of the
+// Pipeline or the Job if the route has them on the URL
+func listQueuedBuilds(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ListQueuedBuildsRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		builds, err := s.ListQueuedBuilds(ctx, req.TeamCanonical, req.PipelineName, req.JobName)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListQueuedBuildsResponse{Builds: builds, Err: errs}, w)
+	}
+}
+
 type CreateRetryJobBuildRequest struct {
 	TeamCanonical    string      `json:"team_canonical"`
 	PipelineName     string      `json:"pipeline_name"`
@@ -226,15 +326,16 @@ func createRetryJobBuild(s pikoci.Service) http.HandlerFunc {
 			req CreateRetryJobBuildRequest
 			ctx = r.Context()
 		)
-		vars := mux.Vars(r)
-		req.TeamCanonical = vars["team_canonical"]
-		req.PipelineName = vars["pipeline_name"]
-		req.JobName = vars["job_name"]
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			encodeResponse(CreateRetryJobBuildResponse{Err: err.Error()}, w)
 			return
 		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
 		b, err := s.CreateRetryJobBuild(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.ParentBuildNumber, req.Build)
 		var errs string
 		if err != nil {
@@ -304,3 +405,186 @@ func listJobBuilds(s pikoci.Service) http.HandlerFunc {
 		encodeResponse(ListJobBuildsResponse{Builds: builds, Err: errs}, w)
 	}
 }
+
+type AppendJobBuildLogsRequest struct {
+	TeamCanonical string         `json:"team_canonical"`
+	PipelineName  string         `json:"pipeline_name"`
+	JobName       string         `json:"job_name"`
+	BuildNumber   string         `json:"build_number"`
+	Chunk         build.LogChunk `json:"chunk"`
+}
+type AppendJobBuildLogsResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r AppendJobBuildLogsResponse) Error() string { return r.Err }
+
+func appendJobBuildLogs(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req AppendJobBuildLogsRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(AppendJobBuildLogsResponse{Err: err.Error()}, w)
+			return
+		}
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		req.BuildNumber = vars["build_number"]
+		err = s.AppendJobBuildLogs(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.BuildNumber, req.Chunk)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(AppendJobBuildLogsResponse{Err: errs}, w)
+	}
+}
+
+type CreateJobBuildTestsRequest struct {
+	TeamCanonical string           `json:"team_canonical"`
+	PipelineName  string           `json:"pipeline_name"`
+	JobName       string           `json:"job_name"`
+	BuildNumber   string           `json:"build_number"`
+	Cases         []build.TestCase `json:"cases"`
+}
+type CreateJobBuildTestsResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r CreateJobBuildTestsResponse) Error() string { return r.Err }
+
+func createJobBuildTests(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req CreateJobBuildTestsRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(CreateJobBuildTestsResponse{Err: err.Error()}, w)
+			return
+		}
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		req.BuildNumber = vars["build_number"]
+		err = s.CreateJobBuildTests(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.BuildNumber, req.Cases)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(CreateJobBuildTestsResponse{Err: errs}, w)
+	}
+}
+
+type ListJobBuildTestsResponse struct {
+	Suites []*build.TestSuite `json:"data"`
+	Err    string             `json:"error,omitempty"`
+}
+
+func (r ListJobBuildTestsResponse) Error() string { return r.Err }
+
+func listJobBuildTests(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		vars := mux.Vars(r)
+		tc := vars["team_canonical"]
+		pn := vars["pipeline_name"]
+		jn := vars["job_name"]
+		bn := vars["build_number"]
+		ts, err := s.ListJobBuildTests(ctx, tc, pn, jn, bn)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListJobBuildTestsResponse{Suites: ts, Err: errs}, w)
+	}
+}
+
+type ListJobFlakyTestsResponse struct {
+	Tests []*build.FlakyTest `json:"data"`
+	Err   string             `json:"error,omitempty"`
+}
+
+func (r ListJobFlakyTestsResponse) Error() string { return r.Err }
+
+func listJobFlakyTests(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		vars := mux.Vars(r)
+		tc := vars["team_canonical"]
+		pn := vars["pipeline_name"]
+		jn := vars["job_name"]
+		ft, err := s.ListJobFlakyTests(ctx, tc, pn, jn)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListJobFlakyTestsResponse{Tests: ft, Err: errs}, w)
+	}
+}
+
+type WatchJobBuildResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r WatchJobBuildResponse) Error() string { return r.Err }
+
+// watchKeepAliveInterval is how often a comment is sent on the
+// events stream when there are no events, so proxies keep it open
+var watchKeepAliveInterval = 15 * time.Second
+
+// watchJobBuild streams the build.Events of the build as Server-Sent
+// Events, the name of each event is the build.EventType and the data
+// the build.Event as JSON. The stream ends once the build finishes.
+func watchJobBuild(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ctx = r.Context()
+		vars := mux.Vars(r)
+		tc := vars["team_canonical"]
+		pn := vars["pipeline_name"]
+		jn := vars["job_name"]
+		bn := vars["build_number"]
+		events, err := s.WatchJobBuild(ctx, tc, pn, jn, bn)
+		if err != nil {
+			encodeResponse(WatchJobBuildResponse{Err: err.Error()}, w)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		rc.Flush()
+
+		ticker := time.NewTicker(watchKeepAliveInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(e)
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case <-ctx.Done():
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -1,17 +1,27 @@
 package client
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
 	"github.com/xescugc/pikoci/pikoci/resource"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	"github.com/xescugc/pikoci/pikoci/team"
 	thttp "github.com/xescugc/pikoci/pikoci/transport/http"
 	"github.com/xescugc/pikoci/pikoci/user"
@@ -83,6 +93,21 @@ func (cl *Client) RefreshToken(ctx context.Context, un string) (*user.WithMember
 	return resp.Data.User, resp.Data.JWT, nil
 }
 
+func (cl *Client) OIDCEnabled(ctx context.Context) bool {
+	// The OIDC login is done by the browser
+	return false
+}
+
+func (cl *Client) OIDCAuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	// The OIDC login is done by the browser
+	return "", fmt.Errorf("OIDCAuthCodeURL is not exposed via HTTP")
+}
+
+func (cl *Client) OIDCLogin(ctx context.Context, code, verifier, nonce string) (*user.WithMemberships, string, error) {
+	// The OIDC login is done by the browser
+	return nil, "", fmt.Errorf("OIDCLogin is not exposed via HTTP")
+}
+
 func (cl *Client) GetUser(ctx context.Context, un string) (*user.WithMemberships, error) {
 	// No server-side endpoint for GetUser; it's only used internally for authorization
 	return nil, fmt.Errorf("GetUser is not exposed via HTTP")
@@ -220,7 +245,7 @@ func (cl *Client) UpdateTeamMember(ctx context.Context, tc, mu string, tm team.M
 	var resp thttp.UpdateTeamMemberResponse
 
 	err := cl.Request(ctx, http.MethodPut, fmt.Sprintf("%s/teams/%s/members/%s", cl.url, tc, mu), thttp.UpdateTeamMemberRequest{
-		Admin: tm.Admin,
+		Role: tm.Role,
 	}, &resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
@@ -284,6 +309,36 @@ func (cl *Client) SetPipelinePublic(ctx context.Context, tc, pn string, public b
 	return nil
 }
 
+func (cl *Client) PausePipeline(ctx context.Context, tc, pn string) error {
+	var resp thttp.PausePipelineResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/pause", cl.url, tc, pn), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) UnpausePipeline(ctx context.Context, tc, pn string) error {
+	var resp thttp.UnpausePipelineResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/unpause", cl.url, tc, pn), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
 func (cl *Client) GetPublicPipeline(ctx context.Context, tc, pn string) (*pipeline.Pipeline, error) {
 	return cl.GetPipeline(ctx, tc, pn)
 }
@@ -404,10 +459,42 @@ func (cl *Client) DeletePipeline(ctx context.Context, tc, pn string) error {
 	return nil
 }
 
-func (cl *Client) TriggerPipelineJob(ctx context.Context, tc, pn, jn string) error {
+func (cl *Client) TriggerPipelineJob(ctx context.Context, tc, pn, jn string, versions map[string]uint32) error {
 	var resp thttp.TriggerPipelineJobResponse
 
-	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/trigger", cl.url, tc, pn, jn), nil, &resp)
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/trigger", cl.url, tc, pn, jn), thttp.TriggerPipelineJobRequest{
+		Versions: versions,
+	}, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) PauseJob(ctx context.Context, tc, pn, jn string) error {
+	var resp thttp.PauseJobResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/pause", cl.url, tc, pn, jn), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) UnpauseJob(ctx context.Context, tc, pn, jn string) error {
+	var resp thttp.UnpauseJobResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/unpause", cl.url, tc, pn, jn), nil, &resp)
 	if err != nil {
 		return fmt.Errorf("failed to make request: %w", err)
 	}
@@ -447,6 +534,8 @@ func (cl *Client) CreateJobBuild(ctx context.Context, tc, pn, jn string, b build
 	if resp.Err != "" {
 		if resp.Err == pikoci.ErrConcurrencyLimit.Error() {
 			return nil, pikoci.ErrConcurrencyLimit
+		} else if resp.Err == lock.ErrHeld.Error() {
+			return nil, lock.ErrHeld
 		}
 		return nil, fmt.Errorf("error from request: %s", resp.Err)
 	}
@@ -471,6 +560,137 @@ func (cl *Client) UpdateJobBuild(ctx context.Context, tc, pn, jn string, buildNu
 	return nil
 }
 
+func (cl *Client) AppendJobBuildLogs(ctx context.Context, tc, pn, jn string, buildNumber string, c build.LogChunk) error {
+	var resp thttp.AppendJobBuildLogsResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s/logs", cl.url, tc, pn, jn, buildNumber), thttp.AppendJobBuildLogsRequest{
+		Chunk: c,
+	}, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) CreateJobBuildTests(ctx context.Context, tc, pn, jn string, buildNumber string, cs []build.TestCase) error {
+	var resp thttp.CreateJobBuildTestsResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s/tests", cl.url, tc, pn, jn, buildNumber), thttp.CreateJobBuildTestsRequest{
+		Cases: cs,
+	}, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) ListJobBuildTests(ctx context.Context, tc, pn, jn string, buildNumber string) ([]*build.TestSuite, error) {
+	var resp thttp.ListJobBuildTestsResponse
+
+	err := cl.Request(ctx, http.MethodGet, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s/tests", cl.url, tc, pn, jn, buildNumber), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Suites, nil
+}
+
+func (cl *Client) ListJobFlakyTests(ctx context.Context, tc, pn, jn string) ([]*build.FlakyTest, error) {
+	var resp thttp.ListJobFlakyTestsResponse
+
+	err := cl.Request(ctx, http.MethodGet, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/flaky-tests", cl.url, tc, pn, jn), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Tests, nil
+}
+
+// WatchJobBuild reads the Server-Sent Events of the build, the
+// channel is closed when the stream ends or ctx is done
+func (cl *Client) WatchJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string) (<-chan build.Event, error) {
+	u := fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s/events", cl.url, tc, pn, jn, buildNumber)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request %q: %w", u, err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "text/event-stream")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", cl.jwt))
+
+	hresp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request %q: %w", u, err)
+	}
+
+	if !strings.HasPrefix(hresp.Header.Get("Content-Type"), "text/event-stream") {
+		defer hresp.Body.Close()
+		var eresp thttp.ErrorResponse
+		err = json.NewDecoder(hresp.Body).Decode(&eresp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read all body on %q: %w", u, err)
+		}
+		return nil, fmt.Errorf("error from request: %s", eresp.Err)
+	}
+
+	events := make(chan build.Event)
+	go func() {
+		defer close(events)
+		defer hresp.Body.Close()
+
+		r := bufio.NewReader(hresp.Body)
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+
+			// A blank line dispatches the event, the lines
+			// starting with ':' are comments to keep it alive
+			if line == "" {
+				if data.Len() == 0 {
+					continue
+				}
+				var e build.Event
+				err = json.Unmarshal([]byte(data.String()), &e)
+				data.Reset()
+				if err != nil {
+					return
+				}
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			} else if d, ok := strings.CutPrefix(line, "data:"); ok {
+				data.WriteString(strings.TrimPrefix(d, " "))
+			}
+		}
+	}()
+
+	return events, nil
+}
+
 func (cl *Client) DeleteJobBuild(ctx context.Context, tc, pn, jn string, buildNumber string) error {
 	var resp thttp.DeleteJobBuildResponse
 
@@ -531,6 +751,51 @@ func (cl *Client) RetryJobBuild(ctx context.Context, tc, pn, jn, buildNumber str
 	return nil
 }
 
+func (cl *Client) StartJobBuild(ctx context.Context, tc, pn, jn, buildNumber string, b build.Build) (*build.Build, error) {
+	var resp thttp.StartJobBuildResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s/start", cl.url, tc, pn, jn, buildNumber), thttp.StartJobBuildRequest{
+		Build: b,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		if resp.Err == pikoci.ErrConcurrencyLimit.Error() {
+			return nil, pikoci.ErrConcurrencyLimit
+		} else if resp.Err == pikoci.ErrBuildNotPending.Error() {
+			return nil, pikoci.ErrBuildNotPending
+		} else if resp.Err == lock.ErrHeld.Error() {
+			return nil, lock.ErrHeld
+		}
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Build, nil
+}
+
+func (cl *Client) ListQueuedBuilds(ctx context.Context, tc, pn, jn string) ([]*build.Queued, error) {
+	var resp thttp.ListQueuedBuildsResponse
+
+	url := fmt.Sprintf("%s/teams/%s/queue", cl.url, tc)
+	if jn != "" {
+		url = fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/queue", cl.url, tc, pn, jn)
+	} else if pn != "" {
+		url = fmt.Sprintf("%s/teams/%s/pipelines/%s/queue", cl.url, tc, pn)
+	}
+	err := cl.Request(ctx, http.MethodGet, url, nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Builds, nil
+}
+
 func (cl *Client) CreateRetryJobBuild(ctx context.Context, tc, pn, jn, parentBuildNumber string, b build.Build) (*build.Build, error) {
 	var resp thttp.CreateRetryJobBuildResponse
 
@@ -545,6 +810,8 @@ func (cl *Client) CreateRetryJobBuild(ctx context.Context, tc, pn, jn, parentBui
 	if resp.Err != "" {
 		if resp.Err == pikoci.ErrConcurrencyLimit.Error() {
 			return nil, pikoci.ErrConcurrencyLimit
+		} else if resp.Err == lock.ErrHeld.Error() {
+			return nil, lock.ErrHeld
 		}
 		return nil, fmt.Errorf("error from request: %s", resp.Err)
 	}
@@ -567,6 +834,24 @@ func (cl *Client) FindBuildGetVersions(ctx context.Context, tc, pn, jn string, b
 	return resp.Versions, nil
 }
 
+func (cl *Client) UploadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, r io.Reader) error {
+	err := cl.Stream(ctx, http.MethodPut, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s/artifacts/%s", cl.url, tc, pn, jn, buildNumber, url.PathEscape(tn)), r, nil)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	return nil
+}
+
+func (cl *Client) DownloadBuildArtifact(ctx context.Context, tc, pn, jn, buildNumber, tn string, w io.Writer) error {
+	err := cl.Stream(ctx, http.MethodGet, fmt.Sprintf("%s/teams/%s/pipelines/%s/jobs/%s/builds/%s/artifacts/%s", cl.url, tc, pn, jn, buildNumber, url.PathEscape(tn)), nil, w)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	return nil
+}
+
 func (cl *Client) InsertBuildGetVersion(ctx context.Context, tc, pn, jn string, buildID uint32, stepName string, versionID uint32) error {
 	var resp thttp.InsertBuildGetVersionResponse
 
@@ -634,6 +919,66 @@ func (cl *Client) ListResourceVersions(ctx context.Context, tc, pn, rCan string)
 	return resp.Versions, nil
 }
 
+func (cl *Client) PinResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	var resp thttp.PinResourceVersionResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/resources/%s/versions/%d/pin", cl.url, tc, pn, rCan, vID), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) UnpinResource(ctx context.Context, tc, pn, rCan string) error {
+	var resp thttp.UnpinResourceResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/resources/%s/unpin", cl.url, tc, pn, rCan), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) EnableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	var resp thttp.EnableResourceVersionResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/resources/%s/versions/%d/enable", cl.url, tc, pn, rCan, vID), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) DisableResourceVersion(ctx context.Context, tc, pn, rCan string, vID uint32) error {
+	var resp thttp.DisableResourceVersionResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/pipelines/%s/resources/%s/versions/%d/disable", cl.url, tc, pn, rCan, vID), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
 func (cl *Client) GetPipelineResource(ctx context.Context, tc, pn, rCan string) (*resource.Resource, error) {
 	var resp thttp.GetPipelineResourceResponse
 
@@ -681,13 +1026,32 @@ func (cl *Client) TriggerPipelineResource(ctx context.Context, tc, pn, rCan stri
 	return nil
 }
 
-func (cl *Client) WebhookTrigger(ctx context.Context, token string) error {
+// WebhookTrigger sends the body as it is, not JSON encoded like on
+// the other requests, so the signature on the headers h matches it
+func (cl *Client) WebhookTrigger(ctx context.Context, token string, h http.Header, body []byte) error {
 	var resp thttp.WebhookTriggerResponse
 
-	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/webhooks/%s", cl.url, token), nil, &resp)
+	wurl := fmt.Sprintf("%s/webhooks/%s", cl.url, token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wurl, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request %q: %w", wurl, err)
+	}
+	for k, vs := range h {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	hresp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to make request: %w", err)
 	}
+	defer hresp.Body.Close()
+
+	err = json.NewDecoder(hresp.Body).Decode(&resp)
+	if err != nil {
+		return fmt.Errorf("failed to decode body on %q: %w", wurl, err)
+	}
 
 	if resp.Err != "" {
 		return fmt.Errorf("error from request: %s", resp.Err)
@@ -710,3 +1074,354 @@ func (cl *Client) RegenerateWebhookToken(ctx context.Context, tc, pn, rCan strin
 
 	return resp.Token, nil
 }
+
+func (cl *Client) RegisterWorker(ctx context.Context, w registry.Worker) (*registry.Worker, error) {
+	var resp thttp.RegisterWorkerResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/workers", cl.url), thttp.RegisterWorkerRequest{
+		Worker: w,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Worker, nil
+}
+
+func (cl *Client) HeartbeatWorker(ctx context.Context, id uint32) error {
+	var resp thttp.HeartbeatWorkerResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/workers/%d/heartbeat", cl.url, id), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		if resp.Err == pikoci.ErrWorkerNotRegistered.Error() {
+			return pikoci.ErrWorkerNotRegistered
+		}
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) ListWorkers(ctx context.Context) ([]*registry.Worker, error) {
+	var resp thttp.ListWorkersResponse
+
+	err := cl.Request(ctx, http.MethodGet, fmt.Sprintf("%s/workers", cl.url), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Workers, nil
+}
+
+func (cl *Client) ClaimWork(ctx context.Context, workerID uint32, tags []string, wait time.Duration) (*queue.Lease, error) {
+	var resp thttp.ClaimWorkResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/workers/%d/work", cl.url, workerID), thttp.ClaimWorkRequest{Tags: tags, Wait: wait}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		if resp.Err == pikoci.ErrPullWorkersDisabled.Error() {
+			return nil, pikoci.ErrPullWorkersDisabled
+		}
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Lease, nil
+}
+
+func (cl *Client) RenewWorkLease(ctx context.Context, workerID uint32, leaseID string) (*queue.Lease, error) {
+	var resp thttp.RenewWorkLeaseResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/workers/%d/work/%s/renew", cl.url, workerID, leaseID), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		if resp.Err == queue.ErrLeaseNotFound.Error() {
+			return nil, queue.ErrLeaseNotFound
+		}
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Lease, nil
+}
+
+func (cl *Client) CompleteWork(ctx context.Context, workerID uint32, leaseID string) error {
+	var resp thttp.CompleteWorkResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/workers/%d/work/%s/complete", cl.url, workerID, leaseID), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		if resp.Err == queue.ErrLeaseNotFound.Error() {
+			return queue.ErrLeaseNotFound
+		}
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) PublishWork(ctx context.Context, body []byte, metadata map[string]string) error {
+	var resp thttp.PublishWorkResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/work", cl.url), thttp.PublishWorkRequest{Body: body, Metadata: metadata}, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) ListLocks(ctx context.Context, tc string) ([]*lock.Lock, error) {
+	var resp thttp.ListLocksResponse
+
+	err := cl.Request(ctx, http.MethodGet, fmt.Sprintf("%s/teams/%s/locks", cl.url, tc), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Locks, nil
+}
+
+func (cl *Client) AcquireLock(ctx context.Context, tc, name, un, reason string) (*lock.Lock, error) {
+	var resp thttp.AcquireLockResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/locks/%s/acquire", cl.url, tc, name), thttp.AcquireLockRequest{
+		Reason: reason,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		if resp.Err == lock.ErrHeld.Error() {
+			return nil, lock.ErrHeld
+		}
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Lock, nil
+}
+
+func (cl *Client) ReleaseLock(ctx context.Context, tc, name, un string) error {
+	var resp thttp.ReleaseLockResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/locks/%s/release", cl.url, tc, name), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) ForceReleaseLock(ctx context.Context, tc, name string) error {
+	var resp thttp.ForceReleaseLockResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/locks/%s/force-release", cl.url, tc, name), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) ListSecrets(ctx context.Context, tc string) ([]*secret.Secret, error) {
+	var resp thttp.ListSecretsResponse
+
+	err := cl.Request(ctx, http.MethodGet, fmt.Sprintf("%s/teams/%s/secrets", cl.url, tc), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Secrets, nil
+}
+
+func (cl *Client) SetSecret(ctx context.Context, tc, name, value string) (*secret.Secret, error) {
+	var resp thttp.SetSecretResponse
+
+	err := cl.Request(ctx, http.MethodPut, fmt.Sprintf("%s/teams/%s/secrets/%s", cl.url, tc, name), thttp.SetSecretRequest{
+		Value: value,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Secret, nil
+}
+
+func (cl *Client) DeleteSecret(ctx context.Context, tc, name string) error {
+	var resp thttp.DeleteSecretResponse
+
+	err := cl.Request(ctx, http.MethodDelete, fmt.Sprintf("%s/teams/%s/secrets/%s", cl.url, tc, name), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) ResolveSecrets(ctx context.Context, tc string, names []string) (map[string]string, error) {
+	var resp thttp.ResolveSecretsResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/secrets/resolve", cl.url, tc), thttp.ResolveSecretsRequest{
+		Names: names,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Values, nil
+}
+
+func (cl *Client) ListUserTokens(ctx context.Context, un string) ([]*apitoken.Token, error) {
+	var resp thttp.ListUserTokensResponse
+
+	err := cl.Request(ctx, http.MethodGet, fmt.Sprintf("%s/user/tokens", cl.url), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Tokens, nil
+}
+
+func (cl *Client) CreateUserToken(ctx context.Context, un string, t apitoken.Token) (*apitoken.Token, string, error) {
+	var resp thttp.CreateUserTokenResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/user/tokens", cl.url), thttp.CreateUserTokenRequest{
+		Name:      t.Name,
+		Scopes:    t.Scopes,
+		ExpiresAt: t.ExpiresAt,
+	}, &resp)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, "", fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Token.Token, resp.Token.Value, nil
+}
+
+func (cl *Client) DeleteUserToken(ctx context.Context, un string, id uint32) error {
+	var resp thttp.DeleteUserTokenResponse
+
+	err := cl.Request(ctx, http.MethodDelete, fmt.Sprintf("%s/user/tokens/%d", cl.url, id), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) ListTeamTokens(ctx context.Context, tc string) ([]*apitoken.Token, error) {
+	var resp thttp.ListTeamTokensResponse
+
+	err := cl.Request(ctx, http.MethodGet, fmt.Sprintf("%s/teams/%s/tokens", cl.url, tc), nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Tokens, nil
+}
+
+func (cl *Client) CreateTeamToken(ctx context.Context, tc string, t apitoken.Token) (*apitoken.Token, string, error) {
+	var resp thttp.CreateTeamTokenResponse
+
+	err := cl.Request(ctx, http.MethodPost, fmt.Sprintf("%s/teams/%s/tokens", cl.url, tc), thttp.CreateTeamTokenRequest{
+		Name:      t.Name,
+		Scopes:    t.Scopes,
+		ExpiresAt: t.ExpiresAt,
+	}, &resp)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return nil, "", fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return resp.Token.Token, resp.Token.Value, nil
+}
+
+func (cl *Client) DeleteTeamToken(ctx context.Context, tc string, id uint32) error {
+	var resp thttp.DeleteTeamTokenResponse
+
+	err := cl.Request(ctx, http.MethodDelete, fmt.Sprintf("%s/teams/%s/tokens/%d", cl.url, tc, id), nil, &resp)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.Err != "" {
+		return fmt.Errorf("error from request: %s", resp.Err)
+	}
+
+	return nil
+}
+
+func (cl *Client) AuthenticateToken(ctx context.Context, raw string) (*apitoken.Token, *user.WithMemberships, error) {
+	// No server-side endpoint for AuthenticateToken; it's only used internally for authentication
+	return nil, nil, fmt.Errorf("AuthenticateToken is not exposed via HTTP")
+}
@@ -1,23 +1,32 @@
 package client_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
 	"github.com/xescugc/pikoci/pikoci/resource"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	"github.com/xescugc/pikoci/pikoci/team"
 	thttp "github.com/xescugc/pikoci/pikoci/transport/http"
 	"github.com/xescugc/pikoci/pikoci/transport/http/client"
@@ -206,7 +215,7 @@ func TestCreateTeamMember(t *testing.T) {
 func TestUpdateTeamMember(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/teams/{tc}/members/{mu}", func(w http.ResponseWriter, req *http.Request) {
-		jsonHandler(w, thttp.UpdateTeamMemberResponse{Member: &team.Member{Admin: true, User: user.User{Username: "alice"}}})
+		jsonHandler(w, thttp.UpdateTeamMemberResponse{Member: &team.Member{Role: user.RoleOwner, User: user.User{Username: "alice"}}})
 	}).Methods("PUT")
 	ts := httptest.NewServer(r)
 	defer ts.Close()
@@ -214,9 +223,9 @@ func TestUpdateTeamMember(t *testing.T) {
 	c, err := client.New(ts.URL, "jwt")
 	require.NoError(t, err)
 
-	m, err := c.UpdateTeamMember(context.Background(), "myteam", "alice", team.Member{Admin: true})
+	m, err := c.UpdateTeamMember(context.Background(), "myteam", "alice", team.Member{Role: user.RoleOwner})
 	require.NoError(t, err)
-	assert.True(t, m.Admin)
+	assert.Equal(t, user.RoleOwner, m.Role)
 }
 
 func TestDeleteTeamMember(t *testing.T) {
@@ -313,6 +322,27 @@ func TestDeletePipeline(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestPausePipeline(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/pause", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.PausePipelineResponse{})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/unpause", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.UnpausePipelineResponse{})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.PausePipeline(context.Background(), "team", "mypipe")
+	require.NoError(t, err)
+
+	err = c.UnpausePipeline(context.Background(), "team", "mypipe")
+	require.NoError(t, err)
+}
+
 func TestGetPipelineImage(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/teams/{tc}/pipelines/{pn}/image.{format}", func(w http.ResponseWriter, req *http.Request) {
@@ -356,7 +386,25 @@ func TestTriggerPipelineJob(t *testing.T) {
 	c, err := client.New(ts.URL, "jwt")
 	require.NoError(t, err)
 
-	err = c.TriggerPipelineJob(context.Background(), "team", "pipe", "job1")
+	err = c.TriggerPipelineJob(context.Background(), "team", "pipe", "job1", nil)
+	require.NoError(t, err)
+}
+
+func TestTriggerPipelineJob_WithVersions(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/trigger", func(w http.ResponseWriter, req *http.Request) {
+		var body thttp.TriggerPipelineJobRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, map[string]uint32{"my-repo": 42}, body.Versions)
+		jsonHandler(w, thttp.TriggerPipelineJobResponse{})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.TriggerPipelineJob(context.Background(), "team", "pipe", "job1", map[string]uint32{"my-repo": 42})
 	require.NoError(t, err)
 }
 
@@ -376,6 +424,27 @@ func TestGetPipelineJob(t *testing.T) {
 	assert.Equal(t, "job1", j.Name)
 }
 
+func TestPauseJob(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/pause", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.PauseJobResponse{})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/unpause", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.UnpauseJobResponse{Err: "not found"})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.PauseJob(context.Background(), "team", "mypipe", "myjob")
+	require.NoError(t, err)
+
+	err = c.UnpauseJob(context.Background(), "team", "mypipe", "myjob")
+	require.Error(t, err)
+}
+
 func TestCreateJobBuild(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds", func(w http.ResponseWriter, req *http.Request) {
@@ -407,6 +476,94 @@ func TestUpdateJobBuild(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAppendJobBuildLogs(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bid}/logs", func(w http.ResponseWriter, req *http.Request) {
+		var ar thttp.AppendJobBuildLogsRequest
+		json.NewDecoder(req.Body).Decode(&ar)
+		assert.Equal(t, build.LogChunk{StepID: "s1", Offset: 3, Data: "out"}, ar.Chunk)
+		jsonHandler(w, thttp.AppendJobBuildLogsResponse{})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.AppendJobBuildLogs(context.Background(), "team", "pipe", "job1", "1", build.LogChunk{StepID: "s1", Offset: 3, Data: "out"})
+	require.NoError(t, err)
+}
+
+func TestWatchJobBuild(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bid}/events", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["bid"] != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			jsonHandler(w, thttp.WatchJobBuildResponse{Err: "not found"})
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "event: status\ndata: {\"type\":\"status\",\"build\":{\"build_number\":\"1\",\"status\":\"started\"}}\n\n")
+		io.WriteString(w, ": keep-alive\n\n")
+		io.WriteString(w, "event: log\ndata: {\"type\":\"log\",\"log\":{\"id\":1,\"step_id\":\"s1\",\"offset\":0,\"data\":\"hello\\n\"}}\n\n")
+	}).Methods("GET")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	events, err := c.WatchJobBuild(context.Background(), "team", "pipe", "job1", "1")
+	require.NoError(t, err)
+
+	var es []build.Event
+	for e := range events {
+		es = append(es, e)
+	}
+	assert.Equal(t, []build.Event{
+		{Type: build.EventStatus, Build: &build.Build{BuildNumber: "1", Status: build.Started}},
+		{Type: build.EventLog, Log: &build.LogChunk{ID: 1, StepID: "s1", Data: "hello\n"}},
+	}, es)
+
+	_, err = c.WatchJobBuild(context.Background(), "team", "pipe", "job1", "2")
+	assert.EqualError(t, err, "error from request: not found")
+}
+
+func TestJobBuildTests(t *testing.T) {
+	cs := []build.TestCase{{Step: "test", Suite: "pkg", Name: "TestA", Status: build.TestPassed}}
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bid}/tests", func(w http.ResponseWriter, req *http.Request) {
+		var cr thttp.CreateJobBuildTestsRequest
+		json.NewDecoder(req.Body).Decode(&cr)
+		assert.Equal(t, cs, cr.Cases)
+		jsonHandler(w, thttp.CreateJobBuildTestsResponse{})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bid}/tests", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListJobBuildTestsResponse{Suites: []*build.TestSuite{{Step: "test", Name: "pkg", Tests: 1}}})
+	}).Methods("GET")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/flaky-tests", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListJobFlakyTestsResponse{Tests: []*build.FlakyTest{{Suite: "pkg", Name: "TestA", Flips: 2}}})
+	}).Methods("GET")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.CreateJobBuildTests(context.Background(), "team", "pipe", "job1", "1", cs)
+	require.NoError(t, err)
+
+	suites, err := c.ListJobBuildTests(context.Background(), "team", "pipe", "job1", "1")
+	require.NoError(t, err)
+	require.Len(t, suites, 1)
+	assert.Equal(t, 1, suites[0].Tests)
+
+	flaky, err := c.ListJobFlakyTests(context.Background(), "team", "pipe", "job1")
+	require.NoError(t, err)
+	require.Len(t, flaky, 1)
+	assert.Equal(t, 2, flaky[0].Flips)
+}
+
 func TestDeleteJobBuild(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bid}", func(w http.ResponseWriter, req *http.Request) {
@@ -489,6 +646,59 @@ func TestListResourceVersions(t *testing.T) {
 	assert.Len(t, versions, 1)
 }
 
+func TestResourceVersionPinning(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/resources/{rCan}/versions/{vID}/pin", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "42", mux.Vars(req)["vID"])
+		jsonHandler(w, thttp.PinResourceVersionResponse{})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/resources/{rCan}/unpin", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.UnpinResourceResponse{})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/resources/{rCan}/versions/{vID}/disable", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.DisableResourceVersionResponse{})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/resources/{rCan}/versions/{vID}/enable", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.EnableResourceVersionResponse{Err: "not found"})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.PinResourceVersion(context.Background(), "team", "pipe", "git.repo", 42)
+	require.NoError(t, err)
+
+	err = c.UnpinResource(context.Background(), "team", "pipe", "git.repo")
+	require.NoError(t, err)
+
+	err = c.DisableResourceVersion(context.Background(), "team", "pipe", "git.repo", 42)
+	require.NoError(t, err)
+
+	err = c.EnableResourceVersion(context.Background(), "team", "pipe", "git.repo", 42)
+	require.Error(t, err)
+}
+
+func TestWebhookTrigger(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/webhooks/{token}", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "my-token", mux.Vars(req)["token"])
+		assert.Equal(t, "my-secret", req.Header.Get("X-Gitlab-Token"))
+		body, _ := io.ReadAll(req.Body)
+		assert.Equal(t, `{"ref":"refs/heads/main"}`, string(body))
+		jsonHandler(w, thttp.WebhookTriggerResponse{})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "")
+	require.NoError(t, err)
+
+	err = c.WebhookTrigger(context.Background(), "my-token", http.Header{"X-Gitlab-Token": []string{"my-secret"}}, []byte(`{"ref":"refs/heads/main"}`))
+	require.NoError(t, err)
+}
+
 func TestGetPipelineResource(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/teams/{tc}/pipelines/{pn}/resources/{rCan}", func(w http.ResponseWriter, req *http.Request) {
@@ -535,6 +745,481 @@ func TestTriggerPipelineResource(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestUploadBuildArtifact(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bn}/artifacts/{tn}", func(w http.ResponseWriter, req *http.Request) {
+		b, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(b))
+		assert.Equal(t, "application/octet-stream", req.Header.Get("Content-Type"))
+		assert.Equal(t, "compile", mux.Vars(req)["tn"])
+		jsonHandler(w, thttp.UploadBuildArtifactResponse{})
+	}).Methods("PUT")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.UploadBuildArtifact(context.Background(), "team", "pipe", "job", "1", "compile", strings.NewReader("data"))
+	require.NoError(t, err)
+}
+
+func TestDownloadBuildArtifact(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bn}/artifacts/{tn}", func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "application/octet-stream", req.Header.Get("Content-Type"))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("data"))
+	}).Methods("GET")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = c.DownloadBuildArtifact(context.Background(), "team", "pipe", "job", "1", "compile", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, "data", buf.String())
+}
+
+func TestDownloadBuildArtifact_Error(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bn}/artifacts/{tn}", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		jsonHandler(w, thttp.DownloadBuildArtifactResponse{Err: "failed to Get Artifact: artifact not found"})
+	}).Methods("GET")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = c.DownloadBuildArtifact(context.Background(), "team", "pipe", "job", "1", "compile", &buf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "artifact not found")
+	assert.Empty(t, buf.String())
+}
+
+func TestRegisterWorker(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/workers", func(w http.ResponseWriter, req *http.Request) {
+		var rr thttp.RegisterWorkerRequest
+		json.NewDecoder(req.Body).Decode(&rr)
+		assert.Equal(t, "w1", rr.Worker.Name)
+		rr.Worker.ID = 3
+		jsonHandler(w, thttp.RegisterWorkerResponse{Worker: &rr.Worker})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	rw, err := c.RegisterWorker(context.Background(), registry.Worker{Name: "w1", Concurrency: 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), rw.ID)
+}
+
+func TestHeartbeatWorker(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/workers/{wid}/heartbeat", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["wid"] != "3" {
+			jsonHandler(w, thttp.HeartbeatWorkerResponse{Err: pikoci.ErrWorkerNotRegistered.Error()})
+			return
+		}
+		jsonHandler(w, thttp.HeartbeatWorkerResponse{})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.HeartbeatWorker(context.Background(), 3)
+	require.NoError(t, err)
+
+	err = c.HeartbeatWorker(context.Background(), 4)
+	assert.ErrorIs(t, err, pikoci.ErrWorkerNotRegistered)
+}
+
+func TestListWorkers(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/workers", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListWorkersResponse{Workers: []*registry.Worker{{ID: 3, Name: "w1"}}})
+	}).Methods("GET")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	ws, err := c.ListWorkers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, ws, 1)
+	assert.Equal(t, "w1", ws[0].Name)
+}
+
+func TestClaimWork(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/workers/{wid}/work", func(w http.ResponseWriter, req *http.Request) {
+		var cr thttp.ClaimWorkRequest
+		json.NewDecoder(req.Body).Decode(&cr)
+		assert.Equal(t, []string{"docker"}, cr.Tags)
+		assert.Equal(t, 30*time.Second, cr.Wait)
+		if mux.Vars(req)["wid"] != "3" {
+			jsonHandler(w, thttp.ClaimWorkResponse{})
+			return
+		}
+		jsonHandler(w, thttp.ClaimWorkResponse{Lease: &queue.Lease{ID: "l1", WorkerID: 3, Body: []byte("{}"), TTL: time.Minute}})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	l, err := c.ClaimWork(context.Background(), 3, []string{"docker"}, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "l1", l.ID)
+	assert.Equal(t, []byte("{}"), l.Body)
+	assert.Equal(t, time.Minute, l.TTL)
+
+	l, err = c.ClaimWork(context.Background(), 4, []string{"docker"}, 30*time.Second)
+	require.NoError(t, err)
+	assert.Nil(t, l)
+}
+
+func TestRenewWorkLease(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/workers/{wid}/work/{lid}/renew", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["lid"] != "l1" {
+			// The errors are sent as a 400 like the server does
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.RenewWorkLeaseResponse{Err: queue.ErrLeaseNotFound.Error()})
+			return
+		}
+		jsonHandler(w, thttp.RenewWorkLeaseResponse{Lease: &queue.Lease{ID: "l1", WorkerID: 3}})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	l, err := c.RenewWorkLease(context.Background(), 3, "l1")
+	require.NoError(t, err)
+	assert.Equal(t, "l1", l.ID)
+
+	_, err = c.RenewWorkLease(context.Background(), 3, "l2")
+	assert.ErrorIs(t, err, queue.ErrLeaseNotFound)
+}
+
+func TestCompleteWork(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/workers/{wid}/work/{lid}/complete", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["lid"] != "l1" {
+			jsonHandler(w, thttp.CompleteWorkResponse{Err: queue.ErrLeaseNotFound.Error()})
+			return
+		}
+		jsonHandler(w, thttp.CompleteWorkResponse{})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.CompleteWork(context.Background(), 3, "l1")
+	require.NoError(t, err)
+
+	err = c.CompleteWork(context.Background(), 3, "l2")
+	assert.ErrorIs(t, err, queue.ErrLeaseNotFound)
+}
+
+func TestPublishWork(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/work", func(w http.ResponseWriter, req *http.Request) {
+		var pr thttp.PublishWorkRequest
+		json.NewDecoder(req.Body).Decode(&pr)
+		assert.Equal(t, []byte(`{"job_name":"jn"}`), pr.Body)
+		assert.Equal(t, map[string]string{queue.DelayMetadataKey: "2s"}, pr.Metadata)
+		jsonHandler(w, thttp.PublishWorkResponse{})
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	err = c.PublishWork(context.Background(), []byte(`{"job_name":"jn"}`), map[string]string{queue.DelayMetadataKey: "2s"})
+	require.NoError(t, err)
+}
+
+func TestStartJobBuild(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/builds/{bn}/start", func(w http.ResponseWriter, req *http.Request) {
+		var sr thttp.StartJobBuildRequest
+		json.NewDecoder(req.Body).Decode(&sr)
+		switch mux.Vars(req)["bn"] {
+		case "1":
+			assert.Equal(t, uint32(3), sr.Build.WorkerID)
+			jsonHandler(w, thttp.StartJobBuildResponse{Build: &build.Build{ID: 5, BuildNumber: "1", Status: build.Started}})
+		case "2":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.StartJobBuildResponse{Err: pikoci.ErrConcurrencyLimit.Error()})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.StartJobBuildResponse{Err: pikoci.ErrBuildNotPending.Error()})
+		}
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	b, err := c.StartJobBuild(context.Background(), "main", "pp", "jn", "1", build.Build{WorkerID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(5), b.ID)
+	assert.Equal(t, build.Started, b.Status)
+
+	_, err = c.StartJobBuild(context.Background(), "main", "pp", "jn", "2", build.Build{})
+	assert.ErrorIs(t, err, pikoci.ErrConcurrencyLimit)
+
+	_, err = c.StartJobBuild(context.Background(), "main", "pp", "jn", "3", build.Build{})
+	assert.ErrorIs(t, err, pikoci.ErrBuildNotPending)
+}
+
+func TestListQueuedBuilds(t *testing.T) {
+	queued := func(jn string) *build.Queued {
+		return &build.Queued{
+			WithJob: build.WithJob{Build: build.Build{BuildNumber: "1", Status: build.Pending}, JobName: jn},
+			Reason:  build.ReasonConcurrency,
+		}
+	}
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/queue", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListQueuedBuildsResponse{Builds: []*build.Queued{queued("team")}})
+	}).Methods("GET")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/queue", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListQueuedBuildsResponse{Builds: []*build.Queued{queued("pipeline")}})
+	}).Methods("GET")
+	r.HandleFunc("/teams/{tc}/pipelines/{pn}/jobs/{jn}/queue", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListQueuedBuildsResponse{Builds: []*build.Queued{queued(mux.Vars(req)["jn"])}})
+	}).Methods("GET")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+
+	for jn, args := range map[string][2]string{
+		"team":     {"", ""},
+		"pipeline": {"pp", ""},
+		"deploy":   {"pp", "deploy"},
+	} {
+		qbs, err := c.ListQueuedBuilds(context.Background(), "main", args[0], args[1])
+		require.NoError(t, err)
+		require.Len(t, qbs, 1)
+		assert.Equal(t, jn, qbs[0].JobName)
+		assert.Equal(t, build.ReasonConcurrency, qbs[0].Reason)
+		assert.Equal(t, build.Pending, qbs[0].Status)
+	}
+}
+
+func TestLocks(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/locks", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListLocksResponse{Locks: []*lock.Lock{{ID: 1, Name: "staging", Holder: "admin"}}})
+	}).Methods("GET")
+	r.HandleFunc("/teams/{tc}/locks/{ln}/acquire", func(w http.ResponseWriter, req *http.Request) {
+		var ar thttp.AcquireLockRequest
+		json.NewDecoder(req.Body).Decode(&ar)
+		if mux.Vars(req)["ln"] == "held" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.AcquireLockResponse{Err: lock.ErrHeld.Error()})
+			return
+		}
+		jsonHandler(w, thttp.AcquireLockResponse{Lock: &lock.Lock{ID: 2, Name: mux.Vars(req)["ln"], Holder: "admin", Reason: ar.Reason}})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/locks/{ln}/release", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["ln"] != "staging" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.ReleaseLockResponse{Err: pikoci.ErrLockNotHolder.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/locks/{ln}/force-release", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["ln"] != "staging" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.ForceReleaseLockResponse{Err: lock.ErrNotFound.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("POST")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ls, err := c.ListLocks(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, ls, 1)
+	assert.Equal(t, "staging", ls[0].Name)
+
+	l, err := c.AcquireLock(ctx, "main", "staging", "", "maintenance")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", l.Holder)
+	assert.Equal(t, "maintenance", l.Reason)
+
+	_, err = c.AcquireLock(ctx, "main", "held", "", "")
+	assert.ErrorIs(t, err, lock.ErrHeld)
+
+	require.NoError(t, c.ReleaseLock(ctx, "main", "staging", ""))
+	assert.ErrorIs(t, c.ReleaseLock(ctx, "main", "production", ""), pikoci.ErrLockNotHolder)
+
+	require.NoError(t, c.ForceReleaseLock(ctx, "main", "staging"))
+	assert.ErrorIs(t, c.ForceReleaseLock(ctx, "main", "qa"), lock.ErrNotFound)
+}
+
+func TestSecrets(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/teams/{tc}/secrets", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListSecretsResponse{Secrets: []*secret.Secret{{ID: 1, Name: "github_token"}}})
+	}).Methods("GET")
+	r.HandleFunc("/teams/{tc}/secrets/resolve", func(w http.ResponseWriter, req *http.Request) {
+		var rr thttp.ResolveSecretsRequest
+		json.NewDecoder(req.Body).Decode(&rr)
+		vs := make(map[string]string)
+		for _, n := range rr.Names {
+			vs[n] = n + "-value"
+		}
+		jsonHandler(w, thttp.ResolveSecretsResponse{Values: vs})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/secrets/{sn}", func(w http.ResponseWriter, req *http.Request) {
+		var sr thttp.SetSecretRequest
+		json.NewDecoder(req.Body).Decode(&sr)
+		if sr.Value == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.SetSecretResponse{Err: secret.ErrNoKeyring.Error()})
+			return
+		}
+		jsonHandler(w, thttp.SetSecretResponse{Secret: &secret.Secret{ID: 2, Name: mux.Vars(req)["sn"]}})
+	}).Methods("PUT")
+	r.HandleFunc("/teams/{tc}/secrets/{sn}", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["sn"] != "github_token" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.DeleteSecretResponse{Err: secret.ErrNotFound.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("DELETE")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ss, err := c.ListSecrets(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, ss, 1)
+	assert.Equal(t, "github_token", ss[0].Name)
+
+	s, err := c.SetSecret(ctx, "main", "npm_token", "s3cr3t")
+	require.NoError(t, err)
+	assert.Equal(t, "npm_token", s.Name)
+	assert.Empty(t, s.Value)
+
+	_, err = c.SetSecret(ctx, "main", "npm_token", "")
+	assert.ErrorIs(t, err, secret.ErrNoKeyring)
+
+	vs, err := c.ResolveSecrets(ctx, "main", []string{"github_token"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"github_token": "github_token-value"}, vs)
+
+	require.NoError(t, c.DeleteSecret(ctx, "main", "github_token"))
+	assert.ErrorIs(t, c.DeleteSecret(ctx, "main", "npm_token"), secret.ErrNotFound)
+}
+
+func TestTokens(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/user/tokens", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListUserTokensResponse{Tokens: []*apitoken.Token{{ID: 1, Name: "laptop"}}})
+	}).Methods("GET")
+	r.HandleFunc("/user/tokens", func(w http.ResponseWriter, req *http.Request) {
+		var cr thttp.CreateUserTokenRequest
+		json.NewDecoder(req.Body).Decode(&cr)
+		jsonHandler(w, thttp.CreateUserTokenResponse{Token: &thttp.CreatedToken{
+			Token: &apitoken.Token{ID: 2, Name: cr.Name, Scopes: cr.Scopes},
+			Value: "pikoci_user",
+		}})
+	}).Methods("POST")
+	r.HandleFunc("/user/tokens/{id}", func(w http.ResponseWriter, req *http.Request) {
+		if mux.Vars(req)["id"] != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(thttp.DeleteUserTokenResponse{Err: apitoken.ErrNotFound.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("DELETE")
+	r.HandleFunc("/teams/{tc}/tokens", func(w http.ResponseWriter, req *http.Request) {
+		jsonHandler(w, thttp.ListTeamTokensResponse{Tokens: []*apitoken.Token{{ID: 3, Name: "ci", TeamCanonical: mux.Vars(req)["tc"]}}})
+	}).Methods("GET")
+	r.HandleFunc("/teams/{tc}/tokens", func(w http.ResponseWriter, req *http.Request) {
+		var cr thttp.CreateTeamTokenRequest
+		json.NewDecoder(req.Body).Decode(&cr)
+		jsonHandler(w, thttp.CreateTeamTokenResponse{Token: &thttp.CreatedToken{
+			Token: &apitoken.Token{ID: 4, Name: cr.Name, TeamCanonical: mux.Vars(req)["tc"], ExpiresAt: cr.ExpiresAt},
+			Value: "pikoci_team",
+		}})
+	}).Methods("POST")
+	r.HandleFunc("/teams/{tc}/tokens/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("DELETE")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	c, err := client.New(ts.URL, "jwt")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	tks, err := c.ListUserTokens(ctx, "admin")
+	require.NoError(t, err)
+	require.Len(t, tks, 1)
+	assert.Equal(t, "laptop", tks[0].Name)
+
+	tk, v, err := c.CreateUserToken(ctx, "admin", apitoken.Token{Name: "ci", Scopes: []apitoken.Scope{apitoken.Trigger}})
+	require.NoError(t, err)
+	assert.Equal(t, "pikoci_user", v)
+	assert.Equal(t, "ci", tk.Name)
+	assert.Equal(t, []apitoken.Scope{apitoken.Trigger}, tk.Scopes)
+
+	require.NoError(t, c.DeleteUserToken(ctx, "admin", 1))
+	assert.ErrorIs(t, c.DeleteUserToken(ctx, "admin", 2), apitoken.ErrNotFound)
+
+	tks, err = c.ListTeamTokens(ctx, "main")
+	require.NoError(t, err)
+	require.Len(t, tks, 1)
+	assert.Equal(t, "main", tks[0].TeamCanonical)
+
+	exp := time.Now().Add(time.Hour).Round(time.Second).UTC()
+	tk, v, err = c.CreateTeamToken(ctx, "main", apitoken.Token{Name: "deploy", Scopes: []apitoken.Scope{apitoken.Admin}, ExpiresAt: &exp})
+	require.NoError(t, err)
+	assert.Equal(t, "pikoci_team", v)
+	assert.Equal(t, "main", tk.TeamCanonical)
+	require.NotNil(t, tk.ExpiresAt)
+	assert.True(t, exp.Equal(*tk.ExpiresAt))
+
+	require.NoError(t, c.DeleteTeamToken(ctx, "main", 4))
+}
+
 func TestRequestError(t *testing.T) {
 	r := mux.NewRouter()
 	r.HandleFunc("/teams", func(w http.ResponseWriter, req *http.Request) {
@@ -562,6 +1247,8 @@ func TestRequest_RefreshToken(t *testing.T) {
 	}).Methods("GET")
 	r.HandleFunc("/refresh-token", func(w http.ResponseWriter, req *http.Request) {
 		refreshCalled.Add(1)
+		// The server flags it too as the JWT used is still the stale one
+		w.Header().Set("X-Refresh-Token", "true")
 		resp := thttp.RefreshTokenResponse{}
 		resp.Data.User = &user.WithMemberships{User: user.User{Username: "alice"}}
 		resp.Data.JWT = "refreshed-jwt"
@@ -5,23 +5,47 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"regexp"
 	"strconv"
 
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/lock"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/secret"
 	thttp "github.com/xescugc/pikoci/pikoci/transport/http"
 )
 
 var successCodeRe = regexp.MustCompile(`2\d\d`)
 
+// sentinelErrors are the errors of the Service returned as they are
+// on the error responses, so they can be checked with errors.Is
+var sentinelErrors = []error{
+	pikoci.ErrWorkerNotRegistered,
+	pikoci.ErrPullWorkersDisabled,
+	pikoci.ErrConcurrencyLimit,
+	pikoci.ErrBuildNotPending,
+	pikoci.ErrPipelinePaused,
+	pikoci.ErrJobPaused,
+	pikoci.ErrLockNotHolder,
+	lock.ErrHeld,
+	lock.ErrNotFound,
+	queue.ErrLeaseNotFound,
+	secret.ErrNotFound,
+	secret.ErrNoKeyring,
+	apitoken.ErrNotFound,
+}
+
 func (c *Client) Request(ctx context.Context, method, url string, body, resp interface{}) error {
 	buff := bytes.NewBuffer(nil)
 	err := json.NewEncoder(buff).Encode(body)
 	if err != nil {
 		return fmt.Errorf("failed to Marshal body on %q: %w", url, err)
 	}
-	req, err := http.NewRequest(method, url, buff)
+	req, err := http.NewRequestWithContext(ctx, method, url, buff)
 	if err != nil {
 		return fmt.Errorf("failed to create request %q: %w", url, err)
 	}
@@ -36,12 +60,7 @@ func (c *Client) Request(ctx context.Context, method, url string, body, resp int
 	defer hresp.Body.Close()
 
 	if !successCodeRe.MatchString(strconv.Itoa(hresp.StatusCode)) {
-		var eresp thttp.ErrorResponse
-		err = json.NewDecoder(hresp.Body).Decode(&eresp)
-		if err != nil {
-			return fmt.Errorf("failed to read all body on %q: %w", url, err)
-		}
-		return fmt.Errorf("response error on %q: %s", url, eresp.Err)
+		return responseError(url, hresp)
 	} else if hresp.StatusCode != http.StatusNoContent {
 		err = json.NewDecoder(hresp.Body).Decode(resp)
 		if err != nil {
@@ -49,6 +68,42 @@ func (c *Client) Request(ctx context.Context, method, url string, body, resp int
 		}
 	}
 
+	// The refresh-token itself is also flagged while the JWT is
+	// stale, so it's not refreshed again from it
+	if hresp.Header.Get("X-Refresh-Token") == "true" && url != fmt.Sprintf("%s/refresh-token", c.url) {
+		c.refreshToken(ctx)
+	}
+
+	return nil
+}
+
+// Stream is like Request but the body and the response are sent as
+// they are with the "application/octet-stream" Content-Type. The body
+// is read from r, if not nil, and the response written to w, if not nil.
+// The errors are still returned as JSON.
+func (c *Client) Stream(ctx context.Context, method, url string, r io.Reader, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return fmt.Errorf("failed to create request %q: %w", url, err)
+	}
+	req.Header.Add("Content-Type", "application/octet-stream")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.jwt))
+
+	hresp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to do request %q: %w", url, err)
+	}
+	defer hresp.Body.Close()
+
+	if !successCodeRe.MatchString(strconv.Itoa(hresp.StatusCode)) {
+		return responseError(url, hresp)
+	}
+	if w != nil {
+		if _, err := io.Copy(w, hresp.Body); err != nil {
+			return fmt.Errorf("failed to read body on %q: %w", url, err)
+		}
+	}
+
 	if hresp.Header.Get("X-Refresh-Token") == "true" {
 		c.refreshToken(ctx)
 	}
@@ -56,6 +111,21 @@ func (c *Client) Request(ctx context.Context, method, url string, body, resp int
 	return nil
 }
 
+// responseError returns the error of the non successful response hresp
+func responseError(url string, hresp *http.Response) error {
+	var eresp thttp.ErrorResponse
+	err := json.NewDecoder(hresp.Body).Decode(&eresp)
+	if err != nil {
+		return fmt.Errorf("failed to read all body on %q: %w", url, err)
+	}
+	for _, se := range sentinelErrors {
+		if eresp.Err == se.Error() {
+			return fmt.Errorf("response error on %q: %w", url, se)
+		}
+	}
+	return fmt.Errorf("response error on %q: %s", url, eresp.Err)
+}
+
 func (c *Client) refreshToken(ctx context.Context) {
 	var resp thttp.RefreshTokenResponse
 
@@ -11,6 +11,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
 	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
 	"github.com/xescugc/pikoci/pikoci/transport/http/assets"
 	"github.com/xescugc/pikoci/pikoci/transport/http/templates"
 	"github.com/xescugc/pikoci/pikoci/user"
@@ -20,6 +21,9 @@ type contextKey string
 
 const (
 	UsernameContextKey   contextKey = "username_context_key"
+	// UserContextKey has the *user.WithMemberships authenticated
+	// with an API token
+	UserContextKey       contextKey = "user_context_key"
 	IsPublicAccessKey    contextKey = "is_public_access_key"
 )
 
@@ -61,9 +65,21 @@ func Handler(s pikoci.Service, ts []byte, l *slog.Logger) http.Handler {
 				un           string
 				isFromWorker bool
 				userClaim    map[string]interface{}
+				apiToken     *apitoken.Token
 			)
 
-			if !authFailed {
+			if !authFailed && apitoken.IsToken(splitToken[1]) {
+				t, um, err := s.AuthenticateToken(rr.Context(), splitToken[1])
+				if err != nil {
+					l.Error("authentication error", "error", err)
+					authFailed = true
+				} else {
+					apiToken = t
+					un = um.Username
+					ctx := context.WithValue(rr.Context(), UsernameContextKey, un)
+					rr = rr.WithContext(context.WithValue(ctx, UserContextKey, um))
+				}
+			} else if !authFailed {
 				tokenString := splitToken[1]
 				token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
 					return ts, nil
@@ -141,6 +157,15 @@ func Handler(s pikoci.Service, ts []byte, l *slog.Logger) http.Handler {
 				return
 			}
 
+			// The API tokens can only be used on the routes of their Scopes
+			if apiToken != nil {
+				sc := routeScopes[crn]
+				if !apiToken.HasScope(sc) {
+					encodeError(fmt.Sprintf("Token needs the %q scope", sc), rw)
+					return
+				}
+			}
+
 			// If the JWT has the 'is_from_worker' we assume admin
 			// so we do not even have to Authorize anything
 			if !isFromWorker {
@@ -166,7 +191,7 @@ func Handler(s pikoci.Service, ts []byte, l *slog.Logger) http.Handler {
 				}
 
 				// Check if JWT claims are stale compared to DB
-				if un != "" {
+				if un != "" && userClaim != nil {
 					um, err := s.GetUser(rr.Context(), un)
 					if err == nil && membershipsDiffer(userClaim, um) {
 						rw.Header().Set("X-Refresh-Token", "true")
@@ -180,6 +205,16 @@ func Handler(s pikoci.Service, ts []byte, l *slog.Logger) http.Handler {
 
 	r.Methods(http.MethodPost).Path("/webhooks/{webhook_token}").Name(WebhookTrigger.String()).Handler(webhookTrigger(s))
 
+	r.Methods(http.MethodGet).Path("/oidc/login").Name(OIDCLogin.String()).Handler(oidcLogin(s, ts, l))
+	r.Methods(http.MethodGet).Path("/oidc/callback").Name(OIDCCallback.String()).Handler(oidcCallback(s, ts, l))
+
+	streamr := r.Headers("Content-Type", artifactContentType).Subrouter()
+
+	streamr.Use(auth)
+
+	streamr.Methods(http.MethodPut).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/artifacts/{task_name}").Name(UploadBuildArtifact.String()).Handler(uploadBuildArtifact(s))
+	streamr.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/artifacts/{task_name}").Name(DownloadBuildArtifact.String()).Handler(downloadBuildArtifact(s))
+
 	jsonr := r.Headers("Content-Type", "application/json").Subrouter()
 
 	jsonr.Methods(http.MethodPost).Path("/login").Handler(userLogin(s))
@@ -202,27 +237,46 @@ func Handler(s pikoci.Service, ts []byte, l *slog.Logger) http.Handler {
 	api.Methods(http.MethodPut).Path("/teams/{team_canonical}/members/{member_username}").Name(UpdateTeamMember.String()).Handler(updateTeamMember(s))
 	api.Methods(http.MethodDelete).Path("/teams/{team_canonical}/members/{member_username}").Name(DeleteTeamMember.String()).Handler(deleteTeamMember(s))
 
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/queue").Name(ListQueuedBuilds.String()).Handler(listQueuedBuilds(s))
+
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines").Name(CreatePipeline.String()).Handler(createPipeline(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines").Name(ListPipelines.String()).Handler(listPipelines(s))
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/image{ext}").Name(CreatePipelineImage.String()).Handler(createPipelineImage(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}").Name(GetPipeline.String()).Handler(getPipeline(s))
 	api.Methods(http.MethodPut).Path("/teams/{team_canonical}/pipelines/{pipeline_name}").Name(UpdatePipeline.String()).Handler(updatePipeline(s))
 	api.Methods(http.MethodDelete).Path("/teams/{team_canonical}/pipelines/{pipeline_name}").Name(DeletePipeline.String()).Handler(deletePipeline(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/pause").Name(PausePipeline.String()).Handler(pausePipeline(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/unpause").Name(UnpausePipeline.String()).Handler(unpausePipeline(s))
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/queue").Name(ListPipelineQueuedBuilds.String()).Handler(listQueuedBuilds(s))
 
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/trigger").Name(TriggerPipelineJob.String()).Handler(triggerPipelineJob(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}").Name(GetPipelineJob.String()).Handler(getPipelineJob(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/pause").Name(PauseJob.String()).Handler(pauseJob(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/unpause").Name(UnpauseJob.String()).Handler(unpauseJob(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds").Name(ListJobBuilds.String()).Handler(listJobBuilds(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds").Name(CreateJobBuild.String()).Handler(createJobBuild(s))
 	api.Methods(http.MethodPut).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}").Name(UpdateJobBuild.String()).Handler(updateJobBuild(s))
 	api.Methods(http.MethodDelete).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}").Name(DeleteJobBuild.String()).Handler(deleteJobBuild(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}").Name(GetJobBuild.String()).Handler(getJobBuild(s))
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/cancel").Name(CancelJobBuild.String()).Handler(cancelJobBuild(s))
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/retry").Name(RetryJobBuild.String()).Handler(retryJobBuild(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/start").Name(StartJobBuild.String()).Handler(startJobBuild(s))
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/queue").Name(ListJobQueuedBuilds.String()).Handler(listQueuedBuilds(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/logs").Name(AppendJobBuildLogs.String()).Handler(appendJobBuildLogs(s))
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/events").Name(WatchJobBuild.String()).Handler(watchJobBuild(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/tests").Name(CreateJobBuildTests.String()).Handler(createJobBuildTests(s))
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_number}/tests").Name(ListJobBuildTests.String()).Handler(listJobBuildTests(s))
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/flaky-tests").Name(ListJobFlakyTests.String()).Handler(listJobFlakyTests(s))
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/retry-builds").Name(CreateRetryJobBuild.String()).Handler(createRetryJobBuild(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds-get-versions/{build_id}").Name(FindBuildGetVersions.String()).Handler(findBuildGetVersions(s))
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/jobs/{job_name}/builds/{build_id}/get-versions").Name(InsertBuildGetVersion.String()).Handler(insertBuildGetVersion(s))
 
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}/versions").Name(CreateResourceVersion.String()).Handler(createResourceVersion(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}/versions").Name(ListResourceVersions.String()).Handler(listResourceVersions(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}/versions/{version_id}/pin").Name(PinResourceVersion.String()).Handler(pinResourceVersion(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}/unpin").Name(UnpinResource.String()).Handler(unpinResource(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}/versions/{version_id}/enable").Name(EnableResourceVersion.String()).Handler(enableResourceVersion(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}/versions/{version_id}/disable").Name(DisableResourceVersion.String()).Handler(disableResourceVersion(s))
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}").Name(GetPipelineResource.String()).Handler(getPipelineResource(s))
 	api.Methods(http.MethodPut).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}").Name(UpdatePipelineResource.String()).Handler(updatePipelineResource(s))
 	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/resources/{resource_canonical}/trigger").Name(TriggerPipelineResource.String()).Handler(triggerPipelineResource(s))
@@ -230,6 +284,32 @@ func Handler(s pikoci.Service, ts []byte, l *slog.Logger) http.Handler {
 
 	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/pipelines/{pipeline_name}/image{ext}").Name(GetPipelineImage.String()).Handler(getPipelineImage(s))
 
+	api.Methods(http.MethodGet).Path("/workers").Name(ListWorkers.String()).Handler(listWorkers(s))
+	api.Methods(http.MethodPost).Path("/workers").Name(RegisterWorker.String()).Handler(registerWorker(s))
+	api.Methods(http.MethodPost).Path("/workers/{worker_id}/heartbeat").Name(HeartbeatWorker.String()).Handler(heartbeatWorker(s))
+	api.Methods(http.MethodPost).Path("/workers/{worker_id}/work").Name(ClaimWork.String()).Handler(claimWork(s))
+	api.Methods(http.MethodPost).Path("/workers/{worker_id}/work/{lease_id}/renew").Name(RenewWorkLease.String()).Handler(renewWorkLease(s))
+	api.Methods(http.MethodPost).Path("/workers/{worker_id}/work/{lease_id}/complete").Name(CompleteWork.String()).Handler(completeWork(s))
+	api.Methods(http.MethodPost).Path("/work").Name(PublishWork.String()).Handler(publishWork(s))
+
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/locks").Name(ListLocks.String()).Handler(listLocks(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/locks/{lock_name}/acquire").Name(AcquireLock.String()).Handler(acquireLock(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/locks/{lock_name}/release").Name(ReleaseLock.String()).Handler(releaseLock(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/locks/{lock_name}/force-release").Name(ForceReleaseLock.String()).Handler(forceReleaseLock(s))
+
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/secrets").Name(ListSecrets.String()).Handler(listSecrets(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/secrets/resolve").Name(ResolveSecrets.String()).Handler(resolveSecrets(s))
+	api.Methods(http.MethodPut).Path("/teams/{team_canonical}/secrets/{secret_name}").Name(SetSecret.String()).Handler(setSecret(s))
+	api.Methods(http.MethodDelete).Path("/teams/{team_canonical}/secrets/{secret_name}").Name(DeleteSecret.String()).Handler(deleteSecret(s))
+
+	api.Methods(http.MethodGet).Path("/user/tokens").Name(ListUserTokens.String()).Handler(listUserTokens(s))
+	api.Methods(http.MethodPost).Path("/user/tokens").Name(CreateUserToken.String()).Handler(createUserToken(s))
+	api.Methods(http.MethodDelete).Path("/user/tokens/{token_id}").Name(DeleteUserToken.String()).Handler(deleteUserToken(s))
+
+	api.Methods(http.MethodGet).Path("/teams/{team_canonical}/tokens").Name(ListTeamTokens.String()).Handler(listTeamTokens(s))
+	api.Methods(http.MethodPost).Path("/teams/{team_canonical}/tokens").Name(CreateTeamToken.String()).Handler(createTeamToken(s))
+	api.Methods(http.MethodDelete).Path("/teams/{team_canonical}/tokens/{token_id}").Name(DeleteTeamToken.String()).Handler(deleteTeamToken(s))
+
 	api.NotFoundHandler = http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -249,7 +329,12 @@ func Handler(s pikoci.Service, ts []byte, l *slog.Logger) http.Handler {
 			http.Error(w, "template not found", http.StatusInternalServerError)
 			return
 		}
-		if err := t.Execute(w, nil); err != nil {
+		data := struct {
+			OIDCEnabled bool
+		}{
+			OIDCEnabled: s.OIDCEnabled(r.Context()),
+		}
+		if err := t.Execute(w, data); err != nil {
 			l.Error("failed to execute template", "error", err)
 		}
 	})
@@ -294,11 +379,7 @@ func membershipsDiffer(jwtUser map[string]interface{}, dbUser *user.WithMembersh
 
 	dbSet := make(map[string]bool, len(dbUser.Memberships))
 	for _, m := range dbUser.Memberships {
-		key := m.TeamCanonical
-		if m.Admin {
-			key += ":admin"
-		}
-		dbSet[key] = true
+		dbSet[m.TeamCanonical+":"+string(m.Role)] = true
 	}
 	for _, jm := range jwtMemberships {
 		m, ok := jm.(map[string]interface{})
@@ -306,12 +387,8 @@ func membershipsDiffer(jwtUser map[string]interface{}, dbUser *user.WithMembersh
 			return true
 		}
 		tc, _ := m["team_canonical"].(string)
-		a, _ := m["admin"].(bool)
-		key := tc
-		if a {
-			key += ":admin"
-		}
-		if !dbSet[key] {
+		r, _ := m["role"].(string)
+		if !dbSet[tc+":"+r] {
 			return true
 		}
 	}
@@ -1,16 +1,26 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/mock"
+	"github.com/xescugc/pikoci/pikoci/resource"
 	"github.com/xescugc/pikoci/pikoci/user"
 	"go.uber.org/mock/gomock"
 )
@@ -55,6 +65,61 @@ func TestErrorResponse_Error(t *testing.T) {
 	assert.Equal(t, "", e.Error())
 }
 
+func TestRouteAuthorization(t *testing.T) {
+	for _, rn := range RouteNameValues() {
+		_, ok := routeAuthorization[rn]
+		assert.True(t, ok, "route %s has no auth", rn)
+	}
+}
+
+func TestRouteAuthorizationRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock.NewService(ctrl)
+	ctx := context.Background()
+
+	um := &user.WithMemberships{
+		User:        user.User{Username: "pepito"},
+		Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
+	}
+	s.EXPECT().GetUser(ctx, "pepito").Return(um, nil).AnyTimes()
+
+	tests := []struct {
+		route RouteName
+		tc    string
+		err   string
+	}{
+		{route: ListTeams},
+		{route: ListPipelines, tc: "main"},
+		{route: ListPipelines, tc: "other", err: "needs to be viewer"},
+		{route: TriggerPipelineJob, tc: "main"},
+		{route: CancelJobBuild, tc: "main"},
+		{route: RetryJobBuild, tc: "main"},
+		{route: UpdatePipeline, tc: "main", err: "needs to be member"},
+		{route: DeletePipeline, tc: "main", err: "needs to be member"},
+		{route: UpdateTeamMember, tc: "main", err: "needs to be owner"},
+		{route: SetSecret, tc: "main", err: "needs to be owner"},
+		{route: CreateTeam, err: "needs to be admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.route.String()+"/"+tt.tc, func(t *testing.T) {
+			err := routeAuthorization[tt.route](ctx, s, "pepito", tt.tc)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRouteScopes(t *testing.T) {
+	for _, rn := range RouteNameValues() {
+		sc, ok := routeScopes[rn]
+		assert.True(t, ok, "route %s has no scope", rn)
+		assert.True(t, sc.Valid(), "route %s has an invalid scope %q", rn, sc)
+	}
+}
+
 func TestMembershipsDiffer(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -67,12 +132,12 @@ func TestMembershipsDiffer(t *testing.T) {
 			jwtUser: map[string]interface{}{
 				"admin": false,
 				"memberships": []interface{}{
-					map[string]interface{}{"team_canonical": "main", "admin": false},
+					map[string]interface{}{"team_canonical": "main", "role": "operator"},
 				},
 			},
 			dbUser: &user.WithMemberships{
 				User:        user.User{Admin: false},
-				Memberships: []user.Member{{TeamCanonical: "main", Admin: false}},
+				Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
 			},
 			expected: false,
 		},
@@ -95,7 +160,7 @@ func TestMembershipsDiffer(t *testing.T) {
 			},
 			dbUser: &user.WithMemberships{
 				User:        user.User{Admin: false},
-				Memberships: []user.Member{{TeamCanonical: "main", Admin: false}},
+				Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
 			},
 			expected: true,
 		},
@@ -104,7 +169,7 @@ func TestMembershipsDiffer(t *testing.T) {
 			jwtUser: map[string]interface{}{
 				"admin": false,
 				"memberships": []interface{}{
-					map[string]interface{}{"team_canonical": "main", "admin": false},
+					map[string]interface{}{"team_canonical": "main", "role": "operator"},
 				},
 			},
 			dbUser: &user.WithMemberships{
@@ -113,16 +178,30 @@ func TestMembershipsDiffer(t *testing.T) {
 			expected: true,
 		},
 		{
-			name: "membership admin changed",
+			name: "membership without role",
+			jwtUser: map[string]interface{}{
+				"admin": false,
+				"memberships": []interface{}{
+					map[string]interface{}{"team_canonical": "main", "admin": true},
+				},
+			},
+			dbUser: &user.WithMemberships{
+				User:        user.User{Admin: false},
+				Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOwner}},
+			},
+			expected: true,
+		},
+		{
+			name: "membership role changed",
 			jwtUser: map[string]interface{}{
 				"admin": false,
 				"memberships": []interface{}{
-					map[string]interface{}{"team_canonical": "main", "admin": false},
+					map[string]interface{}{"team_canonical": "main", "role": "operator"},
 				},
 			},
 			dbUser: &user.WithMemberships{
 				User:        user.User{Admin: false},
-				Memberships: []user.Member{{TeamCanonical: "main", Admin: true}},
+				Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOwner}},
 			},
 			expected: true,
 		},
@@ -164,7 +243,7 @@ func TestRefreshTokenEndpoint(t *testing.T) {
 
 	updatedUM := &user.WithMemberships{
 		User:        user.User{Username: "pepito"},
-		Memberships: []user.Member{{TeamCanonical: "main", Admin: false}},
+		Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
 	}
 
 	// Stale-check in middleware calls GetUser; RefreshToken is the handler
@@ -208,7 +287,7 @@ func TestXRefreshTokenHeader(t *testing.T) {
 
 		updatedUM := &user.WithMemberships{
 			User:        user.User{Username: "pepito"},
-			Memberships: []user.Member{{TeamCanonical: "main", Admin: false}},
+			Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
 		}
 
 		// member() authz calls GetUser, then middleware stale-check calls GetUser again
@@ -235,7 +314,7 @@ func TestXRefreshTokenHeader(t *testing.T) {
 
 		um := &user.WithMemberships{
 			User:        user.User{Username: "pepito"},
-			Memberships: []user.Member{{TeamCanonical: "main", Admin: false}},
+			Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
 		}
 		jwtToken := signJWT(t, secret, um)
 
@@ -281,3 +360,541 @@ func TestXRefreshTokenHeader(t *testing.T) {
 	})
 }
 
+
+func TestTriggerPipelineJobEndpoint(t *testing.T) {
+	secret := []byte("test-secret")
+	logger := slog.Default()
+
+	um := &user.WithMemberships{
+		User:        user.User{Username: "pepito"},
+		Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		versions map[string]uint32
+	}{
+		{name: "without body"},
+		{name: "with versions", body: `{"versions":{"my-repo":42}}`, versions: map[string]uint32{"my-repo": 42}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock.NewService(ctrl)
+			handler := Handler(s, secret, logger)
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			s.EXPECT().GetUser(gomock.Any(), "pepito").Return(um, nil).AnyTimes()
+			s.EXPECT().TriggerPipelineJob(gomock.Any(), "main", "pp", "jn", tt.versions).Return(nil)
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/teams/main/pipelines/pp/jobs/jn/trigger.json", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+signJWT(t, secret, um))
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var tr TriggerPipelineJobResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&tr))
+			assert.Empty(t, tr.Err)
+		})
+	}
+}
+
+func TestLockEndpoints_HolderIsTheUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock.NewService(ctrl)
+	secret := []byte("test-secret")
+	logger := slog.Default()
+
+	handler := Handler(s, secret, logger)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	um := &user.WithMemberships{
+		User:        user.User{Username: "pepito"},
+		Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
+	}
+
+	s.EXPECT().GetUser(gomock.Any(), "pepito").Return(um, nil).AnyTimes()
+	// The holder on the body is ignored, the locks are always held by the user
+	s.EXPECT().AcquireLock(gomock.Any(), "main", "staging", "pepito", "maintenance").Return(&lock.Lock{Name: "staging", Holder: "pepito", Reason: "maintenance"}, nil)
+	s.EXPECT().ReleaseLock(gomock.Any(), "main", "staging", "pepito").Return(nil)
+
+	for _, tt := range []struct {
+		path string
+		body string
+	}{
+		{path: "/teams/main/locks/staging/acquire.json", body: `{"holder":"admin","reason":"maintenance"}`},
+		{path: "/teams/main/locks/staging/release.json", body: `{"holder":"admin"}`},
+	} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+tt.path, strings.NewReader(tt.body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+signJWT(t, secret, um))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, tt.path)
+	}
+}
+
+func TestCreateResourceVersionEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock.NewService(ctrl)
+	secret := []byte("test-secret")
+	handler := Handler(s, secret, slog.Default())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"is_from_worker": true,
+	})
+	workerJWT, err := token.SignedString(secret)
+	require.NoError(t, err)
+
+	v := resource.Version{Version: map[string]any{"ref": "abc"}}
+	s.EXPECT().CreateResourceVersion(gomock.Any(), "main", "pp", "git.repo", v).Return(&resource.Version{ID: 1}, nil)
+
+	// The body is the one sent by the client, with the empty
+	// URL fields that should not overwrite the URL vars
+	body, err := json.Marshal(CreateResourceVersionRequest{Version: v})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/teams/main/pipelines/pp/resources/git.repo/versions", strings.NewReader(string(body)))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+workerJWT)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var cr CreateResourceVersionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&cr))
+	assert.Empty(t, cr.Err)
+	assert.Equal(t, uint32(1), cr.Version.ID)
+}
+
+func TestURLVarsPrecedence(t *testing.T) {
+	// The body of all the requests has other Team, Pipeline, Job,
+	// Build and Resource than the URL, the ones of the URL are used
+	b := build.Build{Status: build.Started}
+	pr := resource.Resource{Canonical: "git.repo"}
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		Body   any
+		Expect func(s *mock.Service)
+	}{
+		{
+			Name:   "CreateJobBuild",
+			Method: http.MethodPost,
+			Path:   "/teams/main/pipelines/pp/jobs/jn/builds",
+			Body:   CreateJobBuildRequest{TeamCanonical: "other", PipelineName: "opp", JobName: "ojn", Build: b},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().CreateJobBuild(gomock.Any(), "main", "pp", "jn", b).Return(&b, nil)
+			},
+		},
+		{
+			Name:   "UpdateJobBuild",
+			Method: http.MethodPut,
+			Path:   "/teams/main/pipelines/pp/jobs/jn/builds/1",
+			Body:   UpdateJobBuildRequest{TeamCanonical: "other", PipelineName: "opp", JobName: "ojn", BuildNumber: "2", Build: b},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().UpdateJobBuild(gomock.Any(), "main", "pp", "jn", "1", b).Return(nil)
+			},
+		},
+		{
+			Name:   "CreateRetryJobBuild",
+			Method: http.MethodPost,
+			Path:   "/teams/main/pipelines/pp/jobs/jn/retry-builds",
+			Body:   CreateRetryJobBuildRequest{TeamCanonical: "other", PipelineName: "opp", JobName: "ojn", ParentBuildNumber: "1", Build: b},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().CreateRetryJobBuild(gomock.Any(), "main", "pp", "jn", "1", b).Return(&b, nil)
+			},
+		},
+		{
+			Name:   "CreatePipeline",
+			Method: http.MethodPost,
+			Path:   "/teams/main/pipelines",
+			Body:   CreatePipelineRequest{TeamCanonical: "other", Name: "pp", Config: []byte("config")},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().CreatePipeline(gomock.Any(), "main", "pp", []byte("config"), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			Name:   "UpdatePipeline",
+			Method: http.MethodPut,
+			Path:   "/teams/main/pipelines/pp",
+			Body:   UpdatePipelineRequest{TeamCanonical: "other", Name: "opp", Config: []byte("config")},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().UpdatePipeline(gomock.Any(), "main", "pp", []byte("config"), gomock.Any()).Return(nil, nil)
+				s.EXPECT().GetPipeline(gomock.Any(), "main", "pp").Return(nil, nil)
+			},
+		},
+		{
+			Name:   "CreatePipelineImage",
+			Method: http.MethodPost,
+			Path:   "/teams/main/pipelines/image.svg",
+			Body:   CreatePipelineImageRequest{TeamCanonical: "other", Config: []byte("config")},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().CreatePipelineImage(gomock.Any(), "main", []byte("config"), gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			Name:   "CreateResourceVersion",
+			Method: http.MethodPost,
+			Path:   "/teams/main/pipelines/pp/resources/git.repo/versions",
+			Body:   CreateResourceVersionRequest{TeamCanonical: "other", PipelineName: "opp", ResourceCanonical: "git.other"},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().CreateResourceVersion(gomock.Any(), "main", "pp", "git.repo", gomock.Any()).Return(&resource.Version{ID: 1}, nil)
+			},
+		},
+		{
+			Name:   "UpdatePipelineResource",
+			Method: http.MethodPut,
+			Path:   "/teams/main/pipelines/pp/resources/git.repo",
+			Body:   UpdatePipelineResourceRequest{TeamCanonical: "other", PipelineName: "opp", ResourceCanonical: "git.other", Resource: pr},
+			Expect: func(s *mock.Service) {
+				s.EXPECT().UpdatePipelineResource(gomock.Any(), "main", "pp", "git.repo", gomock.Any()).Return(nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s := mock.NewService(ctrl)
+			secret := []byte("test-secret")
+			server := httptest.NewServer(Handler(s, secret, slog.Default()))
+			defer server.Close()
+
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"is_from_worker": true,
+			})
+			workerJWT, err := token.SignedString(secret)
+			require.NoError(t, err)
+
+			tt.Expect(s)
+
+			body, err := json.Marshal(tt.Body)
+			require.NoError(t, err)
+			req, err := http.NewRequest(tt.Method, server.URL+tt.Path, strings.NewReader(string(body)))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+workerJWT)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var er ErrorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&er))
+			assert.Empty(t, er.Err)
+		})
+	}
+}
+
+func TestBuildArtifactEndpoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock.NewService(ctrl)
+	secret := []byte("test-secret")
+	handler := Handler(s, secret, slog.Default())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"is_from_worker": true,
+	})
+	workerJWT, err := token.SignedString(secret)
+	require.NoError(t, err)
+
+	u := server.URL + "/teams/main/pipelines/pp/jobs/jn/builds/3/artifacts/compile"
+
+	t.Run("Upload", func(t *testing.T) {
+		s.EXPECT().UploadBuildArtifact(gomock.Any(), "main", "pp", "jn", "3", "compile", gomock.Any()).
+			DoAndReturn(func(ctx context.Context, tc, pn, jn, bn, tn string, r io.Reader) error {
+				b, err := io.ReadAll(r)
+				require.NoError(t, err)
+				assert.Equal(t, "data", string(b))
+				return nil
+			})
+
+		req, err := http.NewRequest(http.MethodPut, u, strings.NewReader("data"))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+workerJWT)
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+	t.Run("Download", func(t *testing.T) {
+		s.EXPECT().DownloadBuildArtifact(gomock.Any(), "main", "pp", "jn", "3", "compile", gomock.Any()).
+			DoAndReturn(func(ctx context.Context, tc, pn, jn, bn, tn string, w io.Writer) error {
+				_, err := io.WriteString(w, "data")
+				return err
+			})
+
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+workerJWT)
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/octet-stream", resp.Header.Get("Content-Type"))
+
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(b))
+	})
+	t.Run("DownloadError", func(t *testing.T) {
+		s.EXPECT().DownloadBuildArtifact(gomock.Any(), "main", "pp", "jn", "3", "compile", gomock.Any()).
+			Return(fmt.Errorf("failed to Get Artifact: artifact not found"))
+
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+workerJWT)
+		req.Header.Set("Content-Type", "application/octet-stream")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		var dr DownloadBuildArtifactResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&dr))
+		assert.Equal(t, "failed to Get Artifact: artifact not found", dr.Err)
+	})
+}
+
+func TestWatchJobBuildEndpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mock.NewService(ctrl)
+	secret := []byte("test-secret")
+	handler := Handler(s, secret, slog.Default())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	um := &user.WithMemberships{
+		User:        user.User{Username: "pepito"},
+		Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOperator}},
+	}
+
+	events := make(chan build.Event, 2)
+	events <- build.Event{Type: build.EventLog, Log: &build.LogChunk{ID: 1, StepID: "s1", Data: "hello\n"}}
+	events <- build.Event{Type: build.EventStatus, Build: &build.Build{BuildNumber: "3", Status: build.Succeeded}}
+	close(events)
+
+	s.EXPECT().GetUser(gomock.Any(), "pepito").Return(um, nil).AnyTimes()
+	s.EXPECT().WatchJobBuild(gomock.Any(), "main", "pp", "jn", "3").Return(events, nil)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/teams/main/pipelines/pp/jobs/jn/builds/3/events.json", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+signJWT(t, secret, um))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	blocks := strings.Split(strings.TrimSuffix(string(body), "\n\n"), "\n\n")
+	require.Len(t, blocks, 2)
+	assert.Equal(t, `event: log`+"\n"+`data: {"type":"log","log":{"id":1,"step_id":"s1","offset":0,"data":"hello\n"}}`, blocks[0])
+	assert.True(t, strings.HasPrefix(blocks[1], "event: status\ndata: {\"type\":\"status\",\"build\":{"), blocks[1])
+}
+
+func TestAPITokenAuthentication(t *testing.T) {
+	secret := []byte("test-secret")
+	logger := slog.Default()
+
+	const raw = "pikoci_token"
+
+	do := func(t *testing.T, server *httptest.Server, method, path string) ErrorResponse {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(`{"value":"s3cr3t"}`))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+raw)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var er ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&er))
+		return er
+	}
+
+	t.Run("UserTokenWithScope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		um := &user.WithMemberships{
+			User:        user.User{Username: "pepito"},
+			Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleViewer}},
+		}
+		tk := &apitoken.Token{Username: "pepito", Scopes: []apitoken.Scope{apitoken.Read}}
+
+		// The User of the token is used for the authorization, without GetUser
+		s.EXPECT().AuthenticateToken(gomock.Any(), raw).Return(tk, um, nil)
+		s.EXPECT().ListSecrets(gomock.Any(), "main").Return(nil, nil)
+
+		er := do(t, server, http.MethodGet, "/teams/main/secrets.json")
+		assert.Empty(t, er.Err)
+	})
+
+	t.Run("UserTokenWithoutScope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		um := &user.WithMemberships{
+			User:        user.User{Username: "pepito"},
+			Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOwner}},
+		}
+		tk := &apitoken.Token{Username: "pepito", Scopes: []apitoken.Scope{apitoken.PipelineWrite}}
+
+		s.EXPECT().AuthenticateToken(gomock.Any(), raw).Return(tk, um, nil)
+
+		er := do(t, server, http.MethodDelete, "/teams/main/secrets/github_token.json")
+		assert.Equal(t, `Token needs the "admin" scope`, er.Err)
+	})
+
+	t.Run("ServiceAccountToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		um := &user.WithMemberships{
+			User:        user.User{Username: "main/ci"},
+			Memberships: []user.Member{{TeamCanonical: "main", Role: user.RoleOwner}},
+		}
+		tk := &apitoken.Token{Name: "ci", TeamCanonical: "main", Scopes: []apitoken.Scope{apitoken.Admin}}
+
+		s.EXPECT().AuthenticateToken(gomock.Any(), raw).Return(tk, um, nil).Times(2)
+		s.EXPECT().SetSecret(gomock.Any(), "main", "github_token", "s3cr3t").Return(nil, nil)
+
+		er := do(t, server, http.MethodPut, "/teams/main/secrets/github_token.json")
+		assert.Empty(t, er.Err)
+
+		er = do(t, server, http.MethodPut, "/teams/other/secrets/github_token.json")
+		assert.Equal(t, "Authentication required", er.Err)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		s.EXPECT().AuthenticateToken(gomock.Any(), raw).Return(nil, nil, apitoken.ErrExpired)
+
+		er := do(t, server, http.MethodGet, "/teams/main/secrets.json")
+		assert.Equal(t, "Authentication required", er.Err)
+	})
+}
+
+func TestOIDCLogin(t *testing.T) {
+	secret := []byte("test-secret")
+	logger := slog.Default()
+
+	c := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	callback := func(t *testing.T, server *httptest.Server, state string, cs []*http.Cookie) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/oidc/callback?code=code&state="+state, nil)
+		require.NoError(t, err)
+		for _, ck := range cs {
+			req.AddCookie(ck)
+		}
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		return resp
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		var state, nonce, verifier string
+		s.EXPECT().OIDCAuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, st, n, v string) (string, error) {
+			state, nonce, verifier = st, n, v
+			return "https://sso.example.com/authorize?state=" + st, nil
+		})
+
+		resp, err := c.Get(server.URL + "/oidc/login")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "https://sso.example.com/authorize?state="+state, resp.Header.Get("Location"))
+		require.Len(t, resp.Cookies(), 1)
+		assert.True(t, resp.Cookies()[0].HttpOnly)
+
+		um := &user.WithMemberships{User: user.User{Username: "pepito"}}
+		s.EXPECT().OIDCLogin(gomock.Any(), "code", verifier, nonce).Return(um, "jwt-token", nil)
+
+		resp = callback(t, server, state, resp.Cookies())
+		assert.Equal(t, "/login#jwt=jwt-token", resp.Header.Get("Location"))
+	})
+
+	t.Run("InvalidState", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		s.EXPECT().OIDCAuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("https://sso.example.com/authorize", nil)
+
+		resp, err := c.Get(server.URL + "/oidc/login")
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		resp = callback(t, server, "other", resp.Cookies())
+		assert.Equal(t, "/login#error="+url.QueryEscape("invalid OIDC state"), resp.Header.Get("Location"))
+	})
+
+	t.Run("MissingCookie", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		resp := callback(t, server, "state", nil)
+		assert.Equal(t, "/login#error="+url.QueryEscape("the OIDC login expired, try again"), resp.Header.Get("Location"))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := mock.NewService(ctrl)
+		server := httptest.NewServer(Handler(s, secret, logger))
+		defer server.Close()
+
+		s.EXPECT().OIDCAuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", pikoci.ErrOIDCDisabled)
+
+		resp, err := c.Get(server.URL + "/oidc/login")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/login#error="+url.QueryEscape(pikoci.ErrOIDCDisabled.Error()), resp.Header.Get("Location"))
+		assert.Empty(t, resp.Cookies())
+	})
+}
@@ -1,6 +1,9 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -12,6 +15,9 @@ type TriggerPipelineJobRequest struct {
 	TeamCanonical string `json:"team_canonical"`
 	PipelineName  string `json:"pipeline_name"`
 	JobName       string `json:"job_name"`
+
+	// Versions is the version ID to use on each get step (by name)
+	Versions map[string]uint32 `json:"versions,omitempty"`
 }
 type TriggerPipelineJobResponse struct {
 	Err string `json:"error,omitempty"`
@@ -25,11 +31,17 @@ func triggerPipelineJob(s pikoci.Service) http.HandlerFunc {
 			req TriggerPipelineJobRequest
 			ctx = r.Context()
 		)
+		// The body is optional, without it the versions are resolved by the worker
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			encodeResponse(TriggerPipelineJobResponse{Err: err.Error()}, w)
+			return
+		}
 		vars := mux.Vars(r)
 		req.TeamCanonical = vars["team_canonical"]
 		req.PipelineName = vars["pipeline_name"]
 		req.JobName = vars["job_name"]
-		err := s.TriggerPipelineJob(ctx, req.TeamCanonical, req.PipelineName, req.JobName)
+		err = s.TriggerPipelineJob(ctx, req.TeamCanonical, req.PipelineName, req.JobName, req.Versions)
 		var errs string
 		if err != nil {
 			errs = err.Error()
@@ -74,3 +86,63 @@ func getPipelineJob(s pikoci.Service) http.HandlerFunc {
 		encodeResponse(GetPipelineJobResponse{Job: j, Err: errs}, w)
 	}
 }
+
+type PauseJobRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	PipelineName  string `json:"pipeline_name"`
+	JobName       string `json:"job_name"`
+}
+type PauseJobResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r PauseJobResponse) Error() string { return r.Err }
+
+func pauseJob(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req PauseJobRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		err := s.PauseJob(ctx, req.TeamCanonical, req.PipelineName, req.JobName)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(PauseJobResponse{Err: errs}, w)
+	}
+}
+
+type UnpauseJobRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	PipelineName  string `json:"pipeline_name"`
+	JobName       string `json:"job_name"`
+}
+type UnpauseJobResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r UnpauseJobResponse) Error() string { return r.Err }
+
+func unpauseJob(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req UnpauseJobRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.JobName = vars["job_name"]
+		err := s.UnpauseJob(ctx, req.TeamCanonical, req.PipelineName, req.JobName)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(UnpauseJobResponse{Err: errs}, w)
+	}
+}
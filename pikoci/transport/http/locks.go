@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/lock"
+)
+
+type ListLocksRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+}
+type ListLocksResponse struct {
+	Locks []*lock.Lock `json:"data,omitempty"`
+	Err   string       `json:"error,omitempty"`
+}
+
+func (r ListLocksResponse) Error() string { return r.Err }
+
+func listLocks(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ListLocksRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		ls, err := s.ListLocks(ctx, req.TeamCanonical)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListLocksResponse{Locks: ls, Err: errs}, w)
+	}
+}
+
+type AcquireLockRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	LockName      string `json:"lock_name"`
+	// Reason is an optional note of why the lock is acquired,
+	// the Holder is always the username of the request
+	Reason string `json:"reason"`
+}
+type AcquireLockResponse struct {
+	Lock *lock.Lock `json:"data,omitempty"`
+	Err  string     `json:"error,omitempty"`
+}
+
+func (r AcquireLockResponse) Error() string { return r.Err }
+
+func acquireLock(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req AcquireLockRequest
+			ctx = r.Context()
+		)
+		// The body is optional, it only has the Reason
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			encodeResponse(AcquireLockResponse{Err: err.Error()}, w)
+			return
+		}
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.LockName = vars["lock_name"]
+		un, _ := ctx.Value(UsernameContextKey).(string)
+		l, err := s.AcquireLock(ctx, req.TeamCanonical, req.LockName, un, req.Reason)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(AcquireLockResponse{Lock: l, Err: errs}, w)
+	}
+}
+
+type ReleaseLockRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	LockName      string `json:"lock_name"`
+}
+type ReleaseLockResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r ReleaseLockResponse) Error() string { return r.Err }
+
+func releaseLock(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ReleaseLockRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.LockName = vars["lock_name"]
+		un, _ := ctx.Value(UsernameContextKey).(string)
+		err := s.ReleaseLock(ctx, req.TeamCanonical, req.LockName, un)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ReleaseLockResponse{Err: errs}, w)
+	}
+}
+
+type ForceReleaseLockRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	LockName      string `json:"lock_name"`
+}
+type ForceReleaseLockResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r ForceReleaseLockResponse) Error() string { return r.Err }
+
+func forceReleaseLock(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ForceReleaseLockRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.LockName = vars["lock_name"]
+		err := s.ForceReleaseLock(ctx, req.TeamCanonical, req.LockName)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ForceReleaseLockResponse{Err: errs}, w)
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/oidc"
+)
+
+const (
+	// oidcCookieName has the state, nonce and PKCE verifier of
+	// the OIDC login in progress, signed with the JWT secret
+	oidcCookieName = "pikoci_oidc"
+	oidcCookieTTL  = 10 * time.Minute
+)
+
+// oidcLogin starts the OIDC login by redirecting to the Provider, the
+// state to check on the oidcCallback is kept on a cookie of the browser
+func oidcLogin(s pikoci.Service, ts []byte, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var vs [3]string
+		for i := range vs {
+			v, err := oidc.RandomString()
+			if err != nil {
+				oidcRedirectError(w, r, l, err)
+				return
+			}
+			vs[i] = v
+		}
+		state, nonce, verifier := vs[0], vs[1], vs[2]
+
+		au, err := s.OIDCAuthCodeURL(ctx, state, nonce, verifier)
+		if err != nil {
+			oidcRedirectError(w, r, l, err)
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"state":    state,
+			"nonce":    nonce,
+			"verifier": verifier,
+			"exp":      time.Now().Add(oidcCookieTTL).Unix(),
+		})
+		c, err := token.SignedString(ts)
+		if err != nil {
+			oidcRedirectError(w, r, l, fmt.Errorf("failed to sign the OIDC state: %w", err))
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcCookieName,
+			Value:    c,
+			Path:     "/oidc",
+			MaxAge:   int(oidcCookieTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, au, http.StatusFound)
+	}
+}
+
+// oidcCallback finishes the OIDC login and redirects to the UI with
+// the JWT on the fragment, so it's not sent to any server
+func oidcCallback(s pikoci.Service, ts []byte, l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		// The cookie is only needed once
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcCookieName,
+			Path:     "/oidc",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		q := r.URL.Query()
+		if e := q.Get("error"); e != "" {
+			oidcRedirectError(w, r, l, fmt.Errorf("the OIDC login failed: %s %s", e, q.Get("error_description")))
+			return
+		}
+
+		c, err := r.Cookie(oidcCookieName)
+		if err != nil {
+			oidcRedirectError(w, r, l, fmt.Errorf("the OIDC login expired, try again"))
+			return
+		}
+
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(c.Value, claims, func(token *jwt.Token) (any, error) {
+			return ts, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+		if err != nil {
+			oidcRedirectError(w, r, l, fmt.Errorf("invalid OIDC state: %w", err))
+			return
+		}
+
+		state, _ := claims["state"].(string)
+		nonce, _ := claims["nonce"].(string)
+		verifier, _ := claims["verifier"].(string)
+		if state == "" || state != q.Get("state") {
+			oidcRedirectError(w, r, l, fmt.Errorf("invalid OIDC state"))
+			return
+		}
+
+		_, token, err := s.OIDCLogin(ctx, q.Get("code"), verifier, nonce)
+		if err != nil {
+			oidcRedirectError(w, r, l, err)
+			return
+		}
+
+		http.Redirect(w, r, "/login#jwt="+url.QueryEscape(token), http.StatusFound)
+	}
+}
+
+func oidcRedirectError(w http.ResponseWriter, r *http.Request, l *slog.Logger, err error) {
+	l.Error("oidc login error", "error", err)
+	http.Redirect(w, r, "/login#error="+url.QueryEscape(err.Error()), http.StatusFound)
+}
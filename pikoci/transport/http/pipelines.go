@@ -28,13 +28,14 @@ func createPipeline(s pikoci.Service) http.HandlerFunc {
 			req CreatePipelineRequest
 			ctx = r.Context()
 		)
-		vars := mux.Vars(r)
-		req.TeamCanonical = vars["team_canonical"]
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			encodeResponse(CreatePipelineResponse{Err: err.Error()}, w)
 			return
 		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
 		pp, err := s.CreatePipeline(ctx, req.TeamCanonical, req.Name, req.Config, req.Vars)
 		var errs string
 		if err != nil {
@@ -64,14 +65,15 @@ func updatePipeline(s pikoci.Service) http.HandlerFunc {
 			req UpdatePipelineRequest
 			ctx = r.Context()
 		)
-		vars := mux.Vars(r)
-		req.TeamCanonical = vars["team_canonical"]
-		req.Name = vars["pipeline_name"]
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			encodeResponse(UpdatePipelineResponse{Err: err.Error()}, w)
 			return
 		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.Name = vars["pipeline_name"]
 		var pp *pipeline.Pipeline
 		var errs string
 		if len(req.Config) > 0 {
@@ -186,6 +188,62 @@ func deletePipeline(s pikoci.Service) http.HandlerFunc {
 	}
 }
 
+type PausePipelineRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	Name          string `json:"name"`
+}
+type PausePipelineResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r PausePipelineResponse) Error() string { return r.Err }
+
+func pausePipeline(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req PausePipelineRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.Name = vars["pipeline_name"]
+		req.TeamCanonical = vars["team_canonical"]
+		err := s.PausePipeline(ctx, req.TeamCanonical, req.Name)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(PausePipelineResponse{Err: errs}, w)
+	}
+}
+
+type UnpausePipelineRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	Name          string `json:"name"`
+}
+type UnpausePipelineResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r UnpausePipelineResponse) Error() string { return r.Err }
+
+func unpausePipeline(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req UnpausePipelineRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.Name = vars["pipeline_name"]
+		req.TeamCanonical = vars["team_canonical"]
+		err := s.UnpausePipeline(ctx, req.TeamCanonical, req.Name)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(UnpausePipelineResponse{Err: errs}, w)
+	}
+}
+
 type GetPipelineImageRequest struct {
 	TeamCanonical string `json:"team_canonical"`
 	Name          string `json:"name"`
@@ -242,14 +300,15 @@ func createPipelineImage(s pikoci.Service) http.HandlerFunc {
 			req CreatePipelineImageRequest
 			ctx = r.Context()
 		)
-		vars := mux.Vars(r)
-		req.TeamCanonical = vars["team_canonical"]
-		req.Format = vars["format"]
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			encodeResponse(CreatePipelineImageResponse{Err: err.Error()}, w)
 			return
 		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.Format = vars["format"]
 		img, err := s.CreatePipelineImage(ctx, req.TeamCanonical, req.Config, req.Vars, req.Format)
 		var errs string
 		if err != nil {
@@ -2,7 +2,10 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/xescugc/pikoci/pikoci"
@@ -28,15 +31,16 @@ func createResourceVersion(s pikoci.Service) http.HandlerFunc {
 			req CreateResourceVersionRequest
 			ctx = r.Context()
 		)
-		vars := mux.Vars(r)
-		req.TeamCanonical = vars["team_canonical"]
-		req.PipelineName = vars["pipeline_name"]
-		req.ResourceCanonical = vars["resource_canonical"]
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			encodeResponse(CreateResourceVersionResponse{Err: err.Error()}, w)
 			return
 		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.ResourceCanonical = vars["resource_canonical"]
 		ver, err := s.CreateResourceVersion(ctx, req.TeamCanonical, req.PipelineName, req.ResourceCanonical, req.Version)
 		var errs string
 		if err != nil {
@@ -83,6 +87,147 @@ func listResourceVersions(s pikoci.Service) http.HandlerFunc {
 	}
 }
 
+type PinResourceVersionRequest struct {
+	TeamCanonical     string `json:"team_canonical"`
+	PipelineName      string `json:"pipeline_name"`
+	ResourceCanonical string `json:"resource_canonical"`
+	VersionID         uint32 `json:"version_id"`
+}
+type PinResourceVersionResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r PinResourceVersionResponse) Error() string { return r.Err }
+
+func pinResourceVersion(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req PinResourceVersionRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.ResourceCanonical = vars["resource_canonical"]
+		vid, err := strconv.Atoi(vars["version_id"])
+		if err != nil {
+			encodeResponse(PinResourceVersionResponse{Err: fmt.Sprintf("invalid Version ID %q", vars["version_id"])}, w)
+			return
+		}
+		req.VersionID = uint32(vid)
+		err = s.PinResourceVersion(ctx, req.TeamCanonical, req.PipelineName, req.ResourceCanonical, req.VersionID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(PinResourceVersionResponse{Err: errs}, w)
+	}
+}
+
+type UnpinResourceRequest struct {
+	TeamCanonical     string `json:"team_canonical"`
+	PipelineName      string `json:"pipeline_name"`
+	ResourceCanonical string `json:"resource_canonical"`
+}
+type UnpinResourceResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r UnpinResourceResponse) Error() string { return r.Err }
+
+func unpinResource(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req UnpinResourceRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.ResourceCanonical = vars["resource_canonical"]
+		err := s.UnpinResource(ctx, req.TeamCanonical, req.PipelineName, req.ResourceCanonical)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(UnpinResourceResponse{Err: errs}, w)
+	}
+}
+
+type EnableResourceVersionRequest struct {
+	TeamCanonical     string `json:"team_canonical"`
+	PipelineName      string `json:"pipeline_name"`
+	ResourceCanonical string `json:"resource_canonical"`
+	VersionID         uint32 `json:"version_id"`
+}
+type EnableResourceVersionResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r EnableResourceVersionResponse) Error() string { return r.Err }
+
+func enableResourceVersion(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req EnableResourceVersionRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.ResourceCanonical = vars["resource_canonical"]
+		vid, err := strconv.Atoi(vars["version_id"])
+		if err != nil {
+			encodeResponse(EnableResourceVersionResponse{Err: fmt.Sprintf("invalid Version ID %q", vars["version_id"])}, w)
+			return
+		}
+		req.VersionID = uint32(vid)
+		err = s.EnableResourceVersion(ctx, req.TeamCanonical, req.PipelineName, req.ResourceCanonical, req.VersionID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(EnableResourceVersionResponse{Err: errs}, w)
+	}
+}
+
+type DisableResourceVersionRequest struct {
+	TeamCanonical     string `json:"team_canonical"`
+	PipelineName      string `json:"pipeline_name"`
+	ResourceCanonical string `json:"resource_canonical"`
+	VersionID         uint32 `json:"version_id"`
+}
+type DisableResourceVersionResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r DisableResourceVersionResponse) Error() string { return r.Err }
+
+func disableResourceVersion(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req DisableResourceVersionRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.ResourceCanonical = vars["resource_canonical"]
+		vid, err := strconv.Atoi(vars["version_id"])
+		if err != nil {
+			encodeResponse(DisableResourceVersionResponse{Err: fmt.Sprintf("invalid Version ID %q", vars["version_id"])}, w)
+			return
+		}
+		req.VersionID = uint32(vid)
+		err = s.DisableResourceVersion(ctx, req.TeamCanonical, req.PipelineName, req.ResourceCanonical, req.VersionID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(DisableResourceVersionResponse{Err: errs}, w)
+	}
+}
+
 type GetPipelineResourceRequest struct {
 	TeamCanonical     string `json:"team_canonical"`
 	PipelineName      string `json:"pipeline_name"`
@@ -114,7 +259,7 @@ func getPipelineResource(s pikoci.Service) http.HandlerFunc {
 			if res != nil {
 				un, _ := ctx.Value(UsernameContextKey).(string)
 				if un != "" {
-					um, uerr := s.GetUser(ctx, un)
+					um, uerr := getUser(ctx, s, un)
 					if uerr != nil || !um.IsAdmin(req.TeamCanonical) {
 						res.WebhookToken = ""
 					}
@@ -149,15 +294,16 @@ func updatePipelineResource(s pikoci.Service) http.HandlerFunc {
 			req UpdatePipelineResourceRequest
 			ctx = r.Context()
 		)
-		vars := mux.Vars(r)
-		req.TeamCanonical = vars["team_canonical"]
-		req.PipelineName = vars["pipeline_name"]
-		req.ResourceCanonical = vars["resource_canonical"]
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			encodeResponse(UpdatePipelineResourceResponse{Err: err.Error()}, w)
 			return
 		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.PipelineName = vars["pipeline_name"]
+		req.ResourceCanonical = vars["resource_canonical"]
 		err = s.UpdatePipelineResource(ctx, req.TeamCanonical, req.PipelineName, req.ResourceCanonical, req.Resource)
 		var errs string
 		if err != nil {
@@ -184,11 +330,19 @@ type WebhookTriggerResponse struct {
 
 func (r WebhookTriggerResponse) Error() string { return r.Err }
 
+// maxWebhookPayloadSize is the maximum size of the body of a webhook request
+const maxWebhookPayloadSize = 1 << 20
+
 func webhookTrigger(s pikoci.Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		token := vars["webhook_token"]
-		err := s.WebhookTrigger(r.Context(), token)
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadSize))
+		if err != nil {
+			encodeResponse(WebhookTriggerResponse{Err: fmt.Sprintf("failed to read body: %s", err)}, w)
+			return
+		}
+		err = s.WebhookTrigger(r.Context(), token, r.Header, body)
 		var errs string
 		if err != nil {
 			errs = err.Error()
@@ -26,12 +26,16 @@ const (
 	GetPipeline
 	DeletePipeline
 	ListPipelines
+	PausePipeline
+	UnpausePipeline
 
 	GetPipelineImage
 	CreatePipelineImage
 
 	TriggerPipelineJob
 	GetPipelineJob
+	PauseJob
+	UnpauseJob
 
 	CreateJobBuild
 	CreateRetryJobBuild
@@ -43,13 +47,57 @@ const (
 	GetJobBuild
 	CancelJobBuild
 	RetryJobBuild
+	StartJobBuild
+	ListQueuedBuilds
+	ListPipelineQueuedBuilds
+	ListJobQueuedBuilds
+	AppendJobBuildLogs
+	WatchJobBuild
+	CreateJobBuildTests
+	ListJobBuildTests
+	ListJobFlakyTests
+
+	UploadBuildArtifact
+	DownloadBuildArtifact
 
 	GetPipelineResource
 	UpdatePipelineResource
 	TriggerPipelineResource
 	CreateResourceVersion
 	ListResourceVersions
+	PinResourceVersion
+	UnpinResource
+	EnableResourceVersion
+	DisableResourceVersion
 
 	WebhookTrigger
 	RegenerateWebhookToken
+
+	RegisterWorker
+	HeartbeatWorker
+	ListWorkers
+	ClaimWork
+	RenewWorkLease
+	CompleteWork
+	PublishWork
+
+	ListLocks
+	AcquireLock
+	ReleaseLock
+	ForceReleaseLock
+
+	ListSecrets
+	SetSecret
+	DeleteSecret
+	ResolveSecrets
+
+	ListUserTokens
+	CreateUserToken
+	DeleteUserToken
+	ListTeamTokens
+	CreateTeamToken
+	DeleteTeamToken
+
+	OIDCLogin
+	OIDCCallback
 )
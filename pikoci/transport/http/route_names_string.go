@@ -7,11 +7,11 @@ import (
 	"strings"
 )
 
-const _RouteNameName = "user_loginrefresh_tokencreate_userlist_userscreate_teamlist_teamsget_teamupdate_teamdelete_teamcreate_team_memberupdate_team_memberdelete_team_membercreate_pipelineupdate_pipelineget_pipelinedelete_pipelinelist_pipelinesget_pipeline_imagecreate_pipeline_imagetrigger_pipeline_jobget_pipeline_jobcreate_job_buildcreate_retry_job_buildupdate_job_builddelete_job_buildlist_job_buildsinsert_build_get_versionfind_build_get_versionsget_job_buildcancel_job_buildretry_job_buildget_pipeline_resourceupdate_pipeline_resourcetrigger_pipeline_resourcecreate_resource_versionlist_resource_versionswebhook_triggerregenerate_webhook_token"
+const _RouteNameName = "user_loginrefresh_tokencreate_userlist_userscreate_teamlist_teamsget_teamupdate_teamdelete_teamcreate_team_memberupdate_team_memberdelete_team_membercreate_pipelineupdate_pipelineget_pipelinedelete_pipelinelist_pipelinespause_pipelineunpause_pipelineget_pipeline_imagecreate_pipeline_imagetrigger_pipeline_jobget_pipeline_jobpause_jobunpause_jobcreate_job_buildcreate_retry_job_buildupdate_job_builddelete_job_buildlist_job_buildsinsert_build_get_versionfind_build_get_versionsget_job_buildcancel_job_buildretry_job_buildstart_job_buildlist_queued_buildslist_pipeline_queued_buildslist_job_queued_buildsappend_job_build_logswatch_job_buildcreate_job_build_testslist_job_build_testslist_job_flaky_testsupload_build_artifactdownload_build_artifactget_pipeline_resourceupdate_pipeline_resourcetrigger_pipeline_resourcecreate_resource_versionlist_resource_versionspin_resource_versionunpin_resourceenable_resource_versiondisable_resource_versionwebhook_triggerregenerate_webhook_tokenregister_workerheartbeat_workerlist_workersclaim_workrenew_work_leasecomplete_workpublish_worklist_locksacquire_lockrelease_lockforce_release_locklist_secretsset_secretdelete_secretresolve_secretslist_user_tokenscreate_user_tokendelete_user_tokenlist_team_tokenscreate_team_tokendelete_team_tokenoidc_loginoidc_callback"
 
-var _RouteNameIndex = [...]uint16{0, 10, 23, 34, 44, 55, 65, 73, 84, 95, 113, 131, 149, 164, 179, 191, 206, 220, 238, 259, 279, 295, 311, 333, 349, 365, 380, 404, 427, 440, 456, 471, 492, 516, 541, 564, 586, 601, 625}
+var _RouteNameIndex = [...]uint16{0, 10, 23, 34, 44, 55, 65, 73, 84, 95, 113, 131, 149, 164, 179, 191, 206, 220, 234, 250, 268, 289, 309, 325, 334, 345, 361, 383, 399, 415, 430, 454, 477, 490, 506, 521, 536, 554, 581, 603, 624, 639, 661, 681, 701, 722, 745, 766, 790, 815, 838, 860, 880, 894, 917, 941, 956, 980, 995, 1011, 1023, 1033, 1049, 1062, 1074, 1084, 1096, 1108, 1126, 1138, 1148, 1161, 1176, 1192, 1209, 1226, 1242, 1259, 1276, 1286, 1299}
 
-const _RouteNameLowerName = "user_loginrefresh_tokencreate_userlist_userscreate_teamlist_teamsget_teamupdate_teamdelete_teamcreate_team_memberupdate_team_memberdelete_team_membercreate_pipelineupdate_pipelineget_pipelinedelete_pipelinelist_pipelinesget_pipeline_imagecreate_pipeline_imagetrigger_pipeline_jobget_pipeline_jobcreate_job_buildcreate_retry_job_buildupdate_job_builddelete_job_buildlist_job_buildsinsert_build_get_versionfind_build_get_versionsget_job_buildcancel_job_buildretry_job_buildget_pipeline_resourceupdate_pipeline_resourcetrigger_pipeline_resourcecreate_resource_versionlist_resource_versionswebhook_triggerregenerate_webhook_token"
+const _RouteNameLowerName = "user_loginrefresh_tokencreate_userlist_userscreate_teamlist_teamsget_teamupdate_teamdelete_teamcreate_team_memberupdate_team_memberdelete_team_membercreate_pipelineupdate_pipelineget_pipelinedelete_pipelinelist_pipelinespause_pipelineunpause_pipelineget_pipeline_imagecreate_pipeline_imagetrigger_pipeline_jobget_pipeline_jobpause_jobunpause_jobcreate_job_buildcreate_retry_job_buildupdate_job_builddelete_job_buildlist_job_buildsinsert_build_get_versionfind_build_get_versionsget_job_buildcancel_job_buildretry_job_buildstart_job_buildlist_queued_buildslist_pipeline_queued_buildslist_job_queued_buildsappend_job_build_logswatch_job_buildcreate_job_build_testslist_job_build_testslist_job_flaky_testsupload_build_artifactdownload_build_artifactget_pipeline_resourceupdate_pipeline_resourcetrigger_pipeline_resourcecreate_resource_versionlist_resource_versionspin_resource_versionunpin_resourceenable_resource_versiondisable_resource_versionwebhook_triggerregenerate_webhook_tokenregister_workerheartbeat_workerlist_workersclaim_workrenew_work_leasecomplete_workpublish_worklist_locksacquire_lockrelease_lockforce_release_locklist_secretsset_secretdelete_secretresolve_secretslist_user_tokenscreate_user_tokendelete_user_tokenlist_team_tokenscreate_team_tokendelete_team_tokenoidc_loginoidc_callback"
 
 func (i RouteName) String() string {
 	if i < 0 || i >= RouteName(len(_RouteNameIndex)-1) {
@@ -41,108 +41,234 @@ func _RouteNameNoOp() {
 	_ = x[GetPipeline-(14)]
 	_ = x[DeletePipeline-(15)]
 	_ = x[ListPipelines-(16)]
-	_ = x[GetPipelineImage-(17)]
-	_ = x[CreatePipelineImage-(18)]
-	_ = x[TriggerPipelineJob-(19)]
-	_ = x[GetPipelineJob-(20)]
-	_ = x[CreateJobBuild-(21)]
-	_ = x[CreateRetryJobBuild-(22)]
-	_ = x[UpdateJobBuild-(23)]
-	_ = x[DeleteJobBuild-(24)]
-	_ = x[ListJobBuilds-(25)]
-	_ = x[InsertBuildGetVersion-(26)]
-	_ = x[FindBuildGetVersions-(27)]
-	_ = x[GetJobBuild-(28)]
-	_ = x[CancelJobBuild-(29)]
-	_ = x[RetryJobBuild-(30)]
-	_ = x[GetPipelineResource-(31)]
-	_ = x[UpdatePipelineResource-(32)]
-	_ = x[TriggerPipelineResource-(33)]
-	_ = x[CreateResourceVersion-(34)]
-	_ = x[ListResourceVersions-(35)]
-	_ = x[WebhookTrigger-(36)]
-	_ = x[RegenerateWebhookToken-(37)]
+	_ = x[PausePipeline-(17)]
+	_ = x[UnpausePipeline-(18)]
+	_ = x[GetPipelineImage-(19)]
+	_ = x[CreatePipelineImage-(20)]
+	_ = x[TriggerPipelineJob-(21)]
+	_ = x[GetPipelineJob-(22)]
+	_ = x[PauseJob-(23)]
+	_ = x[UnpauseJob-(24)]
+	_ = x[CreateJobBuild-(25)]
+	_ = x[CreateRetryJobBuild-(26)]
+	_ = x[UpdateJobBuild-(27)]
+	_ = x[DeleteJobBuild-(28)]
+	_ = x[ListJobBuilds-(29)]
+	_ = x[InsertBuildGetVersion-(30)]
+	_ = x[FindBuildGetVersions-(31)]
+	_ = x[GetJobBuild-(32)]
+	_ = x[CancelJobBuild-(33)]
+	_ = x[RetryJobBuild-(34)]
+	_ = x[StartJobBuild-(35)]
+	_ = x[ListQueuedBuilds-(36)]
+	_ = x[ListPipelineQueuedBuilds-(37)]
+	_ = x[ListJobQueuedBuilds-(38)]
+	_ = x[AppendJobBuildLogs-(39)]
+	_ = x[WatchJobBuild-(40)]
+	_ = x[CreateJobBuildTests-(41)]
+	_ = x[ListJobBuildTests-(42)]
+	_ = x[ListJobFlakyTests-(43)]
+	_ = x[UploadBuildArtifact-(44)]
+	_ = x[DownloadBuildArtifact-(45)]
+	_ = x[GetPipelineResource-(46)]
+	_ = x[UpdatePipelineResource-(47)]
+	_ = x[TriggerPipelineResource-(48)]
+	_ = x[CreateResourceVersion-(49)]
+	_ = x[ListResourceVersions-(50)]
+	_ = x[PinResourceVersion-(51)]
+	_ = x[UnpinResource-(52)]
+	_ = x[EnableResourceVersion-(53)]
+	_ = x[DisableResourceVersion-(54)]
+	_ = x[WebhookTrigger-(55)]
+	_ = x[RegenerateWebhookToken-(56)]
+	_ = x[RegisterWorker-(57)]
+	_ = x[HeartbeatWorker-(58)]
+	_ = x[ListWorkers-(59)]
+	_ = x[ClaimWork-(60)]
+	_ = x[RenewWorkLease-(61)]
+	_ = x[CompleteWork-(62)]
+	_ = x[PublishWork-(63)]
+	_ = x[ListLocks-(64)]
+	_ = x[AcquireLock-(65)]
+	_ = x[ReleaseLock-(66)]
+	_ = x[ForceReleaseLock-(67)]
+	_ = x[ListSecrets-(68)]
+	_ = x[SetSecret-(69)]
+	_ = x[DeleteSecret-(70)]
+	_ = x[ResolveSecrets-(71)]
+	_ = x[ListUserTokens-(72)]
+	_ = x[CreateUserToken-(73)]
+	_ = x[DeleteUserToken-(74)]
+	_ = x[ListTeamTokens-(75)]
+	_ = x[CreateTeamToken-(76)]
+	_ = x[DeleteTeamToken-(77)]
+	_ = x[OIDCLogin-(78)]
+	_ = x[OIDCCallback-(79)]
 }
 
-var _RouteNameValues = []RouteName{UserLogin, RefreshToken, CreateUser, ListUsers, CreateTeam, ListTeams, GetTeam, UpdateTeam, DeleteTeam, CreateTeamMember, UpdateTeamMember, DeleteTeamMember, CreatePipeline, UpdatePipeline, GetPipeline, DeletePipeline, ListPipelines, GetPipelineImage, CreatePipelineImage, TriggerPipelineJob, GetPipelineJob, CreateJobBuild, CreateRetryJobBuild, UpdateJobBuild, DeleteJobBuild, ListJobBuilds, InsertBuildGetVersion, FindBuildGetVersions, GetJobBuild, CancelJobBuild, RetryJobBuild, GetPipelineResource, UpdatePipelineResource, TriggerPipelineResource, CreateResourceVersion, ListResourceVersions, WebhookTrigger, RegenerateWebhookToken}
+var _RouteNameValues = []RouteName{UserLogin, RefreshToken, CreateUser, ListUsers, CreateTeam, ListTeams, GetTeam, UpdateTeam, DeleteTeam, CreateTeamMember, UpdateTeamMember, DeleteTeamMember, CreatePipeline, UpdatePipeline, GetPipeline, DeletePipeline, ListPipelines, PausePipeline, UnpausePipeline, GetPipelineImage, CreatePipelineImage, TriggerPipelineJob, GetPipelineJob, PauseJob, UnpauseJob, CreateJobBuild, CreateRetryJobBuild, UpdateJobBuild, DeleteJobBuild, ListJobBuilds, InsertBuildGetVersion, FindBuildGetVersions, GetJobBuild, CancelJobBuild, RetryJobBuild, StartJobBuild, ListQueuedBuilds, ListPipelineQueuedBuilds, ListJobQueuedBuilds, AppendJobBuildLogs, WatchJobBuild, CreateJobBuildTests, ListJobBuildTests, ListJobFlakyTests, UploadBuildArtifact, DownloadBuildArtifact, GetPipelineResource, UpdatePipelineResource, TriggerPipelineResource, CreateResourceVersion, ListResourceVersions, PinResourceVersion, UnpinResource, EnableResourceVersion, DisableResourceVersion, WebhookTrigger, RegenerateWebhookToken, RegisterWorker, HeartbeatWorker, ListWorkers, ClaimWork, RenewWorkLease, CompleteWork, PublishWork, ListLocks, AcquireLock, ReleaseLock, ForceReleaseLock, ListSecrets, SetSecret, DeleteSecret, ResolveSecrets, ListUserTokens, CreateUserToken, DeleteUserToken, ListTeamTokens, CreateTeamToken, DeleteTeamToken, OIDCLogin, OIDCCallback}
 
 var _RouteNameNameToValueMap = map[string]RouteName{
-	_RouteNameName[0:10]:         UserLogin,
-	_RouteNameLowerName[0:10]:    UserLogin,
-	_RouteNameName[10:23]:        RefreshToken,
-	_RouteNameLowerName[10:23]:   RefreshToken,
-	_RouteNameName[23:34]:        CreateUser,
-	_RouteNameLowerName[23:34]:   CreateUser,
-	_RouteNameName[34:44]:        ListUsers,
-	_RouteNameLowerName[34:44]:   ListUsers,
-	_RouteNameName[44:55]:        CreateTeam,
-	_RouteNameLowerName[44:55]:   CreateTeam,
-	_RouteNameName[55:65]:        ListTeams,
-	_RouteNameLowerName[55:65]:   ListTeams,
-	_RouteNameName[65:73]:        GetTeam,
-	_RouteNameLowerName[65:73]:   GetTeam,
-	_RouteNameName[73:84]:        UpdateTeam,
-	_RouteNameLowerName[73:84]:   UpdateTeam,
-	_RouteNameName[84:95]:        DeleteTeam,
-	_RouteNameLowerName[84:95]:   DeleteTeam,
-	_RouteNameName[95:113]:       CreateTeamMember,
-	_RouteNameLowerName[95:113]:  CreateTeamMember,
-	_RouteNameName[113:131]:      UpdateTeamMember,
-	_RouteNameLowerName[113:131]: UpdateTeamMember,
-	_RouteNameName[131:149]:      DeleteTeamMember,
-	_RouteNameLowerName[131:149]: DeleteTeamMember,
-	_RouteNameName[149:164]:      CreatePipeline,
-	_RouteNameLowerName[149:164]: CreatePipeline,
-	_RouteNameName[164:179]:      UpdatePipeline,
-	_RouteNameLowerName[164:179]: UpdatePipeline,
-	_RouteNameName[179:191]:      GetPipeline,
-	_RouteNameLowerName[179:191]: GetPipeline,
-	_RouteNameName[191:206]:      DeletePipeline,
-	_RouteNameLowerName[191:206]: DeletePipeline,
-	_RouteNameName[206:220]:      ListPipelines,
-	_RouteNameLowerName[206:220]: ListPipelines,
-	_RouteNameName[220:238]:      GetPipelineImage,
-	_RouteNameLowerName[220:238]: GetPipelineImage,
-	_RouteNameName[238:259]:      CreatePipelineImage,
-	_RouteNameLowerName[238:259]: CreatePipelineImage,
-	_RouteNameName[259:279]:      TriggerPipelineJob,
-	_RouteNameLowerName[259:279]: TriggerPipelineJob,
-	_RouteNameName[279:295]:      GetPipelineJob,
-	_RouteNameLowerName[279:295]: GetPipelineJob,
-	_RouteNameName[295:311]:      CreateJobBuild,
-	_RouteNameLowerName[295:311]: CreateJobBuild,
-	_RouteNameName[311:333]:      CreateRetryJobBuild,
-	_RouteNameLowerName[311:333]: CreateRetryJobBuild,
-	_RouteNameName[333:349]:      UpdateJobBuild,
-	_RouteNameLowerName[333:349]: UpdateJobBuild,
-	_RouteNameName[349:365]:      DeleteJobBuild,
-	_RouteNameLowerName[349:365]: DeleteJobBuild,
-	_RouteNameName[365:380]:      ListJobBuilds,
-	_RouteNameLowerName[365:380]: ListJobBuilds,
-	_RouteNameName[380:404]:      InsertBuildGetVersion,
-	_RouteNameLowerName[380:404]: InsertBuildGetVersion,
-	_RouteNameName[404:427]:      FindBuildGetVersions,
-	_RouteNameLowerName[404:427]: FindBuildGetVersions,
-	_RouteNameName[427:440]:      GetJobBuild,
-	_RouteNameLowerName[427:440]: GetJobBuild,
-	_RouteNameName[440:456]:      CancelJobBuild,
-	_RouteNameLowerName[440:456]: CancelJobBuild,
-	_RouteNameName[456:471]:      RetryJobBuild,
-	_RouteNameLowerName[456:471]: RetryJobBuild,
-	_RouteNameName[471:492]:      GetPipelineResource,
-	_RouteNameLowerName[471:492]: GetPipelineResource,
-	_RouteNameName[492:516]:      UpdatePipelineResource,
-	_RouteNameLowerName[492:516]: UpdatePipelineResource,
-	_RouteNameName[516:541]:      TriggerPipelineResource,
-	_RouteNameLowerName[516:541]: TriggerPipelineResource,
-	_RouteNameName[541:564]:      CreateResourceVersion,
-	_RouteNameLowerName[541:564]: CreateResourceVersion,
-	_RouteNameName[564:586]:      ListResourceVersions,
-	_RouteNameLowerName[564:586]: ListResourceVersions,
-	_RouteNameName[586:601]:      WebhookTrigger,
-	_RouteNameLowerName[586:601]: WebhookTrigger,
-	_RouteNameName[601:625]:      RegenerateWebhookToken,
-	_RouteNameLowerName[601:625]: RegenerateWebhookToken,
+	_RouteNameName[0:10]:           UserLogin,
+	_RouteNameLowerName[0:10]:      UserLogin,
+	_RouteNameName[10:23]:          RefreshToken,
+	_RouteNameLowerName[10:23]:     RefreshToken,
+	_RouteNameName[23:34]:          CreateUser,
+	_RouteNameLowerName[23:34]:     CreateUser,
+	_RouteNameName[34:44]:          ListUsers,
+	_RouteNameLowerName[34:44]:     ListUsers,
+	_RouteNameName[44:55]:          CreateTeam,
+	_RouteNameLowerName[44:55]:     CreateTeam,
+	_RouteNameName[55:65]:          ListTeams,
+	_RouteNameLowerName[55:65]:     ListTeams,
+	_RouteNameName[65:73]:          GetTeam,
+	_RouteNameLowerName[65:73]:     GetTeam,
+	_RouteNameName[73:84]:          UpdateTeam,
+	_RouteNameLowerName[73:84]:     UpdateTeam,
+	_RouteNameName[84:95]:          DeleteTeam,
+	_RouteNameLowerName[84:95]:     DeleteTeam,
+	_RouteNameName[95:113]:         CreateTeamMember,
+	_RouteNameLowerName[95:113]:    CreateTeamMember,
+	_RouteNameName[113:131]:        UpdateTeamMember,
+	_RouteNameLowerName[113:131]:   UpdateTeamMember,
+	_RouteNameName[131:149]:        DeleteTeamMember,
+	_RouteNameLowerName[131:149]:   DeleteTeamMember,
+	_RouteNameName[149:164]:        CreatePipeline,
+	_RouteNameLowerName[149:164]:   CreatePipeline,
+	_RouteNameName[164:179]:        UpdatePipeline,
+	_RouteNameLowerName[164:179]:   UpdatePipeline,
+	_RouteNameName[179:191]:        GetPipeline,
+	_RouteNameLowerName[179:191]:   GetPipeline,
+	_RouteNameName[191:206]:        DeletePipeline,
+	_RouteNameLowerName[191:206]:   DeletePipeline,
+	_RouteNameName[206:220]:        ListPipelines,
+	_RouteNameLowerName[206:220]:   ListPipelines,
+	_RouteNameName[220:234]:        PausePipeline,
+	_RouteNameLowerName[220:234]:   PausePipeline,
+	_RouteNameName[234:250]:        UnpausePipeline,
+	_RouteNameLowerName[234:250]:   UnpausePipeline,
+	_RouteNameName[250:268]:        GetPipelineImage,
+	_RouteNameLowerName[250:268]:   GetPipelineImage,
+	_RouteNameName[268:289]:        CreatePipelineImage,
+	_RouteNameLowerName[268:289]:   CreatePipelineImage,
+	_RouteNameName[289:309]:        TriggerPipelineJob,
+	_RouteNameLowerName[289:309]:   TriggerPipelineJob,
+	_RouteNameName[309:325]:        GetPipelineJob,
+	_RouteNameLowerName[309:325]:   GetPipelineJob,
+	_RouteNameName[325:334]:        PauseJob,
+	_RouteNameLowerName[325:334]:   PauseJob,
+	_RouteNameName[334:345]:        UnpauseJob,
+	_RouteNameLowerName[334:345]:   UnpauseJob,
+	_RouteNameName[345:361]:        CreateJobBuild,
+	_RouteNameLowerName[345:361]:   CreateJobBuild,
+	_RouteNameName[361:383]:        CreateRetryJobBuild,
+	_RouteNameLowerName[361:383]:   CreateRetryJobBuild,
+	_RouteNameName[383:399]:        UpdateJobBuild,
+	_RouteNameLowerName[383:399]:   UpdateJobBuild,
+	_RouteNameName[399:415]:        DeleteJobBuild,
+	_RouteNameLowerName[399:415]:   DeleteJobBuild,
+	_RouteNameName[415:430]:        ListJobBuilds,
+	_RouteNameLowerName[415:430]:   ListJobBuilds,
+	_RouteNameName[430:454]:        InsertBuildGetVersion,
+	_RouteNameLowerName[430:454]:   InsertBuildGetVersion,
+	_RouteNameName[454:477]:        FindBuildGetVersions,
+	_RouteNameLowerName[454:477]:   FindBuildGetVersions,
+	_RouteNameName[477:490]:        GetJobBuild,
+	_RouteNameLowerName[477:490]:   GetJobBuild,
+	_RouteNameName[490:506]:        CancelJobBuild,
+	_RouteNameLowerName[490:506]:   CancelJobBuild,
+	_RouteNameName[506:521]:        RetryJobBuild,
+	_RouteNameLowerName[506:521]:   RetryJobBuild,
+	_RouteNameName[521:536]:        StartJobBuild,
+	_RouteNameLowerName[521:536]:   StartJobBuild,
+	_RouteNameName[536:554]:        ListQueuedBuilds,
+	_RouteNameLowerName[536:554]:   ListQueuedBuilds,
+	_RouteNameName[554:581]:        ListPipelineQueuedBuilds,
+	_RouteNameLowerName[554:581]:   ListPipelineQueuedBuilds,
+	_RouteNameName[581:603]:        ListJobQueuedBuilds,
+	_RouteNameLowerName[581:603]:   ListJobQueuedBuilds,
+	_RouteNameName[603:624]:        AppendJobBuildLogs,
+	_RouteNameLowerName[603:624]:   AppendJobBuildLogs,
+	_RouteNameName[624:639]:        WatchJobBuild,
+	_RouteNameLowerName[624:639]:   WatchJobBuild,
+	_RouteNameName[639:661]:        CreateJobBuildTests,
+	_RouteNameLowerName[639:661]:   CreateJobBuildTests,
+	_RouteNameName[661:681]:        ListJobBuildTests,
+	_RouteNameLowerName[661:681]:   ListJobBuildTests,
+	_RouteNameName[681:701]:        ListJobFlakyTests,
+	_RouteNameLowerName[681:701]:   ListJobFlakyTests,
+	_RouteNameName[701:722]:        UploadBuildArtifact,
+	_RouteNameLowerName[701:722]:   UploadBuildArtifact,
+	_RouteNameName[722:745]:        DownloadBuildArtifact,
+	_RouteNameLowerName[722:745]:   DownloadBuildArtifact,
+	_RouteNameName[745:766]:        GetPipelineResource,
+	_RouteNameLowerName[745:766]:   GetPipelineResource,
+	_RouteNameName[766:790]:        UpdatePipelineResource,
+	_RouteNameLowerName[766:790]:   UpdatePipelineResource,
+	_RouteNameName[790:815]:        TriggerPipelineResource,
+	_RouteNameLowerName[790:815]:   TriggerPipelineResource,
+	_RouteNameName[815:838]:        CreateResourceVersion,
+	_RouteNameLowerName[815:838]:   CreateResourceVersion,
+	_RouteNameName[838:860]:        ListResourceVersions,
+	_RouteNameLowerName[838:860]:   ListResourceVersions,
+	_RouteNameName[860:880]:        PinResourceVersion,
+	_RouteNameLowerName[860:880]:   PinResourceVersion,
+	_RouteNameName[880:894]:        UnpinResource,
+	_RouteNameLowerName[880:894]:   UnpinResource,
+	_RouteNameName[894:917]:        EnableResourceVersion,
+	_RouteNameLowerName[894:917]:   EnableResourceVersion,
+	_RouteNameName[917:941]:        DisableResourceVersion,
+	_RouteNameLowerName[917:941]:   DisableResourceVersion,
+	_RouteNameName[941:956]:        WebhookTrigger,
+	_RouteNameLowerName[941:956]:   WebhookTrigger,
+	_RouteNameName[956:980]:        RegenerateWebhookToken,
+	_RouteNameLowerName[956:980]:   RegenerateWebhookToken,
+	_RouteNameName[980:995]:        RegisterWorker,
+	_RouteNameLowerName[980:995]:   RegisterWorker,
+	_RouteNameName[995:1011]:       HeartbeatWorker,
+	_RouteNameLowerName[995:1011]:  HeartbeatWorker,
+	_RouteNameName[1011:1023]:      ListWorkers,
+	_RouteNameLowerName[1011:1023]: ListWorkers,
+	_RouteNameName[1023:1033]:      ClaimWork,
+	_RouteNameLowerName[1023:1033]: ClaimWork,
+	_RouteNameName[1033:1049]:      RenewWorkLease,
+	_RouteNameLowerName[1033:1049]: RenewWorkLease,
+	_RouteNameName[1049:1062]:      CompleteWork,
+	_RouteNameLowerName[1049:1062]: CompleteWork,
+	_RouteNameName[1062:1074]:      PublishWork,
+	_RouteNameLowerName[1062:1074]: PublishWork,
+	_RouteNameName[1074:1084]:      ListLocks,
+	_RouteNameLowerName[1074:1084]: ListLocks,
+	_RouteNameName[1084:1096]:      AcquireLock,
+	_RouteNameLowerName[1084:1096]: AcquireLock,
+	_RouteNameName[1096:1108]:      ReleaseLock,
+	_RouteNameLowerName[1096:1108]: ReleaseLock,
+	_RouteNameName[1108:1126]:      ForceReleaseLock,
+	_RouteNameLowerName[1108:1126]: ForceReleaseLock,
+	_RouteNameName[1126:1138]:      ListSecrets,
+	_RouteNameLowerName[1126:1138]: ListSecrets,
+	_RouteNameName[1138:1148]:      SetSecret,
+	_RouteNameLowerName[1138:1148]: SetSecret,
+	_RouteNameName[1148:1161]:      DeleteSecret,
+	_RouteNameLowerName[1148:1161]: DeleteSecret,
+	_RouteNameName[1161:1176]:      ResolveSecrets,
+	_RouteNameLowerName[1161:1176]: ResolveSecrets,
+	_RouteNameName[1176:1192]:      ListUserTokens,
+	_RouteNameLowerName[1176:1192]: ListUserTokens,
+	_RouteNameName[1192:1209]:      CreateUserToken,
+	_RouteNameLowerName[1192:1209]: CreateUserToken,
+	_RouteNameName[1209:1226]:      DeleteUserToken,
+	_RouteNameLowerName[1209:1226]: DeleteUserToken,
+	_RouteNameName[1226:1242]:      ListTeamTokens,
+	_RouteNameLowerName[1226:1242]: ListTeamTokens,
+	_RouteNameName[1242:1259]:      CreateTeamToken,
+	_RouteNameLowerName[1242:1259]: CreateTeamToken,
+	_RouteNameName[1259:1276]:      DeleteTeamToken,
+	_RouteNameLowerName[1259:1276]: DeleteTeamToken,
+	_RouteNameName[1276:1286]:      OIDCLogin,
+	_RouteNameLowerName[1276:1286]: OIDCLogin,
+	_RouteNameName[1286:1299]:      OIDCCallback,
+	_RouteNameLowerName[1286:1299]: OIDCCallback,
 }
 
 var _RouteNameNames = []string{
@@ -163,27 +289,69 @@ var _RouteNameNames = []string{
 	_RouteNameName[179:191],
 	_RouteNameName[191:206],
 	_RouteNameName[206:220],
-	_RouteNameName[220:238],
-	_RouteNameName[238:259],
-	_RouteNameName[259:279],
-	_RouteNameName[279:295],
-	_RouteNameName[295:311],
-	_RouteNameName[311:333],
-	_RouteNameName[333:349],
-	_RouteNameName[349:365],
-	_RouteNameName[365:380],
-	_RouteNameName[380:404],
-	_RouteNameName[404:427],
-	_RouteNameName[427:440],
-	_RouteNameName[440:456],
-	_RouteNameName[456:471],
-	_RouteNameName[471:492],
-	_RouteNameName[492:516],
-	_RouteNameName[516:541],
-	_RouteNameName[541:564],
-	_RouteNameName[564:586],
-	_RouteNameName[586:601],
-	_RouteNameName[601:625],
+	_RouteNameName[220:234],
+	_RouteNameName[234:250],
+	_RouteNameName[250:268],
+	_RouteNameName[268:289],
+	_RouteNameName[289:309],
+	_RouteNameName[309:325],
+	_RouteNameName[325:334],
+	_RouteNameName[334:345],
+	_RouteNameName[345:361],
+	_RouteNameName[361:383],
+	_RouteNameName[383:399],
+	_RouteNameName[399:415],
+	_RouteNameName[415:430],
+	_RouteNameName[430:454],
+	_RouteNameName[454:477],
+	_RouteNameName[477:490],
+	_RouteNameName[490:506],
+	_RouteNameName[506:521],
+	_RouteNameName[521:536],
+	_RouteNameName[536:554],
+	_RouteNameName[554:581],
+	_RouteNameName[581:603],
+	_RouteNameName[603:624],
+	_RouteNameName[624:639],
+	_RouteNameName[639:661],
+	_RouteNameName[661:681],
+	_RouteNameName[681:701],
+	_RouteNameName[701:722],
+	_RouteNameName[722:745],
+	_RouteNameName[745:766],
+	_RouteNameName[766:790],
+	_RouteNameName[790:815],
+	_RouteNameName[815:838],
+	_RouteNameName[838:860],
+	_RouteNameName[860:880],
+	_RouteNameName[880:894],
+	_RouteNameName[894:917],
+	_RouteNameName[917:941],
+	_RouteNameName[941:956],
+	_RouteNameName[956:980],
+	_RouteNameName[980:995],
+	_RouteNameName[995:1011],
+	_RouteNameName[1011:1023],
+	_RouteNameName[1023:1033],
+	_RouteNameName[1033:1049],
+	_RouteNameName[1049:1062],
+	_RouteNameName[1062:1074],
+	_RouteNameName[1074:1084],
+	_RouteNameName[1084:1096],
+	_RouteNameName[1096:1108],
+	_RouteNameName[1108:1126],
+	_RouteNameName[1126:1138],
+	_RouteNameName[1138:1148],
+	_RouteNameName[1148:1161],
+	_RouteNameName[1161:1176],
+	_RouteNameName[1176:1192],
+	_RouteNameName[1192:1209],
+	_RouteNameName[1209:1226],
+	_RouteNameName[1226:1242],
+	_RouteNameName[1242:1259],
+	_RouteNameName[1259:1276],
+	_RouteNameName[1276:1286],
+	_RouteNameName[1286:1299],
 }
 
 // RouteNameString retrieves an enum value from the enum constants string name.
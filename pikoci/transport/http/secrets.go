@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/secret"
+)
+
+type ListSecretsRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+}
+type ListSecretsResponse struct {
+	Secrets []*secret.Secret `json:"data,omitempty"`
+	Err     string           `json:"error,omitempty"`
+}
+
+func (r ListSecretsResponse) Error() string { return r.Err }
+
+func listSecrets(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ListSecretsRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		ss, err := s.ListSecrets(ctx, req.TeamCanonical)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListSecretsResponse{Secrets: ss, Err: errs}, w)
+	}
+}
+
+type SetSecretRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	SecretName    string `json:"secret_name"`
+	Value         string `json:"value"`
+}
+type SetSecretResponse struct {
+	Secret *secret.Secret `json:"data,omitempty"`
+	Err    string         `json:"error,omitempty"`
+}
+
+func (r SetSecretResponse) Error() string { return r.Err }
+
+func setSecret(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req SetSecretRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(SetSecretResponse{Err: err.Error()}, w)
+			return
+		}
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.SecretName = vars["secret_name"]
+		sec, err := s.SetSecret(ctx, req.TeamCanonical, req.SecretName, req.Value)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(SetSecretResponse{Secret: sec, Err: errs}, w)
+	}
+}
+
+type DeleteSecretRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	SecretName    string `json:"secret_name"`
+}
+type DeleteSecretResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r DeleteSecretResponse) Error() string { return r.Err }
+
+func deleteSecret(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req DeleteSecretRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		req.SecretName = vars["secret_name"]
+		err := s.DeleteSecret(ctx, req.TeamCanonical, req.SecretName)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(DeleteSecretResponse{Err: errs}, w)
+	}
+}
+
+type ResolveSecretsRequest struct {
+	TeamCanonical string   `json:"team_canonical"`
+	Names         []string `json:"names"`
+}
+type ResolveSecretsResponse struct {
+	Values map[string]string `json:"data,omitempty"`
+	Err    string            `json:"error,omitempty"`
+}
+
+func (r ResolveSecretsResponse) Error() string { return r.Err }
+
+func resolveSecrets(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ResolveSecretsRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(ResolveSecretsResponse{Err: err.Error()}, w)
+			return
+		}
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		vs, err := s.ResolveSecrets(ctx, req.TeamCanonical, req.Names)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ResolveSecretsResponse{Values: vs, Err: errs}, w)
+	}
+}
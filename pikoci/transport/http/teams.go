@@ -203,9 +203,9 @@ func createTeamMember(s pikoci.Service) http.HandlerFunc {
 }
 
 type UpdateTeamMemberRequest struct {
-	TeamCanonical  string `json:"team_canonical"`
-	MemberUsername string `json:"member_username"`
-	Admin          bool   `json:"admin"`
+	TeamCanonical  string    `json:"team_canonical"`
+	MemberUsername string    `json:"member_username"`
+	Role           user.Role `json:"role"`
 }
 type UpdateTeamMemberResponse struct {
 	Member *team.Member `json:"data,omitempty"`
@@ -229,8 +229,8 @@ func updateTeamMember(s pikoci.Service) http.HandlerFunc {
 		req.TeamCanonical = vars["team_canonical"]
 		req.MemberUsername = vars["member_username"]
 		tm, err := s.UpdateTeamMember(ctx, req.TeamCanonical, req.MemberUsername, team.Member{
-			Admin: req.Admin,
-			User:  user.User{Username: req.MemberUsername},
+			Role: req.Role,
+			User: user.User{Username: req.MemberUsername},
 		})
 		var errs string
 		if err != nil {
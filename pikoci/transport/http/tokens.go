@@ -0,0 +1,231 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/apitoken"
+)
+
+// CreatedToken is the Token just created with its
+// Value, which can't be retrieved again after
+type CreatedToken struct {
+	Token *apitoken.Token `json:"token"`
+	Value string          `json:"value"`
+}
+
+type ListUserTokensRequest struct {
+	Username string `json:"username"`
+}
+type ListUserTokensResponse struct {
+	Tokens []*apitoken.Token `json:"data,omitempty"`
+	Err    string            `json:"error,omitempty"`
+}
+
+func (r ListUserTokensResponse) Error() string { return r.Err }
+
+func listUserTokens(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ListUserTokensRequest
+			ctx = r.Context()
+		)
+		un, ok := ctx.Value(UsernameContextKey).(string)
+		if !ok {
+			encodeResponse(ListUserTokensResponse{Err: "missing username in context"}, w)
+			return
+		}
+		req.Username = un
+		ts, err := s.ListUserTokens(ctx, req.Username)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListUserTokensResponse{Tokens: ts, Err: errs}, w)
+	}
+}
+
+type CreateUserTokenRequest struct {
+	Username  string           `json:"username"`
+	Name      string           `json:"name"`
+	Scopes    []apitoken.Scope `json:"scopes"`
+	ExpiresAt *time.Time       `json:"expires_at,omitempty"`
+}
+type CreateUserTokenResponse struct {
+	Token *CreatedToken `json:"data,omitempty"`
+	Err   string        `json:"error,omitempty"`
+}
+
+func (r CreateUserTokenResponse) Error() string { return r.Err }
+
+func createUserToken(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req CreateUserTokenRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(CreateUserTokenResponse{Err: err.Error()}, w)
+			return
+		}
+		un, ok := ctx.Value(UsernameContextKey).(string)
+		if !ok {
+			encodeResponse(CreateUserTokenResponse{Err: "missing username in context"}, w)
+			return
+		}
+		req.Username = un
+		t, v, err := s.CreateUserToken(ctx, req.Username, apitoken.Token{
+			Name:      req.Name,
+			Scopes:    req.Scopes,
+			ExpiresAt: req.ExpiresAt,
+		})
+		if err != nil {
+			encodeResponse(CreateUserTokenResponse{Err: err.Error()}, w)
+			return
+		}
+		encodeResponse(CreateUserTokenResponse{Token: &CreatedToken{Token: t, Value: v}}, w)
+	}
+}
+
+type DeleteUserTokenRequest struct {
+	Username string `json:"username"`
+	TokenID  uint32 `json:"token_id"`
+}
+type DeleteUserTokenResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r DeleteUserTokenResponse) Error() string { return r.Err }
+
+func deleteUserToken(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req DeleteUserTokenRequest
+			ctx = r.Context()
+		)
+		un, ok := ctx.Value(UsernameContextKey).(string)
+		if !ok {
+			encodeResponse(DeleteUserTokenResponse{Err: "missing username in context"}, w)
+			return
+		}
+		req.Username = un
+		vars := mux.Vars(r)
+		tid, err := strconv.Atoi(vars["token_id"])
+		if err != nil {
+			encodeResponse(DeleteUserTokenResponse{Err: fmt.Sprintf("invalid Token ID %q", vars["token_id"])}, w)
+			return
+		}
+		req.TokenID = uint32(tid)
+		err = s.DeleteUserToken(ctx, req.Username, req.TokenID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(DeleteUserTokenResponse{Err: errs}, w)
+	}
+}
+
+type ListTeamTokensRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+}
+type ListTeamTokensResponse struct {
+	Tokens []*apitoken.Token `json:"data,omitempty"`
+	Err    string            `json:"error,omitempty"`
+}
+
+func (r ListTeamTokensResponse) Error() string { return r.Err }
+
+func listTeamTokens(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ListTeamTokensRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		ts, err := s.ListTeamTokens(ctx, req.TeamCanonical)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListTeamTokensResponse{Tokens: ts, Err: errs}, w)
+	}
+}
+
+type CreateTeamTokenRequest struct {
+	TeamCanonical string           `json:"team_canonical"`
+	Name          string           `json:"name"`
+	Scopes        []apitoken.Scope `json:"scopes"`
+	ExpiresAt     *time.Time       `json:"expires_at,omitempty"`
+}
+type CreateTeamTokenResponse struct {
+	Token *CreatedToken `json:"data,omitempty"`
+	Err   string        `json:"error,omitempty"`
+}
+
+func (r CreateTeamTokenResponse) Error() string { return r.Err }
+
+func createTeamToken(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req CreateTeamTokenRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(CreateTeamTokenResponse{Err: err.Error()}, w)
+			return
+		}
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		t, v, err := s.CreateTeamToken(ctx, req.TeamCanonical, apitoken.Token{
+			Name:      req.Name,
+			Scopes:    req.Scopes,
+			ExpiresAt: req.ExpiresAt,
+		})
+		if err != nil {
+			encodeResponse(CreateTeamTokenResponse{Err: err.Error()}, w)
+			return
+		}
+		encodeResponse(CreateTeamTokenResponse{Token: &CreatedToken{Token: t, Value: v}}, w)
+	}
+}
+
+type DeleteTeamTokenRequest struct {
+	TeamCanonical string `json:"team_canonical"`
+	TokenID       uint32 `json:"token_id"`
+}
+type DeleteTeamTokenResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r DeleteTeamTokenResponse) Error() string { return r.Err }
+
+func deleteTeamToken(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req DeleteTeamTokenRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		req.TeamCanonical = vars["team_canonical"]
+		tid, err := strconv.Atoi(vars["token_id"])
+		if err != nil {
+			encodeResponse(DeleteTeamTokenResponse{Err: fmt.Sprintf("invalid Token ID %q", vars["token_id"])}, w)
+			return
+		}
+		req.TokenID = uint32(tid)
+		err = s.DeleteTeamToken(ctx, req.TeamCanonical, req.TokenID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(DeleteTeamTokenResponse{Err: errs}, w)
+	}
+}
@@ -0,0 +1,241 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
+)
+
+type RegisterWorkerRequest struct {
+	Worker registry.Worker `json:"worker"`
+}
+type RegisterWorkerResponse struct {
+	Worker *registry.Worker `json:"data,omitempty"`
+	Err    string           `json:"error,omitempty"`
+}
+
+func (r RegisterWorkerResponse) Error() string {
+	return r.Err
+}
+
+func registerWorker(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req RegisterWorkerRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(RegisterWorkerResponse{Err: err.Error()}, w)
+			return
+		}
+		rw, err := s.RegisterWorker(ctx, req.Worker)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(RegisterWorkerResponse{Worker: rw, Err: errs}, w)
+	}
+}
+
+type HeartbeatWorkerRequest struct {
+	WorkerID uint32 `json:"worker_id"`
+}
+type HeartbeatWorkerResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r HeartbeatWorkerResponse) Error() string { return r.Err }
+
+func heartbeatWorker(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req HeartbeatWorkerRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		wid, err := strconv.Atoi(vars["worker_id"])
+		if err != nil {
+			encodeResponse(HeartbeatWorkerResponse{Err: fmt.Sprintf("invalid Worker ID %q", vars["worker_id"])}, w)
+			return
+		}
+		req.WorkerID = uint32(wid)
+		err = s.HeartbeatWorker(ctx, req.WorkerID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(HeartbeatWorkerResponse{Err: errs}, w)
+	}
+}
+
+type ListWorkersResponse struct {
+	Err     string             `json:"error,omitempty"`
+	Workers []*registry.Worker `json:"data,omitempty"`
+}
+
+func (r ListWorkersResponse) Error() string {
+	return r.Err
+}
+
+func listWorkers(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			ctx = r.Context()
+		)
+		ws, err := s.ListWorkers(ctx)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ListWorkersResponse{Workers: ws, Err: errs}, w)
+	}
+}
+
+type ClaimWorkRequest struct {
+	WorkerID uint32        `json:"worker_id"`
+	Tags     []string      `json:"tags"`
+	Wait     time.Duration `json:"wait"`
+}
+type ClaimWorkResponse struct {
+	Lease *queue.Lease `json:"data,omitempty"`
+	Err   string       `json:"error,omitempty"`
+}
+
+func (r ClaimWorkResponse) Error() string {
+	return r.Err
+}
+
+func claimWork(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req ClaimWorkRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(ClaimWorkResponse{Err: err.Error()}, w)
+			return
+		}
+		// The URL vars have precedence over the body
+		vars := mux.Vars(r)
+		wid, err := strconv.Atoi(vars["worker_id"])
+		if err != nil {
+			encodeResponse(ClaimWorkResponse{Err: fmt.Sprintf("invalid Worker ID %q", vars["worker_id"])}, w)
+			return
+		}
+		req.WorkerID = uint32(wid)
+		l, err := s.ClaimWork(ctx, req.WorkerID, req.Tags, req.Wait)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(ClaimWorkResponse{Lease: l, Err: errs}, w)
+	}
+}
+
+type RenewWorkLeaseRequest struct {
+	WorkerID uint32 `json:"worker_id"`
+	LeaseID  string `json:"lease_id"`
+}
+type RenewWorkLeaseResponse struct {
+	Lease *queue.Lease `json:"data,omitempty"`
+	Err   string       `json:"error,omitempty"`
+}
+
+func (r RenewWorkLeaseResponse) Error() string {
+	return r.Err
+}
+
+func renewWorkLease(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req RenewWorkLeaseRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		wid, err := strconv.Atoi(vars["worker_id"])
+		if err != nil {
+			encodeResponse(RenewWorkLeaseResponse{Err: fmt.Sprintf("invalid Worker ID %q", vars["worker_id"])}, w)
+			return
+		}
+		req.WorkerID = uint32(wid)
+		req.LeaseID = vars["lease_id"]
+		l, err := s.RenewWorkLease(ctx, req.WorkerID, req.LeaseID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(RenewWorkLeaseResponse{Lease: l, Err: errs}, w)
+	}
+}
+
+type CompleteWorkRequest struct {
+	WorkerID uint32 `json:"worker_id"`
+	LeaseID  string `json:"lease_id"`
+}
+type CompleteWorkResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r CompleteWorkResponse) Error() string { return r.Err }
+
+func completeWork(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req CompleteWorkRequest
+			ctx = r.Context()
+		)
+		vars := mux.Vars(r)
+		wid, err := strconv.Atoi(vars["worker_id"])
+		if err != nil {
+			encodeResponse(CompleteWorkResponse{Err: fmt.Sprintf("invalid Worker ID %q", vars["worker_id"])}, w)
+			return
+		}
+		req.WorkerID = uint32(wid)
+		req.LeaseID = vars["lease_id"]
+		err = s.CompleteWork(ctx, req.WorkerID, req.LeaseID)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(CompleteWorkResponse{Err: errs}, w)
+	}
+}
+
+type PublishWorkRequest struct {
+	Body     []byte            `json:"body"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+type PublishWorkResponse struct {
+	Err string `json:"error,omitempty"`
+}
+
+func (r PublishWorkResponse) Error() string { return r.Err }
+
+func publishWork(s pikoci.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			req PublishWorkRequest
+			ctx = r.Context()
+		)
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			encodeResponse(PublishWorkResponse{Err: err.Error()}, w)
+			return
+		}
+		err = s.PublishWork(ctx, req.Body, req.Metadata)
+		var errs string
+		if err != nil {
+			errs = err.Error()
+		}
+		encodeResponse(PublishWorkResponse{Err: errs}, w)
+	}
+}
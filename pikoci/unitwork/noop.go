@@ -5,6 +5,7 @@ import (
 
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/resource"
 	"github.com/xescugc/pikoci/pikoci/restype"
@@ -36,3 +37,4 @@ func (u *noopUnitOfWork) ResourceTypes() restype.Repository {
 func (u *noopUnitOfWork) Builds() build.Repository     { return u.repos.BuildsRepo }
 func (u *noopUnitOfWork) Runners() runner.Repository   { return u.repos.RunnersRepo }
 func (u *noopUnitOfWork) SecretTypes() sectype.Repository { return u.repos.SecretTypesRepo }
+func (u *noopUnitOfWork) Locks() lock.Repository          { return u.repos.LocksRepo }
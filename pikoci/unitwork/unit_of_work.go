@@ -7,6 +7,7 @@ import (
 
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/mysql"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/resource"
@@ -30,6 +31,7 @@ type unitOfWork struct {
 	builds        build.Repository
 	runners       runner.Repository
 	secretTypes sectype.Repository
+	locks       lock.Repository
 }
 
 func NewStartUnitOfWork(db *sql.DB, dbSystem string) StartUnitOfWork {
@@ -119,3 +121,9 @@ func (u *unitOfWork) SecretTypes() sectype.Repository {
 	return u.secretTypes
 }
 
+func (u *unitOfWork) Locks() lock.Repository {
+	if u.locks == nil {
+		u.locks = mysql.NewLockRepository(u.tx)
+	}
+	return u.locks
+}
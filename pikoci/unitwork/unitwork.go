@@ -5,6 +5,7 @@ import (
 
 	"github.com/xescugc/pikoci/pikoci/build"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/resource"
 	"github.com/xescugc/pikoci/pikoci/restype"
@@ -26,6 +27,7 @@ type UnitOfWork interface {
 	Builds() build.Repository
 	Runners() runner.Repository
 	SecretTypes() sectype.Repository
+	Locks() lock.Repository
 }
 
 // Repositories holds all repository interfaces, used to construct a noop UoW for testing.
@@ -39,4 +41,5 @@ type Repositories struct {
 	BuildsRepo        build.Repository
 	RunnersRepo       runner.Repository
 	SecretTypesRepo sectype.Repository
+	LocksRepo       lock.Repository
 }
@@ -6,6 +6,10 @@ type User struct {
 	Username string `json:"username"`
 	Password string `json:"-"`
 	Admin    bool   `json:"admin"`
+
+	// OIDCSubject is the subject of the OIDC Provider
+	// the User is provisioned from, if any
+	OIDCSubject string `json:"-"`
 }
 
 type WithMemberships struct {
@@ -15,32 +19,55 @@ type WithMemberships struct {
 }
 
 type Member struct {
-	Admin         bool   `json:"admin"`
+	Role          Role   `json:"role"`
 	TeamCanonical string `json:"team_canonical"`
 }
 
-func (u *WithMemberships) IsAdmin(tcs ...string) bool {
-	if u.Admin {
-		return true
-	}
-	for _, tc := range tcs {
-		if tc == "" {
-			continue
-		}
-		for _, m := range u.Memberships {
-			if m.Admin && m.TeamCanonical == tc {
-				return true
-			}
+// Role is what a Member can do on its Team
+type Role string
+
+// The Roles are ordered, each one allows
+// everything the previous ones allow
+const (
+	// RoleViewer can read the Team, Pipelines, Builds, ...
+	RoleViewer Role = "viewer"
+	// RoleOperator can trigger, pause, cancel and retry
+	// Jobs and Builds and operate the Resources and Locks
+	RoleOperator Role = "operator"
+	// RoleMember can set and delete the Pipelines
+	RoleMember Role = "member"
+	// RoleOwner can manage the Team, its Members, Secrets and Tokens
+	RoleOwner Role = "owner"
+)
+
+// Roles are all the Roles, ordered
+var Roles = []Role{RoleViewer, RoleOperator, RoleMember, RoleOwner}
+
+func (r Role) level() int {
+	for i, rl := range Roles {
+		if rl == r {
+			return i
 		}
 	}
-	return false
+	return -1
 }
 
-func (u *WithMemberships) IsMember(tcs ...string) bool {
+// Valid checks if r is one of the Roles
+func (r Role) Valid() bool { return r.level() != -1 }
+
+// Includes checks if r allows everything o does
+func (r Role) Includes(o Role) bool {
+	return r.Valid() && o.Valid() && r.level() >= o.level()
+}
+
+// HasRole checks if the User has at least the Role r on any of the Teams tcs.
+// Global admins have all the Roles, and if the only Team is empty every
+// User has all the Roles but RoleOwner.
+func (u *WithMemberships) HasRole(r Role, tcs ...string) bool {
 	if u.Admin {
 		return true
 	}
-	if len(tcs) == 1 && tcs[0] == "" {
+	if len(tcs) == 1 && tcs[0] == "" && r != RoleOwner {
 		// In case it's only an empty one it's member
 		return true
 	}
@@ -49,10 +76,20 @@ func (u *WithMemberships) IsMember(tcs ...string) bool {
 			continue
 		}
 		for _, m := range u.Memberships {
-			if m.TeamCanonical == tc {
+			if m.TeamCanonical == tc && m.Role.Includes(r) {
 				return true
 			}
 		}
 	}
 	return false
 }
+
+// IsAdmin checks if the User is owner of any of the Teams tcs
+func (u *WithMemberships) IsAdmin(tcs ...string) bool {
+	return u.HasRole(RoleOwner, tcs...)
+}
+
+// IsMember checks if the User has any Role on any of the Teams tcs
+func (u *WithMemberships) IsMember(tcs ...string) bool {
+	return u.HasRole(RoleViewer, tcs...)
+}
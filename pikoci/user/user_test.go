@@ -20,8 +20,8 @@ func TestWithMemberships_IsAdmin(t *testing.T) {
 		u := &user.WithMemberships{
 			User: user.User{Admin: false},
 			Memberships: []user.Member{
-				{Admin: true, TeamCanonical: "team-a"},
-				{Admin: false, TeamCanonical: "team-b"},
+				{Role: user.RoleOwner, TeamCanonical: "team-a"},
+				{Role: user.RoleViewer, TeamCanonical: "team-b"},
 			},
 		}
 		assert.True(t, u.IsAdmin("team-a"))
@@ -41,7 +41,7 @@ func TestWithMemberships_IsAdmin(t *testing.T) {
 		u := &user.WithMemberships{
 			User: user.User{Admin: false},
 			Memberships: []user.Member{
-				{Admin: true, TeamCanonical: "team-a"},
+				{Role: user.RoleOwner, TeamCanonical: "team-a"},
 			},
 		}
 		assert.False(t, u.IsAdmin(""))
@@ -60,7 +60,7 @@ func TestWithMemberships_IsMember(t *testing.T) {
 		u := &user.WithMemberships{
 			User: user.User{Admin: false},
 			Memberships: []user.Member{
-				{Admin: false, TeamCanonical: "team-a"},
+				{Role: user.RoleViewer, TeamCanonical: "team-a"},
 			},
 		}
 		assert.True(t, u.IsMember("team-a"))
@@ -81,3 +81,44 @@ func TestWithMemberships_IsMember(t *testing.T) {
 		assert.False(t, u.IsMember("team-a"))
 	})
 }
+
+func TestRole_Includes(t *testing.T) {
+	assert.True(t, user.RoleOwner.Includes(user.RoleViewer))
+	assert.True(t, user.RoleMember.Includes(user.RoleOperator))
+	assert.True(t, user.RoleOperator.Includes(user.RoleOperator))
+	assert.False(t, user.RoleOperator.Includes(user.RoleMember))
+	assert.False(t, user.RoleViewer.Includes(user.RoleOwner))
+	assert.False(t, user.Role("admin").Includes(user.RoleViewer))
+	assert.False(t, user.RoleOwner.Includes(user.Role("admin")))
+}
+
+func TestWithMemberships_HasRole(t *testing.T) {
+	t.Run("global admin has all the roles", func(t *testing.T) {
+		u := &user.WithMemberships{
+			User: user.User{Admin: true},
+		}
+		assert.True(t, u.HasRole(user.RoleOwner, "any-team"))
+		assert.True(t, u.HasRole(user.RoleOwner, ""))
+	})
+
+	t.Run("roles are hierarchical", func(t *testing.T) {
+		u := &user.WithMemberships{
+			Memberships: []user.Member{
+				{Role: user.RoleOperator, TeamCanonical: "team-a"},
+				{Role: user.RoleMember, TeamCanonical: "team-b"},
+			},
+		}
+		assert.True(t, u.HasRole(user.RoleViewer, "team-a"))
+		assert.True(t, u.HasRole(user.RoleOperator, "team-a"))
+		assert.False(t, u.HasRole(user.RoleMember, "team-a"))
+		assert.True(t, u.HasRole(user.RoleMember, "team-b"))
+		assert.False(t, u.HasRole(user.RoleOwner, "team-b"))
+		assert.False(t, u.HasRole(user.RoleViewer, "team-c"))
+	})
+
+	t.Run("empty team canonical only", func(t *testing.T) {
+		u := &user.WithMemberships{}
+		assert.True(t, u.HasRole(user.RoleMember, ""))
+		assert.False(t, u.HasRole(user.RoleOwner, ""))
+	})
+}
@@ -0,0 +1,282 @@
+package pikoci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
+	"github.com/xescugc/pikoci/pikoci/utils"
+	"gocloud.dev/pubsub"
+)
+
+// ErrWorkerNotRegistered is returned on the heartbeat of a worker
+// that is no longer registered, it has to register again
+var ErrWorkerNotRegistered = errors.New("worker not registered")
+
+// ErrPullWorkersDisabled is returned when a worker tries to claim
+// work from a server that does not have the Dispatcher started
+var ErrPullWorkersDisabled = errors.New("pull workers are not enabled on the server")
+
+// maxClaimWorkWait is the maximum time a ClaimWork waits for work,
+// so the long-poll requests do not hold the connections forever
+const maxClaimWorkWait = time.Minute
+
+func (q *PikoCI) RegisterWorker(ctx context.Context, w registry.Worker) (*registry.Worker, error) {
+	if w.Name == "" {
+		return nil, fmt.Errorf("worker Name is required")
+	} else if w.Concurrency < 1 {
+		return nil, fmt.Errorf("invalid worker Concurrency %d", w.Concurrency)
+	} else if err := validateWorkerTags(w.Tags); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	w.StartedAt = now
+	w.HeartbeatAt = now
+
+	id, err := q.Workers.Create(ctx, w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Create Worker: %w", err)
+	}
+	w.ID = id
+
+	return &w, nil
+}
+
+func (q *PikoCI) HeartbeatWorker(ctx context.Context, id uint32) error {
+	err := q.Workers.Heartbeat(ctx, id, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, registry.ErrNotFound) {
+			return ErrWorkerNotRegistered
+		}
+		return fmt.Errorf("failed to Heartbeat Worker: %w", err)
+	}
+
+	return nil
+}
+
+func (q *PikoCI) ListWorkers(ctx context.Context) ([]*registry.Worker, error) {
+	ws, err := q.Workers.Filter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Filter Workers: %w", err)
+	}
+
+	return ws, nil
+}
+
+// validateWorkerTags checks the number and format of the worker tags
+func validateWorkerTags(tags []string) error {
+	if len(tags) > queue.MaxWorkerTags {
+		return fmt.Errorf("a worker can not have more than %d tags", queue.MaxWorkerTags)
+	}
+	for _, t := range tags {
+		if !utils.ValidateCanonical(t) {
+			return fmt.Errorf("invalid worker tag format %q", t)
+		}
+	}
+
+	return nil
+}
+
+// StartWorkDispatcher starts handing out the work of the queue to the
+// pull workers with d, and the background loop that every half of the
+// lease TTL sends again to the queue the work of the expired leases
+func (q *PikoCI) StartWorkDispatcher(ctx context.Context, d *queue.Dispatcher) {
+	q.dispatcher = d
+
+	go func() {
+		ticker := time.NewTicker(d.TTL() / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ls, err := d.Expire(ctx, time.Now().UTC())
+				for _, l := range ls {
+					q.logger.Warn("work lease expired, sending the work again to the queue", "lease", l.ID, "worker_id", l.WorkerID)
+				}
+				if err != nil {
+					q.logger.Error("failed to expire work leases", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (q *PikoCI) ClaimWork(ctx context.Context, workerID uint32, tags []string, wait time.Duration) (*queue.Lease, error) {
+	if q.dispatcher == nil {
+		return nil, ErrPullWorkersDisabled
+	} else if err := validateWorkerTags(tags); err != nil {
+		return nil, err
+	} else if wait < 0 {
+		return nil, fmt.Errorf("invalid wait %s", wait)
+	}
+	wait = min(wait, maxClaimWorkWait)
+
+	l, err := q.dispatcher.Claim(ctx, workerID, tags, wait)
+	if err != nil {
+		return nil, fmt.Errorf("failed to Claim work: %w", err)
+	}
+
+	return l, nil
+}
+
+func (q *PikoCI) RenewWorkLease(ctx context.Context, workerID uint32, leaseID string) (*queue.Lease, error) {
+	if q.dispatcher == nil {
+		return nil, ErrPullWorkersDisabled
+	}
+
+	l, err := q.dispatcher.Renew(ctx, workerID, leaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (q *PikoCI) CompleteWork(ctx context.Context, workerID uint32, leaseID string) error {
+	if q.dispatcher == nil {
+		return ErrPullWorkersDisabled
+	}
+
+	return q.dispatcher.Complete(ctx, workerID, leaseID)
+}
+
+func (q *PikoCI) PublishWork(ctx context.Context, body []byte, metadata map[string]string) error {
+	var b queue.Body
+	if err := json.Unmarshal(body, &b); err != nil {
+		return fmt.Errorf("invalid work body: %w", err)
+	}
+
+	err := q.Topic.Send(ctx, &pubsub.Message{Body: body, Metadata: metadata})
+	if err != nil {
+		return fmt.Errorf("failed to Send work: %w", err)
+	}
+
+	return nil
+}
+
+// setJobWaiting sets the Job j as Waiting if none
+// of the registered workers has all its Tags
+func (q *PikoCI) setJobWaiting(ctx context.Context, j *job.Job) error {
+	if len(j.Tags) == 0 {
+		return nil
+	}
+
+	ws, err := q.Workers.Filter(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to Filter Workers: %w", err)
+	}
+	j.Waiting = !slices.ContainsFunc(ws, func(w *registry.Worker) bool {
+		return w.HasTags(j.Tags)
+	})
+
+	return nil
+}
+
+// StartReaper starts the background reaper that every ttl recovers the
+// builds of the workers that have not sent a heartbeat in the last ttl.
+// If requeue is true the recovered builds are also retried.
+func (q *PikoCI) StartReaper(ctx context.Context, ttl time.Duration, requeue bool) {
+	go func() {
+		ticker := time.NewTicker(ttl)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := q.ReapWorkers(ctx, ttl, requeue); err != nil {
+					q.logger.Error("failed to reap workers", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// ReapWorkers fails the builds still started by the expired workers,
+// retrying them if requeue is true, and removes the workers
+func (q *PikoCI) ReapWorkers(ctx context.Context, ttl time.Duration, requeue bool) error {
+	ws, err := q.Workers.FilterExpired(ctx, time.Now().UTC().Add(-ttl))
+	if err != nil {
+		return fmt.Errorf("failed to Filter Expired Workers: %w", err)
+	}
+
+	for _, w := range ws {
+		bs, err := q.Builds.FilterStartedByWorker(ctx, w.ID)
+		if err != nil {
+			return fmt.Errorf("failed to Filter Started Builds of Worker %q: %w", w.Name, err)
+		}
+
+		for _, bwj := range bs {
+			b := bwj.Build
+			b.Status = build.Failed
+			b.Error = fmt.Sprintf("worker %q stopped sending heartbeats", w.Name)
+			b.Duration = time.Since(b.StartedAt)
+
+			// The logs of the running Steps are only on the
+			// log chunks, which are deleted once it's failed
+			cs, err := q.Builds.FilterLogChunks(ctx, bwj.TeamCanonical, bwj.PipelineName, bwj.JobName, b.BuildNumber, 0)
+			if err != nil {
+				return fmt.Errorf("failed to Filter Log Chunks of Build %q of Job %q: %w", b.BuildNumber, bwj.JobName, err)
+			}
+			for i, s := range b.Steps {
+				if s.Status == build.Started {
+					b.Steps[i].Status = build.Failed
+					b.Steps[i].Logs = chunksLogs(s.ID, cs)
+				}
+			}
+
+			err = q.Builds.Update(ctx, bwj.TeamCanonical, bwj.PipelineName, bwj.JobName, b.BuildNumber, b)
+			if err != nil {
+				return fmt.Errorf("failed to Update Build %q of Job %q: %w", b.BuildNumber, bwj.JobName, err)
+			}
+
+			err = q.Builds.DeleteLogChunks(ctx, bwj.TeamCanonical, bwj.PipelineName, bwj.JobName, b.BuildNumber, nil)
+			if err != nil {
+				return fmt.Errorf("failed to Delete Log Chunks of Build %q of Job %q: %w", b.BuildNumber, bwj.JobName, err)
+			}
+
+			if err = q.releaseBuildLocks(ctx, b.ID); err != nil {
+				return err
+			}
+
+			if requeue {
+				err = q.RetryJobBuild(ctx, bwj.TeamCanonical, bwj.PipelineName, bwj.JobName, b.BuildNumber)
+				if err != nil {
+					return fmt.Errorf("failed to Retry Build %q of Job %q: %w", b.BuildNumber, bwj.JobName, err)
+				}
+			}
+		}
+
+		err = q.Workers.Delete(ctx, w.ID)
+		if err != nil {
+			return fmt.Errorf("failed to Delete Worker %q: %w", w.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// chunksLogs returns the logs of the Step stepID from the log chunks cs,
+// in order, skipping the parts of the chunks that were already appended
+func chunksLogs(stepID string, cs []*build.LogChunk) string {
+	var logs string
+	for _, c := range cs {
+		if stepID == "" || c.StepID != stepID {
+			continue
+		}
+		if end := c.Offset + len(c.Data); c.Offset <= len(logs) && end > len(logs) {
+			logs += c.Data[len(logs)-c.Offset:]
+		}
+	}
+	return logs
+}
@@ -0,0 +1,266 @@
+package pikoci_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/mock"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/xescugc/pikoci/pikoci/registry"
+	"go.uber.org/mock/gomock"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/mempubsub"
+)
+
+func TestRegisterWorker(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		w := registry.Worker{Name: "w1", Version: "v1.0.0", Platform: "linux/amd64", Concurrency: 2}
+		s.Workers.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, cw registry.Worker) (uint32, error) {
+			assert.Equal(t, w.Name, cw.Name)
+			assert.Equal(t, w.Concurrency, cw.Concurrency)
+			assert.False(t, cw.StartedAt.IsZero())
+			assert.Equal(t, cw.StartedAt, cw.HeartbeatAt)
+			return 3, nil
+		})
+
+		rw, err := s.S.RegisterWorker(ctx, w)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3), rw.ID)
+		assert.Equal(t, w.Name, rw.Name)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		_, err := s.S.RegisterWorker(ctx, registry.Worker{Concurrency: 1})
+		require.Error(t, err)
+
+		_, err = s.S.RegisterWorker(ctx, registry.Worker{Name: "w1"})
+		require.Error(t, err)
+
+		_, err = s.S.RegisterWorker(ctx, registry.Worker{Name: "w1", Concurrency: 1, Tags: []string{"Docker"}})
+		require.Error(t, err)
+
+		_, err = s.S.RegisterWorker(ctx, registry.Worker{Name: "w1", Concurrency: 1, Tags: []string{"a", "b", "c", "d", "e", "f", "g"}})
+		require.Error(t, err)
+	})
+}
+
+func TestHeartbeatWorker(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Workers.EXPECT().Heartbeat(ctx, uint32(3), gomock.Any()).Return(nil)
+
+		err := s.S.HeartbeatWorker(ctx, 3)
+		require.NoError(t, err)
+	})
+	t.Run("NotRegistered", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Workers.EXPECT().Heartbeat(ctx, uint32(3), gomock.Any()).Return(registry.ErrNotFound)
+
+		err := s.S.HeartbeatWorker(ctx, 3)
+		assert.ErrorIs(t, err, pikoci.ErrWorkerNotRegistered)
+	})
+}
+
+func TestReapWorkers(t *testing.T) {
+	startedAt := time.Now().Add(-time.Hour)
+	dead := &registry.Worker{ID: 3, Name: "w1"}
+	started := func() []*build.WithJob {
+		return []*build.WithJob{
+			{
+				Build: build.Build{
+					ID:          10,
+					BuildNumber: "4",
+					Status:      build.Started,
+					StartedAt:   startedAt,
+					WorkerID:    dead.ID,
+					Steps: []build.Step{
+						{Name: "repo", Status: build.Succeeded},
+						{ID: "s2", Name: "test", Status: build.Started},
+					},
+				},
+				TeamCanonical: "main",
+				PipelineName:  "pp",
+				JobName:       "jn",
+			},
+		}
+	}
+	expectChunks := func(s MockService, ctx context.Context) {
+		s.Builds.EXPECT().FilterLogChunks(ctx, "main", "pp", "jn", "4", uint32(0)).Return([]*build.LogChunk{
+			{ID: 1, StepID: "s2", Offset: 0, Data: "hello "},
+			{ID: 2, StepID: "s3", Offset: 0, Data: "other"},
+			{ID: 3, StepID: "s2", Offset: 3, Data: "lo world"},
+		}, nil)
+	}
+	expectFailed := func(t *testing.T, s MockService, ctx context.Context) {
+		expectChunks(s, ctx)
+		s.Builds.EXPECT().Update(ctx, "main", "pp", "jn", "4", gomock.Any()).DoAndReturn(func(_ context.Context, _, _, _, _ string, b build.Build) error {
+			assert.Equal(t, build.Failed, b.Status)
+			assert.Equal(t, `worker "w1" stopped sending heartbeats`, b.Error)
+			assert.NotZero(t, b.Duration)
+			assert.Equal(t, build.Succeeded, b.Steps[0].Status)
+			assert.Equal(t, build.Failed, b.Steps[1].Status)
+			// The logs of the running Step are kept from the log chunks
+			assert.Equal(t, "hello world", b.Steps[1].Logs)
+			return nil
+		})
+		s.Builds.EXPECT().DeleteLogChunks(ctx, "main", "pp", "jn", "4", nil).Return(nil)
+		s.Locks.EXPECT().DeleteByBuild(ctx, uint32(10)).Return(nil)
+	}
+
+	t.Run("Fail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Workers.EXPECT().FilterExpired(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, before time.Time) ([]*registry.Worker, error) {
+			assert.WithinDuration(t, time.Now().Add(-time.Minute), before, time.Second)
+			return []*registry.Worker{dead}, nil
+		})
+		s.Builds.EXPECT().FilterStartedByWorker(ctx, dead.ID).Return(started(), nil)
+		expectFailed(t, s, ctx)
+		s.Workers.EXPECT().Delete(ctx, dead.ID).Return(nil)
+
+		err := s.P.ReapWorkers(ctx, time.Minute, false)
+		require.NoError(t, err)
+	})
+	t.Run("Requeue", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Workers.EXPECT().FilterExpired(ctx, gomock.Any()).Return([]*registry.Worker{dead}, nil)
+		s.Builds.EXPECT().FilterStartedByWorker(ctx, dead.ID).Return(started(), nil)
+		expectFailed(t, s, ctx)
+		s.Builds.EXPECT().Find(ctx, "main", "pp", "jn", "4").Return(&build.Build{ID: 10, BuildNumber: "4", Status: build.Failed}, nil)
+		s.Jobs.EXPECT().Find(ctx, "main", "pp", "jn").Return(&job.Job{Name: "jn"}, nil)
+
+		mb, _ := json.Marshal(queue.Body{
+			TeamCanonical:    "main",
+			PipelineName:     "pp",
+			JobName:          "jn",
+			RetryBuildNumber: "4",
+			RetryBuildID:     10,
+			BuildNumber:      "4.1",
+		})
+		s.Builds.EXPECT().CreateRetry(ctx, "main", "pp", "jn", "4", gomock.Any()).Return(uint32(11), "4.1", nil)
+		s.Topic.EXPECT().Send(ctx, &pubsub.Message{Body: mb}).Return(nil)
+		s.Workers.EXPECT().Delete(ctx, dead.ID).Return(nil)
+
+		err := s.P.ReapWorkers(ctx, time.Minute, true)
+		require.NoError(t, err)
+	})
+	t.Run("UpdateError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		s.Workers.EXPECT().FilterExpired(ctx, gomock.Any()).Return([]*registry.Worker{dead}, nil)
+		s.Builds.EXPECT().FilterStartedByWorker(ctx, dead.ID).Return(started(), nil)
+		expectChunks(s, ctx)
+		s.Builds.EXPECT().Update(ctx, "main", "pp", "jn", "4", gomock.Any()).Return(assert.AnError)
+
+		err := s.P.ReapWorkers(ctx, time.Minute, false)
+		require.Error(t, err)
+	})
+}
+
+func TestClaimWork(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		tp := mempubsub.NewTopic()
+		defer tp.Shutdown(ctx)
+		sub := mempubsub.NewSubscription(tp, time.Minute)
+		leases := mock.NewLeaseRepository(ctrl)
+		d := queue.NewDispatcher(s.Topic, func(_ context.Context, key string) (queue.Subscription, error) {
+			return sub, nil
+		}, leases, time.Minute)
+		defer d.Shutdown(ctx)
+		s.P.StartWorkDispatcher(ctx, d)
+
+		require.NoError(t, tp.Send(ctx, &pubsub.Message{Body: []byte(`{"job_name":"jn"}`)}))
+
+		var cl queue.Lease
+		leases.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, l queue.Lease) error {
+			cl = l
+			return nil
+		})
+		l, err := s.S.ClaimWork(ctx, 3, nil, time.Second)
+		require.NoError(t, err)
+		require.NotNil(t, l)
+		assert.Equal(t, uint32(3), l.WorkerID)
+		assert.Equal(t, cl, *l)
+
+		leases.EXPECT().UpdateExpiresAt(ctx, uint32(3), l.ID, gomock.Any()).Return(nil)
+		leases.EXPECT().Find(ctx, uint32(3), l.ID).Return(&cl, nil)
+		rl, err := s.S.RenewWorkLease(ctx, 3, l.ID)
+		require.NoError(t, err)
+		assert.Equal(t, l.ID, rl.ID)
+
+		leases.EXPECT().Delete(ctx, uint32(3), l.ID).Return(nil)
+		leases.EXPECT().Delete(ctx, uint32(3), l.ID).Return(queue.ErrLeaseNotFound)
+		require.NoError(t, s.S.CompleteWork(ctx, 3, l.ID))
+		assert.ErrorIs(t, s.S.CompleteWork(ctx, 3, l.ID), queue.ErrLeaseNotFound)
+
+		// Without work it returns no lease after the wait
+		l, err = s.S.ClaimWork(ctx, 3, nil, 100*time.Millisecond)
+		require.NoError(t, err)
+		assert.Nil(t, l)
+
+		_, err = s.S.ClaimWork(ctx, 3, []string{"Docker"}, time.Second)
+		require.Error(t, err)
+	})
+	t.Run("Disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		s := newService(ctrl)
+		ctx := context.TODO()
+
+		_, err := s.S.ClaimWork(ctx, 3, nil, time.Second)
+		assert.ErrorIs(t, err, pikoci.ErrPullWorkersDisabled)
+
+		_, err = s.S.RenewWorkLease(ctx, 3, "l1")
+		assert.ErrorIs(t, err, pikoci.ErrPullWorkersDisabled)
+
+		err = s.S.CompleteWork(ctx, 3, "l1")
+		assert.ErrorIs(t, err, pikoci.ErrPullWorkersDisabled)
+	})
+}
+
+func TestPublishWork(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := newService(ctrl)
+	ctx := context.TODO()
+
+	body := []byte(`{"job_name":"jn"}`)
+	md := map[string]string{queue.DelayMetadataKey: "2s"}
+	s.Topic.EXPECT().Send(ctx, &pubsub.Message{Body: body, Metadata: md}).Return(nil)
+
+	err := s.S.PublishWork(ctx, body, md)
+	require.NoError(t, err)
+
+	err = s.S.PublishWork(ctx, []byte("not json"), nil)
+	require.Error(t, err)
+}
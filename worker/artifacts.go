@@ -0,0 +1,307 @@
+package worker
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/queue"
+)
+
+// uploadArtifact archives the declared outputs of the task and uploads
+// them so downstream jobs can restore them with inputs_from. The archive
+// is streamed to the upload while it's being created.
+func (w *Worker) uploadArtifact(ctx context.Context, m queue.Body, b *build.Build, cwd string, t job.TaskStep) error {
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		err := archiveOutputs(cwd, t.Outputs, pw)
+		pw.CloseWithError(err)
+		errc <- err
+	}()
+
+	err := w.pikoci.UploadBuildArtifact(ctx, m.TeamCanonical, m.PipelineName, m.JobName, b.BuildNumber, t.Name, pr)
+	// Unblock the archive if the upload stopped reading
+	pr.Close()
+	if aerr := <-errc; aerr != nil && !errors.Is(aerr, io.ErrClosedPipe) {
+		return fmt.Errorf("failed to archive outputs: %w", aerr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload outputs: %w", err)
+	}
+	return nil
+}
+
+// restoreArtifacts restores on the workdir the outputs of all the tasks
+// referenced by the task inputs_from, each one as an "artifact" build step.
+// Returns true if any of them failed.
+func (w *Worker) restoreArtifacts(ctx context.Context, m queue.Body, b *build.Build, cwd string, j *job.Job, t job.TaskStep, resolvedVersions map[string]uint32) bool {
+	for _, ref := range t.InputsFrom {
+		stepIdx := len(b.Steps)
+		b.Steps = append(b.Steps, build.Step{Type: "artifact", Name: ref, Status: build.Started})
+		w.updateBuild(ctx, m, *b)
+
+		start := time.Now()
+		out, err := w.restoreArtifact(ctx, m, cwd, j, ref, resolvedVersions)
+		if err != nil {
+			b.Steps[stepIdx] = build.Step{Type: "artifact", Name: ref, Logs: err.Error(), Duration: time.Since(start), Status: build.Failed}
+			b.Status = build.Failed
+			w.failBuild(ctx, m, *b, nil)
+			w.logger.Error("failed to restore artifact", "inputs_from", ref, "error", err)
+			return true
+		}
+
+		b.Steps[stepIdx] = build.Step{Type: "artifact", Name: ref, Logs: out, Duration: time.Since(start), Status: build.Succeeded}
+		if err := w.updateBuild(ctx, m, *b); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Worker) restoreArtifact(ctx context.Context, m queue.Body, cwd string, j *job.Job, ref string, resolvedVersions map[string]uint32) (string, error) {
+	jn, tn, ok := job.ParseInputsFrom(ref)
+	if !ok {
+		return "", fmt.Errorf("invalid inputs_from %q", ref)
+	}
+
+	bn, err := w.findArtifactBuild(ctx, m, j, jn, resolvedVersions)
+	if err != nil {
+		return "", err
+	}
+
+	// The artifact is extracted while it's being downloaded
+	pr, pw := io.Pipe()
+	errc := make(chan error, 1)
+	go func() {
+		err := w.pikoci.DownloadBuildArtifact(ctx, m.TeamCanonical, m.PipelineName, jn, bn, tn, pw)
+		pw.CloseWithError(err)
+		errc <- err
+	}()
+
+	files, err := extractArtifact(cwd, pr)
+	// Unblock the download if the extraction stopped reading
+	pr.Close()
+	if derr := <-errc; derr != nil && !errors.Is(derr, io.ErrClosedPipe) {
+		return "", fmt.Errorf("failed to download outputs of %q from build #%s: %w", ref, bn, derr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract outputs of %q from build #%s: %w", ref, bn, err)
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "restored outputs of %q from build #%s\n", ref, bn)
+	for _, f := range files {
+		fmt.Fprintln(&out, f)
+	}
+	return out.String(), nil
+}
+
+// findArtifactBuild returns the build number of the newest successful build
+// of the job jn. If any get step of j has jn on its passed list, the build
+// must also have used the version resolved for that step, so the restored
+// artifact is the one produced for the version being promoted.
+func (w *Worker) findArtifactBuild(ctx context.Context, m queue.Body, j *job.Job, jn string, resolvedVersions map[string]uint32) (string, error) {
+	constraints := make(map[string]uint32)
+	for _, g := range j.GetSteps() {
+		vid, ok := resolvedVersions[g.ResourceCanonical()]
+		if !ok || vid == 0 {
+			continue
+		}
+		for _, p := range g.Passed {
+			if p == jn {
+				constraints[g.Name] = vid
+			}
+		}
+	}
+
+	// ListJobBuilds returns the newest builds first
+	builds, err := w.pikoci.ListJobBuilds(ctx, m.TeamCanonical, m.PipelineName, jn)
+	if err != nil {
+		return "", fmt.Errorf("failed to list builds for job %q: %w", jn, err)
+	}
+
+	for _, bu := range builds {
+		if bu.Status != build.Succeeded {
+			continue
+		}
+		matches := 0
+		for _, step := range bu.Steps {
+			if vid, ok := constraints[step.Name]; ok && step.Type == "get" && step.VersionID == vid {
+				matches++
+			}
+		}
+		if matches == len(constraints) {
+			return bu.BuildNumber, nil
+		}
+	}
+
+	if len(constraints) > 0 {
+		return "", fmt.Errorf("no successful build of job %q found with the passed versions", jn)
+	}
+	return "", fmt.Errorf("no successful build of job %q found", jn)
+}
+
+// archiveOutputs writes to w a gzipped tarball with the outputs, which
+// are paths relative to cwd (files or directories). The outputs that
+// are not inside of cwd are rejected, the archive is made by the worker
+// so they would leak the files of its host.
+func archiveOutputs(cwd string, outputs []string, w io.Writer) error {
+	for _, o := range outputs {
+		if !filepath.IsLocal(filepath.Clean(o)) {
+			return fmt.Errorf("invalid output %q: must be relative to the workdir", o)
+		}
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, o := range outputs {
+		root := filepath.Join(cwd, o)
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			var link string
+			if info.Mode()&os.ModeSymlink != 0 {
+				link, err = os.Readlink(p)
+				if err != nil {
+					return err
+				}
+			}
+
+			hdr, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(cwd, p)
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(rel)
+
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive output %q: %w", o, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// extractArtifact extracts the gzipped tarball read from r inside cwd and
+// returns the list of extracted paths. All the entries are written
+// through an os.Root of cwd, so entries that would end up outside of
+// it, even by following previously extracted symlinks, are rejected.
+func extractArtifact(cwd string, r io.Reader) ([]string, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	root, err := os.OpenRoot(cwd)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	var (
+		files []string
+		links []string
+	)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("invalid path %q in artifact", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := root.MkdirAll(name, hdr.FileInfo().Mode().Perm()|0o700); err != nil {
+				return nil, fmt.Errorf("invalid path %q in artifact: %w", hdr.Name, err)
+			}
+		case tar.TypeReg:
+			if err := root.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				return nil, fmt.Errorf("invalid path %q in artifact: %w", hdr.Name, err)
+			}
+			f, err := root.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, hdr.FileInfo().Mode().Perm())
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q in artifact: %w", hdr.Name, err)
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return nil, err
+			}
+			if err := f.Close(); err != nil {
+				return nil, err
+			}
+			files = append(files, hdr.Name)
+		case tar.TypeSymlink:
+			target := filepath.FromSlash(hdr.Linkname)
+			if filepath.IsAbs(target) || !filepath.IsLocal(filepath.Join(filepath.Dir(name), target)) {
+				return nil, fmt.Errorf("invalid symlink %q -> %q in artifact", hdr.Name, hdr.Linkname)
+			}
+			if err := root.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				return nil, fmt.Errorf("invalid path %q in artifact: %w", hdr.Name, err)
+			}
+			if err := root.Symlink(hdr.Linkname, name); err != nil {
+				return nil, fmt.Errorf("invalid path %q in artifact: %w", hdr.Name, err)
+			}
+			files = append(files, hdr.Name)
+			links = append(links, name)
+		}
+	}
+
+	// The lexical check of the symlinks does not see through the
+	// parents that are symlinks themselves, so once everything is in
+	// place each one is resolved to make sure it stays inside cwd.
+	// Dangling symlinks are fine as long as they don't escape.
+	for _, l := range links {
+		if _, err := root.Stat(l); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("invalid symlink %q in artifact: %w", filepath.ToSlash(l), err)
+		}
+	}
+	return files, nil
+}
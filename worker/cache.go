@@ -0,0 +1,321 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/queue"
+)
+
+// CacheStore keeps the job and task caches on the worker host.
+// Each cache is stored as a single archive and, once the total size
+// goes over MaxSize, the least recently used ones are evicted.
+// It's safe to share it between the workers of the same instance.
+type CacheStore struct {
+	Dir     string
+	MaxSize int64
+
+	mu sync.Mutex
+}
+
+// NewCacheStore returns a CacheStore that keeps the caches inside dir
+// using at most maxSize bytes, if maxSize is 0 there is no limit
+func NewCacheStore(dir string, maxSize int64) *CacheStore {
+	return &CacheStore{
+		Dir:     dir,
+		MaxSize: maxSize,
+	}
+}
+
+// path returns the archive path of the cache, the matrix cell name
+// is empty outside of a matrix and the task name tn is empty for the
+// job level cache
+func (cs *CacheStore) path(m queue.Body, cell, tn string, c job.Cache) string {
+	h := sha256.Sum256([]byte(strings.Join([]string{m.TeamCanonical, m.PipelineName, m.JobName, cell, tn, c.Key}, "\x00")))
+	return filepath.Join(cs.Dir, hex.EncodeToString(h[:])+".tar.gz")
+}
+
+// Restore extracts the cache inside cwd and returns the list of restored
+// files. It returns fs.ErrNotExist if there is no cache stored yet.
+// Each cell of a matrix has its own caches.
+func (cs *CacheStore) Restore(m queue.Body, cell, tn string, c job.Cache, cwd string) ([]string, error) {
+	p := cs.path(m, cell, tn, c)
+
+	// Once opened the file can be read without the lock, as the
+	// caches are replaced by renaming and evicted by removing them
+	cs.mu.Lock()
+	f, err := os.Open(p)
+	if err == nil {
+		// Mark the cache as recently used for the LRU eviction
+		now := time.Now()
+		os.Chtimes(p, now, now)
+	}
+	cs.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return extractArtifact(cwd, f)
+}
+
+// Save archives the paths of the cache from cwd and stores them,
+// evicting the least recently used caches if MaxSize is exceeded.
+// It returns the size of the stored cache.
+func (cs *CacheStore) Save(m queue.Body, cell, tn string, c job.Cache, cwd string) (int64, error) {
+	var paths []string
+	for _, p := range c.Paths {
+		if _, err := os.Lstat(filepath.Join(cwd, p)); err == nil {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return 0, fmt.Errorf("none of the cache paths exist")
+	}
+
+	if err := os.MkdirAll(cs.Dir, 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// The archive is written to a temporary file first so a concurrent
+	// Restore never reads a partially written cache
+	f, err := os.CreateTemp(cs.Dir, ".save-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	lw := &limitedWriter{w: f, max: cs.MaxSize}
+	if err := archiveOutputs(cwd, paths, lw); err != nil {
+		f.Close()
+		if errors.Is(err, errCacheTooBig) {
+			return 0, fmt.Errorf("cache size exceeds the maximum of %d bytes", cs.MaxSize)
+		}
+		return 0, fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close cache file: %w", err)
+	}
+
+	p := cs.path(m, cell, tn, c)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if err := os.Rename(f.Name(), p); err != nil {
+		return 0, fmt.Errorf("failed to store cache: %w", err)
+	}
+
+	if err := cs.evict(); err != nil {
+		return 0, fmt.Errorf("failed to evict caches: %w", err)
+	}
+
+	return lw.n, nil
+}
+
+var errCacheTooBig = errors.New("cache too big")
+
+// limitedWriter counts the bytes written to w and fails
+// with errCacheTooBig once they go over max, if max is not 0
+type limitedWriter struct {
+	w   io.Writer
+	max int64
+	n   int64
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if lw.max > 0 && lw.n+int64(len(p)) > lw.max {
+		return 0, errCacheTooBig
+	}
+	n, err := lw.w.Write(p)
+	lw.n += int64(n)
+	return n, err
+}
+
+// cacheKey returns the key of the cache c for the workdir cwd. If the
+// cache has KeyFiles, the path and content of each file matching them
+// is hashed into the key, so it changes when any of those files do.
+func cacheKey(cwd string, c job.Cache) (string, error) {
+	if len(c.KeyFiles) == 0 {
+		return c.Key, nil
+	}
+
+	var files []string
+	for _, kf := range c.KeyFiles {
+		matches, err := filepath.Glob(filepath.Join(cwd, kf))
+		if err != nil {
+			return "", fmt.Errorf("invalid key file %q: %w", kf, err)
+		}
+		for _, mf := range matches {
+			if fi, err := os.Stat(mf); err == nil && fi.Mode().IsRegular() {
+				files = append(files, mf)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files found matching the key files %s", strings.Join(c.KeyFiles, ", "))
+	}
+	sort.Strings(files)
+	files = slices.Compact(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		rel, err := filepath.Rel(cwd, f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+		if err := hashFile(h, f); err != nil {
+			return "", fmt.Errorf("failed to hash key file %q: %w", rel, err)
+		}
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))[:16]
+	if c.Key == "" {
+		return sum, nil
+	}
+	return c.Key + "-" + sum, nil
+}
+
+func hashFile(w io.Writer, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// evict removes the least recently used caches until the total
+// size is under MaxSize. It must be called with mu locked.
+func (cs *CacheStore) evict() error {
+	if cs.MaxSize <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(cs.Dir)
+	if err != nil {
+		return err
+	}
+
+	var (
+		total int64
+		infos []fs.FileInfo
+	)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".tar.gz") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		total += info.Size()
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+
+	for _, info := range infos {
+		if total <= cs.MaxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(cs.Dir, info.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= info.Size()
+	}
+	return nil
+}
+
+// restoreCache restores the cache c (from a job, or from the task tn)
+// as a "cache-restore" build step. Caches are best effort, so a failure
+// is reported on the step but it never fails the build. It returns the
+// cache with the key used, so it's saved with the same one even if the
+// key files change while running, or nil if it should not be saved.
+func (w *Worker) restoreCache(ctx context.Context, m queue.Body, b *build.Build, cwd, name, tn string, c *job.Cache) *job.Cache {
+	if c == nil || w.cache == nil {
+		return nil
+	}
+
+	start := time.Now()
+	status := build.Succeeded
+
+	var (
+		out string
+		rc  *job.Cache
+	)
+	key, err := cacheKey(cwd, *c)
+	if err != nil {
+		out = fmt.Sprintf("failed to compute cache key: %s", err)
+		status = build.Failed
+		w.logger.Error("failed to compute cache key", "job", m.JobName, "task", tn, "error", err)
+	} else {
+		rc = &job.Cache{Paths: c.Paths, Key: key}
+		files, err := w.cache.Restore(m, matrixCellName(ctx), tn, *rc, cwd)
+		if errors.Is(err, fs.ErrNotExist) {
+			out = "no cache found"
+		} else if err != nil {
+			out = fmt.Sprintf("failed to restore cache: %s", err)
+			status = build.Failed
+			w.logger.Error("failed to restore cache", "job", m.JobName, "task", tn, "error", err)
+		} else {
+			out = fmt.Sprintf("restored %d files from cache", len(files))
+		}
+		if key != "" {
+			out = fmt.Sprintf("key %q: %s", key, out)
+		}
+	}
+
+	b.Steps = append(b.Steps, build.Step{Type: "cache-restore", Name: name, Logs: out, Duration: time.Since(start), Status: status})
+	w.updateBuild(ctx, m, *b)
+	return rc
+}
+
+// saveCache stores the cache c returned by restoreCache (from a job,
+// or from the task tn) as a "cache-save" build step. Like restoreCache
+// it never fails the build.
+func (w *Worker) saveCache(ctx context.Context, m queue.Body, b *build.Build, cwd, name, tn string, c *job.Cache) {
+	if c == nil || w.cache == nil {
+		return
+	}
+
+	start := time.Now()
+	status := build.Succeeded
+
+	var out string
+	size, err := w.cache.Save(m, matrixCellName(ctx), tn, *c, cwd)
+	if err != nil {
+		out = fmt.Sprintf("failed to save cache: %s", err)
+		status = build.Failed
+		w.logger.Error("failed to save cache", "job", m.JobName, "task", tn, "error", err)
+	} else {
+		out = fmt.Sprintf("saved %s (%d bytes)", strings.Join(c.Paths, ", "), size)
+	}
+	if c.Key != "" {
+		out = fmt.Sprintf("key %q: %s", c.Key, out)
+	}
+
+	b.Steps = append(b.Steps, build.Step{Type: "cache-save", Name: name, Logs: out, Duration: time.Since(start), Status: status})
+	w.updateBuild(ctx, m, *b)
+}
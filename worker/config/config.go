@@ -4,9 +4,27 @@ type Config struct {
 	PikoCIURL   string `mapstructure:"pikoci-url"`
 	WorkerToken string `mapstructure:"worker-token"`
 
+	Name              string   `mapstructure:"name"`
+	Tags              []string `mapstructure:"tags"`
+	HeartbeatInterval string   `mapstructure:"heartbeat-interval"`
+
 	Concurrency  int    `mapstructure:"concurrency"`
 	DrainTimeout string `mapstructure:"drain-timeout"`
 	PubSubSystem string `mapstructure:"pubsub-system"`
 
+	// Pull claims the work from the server instead of the PubSub system
+	Pull bool `mapstructure:"pull"`
+
+	// DB used by the 'sql' PubSub system
+	DBSystem   string `mapstructure:"db-system"`
+	DBHost     string `mapstructure:"db-host"`
+	DBPort     int    `mapstructure:"db-port"`
+	DBUser     string `mapstructure:"db-user"`
+	DBPassword string `mapstructure:"db-password"`
+	DBName     string `mapstructure:"db-name"`
+
+	CacheDir     string `mapstructure:"cache-dir"`
+	CacheMaxSize int64  `mapstructure:"cache-max-size"`
+
 	LogLevel string `mapstructure:"log-level"`
 }
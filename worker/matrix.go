@@ -0,0 +1,178 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/pipeline"
+	"github.com/xescugc/pikoci/pikoci/queue"
+)
+
+// matrixCellKey is the context key of the matrixCell a plan runs on
+type matrixCellKey struct{}
+
+// matrixCell identifies one of the cells of the matrix of a job,
+// it's stored on the context the plan of the cell runs with so
+// updateBuild and failBuild go through the matrix instead of the
+// API directly.
+type matrixCell struct {
+	matrix *buildMatrix
+	cell   int
+}
+
+// matrixCellName returns the name of the matrix cell
+// the plan of ctx runs on, it's empty outside of a matrix
+func matrixCellName(ctx context.Context) string {
+	if mc, ok := ctx.Value(matrixCellKey{}).(matrixCell); ok {
+		return mc.matrix.parent.Cells[mc.cell].Name
+	}
+	return ""
+}
+
+// buildMatrix merges the builds of each cell of the matrix into the
+// job build. Each cell runs the regular plan functions on its own
+// build.Build, and a failed cell only fails the job build once all
+// of them have finished.
+type buildMatrix struct {
+	mu sync.Mutex
+
+	ctx    context.Context
+	parent *build.Build
+	base   []build.Step
+	cells  [][]build.Step
+}
+
+// update stores the steps of the cell, and its error if b has failed,
+// and updates the job build with the steps of all the cells
+func (bm *buildMatrix) update(w *Worker, m queue.Body, cell int, b build.Build) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	c := &bm.parent.Cells[cell]
+	steps := make([]build.Step, len(b.Steps))
+	copy(steps, b.Steps)
+	for i := range steps {
+		steps[i].Cell = c.Name
+	}
+	bm.cells[cell] = steps
+	if b.Status == build.Failed && c.Error == "" {
+		c.Error = b.Error
+	}
+
+	bm.parent.Steps = bm.steps()
+	return w.updateBuild(bm.ctx, m, *bm.parent)
+}
+
+// finish sets the final status of the cell and updates the job build
+func (bm *buildMatrix) finish(w *Worker, m queue.Body, cell int, s build.Status) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	bm.parent.Cells[cell].Status = s
+	bm.parent.Steps = bm.steps()
+	return w.updateBuild(bm.ctx, m, *bm.parent)
+}
+
+// steps returns the job build steps, the ones before the
+// matrix followed by the steps of each cell in order
+func (bm *buildMatrix) steps() []build.Step {
+	steps := make([]build.Step, 0, len(bm.base))
+	steps = append(steps, bm.base...)
+	for _, cs := range bm.cells {
+		steps = append(steps, cs...)
+	}
+	return steps
+}
+
+// runMatrix runs the plan of each cell of the matrix of the job one after
+// the other, each on its own directory of the workdir with its cache. All
+// the cells run even if one of them fails, as each one has its own status
+// on the build. Returns true if any of the cells failed.
+func (w *Worker) runMatrix(ctx context.Context, m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, j *job.Job, resolvedVersions map[string]uint32) (bool, map[string]string) {
+	b.Cells = make([]build.Cell, len(j.Matrix))
+	for i, c := range j.Matrix {
+		b.Cells[i] = build.Cell{Name: c.Name, Status: build.Pending}
+	}
+	bm := &buildMatrix{
+		ctx:    ctx,
+		parent: b,
+		base:   b.Steps,
+		cells:  make([][]build.Step, len(j.Matrix)),
+	}
+
+	var (
+		failed   bool
+		resolved map[string]string
+	)
+	for i, c := range j.Matrix {
+		// Once the build is canceled the cells
+		// not started yet are not run
+		if ctx.Err() != nil {
+			bm.finish(w, m, i, build.Cancelled)
+			failed = true
+			continue
+		}
+
+		ccwd := filepath.Join(cwd, "matrix", strconv.Itoa(i))
+		if err := os.MkdirAll(ccwd, 0755); err != nil {
+			b.Cells[i].Error = fmt.Sprintf("failed to create the workdir of the cell: %s", err)
+			bm.finish(w, m, i, build.Failed)
+			failed = true
+			continue
+		}
+		bm.finish(w, m, i, build.Started)
+
+		cb := &build.Build{
+			ID:          b.ID,
+			BuildNumber: b.BuildNumber,
+			Status:      build.Started,
+			Steps:       []build.Step{},
+			StartedAt:   b.StartedAt,
+		}
+		cj := *j
+		cj.Plan = c.Plan
+		cj.Cache = c.Cache
+		cellCtx := context.WithValue(ctx, matrixCellKey{}, matrixCell{matrix: bm, cell: i})
+
+		cf, cr := w.runPlan(cellCtx, m, cb, ccwd, pp, &cj, resolvedVersions)
+		if cr != nil {
+			resolved = cr
+		}
+
+		status := build.Succeeded
+		if ctx.Err() != nil {
+			status = build.Cancelled
+		} else if cf {
+			status = build.Failed
+		}
+		if status != build.Succeeded {
+			failed = true
+		}
+		bm.finish(w, m, i, status)
+	}
+
+	bm.mu.Lock()
+	b.Steps = bm.steps()
+	bm.mu.Unlock()
+
+	if failed {
+		b.Status = build.Failed
+		for _, c := range b.Cells {
+			if c.Status == build.Failed {
+				b.Error = fmt.Sprintf("matrix cell %q failed", c.Name)
+				if c.Error != "" {
+					b.Error = fmt.Sprintf("matrix cell %q failed: %s", c.Name, c.Error)
+				}
+				break
+			}
+		}
+		w.failBuild(ctx, m, *b, nil)
+	}
+	return failed, resolved
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/xescugc/pikoci/pikoci/build"
+)
+
+// maxStepOutputsSize is the maximum size of the $PIKOCI_OUTPUT file of a step
+const maxStepOutputsSize = 1 << 20
+
+// outputPlaceholder matches the placeholders the pipeline has on
+// the params that use steps.<name>.outputs.<key>
+var outputPlaceholder = regexp.MustCompile(`__pikoci_output:([^:]+):([A-Za-z0-9_]+?)__`)
+
+// outputEnvName matches the characters of the step names
+// that can't be used on the name of an env variable
+var outputEnvName = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// stepOutputsName returns the name of the $PIKOCI_OUTPUT file of the step
+// with stepID, the runners that mount the workdir on containers use it
+// as $PIKOCI_OUTPUT_NAME to point $PIKOCI_OUTPUT to the mounted path
+func stepOutputsName(stepID string) string {
+	return ".pikoci_output_" + stepID
+}
+
+// stepOutputsPath returns the path of the $PIKOCI_OUTPUT file of the step
+// with stepID, it's on the workdir so it can be mounted on containers
+func stepOutputsPath(cwd, stepID string) string {
+	return filepath.Join(cwd, stepOutputsName(stepID))
+}
+
+// readStepOutputs returns the KEY=VALUE outputs the step wrote on the
+// file on path, which is removed after
+func readStepOutputs(path string) (map[string]string, error) {
+	defer os.Remove(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open the outputs: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxStepOutputsSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the outputs: %w", err)
+	}
+	if len(data) > maxStepOutputsSize {
+		return nil, fmt.Errorf("the outputs are bigger than %d bytes", maxStepOutputsSize)
+	}
+
+	outputs := parseEnvFormat(string(data))
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+	return outputs, nil
+}
+
+// setStepOutputs sets the outputs of the steps already run on the build
+// to params, as output_<step>_<key> env variables, and replaces the
+// placeholders of steps.<name>.outputs.<key> on params and args. The
+// placeholders of outputs that don't exist are replaced with "".
+func setStepOutputs(ctx context.Context, b *build.Build, params map[string]string, args []string) {
+	outputs := make(map[string]map[string]string)
+	for _, s := range visibleSteps(ctx, b) {
+		if len(s.Outputs) != 0 {
+			outputs[s.Name] = s.Outputs
+		}
+	}
+
+	replace := func(v string) string {
+		return outputPlaceholder.ReplaceAllStringFunc(v, func(p string) string {
+			sm := outputPlaceholder.FindStringSubmatch(p)
+			return outputs[sm[1]][sm[2]]
+		})
+	}
+	for k, v := range params {
+		params[k] = replace(v)
+	}
+	for i, a := range args {
+		args[i] = replace(a)
+	}
+
+	for name, o := range outputs {
+		for k, v := range o {
+			params["output_"+outputEnvName.ReplaceAllString(name, "_")+"_"+k] = v
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/pipeline"
+	"github.com/xescugc/pikoci/pikoci/queue"
+)
+
+// parallelLaneKey is the context key of the parallelLane a step runs on
+type parallelLaneKey struct{}
+
+// parallelLane identifies one of the steps of an in_parallel group,
+// it's stored on the context the step runs with so updateBuild and
+// failBuild go through the group instead of the API directly.
+type parallelLane struct {
+	group *parallelGroup
+	lane  int
+}
+
+// parallelGroup merges the builds of each lane of an in_parallel step
+// into the job build. Each lane runs the regular step functions on its
+// own build.Build so they never write on the same Steps concurrently.
+type parallelGroup struct {
+	mu sync.Mutex
+
+	// ctx is the context of the job, used to update the build
+	// even if the lanes have been canceled by fail_fast
+	ctx    context.Context
+	name   string
+	parent *build.Build
+	base   []build.Step
+	lanes  [][]build.Step
+	errs   []error
+}
+
+// update stores the steps of the lane and updates the job build with
+// the steps of all the lanes, err is the error the lane failed with
+func (g *parallelGroup) update(w *Worker, m queue.Body, lane int, b build.Build, err error) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	steps := make([]build.Step, len(b.Steps))
+	copy(steps, b.Steps)
+	for i := range steps {
+		steps[i].Group = g.name
+		steps[i].Lane = lane
+	}
+	g.lanes[lane] = steps
+	if err != nil && g.errs[lane] == nil {
+		g.errs[lane] = err
+	}
+
+	g.parent.Steps = g.steps()
+	return w.updateBuild(g.ctx, m, *g.parent)
+}
+
+// steps returns the job build steps, the ones before the group
+// followed by the steps of each lane in order
+func (g *parallelGroup) steps() []build.Step {
+	steps := make([]build.Step, 0, len(g.base))
+	steps = append(steps, g.base...)
+	for _, ls := range g.lanes {
+		steps = append(steps, ls...)
+	}
+	return steps
+}
+
+// runParallelStep runs the steps of the in_parallel group ps concurrently,
+// at most Limit at the same time. Once all of them finish the hooks of the
+// group are run. Returns true if any of the steps failed.
+func (w *Worker) runParallelStep(ctx context.Context, m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, j *job.Job, ps job.PlanStep, idx int, resolvedVersions map[string]uint32, resolved map[string]string) bool {
+	p := ps.Parallel
+
+	g := &parallelGroup{
+		ctx:    ctx,
+		name:   fmt.Sprintf("in_parallel:%d", idx),
+		parent: b,
+		base:   b.Steps,
+		lanes:  make([][]build.Step, len(p.Steps)),
+		errs:   make([]error, len(p.Steps)),
+	}
+
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := p.Limit
+	if limit <= 0 || limit > len(p.Steps) {
+		limit = len(p.Steps)
+	}
+	sem := make(chan struct{}, limit)
+
+	failed := make([]bool, len(p.Steps))
+	var wg sync.WaitGroup
+	for i, cps := range p.Steps {
+		sem <- struct{}{}
+		// With fail_fast the steps not started yet are skipped
+		if groupCtx.Err() != nil {
+			<-sem
+			failed[i] = true
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, cps job.PlanStep) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			lb := &build.Build{
+				ID:          b.ID,
+				BuildNumber: b.BuildNumber,
+				Status:      build.Started,
+				Steps:       []build.Step{},
+				StartedAt:   b.StartedAt,
+			}
+			laneCtx := context.WithValue(groupCtx, parallelLaneKey{}, parallelLane{group: g, lane: i})
+			if skip, f := w.skipPlanStep(laneCtx, m, lb, cps, i, resolved); skip {
+				failed[i] = f
+				return
+			}
+			if w.runPlanStep(laneCtx, m, lb, cwd, pp, j, cps, resolvedVersions, resolved) {
+				failed[i] = true
+				if p.FailFast {
+					cancel()
+				}
+			}
+		}(i, cps)
+	}
+	wg.Wait()
+
+	g.mu.Lock()
+	b.Steps = g.steps()
+	var (
+		anyFailed bool
+		err       error
+	)
+	for i, f := range failed {
+		if !f {
+			continue
+		}
+		anyFailed = true
+		if err == nil {
+			err = g.errs[i]
+		}
+	}
+	g.mu.Unlock()
+
+	if anyFailed {
+		b.Status = build.Failed
+		if err != nil {
+			b.Error = err.Error()
+		}
+		w.failBuild(ctx, m, *b, nil)
+		w.runHooks(ctx, m, b, &b.Steps, cwd, pp, "in_parallel", ps.OnFailure, "on_failure", resolved)
+		w.runHooks(ctx, m, b, &b.Steps, cwd, pp, "in_parallel", ps.Ensure, "ensure", resolved)
+		return true
+	}
+
+	if err := w.updateBuild(ctx, m, *b); err != nil {
+		return true
+	}
+	w.runHooks(ctx, m, b, &b.Steps, cwd, pp, "in_parallel", ps.OnSuccess, "on_success", resolved)
+	w.runHooks(ctx, m, b, &b.Steps, cwd, pp, "in_parallel", ps.Ensure, "ensure", resolved)
+	return false
+}
@@ -0,0 +1,216 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"gocloud.dev/gcerrors"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/batcher"
+	"gocloud.dev/pubsub/driver"
+)
+
+const (
+	// claimWorkWait is the time each claim waits on the server for work
+	claimWorkWait = 30 * time.Second
+
+	// claimWorkRetryDelay is the time to wait before claiming work
+	// again after failing to reach the server
+	claimWorkRetryDelay = 5 * time.Second
+)
+
+type pullTopic struct {
+	pikoci pikoci.Service
+}
+
+// NewPullTopic returns a Topic that publishes the messages
+// through the PikoCI server instead of a PubSub system
+func NewPullTopic(s pikoci.Service) *pubsub.Topic {
+	return pubsub.NewTopic(&pullTopic{pikoci: s}, nil)
+}
+
+// SendBatch publishes the messages with PublishWork
+func (t *pullTopic) SendBatch(ctx context.Context, ms []*driver.Message) error {
+	for _, m := range ms {
+		if m.BeforeSend != nil {
+			if err := m.BeforeSend(func(any) bool { return false }); err != nil {
+				return err
+			}
+		}
+
+		if err := t.pikoci.PublishWork(ctx, m.Body, m.Metadata); err != nil {
+			return err
+		}
+
+		if m.AfterSend != nil {
+			if err := m.AfterSend(func(any) bool { return false }); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (*pullTopic) IsRetryable(error) bool             { return false }
+func (*pullTopic) As(i any) bool                      { return false }
+func (*pullTopic) ErrorAs(error, any) bool            { return false }
+func (*pullTopic) ErrorCode(error) gcerrors.ErrorCode { return gcerrors.Unknown }
+func (*pullTopic) Close() error                       { return nil }
+
+// renewal is the lease being renewed
+// until its work is completed
+type renewal struct {
+	workerID uint32
+	cancel   context.CancelFunc
+}
+
+type pullSubscription struct {
+	pikoci       pikoci.Service
+	registration *Registration
+	tags         []string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	renewals map[string]renewal
+
+	logger *slog.Logger
+}
+
+// NewPullSubscription returns a Subscription that claims the work a worker
+// with the tags can run from the PikoCI server instead of a PubSub system.
+// The leases of the claimed work are renewed until the messages are acked,
+// which completes them, so the messages have to be acked once processed.
+func NewPullSubscription(s pikoci.Service, r *Registration, tags []string, l *slog.Logger) *pubsub.Subscription {
+	ctx, cancel := context.WithCancel(context.Background())
+	ps := &pullSubscription{
+		pikoci:       s,
+		registration: r,
+		tags:         tags,
+		ctx:          ctx,
+		cancel:       cancel,
+		renewals:     make(map[string]renewal),
+		logger:       l,
+	}
+	// Work is claimed one at a time so the worker does not
+	// hold work the other workers could be running
+	return pubsub.NewSubscription(ps, &batcher.Options{MaxBatchSize: 1, MaxHandlers: 1}, nil)
+}
+
+// ReceiveBatch claims the next work, if the server can not be reached
+// it waits and returns no messages so the claim is tried again
+func (s *pullSubscription) ReceiveBatch(ctx context.Context, maxMessages int) ([]*driver.Message, error) {
+	l, err := s.pikoci.ClaimWork(ctx, s.registration.ID(), s.tags, claimWorkWait)
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, pikoci.ErrPullWorkersDisabled) {
+			return nil, err
+		}
+		s.logger.Error("failed to claim work", "error", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(claimWorkRetryDelay):
+		}
+		return nil, nil
+	} else if l == nil {
+		return nil, nil
+	}
+
+	s.renew(l)
+
+	return []*driver.Message{
+		{
+			LoggableID: l.ID,
+			Body:       l.Body,
+			AckID:      l.ID,
+			AsFunc: func(i any) bool {
+				p, ok := i.(**queue.Lease)
+				if !ok {
+					return false
+				}
+				*p = l
+				return true
+			},
+		},
+	}, nil
+}
+
+// renew renews the lease l every third of its TTL until it's acked
+func (s *pullSubscription) renew(l *queue.Lease) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.mu.Lock()
+	s.renewals[l.ID] = renewal{workerID: l.WorkerID, cancel: cancel}
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(l.TTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, err := s.pikoci.RenewWorkLease(ctx, l.WorkerID, l.ID)
+				if err == nil {
+					continue
+				} else if errors.Is(err, queue.ErrLeaseNotFound) {
+					s.logger.Warn("work lease expired, the work was sent again to the queue", "lease", l.ID)
+					return
+				} else if ctx.Err() == nil {
+					s.logger.Error("failed to renew work lease", "lease", l.ID, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// SendAcks stops renewing the acked leases and completes them
+func (s *pullSubscription) SendAcks(ctx context.Context, ackIDs []driver.AckID) error {
+	for _, aid := range ackIDs {
+		id := aid.(string)
+
+		s.mu.Lock()
+		r, ok := s.renewals[id]
+		delete(s.renewals, id)
+		s.mu.Unlock()
+		if !ok {
+			continue
+		}
+		r.cancel()
+
+		err := s.pikoci.CompleteWork(ctx, r.workerID, id)
+		if errors.Is(err, queue.ErrLeaseNotFound) {
+			s.logger.Warn("work lease expired before it was completed", "lease", id)
+		} else if err != nil {
+			s.logger.Error("failed to complete work", "lease", id, "error", err)
+		}
+	}
+	return nil
+}
+
+func (*pullSubscription) CanNack() bool { return false }
+func (*pullSubscription) SendNacks(ctx context.Context, ackIDs []driver.AckID) error {
+	return nil
+}
+
+func (*pullSubscription) IsRetryable(error) bool  { return false }
+func (*pullSubscription) As(i any) bool           { return false }
+func (*pullSubscription) ErrorAs(error, any) bool { return false }
+func (*pullSubscription) ErrorCode(err error) gcerrors.ErrorCode {
+	if errors.Is(err, context.Canceled) {
+		return gcerrors.Canceled
+	}
+	return gcerrors.Unknown
+}
+
+// Close stops renewing the leases, so the server sends
+// their work again to the queue once they expire
+func (s *pullSubscription) Close() error {
+	s.cancel()
+	return nil
+}
@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// redactedValue is what the secrets are replaced with on the logs
+	redactedValue = "***"
+	// minRedactedLen is the minimum length of the values redacted, as
+	// shorter ones would mask any unrelated output that contains them
+	minRedactedLen = 4
+)
+
+// redactorKey is the context key of the redactor of the
+// secrets resolved for the job or the resource check
+type redactorKey struct{}
+
+// withRedactor returns a copy of ctx with a new empty redactor
+func withRedactor(ctx context.Context) context.Context {
+	return context.WithValue(ctx, redactorKey{}, &redactor{})
+}
+
+// redactorFrom returns the redactor of ctx, nil if it has none
+func redactorFrom(ctx context.Context) *redactor {
+	rd, _ := ctx.Value(redactorKey{}).(*redactor)
+	return rd
+}
+
+// redactor masks the secret values, and the usual encodings
+// of them, on the logs of the commands run with them.
+// A nil redactor doesn't redact anything.
+type redactor struct {
+	mu sync.Mutex
+
+	values map[string]struct{}
+	rp     *strings.Replacer
+}
+
+// add adds the values v to the ones redacted. Each line of a multi-line
+// value is redacted on its own, so no redacted value has a line break.
+func (r *redactor) add(vs ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.values == nil {
+		r.values = make(map[string]struct{})
+	}
+	added := false
+	for _, v := range vs {
+		for _, l := range strings.FieldsFunc(v, isLineBreak) {
+			if len(strings.TrimSpace(l)) < minRedactedLen {
+				continue
+			}
+			for _, dv := range []string{
+				l,
+				base64.StdEncoding.EncodeToString([]byte(l)),
+				base64.RawStdEncoding.EncodeToString([]byte(l)),
+				base64.URLEncoding.EncodeToString([]byte(l)),
+				base64.RawURLEncoding.EncodeToString([]byte(l)),
+				url.QueryEscape(l),
+				url.PathEscape(l),
+			} {
+				if _, ok := r.values[dv]; !ok {
+					r.values[dv] = struct{}{}
+					added = true
+				}
+			}
+		}
+	}
+	if !added {
+		return
+	}
+
+	// The longest values go first so a value that contains
+	// another one is fully redacted
+	values := make([]string, 0, len(r.values))
+	for v := range r.values {
+		values = append(values, v)
+	}
+	slices.SortFunc(values, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+	oldnew := make([]string, 0, len(values)*2)
+	for _, v := range values {
+		oldnew = append(oldnew, v, redactedValue)
+	}
+	r.rp = strings.NewReplacer(oldnew...)
+}
+
+// replacer returns the strings.Replacer with the values redacted
+// at the moment, nil if there are none
+func (r *redactor) replacer() *strings.Replacer {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rp
+}
+
+// redact returns s with the values redacted
+func (r *redactor) redact(s string) string {
+	if rp := r.replacer(); rp != nil {
+		return rp.Replace(s)
+	}
+	return s
+}
+
+// redactPartialLogs returns the onPartialLog that calls partialCb with the
+// logs redacted by rp. The logs are cut after the last line break, so a
+// value split between two of the partial logs is never sent, and as the
+// redacted values have no line breaks the lines already sent don't
+// change once the rest of the logs are redacted.
+func redactPartialLogs(rp *strings.Replacer, partialCb func(string)) func(string) {
+	if rp == nil || partialCb == nil {
+		return partialCb
+	}
+	return func(logs string) {
+		i := strings.LastIndexFunc(logs, isLineBreak)
+		if i == -1 {
+			return
+		}
+		partialCb(rp.Replace(logs[:i+1]))
+	}
+}
+
+func isLineBreak(r rune) bool {
+	return r == '\n' || r == '\r'
+}
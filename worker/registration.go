@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/registry"
+)
+
+// Registration keeps a worker instance registered on the PikoCI server
+// by sending heartbeats, so if the instance dies the builds it was
+// running are recovered by the server
+type Registration struct {
+	pikoci   pikoci.Service
+	worker   registry.Worker
+	interval time.Duration
+
+	id     atomic.Uint32
+	logger *slog.Logger
+}
+
+func NewRegistration(s pikoci.Service, rw registry.Worker, interval time.Duration, l *slog.Logger) *Registration {
+	return &Registration{
+		pikoci:   s,
+		worker:   rw,
+		interval: interval,
+		logger:   l,
+	}
+}
+
+// ID returns the ID the worker instance is registered with
+func (r *Registration) ID() uint32 {
+	return r.id.Load()
+}
+
+// Start registers the worker instance and sends a heartbeat
+// every interval until the ctx is done
+func (r *Registration) Start(ctx context.Context) error {
+	if err := r.register(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.heartbeat(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (r *Registration) register(ctx context.Context) error {
+	rw, err := r.pikoci.RegisterWorker(ctx, r.worker)
+	if err != nil {
+		return fmt.Errorf("failed to RegisterWorker: %w", err)
+	}
+	r.id.Store(rw.ID)
+	r.logger.Info("worker registered", "name", rw.Name, "id", rw.ID)
+
+	return nil
+}
+
+func (r *Registration) heartbeat(ctx context.Context) {
+	err := r.pikoci.HeartbeatWorker(ctx, r.ID())
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, pikoci.ErrWorkerNotRegistered) {
+		r.logger.Error("failed to send heartbeat", "error", err)
+		return
+	}
+
+	// The server reaped the worker after missing its heartbeats, so the
+	// builds it was running were already recovered and it starts again
+	r.logger.Warn("worker no longer registered, registering again", "id", r.ID())
+	if err := r.register(ctx); err != nil {
+		r.logger.Error("failed to register worker", "error", err)
+	}
+}
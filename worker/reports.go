@@ -0,0 +1,201 @@
+package worker
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/queue"
+)
+
+const (
+	// maxReportSize is the maximum size of each report file
+	maxReportSize = 10 << 20
+	// maxTestMessageSize is the maximum size of the
+	// message stored for each failed or skipped test
+	maxTestMessageSize = 4 << 10
+)
+
+// junitSuite is both the <testsuites> and the <testsuite>
+// elements, as the reports can have any of them as root
+// and the suites can be nested
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Classname string       `xml:"classname,attr"`
+	Name      string       `xml:"name,attr"`
+	Time      string       `xml:"time,attr"`
+	Failure   *junitResult `xml:"failure"`
+	Error     *junitResult `xml:"error"`
+	Skipped   *junitResult `xml:"skipped"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// message returns the message of the result followed by its
+// text, usually the stack trace, truncated to maxTestMessageSize
+func (r *junitResult) message() string {
+	msg := strings.TrimSpace(r.Message)
+	if txt := strings.TrimSpace(r.Text); txt != "" && txt != msg {
+		if msg != "" {
+			msg += "\n"
+		}
+		msg += txt
+	}
+	if len(msg) > maxTestMessageSize {
+		msg = strings.ToValidUTF8(msg[:maxTestMessageSize], "")
+	}
+	return msg
+}
+
+// parseJUnit returns the test cases of the JUnit XML report on r, the
+// cases without a suite use the name of the report file as the suite
+func parseJUnit(r io.Reader, step, file string) ([]build.TestCase, error) {
+	var root junitSuite
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+
+	var (
+		cs   []build.TestCase
+		walk func(s junitSuite, parent string)
+	)
+	walk = func(s junitSuite, parent string) {
+		name := s.Name
+		if name == "" {
+			name = parent
+		}
+		for _, jc := range s.Cases {
+			c := build.TestCase{
+				Step:      step,
+				Suite:     name,
+				Classname: jc.Classname,
+				Name:      jc.Name,
+				Status:    build.TestPassed,
+				Duration:  parseJUnitTime(jc.Time),
+			}
+			switch {
+			case jc.Error != nil:
+				c.Status = build.TestError
+				c.Message = jc.Error.message()
+			case jc.Failure != nil:
+				c.Status = build.TestFailed
+				c.Message = jc.Failure.message()
+			case jc.Skipped != nil:
+				c.Status = build.TestSkipped
+				c.Message = jc.Skipped.message()
+			}
+			cs = append(cs, c)
+		}
+		for _, ss := range s.Suites {
+			walk(ss, name)
+		}
+	}
+	walk(root, file)
+
+	return cs, nil
+}
+
+// parseJUnitTime converts the time, in seconds, of the
+// reports to a time.Duration, it's 0 if it's not valid
+func parseJUnitTime(t string) time.Duration {
+	s, err := strconv.ParseFloat(strings.ReplaceAll(t, ",", ""), 64)
+	if err != nil || s < 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+// uploadReports parses the report files of the task t that match its
+// patterns on the workdir and uploads its test cases. Returns the lines
+// to add to the logs of the step, with the summary of the tests or the
+// errors found, as the reports never fail the step.
+func (w *Worker) uploadReports(ctx context.Context, m queue.Body, b *build.Build, cwd string, t job.TaskStep) string {
+	if t.Reports == nil {
+		return ""
+	}
+
+	step := t.Name
+	if cn := matrixCellName(ctx); cn != "" {
+		step = fmt.Sprintf("%s (%s)", t.Name, cn)
+	}
+
+	var (
+		logs  []string
+		cs    []build.TestCase
+		files int
+	)
+	for _, p := range t.Reports.JUnit {
+		matches, _ := filepath.Glob(filepath.Join(cwd, p))
+		if len(matches) == 0 {
+			logs = append(logs, fmt.Sprintf("no junit reports found for %q", p))
+			continue
+		}
+		for _, f := range matches {
+			rel, _ := filepath.Rel(cwd, f)
+			fcs, err := readJUnit(f, step, rel)
+			if err != nil {
+				logs = append(logs, fmt.Sprintf("failed to parse the junit report %q: %s", rel, err))
+				continue
+			}
+			files++
+			cs = append(cs, fcs...)
+		}
+	}
+
+	if files != 0 {
+		err := w.pikoci.CreateJobBuildTests(ctx, m.TeamCanonical, m.PipelineName, m.JobName, b.BuildNumber, cs)
+		if err != nil {
+			logs = append(logs, fmt.Sprintf("failed to upload the test reports: %s", err))
+		} else {
+			var passed, failed, skipped int
+			for _, c := range cs {
+				switch c.Status {
+				case build.TestPassed:
+					passed++
+				case build.TestSkipped:
+					skipped++
+				default:
+					failed++
+				}
+			}
+			logs = append(logs, fmt.Sprintf("tests: %d passed, %d failed, %d skipped", passed, failed, skipped))
+		}
+	}
+
+	return strings.Join(logs, "\n")
+}
+
+// readJUnit parses the JUnit report on path
+func readJUnit(path, step, file string) ([]build.TestCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() > maxReportSize {
+		return nil, fmt.Errorf("the report is bigger than %d bytes", maxReportSize)
+	}
+
+	return parseJUnit(f, step, file)
+}
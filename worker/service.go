@@ -2,6 +2,7 @@ package worker
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -15,12 +16,15 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/adrg/xdg"
 	"github.com/google/uuid"
 	"github.com/xescugc/pikoci/pikoci"
 	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/builtin"
 	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/lock"
 	"github.com/xescugc/pikoci/pikoci/pipeline"
 	"github.com/xescugc/pikoci/pikoci/queue"
 	"github.com/xescugc/pikoci/pikoci/resource"
@@ -31,6 +35,10 @@ import (
 	"gocloud.dev/pubsub"
 )
 
+// requeueDelay is the time to wait before receiving again a job that
+// was re-queued for being at its concurrency limit or waiting for a lock
+const requeueDelay = 2 * time.Second
+
 type Service interface {
 	Run(ctx context.Context, q, t string) error
 }
@@ -39,16 +47,20 @@ type Worker struct {
 	topic        queue.Topic
 	pikoci       pikoci.Service
 	subscription queue.Subscription
+	cache        *CacheStore
+	registration *Registration
 
 	draining atomic.Bool
 	logger   *slog.Logger
 }
 
-func New(s pikoci.Service, t queue.Topic, ss queue.Subscription, l *slog.Logger) *Worker {
+func New(s pikoci.Service, t queue.Topic, ss queue.Subscription, cs *CacheStore, r *Registration, l *slog.Logger) *Worker {
 	return &Worker{
 		pikoci:       s,
 		topic:        t,
 		subscription: ss,
+		cache:        cs,
+		registration: r,
 		logger:       l,
 	}
 }
@@ -78,10 +90,15 @@ func (w *Worker) Run(ctx context.Context) error {
 			continue
 		}
 
-		// Ack immediately to prevent re-delivery while the job runs.
-		// Jobs can take minutes (e.g. docker builds), which exceeds
-		// the pubsub ack deadline and causes duplicate triggers.
-		msg.Ack()
+		// The work claimed from the server is renewed until
+		// the ack completes it, so it's acked once processed
+		var lease *queue.Lease
+		if !msg.As(&lease) {
+			// Ack immediately to prevent re-delivery while the job runs.
+			// Jobs can take minutes (e.g. docker builds), which exceeds
+			// the pubsub ack deadline and causes duplicate triggers.
+			msg.Ack()
+		}
 
 		cwd, err := w.createWorkDir()
 		if err != nil {
@@ -90,6 +107,10 @@ func (w *Worker) Run(ctx context.Context) error {
 
 		w.processMessage(ctx, m, cwd)
 		os.RemoveAll(cwd)
+
+		if lease != nil {
+			msg.Ack()
+		}
 	}
 }
 
@@ -114,6 +135,24 @@ func (w *Worker) processMessage(ctx context.Context, m queue.Body, cwd string) {
 		return
 	}
 
+	// Messages of paused pipelines and jobs are dropped with their
+	// pending builds and not queued again once unpaused. A paused
+	// pipeline doesn't check its resources so the versions found after
+	// unpausing it trigger the jobs, but the ones found while only the
+	// job was paused don't, it needs a newer version or a manual trigger
+	if pp.Paused {
+		w.logger.Info("pipeline is paused, dropping message",
+			"pipeline", m.PipelineName, "job", m.JobName, "resource", m.ResourceCanonical)
+		w.deletePendingBuild(ctx, m)
+		return
+	}
+	if m.JobName != "" && jobPaused(pp, m.JobName) {
+		w.logger.Info("job is paused, dropping message",
+			"pipeline", m.PipelineName, "job", m.JobName)
+		w.deletePendingBuild(ctx, m)
+		return
+	}
+
 	// Parse services from the pipeline's raw HCL since they are not stored
 	// in a separate DB table.
 	if len(pp.Raw) > 0 && len(pp.Services) == 0 {
@@ -146,11 +185,20 @@ func (w *Worker) processMessage(ctx context.Context, m queue.Body, cwd string) {
 // processJob handles executing a job: creates a build, runs the plan steps,
 // and runs hooks. Downstream job triggering is handled by the scheduler.
 func (w *Worker) processJob(ctx context.Context, m queue.Body, cwd string, pp *pipeline.Pipeline) {
+	// The secrets resolved for the job are redacted from the logs
+	// of all its steps, hooks included
+	ctx = withRedactor(ctx)
+
 	b := build.Build{
 		Status:    build.Started,
 		Steps:     []build.Step{},
 		StartedAt: time.Now().Round(0),
 	}
+	// The builds are owned by the registered worker instance
+	// so they can be recovered if it stops sending heartbeats
+	if w.registration != nil {
+		b.WorkerID = w.registration.ID()
+	}
 	w.logger.Info("processJob called",
 		"pipeline", m.PipelineName, "job", m.JobName, "version_id", m.VersionID,
 		"resource", m.ResourceCanonical)
@@ -159,21 +207,33 @@ func (w *Worker) processJob(ctx context.Context, m queue.Body, cwd string, pp *p
 		nb  *build.Build
 		err error
 	)
-	if m.RetryBuildNumber != "" {
+	// The messages sent before the Pending Builds
+	// existed have no Build to start yet
+	if m.BuildNumber != "" {
+		nb, err = w.pikoci.StartJobBuild(ctx, m.TeamCanonical, m.PipelineName, m.JobName, m.BuildNumber, b)
+	} else if m.RetryBuildNumber != "" {
 		nb, err = w.pikoci.CreateRetryJobBuild(ctx, m.TeamCanonical, m.PipelineName, m.JobName, m.RetryBuildNumber, b)
 	} else {
 		nb, err = w.pikoci.CreateJobBuild(ctx, m.TeamCanonical, m.PipelineName, m.JobName, b)
 	}
 	if err != nil {
-		if errors.Is(err, pikoci.ErrConcurrencyLimit) {
-			w.logger.Info("job at concurrency limit, re-queuing",
-				"pipeline", m.PipelineName, "job", m.JobName)
+		if errors.Is(err, pikoci.ErrConcurrencyLimit) || errors.Is(err, lock.ErrHeld) {
+			w.logger.Info("job at concurrency limit or waiting for a lock, re-queuing",
+				"pipeline", m.PipelineName, "job", m.JobName, "reason", err)
 			mb, _ := json.Marshal(m)
-			if err := w.topic.Send(ctx, &pubsub.Message{Body: mb}); err != nil {
-				w.logger.Error("failed to re-queue concurrency-limited build",
+			err := w.topic.Send(ctx, &pubsub.Message{
+				Body:     mb,
+				Metadata: map[string]string{queue.DelayMetadataKey: requeueDelay.String()},
+			})
+			if err != nil {
+				w.logger.Error("failed to re-queue limited build",
 					"pipeline", m.PipelineName, "job", m.JobName, "error", err)
 			}
-			time.Sleep(2 * time.Second)
+			time.Sleep(requeueDelay)
+			return
+		} else if errors.Is(err, pikoci.ErrBuildNotPending) {
+			w.logger.Info("build is no longer pending, dropping message",
+				"pipeline", m.PipelineName, "job", m.JobName, "build_number", m.BuildNumber)
 			return
 		}
 		w.logger.Error("failed create build", "pipeline", m.PipelineName, "job", m.JobName, "error", err)
@@ -206,12 +266,9 @@ func (w *Worker) processJob(ctx context.Context, m queue.Body, cwd string, pp *p
 		}
 		// Convert step_name keys to resource_canonical keys
 		resolvedVersions = make(map[string]uint32)
-		for _, ps := range j.Plan {
-			if ps.Type != job.StepTypeGet || ps.Get == nil {
-				continue
-			}
-			if vid, ok := stepVersions[ps.Get.Name]; ok {
-				resolvedVersions[ps.Get.ResourceCanonical()] = vid
+		for _, g := range j.GetSteps() {
+			if vid, ok := stepVersions[g.Name]; ok {
+				resolvedVersions[g.ResourceCanonical()] = vid
 			}
 		}
 	} else {
@@ -221,6 +278,13 @@ func (w *Worker) processJob(ctx context.Context, m queue.Body, cwd string, pp *p
 		}
 		resolvedVersions = rv
 
+		// The versions chosen on a manual trigger are used as they are
+		for _, g := range j.GetSteps() {
+			if vid, ok := m.Versions[g.Name]; ok {
+				resolvedVersions[g.ResourceCanonical()] = vid
+			}
+		}
+
 		// checkVersionAvailability verifies that the get step can pull a version.
 		// If no version is available (e.g. manual trigger with no resource versions),
 		// the build is deleted silently — no hooks run, no failure recorded.
@@ -229,7 +293,17 @@ func (w *Worker) processJob(ctx context.Context, m queue.Body, cwd string, pp *p
 		}
 	}
 
-	failed, resolved := w.runPlan(jobCtx, m, &b, cwd, pp, j, resolvedVersions)
+	var (
+		failed   bool
+		resolved map[string]string
+	)
+	// The jobs with a matrix run the plan of each cell,
+	// which restores and saves the cache of the cell
+	if len(j.Matrix) != 0 {
+		failed, resolved = w.runMatrix(jobCtx, m, &b, cwd, pp, j, resolvedVersions)
+	} else {
+		failed, resolved = w.runPlan(jobCtx, m, &b, cwd, pp, j, resolvedVersions)
+	}
 
 	// Handle user-initiated cancellation
 	if jobCtx.Err() == context.Canceled {
@@ -260,17 +334,18 @@ func (w *Worker) processJob(ctx context.Context, m queue.Body, cwd string, pp *p
 }
 
 // checkPassedConstraints verifies that all jobs in the "passed" list have a
-// successful build that used a common resource version. The returned map
-// contains resourceCanonical → resolvedVersionID for each get step with Passed.
+// successful build that used a common resource version, which has to be the
+// pinned one if the resource is pinned and can't be a disabled one. The returned
+// map contains resourceCanonical → resolvedVersionID for each get step with Passed.
 // If no common version exists, the build is deleted and (false, nil) is returned.
+// The get steps with a version chosen on a manual trigger are not checked.
 func (w *Worker) checkPassedConstraints(ctx context.Context, m queue.Body, b *build.Build, j *job.Job) (bool, map[string]uint32) {
 	resolvedVersions := make(map[string]uint32)
-	for _, ps := range j.Plan {
-		if ps.Type != job.StepTypeGet || ps.Get == nil {
+	for _, g := range j.GetSteps() {
+		if len(g.Passed) == 0 {
 			continue
 		}
-		g := ps.Get
-		if len(g.Passed) == 0 {
+		if _, ok := m.Versions[g.Name]; ok {
 			continue
 		}
 		rCan := g.ResourceCanonical()
@@ -309,9 +384,26 @@ func (w *Worker) checkPassedConstraints(ctx context.Context, m queue.Body, b *bu
 			}
 		}
 
+		if len(intersection) != 0 {
+			dbvers, err := w.pikoci.ListResourceVersions(ctx, m.TeamCanonical, m.PipelineName, rCan)
+			if err != nil {
+				w.failBuild(ctx, m, *b, fmt.Errorf("failed to list resource versions: %w", err))
+				return false, nil
+			}
+			usable := make(map[uint32]bool)
+			for _, v := range resource.UsableVersions(dbvers) {
+				usable[v.ID] = true
+			}
+			for vid := range intersection {
+				if !usable[vid] {
+					delete(intersection, vid)
+				}
+			}
+		}
+
 		if len(intersection) == 0 {
 			if hasSucceeded {
-				w.logger.Info("job will not run: no common version across passed jobs",
+				w.logger.Info("job will not run: no common usable version across passed jobs",
 					"job", m.JobName, "pipeline", m.PipelineName, "resource", rCan)
 			} else {
 				w.logger.Info("job will not run: no successful builds in passed jobs",
@@ -338,11 +430,7 @@ func (w *Worker) checkPassedConstraints(ctx context.Context, m queue.Body, b *bu
 // deleted and false is returned (same behavior as checkPassedConstraints).
 // This prevents hooks from running when no work can be done.
 func (w *Worker) checkVersionAvailability(ctx context.Context, m queue.Body, b *build.Build, j *job.Job, pp *pipeline.Pipeline) bool {
-	for _, ps := range j.Plan {
-		if ps.Type != job.StepTypeGet || ps.Get == nil {
-			continue
-		}
-		g := ps.Get
+	for _, g := range j.GetSteps() {
 		rCan := g.ResourceCanonical()
 		r, ok := pp.Resource(rCan)
 		if !ok {
@@ -357,7 +445,7 @@ func (w *Worker) checkVersionAvailability(ctx context.Context, m queue.Body, b *
 			return false
 		}
 
-		if len(dbvers) == 0 {
+		if len(resource.UsableVersions(dbvers)) == 0 {
 			w.logger.Info("job will not run: no versions available",
 				"job", m.JobName, "pipeline", m.PipelineName, "resource", r.Canonical)
 			w.deleteBuild(ctx, m, *b)
@@ -369,26 +457,52 @@ func (w *Worker) checkVersionAvailability(ctx context.Context, m queue.Body, b *
 
 // runPlan runs all plan steps (service/get/task/put) in declaration order.
 // Services are started when their position in the plan is reached and stopped
-// unconditionally after the plan completes (or fails).
+// unconditionally after the plan completes (or fails). The cache of the job
+// is restored before the plan and saved once it succeeds.
 // Returns true if the job failed during plan execution.
 func (w *Worker) runPlan(ctx context.Context, m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, j *job.Job, resolvedVersions map[string]uint32) (bool, map[string]string) {
 	// Track all started services so we can stop them at the end.
 	var allStartedServices []job.ServiceStep
 	defer func() {
 		if len(allStartedServices) > 0 {
-			w.stopServices(m, b, cwd, pp, allStartedServices)
+			w.stopServices(ctx, m, b, cwd, pp, allStartedServices)
 		}
 	}()
 
+	// The job cache is restored before the plan, unless its key depends
+	// on files of the workdir, then it waits for the get steps at the
+	// start of the plan that fetch them. It's saved if the plan succeeds.
+	var (
+		cache    *job.Cache
+		restored bool
+	)
+	restoreCache := func() {
+		if !restored {
+			restored = true
+			cache = w.restoreCache(ctx, m, b, cwd, j.Name, "", j.Cache)
+		}
+	}
+	if j.Cache == nil || len(j.Cache.KeyFiles) == 0 {
+		restoreCache()
+	}
+
 	// Resolve secret-backed variables once for the entire job execution.
-	resolved, err := w.resolveSecretVars(ctx, cwd, pp)
+	resolved, err := w.resolveSecretVars(ctx, m.TeamCanonical, cwd, pp)
 	if err != nil {
 		w.failBuild(ctx, m, *b, fmt.Errorf("failed to resolve secret vars: %w", err))
 		return true, nil
 	}
 
 	// Run plan steps in declaration order
-	for _, ps := range j.Plan {
+	for i, ps := range j.Plan {
+		if ps.Type != job.StepTypeGet {
+			restoreCache()
+		}
+		if skip, failed := w.skipPlanStep(ctx, m, b, ps, i, resolved); failed {
+			return true, resolved
+		} else if skip {
+			continue
+		}
 		switch ps.Type {
 		case job.StepTypeService:
 			if ps.Service == nil {
@@ -404,32 +518,55 @@ func (w *Worker) runPlan(ctx context.Context, m queue.Body, b *build.Build, cwd
 			if !w.waitForServices(ctx, m, b, cwd, pp, startedServices) {
 				return true, resolved
 			}
-		case job.StepTypeGet:
-			if ps.Get == nil {
+		case job.StepTypeParallel:
+			if ps.Parallel == nil {
 				continue
 			}
-			if w.runGetStep(ctx, m, b, cwd, pp, *ps.Get, ps, resolvedVersions, resolved) {
+			if w.runParallelStep(ctx, m, b, cwd, pp, j, ps, i, resolvedVersions, resolved) {
 				return true, resolved
 			}
-		case job.StepTypeTask:
-			if ps.Task == nil {
-				continue
-			}
-			if w.runTaskStep(ctx, m, b, cwd, pp, *ps.Task, ps, resolved) {
-				return true, resolved
-			}
-		case job.StepTypePut:
-			if ps.Put == nil {
-				continue
-			}
-			if w.runPutStep(ctx, m, b, cwd, pp, *ps.Put, ps, resolved) {
+		default:
+			if w.runPlanStep(ctx, m, b, cwd, pp, j, ps, resolvedVersions, resolved) {
 				return true, resolved
 			}
 		}
 	}
+
+	restoreCache()
+	if ctx.Err() == nil {
+		w.saveCache(ctx, m, b, cwd, j.Name, "", cache)
+	}
 	return false, resolved
 }
 
+// runPlanStep runs a single get/task/put plan step.
+// Returns true if the step failed.
+func (w *Worker) runPlanStep(ctx context.Context, m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, j *job.Job, ps job.PlanStep, resolvedVersions map[string]uint32, resolved map[string]string) bool {
+	switch ps.Type {
+	case job.StepTypeGet:
+		if ps.Get == nil {
+			return false
+		}
+		return w.runGetStep(ctx, m, b, cwd, pp, *ps.Get, ps, resolvedVersions, resolved)
+	case job.StepTypeTask:
+		if ps.Task == nil {
+			return false
+		}
+		if w.restoreArtifacts(ctx, m, b, cwd, j, *ps.Task, resolvedVersions) {
+			return true
+		}
+		t := *ps.Task
+		t.Cache = w.restoreCache(ctx, m, b, cwd, t.Name, t.Name, t.Cache)
+		return w.runTaskStep(ctx, m, b, cwd, pp, t, ps, resolved)
+	case job.StepTypePut:
+		if ps.Put == nil {
+			return false
+		}
+		return w.runPutStep(ctx, m, b, cwd, pp, *ps.Put, ps, resolved)
+	}
+	return false
+}
+
 // runGetStep runs a single get step (resource pull).
 // Returns true if the step failed.
 func (w *Worker) runGetStep(ctx context.Context, m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, g job.GetStep, ps job.PlanStep, resolvedVersions map[string]uint32, resolved ...map[string]string) bool {
@@ -471,6 +608,7 @@ func (w *Worker) runGetStep(ctx context.Context, m queue.Body, b *build.Build, c
 	for k, v := range buildMetadataParams(b, m) {
 		params[k] = v
 	}
+	setStepOutputs(ctx, b, params, nil)
 
 	pullArgs := make([]string, len(rt.Pull.Args))
 	copy(pullArgs, rt.Pull.Args)
@@ -489,8 +627,10 @@ func (w *Worker) runGetStep(ctx context.Context, m queue.Body, b *build.Build, c
 
 	// Append a "running" step and persist it
 	stepIdx := len(b.Steps)
-	b.Steps = append(b.Steps, build.Step{Type: "get", Name: g.Name, Status: build.Started})
+	stepID := uuid.New().String()
+	b.Steps = append(b.Steps, build.Step{ID: stepID, Type: "get", Name: g.Name, Status: build.Started})
 	w.updateBuild(ctx, m, *b)
+	appendLogs := w.stepLogs(ctx, m, b.BuildNumber, stepID)
 
 	var out string
 	var d time.Duration
@@ -503,8 +643,7 @@ func (w *Worker) runGetStep(ctx context.Context, m queue.Body, b *build.Build, c
 
 		prefix := out
 		onPartialLog := func(partial string) {
-			b.Steps[stepIdx].Logs = prefix + partial
-			w.updateBuild(ctx, m, *b)
+			appendLogs(prefix + partial)
 		}
 
 		runCtx := ctx
@@ -531,7 +670,7 @@ func (w *Worker) runGetStep(ctx context.Context, m queue.Body, b *build.Build, c
 	}
 
 	if err != nil {
-		b.Steps[stepIdx] = build.Step{Type: "get", Name: g.Name, Logs: out, Duration: d, Status: build.Failed}
+		b.Steps[stepIdx] = build.Step{ID: stepID, Type: "get", Name: g.Name, Logs: out, Duration: d, Status: build.Failed}
 		b.Status = build.Failed
 		w.failBuild(ctx, m, *b, nil)
 		w.logger.Error("failed to run get step", "step", g.Name, "error", err)
@@ -540,13 +679,23 @@ func (w *Worker) runGetStep(ctx context.Context, m queue.Body, b *build.Build, c
 		return true
 	}
 
+	// The fields of the version are kept on the
+	// step for the when of the next steps
+	version := make(map[string]string)
+	for k, v := range params {
+		if f, ok := strings.CutPrefix(k, "version_"); ok {
+			version[f] = v
+		}
+	}
 	b.Steps[stepIdx] = build.Step{
+		ID:        stepID,
 		Type:      "get",
 		Name:      g.Name,
 		VersionID: usedVersionID,
 		Logs:      out,
 		Duration:  d,
 		Status:    build.Succeeded,
+		Version:   version,
 	}
 	if err := w.updateBuild(ctx, m, *b); err != nil {
 		return true
@@ -584,6 +733,7 @@ func (w *Worker) runTaskStep(ctx context.Context, m queue.Body, b *build.Build,
 	for k, v := range buildMetadataParams(b, m) {
 		t.Run.Params[k] = v
 	}
+	setStepOutputs(ctx, b, t.Run.Params, t.Run.Args)
 
 	for _, input := range t.Inputs {
 		if _, err := os.Stat(filepath.Join(cwd, input)); err != nil {
@@ -604,8 +754,14 @@ func (w *Worker) runTaskStep(ctx context.Context, m queue.Body, b *build.Build,
 
 	// Append a "running" step and persist it
 	stepIdx := len(b.Steps)
-	b.Steps = append(b.Steps, build.Step{Type: "task", Name: t.Name, Status: build.Started})
+	stepID := uuid.New().String()
+	b.Steps = append(b.Steps, build.Step{ID: stepID, Type: "task", Name: t.Name, Status: build.Started})
 	w.updateBuild(ctx, m, *b)
+	appendLogs := w.stepLogs(ctx, m, b.BuildNumber, stepID)
+
+	outputsPath := stepOutputsPath(cwd, stepID)
+	t.Run.Params["PIKOCI_OUTPUT"] = outputsPath
+	t.Run.Params["PIKOCI_OUTPUT_NAME"] = stepOutputsName(stepID)
 
 	var out string
 	var d time.Duration
@@ -618,8 +774,7 @@ func (w *Worker) runTaskStep(ctx context.Context, m queue.Body, b *build.Build,
 
 		prefix := out
 		onPartialLog := func(partial string) {
-			b.Steps[stepIdx].Logs = prefix + partial
-			w.updateBuild(ctx, m, *b)
+			appendLogs(prefix + partial)
 		}
 
 		runCtx := ctx
@@ -645,8 +800,18 @@ func (w *Worker) runTaskStep(ctx context.Context, m queue.Body, b *build.Build,
 		}
 	}
 
+	outputs, oerr := readStepOutputs(outputsPath)
+	if err == nil && oerr != nil {
+		err = oerr
+		out += "\n" + oerr.Error()
+	}
+
+	if rl := w.uploadReports(ctx, m, b, cwd, t); rl != "" {
+		out += "\n" + rl
+	}
+
 	if err != nil {
-		b.Steps[stepIdx] = build.Step{Type: "task", Name: t.Name, Logs: out, Duration: d, Status: build.Failed}
+		b.Steps[stepIdx] = build.Step{ID: stepID, Type: "task", Name: t.Name, Logs: out, Duration: d, Status: build.Failed}
 		b.Status = build.Failed
 		w.failBuild(ctx, m, *b, nil)
 		w.runHooks(ctx, m, b, &b.Steps, cwd, pp, t.Name, ps.OnFailure, "on_failure", secretResolved)
@@ -654,12 +819,23 @@ func (w *Worker) runTaskStep(ctx context.Context, m queue.Body, b *build.Build,
 		return true
 	}
 
-	b.Steps[stepIdx] = build.Step{Type: "task", Name: t.Name, Logs: out, Duration: d, Status: build.Succeeded}
+	b.Steps[stepIdx] = build.Step{ID: stepID, Type: "task", Name: t.Name, Logs: out, Duration: d, Status: build.Succeeded, Outputs: outputs}
 
 	for _, output := range t.Outputs {
 		if _, err := os.Stat(filepath.Join(cwd, output)); err != nil {
 			errMsg := fmt.Sprintf("task finished but output %q was not produced", output)
-			b.Steps[stepIdx] = build.Step{Type: "task", Name: t.Name, Logs: out + "\n" + errMsg, Duration: d, Status: build.Failed}
+			b.Steps[stepIdx] = build.Step{ID: stepID, Type: "task", Name: t.Name, Logs: out + "\n" + errMsg, Duration: d, Status: build.Failed}
+			b.Status = build.Failed
+			w.failBuild(ctx, m, *b, nil)
+			w.runHooks(ctx, m, b, &b.Steps, cwd, pp, t.Name, ps.OnFailure, "on_failure", secretResolved)
+			w.runHooks(ctx, m, b, &b.Steps, cwd, pp, t.Name, ps.Ensure, "ensure", secretResolved)
+			return true
+		}
+	}
+
+	if len(t.Outputs) > 0 {
+		if err := w.uploadArtifact(ctx, m, b, cwd, t); err != nil {
+			b.Steps[stepIdx] = build.Step{ID: stepID, Type: "task", Name: t.Name, Logs: out + "\n" + err.Error(), Duration: d, Status: build.Failed}
 			b.Status = build.Failed
 			w.failBuild(ctx, m, *b, nil)
 			w.runHooks(ctx, m, b, &b.Steps, cwd, pp, t.Name, ps.OnFailure, "on_failure", secretResolved)
@@ -671,6 +847,7 @@ func (w *Worker) runTaskStep(ctx context.Context, m queue.Body, b *build.Build,
 	if err := w.updateBuild(ctx, m, *b); err != nil {
 		return true
 	}
+	w.saveCache(ctx, m, b, cwd, t.Name, t.Name, t.Cache)
 	w.runHooks(ctx, m, b, &b.Steps, cwd, pp, t.Name, ps.OnSuccess, "on_success", secretResolved)
 	w.runHooks(ctx, m, b, &b.Steps, cwd, pp, t.Name, ps.Ensure, "ensure", secretResolved)
 	return false
@@ -714,6 +891,7 @@ func (w *Worker) runPutStep(ctx context.Context, m queue.Body, b *build.Build, c
 	for k, v := range buildMetadataParams(b, m) {
 		params[k] = v
 	}
+	setStepOutputs(ctx, b, params, nil)
 
 	ru, ok := pp.Runner(rt.Push.Runner)
 	if !ok {
@@ -739,8 +917,10 @@ func (w *Worker) runPutStep(ctx context.Context, m queue.Body, b *build.Build, c
 
 	// Append a "running" step and persist it
 	stepIdx := len(b.Steps)
-	b.Steps = append(b.Steps, build.Step{Type: "put", Name: p.Name, Status: build.Started})
+	stepID := uuid.New().String()
+	b.Steps = append(b.Steps, build.Step{ID: stepID, Type: "put", Name: p.Name, Status: build.Started})
 	w.updateBuild(ctx, m, *b)
+	appendLogs := w.stepLogs(ctx, m, b.BuildNumber, stepID)
 
 	var out string
 	var d time.Duration
@@ -753,8 +933,7 @@ func (w *Worker) runPutStep(ctx context.Context, m queue.Body, b *build.Build, c
 
 		prefix := out
 		onPartialLog := func(partial string) {
-			b.Steps[stepIdx].Logs = prefix + partial
-			w.updateBuild(ctx, m, *b)
+			appendLogs(prefix + partial)
 		}
 
 		runCtx := ctx
@@ -781,7 +960,7 @@ func (w *Worker) runPutStep(ctx context.Context, m queue.Body, b *build.Build, c
 	}
 
 	if err != nil {
-		b.Steps[stepIdx] = build.Step{Type: "put", Name: p.Name, Logs: out, Duration: d, Status: build.Failed}
+		b.Steps[stepIdx] = build.Step{ID: stepID, Type: "put", Name: p.Name, Logs: out, Duration: d, Status: build.Failed}
 		b.Status = build.Failed
 		w.failBuild(ctx, m, *b, nil)
 		w.logger.Error("failed to run put step", "step", p.Name, "error", err)
@@ -790,7 +969,7 @@ func (w *Worker) runPutStep(ctx context.Context, m queue.Body, b *build.Build, c
 		return true
 	}
 
-	b.Steps[stepIdx] = build.Step{Type: "put", Name: p.Name, Logs: out, Duration: d, Status: build.Succeeded}
+	b.Steps[stepIdx] = build.Step{ID: stepID, Type: "put", Name: p.Name, Logs: out, Duration: d, Status: build.Succeeded}
 	if err := w.updateBuild(ctx, m, *b); err != nil {
 		return true
 	}
@@ -819,37 +998,44 @@ func (w *Worker) buildPullParams(ctx context.Context, m queue.Body, b *build.Bui
 		return nil, 0
 	}
 
-	// Version priority: resolvedVersionID (from passed constraints) > m.VersionID (from queue) > latest
+	// Version priority: pinned > resolvedVersionID (from passed constraints or manual trigger) > m.VersionID (from queue) > latest
+	// and disabled versions are never used
+	usable := resource.UsableVersions(dbvers)
 	versionID := resolvedVersionID
 	if versionID == 0 {
 		versionID = m.VersionID
 	}
+	if len(usable) == 1 && usable[0].Pinned {
+		versionID = usable[0].ID
+	}
 
+	var ver *resource.Version
 	if versionID != 0 {
-		var found bool
-		for _, ver := range dbvers {
-			if ver.ID == versionID {
-				found = true
-				for k, v := range ver.Version {
-					params["version_"+k] = fmt.Sprintf("%s", v)
-				}
+		for _, v := range usable {
+			if v.ID == versionID {
+				ver = v
 				break
 			}
 		}
-		if !found {
-			w.failBuild(ctx, m, *b, fmt.Errorf("no version found for resource %q", r.Canonical))
+		if ver == nil {
+			if slices.ContainsFunc(dbvers, func(v *resource.Version) bool { return v.ID == versionID && v.Disabled }) {
+				w.failBuild(ctx, m, *b, fmt.Errorf("version %d of resource %q is disabled", versionID, r.Canonical))
+			} else {
+				w.failBuild(ctx, m, *b, fmt.Errorf("no version found for resource %q", r.Canonical))
+			}
 			return nil, 0
 		}
 	} else {
-		if len(dbvers) == 0 {
+		if len(usable) == 0 {
 			w.failBuild(ctx, m, *b, fmt.Errorf("no versions for resource %q", r.Canonical))
 			return nil, 0
 		}
-		slices.Reverse(dbvers)
-		versionID = dbvers[0].ID
-		for k, v := range dbvers[0].Version {
-			params["version_"+k] = fmt.Sprintf("%s", v)
-		}
+		// The latest is the one with the highest ID
+		ver = slices.MaxFunc(usable, func(a, b *resource.Version) int { return cmp.Compare(a.ID, b.ID) })
+		versionID = ver.ID
+	}
+	for k, v := range ver.Version {
+		params["version_"+k] = fmt.Sprintf("%s", v)
 	}
 
 	for k, v := range r.GetParams() {
@@ -878,6 +1064,32 @@ func (w *Worker) runHooks(ctx context.Context, m queue.Body, b *build.Build, ste
 			}
 		}
 
+		// The hooks with a false when are recorded as skipped, and
+		// as failed if it can't be evaluated without failing the build
+		if h.When != nil {
+			status := b.Status.String()
+			if len(buildStatus) > 0 {
+				status = buildStatus[0]
+			}
+			st := "hook"
+			if h.Type == job.StepTypePut && h.Put != nil {
+				st, name = "put", h.Put.Name
+			}
+			ok, err := evalWhen(ctx, m, b, h.When, resolved, status)
+			if err != nil || !ok {
+				s := build.Step{ID: uuid.New().String(), Type: st, Name: name, Status: build.Skipped}
+				if err != nil {
+					s.Logs = fmt.Sprintf("failed to evaluate the when of the hook: %s", err)
+					s.Status = build.Failed
+				}
+				*steps = append(*steps, s)
+				if err := w.updateBuild(ctx, m, *b); err != nil {
+					return
+				}
+				continue
+			}
+		}
+
 		var out string
 		var d time.Duration
 
@@ -899,6 +1111,8 @@ func (w *Worker) runHooks(ctx context.Context, m queue.Body, b *build.Build, ste
 				params[k] = v
 			}
 			rc.Params = params
+			rc.Args = slices.Clone(rc.Args)
+			setStepOutputs(ctx, b, rc.Params, rc.Args)
 			replaceSecretPlaceholders(rc.Params, resolved)
 			replaceSecretPlaceholdersInSlice(rc.Args, resolved)
 			if len(buildStatus) > 0 {
@@ -907,17 +1121,13 @@ func (w *Worker) runHooks(ctx context.Context, m queue.Body, b *build.Build, ste
 
 			// Append a "running" step and persist it
 			stepIdx := len(*steps)
-			*steps = append(*steps, build.Step{Type: "hook", Name: name, Status: build.Started})
+			stepID := uuid.New().String()
+			*steps = append(*steps, build.Step{ID: stepID, Type: "hook", Name: name, Status: build.Started})
 			w.updateBuild(ctx, m, *b)
 
-			onPartialLog := func(partial string) {
-				(*steps)[stepIdx].Logs = partial
-				w.updateBuild(ctx, m, *b)
-			}
-
-			out, d, _ = w.runRunner(ctx, ru, cwd, rc, onPartialLog)
+			out, d, _ = w.runRunner(ctx, ru, cwd, rc, w.stepLogs(ctx, m, b.BuildNumber, stepID))
 
-			(*steps)[stepIdx] = build.Step{Type: "hook", Name: name, Logs: out, Duration: d, Status: build.Succeeded}
+			(*steps)[stepIdx] = build.Step{ID: stepID, Type: "hook", Name: name, Logs: out, Duration: d, Status: build.Succeeded}
 			if err := w.updateBuild(ctx, m, *b); err != nil {
 				return
 			}
@@ -978,7 +1188,19 @@ func (w *Worker) processResourceCheck(ctx context.Context, m queue.Body, cwd str
 		}
 	}
 
-	resolved, err := w.resolveSecretVars(ctx, cwd, pp)
+	// The body of the webhook that triggered the check is stored on a file
+	// so the check can create the version from it without polling
+	if len(m.Payload) != 0 {
+		pf := filepath.Join(cwd, "webhook_payload")
+		if err := os.WriteFile(pf, m.Payload, 0o600); err != nil {
+			w.logger.Error("failed to write webhook payload", "resource", r.Canonical, "error", err)
+			return
+		}
+		params["webhook_payload_file"] = pf
+	}
+
+	ctx = withRedactor(ctx)
+	resolved, err := w.resolveSecretVars(ctx, m.TeamCanonical, cwd, pp)
 	if err != nil {
 		w.logger.Error("failed to resolve secret vars for resource check", "error", err)
 		r.Logs = err.Error()
@@ -1003,9 +1225,13 @@ func (w *Worker) processResourceCheck(ctx context.Context, m queue.Body, cwd str
 		Args:   checkArgs,
 		Params: params,
 	}
-	out, _, err := w.runRunner(ctx, ru, cwd, rc)
+	// The versions are read from the output as it is,
+	// only the logs of the check are redacted
+	rd := redactorFrom(ctx)
+	out, _, err := w.runCommand(ctx, ru, cwd, rc, nil)
+	w.logger.Debug("finished running command", "out", rd.redact(out))
 	if err != nil {
-		r.Logs = out
+		r.Logs = rd.redact(out)
 		if nerr := w.pikoci.UpdatePipelineResource(ctx, m.TeamCanonical, m.PipelineName, r.Canonical, r); nerr != nil {
 			w.logger.Error("failed update resource", "resource", r.Canonical, "pipeline", m.PipelineName, "error", nerr)
 		}
@@ -1029,8 +1255,8 @@ func (w *Worker) processResourceCheck(ctx context.Context, m queue.Body, cwd str
 
 	vers := make([]map[string]interface{}, 0)
 	if err := json.Unmarshal([]byte(rawVers), &vers); err != nil {
-		w.logger.Error("failed to unmarshal versions", "raw", rawVers, "error", err)
-		r.Logs = fmt.Sprintf("failed to Unmarshal versions(%s): %v", rawVers, err)
+		w.logger.Error("failed to unmarshal versions", "raw", rd.redact(rawVers), "error", err)
+		r.Logs = rd.redact(fmt.Sprintf("failed to Unmarshal versions(%s): %v", rawVers, err))
 		if nerr := w.pikoci.UpdatePipelineResource(ctx, m.TeamCanonical, m.PipelineName, r.Canonical, r); nerr != nil {
 			w.logger.Error("failed update resource", "resource", r.Canonical, "pipeline", m.PipelineName, "error", nerr)
 		}
@@ -1057,39 +1283,80 @@ func (w *Worker) processResourceCheck(ctx context.Context, m queue.Body, cwd str
 }
 
 // triggerResourceJobs triggers jobs that depend on a resource via "get" with trigger=true.
+// Pinned resources do not trigger jobs as the new versions would not be used.
 func (w *Worker) triggerResourceJobs(ctx context.Context, m queue.Body, pp *pipeline.Pipeline, r resource.Resource, cv *resource.Version) {
+	if r.PinnedVersionID != 0 {
+		w.logger.Info("resource is pinned, not triggering jobs",
+			"pipeline", pp.Name, "resource", r.Canonical, "version_id", cv.ID)
+		return
+	}
 	for _, j := range pp.Jobs {
-		for _, ps := range j.Plan {
-			if ps.Type != job.StepTypeGet || ps.Get == nil {
-				continue
-			}
-			g := ps.Get
+		if j.Paused {
+			continue
+		}
+		for _, g := range j.GetSteps() {
 			// If Passed is not 0 it means is waiting for another job
 			// and this trigger is only for resources
 			if g.Name == r.Name && g.Type == r.Type && g.Trigger && len(g.Passed) == 0 {
+				pb, err := w.pikoci.CreateJobBuild(ctx, m.TeamCanonical, pp.Name, j.Name, build.Build{
+					Status:    build.Pending,
+					Steps:     []build.Step{},
+					StartedAt: time.Now().Round(0),
+				})
+				if err != nil {
+					w.logger.Error("failed to create pending build", "job", j.Name, "error", err)
+					continue
+				}
 				qb := queue.Body{
 					TeamCanonical:     m.TeamCanonical,
 					PipelineName:      pp.Name,
 					JobName:           j.Name,
 					ResourceCanonical: r.Canonical,
 					VersionID:         cv.ID,
+					BuildNumber:       pb.BuildNumber,
+					Tags:              j.Tags,
 				}
 				mb, err := json.Marshal(qb)
 				if err != nil {
 					w.logger.Error("failed to marshal trigger body", "error", err)
+					w.deletePendingBuild(ctx, qb)
 					continue
 				}
 				w.logger.Info("sending trigger message",
 					"pipeline", pp.Name, "job", j.Name, "resource", r.Canonical,
-					"version_id", cv.ID, "step", g.Name)
+					"version_id", cv.ID, "step", g.Name, "build_number", pb.BuildNumber)
 				if err := w.topic.Send(ctx, &pubsub.Message{Body: mb}); err != nil {
 					w.logger.Error("failed to send trigger message", "job", j.Name, "error", err)
+					w.deletePendingBuild(ctx, qb)
 				}
 			}
 		}
 	}
 }
 
+// deletePendingBuild deletes the Pending Build of the message m, if
+// it has one, as it will not be started once the message is dropped
+func (w *Worker) deletePendingBuild(ctx context.Context, m queue.Body) {
+	if m.JobName == "" || m.BuildNumber == "" {
+		return
+	}
+	err := w.pikoci.DeleteJobBuild(ctx, m.TeamCanonical, m.PipelineName, m.JobName, m.BuildNumber)
+	if err != nil {
+		w.logger.Error("failed to delete pending build",
+			"pipeline", m.PipelineName, "job", m.JobName, "build_number", m.BuildNumber, "error", err)
+	}
+}
+
+// jobPaused returns true if the job jn of the pipeline pp is paused
+func jobPaused(pp *pipeline.Pipeline, jn string) bool {
+	for _, j := range pp.Jobs {
+		if j.Name == jn {
+			return j.Paused
+		}
+	}
+	return false
+}
+
 func (w *Worker) pollForCancellation(apiCtx, jobCtx context.Context, cancel context.CancelFunc, m queue.Body, buildNumber string) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
@@ -1114,6 +1381,12 @@ func (w *Worker) pollForCancellation(apiCtx, jobCtx context.Context, cancel cont
 
 // updateBuild persists the current build state to the DB.
 func (w *Worker) updateBuild(ctx context.Context, m queue.Body, b build.Build) error {
+	if pl, ok := ctx.Value(parallelLaneKey{}).(parallelLane); ok {
+		return pl.group.update(w, m, pl.lane, b, nil)
+	}
+	if mc, ok := ctx.Value(matrixCellKey{}).(matrixCell); ok {
+		return mc.matrix.update(w, m, mc.cell, b)
+	}
 	err := w.pikoci.UpdateJobBuild(ctx, m.TeamCanonical, m.PipelineName, m.JobName, b.BuildNumber, b)
 	if err != nil {
 		w.logger.Error("failed update build", "pipeline", m.PipelineName, "job", m.JobName, "error", err)
@@ -1127,6 +1400,17 @@ func (w *Worker) failBuild(ctx context.Context, m queue.Body, b build.Build, err
 		b.Error = err.Error()
 		w.logger.Error(err.Error())
 	}
+	// Inside an in_parallel group the job build is only failed
+	// once all the steps of the group have finished
+	if pl, ok := ctx.Value(parallelLaneKey{}).(parallelLane); ok {
+		pl.group.update(w, m, pl.lane, b, err)
+		return
+	}
+	// Inside a matrix it's only failed once all the cells have finished
+	if mc, ok := ctx.Value(matrixCellKey{}).(matrixCell); ok {
+		mc.matrix.update(w, m, mc.cell, b)
+		return
+	}
 	if uerr := w.pikoci.UpdateJobBuild(ctx, m.TeamCanonical, m.PipelineName, m.JobName, b.BuildNumber, b); uerr != nil {
 		w.logger.Error("failed update build", "pipeline", m.PipelineName, "job", m.JobName, "error", uerr)
 	}
@@ -1157,7 +1441,30 @@ func (sw *streamWriter) String() string {
 	return sw.buf.String()
 }
 
+// runRunner runs the command rc with the runner ru, the secrets of the
+// redactor of ctx are redacted from its output and the partial logs
 func (w *Worker) runRunner(ctx context.Context, ru runner.Runner, cwd string, rc utils.RunnerCommand, onPartialLog ...func(string)) (string, time.Duration, error) {
+	// The values are the ones at the start so the partial
+	// logs and the output are redacted the same way
+	rp := redactorFrom(ctx).replacer()
+
+	var partialCb func(string)
+	if len(onPartialLog) > 0 {
+		partialCb = redactPartialLogs(rp, onPartialLog[0])
+	}
+
+	out, duration, err := w.runCommand(ctx, ru, cwd, rc, partialCb)
+	if rp != nil {
+		out = rp.Replace(out)
+	}
+	w.logger.Debug("finished running command", "out", out)
+
+	return out, duration, err
+}
+
+// runCommand runs the command rc with the runner ru and returns
+// its output as it is, only the worker logs are redacted
+func (w *Worker) runCommand(ctx context.Context, ru runner.Runner, cwd string, rc utils.RunnerCommand, partialCb func(string)) (string, time.Duration, error) {
 	envs := map[string]string{"WORKDIR": cwd}
 	for k, v := range rc.Params {
 		envs[k] = v
@@ -1169,6 +1476,10 @@ func (w *Worker) runRunner(ctx context.Context, ru runner.Runner, cwd string, rc
 		return os.Getenv(p)
 	}
 
+	if ru.Name == builtin.NativeRunner {
+		return w.runNative(ctx, cwd, rc, envs, partialCb)
+	}
+
 	var args []string
 	var out string
 	for _, a := range ru.Run.Args {
@@ -1200,12 +1511,8 @@ func (w *Worker) runRunner(ctx context.Context, ru runner.Runner, cwd string, rc
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
 
-	w.logger.Debug("running command", "cmd", cmd.String(), "envs", createKeyValuePairs(envs))
-
-	var partialCb func(string)
-	if len(onPartialLog) > 0 {
-		partialCb = onPartialLog[0]
-	}
+	rd := redactorFrom(ctx)
+	w.logger.Debug("running command", "cmd", rd.redact(cmd.String()), "envs", rd.redact(createKeyValuePairs(envs)))
 
 	sw := &streamWriter{}
 	cmd.Stdout = sw
@@ -1217,42 +1524,101 @@ func (w *Worker) runRunner(ctx context.Context, ru runner.Runner, cwd string, rc
 		return out, time.Since(start), err
 	}
 
-	var ticker *time.Ticker
-	var wg sync.WaitGroup
-	done := make(chan struct{})
-	if partialCb != nil {
-		ticker = time.NewTicker(2 * time.Second)
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for {
-				select {
-				case <-ticker.C:
-					partialCb(sw.String())
-				case <-done:
-					return
-				}
-			}
-		}()
-	}
-
+	stop := streamPartialLogs(sw, partialCb)
 	err := cmd.Wait()
-	if ticker != nil {
-		ticker.Stop()
-	}
-	close(done)
-	wg.Wait()
+	stop()
 
 	duration := time.Since(start)
 	out += sw.String()
 	if err != nil {
 		out += "\n" + err.Error()
 	}
-	w.logger.Debug("finished running command", "out", out)
 
 	return out, duration, err
 }
 
+// runNative runs the command rc implemented in Go by the
+// builtin package, envs are the env variables of the command
+func (w *Worker) runNative(ctx context.Context, cwd string, rc utils.RunnerCommand, envs map[string]string, partialCb func(string)) (string, time.Duration, error) {
+	rd := redactorFrom(ctx)
+	w.logger.Debug("running native command", "args", rd.redact(strings.Join(rc.Args, " ")), "envs", rd.redact(createKeyValuePairs(envs)))
+
+	sw := &streamWriter{}
+	start := time.Now()
+
+	stop := streamPartialLogs(sw, partialCb)
+	err := builtin.RunNative(ctx, cwd, rc.Args, envs, sw)
+	stop()
+
+	duration := time.Since(start)
+	out := sw.String()
+	if err != nil {
+		out += "\n" + err.Error()
+	}
+
+	return out, duration, err
+}
+
+// streamPartialLogs calls partialCb with the output of sw every 2s
+// until the returned function is called, if partialCb is not nil
+func streamPartialLogs(sw *streamWriter, partialCb func(string)) func() {
+	if partialCb == nil {
+		return func() {}
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				partialCb(sw.String())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+		wg.Wait()
+	}
+}
+
+// stepLogs returns the onPartialLog of the running step stepID, each
+// call appends the part of logs not sent yet to the build as a LogChunk
+// instead of updating the whole build with all the logs
+func (w *Worker) stepLogs(ctx context.Context, m queue.Body, buildNumber, stepID string) func(string) {
+	var sent int
+	return func(logs string) {
+		// A rune split between two writes is left for the next
+		// chunk so the Data of each one is valid UTF-8
+		end := len(logs)
+		for i := 1; i < utf8.UTFMax && end-i >= sent; i++ {
+			if utf8.RuneStart(logs[end-i]) {
+				if !utf8.FullRuneInString(logs[end-i:]) {
+					end -= i
+				}
+				break
+			}
+		}
+		if end <= sent {
+			return
+		}
+
+		c := build.LogChunk{StepID: stepID, Offset: sent, Data: logs[sent:end]}
+		if err := w.pikoci.AppendJobBuildLogs(ctx, m.TeamCanonical, m.PipelineName, m.JobName, buildNumber, c); err != nil {
+			w.logger.Error("failed append build logs", "pipeline", m.PipelineName, "job", m.JobName, "error", err)
+			return
+		}
+		sent = end
+	}
+}
+
 // fetchSecrets resolves secret values for the given secrets map (secret_type name -> path)
 // and returns them as a map of "secret_<key>" env vars.
 func (w *Worker) fetchSecrets(ctx context.Context, cwd string, pp *pipeline.Pipeline, secrets map[string]string) (map[string]string, error) {
@@ -1298,9 +1664,10 @@ func (w *Worker) fetchSecrets(ctx context.Context, cwd string, pp *pipeline.Pipe
 			Params: params,
 		}
 
-		out, _, err := w.runRunner(ctx, ru, cwd, rc)
+		// The output is the secret itself so it can't be redacted
+		out, _, err := w.runCommand(ctx, ru, cwd, rc, nil)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch secret from %q at %q: %s\n%w", stName, path, out, err)
+			return nil, fmt.Errorf("failed to fetch secret from %q at %q: %s\n%w", stName, path, redactorFrom(ctx).redact(out), err)
 		}
 
 		// Parse output based on format config
@@ -1328,6 +1695,26 @@ func (w *Worker) fetchSecrets(ctx context.Context, cwd string, pp *pipeline.Pipe
 	return result, nil
 }
 
+// fetchPikoCISecrets gets from the server the Secrets of the Team tc the
+// varNames use and returns them as a map of "secret_<key>" env vars.
+func (w *Worker) fetchPikoCISecrets(ctx context.Context, tc string, pp *pipeline.Pipeline, varNames []string) (map[string]string, error) {
+	names := make([]string, 0, len(varNames))
+	for _, vn := range varNames {
+		names = append(names, pp.SecretVars[vn].Key)
+	}
+
+	values, err := w.pikoci.ResolveSecrets(ctx, tc, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the Team Secrets: %w", err)
+	}
+
+	result := make(map[string]string, len(values))
+	for k, v := range values {
+		result["secret_"+k] = v
+	}
+	return result, nil
+}
+
 // startServices starts all service steps and returns the successfully started service steps.
 func (w *Worker) startServices(ctx context.Context, m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, serviceSteps []job.ServiceStep) []job.ServiceStep {
 	var started []job.ServiceStep
@@ -1358,24 +1745,20 @@ func (w *Worker) startServices(ctx context.Context, m queue.Body, b *build.Build
 
 		// Append a "running" step and persist it
 		stepIdx := len(b.Steps)
-		b.Steps = append(b.Steps, build.Step{Type: "service", Name: ss.Name + ":start", Status: build.Started})
+		stepID := uuid.New().String()
+		b.Steps = append(b.Steps, build.Step{ID: stepID, Type: "service", Name: ss.Name + ":start", Status: build.Started})
 		w.updateBuild(ctx, m, *b)
 
-		onPartialLog := func(partial string) {
-			b.Steps[stepIdx].Logs = partial
-			w.updateBuild(ctx, m, *b)
-		}
-
-		out, d, err := w.runRunner(ctx, ru, cwd, rc, onPartialLog)
+		out, d, err := w.runRunner(ctx, ru, cwd, rc, w.stepLogs(ctx, m, b.BuildNumber, stepID))
 		if err != nil {
-			b.Steps[stepIdx] = build.Step{Type: "service", Name: ss.Name + ":start", Logs: out, Duration: d, Status: build.Failed}
+			b.Steps[stepIdx] = build.Step{ID: stepID, Type: "service", Name: ss.Name + ":start", Logs: out, Duration: d, Status: build.Failed}
 			b.Status = build.Failed
 			w.failBuild(ctx, m, *b, nil)
 			w.logger.Error("failed to start service", "service", ss.Name, "error", err)
 			return started
 		}
 
-		b.Steps[stepIdx] = build.Step{Type: "service", Name: ss.Name + ":start", Logs: out, Duration: d, Status: build.Succeeded}
+		b.Steps[stepIdx] = build.Step{ID: stepID, Type: "service", Name: ss.Name + ":start", Logs: out, Duration: d, Status: build.Succeeded}
 		if err := w.updateBuild(ctx, m, *b); err != nil {
 			return started
 		}
@@ -1565,10 +1948,12 @@ func (w *Worker) waitForServices(ctx context.Context, m queue.Body, b *build.Bui
 }
 
 // stopServices stops all started services unconditionally.
-// Uses a fresh context to ensure cleanup runs even if the parent context is cancelled.
-func (w *Worker) stopServices(m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, startedServices []job.ServiceStep) {
+// Uses a fresh context to ensure cleanup runs even if the parent context is cancelled,
+// only the redactor of ctx is kept.
+func (w *Worker) stopServices(ctx context.Context, m queue.Body, b *build.Build, cwd string, pp *pipeline.Pipeline, startedServices []job.ServiceStep) {
 	stopCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	stopCtx = context.WithValue(stopCtx, redactorKey{}, redactorFrom(ctx))
 
 	for _, ss := range startedServices {
 		svc, ok := pp.Service(ss.Name)
@@ -1592,21 +1977,17 @@ func (w *Worker) stopServices(m queue.Body, b *build.Build, cwd string, pp *pipe
 
 		// Append a "running" step and persist it
 		stepIdx := len(b.Steps)
-		b.Steps = append(b.Steps, build.Step{Type: "service", Name: ss.Name + ":stop", Status: build.Started})
+		stepID := uuid.New().String()
+		b.Steps = append(b.Steps, build.Step{ID: stepID, Type: "service", Name: ss.Name + ":stop", Status: build.Started})
 		w.updateBuild(stopCtx, m, *b)
 
-		onPartialLog := func(partial string) {
-			b.Steps[stepIdx].Logs = partial
-			w.updateBuild(stopCtx, m, *b)
-		}
-
-		out, d, err := w.runRunner(stopCtx, ru, cwd, rc, onPartialLog)
+		out, d, err := w.runRunner(stopCtx, ru, cwd, rc, w.stepLogs(stopCtx, m, b.BuildNumber, stepID))
 		stepStatus := build.Succeeded
 		if err != nil {
 			stepStatus = build.Failed
 			w.logger.Error("failed to stop service", "service", ss.Name, "error", err)
 		}
-		b.Steps[stepIdx] = build.Step{Type: "service", Name: ss.Name + ":stop", Logs: out, Duration: d, Status: stepStatus}
+		b.Steps[stepIdx] = build.Step{ID: stepID, Type: "service", Name: ss.Name + ":stop", Logs: out, Duration: d, Status: stepStatus}
 		w.updateBuild(stopCtx, m, *b)
 	}
 }
@@ -1614,7 +1995,8 @@ func (w *Worker) stopServices(m queue.Body, b *build.Build, cwd string, pp *pipe
 // resolveSecretVars resolves all secret-backed variable placeholders by fetching
 // the actual secret values from the configured secret types. Variables sharing
 // the same secret type and path are batched into a single fetch call.
-func (w *Worker) resolveSecretVars(ctx context.Context, cwd string, pp *pipeline.Pipeline) (map[string]string, error) {
+// The values are added to the redactor of ctx.
+func (w *Worker) resolveSecretVars(ctx context.Context, tc, cwd string, pp *pipeline.Pipeline) (map[string]string, error) {
 	if len(pp.SecretVars) == 0 {
 		return nil, nil
 	}
@@ -1629,7 +2011,16 @@ func (w *Worker) resolveSecretVars(ctx context.Context, cwd string, pp *pipeline
 
 	resolved := make(map[string]string)
 	for k, varNames := range groups {
-		secrets, err := w.fetchSecrets(ctx, cwd, pp, map[string]string{k.typ: k.path})
+		var (
+			secrets map[string]string
+			err     error
+		)
+		// The secret_type of the Pipeline takes precedence over the built-in one
+		if _, ok := pp.SecretType(k.typ); !ok && k.typ == builtin.PikoCISecretType {
+			secrets, err = w.fetchPikoCISecrets(ctx, tc, pp, varNames)
+		} else {
+			secrets, err = w.fetchSecrets(ctx, cwd, pp, map[string]string{k.typ: k.path})
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve secrets from %q at %q: %w", k.typ, k.path, err)
 		}
@@ -1641,6 +2032,7 @@ func (w *Worker) resolveSecretVars(ctx context.Context, cwd string, pp *pipeline
 				return nil, fmt.Errorf("secret for variable %q: key %q not found in response", varName, sv.Key)
 			}
 			resolved[placeholder] = val
+			redactorFrom(ctx).add(val)
 		}
 	}
 	return resolved, nil
@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/xescugc/pikoci/pikoci"
+	"github.com/xescugc/pikoci/pikoci/build"
+	"github.com/xescugc/pikoci/pikoci/job"
+	"github.com/xescugc/pikoci/pikoci/queue"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// planStepName returns the build.Step type and name of the plan
+// step ps, idx is its position on the plan of the job
+func planStepName(ps job.PlanStep, idx int) (string, string) {
+	switch {
+	case ps.Get != nil:
+		return "get", ps.Get.Name
+	case ps.Task != nil:
+		return "task", ps.Task.Name
+	case ps.Put != nil:
+		return "put", ps.Put.Name
+	case ps.Service != nil:
+		return "service", ps.Service.Name
+	case ps.Parallel != nil:
+		return "in_parallel", fmt.Sprintf("in_parallel:%d", idx)
+	}
+	return string(ps.Type), ""
+}
+
+// skipPlanStep evaluates the when condition of the plan step ps, which is
+// recorded as skipped on the build if it's false. If the condition can't be
+// evaluated the step and the build are failed. Returns if the step has to
+// be skipped and if the build failed.
+func (w *Worker) skipPlanStep(ctx context.Context, m queue.Body, b *build.Build, ps job.PlanStep, idx int, resolved map[string]string) (bool, bool) {
+	if ps.When == nil {
+		return false, false
+	}
+	st, name := planStepName(ps, idx)
+
+	ok, err := evalWhen(ctx, m, b, ps.When, resolved, b.Status.String())
+	if err != nil {
+		err = fmt.Errorf("failed to evaluate the when of %s %q: %w", st, name, err)
+		b.Steps = append(b.Steps, build.Step{ID: uuid.New().String(), Type: st, Name: name, Logs: err.Error(), Status: build.Failed})
+		b.Status = build.Failed
+		w.failBuild(ctx, m, *b, err)
+		return true, true
+	}
+	if ok {
+		return false, false
+	}
+
+	b.Steps = append(b.Steps, build.Step{ID: uuid.New().String(), Type: st, Name: name, Status: build.Skipped})
+	w.updateBuild(ctx, m, *b)
+	return true, false
+}
+
+// evalWhen returns the result of the when condition wh, which can use the
+// BUILD_* metadata of the build, the fields of the versions fetched by the
+// get steps already run, the status and outputs of the steps and the
+// variables. The status is the one of the build for BUILD_STATUS.
+func evalWhen(ctx context.Context, m queue.Body, b *build.Build, wh *job.When, resolved map[string]string, status string) (bool, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(wh.Expr), "when", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return false, diags
+	}
+
+	vars := make(map[string]cty.Value)
+	for k, v := range buildMetadataParams(b, m) {
+		vars[k] = cty.StringVal(v)
+	}
+	vars["BUILD_STATUS"] = cty.StringVal(status)
+
+	roots := make(map[string]map[string]cty.Value)
+	for k, v := range wh.Vars {
+		root, name, _ := strings.Cut(k, ".")
+		if roots[root] == nil {
+			roots[root] = make(map[string]cty.Value)
+		}
+		switch tv := v.(type) {
+		case string:
+			for placeholder, val := range resolved {
+				tv = strings.ReplaceAll(tv, placeholder, val)
+			}
+			roots[root][name] = cty.StringVal(tv)
+		case float64:
+			roots[root][name] = cty.NumberFloatVal(tv)
+		case bool:
+			roots[root][name] = cty.BoolVal(tv)
+		default:
+			return false, fmt.Errorf("%s has an unsupported type", k)
+		}
+	}
+	for root, vs := range roots {
+		vars[root] = cty.ObjectVal(vs)
+	}
+
+	var (
+		version  = cty.EmptyObjectVal
+		versions = make(map[string]cty.Value)
+		steps    = make(map[string]cty.Value)
+	)
+	for _, s := range visibleSteps(ctx, b) {
+		if s.Name == "" {
+			continue
+		}
+		outputs := make(map[string]cty.Value, len(s.Outputs))
+		for k, v := range s.Outputs {
+			outputs[k] = cty.StringVal(v)
+		}
+		steps[s.Name] = cty.ObjectVal(map[string]cty.Value{
+			"status":  cty.StringVal(s.Status.String()),
+			"outputs": cty.ObjectVal(outputs),
+		})
+		if s.Type != "get" || s.Version == nil {
+			continue
+		}
+		fields := make(map[string]cty.Value)
+		for k, v := range s.Version {
+			fields[k] = cty.StringVal(v)
+		}
+		if len(versions) == 0 {
+			version = cty.ObjectVal(fields)
+		}
+		versions[s.Name] = cty.ObjectVal(fields)
+	}
+	vars["version"] = version
+	vars["versions"] = cty.ObjectVal(versions)
+	vars["steps"] = cty.ObjectVal(steps)
+
+	val, diags := expr.Value(&hcl.EvalContext{
+		Variables: vars,
+		Functions: pikoci.HCLFunctions(),
+	})
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if val.IsNull() || !val.IsKnown() || val.Type() != cty.Bool {
+		return false, fmt.Errorf("the result must be a bool, got %s", val.Type().FriendlyName())
+	}
+	return val.True(), nil
+}
+
+// visibleSteps returns the steps a step running with ctx can use on its
+// when and outputs, which are the ones of b preceded by the ones run
+// before the matrix cell or the in_parallel group the step runs on
+func visibleSteps(ctx context.Context, b *build.Build) []build.Step {
+	var steps []build.Step
+	if mc, ok := ctx.Value(matrixCellKey{}).(matrixCell); ok {
+		steps = append(steps, mc.matrix.base...)
+	}
+	if pl, ok := ctx.Value(parallelLaneKey{}).(parallelLane); ok {
+		steps = append(steps, pl.group.base...)
+	}
+	return append(steps, b.Steps...)
+}